@@ -40,6 +40,17 @@ type HTTPProxySpec struct {
 	// is given precedence over this field.
 	// +optional
 	IngressClassName string `json:"ingressClassName,omitempty"`
+	// DisableGlobalHeadersPolicy, if true, stops the request and response
+	// headers policies set in the Contour configuration from being applied
+	// to the routes of this HTTPProxy.
+	// +optional
+	DisableGlobalHeadersPolicy bool `json:"disableGlobalHeadersPolicy,omitempty"`
+	// DisableDefaultLocalRateLimitPolicy, if true, stops the local rate
+	// limit policy set in the Contour configuration from being applied
+	// to the virtual host of this HTTPProxy. Ignored if this HTTPProxy
+	// is not a root.
+	// +optional
+	DisableDefaultLocalRateLimitPolicy bool `json:"disableDefaultLocalRateLimitPolicy,omitempty"`
 }
 
 // Include describes a set of policies that can be applied to an HTTPProxy in a namespace.
@@ -185,7 +196,10 @@ type AuthorizationPolicy struct {
 	// authentication server in the check request. If a context
 	// is provided at an enclosing scope, the entries are merged
 	// such that the inner scope overrides matching keys from the
-	// outer scope.
+	// outer scope. An inner scope entry with an empty value removes
+	// the matching key. Values may reference %CONTOUR_VHOST%,
+	// %CONTOUR_PATH% and %CONTOUR_METHOD%, which are expanded from
+	// the virtual host name and the route's match conditions.
 	//
 	// +optional
 	Context map[string]string `json:"context,omitempty"`
@@ -200,10 +214,19 @@ type VirtualHost struct {
 	// +kubebuilder:validation:Pattern="^(\\*\\.)?[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
 	Fqdn string `json:"fqdn"`
 
+	// Aliases are additional fully qualified domain names served by
+	// this virtual host, sharing its routes and TLS configuration.
+	// Aliases cannot be wildcards, and cannot be set when the fqdn
+	// is a wildcard. Each alias must be unique across all HTTPProxies.
+	//
+	// +optional
+	// +kubebuilder:validation:items:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Aliases []string `json:"aliases,omitempty"`
+
 	// If present the fields describes TLS properties of the virtual
-	// host. The SNI names that will be matched on are described in fqdn,
-	// the tls.secretName secret must contain a certificate that itself
-	// contains a name that matches the FQDN.
+	// host. The SNI names that will be matched on are described in fqdn
+	// and aliases, the tls.secretName secret must contain a certificate
+	// that itself contains names that match the FQDN and aliases.
 	//
 	// +optional
 	TLS *TLS `json:"tls,omitempty"`
@@ -223,6 +246,80 @@ type VirtualHost struct {
 	// The policy for rate limiting on the virtual host.
 	// +optional
 	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// The policy for timeouts of downstream connections to the
+	// virtual host, overriding the timeouts in the Contour
+	// configuration. Connection timeouts can only be configured
+	// on virtual hosts that terminate TLS.
+	// +optional
+	ConnectionTimeoutPolicy *ConnectionTimeoutPolicy `json:"connectionTimeoutPolicy,omitempty"`
+	// DebugHeaders, if true, adds response headers identifying the
+	// HTTPProxy, route and upstream Service that served each request,
+	// and the address of the upstream host. These headers expose
+	// details of the cluster to clients, so should only be enabled
+	// while debugging.
+	// +optional
+	DebugHeaders bool `json:"debugHeaders,omitempty"`
+	// HSTSPolicy adds a Strict-Transport-Security header to responses
+	// served over HTTPS. HSTS can only be configured on virtual hosts
+	// that terminate TLS.
+	// +optional
+	HSTSPolicy *HSTSPolicy `json:"hstsPolicy,omitempty"`
+	// The policy for the limits of the headers of requests to the
+	// virtual host, overriding the limits in the Contour
+	// configuration. Header limits can only be configured on
+	// virtual hosts that terminate TLS.
+	// +optional
+	HeaderLimitsPolicy *HeaderLimitsPolicy `json:"headerLimitsPolicy,omitempty"`
+	// RoutePrecedence selects how the routes of the virtual host,
+	// including those of included HTTPProxies, are ordered when
+	// more than one of them matches a request. `Specificity`, the
+	// default, orders them from the most to the least specific
+	// conditions. `Priority` orders them by their `priority` first,
+	// highest first, and routes of equal priority by specificity.
+	// +optional
+	// +kubebuilder:validation:Enum=Specificity;Priority
+	RoutePrecedence RoutePrecedence `json:"routePrecedence,omitempty"`
+}
+
+// RoutePrecedence is how the routes of a virtual host are ordered.
+type RoutePrecedence string
+
+const (
+	// RoutePrecedenceSpecificity orders routes from the most
+	// to the least specific conditions.
+	RoutePrecedenceSpecificity RoutePrecedence = "Specificity"
+
+	// RoutePrecedencePriority orders routes by their priority,
+	// then from the most to the least specific conditions.
+	RoutePrecedencePriority RoutePrecedence = "Priority"
+)
+
+// ConnectionTimeoutPolicy defines the timeouts of downstream
+// connections to a virtual host.
+type ConnectionTimeoutPolicy struct {
+	// MaxConnectionDuration is the maximum period of time after an
+	// HTTP connection has been established from the client to the
+	// proxy before it is closed by the proxy, regardless of whether
+	// there has been activity or not.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	MaxConnectionDuration string `json:"maxConnectionDuration,omitempty"`
+
+	// ConnectionShutdownGracePeriod is how long the proxy will wait
+	// between sending an initial GOAWAY frame and a second, final
+	// GOAWAY frame when terminating an HTTP/2 connection, e.g. when
+	// the maximum connection duration is reached.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	ConnectionShutdownGracePeriod string `json:"connectionShutdownGracePeriod,omitempty"`
+
+	// DelayedCloseTimeout is how long the proxy will wait, once
+	// connection close processing has been initiated, for the
+	// downstream peer to close the connection before it is
+	// closed by the proxy.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	DelayedCloseTimeout string `json:"delayedCloseTimeout,omitempty"`
 }
 
 // TLS describes tls properties. The SNI names that will be matched on
@@ -263,6 +360,53 @@ type TLS struct {
 	// EnableFallbackCertificate defines if the vhost should allow a default certificate to
 	// be applied which handles all requests which don't match the SNI defined in this vhost.
 	EnableFallbackCertificate bool `json:"enableFallbackCertificate,omitempty"`
+
+	// PermitInsecurePrefixes is a list of path prefixes of this
+	// virtual host that are served over HTTP, rather than redirected
+	// to HTTPS, in addition to the prefixes permitted by the Contour
+	// configuration. Ignored if the Contour configuration disables
+	// permitInsecure. Each prefix must begin with `/`.
+	// +optional
+	PermitInsecurePrefixes []string `json:"permitInsecurePrefixes,omitempty"`
+}
+
+// HSTSPolicy defines the HTTP Strict Transport Security (HSTS) policy
+// of a virtual host, which tells clients to only access it over HTTPS.
+type HSTSPolicy struct {
+	// MaxAge is how long clients should remember to only access the
+	// virtual host over HTTPS, e.g. "8760h". It is rounded down to
+	// whole seconds.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s))+)$`
+	MaxAge string `json:"maxAge"`
+
+	// IncludeSubDomains applies the policy to all subdomains
+	// of the virtual host too.
+	// +optional
+	IncludeSubDomains bool `json:"includeSubDomains,omitempty"`
+
+	// Preload consents to the virtual host being included in
+	// browsers' HSTS preload lists. Preloading requires a max
+	// age of at least a year and including subdomains.
+	// +optional
+	Preload bool `json:"preload,omitempty"`
+}
+
+// HeaderLimitsPolicy defines the limits of the headers of requests
+// to a virtual host.
+type HeaderLimitsPolicy struct {
+	// MaxRequestHeadersKB is the maximum size, in KiB, of the
+	// headers of a request.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8192
+	// +optional
+	MaxRequestHeadersKB uint32 `json:"maxRequestHeadersKB,omitempty"`
+
+	// MaxRequestHeadersCount is the maximum number of headers
+	// of a request.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxRequestHeadersCount uint32 `json:"maxRequestHeadersCount,omitempty"`
 }
 
 // CORSHeaderValue specifies the value of the string headers returned by a cross-domain request.
@@ -316,6 +460,11 @@ type Route struct {
 	// not permitted when a `virtualhost.tls` block is present.
 	// +optional
 	PermitInsecure bool `json:"permitInsecure,omitempty"`
+	// The policy for redirecting insecure requests over HTTP to HTTPS
+	// when a `virtualhost.tls` block is present. Ignored if the route
+	// permits insecure requests.
+	// +optional
+	HTTPSRedirectPolicy *HTTPSRedirectPolicy `json:"httpsRedirectPolicy,omitempty"`
 	// AuthPolicy updates the authorization policy that was set
 	// on the root HTTPProxy object for client requests that
 	// match this route.
@@ -327,12 +476,33 @@ type Route struct {
 	// The retry policy for this route.
 	// +optional
 	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// The hedge policy for this route.
+	// +optional
+	HedgePolicy *HedgePolicy `json:"hedgePolicy,omitempty"`
+	// The gRPC policy for this route. When set, the deadline gRPC
+	// clients set with the grpc-timeout request header is used in
+	// place of the route's response timeout, and retries default to
+	// gRPC conditions. The route's services must use the h2 or h2c
+	// protocol.
+	// +optional
+	GRPCPolicy *GRPCPolicy `json:"grpcPolicy,omitempty"`
+	// The stats policy for this route.
+	// +optional
+	StatsPolicy *StatsPolicy `json:"statsPolicy,omitempty"`
 	// The health check policy for this route.
 	// +optional
 	HealthCheckPolicy *HTTPHealthCheckPolicy `json:"healthCheckPolicy,omitempty"`
 	// The load balancing policy for this route.
 	// +optional
 	LoadBalancerPolicy *LoadBalancerPolicy `json:"loadBalancerPolicy,omitempty"`
+	// The policy for pinning clients to one of the route's weighted
+	// services, so that clients sent to a canary stay on it.
+	// +optional
+	TrafficSplitPolicy *TrafficSplitPolicy `json:"trafficSplitPolicy,omitempty"`
+	// The policy for gradually shifting requests from one of the
+	// route's services to another over a schedule.
+	// +optional
+	TrafficShiftPolicy *TrafficShiftPolicy `json:"trafficShiftPolicy,omitempty"`
 	// The policy for rewriting the path of the request URL
 	// after the request has been routed to a Service.
 	//
@@ -353,8 +523,41 @@ type Route struct {
 	// The policy for rate limiting on the route.
 	// +optional
 	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// The HTTP filters that are disabled for requests that match
+	// the route, overriding the virtual host's policies for them.
+	// +optional
+	DisabledFilters []HTTPFilterName `json:"disabledFilters,omitempty"`
+	// Priority orders the route before the routes of the virtual
+	// host with a lower priority, regardless of their conditions.
+	// It only applies if the virtual host of the root HTTPProxy
+	// sets routePrecedence to Priority.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000
+	Priority int32 `json:"priority,omitempty"`
 }
 
+// HTTPFilterName is the name of an HTTP filter that can be
+// disabled on a route.
+// +kubebuilder:validation:Enum=cors;ext_authz;local_ratelimit;ratelimit
+type HTTPFilterName string
+
+const (
+	// HTTPFilterCORS is the CORS filter, which applies the
+	// virtual host's CORS policy.
+	HTTPFilterCORS HTTPFilterName = "cors"
+
+	// HTTPFilterExtAuthz is the external authorization filter,
+	// which applies the virtual host's authorization policy.
+	HTTPFilterExtAuthz HTTPFilterName = "ext_authz"
+
+	// HTTPFilterLocalRateLimit is the local rate limit filter.
+	HTTPFilterLocalRateLimit HTTPFilterName = "local_ratelimit"
+
+	// HTTPFilterRateLimit is the global rate limit filter.
+	HTTPFilterRateLimit HTTPFilterName = "ratelimit"
+)
+
 type CookieRewritePolicy struct {
 	// Name is the name of the cookie for which attributes will be rewritten.
 	// +kubebuilder:validation:MinLength=1
@@ -404,6 +607,78 @@ type CookieDomainRewrite struct {
 	Value string `json:"value"`
 }
 
+// HTTPSRedirectPolicy defines how insecure requests are redirected to HTTPS.
+type HTTPSRedirectPolicy struct {
+	// StatusCode is the HTTP status code of the redirect response.
+	// Valid values are 301 (Moved Permanently) and 308 (Permanent
+	// Redirect). If not specified, 301 is used.
+	// +optional
+	// +kubebuilder:validation:Enum=301;308
+	StatusCode int `json:"statusCode,omitempty"`
+
+	// Port is the port to redirect to. If not specified,
+	// the default HTTPS port is used.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+}
+
+// TrafficSplitPolicy defines how requests are pinned to one of a
+// route's weighted services, bypassing the service weights.
+// At least one of HeaderName or CookieName must be specified.
+type TrafficSplitPolicy struct {
+	// HeaderName is the name of a request header. Requests whose
+	// header value is the name of one of the route's services are
+	// sent to that service.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	HeaderName string `json:"headerName,omitempty"`
+
+	// CookieName is the name of a cookie recording the service a
+	// client was sent to. Responses from weighted services set the
+	// cookie, and requests carrying it are sent to the same service.
+	// Requests that also carry the HeaderName header are routed by
+	// the header.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=4096
+	// +kubebuilder:validation:Pattern=`^[^()<>@,;:\\"\/[\]?={} \t\x7f\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f]+$`
+	CookieName string `json:"cookieName,omitempty"`
+}
+
+// TrafficShiftPolicy defines how requests are gradually shifted
+// from one of a route's services to another. The weights of the
+// two services are set by Contour, stepping through Steps with
+// Interval between each step. Setting the annotation
+// projectcontour.io/traffic-shift-halt to "true" on the HTTPProxy
+// halts the shift at its current step.
+type TrafficShiftPolicy struct {
+	// From is the name of the route's service that requests
+	// are shifted away from.
+	// +kubebuilder:validation:MinLength=1
+	From string `json:"from"`
+
+	// To is the name of the route's service that requests
+	// are shifted to.
+	// +kubebuilder:validation:MinLength=1
+	To string `json:"to"`
+
+	// Steps are the percentages of requests sent to the To
+	// service at each step of the shift. The remaining requests
+	// are sent to the From service. Changing the steps restarts
+	// the shift from its first step.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Minimum=0
+	// +kubebuilder:validation:items:Maximum=100
+	Steps []uint32 `json:"steps"`
+
+	// Interval is how long each step lasts before the
+	// shift moves on to the next step.
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	Interval string `json:"interval"`
+}
+
 // RateLimitPolicy defines rate limiting parameters.
 type RateLimitPolicy struct {
 	// Local defines local rate limiting parameters, i.e. parameters
@@ -459,9 +734,25 @@ type GlobalRateLimitPolicy struct {
 	// Descriptors defines the list of descriptors that will
 	// be generated and sent to the rate limit service. Each
 	// descriptor contains 1+ key-value pair entries.
-	// +required
+	// Descriptors are required unless the policy is disabled
+	// or inherits the virtual host's descriptors.
+	// +optional
 	// +kubebuilder:validation:MinItems=1
 	Descriptors []RateLimitDescriptor `json:"descriptors,omitempty"`
+
+	// InheritVirtualHostPolicy, if true, adds the descriptors of the
+	// virtual host's global rate limit policy ahead of this policy's
+	// descriptors. By default, a route's descriptors replace the
+	// virtual host's descriptors only in the stages the route uses,
+	// so that limits in other stages still apply. Only valid on routes.
+	// +optional
+	InheritVirtualHostPolicy bool `json:"inheritVirtualHostPolicy,omitempty"`
+
+	// Disabled, if true, turns off global rate limiting for the
+	// route, including any policy defined on the virtual host.
+	// Only valid on routes.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // RateLimitDescriptor defines a list of key-value pair generators.
@@ -470,6 +761,39 @@ type RateLimitDescriptor struct {
 	// +required
 	// +kubebuilder:validation:MinItems=1
 	Entries []RateLimitDescriptorEntry `json:"entries,omitempty"`
+
+	// Stage is the rate limit filter stage the descriptor applies to.
+	// A descriptor is only sent to the rate limit service by a rate
+	// limit filter with the same stage. Contour configures a rate
+	// limit filter for each stage listed in the rate limit service
+	// configuration, which defaults to stage 0 only.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	Stage uint32 `json:"stage,omitempty"`
+
+	// Limit is the rate limit the rate limit service applies to
+	// requests with this descriptor. It is only used when Contour
+	// generates the configuration of the rate limit service, and is
+	// otherwise ignored.
+	// +optional
+	Limit *RateLimitDescriptorLimit `json:"limit,omitempty"`
+}
+
+// RateLimitDescriptorLimit defines the rate limit applied by the rate
+// limit service to requests with a descriptor.
+type RateLimitDescriptorLimit struct {
+	// Requests defines how many requests per unit of time should
+	// be allowed before rate limiting occurs.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	Requests uint32 `json:"requests"`
+
+	// Unit defines the period of time within which requests
+	// over the limit will be rate limited.
+	// +required
+	// +kubebuilder:validation:Enum=second;minute;hour;day
+	Unit string `json:"unit"`
 }
 
 // RateLimitDescriptorEntry is a key-value pair generator. Exactly
@@ -495,6 +819,12 @@ type RateLimitDescriptorEntry struct {
 	// and a value equal to the client's IP address (from x-forwarded-for).
 	// +optional
 	RemoteAddress *RemoteAddressDescriptor `json:"remoteAddress,omitempty"`
+
+	// Metadata defines a descriptor entry that's populated from the
+	// dynamic metadata of the request, such as the metadata returned
+	// by the external authorization server.
+	// +optional
+	Metadata *MetadataDescriptor `json:"metadata,omitempty"`
 }
 
 // GenericKeyDescriptor defines a descriptor entry with a static key and
@@ -553,6 +883,35 @@ type RequestHeaderValueMatchDescriptor struct {
 // (from x-forwarded-for).
 type RemoteAddressDescriptor struct{}
 
+// MetadataDescriptor defines a descriptor entry whose value is read
+// from the dynamic metadata of the request. If the metadata is not
+// present and no default value is set, the descriptor is not sent
+// to the rate limit service.
+type MetadataDescriptor struct {
+	// DescriptorKey defines the key to use on the descriptor entry.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	DescriptorKey string `json:"descriptorKey"`
+
+	// Namespace is the namespace of the dynamic metadata, which is
+	// the name of the Envoy filter that sets it, for example
+	// "envoy.filters.http.ext_authz".
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Path is the list of keys that leads to the value within
+	// the namespace. The value must be a string.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Path []string `json:"path"`
+
+	// DefaultValue is the value of the descriptor entry if the
+	// metadata is not present.
+	// +optional
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
 // TCPProxy contains the set of services to proxy TCP connections.
 type TCPProxy struct {
 	// The load balancing policy for the backend services. Note that the
@@ -613,6 +972,14 @@ type Service struct {
 	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
 	// If Mirror is true the Service will receive a read only mirror of the traffic for this route.
 	Mirror bool `json:"mirror,omitempty"`
+	// If Backup is true the Service only receives the traffic for this route
+	// when none of the endpoints of the route's other services are healthy.
+	// When a route has several backup services, they are tried in the order
+	// they are listed. A backup service is reached with the protocol and
+	// policies of the service it backs up, and must be an ExternalName
+	// service if, and only if, the route's other services are.
+	// +optional
+	Backup bool `json:"backup,omitempty"`
 	// The policy for managing request headers during proxying.
 	// Rewriting the 'Host' header is not supported.
 	// +optional
@@ -624,6 +991,52 @@ type Service struct {
 	// The policies for rewriting Set-Cookie header attributes.
 	// +optional
 	CookieRewritePolicies []CookieRewritePolicy `json:"cookieRewritePolicies,omitempty"`
+	// DNSPolicy defines how the DNS name of an ExternalName Service is resolved.
+	// If omitted, it falls back on Service annotations.
+	// +optional
+	DNSPolicy *DNSPolicy `json:"dnsPolicy,omitempty"`
+	// Endpoints are the static addresses of the Service, for backends
+	// that are not run by Kubernetes. If set, Name identifies the Service
+	// rather than referencing a Kubernetes Service, and traffic is sent
+	// to these endpoints directly. Static endpoints must be enabled with
+	// the enableStaticEndpoints config file setting.
+	// +optional
+	Endpoints []StaticEndpoint `json:"endpoints,omitempty"`
+}
+
+// StaticEndpoint is an address of a Service that is not
+// discovered from Kubernetes.
+type StaticEndpoint struct {
+	// Address is the IP address of the endpoint.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+	// Port is the port of the endpoint. Defaults to the port of the Service.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+}
+
+// DNSPolicy defines how the DNS name of an ExternalName Service is resolved.
+type DNSPolicy struct {
+	// DiscoveryType is how the DNS name is resolved. Strict resolves the
+	// name continuously and load balances across all of the returned
+	// addresses. Logical connects to the first returned address only,
+	// which suits large DNS-based services. Defaults to Strict.
+	// +kubebuilder:validation:Enum=Strict;Logical
+	// +optional
+	DiscoveryType string `json:"discoveryType,omitempty"`
+	// Resolvers are the addresses of the DNS resolvers to use instead of the
+	// resolvers configured on the Envoy host, as "ip" or "ip:port".
+	// The port defaults to 53.
+	// +optional
+	Resolvers []string `json:"resolvers,omitempty"`
+	// LookupFamily is the IP address family to resolve the DNS name to,
+	// overriding the dns-lookup-family configured for Contour. Auto
+	// prefers IPv6 addresses and falls back to IPv4 addresses.
+	// +kubebuilder:validation:Enum=auto;v4;v6
+	// +optional
+	LookupFamily string `json:"lookupFamily,omitempty"`
 }
 
 // HTTPHealthCheckPolicy defines health checks on the upstream service.
@@ -634,6 +1047,13 @@ type HTTPHealthCheckPolicy struct {
 	// If left empty (default value), the name "contour-envoy-healthcheck"
 	// will be used.
 	Host string `json:"host,omitempty"`
+	// The port of the upstream endpoints that health checks are sent
+	// to, if it is not the port that traffic is sent to. This is a
+	// port of the Pod's containers, rather than a port of the Service.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
 	// The interval (seconds) between health checks
 	// +optional
 	IntervalSeconds int64 `json:"intervalSeconds"`
@@ -648,10 +1068,47 @@ type HTTPHealthCheckPolicy struct {
 	// +optional
 	// +kubebuilder:validation:Minimum=0
 	HealthyThresholdCount int64 `json:"healthyThresholdCount"`
+	// The ranges of HTTP status codes of responses that mark a
+	// host healthy. If left empty, only a 200 response does.
+	// +optional
+	ExpectedStatuses []HTTPStatusRange `json:"expectedStatuses,omitempty"`
+	// Headers that are added to health check requests.
+	// +optional
+	RequestHeaders []HeaderValue `json:"requestHeaders,omitempty"`
+	// The maximum jitter (seconds) added to the first health check
+	// of a host, to spread out health checks after a restart
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	InitialJitterSeconds int64 `json:"initialJitterSeconds,omitempty"`
+	// The maximum jitter (seconds) added to the interval between
+	// health checks
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	IntervalJitterSeconds int64 `json:"intervalJitterSeconds,omitempty"`
+}
+
+// HTTPStatusRange is a range of HTTP status codes,
+// from Start inclusive to End exclusive.
+type HTTPStatusRange struct {
+	// The first HTTP status code of the range.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	Start int64 `json:"start"`
+	// The HTTP status code after the last one of the range.
+	// +kubebuilder:validation:Minimum=101
+	// +kubebuilder:validation:Maximum=600
+	End int64 `json:"end"`
 }
 
 // TCPHealthCheckPolicy defines health checks on the upstream service.
 type TCPHealthCheckPolicy struct {
+	// The port of the upstream endpoints that health checks are sent
+	// to, if it is not the port that traffic is sent to. This is a
+	// port of the Pod's containers, rather than a port of the Service.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
 	// The interval (seconds) between health checks
 	// +optional
 	IntervalSeconds int64 `json:"intervalSeconds"`
@@ -736,6 +1193,87 @@ type RetryPolicy struct {
 	// This field is only respected when you include `retriable-status-codes` in the `RetryOn` field.
 	// +optional
 	RetriableStatusCodes []uint32 `json:"retriableStatusCodes,omitempty"`
+	// RetryHostPredicates specifies the conditions under which the host
+	// chosen for a retry is rejected, and another host is chosen.
+	//
+	// Supported predicates:
+	//
+	// - `previous-hosts`: hosts that previous attempts of the request were sent to.
+	// - `canary-hosts`: hosts marked as canaries in their endpoint metadata.
+	// +optional
+	RetryHostPredicates []RetryHostPredicate `json:"retryHostPredicates,omitempty"`
+	// HostSelectionMaxAttempts is the maximum number of times a host is
+	// chosen for a retry while the retry host predicates reject them,
+	// after which the last host chosen is used. If not supplied, host
+	// selection is reattempted once.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HostSelectionMaxAttempts int64 `json:"hostSelectionMaxAttempts,omitempty"`
+	// RetryPriority specifies how the priority level of the hosts
+	// that retries are sent to is chosen.
+	// +optional
+	RetryPriority *RetryPriority `json:"retryPriority,omitempty"`
+}
+
+// RetryHostPredicate is a condition under which a host is rejected for a retry.
+// +kubebuilder:validation:Enum=previous-hosts;canary-hosts
+type RetryHostPredicate string
+
+// RetryPriority defines how the priority level of the hosts that
+// retries are sent to is chosen.
+type RetryPriority struct {
+	// PreviousPrioritiesUpdateFrequency sends retries to priority
+	// levels other than those previous attempts of the request were
+	// sent to. The levels that are excluded are updated after this
+	// many attempts.
+	// +kubebuilder:validation:Minimum=1
+	PreviousPrioritiesUpdateFrequency int32 `json:"previousPrioritiesUpdateFrequency"`
+}
+
+// HedgePolicy defines the attributes associated with hedging requests,
+// that is, racing additional requests to the upstream Service against
+// a slow request and using the first response.
+type HedgePolicy struct {
+	// HedgeOnPerTryTimeout sends an additional request when the per-try
+	// timeout of the route's retry policy elapses, rather than canceling
+	// the outstanding request. The first response from any of the
+	// outstanding requests is used. Requires a retry policy with a
+	// per-try timeout.
+	// +optional
+	HedgeOnPerTryTimeout bool `json:"hedgeOnPerTryTimeout,omitempty"`
+}
+
+// GRPCPolicy defines how the requests of gRPC clients are handled.
+type GRPCPolicy struct {
+	// MaxTimeout limits the deadline that clients may set with the
+	// grpc-timeout request header. If not supplied, or set to
+	// "infinity", the deadline is not limited.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	MaxTimeout string `json:"maxTimeout,omitempty"`
+
+	// TimeoutOffset is subtracted from the deadline set with the
+	// grpc-timeout request header, so that Envoy times out requests
+	// before their clients do, allowing for network latency.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	TimeoutOffset string `json:"timeoutOffset,omitempty"`
+}
+
+// StatsPolicy defines the statistics Envoy emits for the requests
+// of a route, in addition to the statistics of its upstream clusters.
+type StatsPolicy struct {
+	// VirtualClusterName is the name of the Envoy virtual cluster
+	// matching the requests of the route. Envoy emits request
+	// statistics for each virtual cluster, prefixed with
+	// "vhost.<virtual host>.vcluster.<name>.". Routes of the same
+	// virtual host with the same name share their statistics, so
+	// that statistics can be split by logical API rather than
+	// by upstream cluster.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_-]+$`
+	VirtualClusterName string `json:"virtualClusterName"`
 }
 
 // ReplacePrefix describes a path prefix replacement.
@@ -855,10 +1393,26 @@ type HeaderValue struct {
 
 // UpstreamValidation defines how to verify the backend service's certificate
 type UpstreamValidation struct {
-	// Name or namespaced name of the Kubernetes secret used to validate the certificate presented by the backend
-	CACertificate string `json:"caSecret"`
-	// Key which is expected to be present in the 'subjectAltName' of the presented certificate
-	SubjectName string `json:"subjectName"`
+	// Name or namespaced name of the Kubernetes secret used to validate the certificate presented by the backend.
+	// Required unless SPKIHashes or InsecureSkipVerify is set.
+	// +optional
+	CACertificate string `json:"caSecret,omitempty"`
+	// Key which is expected to be present in the 'subjectAltName' of the presented certificate.
+	// Required if CACertificate is set.
+	// +optional
+	SubjectName string `json:"subjectName,omitempty"`
+	// SPKIHashes pins the certificate presented by the backend to one of
+	// these base64-encoded SHA-256 hashes of its Subject Public Key
+	// Information. This can be used instead of, or as well as, validating
+	// the certificate against CACertificate.
+	// +optional
+	SPKIHashes []string `json:"spkiHashes,omitempty"`
+	// InsecureSkipVerify disables validation of the certificate presented
+	// by the backend, and cannot be combined with the other fields. It is
+	// only permitted in the namespaces that the Contour configuration
+	// allows it in.
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 }
 
 // DownstreamValidation defines how to verify the client certificate.
@@ -881,8 +1435,46 @@ type DownstreamValidation struct {
 	// presented to the external authorization server.
 	// +optional
 	SkipClientCertValidation bool `json:"skipClientCertValidation"`
+
+	// Mode sets whether clients must present a certificate.
+	// Required, the default, rejects connections without a valid
+	// client certificate. Optional requests a client certificate
+	// and, if one is presented, verifies it against the CA
+	// certificate bundle. OptionalNoCA requests a client
+	// certificate but does not require it to be verified, in which
+	// case the CA certificate bundle is optional.
+	// Mode cannot be combined with SkipClientCertValidation.
+	// +optional
+	// +kubebuilder:validation:Enum=Required;Optional;OptionalNoCA
+	Mode ClientCertificateMode `json:"mode,omitempty"`
+
+	// ForwardClientCertificateStatus, if true, sets the
+	// X-Client-Cert-Present and X-Client-Cert-Verified request
+	// headers to "true" or "false", for whether the client presented
+	// a certificate and whether Envoy verified it against the CA
+	// certificate bundle. Values of these headers sent by the client
+	// are replaced.
+	// +optional
+	ForwardClientCertificateStatus bool `json:"forwardClientCertificateStatus,omitempty"`
 }
 
+// ClientCertificateMode sets whether clients must present a certificate.
+type ClientCertificateMode string
+
+const (
+	// ClientCertificateModeRequired rejects connections
+	// without a valid client certificate.
+	ClientCertificateModeRequired ClientCertificateMode = "Required"
+
+	// ClientCertificateModeOptional verifies the client
+	// certificate, if one is presented.
+	ClientCertificateModeOptional ClientCertificateMode = "Optional"
+
+	// ClientCertificateModeOptionalNoCA requests a client
+	// certificate but does not require it to be verified.
+	ClientCertificateModeOptionalNoCA ClientCertificateMode = "OptionalNoCA"
+)
+
 // HTTPProxyStatus reports the current state of the HTTPProxy.
 type HTTPProxyStatus struct {
 	// +optional
@@ -910,6 +1502,35 @@ type HTTPProxyStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []DetailedCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// +optional
+	// TrafficShifts reports the progress of the traffic
+	// shifts of the routes of the HTTPProxy.
+	TrafficShifts []TrafficShiftStatus `json:"trafficShifts,omitempty"`
+}
+
+// TrafficShiftStatus reports the progress of the
+// traffic shift of a route.
+type TrafficShiftStatus struct {
+	// Route identifies the route by its match conditions.
+	Route string `json:"route"`
+	// From is the service requests are shifted away from.
+	From string `json:"from"`
+	// To is the service requests are shifted to.
+	To string `json:"to"`
+	// Step is the index of the current step of the shift.
+	Step int `json:"step"`
+	// Weight is the percentage of requests currently
+	// sent to the To service.
+	Weight uint32 `json:"weight"`
+	// Halted is true while the shift is halted by the
+	// projectcontour.io/traffic-shift-halt annotation.
+	// +optional
+	Halted bool `json:"halted,omitempty"`
+	// Complete is true once the shift has reached its last step.
+	// +optional
+	Complete bool `json:"complete,omitempty"`
+	// LastTransitionTime is when the current step started.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
 }
 
 // +genclient
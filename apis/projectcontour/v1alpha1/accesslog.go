@@ -140,19 +140,35 @@ const JSONAccessLog AccessLogType = "json"
 type AccessLogFields []string
 
 func (a AccessLogFields) Validate() error {
+	fields := a.AsFieldMap()
 
-	for key, val := range a.AsFieldMap() {
+	for key, val := range fields {
 		if val == "" {
 			return fmt.Errorf("invalid JSON log field name %s", key)
 		}
 
+		for _, name := range strings.Split(key, ".") {
+			if name == "" {
+				return fmt.Errorf("invalid JSON log field name %q: nested field names must not be empty", key)
+			}
+		}
+
 		if jsonFields[key] == val {
 			continue
 		}
 
 		err := parseAccessLogFormat(val)
 		if err != nil {
-			return fmt.Errorf("invalid JSON field: %s", err)
+			return fmt.Errorf("invalid JSON log field %q: %s", key, err)
+		}
+	}
+
+	// A field can't both have a value and contain nested fields.
+	for key := range fields {
+		for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+			if _, ok := fields[key[:i]]; ok {
+				return fmt.Errorf("invalid JSON log field %q: conflicts with nested field %q", key[:i], key)
+			}
 		}
 	}
 
@@ -189,21 +205,20 @@ func (a AccessLogFields) AsFieldMap() map[string]string {
 	return fieldMap
 }
 
-// TODO: Commented code since it's not yet in use, but makes the linter unhappy.
-// func validateAccessLogFormatString(format string) error {
-//	// Empty format means use default format, defined by Envoy.
-//	if format == "" {
-//		return nil
-//	}
-//	err := parseAccessLogFormat(format)
-//	if err != nil {
-//		return fmt.Errorf("invalid access log format: %s", err)
-//	}
-//	if !strings.HasSuffix(format, "\n") {
-//		return fmt.Errorf("invalid access log format: must end in newline")
-//	}
-//	return nil
-//}
+func validateAccessLogFormatString(format string) error {
+	// Empty format means use default format, defined by Envoy.
+	if format == "" {
+		return nil
+	}
+	err := parseAccessLogFormat(format)
+	if err != nil {
+		return fmt.Errorf("invalid access log format: %s", err)
+	}
+	if !strings.HasSuffix(format, "\n") {
+		return fmt.Errorf("invalid access log format: must end in newline")
+	}
+	return nil
+}
 
 // commandOperatorRegexp parses the command operators used in Envoy access log config
 //
@@ -229,22 +244,22 @@ func parseAccessLogFormat(format string) error {
 	for _, f := range tokens {
 		op := f[2]
 		if op == "" {
-			return fmt.Errorf("invalid Envoy format: %s", f)
+			return fmt.Errorf("invalid Envoy format: %s", f[0])
 		}
 
 		_, okSimple := envoySimpleOperators[op]
 		_, okComplex := envoyComplexOperators[op]
 		if !okSimple && !okComplex {
-			return fmt.Errorf("invalid Envoy format: %s, invalid Envoy operator: %s", f, op)
+			return fmt.Errorf("invalid Envoy format: %s, invalid Envoy operator: %s", f[0], op)
 		}
 
 		if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "REQ_WITHOUT_QUERY") && f[3] == "" {
-			return fmt.Errorf("invalid Envoy format: %s, arguments required for operator: %s", f, op)
+			return fmt.Errorf("invalid Envoy format: %s, arguments required for operator: %s", f[0], op)
 		}
 
 		// START_TIME cannot not have truncation length.
 		if op == "START_TIME" && f[4] != "" {
-			return fmt.Errorf("invalid Envoy format: %s, operator %s cannot have truncation length", f, op)
+			return fmt.Errorf("invalid Envoy format: %s, operator %s cannot have truncation length", f[0], op)
 		}
 	}
 
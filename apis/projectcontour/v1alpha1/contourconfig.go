@@ -64,6 +64,13 @@ type ContourConfigurationSpec struct {
 	// +kubebuilder:default=false
 	EnableExternalNameService bool `json:"enableExternalNameService"`
 
+	// EnableStaticEndpoints allows HTTPProxy services to route to
+	// static endpoints rather than to Kubernetes Services.
+	// Defaults to disabled for security reasons.
+	// +optional
+	// +kubebuilder:default=false
+	EnableStaticEndpoints bool `json:"enableStaticEndpoints"`
+
 	// RateLimitService optionally holds properties of the Rate Limit Service
 	// to be used for global rate limiting.
 	// +optional
@@ -73,10 +80,79 @@ type ContourConfigurationSpec struct {
 	// +optional
 	Policy *PolicyConfig `json:"policy,omitempty"`
 
+	// TimeoutPolicy defines the default upstream connect timeout
+	// and the largest route timeouts that users may set.
+	// +optional
+	TimeoutPolicy *TimeoutPolicyConfig `json:"timeoutPolicy,omitempty"`
+
 	// Metrics defines the endpoint Contour uses to serve metrics.
 	// +optional
 	// +kubebuilder:default={address: "0.0.0.0", port: 8000}
 	Metrics MetricsConfig `json:"metrics"`
+
+	// Tracing optionally defines how Contour exports OpenTelemetry
+	// traces of its event handling, DAG builds and xDS pushes.
+	// +optional
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+
+	// ACMEChallengeSolver optionally routes ACME HTTP-01 challenge
+	// requests on every insecure virtual host to a solver Service.
+	// +optional
+	ACMEChallengeSolver *ACMEChallengeSolverConfig `json:"acmeChallengeSolver,omitempty"`
+
+	// RemoteClusters are other Kubernetes clusters whose endpoints
+	// are added to those of the Services with the same namespace
+	// and name in this cluster.
+	// +optional
+	RemoteClusters []RemoteClusterConfig `json:"remoteClusters,omitempty"`
+}
+
+// RemoteClusterConfig defines another Kubernetes cluster whose
+// endpoints are aggregated with those of this cluster.
+type RemoteClusterConfig struct {
+	// Name is the unique name of the remote cluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Kubeconfig is the path to the kubeconfig file used to
+	// connect to the remote cluster.
+	// +kubebuilder:validation:MinLength=1
+	Kubeconfig string `json:"kubeconfig"`
+}
+
+// ACMEChallengeSolverConfig defines the Service that ACME HTTP-01
+// challenge requests are routed to.
+type ACMEChallengeSolverConfig struct {
+	// Service is the namespace and name of the solver Service.
+	Service NamespacedName `json:"service"`
+
+	// Port is the port of the solver Service.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port"`
+}
+
+// TracingConfig defines how Contour exports control plane traces.
+type TracingConfig struct {
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	// +kubebuilder:validation:MinLength=1
+	Endpoint string `json:"endpoint"`
+
+	// Insecure disables TLS when connecting to the collector.
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// SamplingPercent is the percentage of traces to sample.
+	// Defaults to 100.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	SamplingPercent *uint32 `json:"samplingPercent,omitempty"`
+
+	// ServiceName is the service name reported with each span.
+	// Defaults to "contour".
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
 }
 
 // XDSServerType is the type of xDS server implementation.
@@ -165,6 +241,12 @@ type MetricsConfig struct {
 	// Metrics and health endpoints cannot have same port number when metrics is served over HTTPS.
 	// +optional
 	TLS *MetricsTLS `json:"tls,omitempty"`
+
+	// Paths is the list of Envoy admin interface path prefixes
+	// exposed by the Envoy metrics listener. Only applies to Envoy.
+	// Defaults to "/stats".
+	// +optional
+	Paths []string `json:"paths,omitempty"`
 }
 
 // TLS holds TLS file config details.
@@ -180,6 +262,12 @@ type MetricsTLS struct {
 	// Client key filename.
 	// +optional
 	KeyFile string `json:"keyFile,omitempty"`
+
+	// UseXDSCertificate serves the Envoy metrics endpoint using the
+	// client certificate and key Envoy uses to connect to Contour,
+	// instead of CertFile and KeyFile. Only applies to Envoy.
+	// +optional
+	UseXDSCertificate bool `json:"useXDSCertificate,omitempty"`
 }
 
 // HTTPVersionType is the name of a supported HTTP version.
@@ -242,6 +330,51 @@ type EnvoyConfig struct {
 
 	// Network holds various configurable Envoy network values.
 	Network NetworkParameters `json:"network"`
+
+	// ListenerProfiles define listener settings that are served to
+	// specific Envoy nodes in place of the settings above, so that
+	// different Envoy fleets served by one Contour can have different
+	// edge policies. Listener profiles require the "envoy" xDS server type.
+	// +optional
+	ListenerProfiles []ListenerProfile `json:"listenerProfiles,omitempty"`
+}
+
+// ListenerProfile defines the listener settings served to the Envoy
+// nodes bound to it. Settings that are not set in the profile are
+// inherited from the EnvoyConfig.
+type ListenerProfile struct {
+	// Name is the unique name of the profile.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// NodeIDs binds the Envoy nodes with these IDs to the profile.
+	// The node ID is set with Envoy's --service-node flag.
+	// +optional
+	NodeIDs []string `json:"nodeIDs,omitempty"`
+
+	// NodeClusters binds the Envoy nodes with these clusters to the
+	// profile. The node cluster is set with Envoy's --service-cluster
+	// flag. Nodes bound by ID take precedence.
+	// +optional
+	NodeClusters []string `json:"nodeClusters,omitempty"`
+
+	// Timeouts overrides the listener timeouts.
+	// +optional
+	Timeouts *TimeoutParameters `json:"timeouts,omitempty"`
+
+	// Logging overrides the access log configuration.
+	// +optional
+	Logging *EnvoyLogging `json:"logging,omitempty"`
+
+	// HTTPFilters overrides the listener filters enabled on the
+	// HTTP listener.
+	// +optional
+	HTTPFilters *EnvoyListenerFilters `json:"httpFilters,omitempty"`
+
+	// HTTPSFilters overrides the listener filters enabled on the
+	// HTTPS listener.
+	// +optional
+	HTTPSFilters *EnvoyListenerFilters `json:"httpsFilters,omitempty"`
 }
 
 // LogLevel is the logging levels available.
@@ -295,10 +428,216 @@ type EnvoyListenerConfig struct {
 	// +kubebuilder:validation:Enum="";"exact"
 	ConnectionBalancer string `json:"connectionBalancer"`
 
+	// FilterTimeout is the timeout for the listener filters to finish
+	// inspecting a new connection. Set to "infinity" to disable the
+	// timeout entirely. If not set, Envoy's default of 15s applies.
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/listener/v3/listener.proto#envoy-v3-api-field-config-listener-v3-listener-listener-filters-timeout
+	// for more information.
+	// +optional
+	FilterTimeout *string `json:"filterTimeout,omitempty"`
+
+	// ContinueOnFilterTimeout passes a connection to the filter chains
+	// when its listener filters time out, rather than closing it.
+	// +optional
+	ContinueOnFilterTimeout bool `json:"continueOnFilterTimeout,omitempty"`
+
+	// DrainType sets when Envoy drains the connections of the listeners.
+	// If the value is "default", connections are drained when a listener
+	// or filter chain is updated or removed, and when Envoy is shutting
+	// down or failing health checks. If the value is "modify-only",
+	// connections are only drained when a listener or filter chain is
+	// updated or removed.
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/listener/v3/listener.proto#envoy-v3-api-enum-config-listener-v3-listener-draintype
+	// for more information.
+	// +kubebuilder:validation:Enum="";"default";"modify-only"
+	// +optional
+	DrainType string `json:"drainType,omitempty"`
+
+	// SocketOptions configures the socket options of the listeners.
+	// +optional
+	SocketOptions *EnvoyListenerSocketOptions `json:"socketOptions,omitempty"`
+
+	// MaxConnectionsPerListener limits the number of downstream
+	// connections of each HTTP and HTTPS listener. The limit is
+	// delivered to Envoy as a runtime value over RTDS. If not set
+	// or zero, the number of connections is not limited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConnectionsPerListener uint32 `json:"maxConnectionsPerListener,omitempty"`
+
+	// GlobalMaxConnections limits the number of downstream
+	// connections of all listeners of an Envoy. The limit is
+	// delivered to Envoy as a runtime value over RTDS, and
+	// overrides the limit set in the Envoy bootstrap. If not set
+	// or zero, the limit set in the bootstrap applies.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	GlobalMaxConnections uint32 `json:"globalMaxConnections,omitempty"`
+
+	// EnableH2C accepts HTTP/2 over cleartext (h2c) with prior
+	// knowledge, as well as HTTP/1, on the HTTP listener, whatever
+	// the default HTTP versions. This allows gRPC clients to reach
+	// Envoy without TLS. Upgrading HTTP/1.1 connections to h2c is
+	// not supported.
+	// +optional
+	EnableH2C bool `json:"enableH2C,omitempty"`
+
+	// DefaultHostForHTTP10 is the host that HTTP/1.0 requests
+	// without a Host header are handled as. If not set, such
+	// requests are rejected. HTTP/1.0 requests with a Host header
+	// are always accepted.
+	// +optional
+	DefaultHostForHTTP10 string `json:"defaultHostForHTTP10,omitempty"`
+
+	// PreserveHeaderCase preserves the case of the HTTP/1 header
+	// names of requests and responses received by the listeners,
+	// rather than lowercasing them.
+	// +optional
+	PreserveHeaderCase bool `json:"preserveHeaderCase,omitempty"`
+
+	// HeaderLimits limits the size and number of the headers of
+	// requests received by the listeners.
+	// +optional
+	HeaderLimits *HeaderLimitsConfig `json:"headerLimits,omitempty"`
+
+	// HTTPFilters overrides the listener filters enabled on the
+	// HTTP listener.
+	// +optional
+	HTTPFilters *EnvoyListenerFilters `json:"httpFilters,omitempty"`
+
+	// HTTPSFilters overrides the listener filters enabled on the
+	// HTTPS listener.
+	// +optional
+	HTTPSFilters *EnvoyListenerFilters `json:"httpsFilters,omitempty"`
+
+	// ProxyProtocol configures the PROXY protocol listener filter.
+	// +optional
+	ProxyProtocol *ProxyProtocolConfig `json:"proxyProtocol,omitempty"`
+
 	// TLS holds various configurable Envoy TLS listener values.
 	TLS EnvoyTLS `json:"tls"`
 }
 
+// HeaderLimitsConfig limits the headers of requests received by the
+// Envoy listeners.
+type HeaderLimitsConfig struct {
+	// MaxRequestHeadersKB is the maximum size, in KiB, of the headers
+	// of a request. If not set, Envoy's default of 60 KiB applies.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8192
+	// +optional
+	MaxRequestHeadersKB uint32 `json:"maxRequestHeadersKB,omitempty"`
+
+	// MaxRequestHeadersCount is the maximum number of headers of a
+	// request. If not set, Envoy's default of 100 applies.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxRequestHeadersCount uint32 `json:"maxRequestHeadersCount,omitempty"`
+
+	// RejectAction is how Envoy rejects requests that exceed the
+	// limits, or are otherwise invalid. If the value is
+	// "close-connection", the connection of the request is closed,
+	// after an error response for HTTP/1. If the value is
+	// "reject-request", only the request is rejected, and the
+	// connection is kept open. If not set, defaults to
+	// "close-connection".
+	// +kubebuilder:validation:Enum="";"close-connection";"reject-request"
+	// +optional
+	RejectAction string `json:"rejectAction,omitempty"`
+}
+
+// ProxyProtocolConfig holds the PROXY protocol listener filter settings.
+type ProxyProtocolConfig struct {
+	// TLVs are the PROXY protocol v2 TLVs to extract into the
+	// dynamic metadata of a connection.
+	// +optional
+	TLVs []ProxyProtocolTLV `json:"tlvs,omitempty"`
+}
+
+// ProxyProtocolTLV is a PROXY protocol v2 TLV to extract into
+// dynamic metadata.
+type ProxyProtocolTLV struct {
+	// Type is the type of the TLV.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=255
+	Type int `json:"type"`
+
+	// MetadataNamespace is the dynamic metadata namespace to store
+	// the value in. Defaults to "envoy.filters.listener.proxy_protocol".
+	// +optional
+	MetadataNamespace string `json:"metadataNamespace,omitempty"`
+
+	// Key is the dynamic metadata key to store the value in.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+}
+
+// EnvoyListenerSocketOptions holds the socket options of the Envoy listeners.
+type EnvoyListenerSocketOptions struct {
+	// TCPKeepalive configures TCP keep-alive on downstream connections.
+	// +optional
+	TCPKeepalive *TCPKeepaliveConfig `json:"tcpKeepalive,omitempty"`
+
+	// TCPFastOpenQueueLength enables TCP Fast Open on the listeners
+	// with the given queue length. If not set or zero, TCP Fast Open
+	// is not enabled.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TCPFastOpenQueueLength uint32 `json:"tcpFastOpenQueueLength,omitempty"`
+
+	// ReusePort sets SO_REUSEPORT on the listener sockets, so that
+	// each Envoy worker thread accepts connections on its own socket.
+	// +optional
+	ReusePort bool `json:"reusePort,omitempty"`
+
+	// Freebind sets IP_FREEBIND on the listener sockets, so that
+	// listeners can bind to addresses that are not yet assigned to
+	// the host.
+	// +optional
+	Freebind bool `json:"freebind,omitempty"`
+}
+
+// TCPKeepaliveConfig holds the TCP keep-alive settings of the Envoy listeners.
+type TCPKeepaliveConfig struct {
+	// Disabled disables TCP keep-alive on downstream connections.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// IdleTime is the time, in seconds, a connection needs to be idle
+	// before TCP keep-alive probes are sent. Defaults to 45.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IdleTime uint32 `json:"idleTime,omitempty"`
+
+	// Interval is the time, in seconds, between TCP keep-alive probes.
+	// Defaults to 5.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Interval uint32 `json:"interval,omitempty"`
+
+	// Probes is the number of unanswered TCP keep-alive probes to send
+	// before the connection is closed. Defaults to 9.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Probes uint32 `json:"probes,omitempty"`
+}
+
+// EnvoyListenerFilters holds the listener filters to enable on a listener.
+type EnvoyListenerFilters struct {
+	// ProxyProtocol enables the PROXY protocol listener filter.
+	// If not set, UseProxyProto applies.
+	// +optional
+	ProxyProtocol *bool `json:"proxyProtocol,omitempty"`
+
+	// TLSInspector enables the TLS inspector listener filter.
+	// If not set, it is disabled on the HTTP listener and
+	// enabled on the HTTPS listener.
+	// +optional
+	TLSInspector *bool `json:"tlsInspector,omitempty"`
+}
+
 // +kubebuilder:validation:Enum="[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305]";"[ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]";"ECDHE-ECDSA-AES128-GCM-SHA256";"ECDHE-RSA-AES128-GCM-SHA256";"ECDHE-ECDSA-AES128-SHA";"ECDHE-RSA-AES128-SHA";"AES128-GCM-SHA256";"AES128-SHA";"ECDHE-ECDSA-AES256-GCM-SHA384";"ECDHE-RSA-AES256-GCM-SHA384";"ECDHE-ECDSA-AES256-SHA";"ECDHE-RSA-AES256-SHA";"AES256-GCM-SHA384";"AES256-SHA"
 type TLSCipherType string
 
@@ -438,6 +777,96 @@ type ClusterParameters struct {
 	// +kubebuilder:default="auto"
 	// +kubebuilder:validation:Enum="auto";"v4";"v6"
 	DNSLookupFamily ClusterDNSFamilyType `json:"dnsLookupFamily"`
+
+	// UseEndpointSlices configures Contour to watch EndpointSlices
+	// rather than Endpoints for the addresses of upstream Services.
+	// This is required for dual-stack Services, since Endpoints only
+	// carry addresses of the Service's primary IP family.
+	// Requires Kubernetes 1.21 or later.
+	// +optional
+	UseEndpointSlices bool `json:"useEndpointSlices,omitempty"`
+
+	// EndpointDrainDelay configures Contour to watch Pods, and to
+	// stop sending requests to the endpoints of a Pod as soon as the
+	// Pod starts terminating, rather than when its Endpoints are
+	// updated. The endpoints are kept draining for this long, so that
+	// requests in progress can complete, and then removed. If unset,
+	// Pods are not watched.
+	// +optional
+	EndpointDrainDelay *string `json:"endpointDrainDelay,omitempty"`
+
+	// PreserveHeaderCase preserves the case of the HTTP/1 header
+	// names of requests sent to upstream clusters, as they were
+	// received by the listeners, rather than lowercasing them.
+	// Requires the listeners to preserve header case too.
+	// +optional
+	PreserveHeaderCase bool `json:"preserveHeaderCase,omitempty"`
+
+	// HealthyPanicThreshold is the percentage of healthy hosts of an
+	// upstream cluster below which Envoy enters panic mode, ignoring
+	// host health and balancing requests across all hosts. If not
+	// set or zero, panic mode is disabled, and requests are only sent
+	// to healthy hosts, however few there are.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HealthyPanicThreshold uint32 `json:"healthyPanicThreshold,omitempty"`
+
+	// ZoneAwareRoutingMinClusterSize is the minimum number of hosts
+	// of an upstream cluster for zone aware routing to be used. If
+	// not set or zero, Envoy's default of 6 applies. Zone aware
+	// routing requires Envoy to be configured with a local cluster.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ZoneAwareRoutingMinClusterSize uint64 `json:"zoneAwareRoutingMinClusterSize,omitempty"`
+
+	// InsecureSkipVerifyNamespaces are the namespaces in which the
+	// validation of the certificates of upstream Services may be
+	// skipped with `insecureSkipVerify`. The value "*" allows it in
+	// every namespace. If empty, it is not allowed in any namespace.
+	// +optional
+	InsecureSkipVerifyNamespaces []string `json:"insecureSkipVerifyNamespaces,omitempty"`
+
+	// StaticClusters are clusters of fixed addresses, not backed by
+	// Kubernetes Services, that are always sent to Envoy, e.g. for
+	// the stats sinks or trace collectors referenced by the Envoy
+	// configuration.
+	// +optional
+	StaticClusters []StaticCluster `json:"staticClusters,omitempty"`
+}
+
+// StaticCluster defines a cluster of fixed addresses.
+type StaticCluster struct {
+	// Name is the name of the Envoy cluster. It cannot contain a
+	// "/", so that it cannot clash with the clusters of Services.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[^/]+$`
+	Name string `json:"name"`
+
+	// Endpoints are the addresses of the cluster.
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []StaticClusterEndpoint `json:"endpoints"`
+
+	// Protocol is the protocol of the endpoints. If the value is
+	// "h2c", Envoy uses HTTP/2 without TLS. If not set, HTTP
+	// requests are sent with HTTP/1.1.
+	// +kubebuilder:validation:Enum="";h2c
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// StaticClusterEndpoint is the address of an endpoint of a
+// static cluster.
+type StaticClusterEndpoint struct {
+	// Address is the IP address or DNS name of the endpoint.
+	// If it is a DNS name, it is resolved by Envoy.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Port is the port of the endpoint.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port"`
 }
 
 // HTTPProxyConfig defines parameters on HTTPProxy.
@@ -446,10 +875,24 @@ type HTTPProxyConfig struct {
 	// permitInsecure field in HTTPProxy.
 	DisablePermitInsecure bool `json:"disablePermitInsecure"`
 
+	// PermitInsecurePrefixes is a list of path prefixes that are
+	// always served over HTTP, without a redirect to HTTPS, on
+	// virtual hosts that have TLS enabled. This is useful for paths
+	// such as /.well-known/acme-challenge/ used by ACME HTTP-01
+	// challenges.
+	// +optional
+	PermitInsecurePrefixes []string `json:"permitInsecurePrefixes,omitempty"`
+
 	// Restrict Contour to searching these namespaces for root ingress routes.
 	// +optional
 	RootNamespaces []string `json:"rootNamespaces,omitempty"`
 
+	// DenyByDefault only programs the root HTTPProxies and Ingresses
+	// in the root namespaces, and the HTTPProxies they include. If no
+	// root namespaces are set, none are programmed.
+	// +optional
+	DenyByDefault bool `json:"denyByDefault,omitempty"`
+
 	// FallbackCertificate defines the namespace/name of the Kubernetes secret to
 	// use as fallback when a non-SNI request is received.
 	// +optional
@@ -471,6 +914,13 @@ type NetworkParameters struct {
 	// If configured to port "0" then the admin interface is disabled.
 	// +kubebuilder:default=9001
 	EnvoyAdminPort int `json:"adminPort"`
+
+	// DualStack binds Envoy listeners that are bound to the IPv4
+	// or IPv6 wildcard address to the wildcard address of the other
+	// IP family too, using a separate listener, so that both IPv4
+	// and IPv6 clients can connect.
+	// +optional
+	DualStack bool `json:"dualStack,omitempty"`
 }
 
 // RateLimitServiceConfig defines properties of a global Rate Limit Service.
@@ -493,6 +943,23 @@ type RateLimitServiceConfig struct {
 	//
 	// ref. https://tools.ietf.org/id/draft-polli-ratelimit-headers-03.html
 	EnableXRateLimitHeaders bool `json:"enableXRateLimitHeaders"`
+
+	// ConfigMap, if set, identifies the ConfigMap to which Contour
+	// writes the configuration of the Envoy rate limit service,
+	// generated from the descriptors and limits of the global rate
+	// limit policies of HTTPProxies. The ConfigMap is meant to be
+	// mounted into the configuration directory of the rate limit
+	// service.
+	// +optional
+	ConfigMap *NamespacedName `json:"configMap,omitempty"`
+
+	// Stages lists the rate limit stages for which Envoy's
+	// rate limit filter is configured. Descriptors are only
+	// evaluated in a stage that is listed here. Stages must
+	// be between 0 and 10.
+	// Defaults to 0 only.
+	// +optional
+	Stages []uint32 `json:"stages,omitempty"`
 }
 
 // PolicyConfig holds default policy used if not explicitly set by the user
@@ -508,6 +975,44 @@ type PolicyConfig struct {
 	// ApplyToIngress determines if the Policies will apply to ingress objects
 	// +optional
 	ApplyToIngress bool `json:"applyToIngress"`
+
+	// FilterExemptPrefixes is a list of path prefixes, e.g. /healthz,
+	// for which external authorization and global rate limiting are
+	// disabled on all virtual hosts, including those of Ingress
+	// objects. This allows health checkers, which cannot authenticate,
+	// to reach these paths.
+	// +optional
+	FilterExemptPrefixes []string `json:"filterExemptPrefixes,omitempty"`
+
+	// LocalRateLimitPolicy is the default local rate limit policy of
+	// every virtual host, including those of Ingress objects and
+	// HTTPRoutes. The limit applies separately to each virtual host
+	// on each Envoy instance. An HTTPProxy overrides it by setting
+	// its own virtual host local rate limit policy, or opts out of
+	// it with spec.disableDefaultLocalRateLimitPolicy.
+	// +optional
+	LocalRateLimitPolicy *contour_api_v1.LocalRateLimitPolicy `json:"localRateLimitPolicy,omitempty"`
+}
+
+// TimeoutPolicyConfig defines the default upstream connect timeout
+// and the largest route timeouts that users may set.
+type TimeoutPolicyConfig struct {
+	// ConnectTimeout is the timeout for new connections to
+	// upstream Services. Defaults to 2s.
+	// +optional
+	ConnectTimeout *string `json:"connectTimeout,omitempty"`
+
+	// MaxResponseTimeout is the largest response timeout that an
+	// HTTPProxy route or Ingress may set. HTTPProxy routes that set a
+	// larger or infinite response timeout are rejected. Unlimited if unset.
+	// +optional
+	MaxResponseTimeout *string `json:"maxResponseTimeout,omitempty"`
+
+	// MaxIdleTimeout is the largest idle timeout that an HTTPProxy
+	// route may set. HTTPProxy routes that set a larger or infinite
+	// idle timeout are rejected. Unlimited if unset.
+	// +optional
+	MaxIdleTimeout *string `json:"maxIdleTimeout,omitempty"`
 }
 
 type HeadersPolicy struct {
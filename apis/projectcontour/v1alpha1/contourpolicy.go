@@ -0,0 +1,148 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyDefaults are the policies of the HTTPProxies in a namespace
+// that apply where the HTTPProxies do not set their own.
+type PolicyDefaults struct {
+	// TimeoutPolicy sets the timeouts of routes that do not
+	// set them. Each timeout is defaulted separately.
+	//
+	// +optional
+	TimeoutPolicy *contour_api_v1.TimeoutPolicy `json:"timeoutPolicy,omitempty"`
+
+	// LoadBalancerPolicy is the load balancer policy of routes
+	// that do not set one.
+	//
+	// +optional
+	LoadBalancerPolicy *contour_api_v1.LoadBalancerPolicy `json:"loadBalancerPolicy,omitempty"`
+
+	// RateLimitPolicy is the rate limit policy of virtual hosts
+	// that do not set one.
+	//
+	// +optional
+	RateLimitPolicy *contour_api_v1.RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+
+	// RequestHeadersPolicy sets and removes request headers on
+	// every route, unless the route sets or removes the same header.
+	//
+	// +optional
+	RequestHeadersPolicy *contour_api_v1.HeadersPolicy `json:"requestHeadersPolicy,omitempty"`
+
+	// ResponseHeadersPolicy sets and removes response headers on
+	// every route, unless the route sets or removes the same header.
+	//
+	// +optional
+	ResponseHeadersPolicy *contour_api_v1.HeadersPolicy `json:"responseHeadersPolicy,omitempty"`
+}
+
+// PolicyLimits are the limits that the HTTPProxies in a namespace
+// must keep to. An HTTPProxy that exceeds them is not valid.
+type PolicyLimits struct {
+	// MaxResponseTimeout is the longest response timeout that a
+	// route can set. Routes cannot disable their response timeout.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	MaxResponseTimeout string `json:"maxResponseTimeout,omitempty"`
+
+	// MaxIdleTimeout is the longest idle timeout that a route
+	// can set. Routes cannot disable their idle timeout.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	MaxIdleTimeout string `json:"maxIdleTimeout,omitempty"`
+
+	// AllowedLoadBalancerStrategies are the load balancer strategies
+	// that routes can set. If empty, any strategy can be set. Routes
+	// that do not set a strategy are always allowed.
+	//
+	// +optional
+	// +kubebuilder:validation:items:Enum=RoundRobin;WeightedLeastRequest;Random;RequestHash;Cookie
+	AllowedLoadBalancerStrategies []string `json:"allowedLoadBalancerStrategies,omitempty"`
+
+	// ProtectedHeaders are the names of the headers that the
+	// request and response headers policies of routes and their
+	// services cannot set or remove. The headers policies of
+	// the defaults can set them.
+	//
+	// +optional
+	ProtectedHeaders []string `json:"protectedHeaders,omitempty"`
+}
+
+// ContourPolicySpec defines the policies of the HTTPProxies in the
+// namespace of a ContourPolicy.
+type ContourPolicySpec struct {
+	// Defaults are applied to the HTTPProxies in the namespace
+	// where they do not set their own policies.
+	//
+	// +optional
+	Defaults *PolicyDefaults `json:"defaults,omitempty"`
+
+	// Limits are the limits that the HTTPProxies in the
+	// namespace must keep to.
+	//
+	// +optional
+	Limits *PolicyLimits `json:"limits,omitempty"`
+}
+
+// ContourPolicyStatus defines the observed state of a ContourPolicy.
+type ContourPolicyStatus struct {
+	// Conditions contains the current status of the ContourPolicy.
+	//
+	// Contour will update a single condition, `Valid`, that is in normal-true polarity.
+	// The policy only applies to the HTTPProxies in its namespace while it is valid.
+	//
+	// Contour will not modify any other Conditions set in this block,
+	// in case some other controller wants to add a Condition.
+	//
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []contour_api_v1.DetailedCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=contourpolicy;contourpolicies
+
+// ContourPolicy is the schema for the Contour policy API. A
+// ContourPolicy lets cluster administrators set the defaults and
+// limits of the policies of the HTTPProxies in its namespace.
+// A namespace can have at most one ContourPolicy.
+type ContourPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ContourPolicySpec   `json:"spec,omitempty"`
+	Status ContourPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ContourPolicyList contains a list of ContourPolicy resources.
+type ContourPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ContourPolicy `json:"items"`
+}
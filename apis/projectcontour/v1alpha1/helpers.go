@@ -31,6 +31,30 @@ func (status *ExtensionServiceStatus) GetConditionFor(condType string) *contour_
 	return nil
 }
 
+// GetConditionFor returns the a pointer to the condition for a given type,
+// or nil if there are no conditions of that type.
+func (status *ContourPolicyStatus) GetConditionFor(condType string) *contour_api_v1.DetailedCondition {
+	for i, cond := range status.Conditions {
+		if cond.Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// GetConditionFor returns the a pointer to the condition for a given type,
+// or nil if there are no conditions of that type.
+func (status *TrafficWeightsStatus) GetConditionFor(condType string) *contour_api_v1.DetailedCondition {
+	for i, cond := range status.Conditions {
+		if cond.Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
 // Validate configuration that is not already covered by CRD validation.
 func (c *ContourConfigurationSpec) Validate() error {
 	if err := endpointsInConfict(c.Health, c.Metrics); err != nil {
@@ -41,6 +65,18 @@ func (c *ContourConfigurationSpec) Validate() error {
 		return err
 	}
 
+	if len(c.Envoy.ListenerProfiles) > 0 && c.XDSServer.Type != EnvoyServerType {
+		return fmt.Errorf("invalid contour configuration: listener profiles require the %q xDS server type", EnvoyServerType)
+	}
+
+	remoteClusters := map[string]bool{}
+	for _, rc := range c.RemoteClusters {
+		if remoteClusters[rc.Name] {
+			return fmt.Errorf("invalid contour configuration: duplicate remote cluster %q", rc.Name)
+		}
+		remoteClusters[rc.Name] = true
+	}
+
 	return nil
 }
 
@@ -49,9 +85,92 @@ func (e *EnvoyConfig) Validate() error {
 	if err := endpointsInConfict(e.Health, e.Metrics); err != nil {
 		return fmt.Errorf("invalid envoy configuration: %v", err)
 	}
+
+	if err := e.Logging.Validate(); err != nil {
+		return fmt.Errorf("invalid envoy configuration: %v", err)
+	}
+
+	if err := validateListenerProfiles(e.ListenerProfiles); err != nil {
+		return fmt.Errorf("invalid envoy configuration: %v", err)
+	}
+
+	if err := validateStaticClusters(e.Cluster.StaticClusters); err != nil {
+		return fmt.Errorf("invalid envoy configuration: %v", err)
+	}
+	return nil
+}
+
+// validateStaticClusters checks that the static clusters have
+// unique names.
+func validateStaticClusters(clusters []StaticCluster) error {
+	names := map[string]bool{}
+
+	for _, c := range clusters {
+		if names[c.Name] {
+			return fmt.Errorf("duplicate static cluster %q", c.Name)
+		}
+		names[c.Name] = true
+	}
+
+	return nil
+}
+
+// validateListenerProfiles checks that the listener profiles have
+// unique names and that each Envoy node ID or cluster is bound to
+// at most one profile.
+func validateListenerProfiles(profiles []ListenerProfile) error {
+	names := map[string]bool{}
+	nodeIDs := map[string]string{}
+	nodeClusters := map[string]string{}
+
+	for _, p := range profiles {
+		if p.Name == "" {
+			return fmt.Errorf("listener profile name must not be empty")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate listener profile %q", p.Name)
+		}
+		names[p.Name] = true
+
+		if len(p.NodeIDs) == 0 && len(p.NodeClusters) == 0 {
+			return fmt.Errorf("listener profile %q must bind at least one node ID or node cluster", p.Name)
+		}
+		for _, id := range p.NodeIDs {
+			if other, ok := nodeIDs[id]; ok {
+				return fmt.Errorf("node ID %q is bound to listener profiles %q and %q", id, other, p.Name)
+			}
+			nodeIDs[id] = p.Name
+		}
+		for _, cluster := range p.NodeClusters {
+			if other, ok := nodeClusters[cluster]; ok {
+				return fmt.Errorf("node cluster %q is bound to listener profiles %q and %q", cluster, other, p.Name)
+			}
+			nodeClusters[cluster] = p.Name
+		}
+
+		if p.Logging != nil {
+			if err := p.Logging.Validate(); err != nil {
+				return fmt.Errorf("listener profile %q: %v", p.Name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// Validate the access log format string and JSON fields, so that
+// invalid command operators are reported when the configuration is
+// loaded rather than rejected by Envoy.
+func (l *EnvoyLogging) Validate() error {
+	if l.AccessLogFormatString != nil {
+		if err := validateAccessLogFormatString(*l.AccessLogFormatString); err != nil {
+			return err
+		}
+	}
+
+	return l.AccessLogFields.Validate()
+}
+
 // endpointsInConfict returns error if different protocol are configured to use single port.
 func endpointsInConfict(health HealthConfig, metrics MetricsConfig) error {
 	if metrics.TLS != nil && health.Address == metrics.Address && health.Port == metrics.Port {
@@ -21,6 +21,8 @@ import (
 
 var ExtensionServiceGVR = GroupVersion.WithResource("extensionservices")
 var ContourConfigurationGVR = GroupVersion.WithResource("contourconfigurations")
+var TrafficWeightsGVR = GroupVersion.WithResource("trafficweights")
+var ContourPolicyGVR = GroupVersion.WithResource("contourpolicies")
 
 var (
 	// GroupVersion is group version used to register these objects
@@ -40,6 +42,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ExtensionServiceList{},
 		&ContourConfiguration{},
 		&ContourConfigurationList{},
+		&TrafficWeights{},
+		&TrafficWeightsList{},
+		&ContourPolicy{},
+		&ContourPolicyList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
@@ -0,0 +1,111 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceWeight is the weight of one of a route's services.
+type ServiceWeight struct {
+	// Name is the name of the service, as it is given
+	// in the route of the HTTPProxy.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Weight is the proportion of requests sent to the service,
+	// relative to the weights of the route's other services.
+	Weight uint32 `json:"weight"`
+}
+
+// RouteWeights are the weights of the services of a route.
+type RouteWeights struct {
+	// Conditions identify the route by its match conditions,
+	// exactly as they are given in the HTTPProxy.
+	//
+	// +optional
+	Conditions []contour_api_v1.MatchCondition `json:"conditions,omitempty"`
+
+	// Services are the weights of the route's services. Each of
+	// the route's services, other than a mirror, must be given
+	// a weight.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Services []ServiceWeight `json:"services"`
+}
+
+// TrafficWeightsSpec defines the desired state of a TrafficWeights
+// resource.
+type TrafficWeightsSpec struct {
+	// HTTPProxy is the name of the HTTPProxy, in the same namespace,
+	// whose route weights are set.
+	//
+	// +kubebuilder:validation:MinLength=1
+	HTTPProxy string `json:"httpProxy"`
+
+	// Routes are the weights of the HTTPProxy's routes. Routes
+	// that are not listed keep the weights of the HTTPProxy.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Routes []RouteWeights `json:"routes"`
+}
+
+// TrafficWeightsStatus defines the observed state of a
+// TrafficWeights resource.
+type TrafficWeightsStatus struct {
+	// Conditions contains the current status of the TrafficWeights resource.
+	//
+	// Contour will update a single condition, `Valid`, that is in normal-true polarity.
+	// The weights are only applied while the resource is valid.
+	//
+	// Contour will not modify any other Conditions set in this block,
+	// in case some other controller wants to add a Condition.
+	//
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []contour_api_v1.DetailedCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=trafficweights
+
+// TrafficWeights is the schema for the Contour traffic weights API.
+// A TrafficWeights resource sets the weights of the services of the
+// routes of an HTTPProxy, so that progressive delivery controllers
+// can shift traffic without editing the HTTPProxy. All of the weights
+// of a TrafficWeights resource are applied together, or not at all.
+type TrafficWeights struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TrafficWeightsSpec   `json:"spec,omitempty"`
+	Status TrafficWeightsStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TrafficWeightsList contains a list of TrafficWeights resources.
+type TrafficWeightsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TrafficWeights `json:"items"`
+}
@@ -26,6 +26,22 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEChallengeSolverConfig) DeepCopyInto(out *ACMEChallengeSolverConfig) {
+	*out = *in
+	out.Service = in.Service
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEChallengeSolverConfig.
+func (in *ACMEChallengeSolverConfig) DeepCopy() *ACMEChallengeSolverConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEChallengeSolverConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in AccessLogFields) DeepCopyInto(out *AccessLogFields) {
 	{
@@ -48,6 +64,23 @@ func (in AccessLogFields) DeepCopy() AccessLogFields {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterParameters) DeepCopyInto(out *ClusterParameters) {
 	*out = *in
+	if in.EndpointDrainDelay != nil {
+		in, out := &in.EndpointDrainDelay, &out.EndpointDrainDelay
+		*out = new(string)
+		**out = **in
+	}
+	if in.InsecureSkipVerifyNamespaces != nil {
+		in, out := &in.InsecureSkipVerifyNamespaces, &out.InsecureSkipVerifyNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaticClusters != nil {
+		in, out := &in.StaticClusters, &out.StaticClusters
+		*out = make([]StaticCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterParameters.
@@ -140,14 +173,34 @@ func (in *ContourConfigurationSpec) DeepCopyInto(out *ContourConfigurationSpec)
 	if in.RateLimitService != nil {
 		in, out := &in.RateLimitService, &out.RateLimitService
 		*out = new(RateLimitServiceConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Policy != nil {
 		in, out := &in.Policy, &out.Policy
 		*out = new(PolicyConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TimeoutPolicy != nil {
+		in, out := &in.TimeoutPolicy, &out.TimeoutPolicy
+		*out = new(TimeoutPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Metrics.DeepCopyInto(&out.Metrics)
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		*out = new(TracingConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ACMEChallengeSolver != nil {
+		in, out := &in.ACMEChallengeSolver, &out.ACMEChallengeSolver
+		*out = new(ACMEChallengeSolverConfig)
+		**out = **in
+	}
+	if in.RemoteClusters != nil {
+		in, out := &in.RemoteClusters, &out.RemoteClusters
+		*out = make([]RemoteClusterConfig, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContourConfigurationSpec.
@@ -279,6 +332,112 @@ func (in *ContourDeploymentStatus) DeepCopy() *ContourDeploymentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContourPolicy) DeepCopyInto(out *ContourPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContourPolicy.
+func (in *ContourPolicy) DeepCopy() *ContourPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ContourPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ContourPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContourPolicyList) DeepCopyInto(out *ContourPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ContourPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContourPolicyList.
+func (in *ContourPolicyList) DeepCopy() *ContourPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ContourPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ContourPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContourPolicySpec) DeepCopyInto(out *ContourPolicySpec) {
+	*out = *in
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(PolicyDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(PolicyLimits)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContourPolicySpec.
+func (in *ContourPolicySpec) DeepCopy() *ContourPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ContourPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContourPolicyStatus) DeepCopyInto(out *ContourPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.DetailedCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContourPolicyStatus.
+func (in *ContourPolicyStatus) DeepCopy() *ContourPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ContourPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DebugConfig) DeepCopyInto(out *DebugConfig) {
 	*out = *in
@@ -319,8 +478,15 @@ func (in *EnvoyConfig) DeepCopyInto(out *EnvoyConfig) {
 		*out = new(TimeoutParameters)
 		(*in).DeepCopyInto(*out)
 	}
-	out.Cluster = in.Cluster
+	in.Cluster.DeepCopyInto(&out.Cluster)
 	out.Network = in.Network
+	if in.ListenerProfiles != nil {
+		in, out := &in.ListenerProfiles, &out.ListenerProfiles
+		*out = make([]ListenerProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfig.
@@ -351,6 +517,36 @@ func (in *EnvoyListener) DeepCopy() *EnvoyListener {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyListenerConfig) DeepCopyInto(out *EnvoyListenerConfig) {
 	*out = *in
+	if in.FilterTimeout != nil {
+		in, out := &in.FilterTimeout, &out.FilterTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.SocketOptions != nil {
+		in, out := &in.SocketOptions, &out.SocketOptions
+		*out = new(EnvoyListenerSocketOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeaderLimits != nil {
+		in, out := &in.HeaderLimits, &out.HeaderLimits
+		*out = new(HeaderLimitsConfig)
+		**out = **in
+	}
+	if in.HTTPFilters != nil {
+		in, out := &in.HTTPFilters, &out.HTTPFilters
+		*out = new(EnvoyListenerFilters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPSFilters != nil {
+		in, out := &in.HTTPSFilters, &out.HTTPSFilters
+		*out = new(EnvoyListenerFilters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProxyProtocol != nil {
+		in, out := &in.ProxyProtocol, &out.ProxyProtocol
+		*out = new(ProxyProtocolConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	in.TLS.DeepCopyInto(&out.TLS)
 }
 
@@ -364,6 +560,51 @@ func (in *EnvoyListenerConfig) DeepCopy() *EnvoyListenerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyListenerFilters) DeepCopyInto(out *EnvoyListenerFilters) {
+	*out = *in
+	if in.ProxyProtocol != nil {
+		in, out := &in.ProxyProtocol, &out.ProxyProtocol
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TLSInspector != nil {
+		in, out := &in.TLSInspector, &out.TLSInspector
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyListenerFilters.
+func (in *EnvoyListenerFilters) DeepCopy() *EnvoyListenerFilters {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyListenerFilters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyListenerSocketOptions) DeepCopyInto(out *EnvoyListenerSocketOptions) {
+	*out = *in
+	if in.TCPKeepalive != nil {
+		in, out := &in.TCPKeepalive, &out.TCPKeepalive
+		*out = new(TCPKeepaliveConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyListenerSocketOptions.
+func (in *EnvoyListenerSocketOptions) DeepCopy() *EnvoyListenerSocketOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyListenerSocketOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyLogging) DeepCopyInto(out *EnvoyLogging) {
 	*out = *in
@@ -479,7 +720,7 @@ func (in *ExtensionServiceSpec) DeepCopyInto(out *ExtensionServiceSpec) {
 	if in.UpstreamValidation != nil {
 		in, out := &in.UpstreamValidation, &out.UpstreamValidation
 		*out = new(v1.UpstreamValidation)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Protocol != nil {
 		in, out := &in.Protocol, &out.Protocol
@@ -563,6 +804,11 @@ func (in *GatewayConfig) DeepCopy() *GatewayConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPProxyConfig) DeepCopyInto(out *HTTPProxyConfig) {
 	*out = *in
+	if in.PermitInsecurePrefixes != nil {
+		in, out := &in.PermitInsecurePrefixes, &out.PermitInsecurePrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.RootNamespaces != nil {
 		in, out := &in.RootNamespaces, &out.RootNamespaces
 		*out = make([]string, len(*in))
@@ -585,6 +831,21 @@ func (in *HTTPProxyConfig) DeepCopy() *HTTPProxyConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderLimitsConfig) DeepCopyInto(out *HeaderLimitsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderLimitsConfig.
+func (in *HeaderLimitsConfig) DeepCopy() *HeaderLimitsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderLimitsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HeadersPolicy) DeepCopyInto(out *HeadersPolicy) {
 	*out = *in
@@ -652,6 +913,51 @@ func (in *IngressConfig) DeepCopy() *IngressConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ListenerProfile) DeepCopyInto(out *ListenerProfile) {
+	*out = *in
+	if in.NodeIDs != nil {
+		in, out := &in.NodeIDs, &out.NodeIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeClusters != nil {
+		in, out := &in.NodeClusters, &out.NodeClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(TimeoutParameters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(EnvoyLogging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPFilters != nil {
+		in, out := &in.HTTPFilters, &out.HTTPFilters
+		*out = new(EnvoyListenerFilters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPSFilters != nil {
+		in, out := &in.HTTPSFilters, &out.HTTPSFilters
+		*out = new(EnvoyListenerFilters)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ListenerProfile.
+func (in *ListenerProfile) DeepCopy() *ListenerProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ListenerProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
 	*out = *in
@@ -660,6 +966,11 @@ func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
 		*out = new(MetricsTLS)
 		**out = **in
 	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsConfig.
@@ -730,6 +1041,16 @@ func (in *PolicyConfig) DeepCopyInto(out *PolicyConfig) {
 		*out = new(HeadersPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FilterExemptPrefixes != nil {
+		in, out := &in.FilterExemptPrefixes, &out.FilterExemptPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LocalRateLimitPolicy != nil {
+		in, out := &in.LocalRateLimitPolicy, &out.LocalRateLimitPolicy
+		*out = new(v1.LocalRateLimitPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyConfig.
@@ -742,10 +1063,120 @@ func (in *PolicyConfig) DeepCopy() *PolicyConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyDefaults) DeepCopyInto(out *PolicyDefaults) {
+	*out = *in
+	if in.TimeoutPolicy != nil {
+		in, out := &in.TimeoutPolicy, &out.TimeoutPolicy
+		*out = new(v1.TimeoutPolicy)
+		**out = **in
+	}
+	if in.LoadBalancerPolicy != nil {
+		in, out := &in.LoadBalancerPolicy, &out.LoadBalancerPolicy
+		*out = new(v1.LoadBalancerPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimitPolicy != nil {
+		in, out := &in.RateLimitPolicy, &out.RateLimitPolicy
+		*out = new(v1.RateLimitPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestHeadersPolicy != nil {
+		in, out := &in.RequestHeadersPolicy, &out.RequestHeadersPolicy
+		*out = new(v1.HeadersPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResponseHeadersPolicy != nil {
+		in, out := &in.ResponseHeadersPolicy, &out.ResponseHeadersPolicy
+		*out = new(v1.HeadersPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyDefaults.
+func (in *PolicyDefaults) DeepCopy() *PolicyDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyLimits) DeepCopyInto(out *PolicyLimits) {
+	*out = *in
+	if in.AllowedLoadBalancerStrategies != nil {
+		in, out := &in.AllowedLoadBalancerStrategies, &out.AllowedLoadBalancerStrategies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProtectedHeaders != nil {
+		in, out := &in.ProtectedHeaders, &out.ProtectedHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyLimits.
+func (in *PolicyLimits) DeepCopy() *PolicyLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyProtocolConfig) DeepCopyInto(out *ProxyProtocolConfig) {
+	*out = *in
+	if in.TLVs != nil {
+		in, out := &in.TLVs, &out.TLVs
+		*out = make([]ProxyProtocolTLV, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyProtocolConfig.
+func (in *ProxyProtocolConfig) DeepCopy() *ProxyProtocolConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyProtocolConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyProtocolTLV) DeepCopyInto(out *ProxyProtocolTLV) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyProtocolTLV.
+func (in *ProxyProtocolTLV) DeepCopy() *ProxyProtocolTLV {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyProtocolTLV)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RateLimitServiceConfig) DeepCopyInto(out *RateLimitServiceConfig) {
 	*out = *in
 	out.ExtensionService = in.ExtensionService
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]uint32, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitServiceConfig.
@@ -758,6 +1189,113 @@ func (in *RateLimitServiceConfig) DeepCopy() *RateLimitServiceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteClusterConfig) DeepCopyInto(out *RemoteClusterConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClusterConfig.
+func (in *RemoteClusterConfig) DeepCopy() *RemoteClusterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteClusterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteWeights) DeepCopyInto(out *RouteWeights) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.MatchCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]ServiceWeight, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteWeights.
+func (in *RouteWeights) DeepCopy() *RouteWeights {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteWeights)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceWeight) DeepCopyInto(out *ServiceWeight) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceWeight.
+func (in *ServiceWeight) DeepCopy() *ServiceWeight {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceWeight)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticCluster) DeepCopyInto(out *StaticCluster) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]StaticClusterEndpoint, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticCluster.
+func (in *StaticCluster) DeepCopy() *StaticCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticClusterEndpoint) DeepCopyInto(out *StaticClusterEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticClusterEndpoint.
+func (in *StaticClusterEndpoint) DeepCopy() *StaticClusterEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticClusterEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TCPKeepaliveConfig) DeepCopyInto(out *TCPKeepaliveConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPKeepaliveConfig.
+func (in *TCPKeepaliveConfig) DeepCopy() *TCPKeepaliveConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TCPKeepaliveConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
@@ -818,6 +1356,159 @@ func (in *TimeoutParameters) DeepCopy() *TimeoutParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeoutPolicyConfig) DeepCopyInto(out *TimeoutPolicyConfig) {
+	*out = *in
+	if in.ConnectTimeout != nil {
+		in, out := &in.ConnectTimeout, &out.ConnectTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxResponseTimeout != nil {
+		in, out := &in.MaxResponseTimeout, &out.MaxResponseTimeout
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxIdleTimeout != nil {
+		in, out := &in.MaxIdleTimeout, &out.MaxIdleTimeout
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeoutPolicyConfig.
+func (in *TimeoutPolicyConfig) DeepCopy() *TimeoutPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeoutPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingConfig) DeepCopyInto(out *TracingConfig) {
+	*out = *in
+	if in.SamplingPercent != nil {
+		in, out := &in.SamplingPercent, &out.SamplingPercent
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingConfig.
+func (in *TracingConfig) DeepCopy() *TracingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficWeights) DeepCopyInto(out *TrafficWeights) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficWeights.
+func (in *TrafficWeights) DeepCopy() *TrafficWeights {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficWeights)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TrafficWeights) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficWeightsList) DeepCopyInto(out *TrafficWeightsList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TrafficWeights, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficWeightsList.
+func (in *TrafficWeightsList) DeepCopy() *TrafficWeightsList {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficWeightsList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TrafficWeightsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficWeightsSpec) DeepCopyInto(out *TrafficWeightsSpec) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]RouteWeights, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficWeightsSpec.
+func (in *TrafficWeightsSpec) DeepCopy() *TrafficWeightsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficWeightsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficWeightsStatus) DeepCopyInto(out *TrafficWeightsStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.DetailedCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficWeightsStatus.
+func (in *TrafficWeightsStatus) DeepCopy() *TrafficWeightsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficWeightsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XDSServerConfig) DeepCopyInto(out *XDSServerConfig) {
 	*out = *in
@@ -14,6 +14,10 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+
 	"github.com/projectcontour/contour/internal/envoy"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
@@ -26,6 +30,11 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *envoy.Boo
 	bootstrap := app.Command("bootstrap", "Generate bootstrap configuration.")
 	bootstrap.Arg("path", "Configuration file ('-' for standard output).").Required().StringVar(&config.Path)
 	bootstrap.Flag("resources-dir", "Directory where configuration files will be written to.").StringVar(&config.ResourcesDir)
+	bootstrap.Flag("resources-file-mode", "Octal mode of the configuration files written.").Default("0644").SetValue((*fileMode)(&config.FileMode))
+	bootstrap.Flag("resources-dir-mode", "Octal mode of the SDS resource directories created.").Default("0777").SetValue((*fileMode)(&config.DirMode))
+	bootstrap.Flag("sds-tls-certificate-path", "Path of the SDS resource for the xDS client certificate. Defaults to sds/xds-tls-certificate.json in the resources directory.").StringVar(&config.SDSTLSCertificatePath)
+	bootstrap.Flag("sds-validation-context-path", "Path of the SDS resource for the xDS CA bundle. Defaults to sds/xds-validation-context.json in the resources directory.").StringVar(&config.SDSValidationContextPath)
+	bootstrap.Flag("sds-refresh-interval", "How often to check the xDS certificate files for changes and rewrite the SDS resources. Zero writes them once and exits.").DurationVar(&config.SDSRefreshInterval)
 	bootstrap.Flag("admin-address", "Path to Envoy admin unix domain socket.").Default("/admin/admin.sock").StringVar(&config.AdminAddress)
 	bootstrap.Flag("admin-port", "DEPRECATED: Envoy admin interface port.").IntVar(&config.AdminPort)
 	bootstrap.Flag("xds-address", "xDS gRPC API address.").StringVar(&config.XDSAddress)
@@ -33,8 +42,40 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *envoy.Boo
 	bootstrap.Flag("envoy-cafile", "CA Filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_CAFILE").StringVar(&config.GrpcCABundle)
 	bootstrap.Flag("envoy-cert-file", "Client certificate filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_CERT_FILE").StringVar(&config.GrpcClientCert)
 	bootstrap.Flag("envoy-key-file", "Client key filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_KEY_FILE").StringVar(&config.GrpcClientKey)
+	bootstrap.Flag("envoy-watched-directory", "Directory Envoy watches to reload the xDS certificate files, such as the root of a projected volume. Defaults to the directory of each file.").StringVar(&config.GrpcWatchedDirectory)
 	bootstrap.Flag("namespace", "The namespace the Envoy container will run in.").Envar("CONTOUR_NAMESPACE").Default("projectcontour").StringVar(&config.Namespace)
 	bootstrap.Flag("xds-resource-version", "The versions of the xDS resources to request from Contour.").Default("v3").StringVar((*string)(&config.XDSResourceVersion))
 	bootstrap.Flag("dns-lookup-family", "Defines what DNS Resolution Policy to use for Envoy -> Contour cluster name lookup. Either v4, v6 or auto.").StringVar(&config.DNSLookupFamily)
+	bootstrap.Flag("overload-max-heap", "Maximum heap size in bytes for the Envoy overload manager. Zero disables the overload manager.").Uint64Var(&config.MaximumHeapSizeBytes)
+	bootstrap.Flag("overload-shrink-heap-threshold", "Fraction of the maximum heap size above which Envoy shrinks its heap.").Default("0.95").Float64Var(&config.ShrinkHeapThreshold)
+	bootstrap.Flag("overload-stop-accepting-threshold", "Fraction of the maximum heap size above which Envoy stops accepting requests and connections.").Default("0.98").Float64Var(&config.StopAcceptingRequestsThreshold)
+	bootstrap.Flag("overload-max-downstream-connections", "Maximum number of active downstream connections across all Envoy listeners. Zero means unlimited.").Uint64Var(&config.MaximumDownstreamConnections)
+	bootstrap.Flag("statsd-address", "IP:port of a statsd server to send Envoy stats to over UDP.").StringVar(&config.StatsdAddress)
+	bootstrap.Flag("dogstatsd-address", "IP:port of a DogStatsD server to send Envoy stats to over UDP.").StringVar(&config.DogStatsdAddress)
+	bootstrap.Flag("stats-prefix", "Prefix for the names of stats sent to the statsd and DogStatsD servers.").StringVar(&config.StatsPrefix)
+	bootstrap.Flag("stats-flush-interval", "How often Envoy flushes stats to the stats sinks.").DurationVar(&config.StatsFlushInterval)
+	bootstrap.Flag("stats-inclusion-regex", "Regular expression for the names of Envoy stats to create. May be repeated; if unset, all stats are created.").StringsVar(&config.StatsInclusionRegexes)
+	bootstrap.Flag("admin-listener-address", "Address of the listener exposing allowlisted Envoy admin paths.").Default("127.0.0.1").StringVar(&config.AdminListenerAddress)
+	bootstrap.Flag("admin-listener-port", "Port of the listener exposing allowlisted Envoy admin paths. If unset, the admin interface is only reachable through its unix domain socket.").IntVar(&config.AdminListenerPort)
+	bootstrap.Flag("admin-listener-path", "Envoy admin path to expose on the admin listener. May be repeated.").StringsVar(&config.AdminListenerPaths)
+	bootstrap.Flag("admin-listener-cert-file", "Certificate filename for serving the admin listener over TLS.").StringVar(&config.AdminListenerCertFile)
+	bootstrap.Flag("admin-listener-key-file", "Key filename for serving the admin listener over TLS.").StringVar(&config.AdminListenerKeyFile)
+	bootstrap.Flag("admin-listener-cafile", "CA filename for verifying client certificates presented to the admin listener.").StringVar(&config.AdminListenerCAFile)
 	return bootstrap, &config
 }
+
+// fileMode is a kingpin.Value that parses an octal file mode.
+type fileMode os.FileMode
+
+func (m *fileMode) Set(s string) error {
+	v, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || os.FileMode(v)&^os.ModePerm != 0 {
+		return fmt.Errorf("invalid file mode %q", s)
+	}
+	*m = fileMode(v)
+	return nil
+}
+
+func (m *fileMode) String() string {
+	return fmt.Sprintf("%#o", uint32(*m))
+}
@@ -0,0 +1,232 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/projectcontour/contour/internal/debug"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// bundleContext holds the parameters for the
+// "contour debug bundle" command.
+type bundleContext struct {
+	// debugAddr is the base URL of Contour's debug service,
+	// which serves the profiles, the DAG and the configuration.
+	debugAddr  string
+	debugToken string
+
+	// metricsAddr is the base URL of Contour's metrics service.
+	metricsAddr  string
+	metricsToken string
+
+	// includeXDS is whether the xDS resources are fetched, with
+	// the parameters of "contour envoy config".
+	includeXDS bool
+	xds        envoyConfigContext
+
+	// cpuProfile is how long the CPU is profiled for. If zero,
+	// no CPU profile is collected.
+	cpuProfile time.Duration
+
+	// output is the path of the archive. If empty, the archive
+	// is named after the time it is collected at.
+	output string
+}
+
+// bundleFile is a file of the bundle, and how to collect it.
+type bundleFile struct {
+	name    string
+	collect func() ([]byte, error)
+}
+
+func registerBundle(cmd *kingpin.CmdClause) (*kingpin.CmdClause, *bundleContext) {
+	ctx := &bundleContext{}
+
+	bundle := cmd.Command("bundle", "Collect profiles, the DAG, the xDS resources, the metrics and the configuration of a running Contour into an archive.")
+	bundle.Flag("debug-address", "Contour debug service URL.").Default("http://127.0.0.1:6060").StringVar(&ctx.debugAddr)
+	bundle.Flag("debug-token", "Bearer token for the Contour debug service.").Envar("CLI_DEBUG_TOKEN").StringVar(&ctx.debugToken)
+	bundle.Flag("metrics-address", "Contour metrics service URL.").Default("http://127.0.0.1:8000").StringVar(&ctx.metricsAddr)
+	bundle.Flag("metrics-token", "Bearer token for the Contour metrics service.").Envar("CLI_METRICS_TOKEN").StringVar(&ctx.metricsToken)
+	bundle.Flag("xds", "Fetch the xDS resources Contour serves. Use --no-xds to skip them.").Default("true").BoolVar(&ctx.includeXDS)
+	bundle.Flag("contour", "Contour xDS server host:port.").Default("127.0.0.1:8001").StringVar(&ctx.xds.ContourAddr)
+	bundle.Flag("cafile", "CA bundle file for connecting to a TLS-secured Contour.").Envar("CLI_CAFILE").StringVar(&ctx.xds.CAFile)
+	bundle.Flag("cert-file", "Client certificate file for connecting to a TLS-secured Contour.").Envar("CLI_CERT_FILE").StringVar(&ctx.xds.ClientCert)
+	bundle.Flag("key-file", "Client key file for connecting to a TLS-secured Contour.").Envar("CLI_KEY_FILE").StringVar(&ctx.xds.ClientKey)
+	bundle.Flag("node-id", "Envoy node ID to request resources as.").Default("contour-cli").StringVar(&ctx.xds.nodeID)
+	bundle.Flag("timeout", "Time to wait for each xDS resource type.").Default("10s").DurationVar(&ctx.xds.timeout)
+	bundle.Flag("cpu-profile", "How long to profile the CPU for. Zero skips the CPU profile.").Default("10s").DurationVar(&ctx.cpuProfile)
+	bundle.Flag("output", "Path of the archive to write.").Short('o').PlaceHolder("contour-bundle-<time>.tar.gz").StringVar(&ctx.output)
+
+	return bundle, ctx
+}
+
+// run collects the bundle and writes it to an archive, whose path it
+// returns. Files that cannot be collected are listed in errors.txt of
+// the archive, and returned, rather than failing the whole bundle.
+func (ctx *bundleContext) run(now time.Time) (string, []string, error) {
+	output := ctx.output
+	if output == "" {
+		output = "contour-bundle-" + now.UTC().Format("20060102-150405") + ".tar.gz"
+	}
+
+	var failures []string
+	files := map[string][]byte{}
+	for _, f := range ctx.files() {
+		data, err := f.collect()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", f.name, err))
+			continue
+		}
+		files[f.name] = data
+	}
+
+	if ctx.includeXDS {
+		xds, err := ctx.collectXDS()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("xds: %v", err))
+		}
+		for name, data := range xds {
+			files[name] = data
+		}
+	}
+
+	if len(failures) > 0 {
+		files["errors.txt"] = []byte(strings.Join(failures, "\n") + "\n")
+	}
+
+	if err := writeBundle(output, now, files); err != nil {
+		return "", nil, err
+	}
+	return output, failures, nil
+}
+
+// files returns the files collected over HTTP.
+func (ctx *bundleContext) files() []bundleFile {
+	files := []bundleFile{
+		{"config.json", ctx.get(ctx.debugAddr, ctx.debugToken, debug.ConfigPath)},
+		{"dag.dot", ctx.get(ctx.debugAddr, ctx.debugToken, "/debug/dag")},
+		{"metrics.txt", ctx.get(ctx.metricsAddr, ctx.metricsToken, "/metrics")},
+		{"pprof/heap.pb.gz", ctx.get(ctx.debugAddr, ctx.debugToken, "/debug/pprof/heap")},
+		{"pprof/goroutine.txt", ctx.get(ctx.debugAddr, ctx.debugToken, "/debug/pprof/goroutine?debug=2")},
+		{"pprof/block.pb.gz", ctx.get(ctx.debugAddr, ctx.debugToken, "/debug/pprof/block")},
+		{"pprof/threadcreate.pb.gz", ctx.get(ctx.debugAddr, ctx.debugToken, "/debug/pprof/threadcreate")},
+	}
+
+	if ctx.cpuProfile > 0 {
+		files = append(files, bundleFile{
+			"pprof/profile.pb.gz",
+			ctx.get(ctx.debugAddr, ctx.debugToken, fmt.Sprintf("/debug/pprof/profile?seconds=%d", int(ctx.cpuProfile.Seconds()))),
+		})
+	}
+
+	return files
+}
+
+// get returns a function that fetches urlPath from the
+// service at addr, authenticating with token if set.
+func (ctx *bundleContext) get(addr, token, urlPath string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+urlPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		// The CPU profile takes as long as it profiles for.
+		client := http.Client{Timeout: ctx.cpuProfile + 10*time.Second}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+		return body, nil
+	}
+}
+
+// collectXDS returns the xDS resources of each type as a JSON file.
+func (ctx *bundleContext) collectXDS() (map[string][]byte, error) {
+	config, err := ctx.xds.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for typeURL, resources := range config {
+		data, err := json.MarshalIndent(resources, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files["xds/"+path.Base(typeURL)+".json"] = data
+	}
+	return files, nil
+}
+
+// writeBundle writes the files to a gzipped tar archive at output.
+func writeBundle(output string, now time.Time, files map[string][]byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := files[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, buf.Bytes(), 0600)
+}
@@ -0,0 +1,96 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugBundle(t *testing.T) {
+	debugSvc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer debug-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/debug/config", "/debug/dag", "/debug/pprof/heap", "/debug/pprof/goroutine":
+			io.WriteString(w, r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer debugSvc.Close()
+
+	metricsSvc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "contour_is_leader 1\n")
+	}))
+	defer metricsSvc.Close()
+
+	dir, err := ioutil.TempDir("", "bundle_test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := &bundleContext{
+		debugAddr:   debugSvc.URL + "/",
+		debugToken:  "debug-token",
+		metricsAddr: metricsSvc.URL,
+		output:      filepath.Join(dir, "bundle.tar.gz"),
+	}
+
+	output, failures, err := ctx.run(time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, ctx.output, output)
+	assert.Len(t, failures, 2)
+
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+
+	assert.Equal(t, "/debug/config", files["config.json"])
+	assert.Equal(t, "/debug/dag", files["dag.dot"])
+	assert.Equal(t, "contour_is_leader 1\n", files["metrics.txt"])
+	assert.Equal(t, "/debug/pprof/heap", files["pprof/heap.pb.gz"])
+	assert.Equal(t, "/debug/pprof/goroutine", files["pprof/goroutine.txt"])
+	assert.Contains(t, files["errors.txt"], "pprof/block.pb.gz: 404 Not Found")
+	assert.Contains(t, files["errors.txt"], "pprof/threadcreate.pb.gz: 404 Not Found")
+	assert.NotContains(t, files, "pprof/profile.pb.gz")
+}
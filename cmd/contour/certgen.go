@@ -18,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/projectcontour/contour/internal/certgen"
 	"github.com/projectcontour/contour/internal/k8s"
@@ -42,7 +43,13 @@ func registerCertGen(app *kingpin.Application) (*kingpin.CmdClause, *certgenConf
 	certgenApp.Flag("namespace", "Kubernetes namespace, used for Kube objects.").Default(certs.DefaultNamespace).Envar("CONTOUR_NAMESPACE").StringVar(&certgenConfig.Namespace)
 	// NOTE: --certificate-lifetime can be used to accept Duration string once certificate rotation is supported.
 	certgenApp.Flag("certificate-lifetime", "Generated certificate lifetime (in days).").Default(strconv.Itoa(certs.DefaultCertificateLifetime)).UintVar(&certgenConfig.Lifetime)
+	certgenApp.Flag("ca-certificate-lifetime", "Generated CA certificate lifetime (in days). Defaults to the certificate lifetime.").UintVar(&certgenConfig.CALifetime)
+	certgenApp.Flag("key-type", "Type of the generated keys (rsa or ecdsa).").Default(certs.KeyTypeRSA).EnumVar(&certgenConfig.KeyType, certs.KeyTypeRSA, certs.KeyTypeECDSA)
+	certgenApp.Flag("contour-san", "Additional DNS name or IP address for the Contour certificate. May be repeated.").StringsVar(&certgenConfig.ContourSANs)
+	certgenApp.Flag("envoy-san", "Additional DNS name or IP address for the Envoy certificate. May be repeated.").StringsVar(&certgenConfig.EnvoySANs)
 	certgenApp.Flag("overwrite", "Overwrite existing files or Secrets.").BoolVar(&certgenConfig.Overwrite)
+	certgenApp.Flag("rotate", "Renew existing certificates that expire within --renew-before days, preserving the CA.").BoolVar(&certgenConfig.Rotate)
+	certgenApp.Flag("renew-before", "Renew certificates expiring within this many days when rotating.").Default("30").UintVar(&certgenConfig.RenewBefore)
 	certgenApp.Flag("secrets-format", "Specify how to format the generated Kubernetes Secrets.").Default("legacy").StringVar(&certgenConfig.Format)
 
 	certgenApp.Arg("outputdir", "Directory to write output files into (default \"certs\").").Default("certs").StringVar(&certgenConfig.OutputDir)
@@ -77,6 +84,26 @@ type certgenConfig struct {
 	// Lifetime is the number of days for which certificates will be valid.
 	Lifetime uint
 
+	// CALifetime is the number of days for which the CA certificate will be valid.
+	CALifetime uint
+
+	// KeyType is the type of the generated keys (must be "rsa" or "ecdsa").
+	KeyType string
+
+	// ContourSANs are additional Subject Alt Names for the Contour certificate.
+	ContourSANs []string
+
+	// EnvoySANs are additional Subject Alt Names for the Envoy certificate.
+	EnvoySANs []string
+
+	// Rotate means that existing certificates are renewed when they are close
+	// to expiry, rather than generated unconditionally.
+	Rotate bool
+
+	// RenewBefore is the number of days before expiry at which certificates
+	// are renewed when rotating.
+	RenewBefore uint
+
 	// Overwrite allows certgen to overwrite any existing files or Kubernetes Secrets.
 	Overwrite bool
 
@@ -92,6 +119,14 @@ func OutputCerts(config *certgenConfig, kubeclient *kubernetes.Clientset, certs
 		force = certgen.Overwrite
 	}
 
+	if !config.Rotate {
+		// The CA key is only kept when rotating, which
+		// needs it to sign the renewed certificates.
+		withoutCAKey := *certs
+		withoutCAKey.CAPrivateKey = nil
+		certs = &withoutCAKey
+	}
+
 	if config.OutputYAML || config.OutputKube {
 		switch config.Format {
 		case "legacy":
@@ -101,6 +136,10 @@ func OutputCerts(config *certgenConfig, kubeclient *kubernetes.Clientset, certs
 		default:
 			return fmt.Errorf("unsupported Secrets format %q", config.Format)
 		}
+
+		if len(certs.CAPrivateKey) > 0 {
+			secrets = append(secrets, certgen.AsCASecret(config.Namespace, certs))
+		}
 	}
 
 	if config.OutputPEM {
@@ -126,21 +165,59 @@ func OutputCerts(config *certgenConfig, kubeclient *kubernetes.Clientset, certs
 	return nil
 }
 
-func doCertgen(config *certgenConfig, log logrus.FieldLogger) {
-	generatedCerts, err := certs.GenerateCerts(
-		&certs.Configuration{
-			Lifetime:  config.Lifetime,
-			Namespace: config.Namespace,
-		})
-	if err != nil {
-		log.WithError(err).Fatal("failed to generate certificates")
+// readCerts reads the existing certificates from the configured output.
+func readCerts(config *certgenConfig, kubeclient *kubernetes.Clientset) (*certs.Certificates, error) {
+	switch {
+	case config.OutputKube:
+		return certgen.ReadCertsKube(kubeclient, config.Namespace)
+	case config.OutputPEM:
+		return certgen.ReadCertsPEM(config.OutputDir)
+	default:
+		return nil, fmt.Errorf("--rotate requires --kube or --pem")
 	}
+}
 
+func doCertgen(config *certgenConfig, log logrus.FieldLogger) {
 	coreClient, err := k8s.NewCoreClient(config.KubeConfig, config.InCluster)
 	if err != nil {
 		log.WithError(err).Fatalf("failed to create Kubernetes client")
 	}
 
+	certConfig := &certs.Configuration{
+		Lifetime:    config.Lifetime,
+		CALifetime:  config.CALifetime,
+		KeyType:     config.KeyType,
+		Namespace:   config.Namespace,
+		ContourSANs: config.ContourSANs,
+		EnvoySANs:   config.EnvoySANs,
+	}
+
+	var generatedCerts *certs.Certificates
+	if config.Rotate {
+		existing, err := readCerts(config, coreClient)
+		if err != nil {
+			log.WithError(err).Fatal("failed to read existing certificates")
+		}
+
+		var renewed bool
+		generatedCerts, renewed, err = certs.RotateCerts(certConfig, existing, 24*time.Duration(config.RenewBefore)*time.Hour)
+		if err != nil {
+			log.WithError(err).Fatal("failed to rotate certificates")
+		}
+		if !renewed {
+			log.Info("certificates are not due for renewal")
+			return
+		}
+
+		// Renewed certificates always replace the existing ones.
+		config.Overwrite = true
+	} else {
+		generatedCerts, err = certs.GenerateCerts(certConfig)
+		if err != nil {
+			log.WithError(err).Fatal("failed to generate certificates")
+		}
+	}
+
 	if oerr := OutputCerts(config, coreClient, generatedCerts); oerr != nil {
 		log.WithError(oerr).Fatalf("failed output certificates")
 	}
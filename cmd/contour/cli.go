@@ -25,6 +25,7 @@ import (
 	envoy_service_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
 	envoy_service_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
 	envoy_service_route_v3 "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	envoy_service_secret_v3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
 	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -112,6 +113,13 @@ func (c *Client) RouteStream() envoy_service_route_v3.RouteDiscoveryService_Stre
 	return stream
 }
 
+// SecretStream returns a stream of Secrets using the config in the Client.
+func (c *Client) SecretStream() envoy_service_secret_v3.SecretDiscoveryService_StreamSecretsClient {
+	stream, err := envoy_service_secret_v3.NewSecretDiscoveryServiceClient(c.dial()).StreamSecrets(context.Background())
+	kingpin.FatalIfError(err, "failed to fetch stream of Secrets")
+	return stream
+}
+
 type stream interface {
 	Send(*envoy_discovery_v3.DiscoveryRequest) error
 	Recv() (*envoy_discovery_v3.DiscoveryResponse, error)
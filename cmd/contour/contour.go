@@ -14,7 +14,9 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/projectcontour/contour/internal/build"
@@ -40,6 +42,9 @@ func main() {
 	// Add a "shutdown" command which initiates an Envoy shutdown sequence.
 	sdmShutdown, sdmShutdownCtx := registerShutdown(envoyCmd, log)
 
+	// Add a "config" command which fetches the xDS resources served to an Envoy node.
+	envoyConfig, envoyConfigCtx := registerEnvoyConfig(envoyCmd)
+
 	certgenApp, certgenConfig := registerCertGen(app)
 
 	cli := app.Command("cli", "A CLI client for the Contour Kubernetes ingress controller.")
@@ -60,6 +65,12 @@ func main() {
 	rds.Arg("resources", "RDS resource filter").StringsVar(&resources)
 	sds := cli.Command("sds", "Watch secrets.")
 	sds.Arg("resources", "SDS resource filter").StringsVar(&resources)
+	logLevel, logLevelCtx := registerLogLevel(cli)
+
+	debugCmd := app.Command("debug", "Sub-command for debugging Contour.")
+	why, whyCtx := registerWhy(debugCmd)
+	ignored, ignoredCtx := registerIgnored(debugCmd)
+	bundle, bundleCtx := registerBundle(debugCmd)
 
 	serve, serveCtx := registerServe(app)
 	version := app.Command("version", "Build information for Contour.")
@@ -70,6 +81,12 @@ func main() {
 		doShutdownManager(shutdownManagerCtx)
 	case sdmShutdown.FullCommand():
 		sdmShutdownCtx.shutdownHandler()
+	case envoyConfig.FullCommand():
+		differences, err := envoyConfigCtx.run(os.Stdout)
+		kingpin.FatalIfError(err, "failed to fetch Envoy configuration")
+		if differences > 0 {
+			os.Exit(1)
+		}
 	case bootstrap.FullCommand():
 		if err := bootstrapCtx.XDSResourceVersion.Validate(); err != nil {
 			log.WithError(err).Fatal("failed to parse bootstrap args")
@@ -80,6 +97,12 @@ func main() {
 		if err := envoy_v3.WriteBootstrap(bootstrapCtx); err != nil {
 			log.WithError(err).Fatal("failed to write bootstrap configuration")
 		}
+		if bootstrapCtx.SDSRefreshInterval > 0 {
+			log.WithField("interval", bootstrapCtx.SDSRefreshInterval).Info("watching xDS certificate files for changes")
+			if err := envoy_v3.WatchSDSResources(bootstrapCtx, make(chan struct{})); err != nil {
+				log.WithError(err).Fatal("failed to write SDS resources")
+			}
+		}
 	case certgenApp.FullCommand():
 		doCertgen(certgenConfig, log)
 	case cds.FullCommand():
@@ -95,8 +118,27 @@ func main() {
 		stream := client.RouteStream()
 		watchstream(stream, resource_v3.RouteType, resources)
 	case sds.FullCommand():
-		stream := client.RouteStream()
+		stream := client.SecretStream()
 		watchstream(stream, resource_v3.SecretType, resources)
+	case logLevel.FullCommand():
+		level, err := logLevelCtx.run()
+		kingpin.FatalIfError(err, "failed to access log level")
+		fmt.Println(level)
+	case why.FullCommand():
+		report, err := whyCtx.run()
+		kingpin.FatalIfError(err, "failed to explain routing")
+		printWhyReport(os.Stdout, report)
+	case ignored.FullCommand():
+		report, err := ignoredCtx.run()
+		kingpin.FatalIfError(err, "failed to report ignored objects")
+		printIgnoredReport(os.Stdout, report)
+	case bundle.FullCommand():
+		output, failures, err := bundleCtx.run(time.Now())
+		kingpin.FatalIfError(err, "failed to write debug bundle")
+		for _, failure := range failures {
+			log.Warnf("not collected: %s", failure)
+		}
+		fmt.Println(output)
 	case serve.FullCommand():
 		// Parse args a second time so cli flags are applied
 		// on top of any values sourced from -c's config file.
@@ -0,0 +1,323 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// envoyConfigTypes are the xDS resource types fetched by
+// "contour envoy config", in the order they are reported.
+var envoyConfigTypes = []string{
+	resource_v3.ListenerType,
+	resource_v3.RouteType,
+	resource_v3.ClusterType,
+	resource_v3.EndpointType,
+	resource_v3.SecretType,
+}
+
+// envoyConfigContext holds the parameters for the
+// "contour envoy config" command.
+type envoyConfigContext struct {
+	Client
+
+	// nodeID is the Envoy node ID presented to the xDS server.
+	nodeID string
+
+	// adminAddress, if set, is the Envoy admin interface whose
+	// config_dump is compared against the xDS resources.
+	adminAddress string
+
+	// timeout bounds the time spent waiting for each resource type.
+	timeout time.Duration
+}
+
+func registerEnvoyConfig(cmd *kingpin.CmdClause) (*kingpin.CmdClause, *envoyConfigContext) {
+	ctx := &envoyConfigContext{}
+
+	config := cmd.Command("config", "Fetch the xDS resources Contour serves to an Envoy node.")
+	config.Flag("contour", "Contour xDS server host:port.").Default("127.0.0.1:8001").StringVar(&ctx.ContourAddr)
+	config.Flag("cafile", "CA bundle file for connecting to a TLS-secured Contour.").Envar("CLI_CAFILE").StringVar(&ctx.CAFile)
+	config.Flag("cert-file", "Client certificate file for connecting to a TLS-secured Contour.").Envar("CLI_CERT_FILE").StringVar(&ctx.ClientCert)
+	config.Flag("key-file", "Client key file for connecting to a TLS-secured Contour.").Envar("CLI_KEY_FILE").StringVar(&ctx.ClientKey)
+	config.Flag("node-id", "Envoy node ID to request resources as.").Default("contour-cli").StringVar(&ctx.nodeID)
+	config.Flag("envoy-admin-address", "Envoy admin interface (host:port or unix socket path) to diff the resources against.").PlaceHolder("/admin/admin.sock").StringVar(&ctx.adminAddress)
+	config.Flag("timeout", "Time to wait for each resource type.").Default("10s").DurationVar(&ctx.timeout)
+
+	return config, ctx
+}
+
+// fetch requests every resource type from the xDS server and
+// returns the resources, as JSON objects, keyed by type URL.
+func (ctx *envoyConfigContext) fetch() (map[string][]json.RawMessage, error) {
+	streams := map[string]func() stream{
+		resource_v3.ClusterType:  func() stream { return ctx.ClusterStream() },
+		resource_v3.EndpointType: func() stream { return ctx.EndpointStream() },
+		resource_v3.ListenerType: func() stream { return ctx.ListenerStream() },
+		resource_v3.RouteType:    func() stream { return ctx.RouteStream() },
+		resource_v3.SecretType:   func() stream { return ctx.SecretStream() },
+	}
+
+	m := protojson.MarshalOptions{UseProtoNames: true}
+	config := map[string][]json.RawMessage{}
+
+	for _, typeURL := range envoyConfigTypes {
+		resp, err := recvWithTimeout(streams[typeURL](), &envoy_discovery_v3.DiscoveryRequest{
+			Node:    &envoy_core_v3.Node{Id: ctx.nodeID},
+			TypeUrl: typeURL,
+		}, ctx.timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", typeURL, err)
+		}
+
+		resources := []json.RawMessage{}
+		for _, r := range resp.GetResources() {
+			msg, err := r.UnmarshalNew()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", r.GetTypeUrl(), err)
+			}
+			data, err := m.Marshal(msg)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, data)
+		}
+		config[typeURL] = resources
+	}
+
+	return config, nil
+}
+
+func recvWithTimeout(st stream, req *envoy_discovery_v3.DiscoveryRequest, timeout time.Duration) (*envoy_discovery_v3.DiscoveryResponse, error) {
+	if err := st.Send(req); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		resp *envoy_discovery_v3.DiscoveryResponse
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := st.Recv()
+		ch <- result{resp, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.resp, res.err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// configDump fetches the dynamic resources from the Envoy admin
+// config_dump, decoded as generic JSON objects keyed by type URL.
+func (ctx *envoyConfigContext) configDump() (map[string][]interface{}, error) {
+	client := http.Client{Timeout: ctx.timeout}
+	url := "http://" + ctx.adminAddress + "/config_dump?include_eds"
+
+	if strings.HasPrefix(ctx.adminAddress, "/") {
+		address := ctx.adminAddress
+		client.Transport = &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", address)
+			},
+		}
+		url = "http://unix/config_dump?include_eds"
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from Envoy admin: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseConfigDump(data)
+}
+
+// configDumpPaths locates the dynamic resources for each xDS type
+// within an Envoy admin config_dump: the field holding the list of
+// resources, and the path to each resource within a list entry.
+var configDumpPaths = map[string]struct {
+	list     string
+	resource []string
+}{
+	resource_v3.ClusterType:  {"dynamic_active_clusters", []string{"cluster"}},
+	resource_v3.EndpointType: {"dynamic_endpoint_configs", []string{"endpoint_config"}},
+	resource_v3.ListenerType: {"dynamic_listeners", []string{"active_state", "listener"}},
+	resource_v3.RouteType:    {"dynamic_route_configs", []string{"route_config"}},
+	resource_v3.SecretType:   {"dynamic_active_secrets", []string{"secret"}},
+}
+
+func parseConfigDump(data []byte) (map[string][]interface{}, error) {
+	var dump struct {
+		Configs []map[string]interface{} `json:"configs"`
+	}
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, fmt.Errorf("failed to decode config_dump: %w", err)
+	}
+
+	resources := map[string][]interface{}{}
+	for _, config := range dump.Configs {
+		for typeURL, path := range configDumpPaths {
+			list, ok := config[path.list].([]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, entry := range list {
+				var obj interface{} = entry
+				for _, field := range path.resource {
+					m, ok := obj.(map[string]interface{})
+					if !ok {
+						obj = nil
+						break
+					}
+					obj = m[field]
+				}
+				if m, ok := obj.(map[string]interface{}); ok {
+					// Envoy reports resources as expanded Anys.
+					delete(m, "@type")
+					resources[typeURL] = append(resources[typeURL], m)
+				}
+			}
+		}
+	}
+
+	return resources, nil
+}
+
+// resourceName returns the name of a decoded xDS resource.
+func resourceName(typeURL string, obj interface{}) string {
+	m, ok := obj.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	key := "name"
+	if typeURL == resource_v3.EndpointType {
+		key = "cluster_name"
+	}
+
+	name, _ := m[key].(string)
+	return name
+}
+
+// diffConfig writes the differences between the resources served
+// by Contour and those reported by Envoy, returning the number of
+// differences found. Envoy redacts the private keys of secrets in
+// its config_dump, so secrets are only compared by name.
+func diffConfig(w io.Writer, contour map[string][]json.RawMessage, envoy map[string][]interface{}) (int, error) {
+	differences := 0
+
+	for _, typeURL := range envoyConfigTypes {
+		kind := typeURL[strings.LastIndex(typeURL, ".")+1:]
+
+		want := map[string]interface{}{}
+		for _, raw := range contour[typeURL] {
+			var obj interface{}
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return differences, err
+			}
+			want[resourceName(typeURL, obj)] = obj
+		}
+
+		got := map[string]interface{}{}
+		for _, obj := range envoy[typeURL] {
+			got[resourceName(typeURL, obj)] = obj
+		}
+
+		var names []string
+		for name := range want {
+			names = append(names, name)
+		}
+		for name := range got {
+			if _, ok := want[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			served, inContour := want[name]
+			dumped, inEnvoy := got[name]
+
+			switch {
+			case !inEnvoy:
+				fmt.Fprintf(w, "- %s %q: served by Contour, not present in Envoy\n", kind, name)
+			case !inContour:
+				fmt.Fprintf(w, "+ %s %q: present in Envoy, not served by Contour\n", kind, name)
+			case typeURL != resource_v3.SecretType && !reflect.DeepEqual(served, dumped):
+				fmt.Fprintf(w, "~ %s %q: differs\n", kind, name)
+			default:
+				continue
+			}
+			differences++
+		}
+	}
+
+	return differences, nil
+}
+
+// run fetches the resources and writes them, or the differences
+// to Envoy's configuration, to w.
+func (ctx *envoyConfigContext) run(w io.Writer) (int, error) {
+	config, err := ctx.fetch()
+	if err != nil {
+		return 0, err
+	}
+
+	if ctx.adminAddress == "" {
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return 0, err
+	}
+
+	dump, err := ctx.configDump()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch Envoy config_dump: %w", err)
+	}
+
+	return diffConfig(w, config, dump)
+}
@@ -0,0 +1,113 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvoyConfigDiff(t *testing.T) {
+	dump := []byte(`{
+  "configs": [
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.ClustersConfigDump",
+      "dynamic_active_clusters": [
+        {
+          "version_info": "1",
+          "cluster": {
+            "@type": "type.googleapis.com/envoy.config.cluster.v3.Cluster",
+            "name": "default/kuard/80/da39a3ee5e",
+            "type": "EDS"
+          }
+        },
+        {
+          "version_info": "1",
+          "cluster": {
+            "@type": "type.googleapis.com/envoy.config.cluster.v3.Cluster",
+            "name": "default/stale/80/da39a3ee5e",
+            "type": "EDS"
+          }
+        }
+      ]
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.ListenersConfigDump",
+      "dynamic_listeners": [
+        {
+          "name": "ingress_http",
+          "active_state": {
+            "listener": {
+              "@type": "type.googleapis.com/envoy.config.listener.v3.Listener",
+              "name": "ingress_http",
+              "per_connection_buffer_limit_bytes": 32768
+            }
+          }
+        }
+      ]
+    },
+    {
+      "@type": "type.googleapis.com/envoy.admin.v3.SecretsConfigDump",
+      "dynamic_active_secrets": [
+        {
+          "name": "default/secret/68621186db",
+          "version_info": "1",
+          "secret": {
+            "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret",
+            "name": "default/secret/68621186db",
+            "tls_certificate": {
+              "certificate_chain": {"inline_bytes": "Y2VydA=="},
+              "private_key": {"inline_bytes": "W3JlZGFjdGVkXQ=="}
+            }
+          }
+        }
+      ]
+    }
+  ]
+}`)
+
+	envoy, err := parseConfigDump(dump)
+	require.NoError(t, err)
+	assert.Len(t, envoy[resource_v3.ClusterType], 2)
+	assert.Len(t, envoy[resource_v3.ListenerType], 1)
+	assert.Len(t, envoy[resource_v3.SecretType], 1)
+
+	contour := map[string][]json.RawMessage{
+		resource_v3.ClusterType: {
+			json.RawMessage(`{"name": "default/kuard/80/da39a3ee5e", "type": "EDS"}`),
+		},
+		resource_v3.ListenerType: {
+			json.RawMessage(`{"name": "ingress_http", "per_connection_buffer_limit_bytes": 65536}`),
+			json.RawMessage(`{"name": "ingress_https"}`),
+		},
+		// Envoy redacts the private key, so only the name is compared.
+		resource_v3.SecretType: {
+			json.RawMessage(`{"name": "default/secret/68621186db", "tls_certificate": {"certificate_chain": {"inline_bytes": "Y2VydA=="}, "private_key": {"inline_bytes": "a2V5"}}}`),
+		},
+	}
+
+	var out bytes.Buffer
+	differences, err := diffConfig(&out, contour, envoy)
+	require.NoError(t, err)
+	assert.Equal(t, 3, differences)
+	assert.Equal(t, `~ Listener "ingress_http": differs
+- Listener "ingress_https": served by Contour, not present in Envoy
++ Cluster "default/stale/80/da39a3ee5e": present in Envoy, not served by Contour
+`, out.String())
+}
@@ -0,0 +1,121 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/projectcontour/contour/internal/debug"
+	"github.com/projectcontour/contour/pkg/dag"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// ignoredContext holds the parameters for the
+// "contour debug ignored" command.
+type ignoredContext struct {
+	// debugAddr is the base URL of Contour's debug service,
+	// which reports the objects ignored by the live DAG.
+	debugAddr string
+
+	// files are Kubernetes manifests to build the DAG from,
+	// instead of querying a running Contour.
+	files []string
+
+	// The settings of the DAG built from the manifests.
+	ingressClassName string
+	rootNamespaces   []string
+	denyByDefault    bool
+}
+
+func registerIgnored(cmd *kingpin.CmdClause) (*kingpin.CmdClause, *ignoredContext) {
+	ctx := &ignoredContext{}
+
+	ignored := cmd.Command("ignored", "List the Kubernetes objects that Contour does not program.")
+	ignored.Flag("debug-address", "Contour debug service URL.").Default("http://127.0.0.1:6060").StringVar(&ctx.debugAddr)
+	ignored.Flag("file", "Kubernetes manifest to build the DAG from, instead of querying a running Contour. May be repeated.").Short('f').ExistingFilesVar(&ctx.files)
+	ignored.Flag("ingress-class-name", "Ingress class of the objects of the manifests.").StringVar(&ctx.ingressClassName)
+	ignored.Flag("root-namespace", "Namespace where roots of the manifests can be defined. May be repeated.").StringsVar(&ctx.rootNamespaces)
+	ignored.Flag("deny-by-default", "Only program the roots of the manifests in the root namespaces.").BoolVar(&ctx.denyByDefault)
+
+	return ignored, ctx
+}
+
+// run reports the ignored objects of the DAG built from the
+// manifests if any are given, or else of the DAG of the
+// running Contour.
+func (ctx *ignoredContext) run() (*debug.IgnoredReport, error) {
+	if len(ctx.files) > 0 {
+		return ctx.reportOffline()
+	}
+	return ctx.reportLive()
+}
+
+func (ctx *ignoredContext) reportOffline() (*debug.IgnoredReport, error) {
+	var objs []interface{}
+	for _, file := range ctx.files {
+		fileObjs, err := readManifest(file)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, fileObjs...)
+	}
+
+	d := dag.Build(dag.Options{
+		IngressClassName: ctx.ingressClassName,
+		RootNamespaces:   ctx.rootNamespaces,
+		DenyByDefault:    ctx.denyByDefault,
+	}, objs...)
+	return debug.Ignored(d), nil
+}
+
+func (ctx *ignoredContext) reportLive() (*debug.IgnoredReport, error) {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(ctx.debugAddr, "/") + debug.IgnoredPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var report debug.IgnoredReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// printIgnoredReport writes a report in a form for people to read.
+func printIgnoredReport(w io.Writer, report *debug.IgnoredReport) {
+	if len(report.Objects) == 0 {
+		fmt.Fprintln(w, "No objects are ignored.")
+		return
+	}
+	for _, o := range report.Objects {
+		fmt.Fprintf(w, "%s %s/%s: %s\n", o.Kind, o.Namespace, o.Name, o.Reason)
+	}
+}
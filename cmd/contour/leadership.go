@@ -18,6 +18,7 @@ import (
 	"os"
 
 	"github.com/google/uuid"
+	"github.com/projectcontour/contour/internal/leadership"
 	"github.com/projectcontour/contour/internal/workgroup"
 	"github.com/projectcontour/contour/pkg/config"
 	"github.com/sirupsen/logrus"
@@ -26,8 +27,9 @@ import (
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
-func disableLeaderElection(log logrus.FieldLogger) chan struct{} {
+func disableLeaderElection(log logrus.FieldLogger, status *leadership.Status) chan struct{} {
 	log.Info("Leader election disabled")
+	status.Disable()
 
 	leader := make(chan struct{})
 	close(leader)
@@ -37,13 +39,15 @@ func disableLeaderElection(log logrus.FieldLogger) chan struct{} {
 // setupLeadershipElection registers leadership workers with the group and returns
 // a channel which will become ready when this process becomes the leader, or, in the
 // event that leadership election is disabled, the channel will be ready immediately.
+// The progress of the election is recorded in status.
 func setupLeadershipElection(
 	g *workgroup.Group,
 	log logrus.FieldLogger,
 	conf config.LeaderElectionParameters,
 	client *kubernetes.Clientset, updateNow func(),
+	status *leadership.Status,
 ) chan struct{} {
-	le, leader, deposed := newLeaderElector(log, conf, client)
+	le, leader, deposed := newLeaderElector(log, conf, client, status)
 
 	g.AddContext(func(electionCtx context.Context) error {
 		log.WithFields(logrus.Fields{
@@ -87,6 +91,7 @@ func newLeaderElector(
 	log logrus.FieldLogger,
 	conf config.LeaderElectionParameters,
 	client *kubernetes.Clientset,
+	status *leadership.Status,
 ) (*leaderelection.LeaderElector, chan struct{}, chan struct{}) {
 	log = log.WithField("context", "leaderelection")
 	// leaderOK will block gRPC startup until it's closed.
@@ -109,18 +114,22 @@ func newLeaderElector(
 					"lock":     rl.Describe(),
 					"identity": rl.Identity(),
 				}).Info("elected leader")
+				status.StartedLeading()
 				close(leaderOK)
 			},
 			OnStoppedLeading: func() {
+				status.StoppedLeading()
 				// The context being canceled will trigger a handler that will
 				// deal with being deposed.
 				close(deposed)
 			},
+			OnNewLeader: status.NewLeader,
 		},
 	})
 	if err != nil {
 		log.WithError(err).Fatal("failed to create leader elector")
 	}
+	status.Enable(rl.Identity(), le)
 	return le, leaderOK, deposed
 }
 
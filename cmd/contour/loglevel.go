@@ -0,0 +1,92 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/projectcontour/contour/internal/debug"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// logLevelContext holds the parameters for the
+// "contour cli log-level" command.
+type logLevelContext struct {
+	// debugAddr is the base URL of Contour's debug service.
+	debugAddr string
+
+	// token is the bearer token presented to the debug service.
+	token string
+
+	// level is the new log level. If empty, the current
+	// level is reported and left unchanged.
+	level string
+}
+
+func registerLogLevel(cmd *kingpin.CmdClause) (*kingpin.CmdClause, *logLevelContext) {
+	ctx := &logLevelContext{}
+
+	loglevel := cmd.Command("log-level", "Get or set the log level of a running Contour.")
+	loglevel.Flag("debug-address", "Contour debug service URL.").Default("http://127.0.0.1:6060").StringVar(&ctx.debugAddr)
+	loglevel.Flag("token", "Bearer token for the Contour debug service.").Envar("CONTOUR_CLI_LOG_LEVEL_TOKEN").StringVar(&ctx.token)
+	loglevel.Arg("level", "New log level (trace, debug, info, warning, error).").StringVar(&ctx.level)
+
+	return loglevel, ctx
+}
+
+// run queries or updates the log level, returning the
+// level reported by Contour.
+func (ctx *logLevelContext) run() (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(ctx.debugAddr, "/") + debug.LogLevelPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid debug address %q: %w", ctx.debugAddr, err)
+	}
+
+	method := http.MethodGet
+	if ctx.level != "" {
+		method = http.MethodPut
+		u.RawQuery = url.Values{"level": []string{ctx.level}}.Encode()
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if ctx.token != "" {
+		req.Header.Set("Authorization", "Bearer "+ctx.token)
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
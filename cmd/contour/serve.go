@@ -39,8 +39,12 @@ import (
 	"github.com/projectcontour/contour/internal/health"
 	"github.com/projectcontour/contour/internal/httpsvc"
 	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/internal/leadership"
 	"github.com/projectcontour/contour/internal/metrics"
+	"github.com/projectcontour/contour/internal/ratelimit"
+	"github.com/projectcontour/contour/internal/readiness"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/projectcontour/contour/internal/tracing"
 	"github.com/projectcontour/contour/internal/workgroup"
 	"github.com/projectcontour/contour/internal/xds"
 	contour_xds_v3 "github.com/projectcontour/contour/internal/xds/v3"
@@ -50,8 +54,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 	"gopkg.in/alecthomas/kingpin.v2"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -60,6 +66,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
@@ -121,27 +128,45 @@ func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext)
 
 	serve.Flag("incluster", "Use in cluster configuration.").BoolVar(&ctx.Config.InCluster)
 	serve.Flag("kubeconfig", "Path to kubeconfig (if not in running inside a cluster).").PlaceHolder("/path/to/file").StringVar(&ctx.Config.Kubeconfig)
+	serve.Flag("kubernetes-client-qps", "Sustained requests per second to the Kubernetes API server. Negative disables client side rate limiting.").PlaceHolder("<qps>").Float32Var(&ctx.Config.KubernetesClient.QPS)
+	serve.Flag("kubernetes-client-burst", "Requests to the Kubernetes API server allowed above the QPS limit in bursts.").PlaceHolder("<requests>").IntVar(&ctx.Config.KubernetesClient.Burst)
+	serve.Flag("informer-resync-period", "How often informers replay all cached Kubernetes objects.").PlaceHolder("<duration>").DurationVar(&ctx.Config.KubernetesClient.ResyncPeriod)
 
 	serve.Flag("disable-leader-election", "Disable leader election mechanism.").BoolVar(&ctx.DisableLeaderElection)
 	serve.Flag("leader-election-lease-duration", "The duration of the leadership lease.").Default("15s").DurationVar(&ctx.Config.LeaderElection.LeaseDuration)
 	serve.Flag("leader-election-renew-deadline", "The duration leader will retry refreshing leadership before giving up.").Default("10s").DurationVar(&ctx.Config.LeaderElection.RenewDeadline)
 	serve.Flag("leader-election-retry-period", "The interval which Contour will attempt to acquire leadership lease.").Default("2s").DurationVar(&ctx.Config.LeaderElection.RetryPeriod)
 	serve.Flag("leader-election-resource-name", "The name of the resource (ConfigMap) leader election will lease.").Default("leader-elect").StringVar(&ctx.Config.LeaderElection.Name)
+	serve.Flag("leader-election-readiness-timeout", "The duration leader election may be lost before Contour reports itself as not ready. Zero disables the check.").Default("0s").DurationVar(&ctx.Config.LeaderElection.ReadinessTimeout)
+	serve.Flag("readiness-mode", "When Contour reports itself as ready: once serving xDS, or once the first complete xDS snapshot is built.").PlaceHolder("<default|snapshot>").StringVar((*string)(&ctx.Config.Readiness.Mode))
+	serve.Flag("readiness-max-wait", "The duration the snapshot readiness mode waits for the first snapshot before reporting ready regardless. Zero waits indefinitely.").PlaceHolder("<duration>").DurationVar(&ctx.Config.Readiness.MaxWait)
 	serve.Flag("leader-election-resource-namespace", "The namespace of the resource (ConfigMap) leader election will lease.").Default(ctx.Config.LeaderElection.Namespace).StringVar(&ctx.Config.LeaderElection.Namespace)
 
 	serve.Flag("xds-address", "xDS gRPC API address.").PlaceHolder("<ipaddr>").StringVar(&ctx.xdsAddr)
 	serve.Flag("xds-port", "xDS gRPC API port.").PlaceHolder("<port>").IntVar(&ctx.xdsPort)
+	serve.Flag("xds-drift-threshold", "How long an Envoy may take to apply the latest xDS snapshot before it is reported as lagging.").PlaceHolder("<duration>").DurationVar(&ctx.xdsDriftThreshold)
 
 	serve.Flag("stats-address", "Envoy /stats interface address.").PlaceHolder("<ipaddr>").StringVar(&ctx.statsAddr)
 	serve.Flag("stats-port", "Envoy /stats interface port.").PlaceHolder("<port>").IntVar(&ctx.statsPort)
 
 	serve.Flag("debug-http-address", "Address the debug http endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.debugAddr)
 	serve.Flag("debug-http-port", "Port the debug http endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.debugPort)
+	serve.Flag("debug-cafile", "CA bundle file name for verifying client certificates of the debug endpoint.").PlaceHolder("/path/to/file").Envar("CONTOUR_DEBUG_CAFILE").StringVar(&ctx.debugCAFile)
+	serve.Flag("debug-cert-file", "Certificate file name for serving the debug endpoint over TLS.").PlaceHolder("/path/to/file").Envar("CONTOUR_DEBUG_CERT_FILE").StringVar(&ctx.debugCert)
+	serve.Flag("debug-key-file", "Key file name for serving the debug endpoint over TLS.").PlaceHolder("/path/to/file").Envar("CONTOUR_DEBUG_KEY_FILE").StringVar(&ctx.debugKey)
+	serve.Flag("debug-token", "Bearer token required to access the debug endpoint.").Envar("CONTOUR_DEBUG_TOKEN").PlaceHolder("<token>").StringVar(&ctx.debugToken)
 
 	serve.Flag("http-address", "Address the metrics HTTP endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.metricsAddr)
 	serve.Flag("http-port", "Port the metrics HTTP endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.metricsPort)
+	serve.Flag("metrics-cafile", "CA bundle file name for verifying client certificates of the metrics endpoint.").PlaceHolder("/path/to/file").Envar("CONTOUR_METRICS_CAFILE").StringVar(&ctx.metricsCAFile)
+	serve.Flag("metrics-cert-file", "Certificate file name for serving the metrics endpoint over TLS.").PlaceHolder("/path/to/file").Envar("CONTOUR_METRICS_CERT_FILE").StringVar(&ctx.metricsCert)
+	serve.Flag("metrics-key-file", "Key file name for serving the metrics endpoint over TLS.").PlaceHolder("/path/to/file").Envar("CONTOUR_METRICS_KEY_FILE").StringVar(&ctx.metricsKey)
+	serve.Flag("metrics-token", "Bearer token required to access the metrics endpoint.").Envar("CONTOUR_METRICS_TOKEN").PlaceHolder("<token>").StringVar(&ctx.metricsToken)
 	serve.Flag("health-address", "Address the health HTTP endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.healthAddr)
 	serve.Flag("health-port", "Port the health HTTP endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.healthPort)
+	serve.Flag("webhook-address", "Address the conversion webhook HTTPS endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.webhookAddr)
+	serve.Flag("webhook-port", "Port the conversion webhook HTTPS endpoint will bind to. The webhook is disabled if zero.").PlaceHolder("<port>").IntVar(&ctx.webhookPort)
+	serve.Flag("webhook-cert-dir", "Directory containing the tls.crt and tls.key files for serving the conversion webhook.").PlaceHolder("/path/to/dir").StringVar(&ctx.webhookCertDir)
 
 	serve.Flag("contour-cafile", "CA bundle file name for serving gRPC with TLS.").Envar("CONTOUR_CAFILE").StringVar(&ctx.caFile)
 	serve.Flag("contour-cert-file", "Contour certificate file name for serving gRPC over TLS.").PlaceHolder("/path/to/file").Envar("CONTOUR_CERT_FILE").StringVar(&ctx.contourCert)
@@ -164,6 +189,7 @@ func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext)
 	serve.Flag("accesslog-format", "Format for Envoy access logs.").PlaceHolder("<envoy|json>").StringVar((*string)(&ctx.Config.AccessLogFormat))
 
 	serve.Flag("debug", "Enable debug logging.").Short('d').BoolVar(&ctx.Config.Debug)
+	serve.Flag("debug-log-level-token", "Bearer token required to change the log level via the debug service.").Envar("CONTOUR_DEBUG_LOG_LEVEL_TOKEN").PlaceHolder("<token>").StringVar(&ctx.debugLogLevelToken)
 	serve.Flag("kubernetes-debug", "Enable Kubernetes client debug logging with log level.").PlaceHolder("<log level>").UintVar(&ctx.KubernetesDebug)
 	return serve, ctx
 }
@@ -175,6 +201,8 @@ type Server struct {
 	coreClient *kubernetes.Clientset
 	mgr        manager.Manager
 	registry   *prometheus.Registry
+	leadership *leadership.Status
+	readiness  *readiness.Status
 }
 
 // NewServer returns a Server object which contains the initial configuration
@@ -189,6 +217,8 @@ func NewServer(log logrus.FieldLogger, ctx *serveContext) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create REST config for Kubernetes clients: %w", err)
 	}
+	restConfig.QPS = ctx.Config.KubernetesClient.QPS
+	restConfig.Burst = ctx.Config.KubernetesClient.Burst
 
 	coreClient, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
@@ -201,13 +231,28 @@ func NewServer(log logrus.FieldLogger, ctx *serveContext) (*Server, error) {
 	}
 
 	// Instantiate a controller-runtime manager.
-	mgr, err := manager.New(restConfig, manager.Options{
-		Scheme: scheme,
-	})
+	mgrOptions := manager.Options{
+		Scheme:  scheme,
+		Host:    ctx.webhookAddr,
+		Port:    ctx.webhookPort,
+		CertDir: ctx.webhookCertDir,
+	}
+	if resync := ctx.Config.KubernetesClient.ResyncPeriod; resync > 0 {
+		mgrOptions.SyncPeriod = &resync
+	}
+
+	mgr, err := manager.New(restConfig, mgrOptions)
 	if err != nil {
 		return nil, fmt.Errorf("unable to set up controller manager: %w", err)
 	}
 
+	// Serve the conversion webhook for versioned Contour APIs, if
+	// configured. The webhook server is started with the manager,
+	// and converts between the versions registered in the scheme.
+	if ctx.webhookPort != 0 {
+		mgr.GetWebhookServer().Register("/convert", &conversion.Webhook{})
+	}
+
 	// Set up Prometheus registry and register base metrics.
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
@@ -265,10 +310,16 @@ func (s *Server) doServe() error {
 		return s.mgr.Start(signals.SetupSignalHandler())
 	})
 
+	if err := s.setupTracing(contourConfiguration.Tracing); err != nil {
+		return err
+	}
+
 	// informerNamespaces is a list of namespaces that we should start informers for.
 	var informerNamespaces []string
 
-	if len(contourConfiguration.HTTPProxy.RootNamespaces) > 0 {
+	// In deny-by-default mode every namespace is watched, so
+	// that roots can include HTTPProxies in other namespaces.
+	if len(contourConfiguration.HTTPProxy.RootNamespaces) > 0 && !contourConfiguration.HTTPProxy.DenyByDefault {
 		informerNamespaces = append(informerNamespaces, contourConfiguration.HTTPProxy.RootNamespaces...)
 
 		// Add the FallbackCertificateNamespace to informerNamespaces if it isn't present.
@@ -298,13 +349,53 @@ func (s *Server) doServe() error {
 		return err
 	}
 
+	timeoutLimits, err := contourconfig.ParseTimeoutPolicyLimits(contourConfiguration.TimeoutPolicy)
+	if err != nil {
+		return err
+	}
+
+	var defaultLocalRateLimitPolicy *dag.LocalRateLimitPolicy
+	if contourConfiguration.Policy != nil {
+		defaultLocalRateLimitPolicy, err = dag.NewLocalRateLimitPolicy(contourConfiguration.Policy.LocalRateLimitPolicy)
+		if err != nil {
+			return fmt.Errorf("invalid default local rate limit policy: %w", err)
+		}
+	}
+
+	var endpointDrainDelay time.Duration
+	if contourConfiguration.Envoy.Cluster.EndpointDrainDelay != nil {
+		endpointDrainDelay, err = time.ParseDuration(*contourConfiguration.Envoy.Cluster.EndpointDrainDelay)
+		if err != nil {
+			return fmt.Errorf("failed to parse endpoint drain delay: %w", err)
+		}
+	}
+
+	var listenerFiltersTimeout timeout.Setting
+	if contourConfiguration.Envoy.Listener.FilterTimeout != nil {
+		listenerFiltersTimeout, err = timeout.Parse(*contourConfiguration.Envoy.Listener.FilterTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to parse listener filter timeout: %w", err)
+		}
+	}
+
 	accessLogFormatString := ""
 	if contourConfiguration.Envoy.Logging.AccessLogFormatString != nil {
 		accessLogFormatString = *contourConfiguration.Envoy.Logging.AccessLogFormatString
 	}
 
+	var headerLimits dag.HeaderLimits
+	var rejectInvalidRequests bool
+	if hl := contourConfiguration.Envoy.Listener.HeaderLimits; hl != nil {
+		headerLimits = dag.HeaderLimits{
+			MaxRequestHeadersKB:    hl.MaxRequestHeadersKB,
+			MaxRequestHeadersCount: hl.MaxRequestHeadersCount,
+		}
+		rejectInvalidRequests = hl.RejectAction == "reject-request"
+	}
+
 	listenerConfig := xdscache_v3.ListenerConfig{
-		UseProxyProto: contourConfiguration.Envoy.Listener.UseProxyProto,
+		UseProxyProto:     contourConfiguration.Envoy.Listener.UseProxyProto,
+		ProxyProtocolTLVs: proxyProtocolTLVs(contourConfiguration.Envoy.Listener.ProxyProtocol),
 		HTTPListeners: map[string]xdscache_v3.Listener{
 			xdscache_v3.ENVOY_HTTP_LISTENER: {
 				Name:    xdscache_v3.ENVOY_HTTP_LISTENER,
@@ -320,44 +411,102 @@ func (s *Server) doServe() error {
 				Port:    contourConfiguration.Envoy.HTTPSListener.Port,
 			},
 		},
-		HTTPSAccessLog:               contourConfiguration.Envoy.HTTPSListener.AccessLog,
-		AccessLogType:                contourConfiguration.Envoy.Logging.AccessLogFormat,
-		AccessLogFields:              contourConfiguration.Envoy.Logging.AccessLogFields,
-		AccessLogFormatString:        accessLogFormatString,
-		AccessLogFormatterExtensions: AccessLogFormatterExtensions(contourConfiguration.Envoy.Logging.AccessLogFormat, contourConfiguration.Envoy.Logging.AccessLogFields, contourConfiguration.Envoy.Logging.AccessLogFormatString),
-		MinimumTLSVersion:            annotation.MinTLSVersion(contourConfiguration.Envoy.Listener.TLS.MinimumProtocolVersion, "1.2"),
-		CipherSuites:                 config.SanitizeCipherSuites(cipherSuites),
-		Timeouts:                     timeouts,
-		DefaultHTTPVersions:          parseDefaultHTTPVersions(contourConfiguration.Envoy.DefaultHTTPVersions),
-		AllowChunkedLength:           !contourConfiguration.Envoy.Listener.DisableAllowChunkedLength,
-		XffNumTrustedHops:            contourConfiguration.Envoy.Network.XffNumTrustedHops,
-		ConnectionBalancer:           contourConfiguration.Envoy.Listener.ConnectionBalancer,
+		HTTPSAccessLog:                   contourConfiguration.Envoy.HTTPSListener.AccessLog,
+		AccessLogType:                    contourConfiguration.Envoy.Logging.AccessLogFormat,
+		AccessLogFields:                  contourConfiguration.Envoy.Logging.AccessLogFields,
+		AccessLogFormatString:            accessLogFormatString,
+		AccessLogFormatterExtensions:     AccessLogFormatterExtensions(contourConfiguration.Envoy.Logging.AccessLogFormat, contourConfiguration.Envoy.Logging.AccessLogFields, contourConfiguration.Envoy.Logging.AccessLogFormatString),
+		MinimumTLSVersion:                annotation.MinTLSVersion(contourConfiguration.Envoy.Listener.TLS.MinimumProtocolVersion, "1.2"),
+		CipherSuites:                     config.SanitizeCipherSuites(cipherSuites),
+		Timeouts:                         timeouts,
+		DefaultHTTPVersions:              parseDefaultHTTPVersions(contourConfiguration.Envoy.DefaultHTTPVersions),
+		EnableH2C:                        contourConfiguration.Envoy.Listener.EnableH2C,
+		DefaultHostForHTTP10:             contourConfiguration.Envoy.Listener.DefaultHostForHTTP10,
+		PreserveHeaderCase:               contourConfiguration.Envoy.Listener.PreserveHeaderCase,
+		HeaderLimits:                     headerLimits,
+		RejectInvalidRequests:            rejectInvalidRequests,
+		AllowChunkedLength:               !contourConfiguration.Envoy.Listener.DisableAllowChunkedLength,
+		XffNumTrustedHops:                contourConfiguration.Envoy.Network.XffNumTrustedHops,
+		DualStack:                        contourConfiguration.Envoy.Network.DualStack,
+		ConnectionBalancer:               contourConfiguration.Envoy.Listener.ConnectionBalancer,
+		HTTPListenerFilters:              listenerFilters(contourConfiguration.Envoy.Listener.HTTPFilters),
+		HTTPSListenerFilters:             listenerFilters(contourConfiguration.Envoy.Listener.HTTPSFilters),
+		ListenerFiltersTimeout:           listenerFiltersTimeout,
+		ContinueOnListenerFiltersTimeout: contourConfiguration.Envoy.Listener.ContinueOnFilterTimeout,
+		DrainType:                        contourConfiguration.Envoy.Listener.DrainType,
+		SocketOptions:                    listenerSocketOptions(contourConfiguration.Envoy.Listener.SocketOptions),
 	}
 
 	if listenerConfig.RateLimitConfig, err = s.setupRateLimitService(contourConfiguration); err != nil {
 		return err
 	}
 
+	profileListeners, err := listenerProfileCaches(contourConfiguration.Envoy, listenerConfig)
+	if err != nil {
+		return err
+	}
+
 	contourMetrics := metrics.NewMetrics(s.registry)
 
 	// Endpoints updates are handled directly by the EndpointsTranslator
 	// due to their high update rate and their orthogonal nature.
 	endpointHandler := xdscache_v3.NewEndpointsTranslator(s.log.WithField("context", "endpointstranslator"))
+	endpointHandler.DrainDelay = endpointDrainDelay
 
+	// The caches observe each DAG in this order. Endpoints and
+	// clusters come first, so that by the time a listener or route
+	// referring to a new cluster is pushed, the cluster and its load
+	// assignment are ready to be served to Envoy.
 	resources := []xdscache.ResourceCache{
-		xdscache_v3.NewListenerCache(contourConfiguration.Envoy, listenerConfig),
+		endpointHandler,
+		&xdscache_v3.ClusterCache{
+			ConnectTimeout:                 timeoutLimits.ConnectTimeout,
+			PreserveHeaderCase:             contourConfiguration.Envoy.Cluster.PreserveHeaderCase,
+			HealthyPanicThreshold:          contourConfiguration.Envoy.Cluster.HealthyPanicThreshold,
+			ZoneAwareRoutingMinClusterSize: contourConfiguration.Envoy.Cluster.ZoneAwareRoutingMinClusterSize,
+			StaticClusters:                 staticClusters(contourConfiguration.Envoy.Cluster.StaticClusters),
+		},
 		xdscache_v3.NewSecretsCache(envoy_v3.StatsSecrets(contourConfiguration.Envoy.Metrics.TLS)),
 		&xdscache_v3.RouteCache{},
-		&xdscache_v3.ClusterCache{},
-		endpointHandler,
+		xdscache_v3.NewListenerCache(contourConfiguration.Envoy, listenerConfig),
+		xdscache_v3.NewRuntimeCache(xdscache_v3.RuntimeSettings{
+			MaxConnectionsPerListener: contourConfiguration.Envoy.Listener.MaxConnectionsPerListener,
+			GlobalMaxConnections:      contourConfiguration.Envoy.Listener.GlobalMaxConnections,
+		}),
 	}
 
 	// snapshotHandler is used to produce new snapshots when the internal state changes for any xDS resource.
 	snapshotHandler := xdscache.NewSnapshotHandler(resources, s.log.WithField("context", "snapshotHandler"))
+	snapshotHandler.Metrics = contourMetrics
+
+	// With the Envoy xDS server, track the snapshot version
+	// each Envoy has applied, to report those lagging behind.
+	var versionTracker *contour_xds_v3.VersionTracker
+	if contourConfiguration.XDSServer.Type == contour_api_v1alpha1.EnvoyServerType {
+		versionTracker = &contour_xds_v3.VersionTracker{
+			Threshold: s.ctx.xdsDriftThreshold,
+			Metrics:   contourMetrics,
+		}
+		snapshotHandler.AddSnapshotter(versionTracker)
+		s.group.Add(versionTracker.Start)
+	}
+
+	// Track the xDS streams of the connected Envoys, so
+	// that they can be inspected and disconnected.
+	xdsConnections := &xds.Connections{Metrics: contourMetrics}
 
 	// register observer for endpoints updates.
 	endpointHandler.Observer = contour.ComposeObservers(snapshotHandler)
 
+	// The listener caches of the listener profiles observe the DAG
+	// alongside the other resources, so they must come before the
+	// snapshotHandler.
+	observers := xdscache.ObserversOf(resources)
+	for name, listeners := range profileListeners {
+		snapshotHandler.AddProfile(name, listeners)
+		observers = append(observers, listeners)
+	}
+
 	// Log that we're using the fallback certificate if configured.
 	if contourConfiguration.HTTPProxy.FallbackCertificate != nil {
 		s.log.WithField("context", "fallback-certificate").Infof("enabled fallback certificate with secret: %q", contourConfiguration.HTTPProxy.FallbackCertificate)
@@ -380,21 +529,35 @@ func (s *Server) doServe() error {
 		fallbackCert = &types.NamespacedName{Name: contourConfiguration.HTTPProxy.FallbackCertificate.Name, Namespace: contourConfiguration.HTTPProxy.FallbackCertificate.Namespace}
 	}
 
+	// The readiness status brackets the other observers, so that it
+	// only records a snapshot once every xDS cache has seen the DAG.
+	s.readiness = readiness.NewStatus()
+	observers = append([]dag.Observer{dag.ObserverFunc(func(*dag.DAG) { s.readiness.DAGBuilt() })}, observers...)
+	observers = append(observers, snapshotHandler, dag.ObserverFunc(func(*dag.DAG) { s.readiness.SnapshotGenerated() }))
+
 	// Build the core Kubernetes event handler.
 	contourHandler := &contour.EventHandler{
 		HoldoffDelay:    100 * time.Millisecond,
 		HoldoffMaxDelay: 500 * time.Millisecond,
-		Observer:        dag.ComposeObservers(append(xdscache.ObserversOf(resources), snapshotHandler)...),
+		Observer:        dag.ComposeObservers(observers...),
 		Builder: s.getDAGBuilder(dagBuilderConfig{
-			ingressClassName:          ingressClassName,
-			rootNamespaces:            contourConfiguration.HTTPProxy.RootNamespaces,
-			gatewayAPIConfigured:      contourConfiguration.Gateway != nil,
-			disablePermitInsecure:     contourConfiguration.HTTPProxy.DisablePermitInsecure,
-			enableExternalNameService: contourConfiguration.EnableExternalNameService,
-			dnsLookupFamily:           contourConfiguration.Envoy.Cluster.DNSLookupFamily,
-			headersPolicy:             contourConfiguration.Policy,
-			clientCert:                clientCert,
-			fallbackCert:              fallbackCert,
+			ingressClassName:             ingressClassName,
+			rootNamespaces:               contourConfiguration.HTTPProxy.RootNamespaces,
+			denyByDefault:                contourConfiguration.HTTPProxy.DenyByDefault,
+			gatewayAPIConfigured:         contourConfiguration.Gateway != nil,
+			disablePermitInsecure:        contourConfiguration.HTTPProxy.DisablePermitInsecure,
+			permitInsecurePrefixes:       contourConfiguration.HTTPProxy.PermitInsecurePrefixes,
+			enableExternalNameService:    contourConfiguration.EnableExternalNameService,
+			enableStaticEndpoints:        contourConfiguration.EnableStaticEndpoints,
+			dnsLookupFamily:              contourConfiguration.Envoy.Cluster.DNSLookupFamily,
+			insecureSkipVerifyNamespaces: contourConfiguration.Envoy.Cluster.InsecureSkipVerifyNamespaces,
+			headersPolicy:                contourConfiguration.Policy,
+			clientCert:                   clientCert,
+			fallbackCert:                 fallbackCert,
+			acmeChallengeSolver:          contourConfiguration.ACMEChallengeSolver,
+			maxResponseTimeout:           timeoutLimits.MaxResponseTimeout,
+			maxIdleTimeout:               timeoutLimits.MaxIdleTimeout,
+			localRateLimitPolicy:         defaultLocalRateLimitPolicy,
 		}),
 		FieldLogger: s.log.WithField("context", "contourEventHandler"),
 	}
@@ -406,18 +569,20 @@ func (s *Server) doServe() error {
 	}
 
 	// Register leadership election.
+	s.leadership = &leadership.Status{Metrics: contourMetrics}
 	if s.ctx.DisableLeaderElection {
-		contourHandler.IsLeader = disableLeaderElection(s.log)
+		contourHandler.IsLeader = disableLeaderElection(s.log, s.leadership)
 	} else {
-		contourHandler.IsLeader = setupLeadershipElection(&s.group, s.log, s.ctx.Config.LeaderElection, s.coreClient, contourHandler.UpdateNow)
+		contourHandler.IsLeader = setupLeadershipElection(&s.group, s.log, s.ctx.Config.LeaderElection, s.coreClient, contourHandler.UpdateNow, s.leadership)
 	}
 
 	// Start setting up StatusUpdateHandler since we need it in
 	// the Gateway API controllers. Will finish setting it up and
 	// start it later.
 	sh := k8s.StatusUpdateHandler{
-		Log:    s.log.WithField("context", "StatusUpdateHandler"),
-		Client: s.mgr.GetClient(),
+		Log:     s.log.WithField("context", "StatusUpdateHandler"),
+		Client:  s.mgr.GetClient(),
+		Metrics: contourMetrics,
 	}
 
 	// Inform on default resources.
@@ -425,6 +590,8 @@ func (s *Server) doServe() error {
 		"httpproxies":               &contour_api_v1.HTTPProxy{},
 		"tlscertificatedelegations": &contour_api_v1.TLSCertificateDelegation{},
 		"extensionservices":         &contour_api_v1alpha1.ExtensionService{},
+		"trafficweights":            &contour_api_v1alpha1.TrafficWeights{},
+		"contourpolicies":           &contour_api_v1alpha1.ContourPolicy{},
 		"contourconfigurations":     &contour_api_v1alpha1.ContourConfiguration{},
 		"services":                  &corev1.Service{},
 		"ingresses":                 &networking_v1.Ingress{},
@@ -450,17 +617,61 @@ func (s *Server) doServe() error {
 		s.log.WithError(err).WithField("resource", "secrets").Fatal("failed to create informer")
 	}
 
-	// Inform on endpoints.
-	if err := informOnResource(&corev1.Endpoints{}, &contour.EventRecorder{
-		Next:    endpointHandler,
-		Counter: contourMetrics.EventHandlerOperations,
-	}, s.mgr.GetCache()); err != nil {
-		s.log.WithError(err).WithField("resource", "endpoints").Fatal("failed to create informer")
+	// Inform on endpoints, or on endpoint slices if configured.
+	if contourConfiguration.Envoy.Cluster.UseEndpointSlices {
+		if err := informOnResource(&discoveryv1.EndpointSlice{}, &contour.EventRecorder{
+			Next:    endpointHandler,
+			Counter: contourMetrics.EventHandlerOperations,
+		}, s.mgr.GetCache()); err != nil {
+			s.log.WithError(err).WithField("resource", "endpointslices").Fatal("failed to create informer")
+		}
+	} else {
+		if err := informOnResource(&corev1.Endpoints{}, &contour.EventRecorder{
+			Next:    endpointHandler,
+			Counter: contourMetrics.EventHandlerOperations,
+		}, s.mgr.GetCache()); err != nil {
+			s.log.WithError(err).WithField("resource", "endpoints").Fatal("failed to create informer")
+		}
+	}
+
+	// Inform on pods, so that the endpoints of terminating pods
+	// can be drained before their endpoints are updated.
+	if contourConfiguration.Envoy.Cluster.EndpointDrainDelay != nil {
+		if err := informOnResource(&corev1.Pod{}, &contour.EventRecorder{
+			Next:    endpointHandler,
+			Counter: contourMetrics.EventHandlerOperations,
+		}, s.mgr.GetCache()); err != nil {
+			s.log.WithError(err).WithField("resource", "pods").Fatal("failed to create informer")
+		}
+	}
+
+	// Inform on the endpoints of remote clusters, which are added
+	// to those of the Services with the same namespace and name.
+	for _, rc := range contourConfiguration.RemoteClusters {
+		if err := s.setupRemoteCluster(rc, endpointHandler, contourMetrics); err != nil {
+			return err
+		}
 	}
 
 	// Register our event handler with the workgroup.
 	s.group.Add(contourHandler.Start())
 
+	// Rebuild the DAG once the informer caches have synced, so
+	// that readiness does not depend on another object changing.
+	s.group.AddContext(func(taskCtx context.Context) error {
+		log := s.log.WithField("context", "readiness")
+		if !s.mgr.GetCache().WaitForCacheSync(taskCtx) {
+			return nil
+		}
+
+		log.Info("informer caches synced, triggering rebuild")
+		s.readiness.CachesSynced()
+		contourHandler.UpdateNow()
+
+		<-taskCtx.Done()
+		return nil
+	})
+
 	// Create metrics service.
 	s.setupMetrics(contourConfiguration.Metrics, contourConfiguration.Health, s.registry)
 
@@ -468,7 +679,21 @@ func (s *Server) doServe() error {
 	s.setupHealth(contourConfiguration.Health, contourConfiguration.Metrics)
 
 	// Create debug service and register with workgroup.
-	s.setupDebugService(contourConfiguration.Debug, contourHandler)
+	s.setupDebugService(&contourConfiguration, listenerConfig, contourHandler, versionTracker, xdsConnections)
+
+	// Write the rate limit service configuration generated from
+	// each DAG to a ConfigMap, if configured.
+	if rls := contourConfiguration.RateLimitService; rls != nil && rls.ConfigMap != nil {
+		w := ratelimit.NewConfigMapWriter(
+			s.log.WithField("context", "rateLimitConfigMapWriter"),
+			rls.Domain,
+			types.NamespacedName{Namespace: rls.ConfigMap.Namespace, Name: rls.ConfigMap.Name},
+			s.coreClient,
+			contourHandler.IsLeader,
+		)
+		contourHandler.Observer = dag.ComposeObservers(contourHandler.Observer, w)
+		s.group.Add(w.Start)
+	}
 
 	// Once we have the leadership detection channel, we can
 	// push DAG rebuild metrics onto the observer stack.
@@ -476,6 +701,7 @@ func (s *Server) doServe() error {
 		Metrics:      contourMetrics,
 		IsLeader:     contourHandler.IsLeader,
 		NextObserver: contourHandler.Observer,
+		Cache:        &contourHandler.Builder.Source,
 	}
 
 	// Finish setting up the StatusUpdateHandler and
@@ -523,7 +749,7 @@ func (s *Server) doServe() error {
 			Info("Watching Service for Ingress status")
 	}
 
-	s.setupXDSServer(s.mgr, s.registry, contourConfiguration.XDSServer, snapshotHandler, resources)
+	s.setupXDSServer(s.mgr, s.registry, contourConfiguration.XDSServer, listenerProfileHash(contourConfiguration.Envoy.ListenerProfiles), snapshotHandler, resources, versionTracker, xdsConnections)
 
 	// Set up SIGTERM handler for graceful shutdown.
 	s.group.Add(func(stop <-chan struct{}) error {
@@ -542,6 +768,98 @@ func (s *Server) doServe() error {
 	return s.group.Run(context.Background())
 }
 
+// listenerProfileCaches returns a listener cache for each listener
+// profile, holding the listeners of listenerConfig with the profile's
+// overrides applied.
+func listenerProfileCaches(envoyConfig contour_api_v1alpha1.EnvoyConfig, listenerConfig xdscache_v3.ListenerConfig) (map[string]*xdscache_v3.ListenerCache, error) {
+	caches := map[string]*xdscache_v3.ListenerCache{}
+
+	for _, p := range envoyConfig.ListenerProfiles {
+		cfg := listenerConfig
+
+		if p.Timeouts != nil {
+			timeouts, err := contourconfig.ParseTimeoutPolicy(mergeTimeoutParameters(envoyConfig.Timeouts, p.Timeouts))
+			if err != nil {
+				return nil, fmt.Errorf("listener profile %q: %w", p.Name, err)
+			}
+			cfg.Timeouts = timeouts
+		}
+
+		if p.Logging != nil {
+			cfg.AccessLogType = p.Logging.AccessLogFormat
+			cfg.AccessLogFormatString = ""
+			if p.Logging.AccessLogFormatString != nil {
+				cfg.AccessLogFormatString = *p.Logging.AccessLogFormatString
+			}
+			if len(p.Logging.AccessLogFields) > 0 {
+				cfg.AccessLogFields = p.Logging.AccessLogFields
+			}
+			cfg.AccessLogFormatterExtensions = AccessLogFormatterExtensions(cfg.AccessLogType, cfg.AccessLogFields, p.Logging.AccessLogFormatString)
+		}
+
+		if p.HTTPFilters != nil {
+			cfg.HTTPListenerFilters = listenerFilters(p.HTTPFilters)
+		}
+		if p.HTTPSFilters != nil {
+			cfg.HTTPSListenerFilters = listenerFilters(p.HTTPSFilters)
+		}
+
+		caches[p.Name] = xdscache_v3.NewListenerCache(envoyConfig, cfg)
+	}
+
+	return caches, nil
+}
+
+// mergeTimeoutParameters returns the timeouts of base overridden by
+// those set in override.
+func mergeTimeoutParameters(base, override *contour_api_v1alpha1.TimeoutParameters) *contour_api_v1alpha1.TimeoutParameters {
+	merged := &contour_api_v1alpha1.TimeoutParameters{}
+	if base != nil {
+		merged = base.DeepCopy()
+	}
+
+	if override.RequestTimeout != nil {
+		merged.RequestTimeout = override.RequestTimeout
+	}
+	if override.ConnectionIdleTimeout != nil {
+		merged.ConnectionIdleTimeout = override.ConnectionIdleTimeout
+	}
+	if override.StreamIdleTimeout != nil {
+		merged.StreamIdleTimeout = override.StreamIdleTimeout
+	}
+	if override.MaxConnectionDuration != nil {
+		merged.MaxConnectionDuration = override.MaxConnectionDuration
+	}
+	if override.DelayedCloseTimeout != nil {
+		merged.DelayedCloseTimeout = override.DelayedCloseTimeout
+	}
+	if override.ConnectionShutdownGracePeriod != nil {
+		merged.ConnectionShutdownGracePeriod = override.ConnectionShutdownGracePeriod
+	}
+
+	return merged
+}
+
+// listenerProfileHash returns the node hash that binds Envoy nodes
+// to the listener profiles.
+func listenerProfileHash(profiles []contour_api_v1alpha1.ListenerProfile) xds.ProfileHashV3 {
+	hash := xds.ProfileHashV3{
+		NodeIDs:      map[string]string{},
+		NodeClusters: map[string]string{},
+	}
+
+	for _, p := range profiles {
+		for _, id := range p.NodeIDs {
+			hash.NodeIDs[id] = p.Name
+		}
+		for _, cluster := range p.NodeClusters {
+			hash.NodeClusters[cluster] = p.Name
+		}
+	}
+
+	return hash
+}
+
 func (s *Server) setupRateLimitService(contourConfiguration contour_api_v1alpha1.ContourConfigurationSpec) (*xdscache_v3.RateLimitConfig, error) {
 	if contourConfiguration.RateLimitService == nil {
 		return nil, nil
@@ -560,6 +878,12 @@ func (s *Server) setupRateLimitService(contourConfiguration contour_api_v1alpha1
 		return nil, fmt.Errorf("error getting rate limit extension service %s: %v", key, err)
 	}
 
+	for _, stage := range contourConfiguration.RateLimitService.Stages {
+		if stage > config.MaxRateLimitStage {
+			return nil, fmt.Errorf("invalid rate limit stage %d, must be between 0 and %d", stage, config.MaxRateLimitStage)
+		}
+	}
+
 	// get the response timeout from the ExtensionService
 	var responseTimeout timeout.Setting
 	var err error
@@ -577,23 +901,116 @@ func (s *Server) setupRateLimitService(contourConfiguration contour_api_v1alpha1
 		Timeout:                 responseTimeout,
 		FailOpen:                contourConfiguration.RateLimitService.FailOpen,
 		EnableXRateLimitHeaders: contourConfiguration.RateLimitService.EnableXRateLimitHeaders,
+		Stages:                  contourConfiguration.RateLimitService.Stages,
 	}, nil
 }
 
-func (s *Server) setupDebugService(debugConfig contour_api_v1alpha1.DebugConfig, contourHandler *contour.EventHandler) {
+// setupRemoteCluster informs on the Endpoints of a remote cluster,
+// using a cache of its own that is started with the workgroup.
+func (s *Server) setupRemoteCluster(rc contour_api_v1alpha1.RemoteClusterConfig, endpointHandler *xdscache_v3.EndpointsTranslator, contourMetrics *metrics.Metrics) error {
+	restConfig, err := k8s.NewRestConfig(rc.Kubeconfig, false)
+	if err != nil {
+		return fmt.Errorf("failed to create REST config for remote cluster %q: %w", rc.Name, err)
+	}
+	restConfig.QPS = s.ctx.Config.KubernetesClient.QPS
+	restConfig.Burst = s.ctx.Config.KubernetesClient.Burst
+
+	remoteCache, err := ctrl_cache.New(restConfig, ctrl_cache.Options{Scheme: s.mgr.GetScheme()})
+	if err != nil {
+		return fmt.Errorf("failed to create cache for remote cluster %q: %w", rc.Name, err)
+	}
+
+	if err := informOnResource(&corev1.Endpoints{}, &contour.EventRecorder{
+		Next:    endpointHandler.RemoteClusterHandler(rc.Name),
+		Counter: contourMetrics.EventHandlerOperations,
+	}, remoteCache); err != nil {
+		return fmt.Errorf("failed to create endpoints informer for remote cluster %q: %w", rc.Name, err)
+	}
+
+	s.log.WithField("context", "remoteCluster").
+		WithField("cluster", rc.Name).
+		Info("watching endpoints of remote cluster")
+
+	s.group.AddContext(func(taskCtx context.Context) error {
+		return remoteCache.Start(taskCtx)
+	})
+
+	return nil
+}
+
+func (s *Server) setupTracing(tracingConfig *contour_api_v1alpha1.TracingConfig) error {
+	if tracingConfig == nil {
+		return nil
+	}
+
+	samplingPercent := uint32(100)
+	if tracingConfig.SamplingPercent != nil {
+		samplingPercent = *tracingConfig.SamplingPercent
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:        tracingConfig.Endpoint,
+		Insecure:        tracingConfig.Insecure,
+		SamplingPercent: samplingPercent,
+		ServiceName:     tracingConfig.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.WithField("context", "tracing").
+		WithField("endpoint", tracingConfig.Endpoint).
+		WithField("sampling-percent", samplingPercent).
+		Info("exporting control plane traces")
+
+	// Flush any buffered spans when Contour stops.
+	s.group.AddContext(func(taskCtx context.Context) error {
+		<-taskCtx.Done()
+		return shutdown(context.Background())
+	})
+
+	return nil
+}
+
+func (s *Server) setupDebugService(contourConfiguration *contour_api_v1alpha1.ContourConfigurationSpec, listenerConfig xdscache_v3.ListenerConfig, contourHandler *contour.EventHandler, versionTracker *contour_xds_v3.VersionTracker, xdsConnections *xds.Connections) {
+	debugConfig := contourConfiguration.Debug
 	debugsvc := debug.Service{
 		Service: httpsvc.Service{
 			Addr:        debugConfig.Address,
 			Port:        debugConfig.Port,
+			CABundle:    s.ctx.debugCAFile,
+			Cert:        s.ctx.debugCert,
+			Key:         s.ctx.debugKey,
+			Token:       s.ctx.debugToken,
 			FieldLogger: s.log.WithField("context", "debugsvc"),
 		},
-		Builder: &contourHandler.Builder,
+		Builder:       &contourHandler.Builder,
+		LogLevelToken: s.ctx.debugLogLevelToken,
+		Leadership:    s.leadership,
+		Readiness:     s.readiness,
+
+		MinimumTLSVersion: listenerConfig.MinimumTLSVersion,
+		CipherSuites:      listenerConfig.CipherSuites,
+
+		Configuration:  contourConfiguration,
+		XDSConnections: xdsConnections,
+	}
+
+	if versionTracker != nil {
+		debugsvc.XDSLagging = versionTracker
+	}
+
+	// The log level can only be changed at runtime
+	// when we have been given a concrete logger.
+	if logger, ok := s.log.(*logrus.Logger); ok {
+		debugsvc.Logger = logger
 	}
+
 	s.group.Add(debugsvc.Start)
 }
 
 func (s *Server) setupXDSServer(mgr manager.Manager, registry *prometheus.Registry, contourConfiguration contour_api_v1alpha1.XDSServerConfig,
-	snapshotHandler *xdscache.SnapshotHandler, resources []xdscache.ResourceCache) {
+	nodeHash xds.ProfileHashV3, snapshotHandler *xdscache.SnapshotHandler, resources []xdscache.ResourceCache, versionTracker *contour_xds_v3.VersionTracker, xdsConnections *xds.Connections) {
 
 	s.group.AddContext(func(taskCtx context.Context) error {
 		log := s.log.WithField("context", "xds")
@@ -604,13 +1021,28 @@ func (s *Server) setupXDSServer(mgr manager.Manager, registry *prometheus.Regist
 		}
 		log.Printf("informer caches synced")
 
-		grpcServer := xds.NewServer(registry, grpcOptions(log, contourConfiguration.TLS)...)
+		// In snapshot readiness mode, only start listening once the
+		// xDS caches hold a complete configuration, so that Envoy
+		// never receives a partial one and the readiness probe of
+		// the xDS port waits for it too.
+		if s.ctx.Config.Readiness.Mode == config.SnapshotReadinessMode {
+			if err := s.waitForSnapshot(taskCtx, log); err != nil {
+				return err
+			}
+		}
+
+		opts := append(grpcOptions(log, contourConfiguration.TLS), grpc.ChainStreamInterceptor(xdsConnections.StreamInterceptor()))
+		grpcServer := xds.NewServer(registry, opts...)
 
 		switch contourConfiguration.Type {
 		case contour_api_v1alpha1.EnvoyServerType:
-			v3cache := contour_xds_v3.NewSnapshotCache(false, log)
+			v3cache := contour_xds_v3.NewProfileSnapshotCache(false, nodeHash, log)
 			snapshotHandler.AddSnapshotter(v3cache)
-			contour_xds_v3.RegisterServer(envoy_server_v3.NewServer(taskCtx, v3cache, contour_xds_v3.NewRequestLoggingCallbacks(log)), grpcServer)
+			callbacks := contour_xds_v3.NewRequestLoggingCallbacks(log)
+			if versionTracker != nil {
+				callbacks = contour_xds_v3.ComposeCallbacks(callbacks, versionTracker)
+			}
+			contour_xds_v3.RegisterServer(envoy_server_v3.NewServer(taskCtx, v3cache, callbacks), grpcServer)
 		case contour_api_v1alpha1.ContourServerType:
 			contour_xds_v3.RegisterServer(contour_xds_v3.NewContourServer(log, xdscache.ResourcesOf(resources)...), grpcServer)
 		default:
@@ -660,7 +1092,10 @@ func (s *Server) setupMetrics(metricsConfig contour_api_v1alpha1.MetricsConfig,
 		ServeMux:    http.ServeMux{},
 	}
 
-	metricsvc.ServeMux.Handle("/metrics", metrics.Handler(registry))
+	// Only the metrics endpoint requires the token, since the
+	// health endpoints may share the service and are probed by
+	// the kubelet.
+	metricsvc.ServeMux.Handle("/metrics", httpsvc.RequireBearerToken(s.ctx.metricsToken, metrics.Handler(registry)))
 
 	if metricsConfig.TLS != nil {
 		metricsvc.Cert = metricsConfig.TLS.CertFile
@@ -668,15 +1103,61 @@ func (s *Server) setupMetrics(metricsConfig contour_api_v1alpha1.MetricsConfig,
 		metricsvc.CABundle = metricsConfig.TLS.CAFile
 	}
 
+	if s.ctx.metricsCert != "" || s.ctx.metricsKey != "" || s.ctx.metricsCAFile != "" {
+		metricsvc.Cert = s.ctx.metricsCert
+		metricsvc.Key = s.ctx.metricsKey
+		metricsvc.CABundle = s.ctx.metricsCAFile
+	}
+
 	if healthConfig.Address == metricsConfig.Address && healthConfig.Port == metricsConfig.Port {
-		h := health.Handler(s.coreClient)
+		h := health.Handler(s.coreClient, s.leadershipCheck)
 		metricsvc.ServeMux.Handle("/health", h)
 		metricsvc.ServeMux.Handle("/healthz", h)
+		metricsvc.ServeMux.Handle("/ready", health.Handler(s.coreClient, s.leadershipCheck, s.readinessCheck))
 	}
 
 	s.group.Add(metricsvc.Start)
 }
 
+// waitForSnapshot blocks until the first complete xDS snapshot has
+// been generated, or the configured maximum wait has passed.
+func (s *Server) waitForSnapshot(ctx context.Context, log logrus.FieldLogger) error {
+	log.Printf("waiting for first xDS snapshot")
+
+	var timeout <-chan time.Time
+	if maxWait := s.ctx.Config.Readiness.MaxWait; maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-s.readiness.Ready():
+		log.Printf("first xDS snapshot generated")
+	case <-timeout:
+		log.WithField("stage", s.readiness.Info().Stage).Warn("timed out waiting for first xDS snapshot")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// leadershipCheck fails the health endpoints once leader
+// election has been lost for longer than the configured
+// readiness timeout.
+func (s *Server) leadershipCheck() error {
+	return s.leadership.Check(s.ctx.Config.LeaderElection.ReadinessTimeout)
+}
+
+// readinessCheck fails the /ready endpoint until the first complete
+// xDS snapshot has been generated, in snapshot readiness mode.
+func (s *Server) readinessCheck() error {
+	if s.ctx.Config.Readiness.Mode != config.SnapshotReadinessMode {
+		return nil
+	}
+	return s.readiness.Check(s.ctx.Config.Readiness.MaxWait)
+}
+
 func (s *Server) setupHealth(healthConfig contour_api_v1alpha1.HealthConfig,
 	metricsConfig contour_api_v1alpha1.MetricsConfig) {
 
@@ -687,9 +1168,10 @@ func (s *Server) setupHealth(healthConfig contour_api_v1alpha1.HealthConfig,
 			FieldLogger: s.log.WithField("context", "healthsvc"),
 		}
 
-		h := health.Handler(s.coreClient)
+		h := health.Handler(s.coreClient, s.leadershipCheck)
 		healthsvc.ServeMux.Handle("/health", h)
 		healthsvc.ServeMux.Handle("/healthz", h)
+		healthsvc.ServeMux.Handle("/ready", health.Handler(s.coreClient, s.leadershipCheck, s.readinessCheck))
 
 		s.group.Add(healthsvc.Start)
 	}
@@ -735,6 +1217,11 @@ func (s *Server) setupGatewayAPI(contourConfiguration contour_api_v1alpha1.Conto
 			s.log.WithError(err).Fatal("failed to create tlsroute-controller")
 		}
 
+		// Create and register the UDPRoute controller with the manager.
+		if _, err := controller.NewUDPRouteController(mgr, eventHandler, s.log.WithField("context", "udproute-controller")); err != nil {
+			s.log.WithError(err).Fatal("failed to create udproute-controller")
+		}
+
 		// Inform on ReferencePolicies.
 		if err := informOnResource(&gatewayapi_v1alpha2.ReferencePolicy{}, eventHandler, mgr.GetCache()); err != nil {
 			s.log.WithError(err).WithField("resource", "referencepolicies").Fatal("failed to create informer")
@@ -748,16 +1235,24 @@ func (s *Server) setupGatewayAPI(contourConfiguration contour_api_v1alpha1.Conto
 }
 
 type dagBuilderConfig struct {
-	ingressClassName           string
-	rootNamespaces             []string
-	gatewayAPIConfigured       bool
-	disablePermitInsecure      bool
-	enableExternalNameService  bool
-	dnsLookupFamily            contour_api_v1alpha1.ClusterDNSFamilyType
-	headersPolicy              *contour_api_v1alpha1.PolicyConfig
-	applyHeaderPolicyToIngress bool
-	clientCert                 *types.NamespacedName
-	fallbackCert               *types.NamespacedName
+	ingressClassName             string
+	rootNamespaces               []string
+	denyByDefault                bool
+	gatewayAPIConfigured         bool
+	disablePermitInsecure        bool
+	permitInsecurePrefixes       []string
+	enableExternalNameService    bool
+	enableStaticEndpoints        bool
+	dnsLookupFamily              contour_api_v1alpha1.ClusterDNSFamilyType
+	insecureSkipVerifyNamespaces []string
+	headersPolicy                *contour_api_v1alpha1.PolicyConfig
+	applyHeaderPolicyToIngress   bool
+	clientCert                   *types.NamespacedName
+	fallbackCert                 *types.NamespacedName
+	acmeChallengeSolver          *contour_api_v1alpha1.ACMEChallengeSolverConfig
+	maxResponseTimeout           time.Duration
+	maxIdleTimeout               time.Duration
+	localRateLimitPolicy         *dag.LocalRateLimitPolicy
 }
 
 func (s *Server) getDAGBuilder(dbc dagBuilderConfig) dag.Builder {
@@ -805,11 +1300,13 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) dag.Builder {
 	// Get the appropriate DAG processors.
 	dagProcessors := []dag.Processor{
 		&dag.IngressProcessor{
-			EnableExternalNameService: dbc.enableExternalNameService,
-			FieldLogger:               s.log.WithField("context", "IngressProcessor"),
-			ClientCertificate:         dbc.clientCert,
-			RequestHeadersPolicy:      &requestHeadersPolicyIngress,
-			ResponseHeadersPolicy:     &responseHeadersPolicyIngress,
+			EnableExternalNameService:   dbc.enableExternalNameService,
+			FieldLogger:                 s.log.WithField("context", "IngressProcessor"),
+			ClientCertificate:           dbc.clientCert,
+			RequestHeadersPolicy:        &requestHeadersPolicyIngress,
+			ResponseHeadersPolicy:       &responseHeadersPolicyIngress,
+			MaxResponseTimeout:          dbc.maxResponseTimeout,
+			DefaultLocalRateLimitPolicy: dbc.localRateLimitPolicy,
 		},
 		&dag.ExtensionServiceProcessor{
 			// Note that ExtensionService does not support ExternalName, if it does get added,
@@ -818,20 +1315,52 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) dag.Builder {
 			ClientCertificate: dbc.clientCert,
 		},
 		&dag.HTTPProxyProcessor{
-			EnableExternalNameService: dbc.enableExternalNameService,
-			DisablePermitInsecure:     dbc.disablePermitInsecure,
-			FallbackCertificate:       dbc.fallbackCert,
-			DNSLookupFamily:           dbc.dnsLookupFamily,
-			ClientCertificate:         dbc.clientCert,
-			RequestHeadersPolicy:      &requestHeadersPolicy,
-			ResponseHeadersPolicy:     &responseHeadersPolicy,
+			EnableExternalNameService:   dbc.enableExternalNameService,
+			EnableStaticEndpoints:       dbc.enableStaticEndpoints,
+			DisablePermitInsecure:       dbc.disablePermitInsecure,
+			PermitInsecurePrefixes:      dbc.permitInsecurePrefixes,
+			FallbackCertificate:         dbc.fallbackCert,
+			DNSLookupFamily:             dbc.dnsLookupFamily,
+			ClientCertificate:           dbc.clientCert,
+			RequestHeadersPolicy:        &requestHeadersPolicy,
+			ResponseHeadersPolicy:       &responseHeadersPolicy,
+			MaxResponseTimeout:          dbc.maxResponseTimeout,
+			MaxIdleTimeout:              dbc.maxIdleTimeout,
+			DefaultLocalRateLimitPolicy: dbc.localRateLimitPolicy,
 		},
 	}
 
 	if dbc.gatewayAPIConfigured {
 		dagProcessors = append(dagProcessors, &dag.GatewayAPIProcessor{
+			EnableExternalNameService:   dbc.enableExternalNameService,
+			FieldLogger:                 s.log.WithField("context", "GatewayAPIProcessor"),
+			DefaultLocalRateLimitPolicy: dbc.localRateLimitPolicy,
+		})
+	}
+
+	// Processors registered by programs embedding Contour run
+	// once the built-in virtual hosts and routes exist.
+	dagProcessors = append(dagProcessors, dag.RegisteredProcessors()...)
+
+	// The ACME challenge processor adds routes to the virtual hosts
+	// built by the other processors.
+	if dbc.acmeChallengeSolver != nil {
+		dagProcessors = append(dagProcessors, &dag.ACMEChallengeProcessor{
+			FieldLogger:               s.log.WithField("context", "ACMEChallengeProcessor"),
 			EnableExternalNameService: dbc.enableExternalNameService,
-			FieldLogger:               s.log.WithField("context", "GatewayAPIProcessor"),
+			Service: types.NamespacedName{
+				Name:      dbc.acmeChallengeSolver.Service.Name,
+				Namespace: dbc.acmeChallengeSolver.Service.Namespace,
+			},
+			Port: dbc.acmeChallengeSolver.Port,
+		})
+	}
+
+	// The filter exempt processor has to follow the processors that
+	// add routes so that it sees all of them.
+	if dbc.headersPolicy != nil && len(dbc.headersPolicy.FilterExemptPrefixes) > 0 {
+		dagProcessors = append(dagProcessors, &dag.FilterExemptProcessor{
+			Prefixes: dbc.headersPolicy.FilterExemptPrefixes,
 		})
 	}
 
@@ -849,10 +1378,12 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) dag.Builder {
 
 	builder := dag.Builder{
 		Source: dag.KubernetesCache{
-			RootNamespaces:       dbc.rootNamespaces,
-			IngressClassName:     dbc.ingressClassName,
-			ConfiguredSecretRefs: configuredSecretRefs,
-			FieldLogger:          s.log.WithField("context", "KubernetesCache"),
+			RootNamespaces:               dbc.rootNamespaces,
+			DenyByDefault:                dbc.denyByDefault,
+			IngressClassName:             dbc.ingressClassName,
+			ConfiguredSecretRefs:         configuredSecretRefs,
+			InsecureSkipVerifyNamespaces: dbc.insecureSkipVerifyNamespaces,
+			FieldLogger:                  s.log.WithField("context", "KubernetesCache"),
 		},
 		Processors: dagProcessors,
 	}
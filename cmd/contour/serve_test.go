@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 )
 
 func TestGetDAGBuilder(t *testing.T) {
@@ -180,3 +181,29 @@ func mustGetIngressProcessor(t *testing.T, builder *dag.Builder) *dag.IngressPro
 	require.FailNow(t, "IngressProcessor not found in list of DAG builder's processors")
 	return nil
 }
+
+func TestMergeTimeoutParameters(t *testing.T) {
+	base := &contour_api_v1alpha1.TimeoutParameters{
+		RequestTimeout:        pointer.StringPtr("10s"),
+		ConnectionIdleTimeout: pointer.StringPtr("60s"),
+	}
+
+	got := mergeTimeoutParameters(base, &contour_api_v1alpha1.TimeoutParameters{
+		ConnectionIdleTimeout: pointer.StringPtr("1h"),
+		StreamIdleTimeout:     pointer.StringPtr("5m"),
+	})
+	assert.Equal(t, &contour_api_v1alpha1.TimeoutParameters{
+		RequestTimeout:        pointer.StringPtr("10s"),
+		ConnectionIdleTimeout: pointer.StringPtr("1h"),
+		StreamIdleTimeout:     pointer.StringPtr("5m"),
+	}, got)
+
+	// The base timeouts are not modified.
+	assert.Equal(t, pointer.StringPtr("60s"), base.ConnectionIdleTimeout)
+
+	assert.Equal(t, &contour_api_v1alpha1.TimeoutParameters{
+		MaxConnectionDuration: pointer.StringPtr("1h"),
+	}, mergeTimeoutParameters(nil, &contour_api_v1alpha1.TimeoutParameters{
+		MaxConnectionDuration: pointer.StringPtr("1h"),
+	}))
+}
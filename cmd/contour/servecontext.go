@@ -20,12 +20,17 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/projectcontour/contour/internal/k8s"
 	"k8s.io/utils/pointer"
 
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
@@ -51,14 +56,44 @@ type serveContext struct {
 	debugAddr string
 	debugPort int
 
+	// debugLogLevelToken is the bearer token required to change
+	// the log level via the debug service.
+	debugLogLevelToken string
+
+	// debugToken is the bearer token required to access
+	// every endpoint of the debug service.
+	debugToken string
+
+	// TLS files for serving the debug endpoints over HTTPS.
+	debugCert   string
+	debugKey    string
+	debugCAFile string
+
 	// contour's metrics handler parameters
 	metricsAddr string
 	metricsPort int
 
+	// metricsToken is the bearer token required to
+	// access the metrics endpoint.
+	metricsToken string
+
+	// TLS files for serving the metrics endpoint over HTTPS.
+	// If set, they take precedence over the TLS files of the
+	// metrics configuration.
+	metricsCert   string
+	metricsKey    string
+	metricsCAFile string
+
 	// Contour's health handler parameters.
 	healthAddr string
 	healthPort int
 
+	// Contour's conversion webhook parameters. The webhook
+	// is disabled if webhookPort is zero.
+	webhookAddr    string
+	webhookPort    int
+	webhookCertDir string
+
 	// httpproxy root namespaces
 	rootNamespaces string
 
@@ -94,6 +129,10 @@ type ServerConfig struct {
 	xdsAddr                         string
 	xdsPort                         int
 	caFile, contourCert, contourKey string
+
+	// xdsDriftThreshold is how long an Envoy may take to apply
+	// the latest xDS snapshot before it is reported as lagging.
+	xdsDriftThreshold time.Duration
 }
 
 // newServeContext returns a serveContext initialized to defaults.
@@ -109,6 +148,9 @@ func newServeContext() *serveContext {
 		healthPort:            8000,
 		metricsAddr:           "0.0.0.0",
 		metricsPort:           8000,
+		webhookAddr:           "0.0.0.0",
+		webhookPort:           0,
+		webhookCertDir:        "/certs/webhook",
 		httpAccessLog:         xdscache_v3.DEFAULT_HTTP_ACCESS_LOG,
 		httpsAccessLog:        xdscache_v3.DEFAULT_HTTPS_ACCESS_LOG,
 		httpAddr:              "0.0.0.0",
@@ -118,11 +160,12 @@ func newServeContext() *serveContext {
 		PermitInsecureGRPC:    false,
 		DisableLeaderElection: false,
 		ServerConfig: ServerConfig{
-			xdsAddr:     "127.0.0.1",
-			xdsPort:     8001,
-			caFile:      "",
-			contourCert: "",
-			contourKey:  "",
+			xdsAddr:           "127.0.0.1",
+			xdsPort:           8001,
+			caFile:            "",
+			contourCert:       "",
+			contourKey:        "",
+			xdsDriftThreshold: time.Minute,
 		},
 	}
 }
@@ -197,16 +240,32 @@ func tlsconfig(log logrus.FieldLogger, contourXDSTLS *contour_api_v1alpha1.TLS)
 	}
 
 	// Attempt to load certificates and key to catch configuration errors early.
-	if _, lerr := loadConfig(); lerr != nil {
+	lastConfig, lerr := loadConfig()
+	if lerr != nil {
 		log.WithError(lerr).Fatal("failed to load certificate and key")
 	}
 
+	// While a mounted Secret is being updated, the certificate, key and CA
+	// files may briefly be inconsistent with each other. Keep serving the
+	// last configuration that loaded successfully until they are consistent
+	// again, rather than failing handshakes.
+	var mu sync.Mutex
+
 	return &tls.Config{
 		MinVersion: tls.VersionTLS13,
 		ClientAuth: tls.RequireAndVerifyClientCert,
 		Rand:       rand.Reader,
 		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
-			return loadConfig()
+			mu.Lock()
+			defer mu.Unlock()
+
+			config, err := loadConfig()
+			if err != nil {
+				log.WithError(err).Warn("failed to reload certificate and key, using previously loaded configuration")
+				return lastConfig, nil
+			}
+			lastConfig = config
+			return config, nil
 		},
 	}
 }
@@ -238,7 +297,8 @@ func (ctx *serveContext) proxyRootNamespaces() []string {
 }
 
 // parseDefaultHTTPVersions parses a list of supported HTTP versions
-//  (of the form "HTTP/xx") into a slice of unique version constants.
+//
+//	(of the form "HTTP/xx") into a slice of unique version constants.
 func parseDefaultHTTPVersions(versions []contour_api_v1alpha1.HTTPVersionType) []envoy_v3.HTTPVersionType {
 	wanted := map[envoy_v3.HTTPVersionType]struct{}{}
 
@@ -259,6 +319,73 @@ func parseDefaultHTTPVersions(versions []contour_api_v1alpha1.HTTPVersionType) [
 	return parsed
 }
 
+// proxyProtocolTLVs converts the PROXY protocol settings in
+// proxyProtocol, which may be nil, to TLVs for the xDS cache.
+func proxyProtocolTLVs(proxyProtocol *contour_api_v1alpha1.ProxyProtocolConfig) []envoy_v3.ProxyProtocolTLV {
+	if proxyProtocol == nil {
+		return nil
+	}
+
+	var tlvs []envoy_v3.ProxyProtocolTLV
+	for _, tlv := range proxyProtocol.TLVs {
+		tlvs = append(tlvs, envoy_v3.ProxyProtocolTLV{
+			Type:              uint32(tlv.Type),
+			MetadataNamespace: tlv.MetadataNamespace,
+			Key:               tlv.Key,
+		})
+	}
+
+	return tlvs
+}
+
+// staticClusters converts the static clusters in the
+// configuration to Envoy clusters for the cluster cache.
+func staticClusters(clusters []contour_api_v1alpha1.StaticCluster) []*envoy_cluster_v3.Cluster {
+	var ecs []*envoy_cluster_v3.Cluster
+	for _, c := range clusters {
+		var addrs []*envoy_core_v3.Address
+		for _, ep := range c.Endpoints {
+			addrs = append(addrs, envoy_v3.SocketAddress(ep.Address, ep.Port))
+		}
+		ecs = append(ecs, envoy_v3.StaticCluster(c.Name, c.Protocol, addrs...))
+	}
+
+	return ecs
+}
+
+// listenerFilters converts the listener filter overrides in
+// filters, which may be nil, to their xDS cache form.
+func listenerFilters(filters *contour_api_v1alpha1.EnvoyListenerFilters) xdscache_v3.ListenerFilters {
+	if filters == nil {
+		return xdscache_v3.ListenerFilters{}
+	}
+
+	return xdscache_v3.ListenerFilters{
+		ProxyProtocol: filters.ProxyProtocol,
+		TLSInspector:  filters.TLSInspector,
+	}
+}
+
+func listenerSocketOptions(opts *contour_api_v1alpha1.EnvoyListenerSocketOptions) xdscache_v3.ListenerSocketOptions {
+	if opts == nil {
+		return xdscache_v3.ListenerSocketOptions{}
+	}
+
+	dst := xdscache_v3.ListenerSocketOptions{
+		TCPFastOpenQueueLength: opts.TCPFastOpenQueueLength,
+		ReusePort:              opts.ReusePort,
+		Freebind:               opts.Freebind,
+	}
+	if ka := opts.TCPKeepalive; ka != nil {
+		dst.DisableTCPKeepalive = ka.Disabled
+		dst.TCPKeepaliveIdle = ka.IdleTime
+		dst.TCPKeepaliveInterval = ka.Interval
+		dst.TCPKeepaliveProbes = ka.Probes
+	}
+
+	return dst
+}
+
 func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha1.ContourConfigurationSpec {
 	ingress := &contour_api_v1alpha1.IngressConfig{}
 	if len(ctx.ingressClassName) > 0 {
@@ -311,24 +438,20 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 		}
 	}
 
-	timeoutParams := &contour_api_v1alpha1.TimeoutParameters{}
-	if len(ctx.Config.Timeouts.RequestTimeout) > 0 {
-		timeoutParams.RequestTimeout = pointer.StringPtr(ctx.Config.Timeouts.RequestTimeout)
-	}
-	if len(ctx.Config.Timeouts.ConnectionIdleTimeout) > 0 {
-		timeoutParams.ConnectionIdleTimeout = pointer.StringPtr(ctx.Config.Timeouts.ConnectionIdleTimeout)
-	}
-	if len(ctx.Config.Timeouts.StreamIdleTimeout) > 0 {
-		timeoutParams.StreamIdleTimeout = pointer.StringPtr(ctx.Config.Timeouts.StreamIdleTimeout)
-	}
-	if len(ctx.Config.Timeouts.MaxConnectionDuration) > 0 {
-		timeoutParams.MaxConnectionDuration = pointer.StringPtr(ctx.Config.Timeouts.MaxConnectionDuration)
-	}
-	if len(ctx.Config.Timeouts.DelayedCloseTimeout) > 0 {
-		timeoutParams.DelayedCloseTimeout = pointer.StringPtr(ctx.Config.Timeouts.DelayedCloseTimeout)
-	}
-	if len(ctx.Config.Timeouts.ConnectionShutdownGracePeriod) > 0 {
-		timeoutParams.ConnectionShutdownGracePeriod = pointer.StringPtr(ctx.Config.Timeouts.ConnectionShutdownGracePeriod)
+	timeoutParams := timeoutParamsFromConfig(ctx.Config.Timeouts)
+
+	var timeoutPolicy *contour_api_v1alpha1.TimeoutPolicyConfig
+	if ctx.Config.TimeoutPolicy != (config.TimeoutPolicyParameters{}) {
+		timeoutPolicy = &contour_api_v1alpha1.TimeoutPolicyConfig{}
+		if len(ctx.Config.TimeoutPolicy.ConnectTimeout) > 0 {
+			timeoutPolicy.ConnectTimeout = pointer.StringPtr(ctx.Config.TimeoutPolicy.ConnectTimeout)
+		}
+		if len(ctx.Config.TimeoutPolicy.MaxResponseTimeout) > 0 {
+			timeoutPolicy.MaxResponseTimeout = pointer.StringPtr(ctx.Config.TimeoutPolicy.MaxResponseTimeout)
+		}
+		if len(ctx.Config.TimeoutPolicy.MaxIdleTimeout) > 0 {
+			timeoutPolicy.MaxIdleTimeout = pointer.StringPtr(ctx.Config.TimeoutPolicy.MaxIdleTimeout)
+		}
 	}
 
 	var dnsLookupFamily contour_api_v1alpha1.ClusterDNSFamilyType
@@ -351,6 +474,14 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 			Domain:                  ctx.Config.RateLimitService.Domain,
 			FailOpen:                ctx.Config.RateLimitService.FailOpen,
 			EnableXRateLimitHeaders: ctx.Config.RateLimitService.EnableXRateLimitHeaders,
+			Stages:                  ctx.Config.RateLimitService.Stages,
+		}
+
+		if ctx.Config.RateLimitService.ConfigMap != "" {
+			rateLimitService.ConfigMap = &contour_api_v1alpha1.NamespacedName{
+				Name:      k8s.NamespacedNameFrom(ctx.Config.RateLimitService.ConfigMap).Name,
+				Namespace: k8s.NamespacedNameFrom(ctx.Config.RateLimitService.ConfigMap).Namespace,
+			}
 		}
 	}
 
@@ -363,7 +494,26 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 			Set:    ctx.Config.Policy.ResponseHeadersPolicy.Set,
 			Remove: ctx.Config.Policy.ResponseHeadersPolicy.Remove,
 		},
-		ApplyToIngress: ctx.Config.Policy.ApplyToIngress,
+		ApplyToIngress:       ctx.Config.Policy.ApplyToIngress,
+		FilterExemptPrefixes: ctx.Config.Policy.FilterExemptPrefixes,
+	}
+
+	if localRateLimit := ctx.Config.Policy.LocalRateLimit; localRateLimit.Requests > 0 {
+		var headers []contour_api_v1.HeaderValue
+		for name, value := range localRateLimit.ResponseHeadersToAdd {
+			headers = append(headers, contour_api_v1.HeaderValue{Name: name, Value: value})
+		}
+		sort.Slice(headers, func(i, j int) bool {
+			return headers[i].Name < headers[j].Name
+		})
+
+		policy.LocalRateLimitPolicy = &contour_api_v1.LocalRateLimitPolicy{
+			Requests:             localRateLimit.Requests,
+			Unit:                 localRateLimit.Unit,
+			Burst:                localRateLimit.Burst,
+			ResponseStatusCode:   localRateLimit.ResponseStatusCode,
+			ResponseHeadersToAdd: headers,
+		}
 	}
 
 	var clientCertificate *contour_api_v1alpha1.NamespacedName
@@ -379,6 +529,16 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 		accessLogFormatString = pointer.StringPtr(ctx.Config.AccessLogFormatString)
 	}
 
+	var endpointDrainDelay *string
+	if len(ctx.Config.Cluster.EndpointDrainDelay) > 0 {
+		endpointDrainDelay = pointer.StringPtr(ctx.Config.Cluster.EndpointDrainDelay)
+	}
+
+	var listenerFilterTimeout *string
+	if len(ctx.Config.Listener.FilterTimeout) > 0 {
+		listenerFilterTimeout = pointer.StringPtr(ctx.Config.Listener.FilterTimeout)
+	}
+
 	var fallbackCertificate *contour_api_v1alpha1.NamespacedName
 	if len(ctx.Config.TLS.FallbackCertificate.Name) > 0 {
 		fallbackCertificate = &contour_api_v1alpha1.NamespacedName{
@@ -407,6 +567,27 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 	setMetricsFromConfig(ctx.Config.Metrics.Contour, &contourMetrics)
 	setMetricsFromConfig(ctx.Config.Metrics.Envoy, &envoyMetrics)
 
+	var tracingConfig *contour_api_v1alpha1.TracingConfig
+	if ctx.Config.Tracing != nil {
+		tracingConfig = &contour_api_v1alpha1.TracingConfig{
+			Endpoint:        ctx.Config.Tracing.Endpoint,
+			Insecure:        ctx.Config.Tracing.Insecure,
+			SamplingPercent: ctx.Config.Tracing.SamplingPercent,
+			ServiceName:     ctx.Config.Tracing.ServiceName,
+		}
+	}
+
+	var acmeChallengeSolver *contour_api_v1alpha1.ACMEChallengeSolverConfig
+	if ctx.Config.ACMEChallengeSolver != nil {
+		acmeChallengeSolver = &contour_api_v1alpha1.ACMEChallengeSolverConfig{
+			Service: contour_api_v1alpha1.NamespacedName{
+				Name:      k8s.NamespacedNameFrom(ctx.Config.ACMEChallengeSolver.Service).Name,
+				Namespace: k8s.NamespacedNameFrom(ctx.Config.ACMEChallengeSolver.Service).Namespace,
+			},
+			Port: ctx.Config.ACMEChallengeSolver.Port,
+		}
+	}
+
 	// Convert serveContext to a ContourConfiguration
 	contourConfiguration := contour_api_v1alpha1.ContourConfigurationSpec{
 		Ingress: ingress,
@@ -422,9 +603,22 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 		},
 		Envoy: contour_api_v1alpha1.EnvoyConfig{
 			Listener: contour_api_v1alpha1.EnvoyListenerConfig{
-				UseProxyProto:             ctx.useProxyProto,
+				UseProxyProto:             ctx.useProxyProto || ctx.Config.Listener.ProxyProtocol.Enabled,
 				DisableAllowChunkedLength: ctx.Config.DisableAllowChunkedLength,
 				ConnectionBalancer:        ctx.Config.Listener.ConnectionBalancer,
+				FilterTimeout:             listenerFilterTimeout,
+				ContinueOnFilterTimeout:   ctx.Config.Listener.ContinueOnFilterTimeout,
+				DrainType:                 ctx.Config.Listener.DrainType,
+				HTTPFilters:               listenerFiltersFromConfig(ctx.Config.Listener.HTTPFilters),
+				HTTPSFilters:              listenerFiltersFromConfig(ctx.Config.Listener.HTTPSFilters),
+				ProxyProtocol:             proxyProtocolFromConfig(ctx.Config.Listener.ProxyProtocol),
+				SocketOptions:             socketOptionsFromConfig(ctx.Config.Listener.SocketOptions),
+				MaxConnectionsPerListener: ctx.Config.Listener.MaxConnectionsPerListener,
+				GlobalMaxConnections:      ctx.Config.Listener.GlobalMaxConnections,
+				EnableH2C:                 ctx.Config.Listener.EnableH2C,
+				DefaultHostForHTTP10:      ctx.Config.Listener.DefaultHostForHTTP10,
+				PreserveHeaderCase:        ctx.Config.Listener.PreserveHeaderCase,
+				HeaderLimits:              headerLimitsFromConfig(ctx.Config.Listener.HeaderLimits),
 				TLS: contour_api_v1alpha1.EnvoyTLS{
 					MinimumProtocolVersion: ctx.Config.TLS.MinimumProtocolVersion,
 					CipherSuites:           cipherSuites,
@@ -458,23 +652,39 @@ func (ctx *serveContext) convertToContourConfigurationSpec() contour_api_v1alpha
 			DefaultHTTPVersions: defaultHTTPVersions,
 			Timeouts:            timeoutParams,
 			Cluster: contour_api_v1alpha1.ClusterParameters{
-				DNSLookupFamily: dnsLookupFamily,
+				DNSLookupFamily:                dnsLookupFamily,
+				UseEndpointSlices:              ctx.Config.Cluster.UseEndpointSlices,
+				EndpointDrainDelay:             endpointDrainDelay,
+				PreserveHeaderCase:             ctx.Config.Cluster.PreserveHeaderCase,
+				HealthyPanicThreshold:          ctx.Config.Cluster.HealthyPanicThreshold,
+				ZoneAwareRoutingMinClusterSize: ctx.Config.Cluster.ZoneAwareRoutingMinClusterSize,
+				InsecureSkipVerifyNamespaces:   ctx.Config.Cluster.InsecureSkipVerifyNamespaces,
+				StaticClusters:                 staticClustersFromConfig(ctx.Config.Cluster.StaticClusters),
 			},
 			Network: contour_api_v1alpha1.NetworkParameters{
 				XffNumTrustedHops: ctx.Config.Network.XffNumTrustedHops,
 				EnvoyAdminPort:    ctx.Config.Network.EnvoyAdminPort,
+				DualStack:         ctx.Config.Network.DualStack,
 			},
+			ListenerProfiles: listenerProfilesFromConfig(ctx.Config.ListenerProfiles, accessLogFormat),
 		},
 		Gateway: gatewayConfig,
 		HTTPProxy: contour_api_v1alpha1.HTTPProxyConfig{
-			DisablePermitInsecure: ctx.Config.DisablePermitInsecure,
-			RootNamespaces:        ctx.proxyRootNamespaces(),
-			FallbackCertificate:   fallbackCertificate,
+			DisablePermitInsecure:  ctx.Config.DisablePermitInsecure,
+			PermitInsecurePrefixes: ctx.Config.PermitInsecurePrefixes,
+			RootNamespaces:         ctx.proxyRootNamespaces(),
+			DenyByDefault:          ctx.Config.DenyByDefault,
+			FallbackCertificate:    fallbackCertificate,
 		},
 		EnableExternalNameService: ctx.Config.EnableExternalNameService,
+		EnableStaticEndpoints:     ctx.Config.EnableStaticEndpoints,
 		RateLimitService:          rateLimitService,
 		Policy:                    policy,
+		TimeoutPolicy:             timeoutPolicy,
 		Metrics:                   contourMetrics,
+		Tracing:                   tracingConfig,
+		ACMEChallengeSolver:       acmeChallengeSolver,
+		RemoteClusters:            remoteClustersFromConfig(ctx.Config.RemoteClusters),
 	}
 
 	xdsServerType := contour_api_v1alpha1.ContourServerType
@@ -508,17 +718,174 @@ func setMetricsFromConfig(src config.MetricsServerParameters, dst *contour_api_v
 
 	if src.HasTLS() {
 		dst.TLS = &contour_api_v1alpha1.MetricsTLS{
-			CertFile: src.ServerCert,
-			KeyFile:  src.ServerKey,
-			CAFile:   src.CABundle,
+			CertFile:          src.ServerCert,
+			KeyFile:           src.ServerKey,
+			CAFile:            src.CABundle,
+			UseXDSCertificate: src.UseXDSCertificate,
 		}
 	}
 
-	if src.HasTLS() {
-		dst.TLS = &contour_api_v1alpha1.MetricsTLS{
-			CertFile: src.ServerCert,
-			KeyFile:  src.ServerKey,
-			CAFile:   src.CABundle,
+	if len(src.Paths) > 0 {
+		dst.Paths = append([]string{}, src.Paths...)
+	}
+}
+
+func timeoutParamsFromConfig(src config.TimeoutParameters) *contour_api_v1alpha1.TimeoutParameters {
+	dst := &contour_api_v1alpha1.TimeoutParameters{}
+	if len(src.RequestTimeout) > 0 {
+		dst.RequestTimeout = pointer.StringPtr(src.RequestTimeout)
+	}
+	if len(src.ConnectionIdleTimeout) > 0 {
+		dst.ConnectionIdleTimeout = pointer.StringPtr(src.ConnectionIdleTimeout)
+	}
+	if len(src.StreamIdleTimeout) > 0 {
+		dst.StreamIdleTimeout = pointer.StringPtr(src.StreamIdleTimeout)
+	}
+	if len(src.MaxConnectionDuration) > 0 {
+		dst.MaxConnectionDuration = pointer.StringPtr(src.MaxConnectionDuration)
+	}
+	if len(src.DelayedCloseTimeout) > 0 {
+		dst.DelayedCloseTimeout = pointer.StringPtr(src.DelayedCloseTimeout)
+	}
+	if len(src.ConnectionShutdownGracePeriod) > 0 {
+		dst.ConnectionShutdownGracePeriod = pointer.StringPtr(src.ConnectionShutdownGracePeriod)
+	}
+
+	return dst
+}
+
+// listenerProfilesFromConfig converts the listener profiles of the
+// config file. A profile that overrides any access log setting but
+// not the format uses defaultFormat.
+func listenerProfilesFromConfig(src []config.ListenerProfile, defaultFormat contour_api_v1alpha1.AccessLogType) []contour_api_v1alpha1.ListenerProfile {
+	var dst []contour_api_v1alpha1.ListenerProfile
+
+	for _, p := range src {
+		profile := contour_api_v1alpha1.ListenerProfile{
+			Name:         p.Name,
+			NodeIDs:      p.NodeIDs,
+			NodeClusters: p.NodeClusters,
+		}
+
+		if p.Timeouts != nil {
+			profile.Timeouts = timeoutParamsFromConfig(*p.Timeouts)
+		}
+
+		if p.AccessLogFormat != "" || p.AccessLogFormatString != "" || len(p.AccessLogFields) > 0 {
+			profile.Logging = &contour_api_v1alpha1.EnvoyLogging{
+				AccessLogFormat: defaultFormat,
+				AccessLogFields: contour_api_v1alpha1.AccessLogFields(p.AccessLogFields),
+			}
+			if p.AccessLogFormat != "" {
+				profile.Logging.AccessLogFormat = contour_api_v1alpha1.AccessLogType(p.AccessLogFormat)
+			}
+			if p.AccessLogFormatString != "" {
+				profile.Logging.AccessLogFormatString = pointer.StringPtr(p.AccessLogFormatString)
+			}
+		}
+
+		if p.HTTPFilters != nil {
+			profile.HTTPFilters = listenerFiltersFromConfig(*p.HTTPFilters)
+		}
+		if p.HTTPSFilters != nil {
+			profile.HTTPSFilters = listenerFiltersFromConfig(*p.HTTPSFilters)
+		}
+
+		dst = append(dst, profile)
+	}
+
+	return dst
+}
+
+func listenerFiltersFromConfig(src config.ListenerFilterParameters) *contour_api_v1alpha1.EnvoyListenerFilters {
+	if src.ProxyProtocol == nil && src.TLSInspector == nil {
+		return nil
+	}
+
+	return &contour_api_v1alpha1.EnvoyListenerFilters{
+		ProxyProtocol: src.ProxyProtocol,
+		TLSInspector:  src.TLSInspector,
+	}
+}
+
+func socketOptionsFromConfig(src config.SocketOptionsParameters) *contour_api_v1alpha1.EnvoyListenerSocketOptions {
+	if src == (config.SocketOptionsParameters{}) {
+		return nil
+	}
+
+	dst := &contour_api_v1alpha1.EnvoyListenerSocketOptions{
+		TCPFastOpenQueueLength: src.TCPFastOpenQueueLength,
+		ReusePort:              src.ReusePort,
+		Freebind:               src.Freebind,
+	}
+	if src.TCPKeepalive != (config.TCPKeepaliveParameters{}) {
+		dst.TCPKeepalive = &contour_api_v1alpha1.TCPKeepaliveConfig{
+			Disabled: src.TCPKeepalive.Disabled,
+			IdleTime: src.TCPKeepalive.IdleTime,
+			Interval: src.TCPKeepalive.Interval,
+			Probes:   src.TCPKeepalive.Probes,
+		}
+	}
+
+	return dst
+}
+
+func headerLimitsFromConfig(src config.HeaderLimitsParameters) *contour_api_v1alpha1.HeaderLimitsConfig {
+	if src == (config.HeaderLimitsParameters{}) {
+		return nil
+	}
+
+	return &contour_api_v1alpha1.HeaderLimitsConfig{
+		MaxRequestHeadersKB:    src.MaxRequestHeadersKB,
+		MaxRequestHeadersCount: src.MaxRequestHeadersCount,
+		RejectAction:           src.RejectAction,
+	}
+}
+
+func staticClustersFromConfig(src []config.StaticClusterParameters) []contour_api_v1alpha1.StaticCluster {
+	var dst []contour_api_v1alpha1.StaticCluster
+	for _, sc := range src {
+		c := contour_api_v1alpha1.StaticCluster{
+			Name:     sc.Name,
+			Protocol: sc.Protocol,
 		}
+		for _, ep := range sc.Endpoints {
+			c.Endpoints = append(c.Endpoints, contour_api_v1alpha1.StaticClusterEndpoint{
+				Address: ep.Address,
+				Port:    ep.Port,
+			})
+		}
+		dst = append(dst, c)
+	}
+
+	return dst
+}
+
+func remoteClustersFromConfig(src []config.RemoteClusterParameters) []contour_api_v1alpha1.RemoteClusterConfig {
+	var dst []contour_api_v1alpha1.RemoteClusterConfig
+	for _, rc := range src {
+		dst = append(dst, contour_api_v1alpha1.RemoteClusterConfig{
+			Name:       rc.Name,
+			Kubeconfig: rc.Kubeconfig,
+		})
 	}
+
+	return dst
+}
+
+func proxyProtocolFromConfig(src config.ProxyProtocolParameters) *contour_api_v1alpha1.ProxyProtocolConfig {
+	if len(src.TLVs) == 0 {
+		return nil
+	}
+
+	dst := &contour_api_v1alpha1.ProxyProtocolConfig{}
+	for _, tlv := range src.TLVs {
+		dst.TLVs = append(dst.TLVs, contour_api_v1alpha1.ProxyProtocolTLV{
+			Type:              tlv.Type,
+			MetadataNamespace: tlv.MetadataNamespace,
+			Key:               tlv.Key,
+		})
+	}
+
+	return dst
 }
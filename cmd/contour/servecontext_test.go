@@ -239,6 +239,36 @@ func TestTlsVersionDeprecation(t *testing.T) {
 	assert.Equal(t, tlsConfig.MinVersion, uint16(tls.VersionTLS13))
 }
 
+func TestTLSConfigKeepsLastGoodConfig(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "contour-testdata-")
+	checkFatalErr(t, err)
+	defer os.RemoveAll(configDir)
+
+	contourTLS := &contour_api_v1alpha1.TLS{
+		CAFile:   filepath.Join(configDir, "CAcert.pem"),
+		CertFile: filepath.Join(configDir, "contourcert.pem"),
+		KeyFile:  filepath.Join(configDir, "contourkey.pem"),
+		Insecure: false,
+	}
+
+	err = linkFiles("testdata/1", configDir)
+	checkFatalErr(t, err)
+
+	log := fixture.NewTestLogger(t)
+	preliminaryTLSConfig := tlsconfig(log, contourTLS)
+
+	want, err := preliminaryTLSConfig.GetConfigForClient(nil)
+	checkFatalErr(t, err)
+
+	// Simulate a Secret update which has not yet written the new key.
+	checkFatalErr(t, os.Remove(contourTLS.KeyFile))
+	checkFatalErr(t, os.Symlink(filepath.Join(configDir, "missing.pem"), contourTLS.KeyFile))
+
+	got, err := preliminaryTLSConfig.GetConfigForClient(nil)
+	checkFatalErr(t, err)
+	assert.Equal(t, want.Certificates, got.Certificates)
+}
+
 func checkFatalErr(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
@@ -416,6 +446,7 @@ func TestConvertServeContext(t *testing.T) {
 		Domain:                  "contour",
 		FailOpen:                true,
 		EnableXRateLimitHeaders: true,
+		ConfigMap:               "ratens/ratelimit-config",
 	}
 
 	defaultHTTPVersions := newServeContext()
@@ -1180,6 +1211,10 @@ func TestConvertServeContext(t *testing.T) {
 					Domain:                  "contour",
 					FailOpen:                true,
 					EnableXRateLimitHeaders: true,
+					ConfigMap: &contour_api_v1alpha1.NamespacedName{
+						Name:      "ratelimit-config",
+						Namespace: "ratens",
+					},
 				},
 				Policy: &contour_api_v1alpha1.PolicyConfig{
 					RequestHeadersPolicy:  &contour_api_v1alpha1.HeadersPolicy{},
@@ -1393,3 +1428,46 @@ func TestConvertServeContext(t *testing.T) {
 		})
 	}
 }
+
+func TestListenerProfilesFromConfig(t *testing.T) {
+	profiles := listenerProfilesFromConfig([]config.ListenerProfile{
+		{
+			Name:         "internal",
+			NodeClusters: []string{"projectcontour-internal"},
+			Timeouts: &config.TimeoutParameters{
+				ConnectionIdleTimeout: "1h",
+			},
+			AccessLogFields: config.AccessLogFields{"@timestamp", "method"},
+			HTTPSFilters: &config.ListenerFilterParameters{
+				ProxyProtocol: pointer.Bool(false),
+			},
+		},
+		{
+			Name:    "partner",
+			NodeIDs: []string{"envoy-partner-0"},
+		},
+	}, contour_api_v1alpha1.JSONAccessLog)
+
+	assert.Equal(t, []contour_api_v1alpha1.ListenerProfile{
+		{
+			Name:         "internal",
+			NodeClusters: []string{"projectcontour-internal"},
+			Timeouts: &contour_api_v1alpha1.TimeoutParameters{
+				ConnectionIdleTimeout: pointer.StringPtr("1h"),
+			},
+			Logging: &contour_api_v1alpha1.EnvoyLogging{
+				AccessLogFormat: contour_api_v1alpha1.JSONAccessLog,
+				AccessLogFields: contour_api_v1alpha1.AccessLogFields{"@timestamp", "method"},
+			},
+			HTTPSFilters: &contour_api_v1alpha1.EnvoyListenerFilters{
+				ProxyProtocol: pointer.Bool(false),
+			},
+		},
+		{
+			Name:    "partner",
+			NodeIDs: []string{"envoy-partner-0"},
+		},
+	}, profiles)
+
+	assert.Nil(t, listenerProfilesFromConfig(nil, contour_api_v1alpha1.EnvoyAccessLog))
+}
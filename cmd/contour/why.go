@@ -0,0 +1,246 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectcontour/contour/internal/debug"
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/pkg/dag"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// whyContext holds the parameters for the
+// "contour debug why" command.
+type whyContext struct {
+	// debugAddr is the base URL of Contour's debug service,
+	// which explains the routing of the live DAG.
+	debugAddr string
+
+	// files are Kubernetes manifests to build the DAG from,
+	// instead of querying a running Contour.
+	files []string
+
+	// ingressClassName is the ingress class of the
+	// objects of the manifests that are processed.
+	ingressClassName string
+
+	host    string
+	path    string
+	secure  bool
+	headers []string
+}
+
+func registerWhy(cmd *kingpin.CmdClause) (*kingpin.CmdClause, *whyContext) {
+	ctx := &whyContext{}
+
+	why := cmd.Command("why", "Explain how Contour routes a request.")
+	why.Flag("debug-address", "Contour debug service URL.").Default("http://127.0.0.1:6060").StringVar(&ctx.debugAddr)
+	why.Flag("file", "Kubernetes manifest to build the DAG from, instead of querying a running Contour. May be repeated.").Short('f').ExistingFilesVar(&ctx.files)
+	why.Flag("ingress-class-name", "Ingress class of the objects of the manifests.").StringVar(&ctx.ingressClassName)
+	why.Flag("secure", "Explain a request sent over TLS.").BoolVar(&ctx.secure)
+	why.Flag("header", "Request header, as \"name: value\". May be repeated.").Short('H').StringsVar(&ctx.headers)
+	why.Arg("host", "Host of the request.").Required().StringVar(&ctx.host)
+	why.Arg("path", "Path of the request.").Default("/").StringVar(&ctx.path)
+
+	return why, ctx
+}
+
+// run explains the request, using the DAG built from the
+// manifests if any are given, or else the DAG of the
+// running Contour.
+func (ctx *whyContext) run() (*debug.WhyReport, error) {
+	if len(ctx.files) > 0 {
+		return ctx.explainOffline()
+	}
+	return ctx.explainLive()
+}
+
+func (ctx *whyContext) explainOffline() (*debug.WhyReport, error) {
+	req := debug.WhyRequest{
+		Host:    ctx.host,
+		Path:    ctx.path,
+		Secure:  ctx.secure,
+		Headers: map[string]string{},
+	}
+	for _, header := range ctx.headers {
+		name, value, err := debug.ParseWhyHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		req.Headers[name] = value
+	}
+
+	var objs []interface{}
+	for _, file := range ctx.files {
+		fileObjs, err := readManifest(file)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, fileObjs...)
+	}
+
+	d := dag.Build(dag.Options{IngressClassName: ctx.ingressClassName}, objs...)
+	return debug.Explain(d, req), nil
+}
+
+// readManifest decodes the Kubernetes objects of a
+// YAML or JSON manifest that may hold several documents.
+func readManifest(file string) ([]interface{}, error) {
+	scheme, err := k8s.NewContourScheme()
+	if err != nil {
+		return nil, err
+	}
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []interface{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		objs = append(objs, obj)
+	}
+}
+
+func (ctx *whyContext) explainLive() (*debug.WhyReport, error) {
+	u, err := url.Parse(strings.TrimSuffix(ctx.debugAddr, "/") + debug.WhyPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid debug address %q: %w", ctx.debugAddr, err)
+	}
+	u.RawQuery = url.Values{
+		"host":   []string{ctx.host},
+		"path":   []string{ctx.path},
+		"secure": []string{strconv.FormatBool(ctx.secure)},
+		"header": ctx.headers,
+	}.Encode()
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var report debug.WhyReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// printWhyReport writes a report in a form for people to read.
+func printWhyReport(w io.Writer, report *debug.WhyReport) {
+	scheme := "http"
+	if report.Secure {
+		scheme = "https"
+	}
+	fmt.Fprintf(w, "Request: %s://%s%s\n", scheme, report.Host, report.Path)
+
+	switch {
+	case report.VirtualHost == "":
+		fmt.Fprintf(w, "No virtual host serves %s over %s.\n", report.Host, strings.ToUpper(scheme))
+	case report.Route == nil:
+		fmt.Fprintf(w, "Virtual host: %s\n", report.VirtualHost)
+		fmt.Fprintln(w, "No route matches the request.")
+	default:
+		fmt.Fprintf(w, "Virtual host: %s\n", report.VirtualHost)
+		r := report.Route
+		fmt.Fprintln(w, "Route:")
+		if r.Origin != "" {
+			fmt.Fprintf(w, "  Defined by: %s\n", r.Origin)
+		}
+		fmt.Fprintf(w, "  Conditions: %s\n", strings.Join(r.Conditions, ", "))
+		fmt.Fprintf(w, "  Action: %s\n", r.Action)
+		for _, c := range r.Clusters {
+			var details []string
+			if c.Mirror {
+				details = append(details, "mirror")
+			}
+			if c.Weight > 0 {
+				details = append(details, fmt.Sprintf("weight %d", c.Weight))
+			}
+			if c.Protocol != "" {
+				details = append(details, c.Protocol)
+			}
+			fmt.Fprintf(w, "  Service: %s", c.Service)
+			if len(details) > 0 {
+				fmt.Fprintf(w, " (%s)", strings.Join(details, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+		for _, p := range r.Policies {
+			fmt.Fprintf(w, "  Policy: %s\n", p)
+		}
+	}
+
+	if len(report.Alternatives) > 0 {
+		fmt.Fprintln(w, "Alternatives:")
+		for _, a := range report.Alternatives {
+			var what []string
+			if a.Origin != "" {
+				what = append(what, a.Origin)
+			}
+			if len(a.Conditions) > 0 {
+				what = append(what, "["+strings.Join(a.Conditions, ", ")+"]")
+			}
+			if len(what) > 0 {
+				fmt.Fprintf(w, "  %s: %s\n", strings.Join(what, " "), a.Reason)
+			} else {
+				fmt.Fprintf(w, "  %s\n", a.Reason)
+			}
+		}
+	}
+}
@@ -59,6 +59,9 @@ var annotationsByKind = map[string]map[string]struct{}{
 		"projectcontour.io/websocket-routes":             {},
 	},
 	"Service": {
+		"projectcontour.io/dns-discovery-type":    {},
+		"projectcontour.io/dns-lookup-family":     {},
+		"projectcontour.io/dns-resolvers":         {},
 		"projectcontour.io/max-connections":       {},
 		"projectcontour.io/max-pending-requests":  {},
 		"projectcontour.io/max-requests":          {},
@@ -68,8 +71,9 @@ var annotationsByKind = map[string]map[string]struct{}{
 		"projectcontour.io/upstream-protocol.tls": {},
 	},
 	"HTTPProxy": {
-		"kubernetes.io/ingress.class":     {},
-		"projectcontour.io/ingress.class": {},
+		"kubernetes.io/ingress.class":          {},
+		"projectcontour.io/ingress.class":      {},
+		"projectcontour.io/traffic-shift-halt": {},
 	},
 }
 
@@ -245,3 +249,53 @@ func MaxRequests(o metav1.Object) uint32 {
 func MaxRetries(o metav1.Object) uint32 {
 	return parseUInt32(ContourAnnotation(o, "max-retries"))
 }
+
+// DNSDiscoveryType returns the value of the
+// projectcontour.io/dns-discovery-type annotation, which
+// must be "strict" or "logical".
+//
+// An empty string is returned if the annotation is absent or
+// has another value.
+func DNSDiscoveryType(o metav1.Object) string {
+	switch v := strings.ToLower(strings.TrimSpace(ContourAnnotation(o, "dns-discovery-type"))); v {
+	case "strict", "logical":
+		return v
+	default:
+		return ""
+	}
+}
+
+// DNSLookupFamily returns the value of the
+// projectcontour.io/dns-lookup-family annotation, which
+// must be "auto", "v4" or "v6".
+//
+// An empty string is returned if the annotation is absent or
+// has another value.
+func DNSLookupFamily(o metav1.Object) string {
+	switch v := strings.ToLower(strings.TrimSpace(ContourAnnotation(o, "dns-lookup-family"))); v {
+	case "auto", "v4", "v6":
+		return v
+	default:
+		return ""
+	}
+}
+
+// DNSResolvers returns the comma separated list of DNS resolver
+// addresses in the projectcontour.io/dns-resolvers annotation.
+func DNSResolvers(o metav1.Object) []string {
+	var resolvers []string
+	for _, v := range strings.Split(ContourAnnotation(o, "dns-resolvers"), ",") {
+		if r := strings.TrimSpace(v); r != "" {
+			resolvers = append(resolvers, r)
+		}
+	}
+	return resolvers
+}
+
+// TrafficShiftHalted returns whether the
+// projectcontour.io/traffic-shift-halt annotation is "true",
+// halting the traffic shifts of the routes of the object.
+func TrafficShiftHalted(o metav1.Object) bool {
+	halted, _ := strconv.ParseBool(strings.TrimSpace(ContourAnnotation(o, "traffic-shift-halt")))
+	return halted
+}
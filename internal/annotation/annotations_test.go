@@ -382,6 +382,126 @@ func TestAnnotationCompat(t *testing.T) {
 	}
 }
 
+func TestDNSDiscoveryType(t *testing.T) {
+	tests := map[string]struct {
+		a    map[string]string
+		want string
+	}{
+		"nada": {
+			a:    nil,
+			want: "",
+		},
+		"strict": {
+			a:    map[string]string{"projectcontour.io/dns-discovery-type": "strict"},
+			want: "strict",
+		},
+		"logical mixed case": {
+			a:    map[string]string{"projectcontour.io/dns-discovery-type": " Logical"},
+			want: "logical",
+		},
+		"invalid": {
+			a:    map[string]string{"projectcontour.io/dns-discovery-type": "static"},
+			want: "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := DNSDiscoveryType(&v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: tc.a}})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDNSLookupFamily(t *testing.T) {
+	tests := map[string]struct {
+		a    map[string]string
+		want string
+	}{
+		"nada": {
+			a:    nil,
+			want: "",
+		},
+		"v6": {
+			a:    map[string]string{"projectcontour.io/dns-lookup-family": "v6"},
+			want: "v6",
+		},
+		"auto mixed case": {
+			a:    map[string]string{"projectcontour.io/dns-lookup-family": "AUTO "},
+			want: "auto",
+		},
+		"invalid": {
+			a:    map[string]string{"projectcontour.io/dns-lookup-family": "ipv4"},
+			want: "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := DNSLookupFamily(&v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: tc.a}})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDNSResolvers(t *testing.T) {
+	tests := map[string]struct {
+		a    map[string]string
+		want []string
+	}{
+		"nada": {
+			a:    nil,
+			want: nil,
+		},
+		"empty with spaces": {
+			a:    map[string]string{"projectcontour.io/dns-resolvers": ", ,"},
+			want: nil,
+		},
+		"multiple values": {
+			a:    map[string]string{"projectcontour.io/dns-resolvers": "10.96.0.10, 10.96.0.11:5353"},
+			want: []string{"10.96.0.10", "10.96.0.11:5353"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := DNSResolvers(&v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: tc.a}})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestTrafficShiftHalted(t *testing.T) {
+	tests := map[string]struct {
+		a    map[string]string
+		want bool
+	}{
+		"nada": {
+			a:    nil,
+			want: false,
+		},
+		"true": {
+			a:    map[string]string{"projectcontour.io/traffic-shift-halt": "true"},
+			want: true,
+		},
+		"false": {
+			a:    map[string]string{"projectcontour.io/traffic-shift-halt": "false"},
+			want: false,
+		},
+		"malformed": {
+			a:    map[string]string{"projectcontour.io/traffic-shift-halt": "yes please"},
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := TrafficShiftHalted(&contour_api_v1.HTTPProxy{ObjectMeta: metav1.ObjectMeta{Annotations: tc.a}})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestAnnotationKindValidation(t *testing.T) {
 	type status struct {
 		known bool
@@ -18,6 +18,8 @@ package certgen
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path"
 
 	"github.com/projectcontour/contour/internal/dag"
@@ -39,6 +41,12 @@ const (
 	EnvoyCertificateKey = "envoycert.pem"
 	// EnvoyPrivateKeyKey is the dictionary key for the Envoy private key.
 	EnvoyPrivateKeyKey = "envoykey.pem"
+	// CAPrivateKeyKey is the dictionary key for the CA private key.
+	CAPrivateKeyKey = "cakey.pem"
+
+	// CASecretName is the name of the Secret holding the CA certificate
+	// and private key, which is only written when rotating certificates.
+	CASecretName = "contourca"
 )
 
 // OverwritePolicy specifies whether an output should be overwritten.
@@ -103,7 +111,63 @@ func WriteCertsPEM(outputDir string, certdata *certs.Certificates, force Overwri
 		return err
 	}
 
-	return writePEM(outputDir, "envoykey.pem", certdata.EnvoyPrivateKey, force)
+	err = writePEM(outputDir, "envoykey.pem", certdata.EnvoyPrivateKey, force)
+	if err != nil {
+		return err
+	}
+
+	if len(certdata.CAPrivateKey) == 0 {
+		return nil
+	}
+
+	return writePEM(outputDir, CAPrivateKeyKey, certdata.CAPrivateKey, force)
+}
+
+// ReadCertsPEM reads the certificates written by WriteCertsPEM from
+// outputDir. Files that do not exist are left empty.
+func ReadCertsPEM(outputDir string) (*certs.Certificates, error) {
+	certdata := &certs.Certificates{}
+
+	for filename, data := range map[string]*[]byte{
+		CACertificateKey:      &certdata.CACertificate,
+		CAPrivateKeyKey:       &certdata.CAPrivateKey,
+		ContourCertificateKey: &certdata.ContourCertificate,
+		ContourPrivateKeyKey:  &certdata.ContourPrivateKey,
+		EnvoyCertificateKey:   &certdata.EnvoyCertificate,
+		EnvoyPrivateKeyKey:    &certdata.EnvoyPrivateKey,
+	} {
+		b, err := ioutil.ReadFile(path.Join(outputDir, filename))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		*data = b
+	}
+
+	return certdata, nil
+}
+
+// ReadCertsKube reads the CA, Contour and Envoy keypairs from the
+// Secrets in namespace. Secrets that do not exist are left empty.
+func ReadCertsKube(client kubernetes.Interface, namespace string) (*certs.Certificates, error) {
+	certdata := &certs.Certificates{}
+
+	for name, pair := range map[string][2]*[]byte{
+		CASecretName:  {&certdata.CACertificate, &certdata.CAPrivateKey},
+		"contourcert": {&certdata.ContourCertificate, &certdata.ContourPrivateKey},
+		"envoycert":   {&certdata.EnvoyCertificate, &certdata.EnvoyPrivateKey},
+	} {
+		secret, err := client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		*pair[0] = secret.Data[corev1.TLSCertKey]
+		*pair[1] = secret.Data[corev1.TLSPrivateKeyKey]
+	}
+
+	return certdata, nil
 }
 
 // WriteSecretsYAML writes all the keypairs out to Kubernetes Secrets in YAML form
@@ -166,6 +230,18 @@ func AsSecrets(namespace string, certdata *certs.Certificates) []*corev1.Secret
 	}
 }
 
+// AsCASecret transforms the CA certificate and private key in the given
+// Certificates struct into a Secret, so that later certificate rotations
+// can sign renewed certificates with the same CA.
+func AsCASecret(namespace string, certdata *certs.Certificates) *corev1.Secret {
+	return newSecret(corev1.SecretTypeTLS,
+		CASecretName, namespace,
+		map[string][]byte{
+			corev1.TLSCertKey:       certdata.CACertificate,
+			corev1.TLSPrivateKeyKey: certdata.CAPrivateKey,
+		})
+}
+
 // AsLegacySecrets transforms the given Certificates struct into a slice of
 // Secrets that is compatible with certgen from contour 1.4 and earlier.
 // The difference is that the CA cert is in a separate secret, rather
@@ -55,6 +55,8 @@ func (c *Cond) Register(ch chan int, last int, hints ...string) {
 }
 
 // Notify notifies all interested waiters that an event has ocured.
+// If hints are supplied, only waiters that registered a matching hint,
+// or registered no hints at all, are notified.
 func (c *Cond) Notify(hints ...string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -64,8 +66,9 @@ func (c *Cond) Notify(hints ...string) {
 	c.waiters = nil
 
 	for _, waiter := range notify {
-		if len(hints) == 0 {
-			// notify unconditionally
+		if len(hints) == 0 || len(waiter.hints) == 0 {
+			// notify unconditionally, waiters without hints
+			// are interested in every event
 			waiter.ch <- c.last
 			continue
 		}
@@ -108,3 +108,18 @@ func TestCondRegisterWithHintShouldNotNotifyWithWrongHint(t *testing.T) {
 		t.Fatal("ch was not notified")
 	}
 }
+
+func TestCondRegisterWithoutHintShouldNotifyWithHint(t *testing.T) {
+	var c Cond
+	ch := make(chan int, 1)
+	c.Register(ch, 1)
+	c.Notify("ingress_https")
+	select {
+	case v := <-ch:
+		if v != 1 {
+			t.Fatal("ch was notified with the wrong sequence number", v)
+		}
+	default:
+		t.Fatal("ch was not notified")
+	}
+}
@@ -17,17 +17,26 @@
 package contour
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// maxTracedChanges bounds the number of object changes recorded as
+// events on a single DAG rebuild span.
+const maxTracedChanges = 100
+
 // EventHandler implements cache.ResourceEventHandler, filters k8s events towards
 // a dag.Builder and calls through to the Observer to notify it that a new DAG
 // is available.
@@ -58,6 +67,10 @@ type EventHandler struct {
 	// seq is the sequence counter of the number of times
 	// an event has been received.
 	seq int
+
+	// changes holds the object changes that will be included
+	// in the next DAG rebuild, for tracing.
+	changes []trace.EventOption
 }
 
 type opAdd struct {
@@ -112,6 +125,12 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 		// pending is a reference to the current timer's channel.
 		pending <-chan time.Time
 
+		// rebuildTimer holds the timer which will expire when the
+		// last DAG asked to be rebuilt, and scheduled is a
+		// reference to its channel.
+		rebuildTimer *time.Timer
+		scheduled    <-chan time.Time
+
 		// lastDAGRebuild holds the last time rebuildDAG was called.
 		// lastDAGRebuild is seeded to the current time on entry to
 		// run to allow the holdoff timer to batch the updates from
@@ -124,33 +143,40 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 		return
 	}
 
+	holdoff := func() {
+		// If there is already a timer running, stop it.
+		if timer != nil {
+			timer.Stop()
+		}
+
+		delay := e.HoldoffDelay
+		if time.Since(lastDAGRebuild) > e.HoldoffMaxDelay {
+			// the maximum holdoff delay has been exceeded so schedule the update
+			// immediately by delaying for 0ns.
+			delay = 0
+		}
+		timer = time.NewTimer(delay)
+		pending = timer.C
+	}
+
 	for {
-		// In the main loop one of four things can happen.
+		// In the main loop one of five things can happen.
 		// 1. We're waiting for an event on op, stop, or pending, noting that
 		//    pending may be nil if there are no pending events.
 		// 2. We're processing an event.
 		// 3. The holdoff timer from a previous event has fired and we're
 		//    building a new DAG and sending to the Observer.
-		// 4. We're stopping.
+		// 4. The time the last DAG asked to be rebuilt at has come, and
+		//    we're scheduling an update as if an event had been processed.
+		// 5. We're stopping.
 		//
 		// Only one of these things can happen at a time.
 		select {
 		case op := <-e.update:
 			if e.onUpdate(op) {
+				e.recordChange(op)
 				outstanding++
-				// If there is already a timer running, stop it.
-				if timer != nil {
-					timer.Stop()
-				}
-
-				delay := e.HoldoffDelay
-				if time.Since(lastDAGRebuild) > e.HoldoffMaxDelay {
-					// the maximum holdoff delay has been exceeded so schedule the update
-					// immediately by delaying for 0ns.
-					delay = 0
-				}
-				timer = time.NewTimer(delay)
-				pending = timer.C
+				holdoff()
 			} else {
 				// notify any watchers that we received the event but chose
 				// not to process it.
@@ -158,9 +184,24 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 			}
 		case <-pending:
 			e.WithField("last_update", time.Since(lastDAGRebuild)).WithField("outstanding", reset()).Info("performing delayed update")
-			e.rebuildDAG()
+			rebuildAt := e.rebuildDAG()
 			e.incSequence()
 			lastDAGRebuild = time.Now()
+
+			// Schedule the next rebuild the DAG asked for, if any,
+			// replacing the one scheduled by the previous DAG.
+			if rebuildTimer != nil {
+				rebuildTimer.Stop()
+				rebuildTimer, scheduled = nil, nil
+			}
+			if !rebuildAt.IsZero() {
+				rebuildTimer = time.NewTimer(time.Until(rebuildAt))
+				scheduled = rebuildTimer.C
+			}
+		case <-scheduled:
+			e.Info("performing scheduled update")
+			rebuildTimer, scheduled = nil, nil
+			holdoff()
 		case <-stop:
 			// shutdown
 			return nil
@@ -178,10 +219,16 @@ func (e *EventHandler) onUpdate(op interface{}) bool {
 	case opUpdate:
 		if cmp.Equal(op.oldObj, op.newObj,
 			cmpopts.IgnoreFields(contour_api_v1.HTTPProxy{}, "Status"),
+			cmpopts.IgnoreFields(contour_api_v1alpha1.TrafficWeights{}, "Status"),
+			cmpopts.IgnoreFields(contour_api_v1alpha1.ContourPolicy{}, "Status"),
 			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion"),
 			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ManagedFields"),
 		) {
-			e.WithField("op", "update").Debugf("%T skipping update, only status has changed", op.newObj)
+			log := e.WithField("op", "update")
+			if obj, ok := op.newObj.(metav1.Object); ok {
+				log = log.WithFields(k8s.LogFields(obj))
+			}
+			log.Debug("skipping update, only status has changed")
 			return false
 		}
 		remove := e.Builder.Source.Remove(op.oldObj)
@@ -196,6 +243,44 @@ func (e *EventHandler) onUpdate(op interface{}) bool {
 	}
 }
 
+// recordChange notes the object changed by op so that it can be
+// attached to the span of the DAG rebuild that includes it.
+func (e *EventHandler) recordChange(op interface{}) {
+	if len(e.changes) >= maxTracedChanges {
+		return
+	}
+
+	var (
+		verb string
+		obj  interface{}
+	)
+
+	switch op := op.(type) {
+	case opAdd:
+		verb, obj = "add", op.obj
+	case opUpdate:
+		verb, obj = "update", op.newObj
+	case opDelete:
+		verb, obj = "delete", op.obj
+	default:
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("op", verb),
+	}
+	if meta, ok := obj.(metav1.Object); ok {
+		attrs = append(attrs,
+			attribute.String("kind", k8s.KindOf(obj)),
+			attribute.String("namespace", meta.GetNamespace()),
+			attribute.String("name", meta.GetName()),
+			attribute.String("resourceVersion", meta.GetResourceVersion()),
+		)
+	}
+
+	e.changes = append(e.changes, trace.WithAttributes(attrs...))
+}
+
 // incSequence bumps the sequence counter and sends it to e.Sequence.
 func (e *EventHandler) incSequence() {
 	e.seq++
@@ -209,11 +294,32 @@ func (e *EventHandler) incSequence() {
 
 // rebuildDAG builds a new DAG and sends it to the Observer,
 // the updates the status on objects, and updates the metrics.
-func (e *EventHandler) rebuildDAG() {
+// It returns when the DAG should next be rebuilt, if ever.
+func (e *EventHandler) rebuildDAG() time.Time {
+	ctx, span := tracing.Tracer().Start(context.Background(), "RebuildDAG",
+		trace.WithAttributes(attribute.Int("changes", len(e.changes))))
+	defer span.End()
+
+	for _, change := range e.changes {
+		span.AddEvent("ObjectChanged", change)
+	}
+	e.changes = nil
+
+	_, buildSpan := tracing.Tracer().Start(ctx, "BuildDAG")
 	latestDAG := e.Builder.Build()
+	buildSpan.End()
+
+	// Record the rebuild before notifying the observers, so
+	// that any xDS pushes they trigger can link back to it.
+	tracing.RecordUpdate(span.SpanContext())
+
+	_, observeSpan := tracing.Tracer().Start(ctx, "NotifyObservers")
 	e.Observer.OnChange(latestDAG)
+	observeSpan.End()
 
 	for _, upd := range latestDAG.StatusCache.GetStatusUpdates() {
 		e.StatusUpdater.Send(upd)
 	}
+
+	return latestDAG.RebuildAt
 }
@@ -70,6 +70,11 @@ type RebuildMetricsObserver struct {
 
 	// NextObserver contains the stack of dag.Observers that act on DAG rebuilds.
 	NextObserver dag.Observer
+
+	// Cache is the cache of Kubernetes objects the DAG is built
+	// from. If not nil, the number of objects it holds is recorded.
+	// It must only be read from the goroutine that builds the DAG.
+	Cache *dag.KubernetesCache
 }
 
 func (m *RebuildMetricsObserver) OnChange(d *dag.DAG) {
@@ -80,6 +85,12 @@ func (m *RebuildMetricsObserver) OnChange(d *dag.DAG) {
 	m.NextObserver.OnChange(d)
 	timer.ObserveDuration()
 
+	m.Metrics.SetSecretCertificateExpiry(calculateSecretCertificateExpiry(d.GetSecrets()))
+	m.Metrics.SetDAGVertices(calculateDAGVertices(d))
+	if m.Cache != nil {
+		m.Metrics.SetCacheObjects(m.Cache.ObjectCounts())
+	}
+
 	select {
 	// If we are leader, the IsLeader channel is closed.
 	case <-m.IsLeader:
@@ -88,26 +99,86 @@ func (m *RebuildMetricsObserver) OnChange(d *dag.DAG) {
 	}
 }
 
+// calculateSecretCertificateExpiry returns the expiry time of the
+// serving certificate of each of the given secrets.
+func calculateSecretCertificateExpiry(secrets []*dag.Secret) map[metrics.SecretMeta]time.Time {
+	expiries := make(map[metrics.SecretMeta]time.Time, len(secrets))
+	for _, s := range secrets {
+		notAfter, err := s.NotAfter()
+		if err != nil {
+			continue
+		}
+		expiries[metrics.SecretMeta{Name: s.Name(), Namespace: s.Namespace()}] = notAfter
+	}
+	return expiries
+}
+
+// calculateDAGVertices returns the number of vertices of the DAG, by kind.
+// Clusters and Secrets shared by several routes or virtual hosts are
+// counted once.
+func calculateDAGVertices(d *dag.DAG) map[string]int {
+	counts := map[string]int{
+		"Listener":          len(d.Listeners),
+		"VirtualHost":       0,
+		"SecureVirtualHost": 0,
+		"Route":             0,
+		"Cluster":           0,
+		"ExtensionCluster":  len(d.ExtensionClusters),
+		"Secret":            0,
+		"UDPProxy":          len(d.UDPProxies),
+	}
+
+	for _, l := range d.Listeners {
+		counts["VirtualHost"] += len(l.VirtualHosts)
+		counts["SecureVirtualHost"] += len(l.SecureVirtualHosts)
+		for _, vh := range l.VirtualHosts {
+			counts["Route"] += len(vh.Routes)
+		}
+		for _, svh := range l.SecureVirtualHosts {
+			counts["Route"] += len(svh.Routes)
+		}
+	}
+
+	clusters := map[*dag.Cluster]bool{}
+	for _, c := range d.GetClusters() {
+		clusters[c] = true
+	}
+	counts["Cluster"] = len(clusters)
+
+	secrets := map[*dag.Secret]bool{}
+	for _, s := range d.GetSecrets() {
+		secrets[s] = true
+	}
+	counts["Secret"] = len(secrets)
+
+	return counts
+}
+
 func calculateRouteMetric(updates []*status.ProxyUpdate) metrics.RouteMetric {
 	proxyMetricTotal := make(map[metrics.Meta]int)
 	proxyMetricValid := make(map[metrics.Meta]int)
 	proxyMetricInvalid := make(map[metrics.Meta]int)
 	proxyMetricOrphaned := make(map[metrics.Meta]int)
 	proxyMetricRoots := make(map[metrics.Meta]int)
+	proxyMetricConflicted := make(map[metrics.Meta]int)
 
 	for _, u := range updates {
 		calcMetrics(u, proxyMetricValid, proxyMetricInvalid, proxyMetricOrphaned, proxyMetricTotal)
 		if u.Vhost != "" {
 			proxyMetricRoots[metrics.Meta{VHost: u.Vhost, Namespace: u.Fullname.Namespace}]++
 		}
+		if cond, ok := u.Conditions[status.ConflictedCondition]; ok && cond.Status == contour_api_v1.ConditionTrue {
+			proxyMetricConflicted[metrics.Meta{VHost: u.Vhost, Namespace: u.Fullname.Namespace}]++
+		}
 	}
 
 	return metrics.RouteMetric{
-		Invalid:  proxyMetricInvalid,
-		Valid:    proxyMetricValid,
-		Orphaned: proxyMetricOrphaned,
-		Total:    proxyMetricTotal,
-		Root:     proxyMetricRoots,
+		Invalid:    proxyMetricInvalid,
+		Valid:      proxyMetricValid,
+		Orphaned:   proxyMetricOrphaned,
+		Total:      proxyMetricTotal,
+		Root:       proxyMetricRoots,
+		Conflicted: proxyMetricConflicted,
 	}
 }
 
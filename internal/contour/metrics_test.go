@@ -328,7 +328,8 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy1, s3},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
-			Invalid: map[metrics.Meta]int{},
+			Conflicted: map[metrics.Meta]int{},
+			Invalid:    map[metrics.Meta]int{},
 			Valid: map[metrics.Meta]int{
 				{Namespace: "roots", VHost: "example.com"}: 1,
 			},
@@ -346,6 +347,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy2},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots", VHost: "example.com"}: 1,
 			},
@@ -364,6 +366,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy3},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "finance", VHost: "example.com"}: 1,
 			},
@@ -383,6 +386,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy13},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots"}: 1,
 			},
@@ -399,6 +403,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy6},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots", VHost: "example.com"}: 1,
 			},
@@ -417,6 +422,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy7, proxy8},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots"}: 1,
 			},
@@ -437,8 +443,9 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy8},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
-			Invalid: map[metrics.Meta]int{},
-			Valid:   map[metrics.Meta]int{},
+			Conflicted: map[metrics.Meta]int{},
+			Invalid:    map[metrics.Meta]int{},
+			Valid:      map[metrics.Meta]int{},
 			Orphaned: map[metrics.Meta]int{
 				{Namespace: "roots"}: 1,
 			},
@@ -453,6 +460,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy10, proxy11, proxy12, s1, s2},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots"}: 1,
 			},
@@ -474,6 +482,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy14, proxy11},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots"}: 1,
 			},
@@ -492,6 +501,7 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		objs:   []interface{}{proxy14, proxy11, proxy10, s2},
 		wantIR: nil,
 		wantProxy: &metrics.RouteMetric{
+			Conflicted: map[metrics.Meta]int{},
 			Invalid: map[metrics.Meta]int{
 				{Namespace: "roots"}:                       1,
 				{Namespace: "roots", VHost: "example.com"}: 1,
@@ -509,3 +519,47 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		},
 	})
 }
+
+func TestCalculateDAGVertices(t *testing.T) {
+	secret := &dag.Secret{}
+	cluster := &dag.Cluster{}
+
+	d := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: "ingress_http",
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "example.com",
+				Routes: map[string]*dag.Route{
+					"/":    {Clusters: []*dag.Cluster{cluster}},
+					"/foo": {Clusters: []*dag.Cluster{cluster, {}}},
+				},
+			}},
+		}, {
+			Name: "ingress_https",
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{
+				VirtualHost: dag.VirtualHost{
+					Name: "example.com",
+					Routes: map[string]*dag.Route{
+						"/": {Clusters: []*dag.Cluster{cluster}},
+					},
+				},
+				Secret: secret,
+			}, {
+				VirtualHost: dag.VirtualHost{Name: "www.example.com"},
+				Secret:      secret,
+			}},
+		}},
+		ExtensionClusters: []*dag.ExtensionCluster{{Name: "extension/auth/authz"}},
+	}
+
+	assert.Equal(t, map[string]int{
+		"Listener":          2,
+		"VirtualHost":       1,
+		"SecureVirtualHost": 2,
+		"Route":             3,
+		"Cluster":           2,
+		"ExtensionCluster":  1,
+		"Secret":            1,
+		"UDPProxy":          0,
+	}, calculateDAGVertices(d))
+}
@@ -15,6 +15,7 @@ package contourconfig
 
 import (
 	"fmt"
+	"time"
 
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/timeout"
@@ -75,3 +76,46 @@ func ParseTimeoutPolicy(timeoutParameters *contour_api_v1alpha1.TimeoutParameter
 	}
 	return timeouts, nil
 }
+
+// TimeoutPolicyLimits holds the parsed values of a TimeoutPolicyConfig.
+// Zero values are unset.
+type TimeoutPolicyLimits struct {
+	ConnectTimeout     time.Duration
+	MaxResponseTimeout time.Duration
+	MaxIdleTimeout     time.Duration
+}
+
+func ParseTimeoutPolicyLimits(timeoutPolicy *contour_api_v1alpha1.TimeoutPolicyConfig) (TimeoutPolicyLimits, error) {
+	var limits TimeoutPolicyLimits
+
+	if timeoutPolicy == nil {
+		return limits, nil
+	}
+
+	parse := func(str *string) (time.Duration, error) {
+		if str == nil {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(*str)
+		if err != nil {
+			return 0, err
+		}
+		if d <= 0 {
+			return 0, fmt.Errorf("%q must be greater than zero", *str)
+		}
+		return d, nil
+	}
+
+	var err error
+	if limits.ConnectTimeout, err = parse(timeoutPolicy.ConnectTimeout); err != nil {
+		return TimeoutPolicyLimits{}, fmt.Errorf("failed to parse connect timeout: %s", err)
+	}
+	if limits.MaxResponseTimeout, err = parse(timeoutPolicy.MaxResponseTimeout); err != nil {
+		return TimeoutPolicyLimits{}, fmt.Errorf("failed to parse max response timeout: %s", err)
+	}
+	if limits.MaxIdleTimeout, err = parse(timeoutPolicy.MaxIdleTimeout); err != nil {
+		return TimeoutPolicyLimits{}, fmt.Errorf("failed to parse max idle timeout: %s", err)
+	}
+
+	return limits, nil
+}
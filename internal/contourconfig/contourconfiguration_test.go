@@ -121,3 +121,59 @@ func TestParseTimeoutPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTimeoutPolicyLimits(t *testing.T) {
+	testCases := map[string]struct {
+		config   *contour_api_v1alpha1.TimeoutPolicyConfig
+		expected contourconfig.TimeoutPolicyLimits
+		errorMsg string
+	}{
+		"nil timeout policy": {
+			config:   nil,
+			expected: contourconfig.TimeoutPolicyLimits{},
+		},
+		"limits all set": {
+			config: &contour_api_v1alpha1.TimeoutPolicyConfig{
+				ConnectTimeout:     pointer.String("5s"),
+				MaxResponseTimeout: pointer.String("1m"),
+				MaxIdleTimeout:     pointer.String("10m"),
+			},
+			expected: contourconfig.TimeoutPolicyLimits{
+				ConnectTimeout:     time.Second * 5,
+				MaxResponseTimeout: time.Minute,
+				MaxIdleTimeout:     time.Minute * 10,
+			},
+		},
+		"connect timeout invalid": {
+			config: &contour_api_v1alpha1.TimeoutPolicyConfig{
+				ConnectTimeout: pointer.String("xxx"),
+			},
+			errorMsg: "failed to parse connect timeout",
+		},
+		"max response timeout infinite": {
+			config: &contour_api_v1alpha1.TimeoutPolicyConfig{
+				MaxResponseTimeout: pointer.String("infinity"),
+			},
+			errorMsg: "failed to parse max response timeout",
+		},
+		"max idle timeout zero": {
+			config: &contour_api_v1alpha1.TimeoutPolicyConfig{
+				MaxIdleTimeout: pointer.String("0s"),
+			},
+			errorMsg: "failed to parse max idle timeout",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := contourconfig.ParseTimeoutPolicyLimits(tc.config)
+			if len(tc.errorMsg) > 0 {
+				require.Error(t, err, "expected error to be returned")
+				require.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				require.Nil(t, err)
+				require.Equal(t, tc.expected, parsed)
+			}
+		})
+	}
+}
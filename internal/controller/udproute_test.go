@@ -0,0 +1,72 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// recordingEventHandler records the objects it is notified of.
+type recordingEventHandler struct {
+	added   []interface{}
+	deleted []interface{}
+}
+
+func (h *recordingEventHandler) OnAdd(obj interface{})               { h.added = append(h.added, obj) }
+func (h *recordingEventHandler) OnUpdate(oldObj, newObj interface{}) {}
+func (h *recordingEventHandler) OnDelete(obj interface{})            { h.deleted = append(h.deleted, obj) }
+
+func TestUDPRouteReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, gatewayapi_v1alpha2.AddToScheme(scheme))
+
+	route := &gatewayapi_v1alpha2.UDPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "default",
+		},
+	}
+
+	eventHandler := &recordingEventHandler{}
+	r := &udpRouteReconciler{
+		client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(route).Build(),
+		eventHandler: eventHandler,
+		FieldLogger:  fixture.NewTestLogger(t),
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "dns"},
+	})
+	require.NoError(t, err)
+	require.Len(t, eventHandler.added, 1)
+	assert.Equal(t, "dns", eventHandler.added[0].(*gatewayapi_v1alpha2.UDPRoute).Name)
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "missing"},
+	})
+	require.NoError(t, err)
+	require.Len(t, eventHandler.deleted, 1)
+	assert.Equal(t, "missing", eventHandler.deleted[0].(*gatewayapi_v1alpha2.UDPRoute).Name)
+}
@@ -15,10 +15,11 @@ package dag
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/annotation"
-	"github.com/projectcontour/contour/internal/xds"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -33,7 +34,82 @@ func (d *DAG) EnsureService(meta types.NamespacedName, port intstr.IntOrString,
 		return nil, err
 	}
 
-	err = validateExternalName(svc, enableExternalNameSvc)
+	return newService(svc, svcPort, enableExternalNameSvc)
+}
+
+// EnsureUDPService looks for a Kubernetes service in the cache matching the provided
+// namespace, name and UDP port, and returns a DAG service for it.
+func (d *DAG) EnsureUDPService(meta types.NamespacedName, port intstr.IntOrString, cache *KubernetesCache, enableExternalNameSvc bool) (*Service, error) {
+	svc, svcPort, err := cache.LookupUDPService(meta, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return newService(svc, svcPort, enableExternalNameSvc)
+}
+
+// EnsureStaticService returns a DAG service whose endpoints are the provided
+// static endpoints, rather than those of a Kubernetes service. Endpoints that
+// do not set a port use the given port. If static endpoints are not enabled,
+// or any of the endpoints is invalid, an error is returned.
+func (d *DAG) EnsureStaticService(meta types.NamespacedName, port int, endpoints []contour_api_v1.StaticEndpoint, enableStaticEndpoints bool) (*Service, error) {
+	if !enableStaticEndpoints {
+		return nil, fmt.Errorf("%s/%s has static endpoints, these are not currently enabled. See the config.enableStaticEndpoints config file setting", meta.Namespace, meta.Name)
+	}
+
+	static, err := staticEndpoints(endpoints, port)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", meta.Namespace, meta.Name, err)
+	}
+
+	return &Service{
+		Weighted: WeightedService{
+			ServiceName:      meta.Name,
+			ServiceNamespace: meta.Namespace,
+			ServicePort: v1.ServicePort{
+				Protocol: v1.ProtocolTCP,
+				Port:     int32(port),
+			},
+			Weight: 1,
+		},
+		StaticEndpoints: static,
+	}, nil
+}
+
+// staticEndpoints validates the static endpoints of a service. Endpoints
+// must be IP addresses that are routable, so that static endpoints cannot
+// be used to reach Envoy itself, or link-local services such as cloud
+// instance metadata.
+func staticEndpoints(endpoints []contour_api_v1.StaticEndpoint, port int) ([]StaticEndpoint, error) {
+	var static []StaticEndpoint
+	for _, ep := range endpoints {
+		ip := net.ParseIP(ep.Address)
+		if ip == nil {
+			return nil, fmt.Errorf("static endpoint %q is not an IP address", ep.Address)
+		}
+		if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+			return nil, fmt.Errorf("static endpoint %q is not a routable address", ep.Address)
+		}
+
+		p := port
+		if ep.Port != 0 {
+			p = ep.Port
+		}
+		if p < 1 || p > 65535 {
+			return nil, fmt.Errorf("static endpoint %q: port must be in the range 1-65535", ep.Address)
+		}
+
+		static = append(static, StaticEndpoint{
+			Address: ip.String(),
+			Port:    uint32(p),
+		})
+	}
+
+	return static, nil
+}
+
+func newService(svc *v1.Service, svcPort v1.ServicePort, enableExternalNameSvc bool) (*Service, error) {
+	err := validateExternalName(svc, enableExternalNameSvc)
 	if err != nil {
 		return nil, err
 	}
@@ -58,6 +134,7 @@ func (d *DAG) EnsureService(meta types.NamespacedName, port intstr.IntOrString,
 		MaxRequests:        annotation.MaxRequests(svc),
 		MaxRetries:         annotation.MaxRetries(svc),
 		ExternalName:       externalName(svc),
+		DNSPolicy:          serviceDNSPolicy(svc),
 	}, nil
 }
 
@@ -102,6 +179,36 @@ func upstreamProtocol(svc *v1.Service, port v1.ServicePort) string {
 	return protocol
 }
 
+// serviceDNSPolicy returns the DNS policy set by the annotations
+// of an ExternalName Service. Unparsable resolver addresses are
+// ignored, as with other Service annotations.
+func serviceDNSPolicy(svc *v1.Service) *DNSPolicy {
+	if externalName(svc) == "" {
+		return nil
+	}
+
+	var resolvers []DNSResolver
+	for _, r := range annotation.DNSResolvers(svc) {
+		resolver, err := parseDNSResolver(r)
+		if err != nil {
+			continue
+		}
+		resolvers = append(resolvers, resolver)
+	}
+
+	discoveryType := annotation.DNSDiscoveryType(svc)
+	lookupFamily := annotation.DNSLookupFamily(svc)
+	if discoveryType == "" && len(resolvers) == 0 && lookupFamily == "" {
+		return nil
+	}
+
+	return &DNSPolicy{
+		DiscoveryType: discoveryType,
+		Resolvers:     resolvers,
+		LookupFamily:  lookupFamily,
+	}
+}
+
 func externalName(svc *v1.Service) string {
 	if svc.Spec.Type != v1.ServiceTypeExternalName {
 		return ""
@@ -179,6 +286,10 @@ func (d *DAG) GetClusters() []*Cluster {
 				res = append(res, vhost.TCPProxy.Clusters...)
 			}
 		}
+
+		if listener.UDPProxy != nil {
+			res = append(res, listener.UDPProxy.Cluster)
+		}
 	}
 
 	return res
@@ -188,19 +299,30 @@ func (d *DAG) GetServiceClusters() []*ServiceCluster {
 	var res []*ServiceCluster
 
 	for _, cluster := range d.GetClusters() {
+		// Static endpoints are set on the Envoy cluster
+		// itself, rather than discovered with EDS.
+		if len(cluster.Upstream.StaticEndpoints) > 0 {
+			continue
+		}
+
 		// A Service has only one WeightedService entry. Fake up a
 		// ServiceCluster so that the visitor can pretend to not
 		// know this.
+		w := cluster.Upstream.Weighted
+		w.HealthCheckPort = cluster.HealthCheckPort()
+
 		c := &ServiceCluster{
-			ClusterName: xds.ClusterLoadAssignmentName(
-				types.NamespacedName{
-					Name:      cluster.Upstream.Weighted.ServiceName,
-					Namespace: cluster.Upstream.Weighted.ServiceNamespace,
-				},
-				cluster.Upstream.Weighted.ServicePort.Name),
-			Services: []WeightedService{
-				cluster.Upstream.Weighted,
-			},
+			ClusterName: cluster.ClusterLoadAssignmentName(),
+			Services:    []WeightedService{w},
+		}
+
+		// The endpoints of the backups follow those of the
+		// Upstream, each at the next lower priority.
+		for i, b := range cluster.Backups {
+			bw := b.Weighted
+			bw.HealthCheckPort = w.HealthCheckPort
+			bw.Priority = uint32(i + 1)
+			c.Services = append(c.Services, bw)
 		}
 
 		res = append(res, c)
@@ -310,5 +432,5 @@ func validSecret(s *v1.Secret) error {
 		return fmt.Errorf("empty %q key", v1.TLSPrivateKeyKey)
 	}
 
-	return nil
+	return validateCertificateChain(s.Data[v1.TLSCertKey])
 }
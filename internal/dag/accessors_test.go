@@ -17,6 +17,7 @@ import (
 	"errors"
 	"testing"
 
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -167,3 +168,92 @@ func TestBuilderLookupService(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureStaticService(t *testing.T) {
+	meta := types.NamespacedName{Name: "legacy", Namespace: "default"}
+
+	tests := map[string]struct {
+		endpoints             []contour_api_v1.StaticEndpoint
+		enableStaticEndpoints bool
+		want                  *Service
+		wantErr               string
+	}{
+		"endpoints default to the service port": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "10.0.0.10"},
+				{Address: "2001:db8::10", Port: 8443},
+			},
+			enableStaticEndpoints: true,
+			want: &Service{
+				Weighted: WeightedService{
+					Weight:           1,
+					ServiceName:      "legacy",
+					ServiceNamespace: "default",
+					ServicePort: v1.ServicePort{
+						Protocol: "TCP",
+						Port:     8080,
+					},
+				},
+				StaticEndpoints: []StaticEndpoint{
+					{Address: "10.0.0.10", Port: 8080},
+					{Address: "2001:db8::10", Port: 8443},
+				},
+			},
+		},
+		"static endpoints are disabled": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "10.0.0.10"},
+			},
+			wantErr: "default/legacy has static endpoints, these are not currently enabled. See the config.enableStaticEndpoints config file setting",
+		},
+		"endpoint is a DNS name": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "legacy.example.com"},
+			},
+			enableStaticEndpoints: true,
+			wantErr:               `default/legacy: static endpoint "legacy.example.com" is not an IP address`,
+		},
+		"endpoint is loopback": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "127.0.0.1"},
+			},
+			enableStaticEndpoints: true,
+			wantErr:               `default/legacy: static endpoint "127.0.0.1" is not a routable address`,
+		},
+		"endpoint is link-local": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "169.254.169.254"},
+			},
+			enableStaticEndpoints: true,
+			wantErr:               `default/legacy: static endpoint "169.254.169.254" is not a routable address`,
+		},
+		"endpoint is unspecified": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "::"},
+			},
+			enableStaticEndpoints: true,
+			wantErr:               `default/legacy: static endpoint "::" is not a routable address`,
+		},
+		"endpoint port is out of range": {
+			endpoints: []contour_api_v1.StaticEndpoint{
+				{Address: "10.0.0.10", Port: 65536},
+			},
+			enableStaticEndpoints: true,
+			wantErr:               `default/legacy: static endpoint "10.0.0.10": port must be in the range 1-65535`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var dag DAG
+
+			got, gotErr := dag.EnsureStaticService(meta, 8080, tc.endpoints, tc.enableStaticEndpoints)
+			assert.Equal(t, tc.want, got)
+			if tc.wantErr == "" {
+				assert.NoError(t, gotErr)
+			} else {
+				assert.EqualError(t, gotErr, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"math"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ACMEChallengePrefix is the path prefix of ACME HTTP-01 challenge requests.
+const ACMEChallengePrefix = "/.well-known/acme-challenge/"
+
+// ACMEChallengeProcessor routes ACME HTTP-01 challenge requests on
+// every insecure virtual host to a solver Service.
+type ACMEChallengeProcessor struct {
+	logrus.FieldLogger
+
+	// Service is the solver Service.
+	Service types.NamespacedName
+
+	// Port is the port of the solver Service.
+	Port int
+
+	// EnableExternalNameService allows the solver Service to
+	// be of type ExternalName.
+	EnableExternalNameService bool
+}
+
+var _ Processor = &ACMEChallengeProcessor{}
+
+// Run adds a challenge route to each insecure virtual host built by
+// the preceding processors, replacing any route with the same prefix.
+// It must run before the ListenerProcessor.
+func (p *ACMEChallengeProcessor) Run(dag *DAG, cache *KubernetesCache) {
+	if len(dag.VirtualHosts) == 0 {
+		return
+	}
+
+	service, err := dag.EnsureService(p.Service, intstr.FromInt(p.Port), cache, p.EnableExternalNameService)
+	if err != nil {
+		p.WithError(err).
+			WithField("name", p.Service.Name).
+			WithField("namespace", p.Service.Namespace).
+			Error("unresolved ACME challenge solver service reference")
+		return
+	}
+
+	for _, vhost := range dag.VirtualHosts {
+		if !vhost.Valid() {
+			continue
+		}
+
+		vhost.addRoute(&Route{
+			PathMatchCondition: &PrefixMatchCondition{Prefix: ACMEChallengePrefix, PrefixMatchType: PrefixMatchString},
+			// The challenge route must take precedence over
+			// routes of any priority that match its prefix.
+			Priority: math.MaxInt32,
+			Clusters: []*Cluster{{
+				Upstream: service,
+				Protocol: service.Protocol,
+			}},
+			// The solver must be reachable regardless of any
+			// rate limits configured on the virtual host.
+			RateLimitPolicy: &RateLimitPolicy{
+				Local:  &LocalRateLimitPolicy{Disabled: true},
+				Global: &GlobalRateLimitPolicy{Disabled: true},
+			},
+		})
+	}
+}
@@ -62,6 +62,7 @@ func (b *Builder) Build() *DAG {
 	dag := &DAG{
 		VirtualHosts:       map[string]*VirtualHost{},
 		SecureVirtualHosts: map[string]*SecureVirtualHost{},
+		UDPProxies:         map[int]*UDPProxy{},
 		StatusCache:        status.NewCache(gatewayNSName, gatewayController),
 	}
 
@@ -16,16 +16,19 @@ package dag
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"testing"
 	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/projectcontour/contour/internal/gatewayapi"
 	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -466,6 +469,85 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 		},
 	}
 
+	dnsService := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "projectcontour",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "dns-tcp",
+				Protocol:   "TCP",
+				Port:       53,
+				TargetPort: intstr.FromInt(5353),
+			}, {
+				Name:       "dns",
+				Protocol:   "UDP",
+				Port:       53,
+				TargetPort: intstr.FromInt(5353),
+			}},
+		},
+	}
+
+	dnsService2 := dnsService.DeepCopy()
+	dnsService2.Name = "dns2"
+
+	gatewayUDPAllNamespaces := &gatewayapi_v1alpha2.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "contour",
+			Namespace: "projectcontour",
+		},
+		Spec: gatewayapi_v1alpha2.GatewaySpec{
+			GatewayClassName: gatewayapi_v1alpha2.ObjectName(validClass.Name),
+			Listeners: []gatewayapi_v1alpha2.Listener{{
+				Port:     53,
+				Protocol: gatewayapi_v1alpha2.UDPProtocolType,
+				AllowedRoutes: &gatewayapi_v1alpha2.AllowedRoutes{
+					Namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
+						From: gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromAll),
+					},
+				},
+			}},
+		},
+	}
+
+	udpRoute := func(name string, created time.Time, serviceName string) *gatewayapi_v1alpha2.UDPRoute {
+		return &gatewayapi_v1alpha2.UDPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "projectcontour",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: gatewayapi_v1alpha2.UDPRouteSpec{
+				CommonRouteSpec: gatewayapi_v1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayapi_v1alpha2.ParentRef{gatewayapi.GatewayParentRef("projectcontour", "contour")},
+				},
+				Rules: []gatewayapi_v1alpha2.UDPRouteRule{{
+					BackendRefs: gatewayapi.UDPRouteBackendRef(serviceName, 53),
+				}},
+			},
+		}
+	}
+
+	udpListener := func(s *v1.Service) *Listener {
+		return &Listener{
+			Name: "ingress_udp_53",
+			Port: 53,
+			UDPProxy: &UDPProxy{
+				Cluster: &Cluster{
+					Upstream: &Service{
+						Weighted: WeightedService{
+							Weight:           1,
+							ServiceName:      s.Name,
+							ServiceNamespace: s.Namespace,
+							ServicePort:      s.Spec.Ports[1],
+						},
+					},
+				},
+			},
+		}
+	}
+
 	tests := map[string]struct {
 		objs         []interface{}
 		gatewayclass *gatewayapi_v1alpha2.GatewayClass
@@ -867,6 +949,45 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 		},
 		// END TLSRoute<->Gateway selection test cases
 
+		"insert basic UDPRoute": {
+			gatewayclass: validClass,
+			gateway:      gatewayUDPAllNamespaces,
+			objs: []interface{}{
+				dnsService,
+				udpRoute("dns", time.Unix(0, 0), "dns"),
+			},
+			want: listeners(udpListener(dnsService)),
+		},
+		"UDPRoute to a service without a UDP port": {
+			gatewayclass: validClass,
+			gateway:      gatewayUDPAllNamespaces,
+			objs: []interface{}{
+				kuardService,
+				udpRoute("dns", time.Unix(0, 0), "kuard"),
+			},
+			want: listeners(),
+		},
+		"UDPRoute: Gateway selects non-UDPRoutes": {
+			gatewayclass: validClass,
+			gateway:      gatewayHTTPAllNamespaces,
+			objs: []interface{}{
+				dnsService,
+				udpRoute("dns", time.Unix(0, 0), "dns"),
+			},
+			want: listeners(),
+		},
+		"UDPRoutes bound to the same port, the oldest is programmed": {
+			gatewayclass: validClass,
+			gateway:      gatewayUDPAllNamespaces,
+			objs: []interface{}{
+				dnsService,
+				dnsService2,
+				udpRoute("newer", time.Unix(1, 0), "dns2"),
+				udpRoute("older", time.Unix(0, 0), "dns"),
+			},
+			want: listeners(udpListener(dnsService)),
+		},
+
 		"TLSRoute with TLS.Mode=Passthrough is invalid if certificateRef is specified": {
 			gatewayclass: validClass,
 			gateway: &gatewayapi_v1alpha2.Gateway{
@@ -2762,6 +2883,159 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 				},
 			),
 		},
+		"HTTP forward with multiple request header modifiers": {
+			gatewayclass: validClass,
+			gateway:      gatewayHTTPAllNamespaces,
+			objs: []interface{}{
+				kuardService,
+				&gatewayapi_v1alpha2.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "basic",
+						Namespace: "projectcontour",
+					},
+					Spec: gatewayapi_v1alpha2.HTTPRouteSpec{
+						CommonRouteSpec: gatewayapi_v1alpha2.CommonRouteSpec{
+							ParentRefs: []gatewayapi_v1alpha2.ParentRef{gatewayapi.GatewayParentRef("projectcontour", "contour")},
+						},
+						Hostnames: []gatewayapi_v1alpha2.Hostname{
+							"test.projectcontour.io",
+						},
+						Rules: []gatewayapi_v1alpha2.HTTPRouteRule{{
+							Matches: gatewayapi.HTTPRouteMatch(gatewayapi_v1alpha2.PathMatchPathPrefix, "/"),
+							BackendRefs: []gatewayapi_v1alpha2.HTTPBackendRef{
+								{
+									BackendRef: gatewayapi_v1alpha2.BackendRef{
+										BackendObjectReference: gatewayapi.ServiceBackendObjectRef("kuard", 8080),
+										Weight:                 pointer.Int32(1),
+									},
+									Filters: []gatewayapi_v1alpha2.HTTPRouteFilter{
+										{
+											Type: gatewayapi_v1alpha2.HTTPRouteFilterRequestHeaderModifier,
+											RequestHeaderModifier: &gatewayapi_v1alpha2.HTTPRequestHeaderFilter{
+												Set: []gatewayapi_v1alpha2.HTTPHeader{
+													{Name: gatewayapi_v1alpha2.HTTPHeaderName("custom-header-set"), Value: "foo-bar"},
+												},
+											},
+										},
+										{
+											Type: gatewayapi_v1alpha2.HTTPRouteFilterRequestHeaderModifier,
+											RequestHeaderModifier: &gatewayapi_v1alpha2.HTTPRequestHeaderFilter{
+												Set: []gatewayapi_v1alpha2.HTTPHeader{
+													{Name: gatewayapi_v1alpha2.HTTPHeaderName("custom-header-set"), Value: "ignored"},
+												},
+												Add: []gatewayapi_v1alpha2.HTTPHeader{
+													{Name: "custom-header-add", Value: "ignored"},
+												},
+											},
+										},
+									},
+								},
+							},
+						}},
+					},
+				},
+			},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(virtualhost("test.projectcontour.io",
+						&Route{
+							PathMatchCondition: prefixString("/"),
+							Clusters:           clusterHeaders(map[string]string{"Custom-Header-Set": "foo-bar"}, map[string]string{}, nil, "", service(kuardService)),
+						},
+					)),
+				},
+			),
+		},
+		"HTTPRoute rule with request redirect filter with scheme only": {
+			gatewayclass: validClass,
+			gateway:      gatewayHTTPAllNamespaces,
+			objs: []interface{}{
+				kuardService,
+				&gatewayapi_v1alpha2.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "basic",
+						Namespace: "projectcontour",
+					},
+					Spec: gatewayapi_v1alpha2.HTTPRouteSpec{
+						CommonRouteSpec: gatewayapi_v1alpha2.CommonRouteSpec{
+							ParentRefs: []gatewayapi_v1alpha2.ParentRef{gatewayapi.GatewayParentRef("projectcontour", "contour")},
+						},
+						Hostnames: []gatewayapi_v1alpha2.Hostname{
+							"test.projectcontour.io",
+						},
+						Rules: []gatewayapi_v1alpha2.HTTPRouteRule{{
+							Matches: gatewayapi.HTTPRouteMatch(gatewayapi_v1alpha2.PathMatchPathPrefix, "/"),
+							Filters: []gatewayapi_v1alpha2.HTTPRouteFilter{{
+								Type: gatewayapi_v1alpha2.HTTPRouteFilterRequestRedirect,
+								RequestRedirect: &gatewayapi_v1alpha2.HTTPRequestRedirectFilter{
+									Scheme: pointer.String("https"),
+								},
+							}},
+						}},
+					},
+				},
+			},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(virtualhost("test.projectcontour.io",
+						&Route{
+							PathMatchCondition: prefixString("/"),
+							Redirect: &Redirect{
+								Scheme: "https",
+							},
+						},
+					)),
+				},
+			),
+		},
+		"HTTPRoute rule with request redirect filter with port only": {
+			gatewayclass: validClass,
+			gateway:      gatewayHTTPAllNamespaces,
+			objs: []interface{}{
+				kuardService,
+				&gatewayapi_v1alpha2.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "basic",
+						Namespace: "projectcontour",
+					},
+					Spec: gatewayapi_v1alpha2.HTTPRouteSpec{
+						CommonRouteSpec: gatewayapi_v1alpha2.CommonRouteSpec{
+							ParentRefs: []gatewayapi_v1alpha2.ParentRef{gatewayapi.GatewayParentRef("projectcontour", "contour")},
+						},
+						Hostnames: []gatewayapi_v1alpha2.Hostname{
+							"test.projectcontour.io",
+						},
+						Rules: []gatewayapi_v1alpha2.HTTPRouteRule{{
+							Matches: gatewayapi.HTTPRouteMatch(gatewayapi_v1alpha2.PathMatchPathPrefix, "/"),
+							Filters: []gatewayapi_v1alpha2.HTTPRouteFilter{{
+								Type: gatewayapi_v1alpha2.HTTPRouteFilterRequestRedirect,
+								RequestRedirect: &gatewayapi_v1alpha2.HTTPRequestRedirectFilter{
+									Port: gatewayapi.PortNumPtr(8080),
+								},
+							}},
+						}},
+					},
+				},
+			},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(virtualhost("test.projectcontour.io",
+						&Route{
+							PathMatchCondition: prefixString("/"),
+							Redirect: &Redirect{
+								PortNumber: 8080,
+							},
+						},
+					)),
+				},
+			),
+		},
 		"different weights for multiple forwardTos": {
 			gatewayclass: validClass,
 			gateway:      gatewayHTTPAllNamespaces,
@@ -5878,6 +6152,37 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxy21a is downstream validation, optional client
+	// certificates that are not verified, with the status
+	// of the client certificate forwarded in headers
+	proxy21a := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						Mode:                           contour_api_v1.ClientCertificateModeOptionalNoCA,
+						ForwardClientCertificateStatus: true,
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
 	// invalid because tcpproxy both includes another and
 	// has a list of services.
 	proxy37 := &contour_api_v1.HTTPProxy{
@@ -8994,35 +9299,68 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
-		"insert httpproxy with downstream verification, missing ca certificate": {
+		"insert httpproxy w/ tls termination mode w/ optional client certificates without a ca": {
 			objs: []interface{}{
-				proxy18, s1, sec1,
+				proxy21a, s1, sec1,
 			},
-			want: listeners(),
-		},
-		"insert httpproxy with invalid tcpproxy": {
-			objs: []interface{}{proxy37, s1},
-			want: listeners(),
-		},
-		"insert httpproxy with empty tcpproxy": {
-			objs: []interface{}{proxy37a, s1},
-			want: listeners(),
-		},
-		"insert httpproxy w/ tcpproxy w/ missing include": {
-			objs: []interface{}{proxy38, s1},
-			want: listeners(),
-		},
-		"insert httpproxy w/ tcpproxy w/ includes another root": {
-			objs: []interface{}{proxy38, proxy39, s1},
 			want: listeners(
 				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", routeUpgrade("/", service(s1))),
+					),
+				}, &Listener{
 					Name: HTTPS_LISTENER_NAME,
 					Port: 443,
 					SecureVirtualHosts: securevirtualhosts(
 						&SecureVirtualHost{
 							VirtualHost: VirtualHost{
-								Name: "www.example.com", // this is proxy39, not proxy38
-							},
+								Name: "example.com",
+								Routes: routes(
+									routeUpgrade("/", service(s1))),
+							},
+							MinTLSVersion: "1.2",
+							Secret:        secret(sec1),
+							DownstreamValidation: &PeerValidationContext{
+								SkipClientCertValidation:       true,
+								OptionalClientCertificate:      true,
+								ForwardClientCertificateStatus: true,
+							},
+						},
+					),
+				},
+			),
+		},
+		"insert httpproxy with downstream verification, missing ca certificate": {
+			objs: []interface{}{
+				proxy18, s1, sec1,
+			},
+			want: listeners(),
+		},
+		"insert httpproxy with invalid tcpproxy": {
+			objs: []interface{}{proxy37, s1},
+			want: listeners(),
+		},
+		"insert httpproxy with empty tcpproxy": {
+			objs: []interface{}{proxy37a, s1},
+			want: listeners(),
+		},
+		"insert httpproxy w/ tcpproxy w/ missing include": {
+			objs: []interface{}{proxy38, s1},
+			want: listeners(),
+		},
+		"insert httpproxy w/ tcpproxy w/ includes another root": {
+			objs: []interface{}{proxy38, proxy39, s1},
+			want: listeners(
+				&Listener{
+					Name: HTTPS_LISTENER_NAME,
+					Port: 443,
+					SecureVirtualHosts: securevirtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "www.example.com", // this is proxy39, not proxy38
+							},
 							TCPProxy: &TCPProxy{
 								Clusters: clusters(
 									service(s1),
@@ -11087,6 +11425,158 @@ func TestDAGRootNamespaces(t *testing.T) {
 	}
 }
 
+func TestDAGDenyByDefault(t *testing.T) {
+	service := func(namespace string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: namespace,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+
+	root := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []contour_api_v1.Include{{
+				Name:      "blog",
+				Namespace: "teams",
+			}},
+		},
+	}
+
+	blog := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "blog",
+			Namespace: "teams",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// teamRoot is a root HTTPProxy outside the root namespaces.
+	teamRoot := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "team-com",
+			Namespace: "teams",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "team.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "teams",
+		},
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{{
+				Host:             "ingress.example.com",
+				IngressRuleValue: ingressrulev1value(backendv1("kuard", intstr.FromInt(8080))),
+			}},
+		},
+	}
+
+	objs := []interface{}{root, blog, teamRoot, ingress, service("teams")}
+
+	tests := map[string]struct {
+		rootNamespaces []string
+		denyByDefault  bool
+		wantHosts      []string
+		wantIgnored    []Origin
+	}{
+		"root namespaces": {
+			rootNamespaces: []string{"roots"},
+			wantHosts:      []string{"example.com", "ingress.example.com"},
+			wantIgnored: []Origin{
+				{Kind: "HTTPProxy", Namespace: "teams", Name: "team-com"},
+			},
+		},
+		"deny by default": {
+			rootNamespaces: []string{"roots"},
+			denyByDefault:  true,
+			wantHosts:      []string{"example.com"},
+			wantIgnored: []Origin{
+				{Kind: "HTTPProxy", Namespace: "teams", Name: "team-com"},
+				{Kind: "Ingress", Namespace: "teams", Name: "kuard"},
+			},
+		},
+		"deny by default without root namespaces": {
+			denyByDefault: true,
+			wantIgnored: []Origin{
+				{Kind: "HTTPProxy", Namespace: "roots", Name: "example-com"},
+				{Kind: "HTTPProxy", Namespace: "teams", Name: "blog"},
+				{Kind: "HTTPProxy", Namespace: "teams", Name: "team-com"},
+				{Kind: "Ingress", Namespace: "teams", Name: "kuard"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					RootNamespaces: tc.rootNamespaces,
+					DenyByDefault:  tc.denyByDefault,
+					FieldLogger:    fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&IngressProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+
+			for _, o := range objs {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			var hosts []string
+			for host := range dag.VirtualHosts {
+				hosts = append(hosts, host)
+			}
+			assert.ElementsMatch(t, tc.wantHosts, hosts)
+
+			var ignored []Origin
+			for _, o := range dag.Ignored {
+				ignored = append(ignored, o.Origin)
+			}
+			assert.ElementsMatch(t, tc.wantIgnored, ignored)
+		})
+	}
+}
+
 func TestHttpPaths(t *testing.T) {
 	tests := map[string]struct {
 		rule networking_v1.IngressRule
@@ -12011,6 +12501,28 @@ func TestDefaultHeadersPolicies(t *testing.T) {
 		},
 	}
 
+	proxyDisableGlobalHeaders := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			DisableGlobalHeadersPolicy: true,
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
 	s1 := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "kuard",
@@ -12130,6 +12642,36 @@ func TestDefaultHeadersPolicies(t *testing.T) {
 			},
 			Remove: []string{"K-Nada"},
 		},
+	}, {
+		name: "httpproxy opting out of global headers policies",
+		objs: []interface{}{
+			proxyDisableGlobalHeaders, s1,
+		},
+		want: listeners(
+			&Listener{
+				Name: HTTP_LISTENER_NAME,
+				Port: 80,
+				VirtualHosts: virtualhosts(
+					virtualhost("example.com", &Route{
+						PathMatchCondition: prefixString("/"),
+						Clusters:           clusters(service(s1)),
+					},
+					),
+				),
+			},
+		),
+		httpProxyReqHp: &HeadersPolicy{
+			Set: map[string]string{
+				"Custom-Header-Set": "foo-bar",
+			},
+			Remove: []string{"K-Nada"},
+		},
+		httpProxyRespHp: &HeadersPolicy{
+			Set: map[string]string{
+				"Custom-Header-Set": "foo-bar",
+			},
+			Remove: []string{"K-Nada"},
+		},
 	},
 	}
 
@@ -12172,6 +12714,1948 @@ func TestDefaultHeadersPolicies(t *testing.T) {
 	}
 }
 
+func TestPermitInsecurePrefixes(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acme",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				HTTPSRedirectPolicy: &contour_api_v1.HTTPSRedirectPolicy{
+					StatusCode: 308,
+					Port:       8443,
+				},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/public/docs",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/.well-known",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s2.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	redirected := routeUpgrade("/", service(s1))
+	redirected.HTTPSRedirectPolicy = &HTTPSRedirectPolicy{
+		StatusCode: 308,
+		PortNumber: 8443,
+	}
+
+	want := &Listener{
+		Name: HTTP_LISTENER_NAME,
+		Port: 80,
+		VirtualHosts: virtualhosts(
+			virtualhost("example.com",
+				redirected,
+				routeUpgrade("/.well-known", service(s2)),
+				// served by the most specific route for the prefix.
+				prefixroute("/.well-known/acme-challenge/", service(s2)),
+				// added for the "/" route.
+				prefixroute("/public", service(s1)),
+				// not redirected because the route is under a prefix.
+				prefixroute("/public/docs", service(s1)),
+			),
+		),
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{
+				PermitInsecurePrefixes: []string{"/.well-known/acme-challenge/", "/public"},
+			},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{sec1, s1, s2, proxy} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	for _, l := range dag.Listeners {
+		if l.Port == 80 {
+			assert.Equal(t, want, l)
+			return
+		}
+	}
+	t.Fatal("HTTP listener not found")
+}
+
+func TestTrafficSplitPolicyRoutes(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-canary",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(policy *contour_api_v1.TrafficSplitPolicy) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-com",
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []contour_api_v1.Route{{
+					TrafficSplitPolicy: policy,
+					Services: []contour_api_v1.Service{{
+						Name:   s1.Name,
+						Port:   8080,
+						Weight: 90,
+					}, {
+						Name:   s2.Name,
+						Port:   8080,
+						Weight: 10,
+					}},
+				}},
+			},
+		}
+	}
+
+	stable := &Cluster{Upstream: service(s1), Weight: 90}
+	canary := &Cluster{Upstream: service(s2), Weight: 10}
+
+	// withCookie returns a copy of the cluster setting the pinning cookie.
+	withCookie := func(c *Cluster) *Cluster {
+		wc := *c
+		wc.ResponseHeadersPolicy = &HeadersPolicy{
+			Add: map[string]string{"Set-Cookie": "canary=" + c.Upstream.Weighted.ServiceName + "; Path=/"},
+		}
+		return &wc
+	}
+
+	headerPinned := func(c *Cluster) *Route {
+		return &Route{
+			PathMatchCondition: prefixString("/"),
+			HeaderMatchConditions: []HeaderMatchCondition{{
+				Name:      "X-Canary",
+				Value:     c.Upstream.Weighted.ServiceName,
+				MatchType: HeaderMatchTypeExact,
+			}},
+			Clusters: []*Cluster{c},
+		}
+	}
+
+	cookiePinned := func(c *Cluster, header bool) *Route {
+		r := &Route{
+			PathMatchCondition: prefixString("/"),
+			HeaderMatchConditions: []HeaderMatchCondition{{
+				Name:      "Cookie",
+				Value:     `(.*;\s*)?canary=` + c.Upstream.Weighted.ServiceName + `(;.*)?`,
+				MatchType: HeaderMatchTypeRegex,
+			}},
+			Clusters: []*Cluster{c},
+		}
+		if header {
+			r.HeaderMatchConditions = append(r.HeaderMatchConditions, HeaderMatchCondition{
+				Name:      "X-Canary",
+				MatchType: HeaderMatchTypePresent,
+				Invert:    true,
+			})
+		}
+		return r
+	}
+
+	tests := map[string]struct {
+		policy *contour_api_v1.TrafficSplitPolicy
+		want   *VirtualHost
+	}{
+		"header": {
+			policy: &contour_api_v1.TrafficSplitPolicy{
+				HeaderName: "X-Canary",
+			},
+			want: virtualhost("example.com",
+				&Route{
+					PathMatchCondition: prefixString("/"),
+					Clusters:           []*Cluster{stable, canary},
+					TrafficSplitPolicy: &TrafficSplitPolicy{HeaderName: "X-Canary"},
+				},
+				headerPinned(stable),
+				headerPinned(canary),
+			),
+		},
+		"cookie": {
+			policy: &contour_api_v1.TrafficSplitPolicy{
+				CookieName: "canary",
+			},
+			want: virtualhost("example.com",
+				&Route{
+					PathMatchCondition: prefixString("/"),
+					Clusters:           []*Cluster{withCookie(stable), withCookie(canary)},
+					TrafficSplitPolicy: &TrafficSplitPolicy{CookieName: "canary"},
+				},
+				cookiePinned(stable, false),
+				cookiePinned(canary, false),
+			),
+		},
+		"header and cookie": {
+			policy: &contour_api_v1.TrafficSplitPolicy{
+				HeaderName: "X-Canary",
+				CookieName: "canary",
+			},
+			want: virtualhost("example.com",
+				&Route{
+					PathMatchCondition: prefixString("/"),
+					Clusters:           []*Cluster{withCookie(stable), withCookie(canary)},
+					TrafficSplitPolicy: &TrafficSplitPolicy{HeaderName: "X-Canary", CookieName: "canary"},
+				},
+				headerPinned(stable),
+				headerPinned(canary),
+				cookiePinned(stable, true),
+				cookiePinned(canary, true),
+			),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+
+			for _, o := range []interface{}{s1, s2, proxy(tc.policy)} {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			for _, l := range dag.Listeners {
+				if l.Port == 80 {
+					assert.Equal(t, virtualhosts(tc.want), l.VirtualHosts)
+					return
+				}
+			}
+			t.Fatal("HTTP listener not found")
+		})
+	}
+}
+
+func TestACMEChallengeProcessor(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acme-solver",
+			Namespace: "cert-manager",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8089,
+				TargetPort: intstr.FromInt(8089),
+			}},
+		},
+	}
+
+	insecure := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: ACMEChallengePrefix,
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	secure := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secure-example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "secure.example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	solver := prefixroute(ACMEChallengePrefix, service(s2))
+	solver.Priority = math.MaxInt32
+	solver.RateLimitPolicy = &RateLimitPolicy{
+		Local:  &LocalRateLimitPolicy{Disabled: true},
+		Global: &GlobalRateLimitPolicy{Disabled: true},
+	}
+
+	tests := map[string]struct {
+		objs []interface{}
+		want []*Listener
+	}{
+		"solver routes added to insecure virtual hosts": {
+			objs: []interface{}{sec1, s1, s2, insecure, secure},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						// replaces the route with the same prefix.
+						virtualhost("example.com", prefixroute("/", service(s1)), solver),
+						virtualhost("secure.example.com", routeUpgrade("/", service(s1)), solver),
+					),
+				},
+				&Listener{
+					Name: HTTPS_LISTENER_NAME,
+					Port: 443,
+					SecureVirtualHosts: securevirtualhosts(
+						securevirtualhost("secure.example.com", sec1, routeUpgrade("/", service(s1))),
+					),
+				},
+			),
+		},
+		"missing solver service": {
+			objs: []interface{}{s1, insecure},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", prefixroute("/", service(s1)), prefixroute(ACMEChallengePrefix, service(s1))),
+					),
+				},
+			),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ACMEChallengeProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+						Service:     types.NamespacedName{Name: s2.Name, Namespace: s2.Namespace},
+						Port:        8089,
+					},
+					&ListenerProcessor{},
+				},
+			}
+
+			for _, o := range tc.objs {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			got := make(map[int]*Listener)
+			for _, l := range dag.Listeners {
+				got[l.Port] = l
+			}
+
+			want := make(map[int]*Listener)
+			for _, v := range tc.want {
+				want[v.Port] = v
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestFilterExemptProcessor(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/metrics/envoy",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	exempt := func(r *Route) *Route {
+		r.AuthDisabled = true
+		r.RateLimitPolicy = &RateLimitPolicy{
+			Global: &GlobalRateLimitPolicy{Disabled: true},
+		}
+		return r
+	}
+
+	tests := map[string]struct {
+		prefixes []string
+		want     []*Listener
+	}{
+		"no prefixes": {
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							prefixroute("/", service(s1)),
+							prefixroute("/metrics/envoy", service(s1)),
+						),
+					),
+				},
+			),
+		},
+		"routes under a prefix are exempt and routes serving a prefix are added": {
+			prefixes: []string{"/healthz", "/metrics"},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							prefixroute("/", service(s1)),
+							exempt(prefixroute("/healthz", service(s1))),
+							exempt(prefixroute("/metrics", service(s1))),
+							exempt(prefixroute("/metrics/envoy", service(s1))),
+						),
+					),
+				},
+			),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&FilterExemptProcessor{
+						Prefixes: tc.prefixes,
+					},
+					&ListenerProcessor{},
+				},
+			}
+
+			builder.Source.Insert(s1)
+			builder.Source.Insert(proxy)
+			dag := builder.Build()
+
+			got := make(map[int]*Listener)
+			for _, l := range dag.Listeners {
+				got[l.Port] = l
+			}
+
+			want := make(map[int]*Listener)
+			for _, v := range tc.want {
+				want[v.Port] = v
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestBuilderDefaultLocalRateLimitPolicy(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(name, fqdn string, rlp *contour_api_v1.RateLimitPolicy, optOut bool) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s1.Namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn:            fqdn,
+					RateLimitPolicy: rlp,
+				},
+				DisableDefaultLocalRateLimitPolicy: optOut,
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: s1.Namespace,
+		},
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{{
+				Host:             "ingress.example.com",
+				IngressRuleValue: ingressrulev1value(backendv1(s1.Name, intstr.FromInt(8080))),
+			}},
+		},
+	}
+
+	def := &LocalRateLimitPolicy{
+		MaxTokens:     100,
+		TokensPerFill: 100,
+		FillInterval:  time.Second,
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&IngressProcessor{
+				FieldLogger:                 fixture.NewTestLogger(t),
+				DefaultLocalRateLimitPolicy: def,
+			},
+			&HTTPProxyProcessor{
+				DefaultLocalRateLimitPolicy: def,
+			},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		s1,
+		ingress,
+		proxy("default", "default.example.com", nil, false),
+		proxy("override", "override.example.com", &contour_api_v1.RateLimitPolicy{
+			Local: &contour_api_v1.LocalRateLimitPolicy{
+				Requests: 5,
+				Unit:     "minute",
+			},
+		}, false),
+		proxy("opt-out", "opt-out.example.com", nil, true),
+	} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	assert.Equal(t, &RateLimitPolicy{Local: def}, dag.VirtualHosts["ingress.example.com"].RateLimitPolicy)
+	assert.Equal(t, &RateLimitPolicy{Local: def}, dag.VirtualHosts["default.example.com"].RateLimitPolicy)
+	assert.Equal(t, &RateLimitPolicy{
+		Local: &LocalRateLimitPolicy{
+			MaxTokens:     5,
+			TokensPerFill: 5,
+			FillInterval:  time.Minute,
+		},
+	}, dag.VirtualHosts["override.example.com"].RateLimitPolicy)
+	assert.Nil(t, dag.VirtualHosts["opt-out.example.com"].RateLimitPolicy)
+}
+
+func TestBuilderDebugHeaders(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "debug",
+			Namespace: s1.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:         "debug.example.com",
+				DebugHeaders: true,
+			},
+			Routes: []contour_api_v1.Route{
+				{
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				},
+				{
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/custom",
+					}},
+					ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
+						Set: []contour_api_v1.HeaderValue{{
+							Name:  "X-Contour-Route",
+							Value: "custom",
+						}},
+					},
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				},
+			},
+		},
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+	builder.Source.Insert(s1)
+	builder.Source.Insert(proxy)
+	dag := builder.Build()
+
+	vhost := dag.VirtualHosts["debug.example.com"]
+	require.NotNil(t, vhost)
+
+	root := vhost.Routes["prefix: / type: string"]
+	require.NotNil(t, root)
+	assert.Equal(t, map[string]string{
+		"X-Contour-Httpproxy": "default/debug",
+		"X-Contour-Route":     "prefix: / type: string",
+	}, root.ResponseHeadersPolicy.Set)
+	require.Len(t, root.Clusters, 1)
+	assert.Equal(t, map[string]string{
+		"X-Contour-Service":       "default/kuard:8080",
+		"X-Contour-Upstream-Host": "%UPSTREAM_REMOTE_ADDRESS%",
+	}, root.Clusters[0].ResponseHeadersPolicy.Set)
+
+	custom := vhost.Routes["prefix: /custom type: string"]
+	require.NotNil(t, custom)
+	assert.Equal(t, map[string]string{
+		"X-Contour-Httpproxy": "default/debug",
+		"X-Contour-Route":     "custom",
+	}, custom.ResponseHeadersPolicy.Set)
+}
+
+func TestBuilderHSTSPolicy(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(policy *contour_api_v1.HSTSPolicy) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "hsts",
+				Namespace: s1.Namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "hsts.example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: sec1.Name,
+					},
+					HSTSPolicy: policy,
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		policy *contour_api_v1.HSTSPolicy
+		want   *HSTSPolicy
+		valid  bool
+	}{
+		"no policy": {
+			valid: true,
+		},
+		"policy": {
+			policy: &contour_api_v1.HSTSPolicy{
+				MaxAge:            "8760h",
+				IncludeSubDomains: true,
+			},
+			want: &HSTSPolicy{
+				MaxAge:            8760 * time.Hour,
+				IncludeSubDomains: true,
+			},
+			valid: true,
+		},
+		"invalid policy": {
+			policy: &contour_api_v1.HSTSPolicy{
+				MaxAge:  "1h",
+				Preload: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			for _, o := range []interface{}{sec1, s1, proxy(tc.policy)} {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			svhost := dag.SecureVirtualHosts["hsts.example.com"]
+			if !tc.valid {
+				// The proxy is invalid, so no routes are added.
+				assert.False(t, svhost != nil && svhost.Valid())
+				return
+			}
+			require.NotNil(t, svhost)
+			assert.Equal(t, tc.want, svhost.HSTSPolicy)
+		})
+	}
+}
+
+func TestBuilderRoutePriority(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(precedence contour_api_v1.RoutePrecedence) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: s1.Namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn:            "example.com",
+					RoutePrecedence: precedence,
+				},
+				Routes: []contour_api_v1.Route{{
+					Priority: 10,
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				}, {
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/api",
+					}},
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		proxy *contour_api_v1.HTTPProxy
+		want  map[string]int32
+	}{
+		"specificity": {
+			proxy: proxy(""),
+			want:  map[string]int32{"/": 0, "/api": 0},
+		},
+		"priority": {
+			proxy: proxy(contour_api_v1.RoutePrecedencePriority),
+			want:  map[string]int32{"/": 10, "/api": 0},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(s1)
+			builder.Source.Insert(tc.proxy)
+			dag := builder.Build()
+
+			vhost := dag.VirtualHosts["example.com"]
+			require.NotNil(t, vhost)
+
+			got := map[string]int32{}
+			for _, r := range vhost.Routes {
+				got[r.PathMatchCondition.(*PrefixMatchCondition).Prefix] = r.Priority
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestBuilderVirtualHostAliases(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(name, fqdn string, aliases ...string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s1.Namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn:    fqdn,
+					Aliases: aliases,
+					TLS: &contour_api_v1.TLS{
+						SecretName: sec1.Name,
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: s1.Name,
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		objs []interface{}
+		fqdn string
+		want []string
+	}{
+		"no aliases": {
+			objs: []interface{}{proxy("aliases", "example.com")},
+			fqdn: "example.com",
+		},
+		"aliases": {
+			objs: []interface{}{proxy("aliases", "example.com", "www.example.com", "Example.NET")},
+			fqdn: "example.com",
+			want: []string{"www.example.com", "example.net"},
+		},
+		"alias duplicates fqdn": {
+			objs: []interface{}{proxy("aliases", "example.com", "example.com")},
+			fqdn: "example.com",
+		},
+		"wildcard alias": {
+			objs: []interface{}{proxy("aliases", "example.com", "*.example.com")},
+			fqdn: "example.com",
+		},
+		"aliases of wildcard fqdn": {
+			objs: []interface{}{proxy("aliases", "*.example.com", "example.com")},
+			fqdn: "*.example.com",
+		},
+		"alias conflicts with another proxy": {
+			objs: []interface{}{
+				proxy("aliases", "example.com", "www.example.com"),
+				proxy("other", "www.example.com"),
+			},
+			fqdn: "example.com",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			for _, o := range append([]interface{}{sec1, s1}, tc.objs...) {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			vhost := dag.VirtualHosts[tc.fqdn]
+			svhost := dag.SecureVirtualHosts[tc.fqdn]
+			if tc.want == nil {
+				// Either the proxy is invalid, so no routes
+				// are added, or it has no aliases.
+				assert.True(t, vhost == nil || len(vhost.Aliases) == 0)
+				assert.True(t, svhost == nil || len(svhost.Aliases) == 0)
+				return
+			}
+			require.NotNil(t, vhost)
+			require.NotNil(t, svhost)
+			assert.Equal(t, tc.want, vhost.Aliases)
+			assert.Equal(t, tc.want, svhost.Aliases)
+		})
+	}
+}
+
+func TestBuilderTrafficShift(t *testing.T) {
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       8080,
+					TargetPort: intstr.FromInt(8080),
+				}},
+			},
+		}
+	}
+	stable, canary := service("stable"), service("canary")
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shift",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{
+					{Name: stable.Name, Port: 8080},
+					{Name: canary.Name, Port: 8080},
+				},
+				TrafficShiftPolicy: &contour_api_v1.TrafficShiftPolicy{
+					From:     stable.Name,
+					To:       canary.Name,
+					Steps:    []uint32{10, 50, 100},
+					Interval: "10m",
+				},
+			}},
+		},
+	}
+
+	start := time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	processor := &HTTPProxyProcessor{
+		now: func() time.Time { return now },
+	}
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			processor,
+			&ListenerProcessor{},
+		},
+	}
+	builder.Source.Insert(stable)
+	builder.Source.Insert(canary)
+	builder.Source.Insert(proxy)
+
+	// build returns the weights of the stable and
+	// canary services, and the shift's status.
+	build := func() (uint32, uint32, *DAG, contour_api_v1.TrafficShiftStatus) {
+		t.Helper()
+
+		dag := builder.Build()
+		vhost := dag.VirtualHosts["example.com"]
+		require.NotNil(t, vhost)
+		require.Len(t, vhost.Routes, 1)
+
+		weights := map[string]uint32{}
+		for _, r := range vhost.Routes {
+			for _, c := range r.Clusters {
+				weights[c.Upstream.Weighted.ServiceName] = c.Weight
+			}
+		}
+
+		updates := dag.StatusCache.GetProxyUpdates()
+		require.Len(t, updates, 1)
+		require.Len(t, updates[0].TrafficShifts, 1)
+
+		return weights[stable.Name], weights[canary.Name], dag, updates[0].TrafficShifts[0]
+	}
+
+	stableWeight, canaryWeight, dag, st := build()
+	assert.Equal(t, uint32(90), stableWeight)
+	assert.Equal(t, uint32(10), canaryWeight)
+	assert.Equal(t, start.Add(10*time.Minute), dag.RebuildAt)
+	assert.Equal(t, 0, st.Step)
+	assert.False(t, st.Complete)
+
+	now = start.Add(10 * time.Minute)
+	stableWeight, canaryWeight, dag, st = build()
+	assert.Equal(t, uint32(50), stableWeight)
+	assert.Equal(t, uint32(50), canaryWeight)
+	assert.Equal(t, start.Add(20*time.Minute), dag.RebuildAt)
+	assert.Equal(t, 1, st.Step)
+	assert.Equal(t, start.Add(10*time.Minute), st.LastTransitionTime.Time)
+
+	// Halting the shift holds it at the current step.
+	halted := proxy.DeepCopy()
+	halted.Annotations = map[string]string{"projectcontour.io/traffic-shift-halt": "true"}
+	builder.Source.Insert(halted)
+
+	now = start.Add(30 * time.Minute)
+	stableWeight, canaryWeight, dag, st = build()
+	assert.Equal(t, uint32(50), stableWeight)
+	assert.Equal(t, uint32(50), canaryWeight)
+	assert.True(t, dag.RebuildAt.IsZero())
+	assert.True(t, st.Halted)
+
+	// Resuming the shift restarts the current step.
+	builder.Source.Insert(proxy)
+
+	stableWeight, canaryWeight, dag, st = build()
+	assert.Equal(t, uint32(50), stableWeight)
+	assert.Equal(t, uint32(50), canaryWeight)
+	assert.Equal(t, start.Add(40*time.Minute), dag.RebuildAt)
+	assert.False(t, st.Halted)
+
+	now = start.Add(45 * time.Minute)
+	stableWeight, canaryWeight, dag, st = build()
+	assert.Equal(t, uint32(0), stableWeight)
+	assert.Equal(t, uint32(100), canaryWeight)
+	assert.True(t, dag.RebuildAt.IsZero())
+	assert.Equal(t, 2, st.Step)
+	assert.True(t, st.Complete)
+
+	// A new processor picks up where the status left off.
+	resumed := proxy.DeepCopy()
+	resumed.Status.TrafficShifts = []contour_api_v1.TrafficShiftStatus{st}
+	resumed.Status.TrafficShifts[0].Step = 1
+	builder.Source.Insert(resumed)
+	builder.Processors[0] = &HTTPProxyProcessor{
+		now: func() time.Time { return now },
+	}
+
+	stableWeight, canaryWeight, _, st = build()
+	assert.Equal(t, uint32(50), stableWeight)
+	assert.Equal(t, uint32(50), canaryWeight)
+	assert.Equal(t, 1, st.Step)
+}
+
+func TestBuilderTrafficWeights(t *testing.T) {
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       8080,
+					TargetPort: intstr.FromInt(8080),
+				}},
+			},
+		}
+	}
+	stable, canary := service("stable"), service("canary")
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rollout",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/api",
+				}},
+				Services: []contour_api_v1.Service{
+					{Name: stable.Name, Port: 8080, Weight: 100},
+					{Name: canary.Name, Port: 8080},
+				},
+			}, {
+				Services: []contour_api_v1.Service{
+					{Name: stable.Name, Port: 8080},
+				},
+			}},
+		},
+	}
+
+	weights := func(name string, routes ...contour_api_v1alpha1.RouteWeights) *contour_api_v1alpha1.TrafficWeights {
+		return &contour_api_v1alpha1.TrafficWeights{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: contour_api_v1alpha1.TrafficWeightsSpec{
+				HTTPProxy: proxy.Name,
+				Routes:    routes,
+			},
+		}
+	}
+
+	api := func(stableWeight, canaryWeight uint32) contour_api_v1alpha1.RouteWeights {
+		return contour_api_v1alpha1.RouteWeights{
+			Conditions: []contour_api_v1.MatchCondition{{
+				Prefix: "/api",
+			}},
+			Services: []contour_api_v1alpha1.ServiceWeight{
+				{Name: stable.Name, Weight: stableWeight},
+				{Name: canary.Name, Weight: canaryWeight},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		weights []*contour_api_v1alpha1.TrafficWeights
+		want    map[string]uint32
+		valid   bool
+	}{
+		"no traffic weights": {
+			want: map[string]uint32{stable.Name: 100, canary.Name: 0},
+		},
+		"weights set": {
+			weights: []*contour_api_v1alpha1.TrafficWeights{weights("rollout", api(80, 20))},
+			want:    map[string]uint32{stable.Name: 80, canary.Name: 20},
+			valid:   true,
+		},
+		"unknown route": {
+			weights: []*contour_api_v1alpha1.TrafficWeights{weights("rollout", api(80, 20), contour_api_v1alpha1.RouteWeights{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/missing",
+				}},
+				Services: []contour_api_v1alpha1.ServiceWeight{
+					{Name: stable.Name, Weight: 1},
+				},
+			})},
+			want: map[string]uint32{stable.Name: 100, canary.Name: 0},
+		},
+		"missing service weight": {
+			weights: []*contour_api_v1alpha1.TrafficWeights{weights("rollout", contour_api_v1alpha1.RouteWeights{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/api",
+				}},
+				Services: []contour_api_v1alpha1.ServiceWeight{
+					{Name: canary.Name, Weight: 100},
+				},
+			})},
+			want: map[string]uint32{stable.Name: 100, canary.Name: 0},
+		},
+		"unknown service": {
+			weights: []*contour_api_v1alpha1.TrafficWeights{weights("rollout", contour_api_v1alpha1.RouteWeights{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/api",
+				}},
+				Services: []contour_api_v1alpha1.ServiceWeight{
+					{Name: stable.Name, Weight: 50},
+					{Name: canary.Name, Weight: 25},
+					{Name: "other", Weight: 25},
+				},
+			})},
+			want: map[string]uint32{stable.Name: 100, canary.Name: 0},
+		},
+		"conflicting traffic weights": {
+			weights: []*contour_api_v1alpha1.TrafficWeights{
+				weights("rollout", api(80, 20)),
+				weights("other", api(50, 50)),
+			},
+			want: map[string]uint32{stable.Name: 100, canary.Name: 0},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(stable)
+			builder.Source.Insert(canary)
+			builder.Source.Insert(proxy)
+			for _, tw := range tc.weights {
+				builder.Source.Insert(tw)
+			}
+			dag := builder.Build()
+
+			vhost := dag.VirtualHosts["example.com"]
+			require.NotNil(t, vhost)
+
+			got := map[string]uint32{}
+			for _, r := range vhost.Routes {
+				if !r.HasPathPrefix() || r.PathMatchCondition.(*PrefixMatchCondition).Prefix != "/api" {
+					continue
+				}
+				for _, c := range r.Clusters {
+					got[c.Upstream.Weighted.ServiceName] = c.Weight
+				}
+			}
+			assert.Equal(t, tc.want, got)
+
+			for _, tw := range tc.weights {
+				entry, _ := status.TrafficWeightsAccessor(&dag.StatusCache, tw)
+				cond := entry.ConditionFor(status.ValidCondition)
+				if tc.valid {
+					assert.Equal(t, contour_api_v1.ConditionTrue, cond.Status)
+				} else {
+					assert.NotEmpty(t, cond.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestBuilderContourPolicy(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(route contour_api_v1.Route) *contour_api_v1.HTTPProxy {
+		route.Services = []contour_api_v1.Service{{
+			Name: s1.Name,
+			Port: 8080,
+		}}
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: s1.Namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []contour_api_v1.Route{route},
+			},
+		}
+	}
+
+	policy := func(name string, spec contour_api_v1alpha1.ContourPolicySpec) *contour_api_v1alpha1.ContourPolicy {
+		return &contour_api_v1alpha1.ContourPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s1.Namespace,
+			},
+			Spec: spec,
+		}
+	}
+
+	team := func(value string) *contour_api_v1.HeadersPolicy {
+		return &contour_api_v1.HeadersPolicy{
+			Set: []contour_api_v1.HeaderValue{{Name: "X-Team", Value: value}},
+		}
+	}
+
+	defaults := contour_api_v1alpha1.ContourPolicySpec{
+		Defaults: &contour_api_v1alpha1.PolicyDefaults{
+			TimeoutPolicy:        &contour_api_v1.TimeoutPolicy{Response: "10s"},
+			LoadBalancerPolicy:   &contour_api_v1.LoadBalancerPolicy{Strategy: "Random"},
+			RequestHeadersPolicy: team("platform"),
+		},
+	}
+
+	tests := map[string]struct {
+		proxy        *contour_api_v1.HTTPProxy
+		policies     []*contour_api_v1alpha1.ContourPolicy
+		policyValid  bool
+		wantValid    bool
+		wantTimeout  timeout.Setting
+		wantStrategy string
+		wantHeaders  map[string]string
+	}{
+		"no contour policy": {
+			proxy:       proxy(contour_api_v1.Route{}),
+			wantValid:   true,
+			wantTimeout: timeout.DefaultSetting(),
+		},
+		"defaults applied": {
+			proxy:        proxy(contour_api_v1.Route{}),
+			policies:     []*contour_api_v1alpha1.ContourPolicy{policy("policy", defaults)},
+			policyValid:  true,
+			wantValid:    true,
+			wantTimeout:  timeout.DurationSetting(10 * time.Second),
+			wantStrategy: "Random",
+			wantHeaders:  map[string]string{"X-Team": "platform"},
+		},
+		"route policies take precedence": {
+			proxy: proxy(contour_api_v1.Route{
+				TimeoutPolicy:        &contour_api_v1.TimeoutPolicy{Response: "5s"},
+				LoadBalancerPolicy:   &contour_api_v1.LoadBalancerPolicy{Strategy: "WeightedLeastRequest"},
+				RequestHeadersPolicy: team("web"),
+			}),
+			policies:     []*contour_api_v1alpha1.ContourPolicy{policy("policy", defaults)},
+			policyValid:  true,
+			wantValid:    true,
+			wantTimeout:  timeout.DurationSetting(5 * time.Second),
+			wantStrategy: "WeightedLeastRequest",
+			wantHeaders:  map[string]string{"X-Team": "web"},
+		},
+		"timeout exceeds limit": {
+			proxy: proxy(contour_api_v1.Route{
+				TimeoutPolicy: &contour_api_v1.TimeoutPolicy{Response: "1m"},
+			}),
+			policies: []*contour_api_v1alpha1.ContourPolicy{policy("policy", contour_api_v1alpha1.ContourPolicySpec{
+				Limits: &contour_api_v1alpha1.PolicyLimits{MaxResponseTimeout: "30s"},
+			})},
+			policyValid: true,
+		},
+		"strategy not allowed": {
+			proxy: proxy(contour_api_v1.Route{
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{Strategy: "Random"},
+			}),
+			policies: []*contour_api_v1alpha1.ContourPolicy{policy("policy", contour_api_v1alpha1.ContourPolicySpec{
+				Limits: &contour_api_v1alpha1.PolicyLimits{AllowedLoadBalancerStrategies: []string{"RoundRobin"}},
+			})},
+			policyValid: true,
+		},
+		"protected header set by route": {
+			proxy: proxy(contour_api_v1.Route{
+				RequestHeadersPolicy: team("web"),
+			}),
+			policies: []*contour_api_v1alpha1.ContourPolicy{policy("policy", contour_api_v1alpha1.ContourPolicySpec{
+				Limits: &contour_api_v1alpha1.PolicyLimits{ProtectedHeaders: []string{"x-team"}},
+			})},
+			policyValid: true,
+		},
+		"protected header set by defaults": {
+			proxy: proxy(contour_api_v1.Route{}),
+			policies: []*contour_api_v1alpha1.ContourPolicy{policy("policy", contour_api_v1alpha1.ContourPolicySpec{
+				Defaults: &contour_api_v1alpha1.PolicyDefaults{RequestHeadersPolicy: team("platform")},
+				Limits:   &contour_api_v1alpha1.PolicyLimits{ProtectedHeaders: []string{"X-Team"}},
+			})},
+			policyValid: true,
+			wantValid:   true,
+			wantTimeout: timeout.DefaultSetting(),
+			wantHeaders: map[string]string{"X-Team": "platform"},
+		},
+		"defaults exceed limits": {
+			proxy: proxy(contour_api_v1.Route{}),
+			policies: []*contour_api_v1alpha1.ContourPolicy{policy("policy", contour_api_v1alpha1.ContourPolicySpec{
+				Defaults: defaults.Defaults,
+				Limits:   &contour_api_v1alpha1.PolicyLimits{MaxResponseTimeout: "5s"},
+			})},
+			wantValid:   true,
+			wantTimeout: timeout.DefaultSetting(),
+		},
+		"conflicting contour policies": {
+			proxy: proxy(contour_api_v1.Route{}),
+			policies: []*contour_api_v1alpha1.ContourPolicy{
+				policy("policy", defaults),
+				policy("other", defaults),
+			},
+			wantValid:   true,
+			wantTimeout: timeout.DefaultSetting(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(s1)
+			builder.Source.Insert(tc.proxy)
+			for _, cp := range tc.policies {
+				builder.Source.Insert(cp)
+			}
+			dag := builder.Build()
+
+			for _, cp := range tc.policies {
+				entry, _ := status.ContourPolicyAccessor(&dag.StatusCache, cp)
+				cond := entry.ConditionFor(status.ValidCondition)
+				if tc.policyValid {
+					assert.Equal(t, contour_api_v1.ConditionTrue, cond.Status)
+				} else {
+					assert.NotEmpty(t, cond.Errors)
+				}
+			}
+
+			vhost := dag.VirtualHosts["example.com"]
+			if !tc.wantValid {
+				assert.False(t, vhost != nil && vhost.Valid())
+				return
+			}
+			require.NotNil(t, vhost)
+			require.Len(t, vhost.Routes, 1)
+			for _, r := range vhost.Routes {
+				assert.Equal(t, tc.wantTimeout, r.TimeoutPolicy.ResponseTimeout)
+				assert.Equal(t, tc.wantStrategy, r.Clusters[0].LoadBalancerPolicy)
+
+				var headers map[string]string
+				if r.RequestHeadersPolicy != nil {
+					headers = r.RequestHeadersPolicy.Set
+				}
+				assert.Equal(t, tc.wantHeaders, headers)
+			}
+		})
+	}
+}
+
+func TestBuilderCertificateExpiryWarning(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// The certificate of sec1 expires at 2029-12-02T01:34:33Z.
+	tests := map[string]struct {
+		now  time.Time
+		want []contour_api_v1.SubCondition
+	}{
+		"certificate not near expiry": {
+			now: time.Date(2029, time.November, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"certificate near expiry": {
+			now: time.Date(2029, time.November, 25, 0, 0, 0, 0, time.UTC),
+			want: []contour_api_v1.SubCondition{{
+				Type:    contour_api_v1.ConditionTypeTLSError,
+				Status:  contour_api_v1.ConditionTrue,
+				Reason:  "CertificateExpiring",
+				Message: `Spec.VirtualHost.TLS Secret "secret" certificate expires at 2029-12-02T01:34:33Z`,
+			}},
+		},
+		"certificate expired": {
+			now: time.Date(2029, time.December, 3, 0, 0, 0, 0, time.UTC),
+			want: []contour_api_v1.SubCondition{{
+				Type:    contour_api_v1.ConditionTypeTLSError,
+				Status:  contour_api_v1.ConditionTrue,
+				Reason:  "CertificateExpired",
+				Message: `Spec.VirtualHost.TLS Secret "secret" certificate expired at 2029-12-02T01:34:33Z`,
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{
+						now: func() time.Time { return tc.now },
+					},
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(sec1)
+			builder.Source.Insert(s1)
+			builder.Source.Insert(proxy)
+
+			dag := builder.Build()
+
+			// The warnings don't stop the secret being served.
+			svhost := dag.SecureVirtualHosts["example.com"]
+			require.NotNil(t, svhost)
+			assert.Equal(t, sec1.Name, svhost.Secret.Name())
+
+			updates := dag.StatusCache.GetProxyUpdates()
+			require.Len(t, updates, 1)
+
+			validCond := updates[0].ConditionFor(status.ValidCondition)
+			assert.Equal(t, contour_api_v1.ConditionTrue, validCond.Status)
+			assert.Equal(t, tc.want, validCond.Warnings)
+		})
+	}
+}
+
+func TestBuilderGRPCPolicy(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "grpc",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "grpc",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(protocol string, gp *contour_api_v1.GRPCPolicy) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "grpc",
+				Namespace: s1.Namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []contour_api_v1.Route{{
+					GRPCPolicy:  gp,
+					RetryPolicy: &contour_api_v1.RetryPolicy{NumRetries: 2},
+					Services: []contour_api_v1.Service{{
+						Name:     s1.Name,
+						Port:     8080,
+						Protocol: pointer.StringPtr(protocol),
+					}},
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		proxy              *contour_api_v1.HTTPProxy
+		maxResponseTimeout time.Duration
+		want               *GRPCPolicy
+		wantRetryOn        string
+		wantValid          bool
+	}{
+		"no grpc policy": {
+			proxy:       proxy("h2c", nil),
+			wantRetryOn: "5xx",
+			wantValid:   true,
+		},
+		"grpc policy": {
+			proxy:       proxy("h2c", &contour_api_v1.GRPCPolicy{MaxTimeout: "30s"}),
+			want:        &GRPCPolicy{MaxTimeout: 30 * time.Second},
+			wantRetryOn: grpcRetryOn,
+			wantValid:   true,
+		},
+		"grpc policy within limit": {
+			proxy:              proxy("h2", &contour_api_v1.GRPCPolicy{MaxTimeout: "30s"}),
+			maxResponseTimeout: time.Minute,
+			want:               &GRPCPolicy{MaxTimeout: 30 * time.Second},
+			wantRetryOn:        grpcRetryOn,
+			wantValid:          true,
+		},
+		"grpc policy exceeds limit": {
+			proxy:              proxy("h2c", &contour_api_v1.GRPCPolicy{}),
+			maxResponseTimeout: time.Minute,
+		},
+		"grpc policy without http/2": {
+			proxy: proxy("tls", &contour_api_v1.GRPCPolicy{}),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{
+						MaxResponseTimeout: tc.maxResponseTimeout,
+					},
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(s1)
+			builder.Source.Insert(tc.proxy)
+			dag := builder.Build()
+
+			vhost := dag.VirtualHosts["example.com"]
+			if !tc.wantValid {
+				assert.False(t, vhost != nil && vhost.Valid())
+				return
+			}
+			require.NotNil(t, vhost)
+			require.Len(t, vhost.Routes, 1)
+			for _, r := range vhost.Routes {
+				assert.Equal(t, tc.want, r.GRPCPolicy)
+				assert.Equal(t, tc.wantRetryOn, r.RetryPolicy.RetryOn)
+			}
+		})
+	}
+}
+
+func TestVirtualHostPermitInsecurePrefixes(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName:             sec1.Name,
+					PermitInsecurePrefixes: []string{"/public"},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		processor *HTTPProxyProcessor
+		want      *Listener
+	}{
+		"virtual host prefixes are added to the configured prefixes": {
+			processor: &HTTPProxyProcessor{
+				PermitInsecurePrefixes: []string{"/.well-known/acme-challenge/"},
+			},
+			want: &Listener{
+				Name: HTTP_LISTENER_NAME,
+				Port: 80,
+				VirtualHosts: virtualhosts(
+					virtualhost("example.com",
+						routeUpgrade("/", service(s1)),
+						prefixroute("/.well-known/acme-challenge/", service(s1)),
+						prefixroute("/public", service(s1)),
+					),
+				),
+			},
+		},
+		"virtual host prefixes are ignored when permitInsecure is disabled": {
+			processor: &HTTPProxyProcessor{
+				DisablePermitInsecure:  true,
+				PermitInsecurePrefixes: []string{"/.well-known/acme-challenge/"},
+			},
+			want: &Listener{
+				Name: HTTP_LISTENER_NAME,
+				Port: 80,
+				VirtualHosts: virtualhosts(
+					virtualhost("example.com",
+						routeUpgrade("/", service(s1)),
+						prefixroute("/.well-known/acme-challenge/", service(s1)),
+					),
+				),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					tc.processor,
+					&ListenerProcessor{},
+				},
+			}
+			for _, o := range []interface{}{sec1, s1, proxy} {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			for _, l := range dag.Listeners {
+				if l.Port == 80 {
+					assert.Equal(t, tc.want, l)
+					return
+				}
+			}
+			t.Fatal("HTTP listener not found")
+		})
+	}
+}
+
 func routes(routes ...*Route) map[string]*Route {
 	if len(routes) == 0 {
 		return nil
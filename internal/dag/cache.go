@@ -14,6 +14,8 @@
 package dag
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"sync"
@@ -42,6 +44,12 @@ type KubernetesCache struct {
 	// namespace.
 	RootNamespaces []string
 
+	// DenyByDefault restricts roots to the RootNamespaces even
+	// if it is empty, in which case no roots can be defined. It
+	// also applies to Ingresses, which are otherwise allowed in
+	// every namespace.
+	DenyByDefault bool
+
 	// Contour's IngressClassName.
 	// If not set, defaults to DEFAULT_INGRESS_CLASS.
 	IngressClassName string
@@ -49,6 +57,12 @@ type KubernetesCache struct {
 	// Secrets that are referred from the configuration file.
 	ConfiguredSecretRefs []*types.NamespacedName
 
+	// InsecureSkipVerifyNamespaces specifies the namespaces where
+	// upstream certificate validation can be skipped. If empty, it
+	// cannot be skipped in any namespace. The value "*" allows it
+	// in every namespace.
+	InsecureSkipVerifyNamespaces []string
+
 	ingresses                 map[types.NamespacedName]*networking_v1.Ingress
 	ingressclass              *networking_v1.IngressClass
 	httpproxies               map[types.NamespacedName]*contour_api_v1.HTTPProxy
@@ -60,8 +74,11 @@ type KubernetesCache struct {
 	gateway                   *gatewayapi_v1alpha2.Gateway
 	httproutes                map[types.NamespacedName]*gatewayapi_v1alpha2.HTTPRoute
 	tlsroutes                 map[types.NamespacedName]*gatewayapi_v1alpha2.TLSRoute
+	udproutes                 map[types.NamespacedName]*gatewayapi_v1alpha2.UDPRoute
 	referencepolicies         map[types.NamespacedName]*gatewayapi_v1alpha2.ReferencePolicy
 	extensions                map[types.NamespacedName]*contour_api_v1alpha1.ExtensionService
+	trafficweights            map[types.NamespacedName]*contour_api_v1alpha1.TrafficWeights
+	contourpolicies           map[types.NamespacedName]*contour_api_v1alpha1.ContourPolicy
 
 	initialize sync.Once
 
@@ -79,7 +96,44 @@ func (kc *KubernetesCache) init() {
 	kc.httproutes = make(map[types.NamespacedName]*gatewayapi_v1alpha2.HTTPRoute)
 	kc.referencepolicies = make(map[types.NamespacedName]*gatewayapi_v1alpha2.ReferencePolicy)
 	kc.tlsroutes = make(map[types.NamespacedName]*gatewayapi_v1alpha2.TLSRoute)
+	kc.udproutes = make(map[types.NamespacedName]*gatewayapi_v1alpha2.UDPRoute)
 	kc.extensions = make(map[types.NamespacedName]*contour_api_v1alpha1.ExtensionService)
+	kc.trafficweights = make(map[types.NamespacedName]*contour_api_v1alpha1.TrafficWeights)
+	kc.contourpolicies = make(map[types.NamespacedName]*contour_api_v1alpha1.ContourPolicy)
+}
+
+// ObjectCounts returns the number of objects in the cache, by kind.
+func (kc *KubernetesCache) ObjectCounts() map[string]int {
+	kc.initialize.Do(kc.init)
+
+	counts := map[string]int{
+		"Ingress":                  len(kc.ingresses),
+		"HTTPProxy":                len(kc.httpproxies),
+		"Secret":                   len(kc.secrets),
+		"TLSCertificateDelegation": len(kc.tlscertificatedelegations),
+		"Service":                  len(kc.services),
+		"Namespace":                len(kc.namespaces),
+		"HTTPRoute":                len(kc.httproutes),
+		"TLSRoute":                 len(kc.tlsroutes),
+		"UDPRoute":                 len(kc.udproutes),
+		"ReferencePolicy":          len(kc.referencepolicies),
+		"ExtensionService":         len(kc.extensions),
+		"TrafficWeights":           len(kc.trafficweights),
+		"ContourPolicy":            len(kc.contourpolicies),
+		"IngressClass":             0,
+		"GatewayClass":             0,
+		"Gateway":                  0,
+	}
+	if kc.ingressclass != nil {
+		counts["IngressClass"] = 1
+	}
+	if kc.gatewayclass != nil {
+		counts["GatewayClass"] = 1
+	}
+	if kc.gateway != nil {
+		counts["Gateway"] = 1
+	}
+	return counts
 }
 
 // matchesIngressClass returns true if the given IngressClass
@@ -116,9 +170,7 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 			if annotation.IsKnown(key) && !annotation.ValidForKind(kind, key) {
 				// TODO(jpeach): this should be exposed
 				// to the user as a status condition.
-				kc.WithField("name", obj.GetName()).
-					WithField("namespace", obj.GetNamespace()).
-					WithField("kind", kind).
+				kc.WithFields(k8s.LogFields(obj)).
 					WithField("version", k8s.VersionOf(obj)).
 					WithField("annotation", key).
 					Error("ignoring invalid or unsupported annotation")
@@ -128,20 +180,22 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 
 	switch obj := obj.(type) {
 	case *v1.Secret:
-		valid, err := isValidSecret(obj)
+		secret, err := normalizeSecret(obj)
+		valid := err == nil
+		if valid {
+			valid, err = isValidSecret(secret)
+		}
 		if !valid {
 			if err != nil {
-				kc.WithField("name", obj.GetName()).
-					WithField("namespace", obj.GetNamespace()).
-					WithField("kind", "Secret").
+				kc.WithFields(k8s.LogFields(obj)).
 					WithField("version", k8s.VersionOf(obj)).
 					Error(err)
 			}
 			return false
 		}
 
-		kc.secrets[k8s.NamespacedNameOf(obj)] = obj
-		return kc.secretTriggersRebuild(obj)
+		kc.secrets[k8s.NamespacedNameOf(secret)] = secret
+		return kc.secretTriggersRebuild(secret)
 	case *v1.Service:
 		kc.services[k8s.NamespacedNameOf(obj)] = obj
 		return kc.serviceTriggersRebuild(obj)
@@ -151,9 +205,7 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 	case *networking_v1.Ingress:
 		if !ingressclass.MatchesIngress(obj, kc.IngressClassName) {
 			// We didn't get a match so report this object is being ignored.
-			kc.WithField("name", obj.GetName()).
-				WithField("namespace", obj.GetNamespace()).
-				WithField("kind", k8s.KindOf(obj)).
+			kc.WithFields(k8s.LogFields(obj)).
 				WithField("ingress-class-annotation", annotation.IngressClass(obj)).
 				WithField("ingress-class-name", pointer.StringPtrDerefOr(obj.Spec.IngressClassName, "")).
 				WithField("target-ingress-class", kc.IngressClassName).
@@ -171,9 +223,7 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 	case *contour_api_v1.HTTPProxy:
 		if !ingressclass.MatchesHTTPProxy(obj, kc.IngressClassName) {
 			// We didn't get a match so report this object is being ignored.
-			kc.WithField("name", obj.GetName()).
-				WithField("namespace", obj.GetNamespace()).
-				WithField("kind", k8s.KindOf(obj)).
+			kc.WithFields(k8s.LogFields(obj)).
 				WithField("ingress-class-annotation", annotation.IngressClass(obj)).
 				WithField("ingress-class-name", obj.Spec.IngressClassName).
 				WithField("target-ingress-class", kc.IngressClassName).
@@ -198,12 +248,21 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 	case *gatewayapi_v1alpha2.TLSRoute:
 		kc.tlsroutes[k8s.NamespacedNameOf(obj)] = obj
 		return true
+	case *gatewayapi_v1alpha2.UDPRoute:
+		kc.udproutes[k8s.NamespacedNameOf(obj)] = obj
+		return true
 	case *gatewayapi_v1alpha2.ReferencePolicy:
 		kc.referencepolicies[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *contour_api_v1alpha1.ExtensionService:
 		kc.extensions[k8s.NamespacedNameOf(obj)] = obj
 		return true
+	case *contour_api_v1alpha1.TrafficWeights:
+		kc.trafficweights[k8s.NamespacedNameOf(obj)] = obj
+		return true
+	case *contour_api_v1alpha1.ContourPolicy:
+		kc.contourpolicies[k8s.NamespacedNameOf(obj)] = obj
+		return true
 	case *contour_api_v1alpha1.ContourConfiguration:
 		return false
 	default:
@@ -281,6 +340,11 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		_, ok := kc.tlsroutes[m]
 		delete(kc.tlsroutes, m)
 		return ok
+	case *gatewayapi_v1alpha2.UDPRoute:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.udproutes[m]
+		delete(kc.udproutes, m)
+		return ok
 	case *gatewayapi_v1alpha2.ReferencePolicy:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.referencepolicies[m]
@@ -291,6 +355,16 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		_, ok := kc.extensions[m]
 		delete(kc.extensions, m)
 		return ok
+	case *contour_api_v1alpha1.TrafficWeights:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.trafficweights[m]
+		delete(kc.trafficweights, m)
+		return ok
+	case *contour_api_v1alpha1.ContourPolicy:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.contourpolicies[m]
+		delete(kc.contourpolicies, m)
+		return ok
 	case *contour_api_v1alpha1.ContourConfiguration:
 		return false
 	default:
@@ -492,12 +566,43 @@ func (kc *KubernetesCache) LookupSecret(name types.NamespacedName, validate func
 	return s, nil
 }
 
-func (kc *KubernetesCache) LookupUpstreamValidation(uv *contour_api_v1.UpstreamValidation, caCertificate types.NamespacedName) (*PeerValidationContext, error) {
+// LookupUpstreamValidation returns the PeerValidationContext for uv,
+// which is given by an object in namespace.
+func (kc *KubernetesCache) LookupUpstreamValidation(uv *contour_api_v1.UpstreamValidation, caCertificate types.NamespacedName, namespace string) (*PeerValidationContext, error) {
 	if uv == nil {
 		// no upstream validation requested, nothing to do
 		return nil, nil
 	}
 
+	if uv.InsecureSkipVerify {
+		if uv.CACertificate != "" || uv.SubjectName != "" || len(uv.SPKIHashes) > 0 {
+			return nil, errors.New("insecureSkipVerify cannot be combined with other validation")
+		}
+		if !kc.insecureSkipVerifyPermitted(namespace) {
+			return nil, fmt.Errorf("insecureSkipVerify is not permitted in namespace %q", namespace)
+		}
+
+		return &PeerValidationContext{InsecureSkipVerify: true}, nil
+	}
+
+	for _, hash := range uv.SPKIHashes {
+		if b, err := base64.StdEncoding.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid SPKI hash %q: must be a base64-encoded SHA-256 hash", hash)
+		}
+	}
+
+	if uv.CACertificate == "" {
+		if len(uv.SPKIHashes) == 0 {
+			// UpstreamValidation is requested, but there is nothing to validate against
+			return nil, errors.New("missing CA Secret or SPKI hashes")
+		}
+
+		return &PeerValidationContext{
+			SubjectName: uv.SubjectName,
+			SPKIHashes:  uv.SPKIHashes,
+		}, nil
+	}
+
 	cacert, err := kc.LookupSecret(caCertificate, validCA)
 	if err != nil {
 		// UpstreamValidation is requested, but cert is missing or not configured
@@ -512,9 +617,34 @@ func (kc *KubernetesCache) LookupUpstreamValidation(uv *contour_api_v1.UpstreamV
 	return &PeerValidationContext{
 		CACertificate: cacert,
 		SubjectName:   uv.SubjectName,
+		SPKIHashes:    uv.SPKIHashes,
 	}, nil
 }
 
+// insecureSkipVerifyPermitted returns true if upstream certificate
+// validation can be skipped in namespace.
+func (kc *KubernetesCache) insecureSkipVerifyPermitted(namespace string) bool {
+	for _, ns := range kc.InsecureSkipVerifyNamespaces {
+		if ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// rootAllowed returns true if roots can be defined in namespace.
+func (kc *KubernetesCache) rootAllowed(namespace string) bool {
+	if len(kc.RootNamespaces) == 0 {
+		return !kc.DenyByDefault
+	}
+	for _, ns := range kc.RootNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 // DelegationPermitted returns true if the referenced secret has been delegated
 // to the namespace where the ingress object is located.
 func (kc *KubernetesCache) DelegationPermitted(secret types.NamespacedName, targetNamespace string) bool {
@@ -561,22 +691,42 @@ func validCA(s *v1.Secret) error {
 // LookupService returns the Kubernetes service and port matching the provided parameters,
 // or an error if a match can't be found.
 func (kc *KubernetesCache) LookupService(meta types.NamespacedName, port intstr.IntOrString) (*v1.Service, v1.ServicePort, error) {
+	return kc.lookupService(meta, port, v1.ProtocolTCP)
+}
+
+// LookupUDPService returns the Kubernetes service and UDP port matching the
+// provided parameters, or an error if a match can't be found.
+func (kc *KubernetesCache) LookupUDPService(meta types.NamespacedName, port intstr.IntOrString) (*v1.Service, v1.ServicePort, error) {
+	return kc.lookupService(meta, port, v1.ProtocolUDP)
+}
+
+func (kc *KubernetesCache) lookupService(meta types.NamespacedName, port intstr.IntOrString, protocol v1.Protocol) (*v1.Service, v1.ServicePort, error) {
 	svc, ok := kc.services[meta]
 	if !ok {
 		return nil, v1.ServicePort{}, fmt.Errorf("service %q not found", meta)
 	}
 
+	// A service may have ports with the same number
+	// but different protocols, e.g. for DNS, so keep
+	// looking when the protocol does not match.
+	var unsupported v1.Protocol
 	for i := range svc.Spec.Ports {
 		p := svc.Spec.Ports[i]
 		if int(p.Port) == port.IntValue() || port.String() == p.Name {
-			switch p.Protocol {
-			case "", v1.ProtocolTCP:
+			proto := p.Protocol
+			if proto == "" {
+				proto = v1.ProtocolTCP
+			}
+			if proto == protocol {
 				return svc, p, nil
-			default:
-				return nil, v1.ServicePort{}, fmt.Errorf("unsupported service protocol %q", p.Protocol)
 			}
+			unsupported = proto
 		}
 	}
 
+	if unsupported != "" {
+		return nil, v1.ServicePort{}, fmt.Errorf("unsupported service protocol %q", unsupported)
+	}
+
 	return nil, v1.ServicePort{}, fmt.Errorf("port %q on service %q not matched", port.String(), meta)
 }
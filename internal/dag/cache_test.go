@@ -886,6 +886,15 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert gateway-api UDPRoute": {
+			obj: &gatewayapi_v1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "udproute",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
 		"insert gateway-api ReferencePolicy": {
 			obj: &gatewayapi_v1alpha2.ReferencePolicy{
 				ObjectMeta: metav1.ObjectMeta{
@@ -901,6 +910,18 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert traffic weights": {
+			obj: &contour_api_v1alpha1.TrafficWeights{
+				ObjectMeta: fixture.ObjectMeta("default/weights"),
+			},
+			want: true,
+		},
+		"insert contour policy": {
+			obj: &contour_api_v1alpha1.ContourPolicy{
+				ObjectMeta: fixture.ObjectMeta("default/policy"),
+			},
+			want: true,
+		},
 		"insert secret that is referred by configuration file": {
 			obj: &v1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1168,6 +1189,21 @@ func TestKubernetesCacheRemove(t *testing.T) {
 			},
 			want: true,
 		},
+		"remove gateway-api UDPRoute": {
+			cache: cache(&gatewayapi_v1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "udproute",
+					Namespace: "default",
+				},
+			}),
+			obj: &gatewayapi_v1alpha2.UDPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "udproute",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
 		"remove gateway-api ReferencePolicy": {
 			cache: cache(&gatewayapi_v1alpha2.ReferencePolicy{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1192,6 +1228,24 @@ func TestKubernetesCacheRemove(t *testing.T) {
 			},
 			want: true,
 		},
+		"remove traffic weights": {
+			cache: cache(&contour_api_v1alpha1.TrafficWeights{
+				ObjectMeta: fixture.ObjectMeta("default/weights"),
+			}),
+			obj: &contour_api_v1alpha1.TrafficWeights{
+				ObjectMeta: fixture.ObjectMeta("default/weights"),
+			},
+			want: true,
+		},
+		"remove contour policy": {
+			cache: cache(&contour_api_v1alpha1.ContourPolicy{
+				ObjectMeta: fixture.ObjectMeta("default/policy"),
+			}),
+			obj: &contour_api_v1alpha1.ContourPolicy{
+				ObjectMeta: fixture.ObjectMeta("default/policy"),
+			},
+			want: true,
+		},
 		"remove unknown": {
 			cache: cache("not an object"),
 			obj:   "not an object",
@@ -1304,6 +1358,134 @@ func TestLookupService(t *testing.T) {
 	}
 }
 
+func TestLookupUpstreamValidation(t *testing.T) {
+	const spkiHash = "EOCKQZ6FDroeu6GP3SjrfsG36LqpvMO5c+K4iR7HJr4="
+
+	ca := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			CACertificateKey: []byte(fixture.CERTIFICATE),
+		},
+	}
+
+	cache := KubernetesCache{
+		InsecureSkipVerifyNamespaces: []string{"migration"},
+		FieldLogger:                  fixture.NewTestLogger(t),
+	}
+	cache.Insert(ca)
+
+	tests := map[string]struct {
+		uv        *contour_api_v1.UpstreamValidation
+		namespace string
+		want      *PeerValidationContext
+		wantErr   error
+	}{
+		"no validation": {
+			uv:        nil,
+			namespace: "default",
+			want:      nil,
+		},
+		"ca secret": {
+			uv: &contour_api_v1.UpstreamValidation{
+				CACertificate: "ca",
+				SubjectName:   "backend.example.com",
+			},
+			namespace: "default",
+			want: &PeerValidationContext{
+				CACertificate: &Secret{Object: ca},
+				SubjectName:   "backend.example.com",
+			},
+		},
+		"ca secret without subject name": {
+			uv: &contour_api_v1.UpstreamValidation{
+				CACertificate: "ca",
+			},
+			namespace: "default",
+			wantErr:   errors.New("missing subject alternative name"),
+		},
+		"spki hashes": {
+			uv: &contour_api_v1.UpstreamValidation{
+				SPKIHashes: []string{spkiHash},
+			},
+			namespace: "default",
+			want: &PeerValidationContext{
+				SPKIHashes: []string{spkiHash},
+			},
+		},
+		"ca secret and spki hashes": {
+			uv: &contour_api_v1.UpstreamValidation{
+				CACertificate: "ca",
+				SubjectName:   "backend.example.com",
+				SPKIHashes:    []string{spkiHash},
+			},
+			namespace: "default",
+			want: &PeerValidationContext{
+				CACertificate: &Secret{Object: ca},
+				SubjectName:   "backend.example.com",
+				SPKIHashes:    []string{spkiHash},
+			},
+		},
+		"invalid spki hash": {
+			uv: &contour_api_v1.UpstreamValidation{
+				SPKIHashes: []string{"c2hvcnQ="},
+			},
+			namespace: "default",
+			wantErr:   errors.New(`invalid SPKI hash "c2hvcnQ=": must be a base64-encoded SHA-256 hash`),
+		},
+		"nothing to validate against": {
+			uv: &contour_api_v1.UpstreamValidation{
+				SubjectName: "backend.example.com",
+			},
+			namespace: "default",
+			wantErr:   errors.New("missing CA Secret or SPKI hashes"),
+		},
+		"insecure skip verify in permitted namespace": {
+			uv: &contour_api_v1.UpstreamValidation{
+				InsecureSkipVerify: true,
+			},
+			namespace: "migration",
+			want: &PeerValidationContext{
+				InsecureSkipVerify: true,
+			},
+		},
+		"insecure skip verify in other namespace": {
+			uv: &contour_api_v1.UpstreamValidation{
+				InsecureSkipVerify: true,
+			},
+			namespace: "default",
+			wantErr:   errors.New(`insecureSkipVerify is not permitted in namespace "default"`),
+		},
+		"insecure skip verify with ca secret": {
+			uv: &contour_api_v1.UpstreamValidation{
+				CACertificate:      "ca",
+				SubjectName:        "backend.example.com",
+				InsecureSkipVerify: true,
+			},
+			namespace: "migration",
+			wantErr:   errors.New("insecureSkipVerify cannot be combined with other validation"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			caName := types.NamespacedName{Namespace: "default", Name: "ca"}
+			got, err := cache.LookupUpstreamValidation(tc.uv, caName, tc.namespace)
+
+			switch {
+			case tc.wantErr != nil:
+				require.Error(t, err)
+				assert.EqualError(t, tc.wantErr, err.Error())
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
 func TestServiceTriggersRebuild(t *testing.T) {
 
 	cache := func(objs ...interface{}) *KubernetesCache {
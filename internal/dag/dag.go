@@ -23,9 +23,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/projectcontour/contour/internal/xds"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -63,6 +66,87 @@ type DAG struct {
 	VirtualHosts       map[string]*VirtualHost
 	SecureVirtualHosts map[string]*SecureVirtualHost
 	ExtensionClusters  []*ExtensionCluster
+
+	// UDPProxies are the UDP proxies of the DAG, by the
+	// port on which they receive datagrams.
+	UDPProxies map[int]*UDPProxy
+
+	// RebuildAt, if not zero, is when the DAG should be rebuilt
+	// even if no objects have changed, because it holds
+	// configuration that changes over time.
+	RebuildAt time.Time
+
+	// Origins are the Kubernetes objects that the routes of the
+	// DAG were computed from. They do not affect the Envoy
+	// configuration, and are only used to explain it.
+	Origins map[*Route]Origin
+
+	// Ignored are the Kubernetes objects that were not
+	// programmed, and why. Like Origins, they are only
+	// used to explain the DAG.
+	Ignored []IgnoredObject
+}
+
+// IgnoredObject is a Kubernetes object that was not
+// programmed, because of Reason.
+type IgnoredObject struct {
+	Origin
+	Reason string
+}
+
+// Origin identifies the Kubernetes object that a route
+// was computed from.
+type Origin struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (o Origin) String() string {
+	return o.Kind + " " + o.Namespace + "/" + o.Name
+}
+
+// setOrigin records obj as the origin of r, unless
+// the origin of r has already been recorded.
+func (d *DAG) setOrigin(r *Route, obj metav1.Object) {
+	if d.Origins == nil {
+		d.Origins = make(map[*Route]Origin)
+	}
+	if _, ok := d.Origins[r]; ok {
+		return
+	}
+	d.Origins[r] = Origin{
+		Kind:      k8s.KindOf(obj),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+// ignore records that obj was not programmed, and why.
+func (d *DAG) ignore(obj metav1.Object, reason string) {
+	d.Ignored = append(d.Ignored, IgnoredObject{
+		Origin: Origin{
+			Kind:      k8s.KindOf(obj),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		},
+		Reason: reason,
+	})
+}
+
+// copyOrigin records the origin of from as the origin of
+// to, a route derived from it.
+func (d *DAG) copyOrigin(to, from *Route) {
+	if o, ok := d.Origins[from]; ok {
+		d.Origins[to] = o
+	}
+}
+
+// rebuildBy ensures the DAG is rebuilt no later than t.
+func (d *DAG) rebuildBy(t time.Time) {
+	if d.RebuildAt.IsZero() || t.Before(d.RebuildAt) {
+		d.RebuildAt = t
+	}
 }
 
 type MatchCondition interface {
@@ -179,6 +263,45 @@ type Redirect struct {
 	StatusCode int
 }
 
+// HTTPSRedirectPolicy defines how insecure requests
+// are redirected to HTTPS.
+type HTTPSRedirectPolicy struct {
+	// StatusCode is the HTTP response code to
+	// use. Valid options are 301 or 308.
+	StatusCode int
+
+	// PortNumber is the port to redirect to,
+	// if any.
+	PortNumber uint32
+}
+
+// TrafficSplitPolicy defines how requests are pinned
+// to the clusters of one of a route's weighted services.
+type TrafficSplitPolicy struct {
+	// HeaderName is the request header whose value
+	// names the service to pin the request to.
+	HeaderName string
+
+	// CookieName is the cookie recording the service
+	// a client was pinned to.
+	CookieName string
+}
+
+// TrafficShiftPolicy defines how requests are gradually
+// shifted from one of a route's services to another.
+type TrafficShiftPolicy struct {
+	// From and To are the names of the services
+	// requests are shifted from and to.
+	From, To string
+
+	// Steps are the percentages of requests sent
+	// to the To service at each step of the shift.
+	Steps []uint32
+
+	// Interval is how long each step lasts.
+	Interval time.Duration
+}
+
 // Route defines the properties of a route to a Cluster.
 type Route struct {
 
@@ -186,6 +309,10 @@ type Route struct {
 	// Must not be nil.
 	PathMatchCondition MatchCondition
 
+	// Priority orders the route before the routes of its virtual
+	// host with a lower priority, regardless of their conditions.
+	Priority int32
+
 	// HeaderMatchConditions specifies a set of additional Conditions to
 	// match on the request headers.
 	HeaderMatchConditions []HeaderMatchCondition
@@ -196,6 +323,11 @@ type Route struct {
 	// over HTTP?
 	HTTPSUpgrade bool
 
+	// HTTPSRedirectPolicy customizes the redirect generated
+	// when HTTPSUpgrade is set. If nil, a 301 redirect to the
+	// default HTTPS port is generated.
+	HTTPSRedirectPolicy *HTTPSRedirectPolicy
+
 	// AuthDisabled is set if authorization should be disabled
 	// for this route. If authorization is disabled, the AuthContext
 	// field has no effect.
@@ -214,6 +346,17 @@ type Route struct {
 	// RetryPolicy defines the retry / number / timeout options for a route
 	RetryPolicy *RetryPolicy
 
+	// HedgePolicy defines if/how requests for the route are hedged.
+	HedgePolicy *HedgePolicy
+
+	// GRPCPolicy defines how the deadlines of gRPC
+	// requests for the route are handled.
+	GRPCPolicy *GRPCPolicy
+
+	// VirtualClusterName is the name of the Envoy virtual
+	// cluster for the route's requests, if any.
+	VirtualClusterName string
+
 	// Indicates that during forwarding, the matched prefix (or path) should be swapped with this value
 	PrefixRewrite string
 
@@ -237,6 +380,15 @@ type Route struct {
 	// request attributes.
 	RequestHashPolicies []RequestHashPolicy
 
+	// TrafficSplitPolicy defines how requests are pinned to one
+	// of the route's weighted services. Routes for the pinned
+	// requests are added alongside this route by the processor.
+	TrafficSplitPolicy *TrafficSplitPolicy
+
+	// DisabledFilters are the HTTP filters that are disabled
+	// for the route's requests.
+	DisabledFilters []HTTPFilter
+
 	// DirectResponse allows for a specific HTTP status code
 	// to be the response to a route request vs routing to
 	// an envoy cluster.
@@ -247,6 +399,27 @@ type Route struct {
 	Redirect *Redirect
 }
 
+// HTTPFilter is an HTTP filter that can be disabled for a route.
+type HTTPFilter string
+
+const (
+	HTTPFilterCORS           HTTPFilter = "cors"
+	HTTPFilterExtAuthz       HTTPFilter = "ext_authz"
+	HTTPFilterLocalRateLimit HTTPFilter = "local_ratelimit"
+	HTTPFilterRateLimit      HTTPFilter = "ratelimit"
+)
+
+// FilterDisabled returns whether the HTTP filter is disabled
+// for the route.
+func (r *Route) FilterDisabled(f HTTPFilter) bool {
+	for _, disabled := range r.DisabledFilters {
+		if disabled == f {
+			return true
+		}
+	}
+	return false
+}
+
 // HasPathPrefix returns whether this route has a PrefixPathCondition.
 func (r *Route) HasPathPrefix() bool {
 	_, ok := r.PathMatchCondition.(*PrefixMatchCondition)
@@ -285,6 +458,42 @@ type RetryPolicy struct {
 	// PerTryTimeout specifies the timeout per retry attempt.
 	// Ignored if RetryOn is blank.
 	PerTryTimeout timeout.Setting
+
+	// AvoidPreviousHosts and AvoidCanaryHosts reject the host
+	// chosen for a retry if previous attempts were sent to it,
+	// or if it is a canary, respectively.
+	AvoidPreviousHosts bool
+	AvoidCanaryHosts   bool
+
+	// HostSelectionMaxAttempts is the maximum number of times a
+	// host is chosen for a retry. Zero means the Envoy default.
+	HostSelectionMaxAttempts int64
+
+	// PreviousPrioritiesUpdateFrequency, if not zero, sends
+	// retries to priority levels other than those of previous
+	// attempts, updating the excluded levels after this many
+	// attempts.
+	PreviousPrioritiesUpdateFrequency int32
+}
+
+// HedgePolicy defines the hedging options for a route.
+type HedgePolicy struct {
+	// HedgeOnPerTryTimeout sends an additional request when the
+	// per-try timeout elapses, rather than canceling the
+	// outstanding request.
+	HedgeOnPerTryTimeout bool
+}
+
+// GRPCPolicy defines how the deadlines that gRPC clients
+// set with the grpc-timeout request header are handled.
+type GRPCPolicy struct {
+	// MaxTimeout limits the deadline clients may set.
+	// Zero means the deadline is not limited.
+	MaxTimeout time.Duration
+
+	// TimeoutOffset is subtracted from the deadline
+	// clients set.
+	TimeoutOffset time.Duration
 }
 
 // MirrorPolicy defines the mirroring policy for a route.
@@ -328,6 +537,10 @@ type LocalRateLimitPolicy struct {
 	FillInterval         time.Duration
 	ResponseStatusCode   uint32
 	ResponseHeadersToAdd map[string]string
+
+	// Disabled turns off local rate limiting for a route,
+	// overriding any virtual host policy.
+	Disabled bool
 }
 
 // HeaderHashOptions contains options for hashing a HTTP header.
@@ -368,11 +581,31 @@ type RequestHashPolicy struct {
 // GlobalRateLimitPolicy holds global rate limiting parameters.
 type GlobalRateLimitPolicy struct {
 	Descriptors []*RateLimitDescriptor
+
+	// Disabled is true if global rate limiting is turned off
+	// for the route, including the virtual host's policy.
+	Disabled bool
 }
 
 // RateLimitDescriptor is a list of rate limit descriptor entries.
 type RateLimitDescriptor struct {
 	Entries []RateLimitDescriptorEntry
+
+	// Stage is the rate limit filter stage the descriptor applies to.
+	Stage uint32
+
+	// Limit is the rate limit applied by the rate limit service to
+	// requests with this descriptor, if any.
+	Limit *RateLimitDescriptorLimit
+}
+
+// RateLimitDescriptorLimit is the rate limit applied by the rate limit
+// service to requests with a descriptor.
+type RateLimitDescriptorLimit struct {
+	Requests uint32
+
+	// Unit is one of "second", "minute", "hour" or "day".
+	Unit string
 }
 
 // RateLimitDescriptorEntry is an entry in a rate limit descriptor.
@@ -382,6 +615,7 @@ type RateLimitDescriptorEntry struct {
 	HeaderMatch      *HeaderMatchDescriptorEntry
 	HeaderValueMatch *HeaderValueMatchDescriptorEntry
 	RemoteAddress    *RemoteAddressDescriptorEntry
+	Metadata         *MetadataDescriptorEntry
 }
 
 // GenericKeyDescriptorEntry  configures a descriptor entry
@@ -409,6 +643,21 @@ type HeaderValueMatchDescriptorEntry struct {
 // that contains the remote address (i.e. client IP).
 type RemoteAddressDescriptorEntry struct{}
 
+// MetadataDescriptorEntry configures a descriptor entry
+// whose value is read from the request's dynamic metadata.
+type MetadataDescriptorEntry struct {
+	Key string
+
+	// Namespace is the metadata namespace, and Path
+	// the keys of the value within the namespace.
+	Namespace string
+	Path      []string
+
+	// DefaultValue is the value of the entry if
+	// the metadata is not present.
+	DefaultValue string
+}
+
 // CORSPolicy allows setting the CORS policy
 type CORSPolicy struct {
 	// Specifies whether the resource allows credentials.
@@ -443,6 +692,20 @@ type PeerValidationContext struct {
 	// SkipClientCertValidation when set to true will ensure Envoy requests but
 	// does not verify peer certificates.
 	SkipClientCertValidation bool
+	// OptionalClientCertificate when set to true will ensure Envoy
+	// requests but does not require peer certificates.
+	OptionalClientCertificate bool
+	// ForwardClientCertificateStatus when set to true forwards whether
+	// the peer presented a certificate, and whether it was verified,
+	// in request headers.
+	ForwardClientCertificateStatus bool
+	// SPKIHashes holds optional base64-encoded SHA-256 hashes of the
+	// Subject Public Key Information, one of which the certificate
+	// presented by the upstream must match.
+	SPKIHashes []string
+	// InsecureSkipVerify when set to true disables validation of the
+	// certificate presented by the upstream.
+	InsecureSkipVerify bool
 }
 
 // GetCACertificate returns the CA certificate from PeerValidationContext.
@@ -463,12 +726,25 @@ func (pvc *PeerValidationContext) GetSubjectName() string {
 	return pvc.SubjectName
 }
 
+// GetSPKIHashes returns the SPKIHashes from PeerValidationContext.
+func (pvc *PeerValidationContext) GetSPKIHashes() []string {
+	if pvc == nil {
+		// No validation required.
+		return nil
+	}
+	return pvc.SPKIHashes
+}
+
 // A VirtualHost represents a named L4/L7 service.
 type VirtualHost struct {
 	// Name is the fully qualified domain name of a network host,
 	// as defined by RFC 3986.
 	Name string
 
+	// Aliases are additional domain names served by the
+	// VirtualHost, sharing its routes.
+	Aliases []string
+
 	// CORSPolicy is the cross-origin policy to apply to the VirtualHost.
 	CORSPolicy *CORSPolicy
 
@@ -532,6 +808,52 @@ type SecureVirtualHost struct {
 	// only reason to set this to `true` is when you are migrating
 	// from internal to external authorization.
 	AuthorizationFailOpen bool
+
+	// ConnectionTimeouts overrides the connection timeouts
+	// of the HTTP connection manager for this host. If nil,
+	// the global timeouts are used.
+	ConnectionTimeouts *ConnectionTimeouts
+
+	// HSTSPolicy is the HTTP Strict Transport Security policy
+	// of this host. If nil, no Strict-Transport-Security
+	// header is added to responses.
+	HSTSPolicy *HSTSPolicy
+
+	// HeaderLimits overrides the limits of the headers of
+	// requests of the HTTP connection manager for this host.
+	// If nil, the global limits are used.
+	HeaderLimits *HeaderLimits
+}
+
+// HSTSPolicy defines the Strict-Transport-Security header
+// added to responses served by a secure virtual host.
+type HSTSPolicy struct {
+	// MaxAge is how long clients should only
+	// access the host over HTTPS.
+	MaxAge time.Duration
+
+	// IncludeSubDomains applies the policy to
+	// subdomains of the host too.
+	IncludeSubDomains bool
+
+	// Preload consents to the host being included
+	// in browsers' HSTS preload lists.
+	Preload bool
+}
+
+// ConnectionTimeouts holds the timeouts of downstream connections.
+// Settings left as the default fall back to the global timeouts.
+type ConnectionTimeouts struct {
+	MaxConnectionDuration         timeout.Setting
+	ConnectionShutdownGracePeriod timeout.Setting
+	DelayedClose                  timeout.Setting
+}
+
+// HeaderLimits holds the limits of the headers of requests.
+// Limits left as zero fall back to the global limits.
+type HeaderLimits struct {
+	MaxRequestHeadersKB    uint32
+	MaxRequestHeadersCount uint32
 }
 
 func (s *SecureVirtualHost) Valid() bool {
@@ -556,6 +878,10 @@ type Listener struct {
 
 	VirtualHosts       []*VirtualHost
 	SecureVirtualHosts []*SecureVirtualHost
+
+	// UDPProxy, if set, makes the listener a UDP socket
+	// that forwards the datagrams it receives.
+	UDPProxy *UDPProxy
 }
 
 // TCPProxy represents a cluster of TCP endpoints.
@@ -566,6 +892,13 @@ type TCPProxy struct {
 	Clusters []*Cluster
 }
 
+// UDPProxy represents a cluster of UDP endpoints.
+type UDPProxy struct {
+	// Cluster is the upstream service to
+	// forward datagrams to.
+	Cluster *Cluster
+}
+
 // Service represents a single Kubernetes' Service's Port.
 type Service struct {
 	Weighted WeightedService
@@ -594,6 +927,56 @@ type Service struct {
 
 	// ExternalName is an optional field referencing a dns entry for Service type "ExternalName"
 	ExternalName string
+
+	// DNSPolicy defines how ExternalName is resolved, as set by
+	// Service annotations.
+	DNSPolicy *DNSPolicy
+
+	// StaticEndpoints are the endpoints of a service that is not
+	// a Kubernetes Service. If set, the endpoints are not discovered
+	// with EDS.
+	StaticEndpoints []StaticEndpoint
+}
+
+// StaticEndpoint is an endpoint of a service that is not
+// discovered from Kubernetes.
+type StaticEndpoint struct {
+	Address string
+	Port    uint32
+}
+
+const (
+	// DNSDiscoveryTypeStrict resolves an ExternalName continuously
+	// and load balances across all of the returned addresses.
+	DNSDiscoveryTypeStrict = "strict"
+
+	// DNSDiscoveryTypeLogical connects to the first address returned
+	// when resolving an ExternalName.
+	DNSDiscoveryTypeLogical = "logical"
+)
+
+// DNSPolicy defines how the DNS name of an ExternalName Service
+// is resolved.
+type DNSPolicy struct {
+	// DiscoveryType is the cluster discovery type, one of
+	// DNSDiscoveryTypeStrict or DNSDiscoveryTypeLogical.
+	// If empty, DNSDiscoveryTypeStrict is used.
+	DiscoveryType string
+
+	// Resolvers are the DNS resolvers to use instead of the
+	// resolvers configured on the Envoy host.
+	Resolvers []DNSResolver
+
+	// LookupFamily is the IP address family to resolve to,
+	// one of "auto", "v4" or "v6". If empty, the cluster's
+	// DNSLookupFamily is used.
+	LookupFamily string
+}
+
+// DNSResolver is the address of a DNS resolver.
+type DNSResolver struct {
+	Address string
+	Port    uint32
 }
 
 // Cluster holds the connection specific parameters that apply to
@@ -649,9 +1032,62 @@ type Cluster struct {
 	// Note: This only applies to externalName clusters.
 	DNSLookupFamily string
 
+	// DNSPolicy overrides the DNS policy of the upstream
+	// Service. Note: This only applies to externalName clusters.
+	DNSPolicy *DNSPolicy
+
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *Secret
+
+	// Backups are the services whose endpoints receive the traffic
+	// of the cluster, in order, when none of the endpoints of the
+	// Upstream are healthy.
+	Backups []*Service
+}
+
+// ClusterLoadAssignmentName returns the name of the ClusterLoadAssignment
+// of the cluster's endpoints. Since the endpoints of a cluster with
+// backups include those of the backups, it cannot share the
+// ClusterLoadAssignment of its Upstream with other clusters.
+func (c *Cluster) ClusterLoadAssignmentName() string {
+	name := xds.HealthCheckClusterLoadAssignmentName(
+		types.NamespacedName{
+			Name:      c.Upstream.Weighted.ServiceName,
+			Namespace: c.Upstream.Weighted.ServiceNamespace,
+		},
+		c.Upstream.Weighted.ServicePort.Name,
+		c.HealthCheckPort())
+
+	if len(c.Backups) == 0 {
+		return name
+	}
+
+	backups := make([]string, 0, len(c.Backups))
+	for _, b := range c.Backups {
+		backups = append(backups, xds.ClusterLoadAssignmentName(
+			types.NamespacedName{
+				Name:      b.Weighted.ServiceName,
+				Namespace: b.Weighted.ServiceNamespace,
+			},
+			b.Weighted.ServicePort.Name))
+	}
+
+	return xds.BackupClusterLoadAssignmentName(name, backups...)
+}
+
+// HealthCheckPort returns the port of the upstream endpoints that
+// health checks are sent to, or zero if they are sent to the port
+// that traffic is sent to.
+func (c *Cluster) HealthCheckPort() uint32 {
+	switch {
+	case c.HTTPHealthCheckPolicy != nil:
+		return c.HTTPHealthCheckPolicy.Port
+	case c.TCPHealthCheckPolicy != nil:
+		return c.TCPHealthCheckPolicy.Port
+	default:
+		return 0
+	}
 }
 
 // WeightedService represents the load balancing weight of a
@@ -665,6 +1101,15 @@ type WeightedService struct {
 	ServiceNamespace string
 	// ServicePort is the port to which we forward traffic.
 	ServicePort v1.ServicePort
+	// HealthCheckPort, if not zero, is the port of the endpoints
+	// to which we send health checks.
+	HealthCheckPort uint32
+
+	// Priority is the priority of the endpoints of the Service.
+	// Endpoints of a lower priority only receive traffic when none
+	// of the endpoints of the higher priorities are healthy. Zero
+	// is the highest priority.
+	Priority uint32
 }
 
 // ServiceCluster capture the set of Kubernetes Services that will
@@ -777,18 +1222,45 @@ func (s *Secret) PrivateKey() []byte {
 	return s.Object.Data[v1.TLSPrivateKeyKey]
 }
 
+// NotAfter returns the time at which the secret's tls certificate,
+// the first certificate of its bundle, expires.
+func (s *Secret) NotAfter() (time.Time, error) {
+	certs, err := parseCertificates(s.Cert())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(certs) == 0 {
+		return time.Time{}, errors.New("failed to locate certificate")
+	}
+
+	return certs[0].NotAfter, nil
+}
+
 // HTTPHealthCheckPolicy http health check policy
 type HTTPHealthCheckPolicy struct {
 	Path               string
 	Host               string
+	Port               uint32
 	Interval           time.Duration
 	Timeout            time.Duration
 	UnhealthyThreshold uint32
 	HealthyThreshold   uint32
+	ExpectedStatuses   []HTTPStatusRange
+	RequestHeaders     map[string]string
+	InitialJitter      time.Duration
+	IntervalJitter     time.Duration
+}
+
+// HTTPStatusRange is a range of HTTP status codes,
+// from Start inclusive to End exclusive.
+type HTTPStatusRange struct {
+	Start int64
+	End   int64
 }
 
 // TCPHealthCheckPolicy tcp health check policy
 type TCPHealthCheckPolicy struct {
+	Port               uint32
 	Interval           time.Duration
 	Timeout            time.Duration
 	UnhealthyThreshold uint32
@@ -142,12 +142,12 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 		// delegated to the ExtensionService's namespace.
 		// By default, a non-namespaced CACertificate is expected to reside in the ExtensionService's namespace.
 		caCertNamespacedName := k8s.NamespacedNameFrom(v.CACertificate, k8s.DefaultNamespace(ext.Namespace))
-		if !cache.DelegationPermitted(caCertNamespacedName, ext.Namespace) {
+		if v.CACertificate != "" && !cache.DelegationPermitted(caCertNamespacedName, ext.Namespace) {
 			validCondition.AddErrorf(contour_api_v1.ConditionTypeTLSError, "CACertificateNotDelegated",
 				"service.UpstreamValidation.CACertificate Secret %q is not configured for certificate delegation", caCertNamespacedName)
 			return nil
 		}
-		if uv, err := cache.LookupUpstreamValidation(v, caCertNamespacedName); err != nil {
+		if uv, err := cache.LookupUpstreamValidation(v, caCertNamespacedName, ext.Namespace); err != nil {
 			validCondition.AddErrorf(contour_api_v1.ConditionTypeSpecError, "TLSUpstreamValidation",
 				"TLS upstream validation policy error: %s", err.Error())
 		} else {
@@ -0,0 +1,130 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"sort"
+	"strings"
+)
+
+// FilterExemptProcessor disables external authorization and global
+// rate limiting for requests under a set of path prefixes, such as
+// health check or metrics endpoints, on every virtual host.
+type FilterExemptProcessor struct {
+	// Prefixes is the list of exempt path prefixes.
+	Prefixes []string
+}
+
+var _ Processor = &FilterExemptProcessor{}
+
+// Run exempts the routes of each virtual host built by the preceding
+// processors. It must run before the ListenerProcessor.
+func (p *FilterExemptProcessor) Run(dag *DAG, _ *KubernetesCache) {
+	if len(p.Prefixes) == 0 {
+		return
+	}
+
+	for _, vhost := range dag.VirtualHosts {
+		p.exemptRoutes(dag, vhost)
+	}
+
+	for _, vhost := range dag.SecureVirtualHosts {
+		p.exemptRoutes(dag, &vhost.VirtualHost)
+	}
+}
+
+// exemptRoutes exempts the routes of the virtual host that are under
+// one of the exempt prefixes, and for each route that would serve
+// requests for an exempt prefix, adds an exempt route for that prefix.
+func (p *FilterExemptProcessor) exemptRoutes(dag *DAG, vhost *VirtualHost) {
+	type derived struct {
+		route *Route
+		from  string
+	}
+
+	var added []derived
+	for key, r := range vhost.Routes {
+		var path string
+		switch c := r.PathMatchCondition.(type) {
+		case *PrefixMatchCondition:
+			path = c.Prefix
+		case *ExactMatchCondition:
+			path = c.Path
+		default:
+			continue
+		}
+
+		exempt := false
+		for _, prefix := range p.Prefixes {
+			switch {
+			case strings.HasPrefix(path, prefix):
+				exempt = true
+			case strings.HasPrefix(prefix, path):
+				match, ok := r.PathMatchCondition.(*PrefixMatchCondition)
+				if !ok {
+					continue
+				}
+				route := filterExemptRoute(r)
+				dag.copyOrigin(route, r)
+				route.PathMatchCondition = &PrefixMatchCondition{
+					Prefix:          prefix,
+					PrefixMatchType: match.PrefixMatchType,
+				}
+				added = append(added, derived{route: route, from: match.Prefix})
+			}
+		}
+
+		if exempt {
+			vhost.Routes[key] = filterExemptRoute(r)
+			dag.copyOrigin(vhost.Routes[key], r)
+		}
+	}
+
+	// Routes with the same conditions replace each other, so add
+	// the derived routes in order of the length of the prefix they
+	// were derived from, so that the most specific route wins. The
+	// existing routes always win.
+	sort.SliceStable(added, func(i, j int) bool {
+		return len(added[i].from) < len(added[j].from)
+	})
+
+	existing := make(map[string]bool, len(vhost.Routes))
+	for key := range vhost.Routes {
+		existing[key] = true
+	}
+
+	for _, d := range added {
+		if existing[conditionsToString(d.route)] {
+			continue
+		}
+		vhost.addRoute(d.route)
+	}
+}
+
+// filterExemptRoute returns a copy of the route with external
+// authorization and global rate limiting disabled.
+func filterExemptRoute(r *Route) *Route {
+	exempt := *r
+	exempt.AuthDisabled = true
+	exempt.AuthContext = nil
+
+	var rlp RateLimitPolicy
+	if r.RateLimitPolicy != nil {
+		rlp = *r.RateLimitPolicy
+	}
+	rlp.Global = &GlobalRateLimitPolicy{Disabled: true}
+	exempt.RateLimitPolicy = &rlp
+
+	return &exempt
+}
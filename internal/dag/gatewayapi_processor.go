@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/projectcontour/contour/internal/errors"
@@ -37,6 +38,7 @@ import (
 const (
 	KindHTTPRoute = "HTTPRoute"
 	KindTLSRoute  = "TLSRoute"
+	KindUDPRoute  = "UDPRoute"
 )
 
 // GatewayAPIProcessor translates Gateway API types into DAG
@@ -51,6 +53,10 @@ type GatewayAPIProcessor struct {
 	// This is normally disabled for security reasons.
 	// See https://github.com/projectcontour/contour/security/advisories/GHSA-5ph6-qq5x-7jwc for details.
 	EnableExternalNameService bool
+
+	// DefaultLocalRateLimitPolicy is the local rate limit policy
+	// of the virtual hosts of HTTPRoutes (optional).
+	DefaultLocalRateLimitPolicy *LocalRateLimitPolicy
 }
 
 // matchConditions holds match rules.
@@ -138,15 +144,20 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 					}
 				}
 			}
-		case gatewayapi_v1alpha2.HTTPProtocolType:
+		case gatewayapi_v1alpha2.HTTPProtocolType, gatewayapi_v1alpha2.UDPProtocolType:
 			break
 		default:
 			p.Errorf("Listener.Protocol %q is not supported.", listener.Protocol)
 			continue
 		}
 
+		var allowedNamespaces *gatewayapi_v1alpha2.RouteNamespaces
+		if listener.AllowedRoutes != nil {
+			allowedNamespaces = listener.AllowedRoutes.Namespaces
+		}
+
 		// Get a list of the route kinds that the listener accepts.
-		listenerRouteKinds := p.getListenerRouteKinds(listener)
+		listenerRouteKinds := p.getListenerRouteKinds(listener, gwAccessor)
 		gwAccessor.SetListenerSupportedKinds(string(listener.Name), listenerRouteKinds)
 
 		attachedRoutes := 0
@@ -155,7 +166,7 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 			case KindHTTPRoute:
 				for _, route := range p.source.httproutes {
 					// Check if the route is in a namespace that the listener allows.
-					nsMatches, err := p.namespaceMatches(listener.AllowedRoutes.Namespaces, route.Namespace)
+					nsMatches, err := p.namespaceMatches(allowedNamespaces, route.Namespace)
 					if err != nil {
 						p.Errorf("error validating namespaces against Listener.Routes.Namespaces: %s", err)
 					}
@@ -176,7 +187,7 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 			case KindTLSRoute:
 				for _, route := range p.source.tlsroutes {
 					// Check if the route is in a namespace that the listener allows.
-					nsMatches, err := p.namespaceMatches(listener.AllowedRoutes.Namespaces, route.Namespace)
+					nsMatches, err := p.namespaceMatches(allowedNamespaces, route.Namespace)
 					if err != nil {
 						p.Errorf("error validating namespaces against Listener.Routes.Namespaces: %s", err)
 					}
@@ -194,6 +205,27 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 						attachedRoutes++
 					}
 				}
+			case KindUDPRoute:
+				for _, route := range p.udpRoutes() {
+					// Check if the route is in a namespace that the listener allows.
+					nsMatches, err := p.namespaceMatches(allowedNamespaces, route.Namespace)
+					if err != nil {
+						p.Errorf("error validating namespaces against Listener.Routes.Namespaces: %s", err)
+					}
+					if !nsMatches {
+						continue
+					}
+
+					// If the Gateway selects the UDPRoute, check to see if the UDPRoute selects
+					// the Gateway/listener.
+					if !routeSelectsGatewayListener(p.source.gateway, listener, route.Spec.ParentRefs, route.Namespace) {
+						continue
+					}
+
+					if p.computeUDPRoute(route, int(listener.Port), len(gatewayErrors) == 0) {
+						attachedRoutes++
+					}
+				}
 			}
 		}
 
@@ -204,11 +236,12 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 }
 
 // getListenerRouteKinds gets a list of the valid route kinds that
-// the listener accepts.
-func (p *GatewayAPIProcessor) getListenerRouteKinds(listener gatewayapi_v1alpha2.Listener) []gatewayapi_v1alpha2.Kind {
+// the listener accepts. If any of the kinds the listener allows are
+// invalid, the listener's ResolvedRefs condition is set to false.
+func (p *GatewayAPIProcessor) getListenerRouteKinds(listener gatewayapi_v1alpha2.Listener, gwAccessor *status.GatewayStatusUpdate) []gatewayapi_v1alpha2.Kind {
 	// None specified on the listener: return the default based on
 	// the listener's protocol.
-	if len(listener.AllowedRoutes.Kinds) == 0 {
+	if listener.AllowedRoutes == nil || len(listener.AllowedRoutes.Kinds) == 0 {
 		switch listener.Protocol {
 		case gatewayapi_v1alpha2.HTTPProtocolType:
 			return []gatewayapi_v1alpha2.Kind{KindHTTPRoute}
@@ -216,29 +249,45 @@ func (p *GatewayAPIProcessor) getListenerRouteKinds(listener gatewayapi_v1alpha2
 			return []gatewayapi_v1alpha2.Kind{KindHTTPRoute}
 		case gatewayapi_v1alpha2.TLSProtocolType:
 			return []gatewayapi_v1alpha2.Kind{KindTLSRoute}
+		case gatewayapi_v1alpha2.UDPProtocolType:
+			return []gatewayapi_v1alpha2.Kind{KindUDPRoute}
 		}
 	}
 
-	var routeKinds []gatewayapi_v1alpha2.Kind
+	invalidKind := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		p.Error(msg)
+		gwAccessor.AddListenerCondition(
+			string(listener.Name),
+			gatewayapi_v1alpha2.ListenerConditionResolvedRefs,
+			metav1.ConditionFalse,
+			gatewayapi_v1alpha2.ListenerReasonInvalidRouteKinds,
+			msg,
+		)
+	}
 
-	// TODO if a kind is invalid, set "ResolvedRefs: false" condition
-	// on the listener with "InvalidRouteKinds" reason.
+	var routeKinds []gatewayapi_v1alpha2.Kind
 
 	for _, routeKind := range listener.AllowedRoutes.Kinds {
-		if routeKind.Group == nil {
-			p.Errorf("Listener.AllowedRoutes.Group not specified")
-			continue
+		// The group defaults to the Gateway API group.
+		group := gatewayapi_v1alpha2.Group(gatewayapi_v1alpha2.GroupName)
+		if routeKind.Group != nil {
+			group = *routeKind.Group
 		}
-		if *routeKind.Group != gatewayapi_v1alpha2.GroupName {
-			p.Errorf("Listener.AllowedRoutes.Group %q not supported", *routeKind.Group)
+		if group != gatewayapi_v1alpha2.GroupName {
+			invalidKind("Listener.AllowedRoutes.Group %q not supported", group)
 			continue
 		}
-		if routeKind.Kind != gatewayapi_v1alpha2.Kind(KindHTTPRoute) && routeKind.Kind != gatewayapi_v1alpha2.Kind(KindTLSRoute) {
-			p.Errorf("Listener.AllowedRoutes.Kind %q not supported", routeKind.Kind)
+		if routeKind.Kind != gatewayapi_v1alpha2.Kind(KindHTTPRoute) && routeKind.Kind != gatewayapi_v1alpha2.Kind(KindTLSRoute) && routeKind.Kind != gatewayapi_v1alpha2.Kind(KindUDPRoute) {
+			invalidKind("Listener.AllowedRoutes.Kind %q not supported", routeKind.Kind)
 			continue
 		}
 		if routeKind.Kind == gatewayapi_v1alpha2.Kind(KindTLSRoute) && listener.Protocol != gatewayapi_v1alpha2.TLSProtocolType {
-			p.Errorf("invalid listener protocol %q for Kind: TLSRoute", listener.Protocol)
+			invalidKind("invalid listener protocol %q for Kind: TLSRoute", listener.Protocol)
+			continue
+		}
+		if (routeKind.Kind == gatewayapi_v1alpha2.Kind(KindUDPRoute)) != (listener.Protocol == gatewayapi_v1alpha2.UDPProtocolType) {
+			invalidKind("invalid listener protocol %q for Kind: %s", listener.Protocol, routeKind.Kind)
 			continue
 		}
 
@@ -419,6 +468,10 @@ func (p *GatewayAPIProcessor) namespaceMatches(namespaces *gatewayapi_v1alpha2.R
 			// Look for matching labels on Selector.
 			return l.Matches(labels.Set(ns.Labels)), nil
 		}
+
+		// The route's namespace is not known yet, so its labels
+		// can't be matched. The DAG is rebuilt when it is added.
+		return false, nil
 	}
 	return true, nil
 }
@@ -562,6 +615,80 @@ func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha2.TLSRout
 	return programmed
 }
 
+// udpRoutes returns the UDPRoutes of the cache, oldest first, so that
+// the oldest of the routes that conflict over a port is programmed.
+func (p *GatewayAPIProcessor) udpRoutes() []*gatewayapi_v1alpha2.UDPRoute {
+	routes := make([]*gatewayapi_v1alpha2.UDPRoute, 0, len(p.source.udproutes))
+	for _, route := range p.source.udproutes {
+		routes = append(routes, route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if !routes[i].CreationTimestamp.Equal(&routes[j].CreationTimestamp) {
+			return routes[i].CreationTimestamp.Before(&routes[j].CreationTimestamp)
+		}
+		return k8s.NamespacedNameOf(routes[i]).String() < k8s.NamespacedNameOf(routes[j]).String()
+	})
+
+	return routes
+}
+
+func (p *GatewayAPIProcessor) computeUDPRoute(route *gatewayapi_v1alpha2.UDPRoute, port int, validGateway bool) bool {
+	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.UDPRoute{}, route.Status.Parents)
+	defer commit()
+
+	// If the Gateway is invalid, set status on the route.
+	if !validGateway {
+		routeAccessor.AddCondition(gatewayapi_v1alpha2.ConditionRouteAccepted, metav1.ConditionFalse, status.ReasonInvalidGateway, "Invalid Gateway")
+		return false
+	}
+
+	// Envoy forwards the datagrams of a port to a single
+	// cluster, so only one route can be bound to a port.
+	if _, ok := p.dag.UDPProxies[port]; ok {
+		routeAccessor.AddCondition(gatewayapi_v1alpha2.ConditionRouteAccepted, metav1.ConditionFalse, status.ReasonPortConflict, fmt.Sprintf("Another UDPRoute is already bound to port %d.", port))
+		return false
+	}
+
+	var backendRefs []gatewayapi_v1alpha2.BackendRef
+	for _, rule := range route.Spec.Rules {
+		backendRefs = append(backendRefs, rule.BackendRefs...)
+	}
+
+	var programmed bool
+	switch len(backendRefs) {
+	case 0:
+		routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, "At least one Spec.Rules.BackendRef must be specified.")
+	case 1:
+		service, err := p.validateBackendRef(backendRefs[0], KindUDPRoute, route.Namespace)
+		if err != nil {
+			routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, err.Error())
+			break
+		}
+
+		p.dag.UDPProxies[port] = &UDPProxy{
+			Cluster: &Cluster{
+				Upstream: service,
+			},
+		}
+
+		programmed = true
+	default:
+		routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, "Only one Spec.Rules.BackendRef is supported.")
+	}
+
+	// Determine if any errors exist in conditions and set the "Accepted"
+	// condition accordingly.
+	switch len(routeAccessor.Conditions) {
+	case 0:
+		routeAccessor.AddCondition(gatewayapi_v1alpha2.ConditionRouteAccepted, metav1.ConditionTrue, status.ReasonValid, "Valid UDPRoute")
+	default:
+		routeAccessor.AddCondition(gatewayapi_v1alpha2.ConditionRouteAccepted, metav1.ConditionFalse, status.ReasonErrorsExist, "Errors found, check other Conditions for details.")
+	}
+
+	return programmed
+}
+
 func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha2.HTTPRoute, listenerSecret *Secret, listenerHostname *gatewayapi_v1alpha2.Hostname, validGateway bool) bool {
 	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.HTTPRoute{}, route.Status.Parents)
 	defer commit()
@@ -664,6 +791,10 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha2.HTTPRo
 			routes = p.clusterRoutes(route.Namespace, matchconditions, headerPolicy, rule.BackendRefs, routeAccessor)
 		}
 
+		for _, r := range routes {
+			p.dag.setOrigin(r, route)
+		}
+
 		// Add each route to the relevant vhost(s)/svhosts(s).
 		for host := range hosts {
 			for _, route := range routes {
@@ -679,9 +810,11 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha2.HTTPRo
 				case listenerSecret != nil:
 					svhost := p.dag.EnsureSecureVirtualHost(host)
 					svhost.Secret = listenerSecret
+					svhost.RateLimitPolicy = defaultLocalRateLimitPolicy(svhost.RateLimitPolicy, p.DefaultLocalRateLimitPolicy)
 					svhost.addRoute(route)
 				default:
 					vhost := p.dag.EnsureVirtualHost(host)
+					vhost.RateLimitPolicy = defaultLocalRateLimitPolicy(vhost.RateLimitPolicy, p.DefaultLocalRateLimitPolicy)
 					vhost.addRoute(route)
 				}
 
@@ -775,7 +908,12 @@ func (p *GatewayAPIProcessor) validateBackendRef(backendRef gatewayapi_v1alpha2.
 	}
 
 	// TODO: Refactor EnsureService to take an int32 so conversion to intstr is not needed.
-	service, err := p.dag.EnsureService(meta, intstr.FromInt(int(*backendRef.Port)), p.source, p.EnableExternalNameService)
+	ensureService := p.dag.EnsureService
+	if routeKind == KindUDPRoute {
+		ensureService = p.dag.EnsureUDPService
+	}
+
+	service, err := ensureService(meta, intstr.FromInt(int(*backendRef.Port)), p.source, p.EnableExternalNameService)
 	if err != nil {
 		return nil, fmt.Errorf("service %q is invalid: %s", meta.Name, err)
 	}
@@ -848,10 +986,21 @@ func (p *GatewayAPIProcessor) clusterRoutes(routeNamespace string, matchConditio
 			continue
 		}
 
-		var headerPolicy *HeadersPolicy
+		var (
+			headerPolicy       *HeadersPolicy
+			headerModifierSeen bool
+		)
 		for _, filter := range backendRef.Filters {
 			switch filter.Type {
 			case gatewayapi_v1alpha2.HTTPRouteFilterRequestHeaderModifier:
+				// As for the rule-level filters, only the first
+				// header modifier of a backend is processed.
+				if headerModifierSeen {
+					continue
+				}
+
+				headerModifierSeen = true
+
 				var err error
 				headerPolicy, err = headersPolicyGatewayAPI(filter.RequestHeaderModifier)
 				if err != nil {
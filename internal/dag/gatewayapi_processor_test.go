@@ -509,6 +509,20 @@ func TestNamespaceMatches(t *testing.T) {
 			valid:     false,
 			wantError: true,
 		},
+		"From.NamespacesFromSelector doesn't match a namespace that isn't cached": {
+			namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
+				From: gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromSelector),
+				Selector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "notthere",
+						Operator: metav1.LabelSelectorOpDoesNotExist,
+					}},
+				},
+			},
+			namespace: "unknown",
+			valid:     false,
+			wantError: false,
+		},
 		"From.NamespacesFromSelector must define matchLabels or matchExpression": {
 			namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
 				From:     gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromSelector),
@@ -16,9 +16,11 @@ package dag
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
@@ -27,6 +29,7 @@ import (
 	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/timeout"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -48,10 +51,22 @@ type HTTPProxyProcessor struct {
 	source   *KubernetesCache
 	orphaned map[types.NamespacedName]bool
 
+	// routeOwners records the HTTPProxy that defined each route.
+	routeOwners map[*Route]*contour_api_v1.HTTPProxy
+
+	// rejectedIncludes records why includes were skipped,
+	// keyed by the including and the included HTTPProxy.
+	rejectedIncludes map[includeEdge]string
+
 	// DisablePermitInsecure disables the use of the
 	// permitInsecure field in HTTPProxy.
 	DisablePermitInsecure bool
 
+	// PermitInsecurePrefixes is a list of path prefixes that
+	// are served over HTTP, rather than redirected to HTTPS,
+	// on virtual hosts with TLS enabled.
+	PermitInsecurePrefixes []string
+
 	// FallbackCertificate is the optional identifier of the
 	// TLS secret to use by default when SNI is not set on a
 	// request.
@@ -62,6 +77,11 @@ type HTTPProxyProcessor struct {
 	// See https://github.com/projectcontour/contour/security/advisories/GHSA-5ph6-qq5x-7jwc for details.
 	EnableExternalNameService bool
 
+	// EnableStaticEndpoints allows services to route to static
+	// endpoints rather than to Kubernetes Services.
+	// This is normally disabled for security reasons.
+	EnableStaticEndpoints bool
+
 	// DNSLookupFamily defines how external names are looked up
 	// When configured as V4, the DNS resolver will only perform a lookup
 	// for addresses in the IPv4 family. If V6 is configured, the DNS resolver
@@ -81,6 +101,84 @@ type HTTPProxyProcessor struct {
 
 	// Response headers that will be set on all routes (optional).
 	ResponseHeadersPolicy *HeadersPolicy
+
+	// MaxResponseTimeout and MaxIdleTimeout are the largest
+	// response and idle timeouts that a route may set. Routes
+	// that exceed them, or disable the timeouts, are rejected.
+	// Zero means no limit.
+	MaxResponseTimeout time.Duration
+	MaxIdleTimeout     time.Duration
+
+	// DefaultLocalRateLimitPolicy is the local rate limit policy
+	// of virtual hosts that don't set one (optional).
+	DefaultLocalRateLimitPolicy *LocalRateLimitPolicy
+
+	// trafficShifts records the progress of each route's
+	// traffic shift. Unlike the fields above it persists
+	// between runs, since shifts progress over time.
+	trafficShifts map[trafficShiftKey]*trafficShiftState
+
+	// shiftStatuses records the traffic shift status
+	// entries of each HTTPProxy during a run.
+	shiftStatuses map[types.NamespacedName][]contour_api_v1.TrafficShiftStatus
+
+	// routeWeights records the service weights set by
+	// valid TrafficWeights resources, keyed by route.
+	routeWeights map[routeWeightsKey]map[string]uint32
+
+	// namespacePolicies records the policies set by valid
+	// ContourPolicy resources, keyed by namespace.
+	namespacePolicies map[string]*namespacePolicy
+
+	// now returns the current time. If nil, time.Now is used.
+	now func() time.Time
+}
+
+// trafficShiftKey identifies the traffic shift of a route.
+type trafficShiftKey struct {
+	proxy    types.NamespacedName
+	route    string
+	from, to string
+}
+
+// routeWeightsKey identifies a route of an HTTPProxy by its index.
+type routeWeightsKey struct {
+	proxy types.NamespacedName
+	route int
+}
+
+// namespacePolicy is the policy that a valid ContourPolicy
+// sets on the HTTPProxies in its namespace.
+type namespacePolicy struct {
+	name     string
+	defaults *contour_api_v1alpha1.PolicyDefaults
+
+	maxResponseTimeout time.Duration
+	maxIdleTimeout     time.Duration
+
+	// allowedStrategies is empty if any load
+	// balancer strategy is allowed.
+	allowedStrategies map[string]bool
+
+	// protectedHeaders holds canonical header names.
+	protectedHeaders map[string]bool
+}
+
+// trafficShiftState is the progress of a traffic shift.
+type trafficShiftState struct {
+	steps    []uint32
+	interval time.Duration
+
+	// step is the index of the current step, which
+	// began at since.
+	step  int
+	since time.Time
+
+	halted bool
+
+	// seen records whether the shift was
+	// computed during the current run.
+	seen bool
 }
 
 // Run translates HTTPProxies into DAG objects and
@@ -89,26 +187,47 @@ func (p *HTTPProxyProcessor) Run(dag *DAG, source *KubernetesCache) {
 	p.dag = dag
 	p.source = source
 	p.orphaned = make(map[types.NamespacedName]bool, len(p.orphaned))
+	p.routeOwners = make(map[*Route]*contour_api_v1.HTTPProxy)
+	p.rejectedIncludes = make(map[includeEdge]string)
+	p.shiftStatuses = make(map[types.NamespacedName][]contour_api_v1.TrafficShiftStatus)
+	p.routeWeights = make(map[routeWeightsKey]map[string]uint32)
+	p.namespacePolicies = make(map[string]*namespacePolicy)
+	if p.trafficShifts == nil {
+		p.trafficShifts = make(map[trafficShiftKey]*trafficShiftState)
+	}
 
 	// reset the processor when we're done
 	defer func() {
 		p.dag = nil
 		p.source = nil
 		p.orphaned = nil
+		p.routeOwners = nil
+		p.rejectedIncludes = nil
+		p.shiftStatuses = nil
+		p.routeWeights = nil
+		p.namespacePolicies = nil
 	}()
 
+	p.computeContourPolicies()
+	p.computeTrafficWeights()
+
 	for _, proxy := range p.validHTTPProxies() {
 		p.computeHTTPProxy(proxy)
 	}
 
+	p.finishTrafficShifts()
+
 	for meta := range p.orphaned {
 		proxy, ok := p.source.httpproxies[meta]
 		if ok {
 			pa, commit := p.dag.StatusCache.ProxyAccessor(proxy)
-			pa.ConditionFor(status.ValidCondition).AddError(contour_api_v1.ConditionTypeOrphanedError,
+			validCond := pa.ConditionFor(status.ValidCondition)
+			validCond.AddError(contour_api_v1.ConditionTypeOrphanedError,
 				"Orphaned",
 				"this HTTPProxy is not part of a delegation chain from a root HTTPProxy")
+			p.addOrphanHints(validCond, meta)
 			commit()
+			p.dag.ignore(proxy, "HTTPProxy is not included by a root HTTPProxy")
 		}
 	}
 }
@@ -136,9 +255,10 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 
 	// Ensure root httpproxy lives in allowed namespace.
 	// This check must be after we can determine the vhost in order to be able to calculate metrics correctly.
-	if !p.rootAllowed(proxy.Namespace) {
+	if !p.source.rootAllowed(proxy.Namespace) {
 		validCond.AddError(contour_api_v1.ConditionTypeRootNamespaceError, "RootProxyNotAllowedInNamespace",
 			"root HTTPProxy cannot be defined in this namespace")
+		p.dag.ignore(proxy, "root HTTPProxy is not in a root namespace")
 		return
 	}
 
@@ -148,6 +268,13 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 		return
 	}
 
+	aliases, err := p.virtualHostAliases(proxy.Spec.VirtualHost)
+	if err != nil {
+		validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "AliasNotValid",
+			"Spec.VirtualHost.Aliases is invalid: %s", err)
+		return
+	}
+
 	var tlsEnabled bool
 	if tls := proxy.Spec.VirtualHost.TLS; tls != nil {
 		if tls.Passthrough && tls.EnableFallbackCertificate {
@@ -172,6 +299,14 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 			return
 		}
 
+		for _, prefix := range tls.PermitInsecurePrefixes {
+			if !strings.HasPrefix(prefix, "/") {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeTLSError, "TLSConfigNotValid",
+					"Spec.VirtualHost.TLS.PermitInsecurePrefixes: prefix %q must begin with \"/\"", prefix)
+				return
+			}
+		}
+
 		tlsEnabled = true
 
 		// Attach secrets to TLS enabled vhosts.
@@ -192,6 +327,8 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 
 			svhost := p.dag.EnsureSecureVirtualHost(host)
 			svhost.Secret = sec
+			p.addCertificateExpiryWarning(validCond, tls.SecretName, sec)
+
 			// default to a minimum TLS version of 1.2 if it's not specified
 			svhost.MinTLSVersion = annotation.MinTLSVersion(tls.MinimumProtocolVersion, "1.2")
 
@@ -240,7 +377,19 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 			// Fill in DownstreamValidation when external client validation is enabled.
 			if tls.ClientValidation != nil {
 				dv := &PeerValidationContext{
-					SkipClientCertValidation: tls.ClientValidation.SkipClientCertValidation,
+					SkipClientCertValidation:       tls.ClientValidation.SkipClientCertValidation,
+					ForwardClientCertificateStatus: tls.ClientValidation.ForwardClientCertificateStatus,
+				}
+				if mode := tls.ClientValidation.Mode; mode == contour_api_v1.ClientCertificateModeOptional || mode == contour_api_v1.ClientCertificateModeOptionalNoCA {
+					if tls.ClientValidation.SkipClientCertValidation {
+						validCond.AddErrorf(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid",
+							"Spec.VirtualHost.TLS client validation is invalid: mode %q cannot be combined with skipClientCertValidation", mode)
+						return
+					}
+					// OptionalNoCA requests a client certificate
+					// but accepts one that cannot be verified.
+					dv.OptionalClientCertificate = true
+					dv.SkipClientCertValidation = mode == contour_api_v1.ClientCertificateModeOptionalNoCA
 				}
 				if tls.ClientValidation.CACertificate != "" {
 					secretName := k8s.NamespacedNameFrom(tls.ClientValidation.CACertificate, k8s.DefaultNamespace(proxy.Namespace))
@@ -252,7 +401,7 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 						return
 					}
 					dv.CACertificate = cacert
-				} else if !tls.ClientValidation.SkipClientCertValidation {
+				} else if !dv.SkipClientCertValidation {
 					validCond.AddErrorf(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid",
 						"Spec.VirtualHost.TLS client validation is invalid: CA Secret must be specified")
 				}
@@ -298,9 +447,53 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 					svhost.AuthorizationResponseTimeout = timeout
 				}
 			}
+
+			ct, err := connectionTimeouts(proxy.Spec.VirtualHost.ConnectionTimeoutPolicy)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "ConnectionTimeoutPolicyNotValid",
+					"Spec.VirtualHost.ConnectionTimeoutPolicy is invalid: %s", err)
+				return
+			}
+			svhost.ConnectionTimeouts = ct
+
+			hsts, err := hstsPolicy(proxy.Spec.VirtualHost.HSTSPolicy)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "HSTSPolicyNotValid",
+					"Spec.VirtualHost.HSTSPolicy is invalid: %s", err)
+				return
+			}
+			svhost.HSTSPolicy = hsts
+
+			limits, err := headerLimits(proxy.Spec.VirtualHost.HeaderLimitsPolicy)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "HeaderLimitsPolicyNotValid",
+					"Spec.VirtualHost.HeaderLimitsPolicy is invalid: %s", err)
+				return
+			}
+			svhost.HeaderLimits = limits
 		}
 	}
 
+	// Only TLS terminating virtual hosts have a connection
+	// manager of their own to apply the timeouts to.
+	if proxy.Spec.VirtualHost.ConnectionTimeoutPolicy != nil && (!tlsEnabled || proxy.Spec.VirtualHost.TLS.Passthrough) {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+			"ignoring field %q; requires Spec.VirtualHost.TLS.SecretName to be set", "Spec.VirtualHost.ConnectionTimeoutPolicy")
+	}
+
+	// HSTS only applies to responses served over HTTPS.
+	if proxy.Spec.VirtualHost.HSTSPolicy != nil && (!tlsEnabled || proxy.Spec.VirtualHost.TLS.Passthrough) {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+			"ignoring field %q; requires Spec.VirtualHost.TLS.SecretName to be set", "Spec.VirtualHost.HSTSPolicy")
+	}
+
+	// Like connection timeouts, header limits apply to the
+	// connection manager of a TLS terminating virtual host.
+	if proxy.Spec.VirtualHost.HeaderLimitsPolicy != nil && (!tlsEnabled || proxy.Spec.VirtualHost.TLS.Passthrough) {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+			"ignoring field %q; requires Spec.VirtualHost.TLS.SecretName to be set", "Spec.VirtualHost.HeaderLimitsPolicy")
+	}
+
 	if proxy.Spec.TCPProxy != nil {
 		if !tlsEnabled {
 			validCond.AddError(contour_api_v1.ConditionTypeTCPProxyError, "TLSMustBeConfigured",
@@ -310,10 +503,13 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 		if !p.processHTTPProxyTCPProxy(validCond, proxy, nil, host) {
 			return
 		}
+		p.dag.EnsureSecureVirtualHost(host).Aliases = aliases
 	}
 
 	routes := p.computeRoutes(validCond, proxy, proxy, nil, nil, tlsEnabled)
+	routes = p.resolveRouteConflicts(proxy, routes)
 	insecure := p.dag.EnsureVirtualHost(host)
+	insecure.Aliases = aliases
 	cp, err := toCORSPolicy(proxy.Spec.VirtualHost.CORSPolicy)
 	if err != nil {
 		validCond.AddErrorf(contour_api_v1.ConditionTypeCORSError, "PolicyDidNotParse",
@@ -322,31 +518,47 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 	}
 	insecure.CORSPolicy = cp
 
-	rlp, err := rateLimitPolicy(proxy.Spec.VirtualHost.RateLimitPolicy)
+	rlp, err := virtualHostRateLimitPolicy(p.rootRateLimitPolicy(proxy))
 	if err != nil {
 		validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
 			"Spec.VirtualHost.RateLimitPolicy is invalid: %s", err)
 		return
 	}
-	insecure.RateLimitPolicy = rlp
+	insecure.RateLimitPolicy = p.virtualHostRateLimitPolicy(proxy, rlp)
 
-	addRoutes(insecure, routes)
+	addRoutes(insecure, permitInsecurePrefixes(routes, p.insecurePrefixes(proxy)))
+	p.setOrigins(proxy, insecure.Routes)
 
 	// if TLS is enabled for this virtual host and there is no tcp proxy defined,
 	// then add routes to the secure virtualhost definition.
 	if tlsEnabled && proxy.Spec.TCPProxy == nil {
 		secure := p.dag.EnsureSecureVirtualHost(host)
+		secure.Aliases = aliases
 		secure.CORSPolicy = cp
 
-		rlp, err := rateLimitPolicy(proxy.Spec.VirtualHost.RateLimitPolicy)
+		rlp, err := virtualHostRateLimitPolicy(p.rootRateLimitPolicy(proxy))
 		if err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
 				"Spec.VirtualHost.RateLimitPolicy is invalid: %s", err)
 			return
 		}
-		secure.RateLimitPolicy = rlp
+		secure.RateLimitPolicy = p.virtualHostRateLimitPolicy(proxy, rlp)
 
 		addRoutes(secure, routes)
+		p.setOrigins(proxy, secure.Routes)
+	}
+}
+
+// setOrigins records the HTTPProxy that defined each of the routes.
+// Routes derived from a route after its owner was recorded, such
+// as those of permitted insecure prefixes, are attributed to root.
+func (p *HTTPProxyProcessor) setOrigins(root *contour_api_v1.HTTPProxy, routes map[string]*Route) {
+	for _, r := range routes {
+		owner, ok := p.routeOwners[r]
+		if !ok {
+			owner = root
+		}
+		p.dag.setOrigin(r, owner)
 	}
 }
 
@@ -390,6 +602,9 @@ func (p *HTTPProxyProcessor) computeRoutes(
 	if includeMatchConditionsIdentical(proxy.Spec.Includes) {
 		validCond.AddError(contour_api_v1.ConditionTypeIncludeError, "DuplicateMatchConditions",
 			"duplicate conditions defined on an include")
+		for _, include := range proxy.Spec.Includes {
+			p.rejectInclude(proxy, include, "duplicate conditions defined on an include")
+		}
 		return nil
 	}
 
@@ -403,12 +618,14 @@ func (p *HTTPProxyProcessor) computeRoutes(
 		if err := pathMatchConditionsValid(include.Conditions); err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeIncludeError, "PathMatchConditionsNotValid",
 				"include: %s", err)
+			p.rejectInclude(proxy, include, err.Error())
 			continue
 		}
 
 		if err := headerMatchConditionsValid(include.Conditions); err != nil {
 			validCond.AddError(contour_api_v1.ConditionTypeRouteError, "HeaderMatchConditionsNotValid",
 				err.Error())
+			p.rejectInclude(proxy, include, err.Error())
 			continue
 		}
 
@@ -419,11 +636,13 @@ func (p *HTTPProxyProcessor) computeRoutes(
 
 			// Set 502 response when include was not found but include condition was valid.
 			if len(include.Conditions) > 0 {
-				routes = append(routes, &Route{
+				r := &Route{
 					PathMatchCondition:    mergePathMatchConditions(include.Conditions),
 					HeaderMatchConditions: mergeHeaderMatchConditions(include.Conditions),
 					DirectResponse:        directResponse(http.StatusBadGateway),
-				})
+				}
+				p.routeOwners[r] = proxy
+				routes = append(routes, r)
 			}
 
 			continue
@@ -448,13 +667,43 @@ func (p *HTTPProxyProcessor) computeRoutes(
 		"CONTOUR_NAMESPACE": proxy.Namespace,
 	}
 
-	for _, route := range proxy.Spec.Routes {
+	// The global headers policies apply to every route unless
+	// the HTTPProxy defining the route has opted out of them.
+	globalRequestHeadersPolicy, globalResponseHeadersPolicy := p.RequestHeadersPolicy, p.ResponseHeadersPolicy
+	if proxy.Spec.DisableGlobalHeadersPolicy {
+		globalRequestHeadersPolicy, globalResponseHeadersPolicy = nil, nil
+	}
+
+	// The ContourPolicy of the namespace of the HTTPProxy
+	// defining the route sets its defaults and limits.
+	np := p.namespacePolicies[proxy.Namespace]
+	maxResponseTimeout, maxIdleTimeout := p.MaxResponseTimeout, p.MaxIdleTimeout
+	if np != nil {
+		maxResponseTimeout = minLimit(maxResponseTimeout, np.maxResponseTimeout)
+		maxIdleTimeout = minLimit(maxIdleTimeout, np.maxIdleTimeout)
+	}
+
+	for i, route := range proxy.Spec.Routes {
 		if err := pathMatchConditionsValid(route.Conditions); err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "PathMatchConditionsNotValid",
 				"route: %s", err)
 			return nil
 		}
 
+		if np != nil {
+			if err := np.loadBalancerPolicyAllowed(route.LoadBalancerPolicy); err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "LoadBalancerPolicyNotAllowed",
+					"route.loadBalancerPolicy %s", err)
+				return nil
+			}
+			if err := np.headersPoliciesAllowed(route); err != nil {
+				validCond.AddError(contour_api_v1.ConditionTypeRouteError, "HeadersPolicyNotAllowed",
+					err.Error())
+				return nil
+			}
+			route = np.withDefaults(route)
+		}
+
 		routeConditions := conditions
 		routeConditions = append(routeConditions, route.Conditions...)
 
@@ -498,14 +747,82 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				"route.timeoutPolicy failed to parse: %s", err)
 			return nil
 		}
+		if err := timeoutPolicyWithinLimits(tp, maxResponseTimeout, maxIdleTimeout); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "TimeoutPolicyExceedsLimit",
+				"route.timeoutPolicy %s", err)
+			return nil
+		}
+
+		hcp, err := httpHealthCheckPolicy(route.HealthCheckPolicy)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "HealthCheckPolicyNotValid",
+				"route.healthCheckPolicy is invalid: %s", err)
+			return nil
+		}
 
-		rlp, err := rateLimitPolicy(route.RateLimitPolicy)
+		rlp, err := routeRateLimitPolicy(route.RateLimitPolicy, p.rootRateLimitPolicy(rootProxy))
 		if err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
 				"route.rateLimitPolicy is invalid: %s", err)
 			return nil
 		}
 
+		tsp, err := trafficSplitPolicy(route.TrafficSplitPolicy)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "TrafficSplitPolicyNotValid",
+				"route.trafficSplitPolicy is invalid: %s", err)
+			return nil
+		}
+
+		shift, err := trafficShiftPolicy(route.TrafficShiftPolicy, route.Services)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "TrafficShiftPolicyNotValid",
+				"route.trafficShiftPolicy is invalid: %s", err)
+			return nil
+		}
+
+		rp := retryPolicy(route.RetryPolicy)
+
+		gp, err := grpcPolicy(route.GRPCPolicy)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "GRPCPolicyNotValid",
+				"route.grpcPolicy is invalid: %s", err)
+			return nil
+		}
+		if gp != nil {
+			// Client deadlines take the place of the response
+			// timeout, so they are subject to its limit.
+			if maxResponseTimeout > 0 && (gp.MaxTimeout == 0 || gp.MaxTimeout > maxResponseTimeout) {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "GRPCPolicyExceedsLimit",
+					"route.grpcPolicy maxTimeout must be set, and must not exceed %s", maxResponseTimeout)
+				return nil
+			}
+			if rp != nil && len(route.RetryPolicy.RetryOn) == 0 {
+				rp.RetryOn = grpcRetryOn
+			}
+		}
+
+		hp, err := hedgePolicy(route.HedgePolicy, rp)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "HedgePolicyNotValid",
+				"route.hedgePolicy is invalid: %s", err)
+			return nil
+		}
+
+		virtualClusterName, err := statsPolicy(route.StatsPolicy)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "StatsPolicyNotValid",
+				"route.statsPolicy is invalid: %s", err)
+			return nil
+		}
+
+		df, err := disabledFilters(route.DisabledFilters, rlp)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "DisabledFiltersNotValid",
+				"route.disabledFilters is invalid: %s", err)
+			return nil
+		}
+
 		requestHashPolicies, lbPolicy := loadBalancerRequestHashPolicies(route.LoadBalancerPolicy, validCond)
 
 		r := &Route{
@@ -513,13 +830,28 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			HeaderMatchConditions: mergeHeaderMatchConditions(routeConditions),
 			Websocket:             route.EnableWebsockets,
 			HTTPSUpgrade:          routeEnforceTLS(enforceTLS, route.PermitInsecure && !p.DisablePermitInsecure),
+			HTTPSRedirectPolicy:   httpsRedirectPolicy(route.HTTPSRedirectPolicy),
 			TimeoutPolicy:         tp,
-			RetryPolicy:           retryPolicy(route.RetryPolicy),
+			RetryPolicy:           rp,
+			HedgePolicy:           hp,
+			GRPCPolicy:            gp,
+			VirtualClusterName:    virtualClusterName,
 			RequestHeadersPolicy:  reqHP,
 			ResponseHeadersPolicy: respHP,
 			CookieRewritePolicies: cookieRP,
 			RateLimitPolicy:       rlp,
 			RequestHashPolicies:   requestHashPolicies,
+			TrafficSplitPolicy:    tsp,
+			DisabledFilters:       df,
+		}
+
+		if route.Priority != 0 {
+			if rootProxy.Spec.VirtualHost.RoutePrecedence == contour_api_v1.RoutePrecedencePriority {
+				r.Priority = route.Priority
+			} else {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeSpecError, "IgnoredField",
+					"ignoring route.priority, the routePrecedence of the virtual host is not %s", contour_api_v1.RoutePrecedencePriority)
+			}
 		}
 
 		// If the enclosing root proxy enabled authorization,
@@ -536,12 +868,19 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			// Take the default for enabling authorization
 			// from the virtual host. If this route has a
 			// policy, let that override.
+			var routeContext map[string]string
 			if route.AuthPolicy != nil {
 				disabled = route.AuthPolicy.Disabled
+				routeContext = route.AuthPolicy.Context
 			}
 
 			r.AuthDisabled = disabled
-			r.AuthContext = route.AuthorizationContext(rootProxy.Spec.VirtualHost.AuthorizationContext())
+			r.AuthContext = authorizationContext(
+				rootProxy.Spec.VirtualHost.AuthorizationContext(),
+				routeContext,
+				rootProxy.Spec.VirtualHost.Fqdn,
+				r,
+			)
 		}
 
 		if len(route.GetPrefixReplacements()) > 0 {
@@ -582,6 +921,24 @@ func (p *HTTPProxyProcessor) computeRoutes(
 
 		}
 
+		// The weights of a shifting route's services come
+		// from the current step of the shift, and those set
+		// by a TrafficWeights resource replace the route's.
+		weights := p.routeWeights[routeWeightsKey{proxy: k8s.NamespacedNameOf(proxy), route: i}]
+		if shift != nil {
+			weight := p.trafficShiftWeight(proxy, conditionsToString(r), shift)
+			weights = map[string]uint32{
+				shift.From: 100 - weight,
+				shift.To:   weight,
+			}
+		}
+
+		if err := backupServicesValid(route.Services); err != nil {
+			validCond.AddError(contour_api_v1.ConditionTypeServiceError, "BackupServiceNotValid", err.Error())
+			return nil
+		}
+
+		var backups []backupService
 		for _, service := range route.Services {
 			if service.Port < 1 || service.Port > 65535 {
 				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "ServicePortInvalid",
@@ -589,11 +946,21 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				return nil
 			}
 			m := types.NamespacedName{Name: service.Name, Namespace: proxy.Namespace}
-			s, err := p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source, p.EnableExternalNameService)
-			if err != nil {
-				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "ServiceUnresolvedReference",
-					"Spec.Routes unresolved service reference: %s", err)
-				continue
+			var s *Service
+			var err error
+			if len(service.Endpoints) > 0 {
+				s, err = p.dag.EnsureStaticService(m, service.Port, service.Endpoints, p.EnableStaticEndpoints)
+				if err != nil {
+					validCond.AddError(contour_api_v1.ConditionTypeServiceError, "StaticEndpointsNotValid", err.Error())
+					return nil
+				}
+			} else {
+				s, err = p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source, p.EnableExternalNameService)
+				if err != nil {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "ServiceUnresolvedReference",
+						"Spec.Routes unresolved service reference: %s", err)
+					continue
+				}
 			}
 
 			// Determine the protocol to use to speak to this Cluster.
@@ -603,6 +970,20 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				return nil
 			}
 
+			// The endpoints of a backup are part of the clusters
+			// of the route's other services.
+			if service.Backup {
+				backups = append(backups, backupService{name: service.Name, service: s, protocol: protocol})
+				continue
+			}
+
+			dp, err := dnsPolicy(service.DNSPolicy)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "DNSPolicyNotValid",
+					"service %q: %s", service.Name, err)
+				return nil
+			}
+
 			var uv *PeerValidationContext
 			if (protocol == "tls" || protocol == "h2") && service.UpstreamValidation != nil {
 				// If the CACertificate name in the UpstreamValidation is namespaced and the namespace
@@ -610,13 +991,13 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				// delegated to the proxy's namespace.
 				// By default, a non-namespaced CACertificate is expected to reside in the proxy's namespace.
 				caCertNamespacedName := k8s.NamespacedNameFrom(service.UpstreamValidation.CACertificate, k8s.DefaultNamespace(proxy.Namespace))
-				if !p.source.DelegationPermitted(caCertNamespacedName, proxy.Namespace) {
+				if service.UpstreamValidation.CACertificate != "" && !p.source.DelegationPermitted(caCertNamespacedName, proxy.Namespace) {
 					validCond.AddErrorf(contour_api_v1.ConditionTypeTLSError, "CACertificateNotDelegated",
 						"service.UpstreamValidation.CACertificate Secret %q is not configured for certificate delegation", caCertNamespacedName)
 					return nil
 				}
 				// we can only validate TLS connections to services that talk TLS
-				uv, err = p.source.LookupUpstreamValidation(service.UpstreamValidation, caCertNamespacedName)
+				uv, err = p.source.LookupUpstreamValidation(service.UpstreamValidation, caCertNamespacedName, proxy.Namespace)
 				if err != nil {
 					validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "TLSUpstreamValidation",
 						"Service [%s:%d] TLS upstream validation policy error: %s", service.Name, service.Port, err)
@@ -627,13 +1008,13 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			dynamicHeaders["CONTOUR_SERVICE_NAME"] = service.Name
 			dynamicHeaders["CONTOUR_SERVICE_PORT"] = strconv.Itoa(service.Port)
 
-			reqHP, err := headersPolicyService(p.RequestHeadersPolicy, service.RequestHeadersPolicy, dynamicHeaders)
+			reqHP, err := headersPolicyService(globalRequestHeadersPolicy, service.RequestHeadersPolicy, dynamicHeaders)
 			if err != nil {
 				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "RequestHeadersPolicyInvalid",
 					"%s on request headers", err)
 				return nil
 			}
-			respHP, err := headersPolicyService(p.ResponseHeadersPolicy, service.ResponseHeadersPolicy, dynamicHeaders)
+			respHP, err := headersPolicyService(globalResponseHeadersPolicy, service.ResponseHeadersPolicy, dynamicHeaders)
 			if err != nil {
 				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "ResponseHeadersPolicyInvalid",
 					"%s on response headers", err)
@@ -661,7 +1042,7 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				Upstream:              s,
 				LoadBalancerPolicy:    lbPolicy,
 				Weight:                uint32(service.Weight),
-				HTTPHealthCheckPolicy: httpHealthCheckPolicy(route.HealthCheckPolicy),
+				HTTPHealthCheckPolicy: hcp,
 				UpstreamValidation:    uv,
 				RequestHeadersPolicy:  reqHP,
 				ResponseHeadersPolicy: respHP,
@@ -669,8 +1050,12 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				Protocol:              protocol,
 				SNI:                   determineSNI(r.RequestHeadersPolicy, reqHP, s),
 				DNSLookupFamily:       string(p.DNSLookupFamily),
+				DNSPolicy:             dp,
 				ClientCertificate:     clientCertSecret,
 			}
+			if weight, ok := weights[service.Name]; ok && !service.Mirror {
+				c.Weight = weight
+			}
 			if service.Mirror && r.MirrorPolicy != nil {
 				validCond.AddError(contour_api_v1.ConditionTypeServiceError, "OnlyOneMirror",
 					"only one service per route may be nominated as mirror")
@@ -684,10 +1069,24 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				r.Clusters = append(r.Clusters, c)
 			}
 		}
+		if err := setBackups(r.Clusters, backups); err != nil {
+			validCond.AddError(contour_api_v1.ConditionTypeServiceError, "BackupServiceNotValid", err.Error())
+			return nil
+		}
 		if len(r.Clusters) == 0 {
 			r.DirectResponse = directResponse(http.StatusServiceUnavailable)
 		}
 
+		if r.GRPCPolicy != nil {
+			for _, c := range r.Clusters {
+				if c.Protocol != "h2" && c.Protocol != "h2c" {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "GRPCPolicyNotValid",
+						"route.grpcPolicy requires services using the h2 or h2c protocol, but service %q does not", c.Upstream.Weighted.ServiceName)
+					return nil
+				}
+			}
+		}
+
 		// If we have a wildcard match, add a header match regex rule to match the
 		// hostname so we can be sure to only match one DNS label. This is required
 		// as Envoy's virtualhost hostname wildcard matching can match multiple
@@ -696,14 +1095,218 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			r.HeaderMatchConditions = append(r.HeaderMatchConditions, wildcardDomainHeaderMatch(rootProxy.Spec.VirtualHost.Fqdn))
 		}
 
+		if rootProxy.Spec.VirtualHost.DebugHeaders {
+			addDebugHeaders(r, proxy)
+		}
+
+		p.routeOwners[r] = proxy
 		routes = append(routes, r)
 	}
 
 	routes = expandPrefixMatches(routes)
+	routes = expandTrafficSplits(routes)
+
+	// Routes derived by the expansions above belong
+	// to the HTTPProxy that is being processed.
+	for _, r := range routes {
+		if _, ok := p.routeOwners[r]; !ok {
+			p.routeOwners[r] = proxy
+		}
+	}
 
 	return routes
 }
 
+// includeEdge identifies an include from one HTTPProxy of another.
+type includeEdge struct {
+	from, to types.NamespacedName
+}
+
+// includeTarget returns the name of the HTTPProxy referenced by include.
+func includeTarget(proxy *contour_api_v1.HTTPProxy, include contour_api_v1.Include) types.NamespacedName {
+	namespace := include.Namespace
+	if namespace == "" {
+		namespace = proxy.Namespace
+	}
+	return types.NamespacedName{Name: include.Name, Namespace: namespace}
+}
+
+// rejectInclude records why the include of proxy was skipped.
+func (p *HTTPProxyProcessor) rejectInclude(proxy *contour_api_v1.HTTPProxy, include contour_api_v1.Include, reason string) {
+	p.rejectedIncludes[includeEdge{from: k8s.NamespacedNameOf(proxy), to: includeTarget(proxy, include)}] = reason
+}
+
+// addOrphanHints adds a warning to the condition of the orphaned
+// HTTPProxy for every HTTPProxy that includes it, or that includes
+// a missing HTTPProxy with a similar name, explaining why the
+// include did not take effect.
+func (p *HTTPProxyProcessor) addOrphanHints(validCond *contour_api_v1.DetailedCondition, orphan types.NamespacedName) {
+	var parents []*contour_api_v1.HTTPProxy
+	for _, proxy := range p.source.httpproxies {
+		if len(proxy.Spec.Includes) > 0 && k8s.NamespacedNameOf(proxy) != orphan {
+			parents = append(parents, proxy)
+		}
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		return k8s.NamespacedNameOf(parents[i]).String() < k8s.NamespacedNameOf(parents[j]).String()
+	})
+
+	for _, parent := range parents {
+		from := k8s.NamespacedNameOf(parent)
+		for _, include := range parent.Spec.Includes {
+			target := includeTarget(parent, include)
+
+			switch {
+			case target == orphan:
+				if reason, ok := p.rejectedIncludes[includeEdge{from: from, to: target}]; ok {
+					validCond.AddWarningf(contour_api_v1.ConditionTypeOrphanedError, "IncludeNotValid",
+						"HTTPProxy %s includes this HTTPProxy, but the include is not valid: %s", from, reason)
+					continue
+				}
+				validCond.AddWarningf(contour_api_v1.ConditionTypeOrphanedError, "ParentNotIncluded",
+					"HTTPProxy %s includes this HTTPProxy, but is neither a valid root HTTPProxy nor part of a delegation chain from one", from)
+			case includeNearMiss(target, orphan):
+				if _, ok := p.source.httpproxies[target]; ok {
+					continue
+				}
+				validCond.AddWarningf(contour_api_v1.ConditionTypeOrphanedError, "IncludeNotFound",
+					"HTTPProxy %s includes %s, which does not exist; did you mean this HTTPProxy?", from, target)
+			}
+		}
+	}
+}
+
+// includeNearMiss returns true if target names a different HTTPProxy
+// than orphan, but only differs in namespace or in the case of its name.
+func includeNearMiss(target, orphan types.NamespacedName) bool {
+	if target == orphan {
+		return false
+	}
+	if target.Name == orphan.Name {
+		return true
+	}
+	return target.Namespace == orphan.Namespace && strings.EqualFold(target.Name, orphan.Name)
+}
+
+// resolveRouteConflicts removes routes that match the same conditions
+// as a route defined by a different HTTPProxy in the include tree of
+// root. The route of the oldest HTTPProxy wins, and every HTTPProxy
+// whose route was dropped has its Conflicted condition set.
+func (p *HTTPProxyProcessor) resolveRouteConflicts(root *contour_api_v1.HTTPProxy, routes []*Route) []*Route {
+	winners := map[string]*contour_api_v1.HTTPProxy{}
+	for _, r := range routes {
+		owner, ok := p.routeOwners[r]
+		if !ok {
+			continue
+		}
+		key := conditionsToString(r)
+		if winner, ok := winners[key]; !ok || proxyOlder(owner, winner) {
+			winners[key] = owner
+		}
+	}
+
+	type conflict struct {
+		loser types.NamespacedName
+		key   string
+	}
+	reported := map[conflict]bool{}
+
+	var resolved []*Route
+	for _, r := range routes {
+		owner, ok := p.routeOwners[r]
+		key := conditionsToString(r)
+		winner := winners[key]
+		if !ok || winner == owner {
+			resolved = append(resolved, r)
+			continue
+		}
+
+		c := conflict{loser: k8s.NamespacedNameOf(owner), key: key}
+		if reported[c] {
+			continue
+		}
+		reported[c] = true
+
+		pa, commit := p.dag.StatusCache.ProxyAccessor(owner)
+		pa.ConditionFor(status.ConflictedCondition).AddErrorf(contour_api_v1.ConditionTypeRouteError, "RouteConflict",
+			"route %q for fqdn %q conflicts with a route defined by HTTPProxy %s, which takes precedence",
+			key, root.Spec.VirtualHost.Fqdn, k8s.NamespacedNameOf(winner))
+		commit()
+	}
+	return resolved
+}
+
+// proxyOlder returns true if a was created before b. HTTPProxies
+// created at the same time are ordered by namespace and name, so that
+// the result is always deterministic.
+func proxyOlder(a, b *contour_api_v1.HTTPProxy) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return k8s.NamespacedNameOf(a).String() < k8s.NamespacedNameOf(b).String()
+}
+
+// addDebugHeaders adds response headers to the route identifying the
+// HTTPProxy that defines it, its conditions, and for each of its
+// clusters, the upstream Service and the address of the upstream host
+// that served the request. Headers already set by the route's or the
+// clusters' response headers policies are left as they are.
+func addDebugHeaders(r *Route, proxy *contour_api_v1.HTTPProxy) {
+	// Envoy formats header values, so literal %'s must be escaped.
+	escape := func(value string) string {
+		return strings.ReplaceAll(value, "%", "%%")
+	}
+
+	r.ResponseHeadersPolicy = withHeaders(r.ResponseHeadersPolicy, map[string]string{
+		"X-Contour-Httpproxy": escape(k8s.NamespacedNameOf(proxy).String()),
+		"X-Contour-Route":     escape(conditionsToString(r)),
+	})
+
+	for _, c := range r.Clusters {
+		c.ResponseHeadersPolicy = withHeaders(c.ResponseHeadersPolicy, map[string]string{
+			"X-Contour-Service":       escape(fmt.Sprintf("%s/%s:%d", c.Upstream.Weighted.ServiceNamespace, c.Upstream.Weighted.ServiceName, c.Upstream.Weighted.ServicePort.Port)),
+			"X-Contour-Upstream-Host": "%UPSTREAM_REMOTE_ADDRESS%",
+		})
+	}
+}
+
+// withHeaders returns a copy of the headers policy that sets the
+// headers that the policy doesn't already set, add or remove.
+func withHeaders(hp *HeadersPolicy, headers map[string]string) *HeadersPolicy {
+	res := &HeadersPolicy{Set: map[string]string{}}
+	if hp != nil {
+		*res = *hp
+		res.Set = make(map[string]string, len(hp.Set)+len(headers))
+		for k, v := range hp.Set {
+			res.Set[k] = v
+		}
+	}
+
+	for k, v := range headers {
+		if _, ok := res.Set[k]; ok {
+			continue
+		}
+		if _, ok := res.Add[k]; ok {
+			continue
+		}
+		if stringsContainFold(res.Remove, k) {
+			continue
+		}
+		res.Set[k] = v
+	}
+
+	return res
+}
+
+func stringsContainFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
 // processHTTPProxyTCPProxy processes the spec.tcpproxy stanza in a HTTPProxy document
 // following the chain of spec.tcpproxy.include references. It returns true if processing
 // was successful, otherwise false if an error was encountered. The details of the error
@@ -741,14 +1344,30 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 	}
 
 	if len(tcpproxy.Services) > 0 {
+		if err := backupServicesValid(tcpproxy.Services); err != nil {
+			validCond.AddError(contour_api_v1.ConditionTypeTCPProxyError, "BackupServiceNotValid", err.Error())
+			return false
+		}
+
 		var proxy TCPProxy
+		var backups []backupService
 		for _, service := range httpproxy.Spec.TCPProxy.Services {
 			m := types.NamespacedName{Name: service.Name, Namespace: httpproxy.Namespace}
-			s, err := p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source, p.EnableExternalNameService)
-			if err != nil {
-				validCond.AddErrorf(contour_api_v1.ConditionTypeTCPProxyError, "ServiceUnresolvedReference",
-					"Spec.TCPProxy unresolved service reference: %s", err)
-				return false
+			var s *Service
+			var err error
+			if len(service.Endpoints) > 0 {
+				s, err = p.dag.EnsureStaticService(m, service.Port, service.Endpoints, p.EnableStaticEndpoints)
+				if err != nil {
+					validCond.AddError(contour_api_v1.ConditionTypeTCPProxyError, "StaticEndpointsNotValid", err.Error())
+					return false
+				}
+			} else {
+				s, err = p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source, p.EnableExternalNameService)
+				if err != nil {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeTCPProxyError, "ServiceUnresolvedReference",
+						"Spec.TCPProxy unresolved service reference: %s", err)
+					return false
+				}
 			}
 
 			// Determine the protocol to use to speak to this Cluster.
@@ -758,6 +1377,18 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 				return false
 			}
 
+			if service.Backup {
+				backups = append(backups, backupService{name: service.Name, service: s, protocol: protocol})
+				continue
+			}
+
+			dp, err := dnsPolicy(service.DNSPolicy)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "DNSPolicyNotValid",
+					"service %q: %s", service.Name, err)
+				return false
+			}
+
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
 				Upstream:             s,
 				Weight:               uint32(service.Weight),
@@ -765,8 +1396,13 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 				LoadBalancerPolicy:   lbPolicy,
 				TCPHealthCheckPolicy: tcpHealthCheckPolicy(tcpproxy.HealthCheckPolicy),
 				SNI:                  s.ExternalName,
+				DNSPolicy:            dp,
 			})
 		}
+		if err := setBackups(proxy.Clusters, backups); err != nil {
+			validCond.AddError(contour_api_v1.ConditionTypeTCPProxyError, "BackupServiceNotValid", err.Error())
+			return false
+		}
 		secure := p.dag.EnsureSecureVirtualHost(host)
 		secure.TCPProxy = &proxy
 
@@ -830,7 +1466,9 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 // invalid HTTPProxy objects are excluded from the slice and their status
 // updated accordingly.
 func (p *HTTPProxyProcessor) validHTTPProxies() []*contour_api_v1.HTTPProxy {
-	// ensure that a given fqdn is only referenced in a single HTTPProxy resource
+	// ensure that a given fqdn, whether it is the fqdn of the virtual
+	// host or one of its aliases, is only referenced in a single
+	// HTTPProxy resource
 	var valid []*contour_api_v1.HTTPProxy
 	fqdnHTTPProxies := make(map[string][]*contour_api_v1.HTTPProxy)
 	for _, proxy := range p.source.httpproxies {
@@ -838,46 +1476,86 @@ func (p *HTTPProxyProcessor) validHTTPProxies() []*contour_api_v1.HTTPProxy {
 			valid = append(valid, proxy)
 			continue
 		}
-		fqdn := strings.ToLower(proxy.Spec.VirtualHost.Fqdn)
-		fqdnHTTPProxies[fqdn] = append(fqdnHTTPProxies[fqdn], proxy)
+
+		seen := map[string]bool{}
+		for _, name := range append([]string{proxy.Spec.VirtualHost.Fqdn}, proxy.Spec.VirtualHost.Aliases...) {
+			fqdn := strings.ToLower(name)
+			if seen[fqdn] {
+				continue
+			}
+			seen[fqdn] = true
+			fqdnHTTPProxies[fqdn] = append(fqdnHTTPProxies[fqdn], proxy)
+		}
 	}
 
+	conflicted := map[*contour_api_v1.HTTPProxy]bool{}
 	for fqdn, proxies := range fqdnHTTPProxies {
-		switch len(proxies) {
-		case 1:
-			valid = append(valid, proxies[0])
-		default:
-			// multiple proxies use the same fqdn. mark them as invalid.
-			var conflicting []string
-			for _, proxy := range proxies {
-				conflicting = append(conflicting, proxy.Namespace+"/"+proxy.Name)
-			}
-			sort.Strings(conflicting) // sort for test stability
-			msg := fmt.Sprintf("fqdn %q is used in multiple HTTPProxies: %s", fqdn, strings.Join(conflicting, ", "))
-			for _, proxy := range proxies {
-				pa, commit := p.dag.StatusCache.ProxyAccessor(proxy)
-				pa.Vhost = fqdn
-				pa.ConditionFor(status.ValidCondition).AddError(contour_api_v1.ConditionTypeVirtualHostError,
-					"DuplicateVhost",
-					msg)
-				commit()
-			}
+		if len(proxies) == 1 {
+			continue
+		}
+
+		// multiple proxies use the same fqdn. mark them as invalid.
+		var conflicting []string
+		for _, proxy := range proxies {
+			conflicting = append(conflicting, proxy.Namespace+"/"+proxy.Name)
+		}
+		sort.Strings(conflicting) // sort for test stability
+		msg := fmt.Sprintf("fqdn %q is used in multiple HTTPProxies: %s", fqdn, strings.Join(conflicting, ", "))
+		for _, proxy := range proxies {
+			conflicted[proxy] = true
+
+			pa, commit := p.dag.StatusCache.ProxyAccessor(proxy)
+			pa.Vhost = strings.ToLower(proxy.Spec.VirtualHost.Fqdn)
+			pa.ConditionFor(status.ValidCondition).AddError(contour_api_v1.ConditionTypeVirtualHostError,
+				"DuplicateVhost",
+				msg)
+			commit()
+		}
+	}
+
+	for _, proxy := range p.source.httpproxies {
+		if proxy.Spec.VirtualHost != nil && !conflicted[proxy] {
+			valid = append(valid, proxy)
 		}
 	}
 	return valid
 }
 
-// rootAllowed returns true if the HTTPProxy lives in a permitted root namespace.
-func (p *HTTPProxyProcessor) rootAllowed(namespace string) bool {
-	if len(p.source.RootNamespaces) == 0 {
-		return true
+// virtualHostAliases returns the aliases of the virtual host, or an
+// error if they cannot be served alongside its fqdn.
+func (p *HTTPProxyProcessor) virtualHostAliases(vhost *contour_api_v1.VirtualHost) ([]string, error) {
+	if len(vhost.Aliases) == 0 {
+		return nil, nil
 	}
-	for _, ns := range p.source.RootNamespaces {
-		if ns == namespace {
-			return true
+
+	// Routes of a wildcard virtual host match the :authority
+	// header against the wildcard, which its aliases would fail.
+	if strings.HasPrefix(vhost.Fqdn, "*.") {
+		return nil, fmt.Errorf("aliases cannot be set when the fqdn %q is a wildcard", vhost.Fqdn)
+	}
+
+	seen := map[string]bool{strings.ToLower(vhost.Fqdn): true}
+	aliases := make([]string, 0, len(vhost.Aliases))
+	for _, alias := range vhost.Aliases {
+		name := strings.ToLower(alias)
+		switch {
+		case isBlank(name):
+			return nil, fmt.Errorf("alias must not be empty")
+		case strings.HasPrefix(name, "*."):
+			return nil, fmt.Errorf("alias %q cannot be a wildcard", alias)
+		case seen[name]:
+			return nil, fmt.Errorf("alias %q is duplicated", alias)
+		case p.dag.GetVirtualHost(name) != nil || p.dag.GetSecureVirtualHost(name) != nil:
+			// Envoy rejects route configurations that
+			// list the same domain more than once.
+			return nil, fmt.Errorf("alias %q conflicts with an existing virtual host", alias)
 		}
+
+		seen[name] = true
+		aliases = append(aliases, name)
 	}
-	return false
+
+	return aliases, nil
 }
 
 // expandPrefixMatches adds new Routes to account for the difference
@@ -971,6 +1649,331 @@ func expandPrefixMatches(routes []*Route) []*Route {
 	return expandedRoutes
 }
 
+// expandTrafficSplits adds a route per service for each route with a
+// traffic split policy and more than one cluster. The added routes only
+// match requests pinned to the service by the policy's header or cookie,
+// and send them to that service's clusters. When pinning by cookie, the
+// clusters of the weighted route are copied to set the cookie on responses.
+func expandTrafficSplits(routes []*Route) []*Route {
+	var expanded []*Route
+
+	for _, r := range routes {
+		expanded = append(expanded, r)
+
+		policy := r.TrafficSplitPolicy
+		if policy == nil || len(r.Clusters) < 2 {
+			continue
+		}
+
+		// Group the clusters by service name, keeping the
+		// order in which the services were specified.
+		var names []string
+		clusters := map[string][]*Cluster{}
+		for _, c := range r.Clusters {
+			name := c.Upstream.Weighted.ServiceName
+			if _, ok := clusters[name]; !ok {
+				names = append(names, name)
+			}
+			clusters[name] = append(clusters[name], c)
+		}
+
+		for _, name := range names {
+			if len(policy.HeaderName) > 0 {
+				// Shallow copy the Route, as expandPrefixMatches does.
+				pinned := *r
+				pinned.TrafficSplitPolicy = nil
+				pinned.Clusters = clusters[name]
+				pinned.HeaderMatchConditions = append(append([]HeaderMatchCondition{}, r.HeaderMatchConditions...), HeaderMatchCondition{
+					Name:      policy.HeaderName,
+					Value:     name,
+					MatchType: HeaderMatchTypeExact,
+				})
+				expanded = append(expanded, &pinned)
+			}
+
+			if len(policy.CookieName) > 0 {
+				pinned := *r
+				pinned.TrafficSplitPolicy = nil
+				pinned.Clusters = clusters[name]
+				pinned.HeaderMatchConditions = append(append([]HeaderMatchCondition{}, r.HeaderMatchConditions...), trafficSplitCookieMatch(policy.CookieName, name))
+
+				// A pinning header takes precedence over the cookie.
+				if len(policy.HeaderName) > 0 {
+					pinned.HeaderMatchConditions = append(pinned.HeaderMatchConditions, HeaderMatchCondition{
+						Name:      policy.HeaderName,
+						MatchType: HeaderMatchTypePresent,
+						Invert:    true,
+					})
+				}
+				expanded = append(expanded, &pinned)
+			}
+		}
+
+		if len(policy.CookieName) > 0 {
+			weighted := make([]*Cluster, 0, len(r.Clusters))
+			for _, c := range r.Clusters {
+				cookie := fmt.Sprintf("%s=%s; Path=/", policy.CookieName, c.Upstream.Weighted.ServiceName)
+
+				// Copy the Cluster so that the cookie is only
+				// set on responses to unpinned requests.
+				wc := *c
+				wc.ResponseHeadersPolicy = &HeadersPolicy{
+					Add: map[string]string{},
+				}
+				if c.ResponseHeadersPolicy != nil {
+					wc.ResponseHeadersPolicy.Set = c.ResponseHeadersPolicy.Set
+					wc.ResponseHeadersPolicy.Remove = c.ResponseHeadersPolicy.Remove
+					for k, v := range c.ResponseHeadersPolicy.Add {
+						wc.ResponseHeadersPolicy.Add[k] = v
+					}
+				}
+				wc.ResponseHeadersPolicy.Add["Set-Cookie"] = cookie
+				weighted = append(weighted, &wc)
+			}
+			r.Clusters = weighted
+		}
+	}
+
+	return expanded
+}
+
+// certificateExpiryWarning is how long before the serving certificate
+// of an HTTPProxy expires that a warning is added to its status.
+const certificateExpiryWarning = 14 * 24 * time.Hour
+
+// addCertificateExpiryWarning adds a warning to validCond if the serving
+// certificate of the Secret sec, named secretName in the HTTPProxy, has
+// expired or will expire within certificateExpiryWarning.
+func (p *HTTPProxyProcessor) addCertificateExpiryWarning(validCond *contour_api_v1.DetailedCondition, secretName string, sec *Secret) {
+	notAfter, err := sec.NotAfter()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+
+	switch {
+	case !now.Before(notAfter):
+		validCond.AddWarningf(contour_api_v1.ConditionTypeTLSError, "CertificateExpired",
+			"Spec.VirtualHost.TLS Secret %q certificate expired at %s", secretName, notAfter.UTC().Format(time.RFC3339))
+	case notAfter.Sub(now) < certificateExpiryWarning:
+		validCond.AddWarningf(contour_api_v1.ConditionTypeTLSError, "CertificateExpiring",
+			"Spec.VirtualHost.TLS Secret %q certificate expires at %s", secretName, notAfter.UTC().Format(time.RFC3339))
+	}
+}
+
+// trafficSplitCookieMatch returns a header match condition matching
+// requests carrying the cookie name with the given value.
+func trafficSplitCookieMatch(name, value string) HeaderMatchCondition {
+	return HeaderMatchCondition{
+		Name:      "Cookie",
+		MatchType: HeaderMatchTypeRegex,
+		Value:     `(.*;\s*)?` + regexp.QuoteMeta(name) + "=" + regexp.QuoteMeta(value) + `(;.*)?`,
+	}
+}
+
+// trafficShiftWeight advances the traffic shift of the route of proxy
+// identified by route, and returns the percentage of requests that
+// are currently sent to the service the shift is towards.
+func (p *HTTPProxyProcessor) trafficShiftWeight(proxy *contour_api_v1.HTTPProxy, route string, shift *TrafficShiftPolicy) uint32 {
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+
+	name := k8s.NamespacedNameOf(proxy)
+	key := trafficShiftKey{proxy: name, route: route, from: shift.From, to: shift.To}
+	halted := annotation.TrafficShiftHalted(proxy)
+
+	state, ok := p.trafficShifts[key]
+	switch {
+	case !ok:
+		state = &trafficShiftState{
+			steps:    shift.Steps,
+			interval: shift.Interval,
+			since:    now,
+			halted:   halted,
+		}
+		// Pick up where the shift was left, according to the
+		// HTTPProxy's status, so that restarting Contour does
+		// not start shifts over.
+		for _, st := range proxy.Status.TrafficShifts {
+			if st.Route != route || st.From != shift.From || st.To != shift.To {
+				continue
+			}
+			if st.Step < 0 || st.Step >= len(shift.Steps) {
+				continue
+			}
+			state.step = st.Step
+			if !st.Halted && !st.LastTransitionTime.IsZero() {
+				state.since = st.LastTransitionTime.Time
+			}
+		}
+		p.trafficShifts[key] = state
+	case !equality.Semantic.DeepEqual(state.steps, shift.Steps) || state.interval != shift.Interval:
+		// The schedule changed, so start it over.
+		*state = trafficShiftState{
+			steps:    shift.Steps,
+			interval: shift.Interval,
+			since:    now,
+			halted:   halted,
+		}
+	case state.halted && !halted:
+		// Resuming a halted shift restarts its current step.
+		state.since = now
+	}
+	state.halted = halted
+
+	last := len(state.steps) - 1
+	if !state.halted {
+		for state.step < last && !now.Before(state.since.Add(state.interval)) {
+			state.since = state.since.Add(state.interval)
+			state.step++
+		}
+		if state.step < last {
+			p.dag.rebuildBy(state.since.Add(state.interval))
+		}
+	}
+
+	// A route may be computed more than once if its HTTPProxy
+	// is included by several roots, but is only reported once.
+	if !state.seen {
+		state.seen = true
+		p.shiftStatuses[name] = append(p.shiftStatuses[name], contour_api_v1.TrafficShiftStatus{
+			Route:              route,
+			From:               shift.From,
+			To:                 shift.To,
+			Step:               state.step,
+			Weight:             state.steps[state.step],
+			Halted:             state.halted,
+			Complete:           state.step == last,
+			LastTransitionTime: metav1.NewTime(state.since),
+		})
+	}
+
+	return state.steps[state.step]
+}
+
+// computeTrafficWeights validates the TrafficWeights resources
+// and records the service weights set by the valid ones.
+func (p *HTTPProxyProcessor) computeTrafficWeights() {
+	byProxy := map[types.NamespacedName][]*contour_api_v1alpha1.TrafficWeights{}
+	for _, tw := range p.source.trafficweights {
+		name := types.NamespacedName{Namespace: tw.Namespace, Name: tw.Spec.HTTPProxy}
+		byProxy[name] = append(byProxy[name], tw)
+	}
+
+	for name, tws := range byProxy {
+		for _, tw := range tws {
+			entry, commit := status.TrafficWeightsAccessor(&p.dag.StatusCache, tw)
+			validCond := entry.ConditionFor(status.ValidCondition)
+
+			weights, err := p.trafficWeights(name, tws, tw)
+			if err != nil {
+				validCond.AddError(contour_api_v1.ConditionTypeSpecError, "TrafficWeightsNotValid", err.Error())
+			} else {
+				for route, w := range weights {
+					p.routeWeights[routeWeightsKey{proxy: name, route: route}] = w
+				}
+				validCond.Status = contour_api_v1.ConditionTrue
+				validCond.Reason = "Valid"
+				validCond.Message = "Valid TrafficWeights"
+			}
+
+			commit()
+		}
+	}
+}
+
+// trafficWeights returns the service weights that tw sets on the
+// routes of the HTTPProxy called name, keyed by route index. tws
+// are all of the TrafficWeights resources for the HTTPProxy.
+func (p *HTTPProxyProcessor) trafficWeights(name types.NamespacedName, tws []*contour_api_v1alpha1.TrafficWeights, tw *contour_api_v1alpha1.TrafficWeights) (map[int]map[string]uint32, error) {
+	if len(tws) > 1 {
+		var names []string
+		for _, other := range tws {
+			names = append(names, other.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("TrafficWeights %s all set the weights of HTTPProxy %q", strings.Join(names, ", "), name.Name)
+	}
+
+	proxy, ok := p.source.httpproxies[name]
+	if !ok {
+		return nil, fmt.Errorf("HTTPProxy %q not found", name.Name)
+	}
+
+	weights := map[int]map[string]uint32{}
+	for i, rw := range tw.Spec.Routes {
+		route := -1
+		for j := range proxy.Spec.Routes {
+			if equality.Semantic.DeepEqual(proxy.Spec.Routes[j].Conditions, rw.Conditions) {
+				route = j
+				break
+			}
+		}
+		if route < 0 {
+			return nil, fmt.Errorf("routes[%d]: no route of HTTPProxy %q has the same conditions", i, name.Name)
+		}
+		if _, ok := weights[route]; ok {
+			return nil, fmt.Errorf("routes[%d]: the weights of the route are already set", i)
+		}
+		if proxy.Spec.Routes[route].TrafficShiftPolicy != nil {
+			return nil, fmt.Errorf("routes[%d]: the route has a traffic shift policy", i)
+		}
+
+		services := map[string]bool{}
+		for _, service := range proxy.Spec.Routes[route].Services {
+			if !service.Mirror && !service.Backup {
+				services[service.Name] = true
+			}
+		}
+
+		w := map[string]uint32{}
+		for _, sw := range rw.Services {
+			if !services[sw.Name] {
+				return nil, fmt.Errorf("routes[%d]: service %q is not a service of the route", i, sw.Name)
+			}
+			if _, ok := w[sw.Name]; ok {
+				return nil, fmt.Errorf("routes[%d]: the weight of service %q is already set", i, sw.Name)
+			}
+			w[sw.Name] = sw.Weight
+		}
+		for service := range services {
+			if _, ok := w[service]; !ok {
+				return nil, fmt.Errorf("routes[%d]: no weight is set for service %q", i, service)
+			}
+		}
+
+		weights[route] = w
+	}
+
+	return weights, nil
+}
+
+// finishTrafficShifts reports the traffic shifts computed during
+// the run in the status of their HTTPProxies, and forgets the
+// shifts that were not.
+func (p *HTTPProxyProcessor) finishTrafficShifts() {
+	for key, state := range p.trafficShifts {
+		if !state.seen {
+			delete(p.trafficShifts, key)
+			continue
+		}
+		state.seen = false
+	}
+
+	for name, shifts := range p.shiftStatuses {
+		sort.SliceStable(shifts, func(i, j int) bool {
+			return shifts[i].Route < shifts[j].Route
+		})
+		p.dag.StatusCache.SetProxyTrafficShifts(name, shifts)
+	}
+}
+
 func getProtocol(service contour_api_v1.Service, s *Service) (string, error) {
 	// Determine the protocol to use to speak to this Cluster.
 	var protocol string
@@ -1060,11 +2063,348 @@ func isBlank(s string) bool {
 	return len(strings.TrimSpace(s)) == 0
 }
 
+// virtualHostRateLimitPolicy applies the default local rate limit
+// policy to the rate limit policy of the root proxy's virtual host,
+// unless the proxy has opted out of it.
+func (p *HTTPProxyProcessor) virtualHostRateLimitPolicy(proxy *contour_api_v1.HTTPProxy, rlp *RateLimitPolicy) *RateLimitPolicy {
+	if proxy.Spec.DisableDefaultLocalRateLimitPolicy {
+		return rlp
+	}
+	return defaultLocalRateLimitPolicy(rlp, p.DefaultLocalRateLimitPolicy)
+}
+
+// rootRateLimitPolicy returns the rate limit policy of the root
+// proxy's virtual host, or the default of the ContourPolicy of
+// its namespace if it does not set one.
+func (p *HTTPProxyProcessor) rootRateLimitPolicy(proxy *contour_api_v1.HTTPProxy) *contour_api_v1.RateLimitPolicy {
+	if rlp := proxy.Spec.VirtualHost.RateLimitPolicy; rlp != nil {
+		return rlp
+	}
+	if np := p.namespacePolicies[proxy.Namespace]; np != nil && np.defaults != nil {
+		return np.defaults.RateLimitPolicy
+	}
+	return nil
+}
+
+// computeContourPolicies validates the ContourPolicy resources
+// and records the policies set by the valid ones.
+func (p *HTTPProxyProcessor) computeContourPolicies() {
+	byNamespace := map[string][]*contour_api_v1alpha1.ContourPolicy{}
+	for _, cp := range p.source.contourpolicies {
+		byNamespace[cp.Namespace] = append(byNamespace[cp.Namespace], cp)
+	}
+
+	for namespace, cps := range byNamespace {
+		for _, cp := range cps {
+			entry, commit := status.ContourPolicyAccessor(&p.dag.StatusCache, cp)
+			validCond := entry.ConditionFor(status.ValidCondition)
+
+			np, err := contourPolicy(cps, cp)
+			if err != nil {
+				validCond.AddError(contour_api_v1.ConditionTypeSpecError, "ContourPolicyNotValid", err.Error())
+			} else {
+				p.namespacePolicies[namespace] = np
+				validCond.Status = contour_api_v1.ConditionTrue
+				validCond.Reason = "Valid"
+				validCond.Message = "Valid ContourPolicy"
+			}
+
+			commit()
+		}
+	}
+}
+
+// contourPolicy returns the policy that cp sets on the HTTPProxies
+// in its namespace. cps are all of the ContourPolicy resources in
+// the namespace.
+func contourPolicy(cps []*contour_api_v1alpha1.ContourPolicy, cp *contour_api_v1alpha1.ContourPolicy) (*namespacePolicy, error) {
+	if len(cps) > 1 {
+		var names []string
+		for _, other := range cps {
+			names = append(names, other.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("ContourPolicy %s are all in namespace %q, which can have at most one", strings.Join(names, ", "), cp.Namespace)
+	}
+
+	np := &namespacePolicy{
+		name:              cp.Name,
+		defaults:          cp.Spec.Defaults,
+		allowedStrategies: map[string]bool{},
+		protectedHeaders:  map[string]bool{},
+	}
+
+	if limits := cp.Spec.Limits; limits != nil {
+		var err error
+		if limits.MaxResponseTimeout != "" {
+			if np.maxResponseTimeout, err = time.ParseDuration(limits.MaxResponseTimeout); err != nil {
+				return nil, fmt.Errorf("limits.maxResponseTimeout failed to parse: %s", err)
+			}
+		}
+		if limits.MaxIdleTimeout != "" {
+			if np.maxIdleTimeout, err = time.ParseDuration(limits.MaxIdleTimeout); err != nil {
+				return nil, fmt.Errorf("limits.maxIdleTimeout failed to parse: %s", err)
+			}
+		}
+		for _, strategy := range limits.AllowedLoadBalancerStrategies {
+			np.allowedStrategies[strategy] = true
+		}
+		for _, name := range limits.ProtectedHeaders {
+			np.protectedHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	// The defaults must be valid, and within the limits,
+	// as they apply to every HTTPProxy in the namespace.
+	if d := np.defaults; d != nil {
+		tp, err := timeoutPolicy(d.TimeoutPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("defaults.timeoutPolicy failed to parse: %s", err)
+		}
+		if err := timeoutPolicyWithinLimits(tp, np.maxResponseTimeout, np.maxIdleTimeout); err != nil {
+			return nil, fmt.Errorf("defaults.timeoutPolicy %s", err)
+		}
+		if err := np.loadBalancerPolicyAllowed(d.LoadBalancerPolicy); err != nil {
+			return nil, fmt.Errorf("defaults.loadBalancerPolicy %s", err)
+		}
+		if _, err := virtualHostRateLimitPolicy(d.RateLimitPolicy); err != nil {
+			return nil, fmt.Errorf("defaults.rateLimitPolicy is invalid: %s", err)
+		}
+		if _, err := headersPolicyRoute(d.RequestHeadersPolicy, true /* allow Host */, nil); err != nil {
+			return nil, fmt.Errorf("defaults.requestHeadersPolicy: %s", err)
+		}
+		if _, err := headersPolicyRoute(d.ResponseHeadersPolicy, false /* disallow Host */, nil); err != nil {
+			return nil, fmt.Errorf("defaults.responseHeadersPolicy: %s", err)
+		}
+	}
+
+	return np, nil
+}
+
+// loadBalancerPolicyAllowed returns an error if the policy
+// sets a load balancer strategy that is not allowed.
+func (np *namespacePolicy) loadBalancerPolicyAllowed(lbp *contour_api_v1.LoadBalancerPolicy) error {
+	if lbp == nil || lbp.Strategy == "" || len(np.allowedStrategies) == 0 {
+		return nil
+	}
+	if !np.allowedStrategies[lbp.Strategy] {
+		return fmt.Errorf("strategy %q is not allowed by ContourPolicy %q", lbp.Strategy, np.name)
+	}
+	return nil
+}
+
+// headersPoliciesAllowed returns an error if the headers
+// policies of the route or of its services set or remove
+// a protected header.
+func (np *namespacePolicy) headersPoliciesAllowed(route contour_api_v1.Route) error {
+	if err := np.headersPolicyAllowed("route.requestHeadersPolicy", route.RequestHeadersPolicy); err != nil {
+		return err
+	}
+	if err := np.headersPolicyAllowed("route.responseHeadersPolicy", route.ResponseHeadersPolicy); err != nil {
+		return err
+	}
+	for _, service := range route.Services {
+		field := fmt.Sprintf("service %q", service.Name)
+		if err := np.headersPolicyAllowed(field+" requestHeadersPolicy", service.RequestHeadersPolicy); err != nil {
+			return err
+		}
+		if err := np.headersPolicyAllowed(field+" responseHeadersPolicy", service.ResponseHeadersPolicy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (np *namespacePolicy) headersPolicyAllowed(field string, hp *contour_api_v1.HeadersPolicy) error {
+	if hp == nil {
+		return nil
+	}
+	for _, h := range hp.Set {
+		if key := http.CanonicalHeaderKey(h.Name); np.protectedHeaders[key] {
+			return fmt.Errorf("%s cannot set header %q, which ContourPolicy %q protects", field, key, np.name)
+		}
+	}
+	for _, name := range hp.Remove {
+		if key := http.CanonicalHeaderKey(name); np.protectedHeaders[key] {
+			return fmt.Errorf("%s cannot remove header %q, which ContourPolicy %q protects", field, key, np.name)
+		}
+	}
+	return nil
+}
+
+// withDefaults returns the route with the defaults
+// applied where it does not set its own policies.
+func (np *namespacePolicy) withDefaults(route contour_api_v1.Route) contour_api_v1.Route {
+	d := np.defaults
+	if d == nil {
+		return route
+	}
+
+	if d.TimeoutPolicy != nil {
+		tp := contour_api_v1.TimeoutPolicy{}
+		if route.TimeoutPolicy != nil {
+			tp = *route.TimeoutPolicy
+		}
+		if tp.Response == "" {
+			tp.Response = d.TimeoutPolicy.Response
+		}
+		if tp.Idle == "" {
+			tp.Idle = d.TimeoutPolicy.Idle
+		}
+		route.TimeoutPolicy = &tp
+	}
+
+	if route.LoadBalancerPolicy == nil {
+		route.LoadBalancerPolicy = d.LoadBalancerPolicy
+	}
+
+	route.RequestHeadersPolicy = withDefaultHeaders(route.RequestHeadersPolicy, d.RequestHeadersPolicy)
+	route.ResponseHeadersPolicy = withDefaultHeaders(route.ResponseHeadersPolicy, d.ResponseHeadersPolicy)
+
+	return route
+}
+
+// withDefaultHeaders adds the headers that defaults sets and
+// removes to hp, except for those that hp sets or removes itself.
+func withDefaultHeaders(hp, defaults *contour_api_v1.HeadersPolicy) *contour_api_v1.HeadersPolicy {
+	if defaults == nil {
+		return hp
+	}
+
+	res := &contour_api_v1.HeadersPolicy{}
+	if hp != nil {
+		res.Set = append(res.Set, hp.Set...)
+		res.Remove = append(res.Remove, hp.Remove...)
+	}
+
+	names := map[string]bool{}
+	for _, h := range res.Set {
+		names[http.CanonicalHeaderKey(h.Name)] = true
+	}
+	for _, name := range res.Remove {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+
+	for _, h := range defaults.Set {
+		if !names[http.CanonicalHeaderKey(h.Name)] {
+			res.Set = append(res.Set, h)
+		}
+	}
+	for _, name := range defaults.Remove {
+		if !names[http.CanonicalHeaderKey(name)] {
+			res.Remove = append(res.Remove, name)
+		}
+	}
+
+	return res
+}
+
+// minLimit returns the smaller of two limits,
+// where zero means no limit.
+func minLimit(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
 // routeEnforceTLS determines if the route should redirect the user to a secure TLS listener
 func routeEnforceTLS(enforceTLS, permitInsecure bool) bool {
 	return enforceTLS && !permitInsecure
 }
 
+// insecurePrefixes returns the path prefixes of the root proxy's virtual
+// host that are served over HTTP rather than redirected to HTTPS: those
+// of the Contour configuration, and those of the proxy unless the Contour
+// configuration disables permitInsecure.
+func (p *HTTPProxyProcessor) insecurePrefixes(proxy *contour_api_v1.HTTPProxy) []string {
+	tls := proxy.Spec.VirtualHost.TLS
+	if tls == nil || len(tls.PermitInsecurePrefixes) == 0 || p.DisablePermitInsecure {
+		return p.PermitInsecurePrefixes
+	}
+
+	prefixes := make([]string, 0, len(p.PermitInsecurePrefixes)+len(tls.PermitInsecurePrefixes))
+	prefixes = append(prefixes, p.PermitInsecurePrefixes...)
+	return append(prefixes, tls.PermitInsecurePrefixes...)
+}
+
+// permitInsecurePrefixes returns the routes to add to an insecure virtual
+// host so that requests for the given prefixes are served over HTTP rather
+// than redirected to HTTPS. Routes under one of the prefixes are not
+// redirected, and for each route that would redirect requests for a prefix,
+// a route serving that prefix over HTTP is added.
+func permitInsecurePrefixes(routes []*Route, prefixes []string) []*Route {
+	if len(prefixes) == 0 {
+		return routes
+	}
+
+	type derived struct {
+		route *Route
+		from  string
+	}
+
+	var added []derived
+	var result []*Route
+	for _, r := range routes {
+		match, ok := r.PathMatchCondition.(*PrefixMatchCondition)
+		if !ok || !r.HTTPSUpgrade {
+			result = append(result, r)
+			continue
+		}
+
+		exempt := false
+		for _, prefix := range prefixes {
+			switch {
+			case strings.HasPrefix(match.Prefix, prefix):
+				exempt = true
+			case strings.HasPrefix(prefix, match.Prefix):
+				insecure := *r
+				insecure.HTTPSUpgrade = false
+				insecure.HTTPSRedirectPolicy = nil
+				insecure.PathMatchCondition = &PrefixMatchCondition{
+					Prefix:          prefix,
+					PrefixMatchType: match.PrefixMatchType,
+				}
+				added = append(added, derived{route: &insecure, from: match.Prefix})
+			}
+		}
+
+		if exempt {
+			insecure := *r
+			insecure.HTTPSUpgrade = false
+			insecure.HTTPSRedirectPolicy = nil
+			r = &insecure
+		}
+		result = append(result, r)
+	}
+
+	// Routes with the same conditions replace each other when added
+	// to a virtual host, so order the added routes by the length of
+	// the prefix they were derived from, so that the most specific
+	// route wins, and ahead of the existing routes, which always win.
+	sort.SliceStable(added, func(i, j int) bool {
+		return len(added[i].from) < len(added[j].from)
+	})
+
+	insecureRoutes := make([]*Route, 0, len(added)+len(result))
+	for _, d := range added {
+		insecureRoutes = append(insecureRoutes, d.route)
+	}
+
+	return append(insecureRoutes, result...)
+}
+
+func httpsRedirectPolicy(in *contour_api_v1.HTTPSRedirectPolicy) *HTTPSRedirectPolicy {
+	if in == nil {
+		return nil
+	}
+
+	return &HTTPSRedirectPolicy{
+		StatusCode: in.StatusCode,
+		PortNumber: uint32(in.Port),
+	}
+}
+
 func directResponse(statusCode uint32) *DirectResponse {
 	return &DirectResponse{
 		StatusCode: statusCode,
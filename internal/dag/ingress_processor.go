@@ -17,6 +17,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -35,6 +36,9 @@ type IngressProcessor struct {
 	dag    *DAG
 	source *KubernetesCache
 
+	// ingresses are the Ingresses that are programmed.
+	ingresses []*networking_v1.Ingress
+
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *types.NamespacedName
@@ -49,6 +53,14 @@ type IngressProcessor struct {
 
 	// Response headers that will be set on all routes (optional).
 	ResponseHeadersPolicy *HeadersPolicy
+
+	// MaxResponseTimeout is the largest response timeout that
+	// an Ingress may set by annotation. Zero means no limit.
+	MaxResponseTimeout time.Duration
+
+	// DefaultLocalRateLimitPolicy is the local rate limit
+	// policy of the virtual hosts of Ingresses (optional).
+	DefaultLocalRateLimitPolicy *LocalRateLimitPolicy
 }
 
 // Run translates Ingresses into DAG objects and
@@ -56,11 +68,13 @@ type IngressProcessor struct {
 func (p *IngressProcessor) Run(dag *DAG, source *KubernetesCache) {
 	p.dag = dag
 	p.source = source
+	p.ingresses = p.allowedIngresses()
 
 	// reset the processor when we're done
 	defer func() {
 		p.dag = nil
 		p.source = nil
+		p.ingresses = nil
 	}()
 
 	// setup secure vhosts if there is a matching secret
@@ -70,17 +84,31 @@ func (p *IngressProcessor) Run(dag *DAG, source *KubernetesCache) {
 	p.computeIngresses()
 }
 
+// allowedIngresses returns the Ingresses that are programmed. In
+// deny-by-default mode, Ingresses outside the root namespaces are
+// ignored.
+func (p *IngressProcessor) allowedIngresses() []*networking_v1.Ingress {
+	var ingresses []*networking_v1.Ingress
+	for _, ing := range p.source.ingresses {
+		if p.source.DenyByDefault && !p.source.rootAllowed(ing.Namespace) {
+			p.dag.ignore(ing, "Ingress is not in a root namespace")
+			continue
+		}
+		ingresses = append(ingresses, ing)
+	}
+	return ingresses
+}
+
 // computeSecureVirtualhosts populates tls parameters of
 // secure virtual hosts.
 func (p *IngressProcessor) computeSecureVirtualhosts() {
-	for _, ing := range p.source.ingresses {
+	for _, ing := range p.ingresses {
 		for _, tls := range ing.Spec.TLS {
 			secretName := k8s.NamespacedNameFrom(tls.SecretName, k8s.DefaultNamespace(ing.GetNamespace()))
 			sec, err := p.source.LookupSecret(secretName, validSecret)
 			if err != nil {
 				p.WithError(err).
-					WithField("name", ing.GetName()).
-					WithField("namespace", ing.GetNamespace()).
+					WithFields(k8s.LogFields(ing)).
 					WithField("secret", secretName).
 					Error("unresolved secret reference")
 				continue
@@ -88,8 +116,7 @@ func (p *IngressProcessor) computeSecureVirtualhosts() {
 
 			if !p.source.DelegationPermitted(secretName, ing.GetNamespace()) {
 				p.WithError(err).
-					WithField("name", ing.GetName()).
-					WithField("namespace", ing.GetNamespace()).
+					WithFields(k8s.LogFields(ing)).
 					WithField("secret", secretName).
 					Error("certificate delegation not permitted")
 				continue
@@ -101,6 +128,7 @@ func (p *IngressProcessor) computeSecureVirtualhosts() {
 			for _, host := range tls.Hosts {
 				svhost := p.dag.EnsureSecureVirtualHost(host)
 				svhost.Secret = sec
+				svhost.RateLimitPolicy = defaultLocalRateLimitPolicy(svhost.RateLimitPolicy, p.DefaultLocalRateLimitPolicy)
 				// default to a minimum TLS version of 1.2 if it's not specified
 				svhost.MinTLSVersion = annotation.MinTLSVersion(annotation.ContourAnnotation(ing, "tls-minimum-protocol-version"), "1.2")
 			}
@@ -110,7 +138,7 @@ func (p *IngressProcessor) computeSecureVirtualhosts() {
 
 func (p *IngressProcessor) computeIngresses() {
 	// deconstruct each ingress into routes and virtualhost entries
-	for _, ing := range p.source.ingresses {
+	for _, ing := range p.ingresses {
 
 		// rewrite the default ingress to a stock ingress rule.
 		rules := rulesFromSpec(ing.Spec)
@@ -134,8 +162,7 @@ func (p *IngressProcessor) computeIngressRule(ing *networking_v1.Ingress, rule n
 		clientCertSecret, err = p.source.LookupSecret(*p.ClientCertificate, validSecret)
 		if err != nil {
 			p.WithError(err).
-				WithField("name", ing.GetName()).
-				WithField("namespace", ing.GetNamespace()).
+				WithFields(k8s.LogFields(ing)).
 				WithField("secret", p.ClientCertificate).
 				Error("tls.envoy-client-certificate contains unresolved secret reference")
 			return
@@ -159,8 +186,7 @@ func (p *IngressProcessor) computeIngressRule(ing *networking_v1.Ingress, rule n
 		s, err := p.dag.EnsureService(m, port, p.source, p.EnableExternalNameService)
 		if err != nil {
 			p.WithError(err).
-				WithField("name", ing.GetName()).
-				WithField("namespace", ing.GetNamespace()).
+				WithFields(k8s.LogFields(ing)).
 				WithField("service", be.Service.Name).
 				Error("unresolved service reference")
 			continue
@@ -169,16 +195,18 @@ func (p *IngressProcessor) computeIngressRule(ing *networking_v1.Ingress, rule n
 		r, err := p.route(ing, rule.Host, path, pathType, s, clientCertSecret, be.Service.Name, be.Service.Port.Number, p.FieldLogger)
 		if err != nil {
 			p.WithError(err).
-				WithField("name", ing.GetName()).
-				WithField("namespace", ing.GetNamespace()).
+				WithFields(k8s.LogFields(ing)).
 				WithField("regex", path).
 				Errorf("path regex is not valid")
 			return
 		}
 
+		p.dag.setOrigin(r, ing)
+
 		// should we create port 80 routes for this ingress
 		if annotation.TLSRequired(ing) || annotation.HTTPAllowed(ing) {
 			vhost := p.dag.EnsureVirtualHost(host)
+			vhost.RateLimitPolicy = defaultLocalRateLimitPolicy(vhost.RateLimitPolicy, p.DefaultLocalRateLimitPolicy)
 			vhost.addRoute(r)
 		}
 
@@ -221,7 +249,7 @@ func (p *IngressProcessor) route(ingress *networking_v1.Ingress, host string, pa
 	r := &Route{
 		HTTPSUpgrade:  annotation.TLSRequired(ingress),
 		Websocket:     annotation.WebsocketRoutes(ingress)[path],
-		TimeoutPolicy: ingressTimeoutPolicy(ingress, log),
+		TimeoutPolicy: ingressTimeoutPolicy(ingress, p.MaxResponseTimeout, log),
 		RetryPolicy:   ingressRetryPolicy(ingress, log),
 		Clusters: []*Cluster{{
 			Upstream:              service,
@@ -13,17 +13,22 @@
 
 package dag
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+)
 
 // nolint:revive
 const (
 	HTTP_LISTENER_NAME  = "ingress_http"
 	HTTPS_LISTENER_NAME = "ingress_https"
+	UDP_LISTENER_NAME   = "ingress_udp"
 )
 
 // ListenerProcessor adds an HTTP and an HTTPS listener to
 // the DAG if there are virtual hosts and secure virtual
-// hosts already defined as roots in the DAG.
+// hosts already defined as roots in the DAG, and a UDP
+// listener for each of the UDP proxies of the DAG.
 type ListenerProcessor struct{}
 
 // Run adds HTTP and HTTPS listeners to the DAG if there are
@@ -32,6 +37,7 @@ type ListenerProcessor struct{}
 func (p *ListenerProcessor) Run(dag *DAG, _ *KubernetesCache) {
 	p.buildHTTPListener(dag)
 	p.buildHTTPSListener(dag)
+	p.buildUDPListeners(dag)
 }
 
 // buildHTTPListener builds a *dag.Listener for the vhosts bound to port 80.
@@ -87,3 +93,21 @@ func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
 
 	dag.Listeners = append(dag.Listeners, https)
 }
+
+// buildUDPListeners builds a *dag.Listener for each UDP proxy, bound
+// to the port of the proxy. The listeners will be sorted by port.
+func (p *ListenerProcessor) buildUDPListeners(dag *DAG) {
+	ports := make([]int, 0, len(dag.UDPProxies))
+	for port := range dag.UDPProxies {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	for _, port := range ports {
+		dag.Listeners = append(dag.Listeners, &Listener{
+			Name:     fmt.Sprintf("%s_%d", UDP_LISTENER_NAME, port),
+			Port:     port,
+			UDPProxy: dag.UDPProxies[port],
+		})
+	}
+}
@@ -16,8 +16,10 @@ package dag
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -96,12 +98,99 @@ func retryPolicy(rp *contour_api_v1.RetryPolicy) *RetryPolicy {
 		numRetries = 1
 	}
 
-	return &RetryPolicy{
-		RetryOn:              retryOn(rp.RetryOn),
-		RetriableStatusCodes: rp.RetriableStatusCodes,
-		NumRetries:           uint32(numRetries),
-		PerTryTimeout:        perTryTimeout,
+	policy := &RetryPolicy{
+		RetryOn:                  retryOn(rp.RetryOn),
+		RetriableStatusCodes:     rp.RetriableStatusCodes,
+		NumRetries:               uint32(numRetries),
+		PerTryTimeout:            perTryTimeout,
+		HostSelectionMaxAttempts: rp.HostSelectionMaxAttempts,
 	}
+
+	for _, predicate := range rp.RetryHostPredicates {
+		switch predicate {
+		case "previous-hosts":
+			policy.AvoidPreviousHosts = true
+		case "canary-hosts":
+			policy.AvoidCanaryHosts = true
+		}
+	}
+
+	if rp.RetryPriority != nil {
+		policy.PreviousPrioritiesUpdateFrequency = rp.RetryPriority.PreviousPrioritiesUpdateFrequency
+	}
+
+	return policy
+}
+
+// grpcRetryOn are the conditions gRPC requests are retried on
+// when the route's retry policy doesn't specify any.
+const grpcRetryOn = "cancelled,connect-failure,refused-stream,resource-exhausted,unavailable"
+
+func grpcPolicy(gp *contour_api_v1.GRPCPolicy) (*GRPCPolicy, error) {
+	if gp == nil {
+		return nil, nil
+	}
+
+	maxTimeout, err := timeout.Parse(gp.MaxTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse max timeout: %w", err)
+	}
+
+	var offset time.Duration
+	if gp.TimeoutOffset != "" {
+		offset, err = time.ParseDuration(gp.TimeoutOffset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timeout offset: %w", err)
+		}
+		if offset < 0 {
+			return nil, fmt.Errorf("timeout offset %q must not be negative", gp.TimeoutOffset)
+		}
+	}
+
+	// Both the default and a disabled max timeout
+	// leave the deadline unlimited.
+	return &GRPCPolicy{
+		MaxTimeout:    maxTimeout.Duration(),
+		TimeoutOffset: offset,
+	}, nil
+}
+
+func hedgePolicy(hp *contour_api_v1.HedgePolicy, rp *RetryPolicy) (*HedgePolicy, error) {
+	if hp == nil {
+		return nil, nil
+	}
+
+	// Hedged requests are retries that don't cancel the
+	// outstanding request, so there must be retries and
+	// a per-try timeout for a request to be hedged.
+	if hp.HedgeOnPerTryTimeout {
+		if rp == nil || rp.NumRetries == 0 {
+			return nil, errors.New("hedgeOnPerTryTimeout requires a retry policy with at least one retry")
+		}
+		if rp.PerTryTimeout.UseDefault() || rp.PerTryTimeout.IsDisabled() {
+			return nil, errors.New("hedgeOnPerTryTimeout requires a retry policy with a per-try timeout")
+		}
+	}
+
+	return &HedgePolicy{
+		HedgeOnPerTryTimeout: hp.HedgeOnPerTryTimeout,
+	}, nil
+}
+
+// virtualClusterNameRegex matches the virtual cluster names that
+// don't add segments to the names of Envoy statistics.
+var virtualClusterNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func statsPolicy(sp *contour_api_v1.StatsPolicy) (string, error) {
+	if sp == nil {
+		return "", nil
+	}
+
+	if !virtualClusterNameRegex.MatchString(sp.VirtualClusterName) {
+		return "", fmt.Errorf("invalid virtual cluster name %q: must consist of alphanumeric characters, '-' or '_'", sp.VirtualClusterName)
+	}
+
+	return sp.VirtualClusterName, nil
 }
 
 func headersPolicyService(defaultPolicy *HeadersPolicy, policy *contour_api_v1.HeadersPolicy, dynamicHeaders map[string]string) (*HeadersPolicy, error) {
@@ -396,7 +485,7 @@ func ingressRetryPolicy(ingress *networking_v1.Ingress, log logrus.FieldLogger)
 	return rp
 }
 
-func ingressTimeoutPolicy(ingress *networking_v1.Ingress, log logrus.FieldLogger) TimeoutPolicy {
+func ingressTimeoutPolicy(ingress *networking_v1.Ingress, maxResponse time.Duration, log logrus.FieldLogger) TimeoutPolicy {
 	response := annotation.ContourAnnotation(ingress, "response-timeout")
 	if len(response) == 0 {
 		// Note: due to a misunderstanding the name of the annotation is
@@ -419,10 +508,40 @@ func ingressTimeoutPolicy(ingress *networking_v1.Ingress, log logrus.FieldLogger
 		log.WithError(err).Error("Error parsing response-timeout annotation, using the default value")
 		return TimeoutPolicy{}
 	}
+	if err := timeoutPolicyWithinLimits(tp, maxResponse, 0); err != nil {
+		log.WithError(err).Error("Ignoring response-timeout annotation, using the default value")
+		return TimeoutPolicy{}
+	}
 
 	return tp
 }
 
+// timeoutPolicyWithinLimits returns an error if the response or idle
+// timeout of tp is disabled or exceeds maxResponse or maxIdle. Timeouts
+// left at their default, and limits of zero, are not checked.
+func timeoutPolicyWithinLimits(tp TimeoutPolicy, maxResponse, maxIdle time.Duration) error {
+	if err := timeoutWithinLimit(tp.ResponseTimeout, maxResponse); err != nil {
+		return fmt.Errorf("response timeout %w", err)
+	}
+	if err := timeoutWithinLimit(tp.IdleTimeout, maxIdle); err != nil {
+		return fmt.Errorf("idle timeout %w", err)
+	}
+	return nil
+}
+
+func timeoutWithinLimit(s timeout.Setting, max time.Duration) error {
+	switch {
+	case max == 0 || s.UseDefault():
+		return nil
+	case s.IsDisabled():
+		return fmt.Errorf("cannot be disabled, the maximum is %s", max)
+	case s.Duration() > max:
+		return fmt.Errorf("%s exceeds the maximum of %s", s.Duration(), max)
+	default:
+		return nil
+	}
+}
+
 func timeoutPolicy(tp *contour_api_v1.TimeoutPolicy) (TimeoutPolicy, error) {
 	if tp == nil {
 		return TimeoutPolicy{
@@ -447,18 +566,50 @@ func timeoutPolicy(tp *contour_api_v1.TimeoutPolicy) (TimeoutPolicy, error) {
 	}, nil
 }
 
-func httpHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) *HTTPHealthCheckPolicy {
+func httpHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) (*HTTPHealthCheckPolicy, error) {
 	if hc == nil {
-		return nil
+		return nil, nil
+	}
+
+	var expectedStatuses []HTTPStatusRange
+	for _, r := range hc.ExpectedStatuses {
+		if r.Start < 100 || r.End > 600 || r.Start >= r.End {
+			return nil, fmt.Errorf("invalid expected status range [%d, %d)", r.Start, r.End)
+		}
+		expectedStatuses = append(expectedStatuses, HTTPStatusRange{Start: r.Start, End: r.End})
 	}
+
+	var requestHeaders map[string]string
+	for _, h := range hc.RequestHeaders {
+		key := http.CanonicalHeaderKey(h.Name)
+		if _, ok := requestHeaders[key]; ok {
+			return nil, fmt.Errorf("duplicate request header %q", key)
+		}
+		if key == "Host" {
+			return nil, fmt.Errorf("request header %q is not supported, use host instead", key)
+		}
+		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
+			return nil, fmt.Errorf("invalid request header %q: %v", key, msgs)
+		}
+		if requestHeaders == nil {
+			requestHeaders = map[string]string{}
+		}
+		requestHeaders[key] = escapeHeaderValue(h.Value, nil)
+	}
+
 	return &HTTPHealthCheckPolicy{
 		Path:               hc.Path,
 		Host:               hc.Host,
+		Port:               uint32(hc.Port),
 		Interval:           time.Duration(hc.IntervalSeconds) * time.Second,
 		Timeout:            time.Duration(hc.TimeoutSeconds) * time.Second,
 		UnhealthyThreshold: uint32(hc.UnhealthyThresholdCount),
 		HealthyThreshold:   uint32(hc.HealthyThresholdCount),
-	}
+		ExpectedStatuses:   expectedStatuses,
+		RequestHeaders:     requestHeaders,
+		InitialJitter:      time.Duration(hc.InitialJitterSeconds) * time.Second,
+		IntervalJitter:     time.Duration(hc.IntervalJitterSeconds) * time.Second,
+	}, nil
 }
 
 func tcpHealthCheckPolicy(hc *contour_api_v1.TCPHealthCheckPolicy) *TCPHealthCheckPolicy {
@@ -466,6 +617,7 @@ func tcpHealthCheckPolicy(hc *contour_api_v1.TCPHealthCheckPolicy) *TCPHealthChe
 		return nil
 	}
 	return &TCPHealthCheckPolicy{
+		Port:               uint32(hc.Port),
 		Interval:           time.Duration(hc.IntervalSeconds) * time.Second,
 		Timeout:            time.Duration(hc.TimeoutSeconds) * time.Second,
 		UnhealthyThreshold: hc.UnhealthyThresholdCount,
@@ -513,7 +665,7 @@ func rateLimitPolicy(in *contour_api_v1.RateLimitPolicy) (*RateLimitPolicy, erro
 
 	rp := &RateLimitPolicy{}
 
-	local, err := localRateLimitPolicy(in.Local)
+	local, err := NewLocalRateLimitPolicy(in.Local)
 	if err != nil {
 		return nil, err
 	}
@@ -528,7 +680,86 @@ func rateLimitPolicy(in *contour_api_v1.RateLimitPolicy) (*RateLimitPolicy, erro
 	return rp, nil
 }
 
-func localRateLimitPolicy(in *contour_api_v1.LocalRateLimitPolicy) (*LocalRateLimitPolicy, error) {
+// virtualHostRateLimitPolicy returns the rate limit policy for a virtual
+// host, rejecting fields that are only valid on routes.
+func virtualHostRateLimitPolicy(in *contour_api_v1.RateLimitPolicy) (*RateLimitPolicy, error) {
+	if in != nil && in.Global != nil {
+		if in.Global.Disabled {
+			return nil, errors.New("global rate limit policy can only be disabled on routes")
+		}
+		if in.Global.InheritVirtualHostPolicy {
+			return nil, errors.New("global rate limit policy can only inherit the virtual host policy on routes")
+		}
+	}
+
+	return rateLimitPolicy(in)
+}
+
+// routeRateLimitPolicy returns the rate limit policy for a route. If the
+// route's global policy inherits the virtual host's policy, the virtual
+// host's descriptors are added ahead of the route's descriptors.
+// Otherwise, the route's descriptors only replace the virtual host's
+// descriptors in the stages the route uses, and the virtual host's
+// descriptors in other stages are added ahead of them, so that rate
+// limits in distinct stages compose.
+func routeRateLimitPolicy(in *contour_api_v1.RateLimitPolicy, vhost *contour_api_v1.RateLimitPolicy) (*RateLimitPolicy, error) {
+	rp, err := rateLimitPolicy(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if rp == nil || rp.Global == nil || rp.Global.Disabled {
+		return rp, nil
+	}
+
+	if vhost == nil || vhost.Global == nil {
+		return rp, nil
+	}
+
+	inherited, err := globalRateLimitPolicy(vhost.Global)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Global.InheritVirtualHostPolicy {
+		rp.Global.Descriptors = append(inherited.Descriptors, rp.Global.Descriptors...)
+		return rp, nil
+	}
+
+	stages := map[uint32]bool{}
+	for _, d := range rp.Global.Descriptors {
+		stages[d.Stage] = true
+	}
+
+	var descriptors []*RateLimitDescriptor
+	for _, d := range inherited.Descriptors {
+		if !stages[d.Stage] {
+			descriptors = append(descriptors, d)
+		}
+	}
+	rp.Global.Descriptors = append(descriptors, rp.Global.Descriptors...)
+
+	return rp, nil
+}
+
+// defaultLocalRateLimitPolicy returns the virtual host rate limit policy
+// with the default local rate limit policy applied, unless the virtual
+// host has its own local rate limit policy.
+func defaultLocalRateLimitPolicy(rlp *RateLimitPolicy, def *LocalRateLimitPolicy) *RateLimitPolicy {
+	if def == nil || (rlp != nil && rlp.Local != nil) {
+		return rlp
+	}
+
+	res := &RateLimitPolicy{Local: def}
+	if rlp != nil {
+		res.Global = rlp.Global
+	}
+	return res
+}
+
+// NewLocalRateLimitPolicy validates the given local rate limit policy
+// and returns its DAG representation.
+func NewLocalRateLimitPolicy(in *contour_api_v1.LocalRateLimitPolicy) (*LocalRateLimitPolicy, error) {
 	if in == nil {
 		return nil, nil
 	}
@@ -580,10 +811,27 @@ func globalRateLimitPolicy(in *contour_api_v1.GlobalRateLimitPolicy) (*GlobalRat
 		return nil, nil
 	}
 
+	if in.Disabled {
+		if len(in.Descriptors) > 0 || in.InheritVirtualHostPolicy {
+			return nil, errors.New("disabled global rate limit policy cannot define or inherit descriptors")
+		}
+		return &GlobalRateLimitPolicy{Disabled: true}, nil
+	}
+
+	if len(in.Descriptors) == 0 && !in.InheritVirtualHostPolicy {
+		return nil, errors.New("global rate limit policy must define at least one descriptor")
+	}
+
 	res := &GlobalRateLimitPolicy{}
 
 	for _, d := range in.Descriptors {
-		var rld RateLimitDescriptor
+		if d.Stage > 10 {
+			return nil, fmt.Errorf("rate limit descriptor stage %d must be between 0 and 10", d.Stage)
+		}
+
+		rld := RateLimitDescriptor{
+			Stage: d.Stage,
+		}
 
 		for _, entry := range d.Entries {
 			// ensure exactly one field is populated on the entry
@@ -631,11 +879,45 @@ func globalRateLimitPolicy(in *contour_api_v1.GlobalRateLimitPolicy) (*GlobalRat
 				})
 			}
 
+			if entry.Metadata != nil {
+				set++
+
+				if entry.Metadata.DescriptorKey == "" || entry.Metadata.Namespace == "" || len(entry.Metadata.Path) == 0 {
+					return nil, errors.New("rate limit metadata descriptor entry must have a descriptor key, namespace and path")
+				}
+
+				rld.Entries = append(rld.Entries, RateLimitDescriptorEntry{
+					Metadata: &MetadataDescriptorEntry{
+						Key:          entry.Metadata.DescriptorKey,
+						Namespace:    entry.Metadata.Namespace,
+						Path:         entry.Metadata.Path,
+						DefaultValue: entry.Metadata.DefaultValue,
+					},
+				})
+			}
+
 			if set != 1 {
 				return nil, errors.New("rate limit descriptor entry must have exactly one field set")
 			}
 		}
 
+		if l := d.Limit; l != nil {
+			if l.Requests == 0 {
+				return nil, errors.New("rate limit descriptor limit must allow at least one request")
+			}
+
+			switch l.Unit {
+			case "second", "minute", "hour", "day":
+			default:
+				return nil, fmt.Errorf("invalid rate limit descriptor limit unit %q", l.Unit)
+			}
+
+			rld.Limit = &RateLimitDescriptorLimit{
+				Requests: l.Requests,
+				Unit:     l.Unit,
+			}
+		}
+
 		res.Descriptors = append(res.Descriptors, &rld)
 	}
 
@@ -720,3 +1002,368 @@ func loadBalancerRequestHashPolicies(lbp *contour_api_v1.LoadBalancerPolicy, val
 	}
 
 }
+
+func trafficSplitPolicy(in *contour_api_v1.TrafficSplitPolicy) (*TrafficSplitPolicy, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	if len(in.HeaderName) == 0 && len(in.CookieName) == 0 {
+		return nil, errors.New("at least one of headerName or cookieName must be specified")
+	}
+
+	if len(in.HeaderName) > 0 {
+		if msgs := validation.IsHTTPHeaderName(in.HeaderName); len(msgs) != 0 {
+			return nil, fmt.Errorf("invalid header name %q: %v", in.HeaderName, msgs)
+		}
+	}
+
+	return &TrafficSplitPolicy{
+		HeaderName: in.HeaderName,
+		CookieName: in.CookieName,
+	}, nil
+}
+
+func trafficShiftPolicy(in *contour_api_v1.TrafficShiftPolicy, services []contour_api_v1.Service) (*TrafficShiftPolicy, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	if in.From == in.To {
+		return nil, fmt.Errorf("from and to must name different services")
+	}
+
+	if len(in.Steps) == 0 {
+		return nil, errors.New("at least one step must be specified")
+	}
+	for _, step := range in.Steps {
+		if step > 100 {
+			return nil, fmt.Errorf("step %d must be a percentage between 0 and 100", step)
+		}
+	}
+
+	interval, err := time.ParseDuration(in.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interval %q: %w", in.Interval, err)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval %q must be positive", in.Interval)
+	}
+
+	// The shift sets the weights of all of the route's
+	// services, so it must only have the two it shifts
+	// between, besides any mirror or backups.
+	names := map[string]bool{}
+	for _, service := range services {
+		if service.Mirror || service.Backup {
+			continue
+		}
+		if service.Name != in.From && service.Name != in.To {
+			return nil, fmt.Errorf("service %q is neither the from nor the to service", service.Name)
+		}
+		names[service.Name] = true
+	}
+	for _, name := range []string{in.From, in.To} {
+		if !names[name] {
+			return nil, fmt.Errorf("service %q is not a service of the route", name)
+		}
+	}
+
+	return &TrafficShiftPolicy{
+		From:     in.From,
+		To:       in.To,
+		Steps:    append([]uint32(nil), in.Steps...),
+		Interval: interval,
+	}, nil
+}
+
+// backupService is a service that backs up the other services of a
+// route or TCP proxy.
+type backupService struct {
+	name     string
+	service  *Service
+	protocol string
+}
+
+// backupServicesValid returns an error if the backups among services
+// have no services to back up, or are also mirrors.
+func backupServicesValid(services []contour_api_v1.Service) error {
+	backups, others := 0, 0
+	for _, service := range services {
+		switch {
+		case service.Backup && service.Mirror:
+			return fmt.Errorf("service %q cannot be both a backup and a mirror", service.Name)
+		case service.Backup:
+			backups++
+		case !service.Mirror:
+			others++
+		}
+	}
+
+	if backups > 0 && others == 0 {
+		return errors.New("backup services require another service to back up")
+	}
+
+	return nil
+}
+
+// setBackups sets the backups of each of the clusters. Since the
+// endpoints of the backups become endpoints of the clusters, they
+// must be reached with the same protocol, and must be ExternalName
+// services if, and only if, the Upstreams of the clusters are.
+func setBackups(clusters []*Cluster, backups []backupService) error {
+	for _, c := range clusters {
+		for _, b := range backups {
+			if b.protocol != c.Protocol {
+				return fmt.Errorf("backup service %q: protocol %q does not match the protocol %q of service %q",
+					b.name, b.protocol, c.Protocol, c.Upstream.Weighted.ServiceName)
+			}
+			if len(b.service.StaticEndpoints) > 0 || len(c.Upstream.StaticEndpoints) > 0 {
+				return fmt.Errorf("backup service %q: services with static endpoints cannot back up, or be backed up",
+					b.name)
+			}
+			if (b.service.ExternalName == "") != (c.Upstream.ExternalName == "") {
+				return fmt.Errorf("backup service %q: only ExternalName services can back up, or be backed up by, ExternalName services",
+					b.name)
+			}
+		}
+	}
+
+	for _, c := range clusters {
+		for _, b := range backups {
+			c.Backups = append(c.Backups, b.service)
+		}
+	}
+
+	return nil
+}
+
+// authorizationContext returns the authorization context entries for
+// a route. Entries from the virtual host are inherited, entries from the
+// route override inherited entries with the same key, and a route entry
+// with an empty value removes the inherited entry. Once merged, the
+// %CONTOUR_VHOST%, %CONTOUR_PATH% and %CONTOUR_METHOD% variables in each
+// value are expanded from the virtual host name and the route's match
+// conditions.
+func authorizationContext(vhost, route map[string]string, fqdn string, r *Route) map[string]string {
+	values := make(map[string]string, len(vhost)+len(route))
+
+	for k, v := range vhost {
+		values[k] = v
+	}
+
+	for k, v := range route {
+		if v == "" {
+			delete(values, k)
+			continue
+		}
+		values[k] = v
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	var path string
+	switch c := r.PathMatchCondition.(type) {
+	case *PrefixMatchCondition:
+		path = c.Prefix
+	case *ExactMatchCondition:
+		path = c.Path
+	case *RegexMatchCondition:
+		path = c.Regex
+	}
+
+	var method string
+	for _, hc := range r.HeaderMatchConditions {
+		if strings.EqualFold(hc.Name, ":method") && hc.MatchType == HeaderMatchTypeExact && !hc.Invert {
+			method = hc.Value
+		}
+	}
+
+	replacer := strings.NewReplacer(
+		"%CONTOUR_VHOST%", fqdn,
+		"%CONTOUR_PATH%", path,
+		"%CONTOUR_METHOD%", method,
+	)
+
+	for k, v := range values {
+		values[k] = replacer.Replace(v)
+	}
+
+	return values
+}
+
+func connectionTimeouts(in *contour_api_v1.ConnectionTimeoutPolicy) (*ConnectionTimeouts, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	maxConnectionDuration, err := timeout.Parse(in.MaxConnectionDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing max connection duration: %w", err)
+	}
+
+	connectionShutdownGracePeriod, err := timeout.Parse(in.ConnectionShutdownGracePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing connection shutdown grace period: %w", err)
+	}
+
+	delayedClose, err := timeout.Parse(in.DelayedCloseTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing delayed close timeout: %w", err)
+	}
+
+	return &ConnectionTimeouts{
+		MaxConnectionDuration:         maxConnectionDuration,
+		ConnectionShutdownGracePeriod: connectionShutdownGracePeriod,
+		DelayedClose:                  delayedClose,
+	}, nil
+}
+
+// hstsPreloadMinMaxAge is the minimum max age of
+// an HSTS policy that consents to preloading.
+const hstsPreloadMinMaxAge = 365 * 24 * time.Hour
+
+func hstsPolicy(in *contour_api_v1.HSTSPolicy) (*HSTSPolicy, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	maxAge, err := time.ParseDuration(in.MaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing max age: %w", err)
+	}
+	if maxAge < 0 {
+		return nil, fmt.Errorf("max age %q must not be negative", in.MaxAge)
+	}
+
+	if in.Preload {
+		if !in.IncludeSubDomains {
+			return nil, errors.New("preload requires includeSubDomains")
+		}
+		if maxAge < hstsPreloadMinMaxAge {
+			return nil, fmt.Errorf("preload requires a max age of at least %s", hstsPreloadMinMaxAge)
+		}
+	}
+
+	return &HSTSPolicy{
+		MaxAge:            maxAge.Truncate(time.Second),
+		IncludeSubDomains: in.IncludeSubDomains,
+		Preload:           in.Preload,
+	}, nil
+}
+
+// maxRequestHeadersKB is the largest size of request
+// headers that Envoy accepts.
+const maxRequestHeadersKB = 8192
+
+func headerLimits(in *contour_api_v1.HeaderLimitsPolicy) (*HeaderLimits, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	if in.MaxRequestHeadersKB > maxRequestHeadersKB {
+		return nil, fmt.Errorf("max request headers size %d KiB must be at most %d KiB", in.MaxRequestHeadersKB, maxRequestHeadersKB)
+	}
+
+	return &HeaderLimits{
+		MaxRequestHeadersKB:    in.MaxRequestHeadersKB,
+		MaxRequestHeadersCount: in.MaxRequestHeadersCount,
+	}, nil
+}
+
+// disabledFilters returns the HTTP filters disabled for a route.
+// Rate limit filters cannot be disabled on a route that sets the
+// limits of the same filter.
+func disabledFilters(in []contour_api_v1.HTTPFilterName, rlp *RateLimitPolicy) ([]HTTPFilter, error) {
+	var filters []HTTPFilter
+	seen := map[HTTPFilter]bool{}
+
+	for _, name := range in {
+		f := HTTPFilter(name)
+		switch f {
+		case HTTPFilterCORS, HTTPFilterExtAuthz:
+		case HTTPFilterLocalRateLimit:
+			if rlp != nil && rlp.Local != nil && !rlp.Local.Disabled {
+				return nil, fmt.Errorf("filter %q cannot be disabled on a route with a local rate limit policy", f)
+			}
+		case HTTPFilterRateLimit:
+			if rlp != nil && rlp.Global != nil && !rlp.Global.Disabled {
+				return nil, fmt.Errorf("filter %q cannot be disabled on a route with a global rate limit policy", f)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported filter %q", f)
+		}
+
+		if !seen[f] {
+			seen[f] = true
+			filters = append(filters, f)
+		}
+	}
+
+	return filters, nil
+}
+
+func dnsPolicy(in *contour_api_v1.DNSPolicy) (*DNSPolicy, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	var discoveryType string
+	switch in.DiscoveryType {
+	case "":
+	case "Strict":
+		discoveryType = DNSDiscoveryTypeStrict
+	case "Logical":
+		discoveryType = DNSDiscoveryTypeLogical
+	default:
+		return nil, fmt.Errorf("invalid discovery type %q", in.DiscoveryType)
+	}
+
+	var resolvers []DNSResolver
+	for _, r := range in.Resolvers {
+		resolver, err := parseDNSResolver(r)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, resolver)
+	}
+
+	switch in.LookupFamily {
+	case "", "auto", "v4", "v6":
+	default:
+		return nil, fmt.Errorf("invalid lookup family %q", in.LookupFamily)
+	}
+
+	return &DNSPolicy{
+		DiscoveryType: discoveryType,
+		Resolvers:     resolvers,
+		LookupFamily:  in.LookupFamily,
+	}, nil
+}
+
+// parseDNSResolver parses a DNS resolver address of the form
+// "ip" or "ip:port". The port defaults to 53.
+func parseDNSResolver(address string) (DNSResolver, error) {
+	if ip := net.ParseIP(address); ip != nil {
+		return DNSResolver{Address: ip.String(), Port: 53}, nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return DNSResolver{}, fmt.Errorf("invalid DNS resolver address %q: %w", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return DNSResolver{}, fmt.Errorf("invalid DNS resolver address %q: %q is not an IP address", address, host)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || p == 0 {
+		return DNSResolver{}, fmt.Errorf("invalid DNS resolver address %q: invalid port %q", address, port)
+	}
+
+	return DNSResolver{Address: ip.String(), Port: uint32(p)}, nil
+}
@@ -180,6 +180,31 @@ func TestRetryPolicy(t *testing.T) {
 				NumRetries:           1,
 			},
 		},
+		"retry host predicates": {
+			rp: &contour_api_v1.RetryPolicy{
+				RetryHostPredicates:      []contour_api_v1.RetryHostPredicate{"previous-hosts", "canary-hosts"},
+				HostSelectionMaxAttempts: 3,
+			},
+			want: &RetryPolicy{
+				RetryOn:                  "5xx",
+				NumRetries:               1,
+				AvoidPreviousHosts:       true,
+				AvoidCanaryHosts:         true,
+				HostSelectionMaxAttempts: 3,
+			},
+		},
+		"retry priority": {
+			rp: &contour_api_v1.RetryPolicy{
+				RetryPriority: &contour_api_v1.RetryPriority{
+					PreviousPrioritiesUpdateFrequency: 2,
+				},
+			},
+			want: &RetryPolicy{
+				RetryOn:                           "5xx",
+				NumRetries:                        1,
+				PreviousPrioritiesUpdateFrequency: 2,
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -190,6 +215,165 @@ func TestRetryPolicy(t *testing.T) {
 	}
 }
 
+func TestHedgePolicy(t *testing.T) {
+	retries := &RetryPolicy{
+		RetryOn:       "5xx",
+		NumRetries:    2,
+		PerTryTimeout: timeout.DurationSetting(100 * time.Millisecond),
+	}
+
+	tests := map[string]struct {
+		hp      *contour_api_v1.HedgePolicy
+		rp      *RetryPolicy
+		want    *HedgePolicy
+		wantErr bool
+	}{
+		"nil hedge policy": {
+			hp:   nil,
+			rp:   retries,
+			want: nil,
+		},
+		"hedge on per try timeout": {
+			hp:   &contour_api_v1.HedgePolicy{HedgeOnPerTryTimeout: true},
+			rp:   retries,
+			want: &HedgePolicy{HedgeOnPerTryTimeout: true},
+		},
+		"no retry policy": {
+			hp:      &contour_api_v1.HedgePolicy{HedgeOnPerTryTimeout: true},
+			wantErr: true,
+		},
+		"retries disabled": {
+			hp: &contour_api_v1.HedgePolicy{HedgeOnPerTryTimeout: true},
+			rp: &RetryPolicy{
+				RetryOn:       "5xx",
+				PerTryTimeout: timeout.DurationSetting(100 * time.Millisecond),
+			},
+			wantErr: true,
+		},
+		"no per try timeout": {
+			hp: &contour_api_v1.HedgePolicy{HedgeOnPerTryTimeout: true},
+			rp: &RetryPolicy{
+				RetryOn:       "5xx",
+				NumRetries:    2,
+				PerTryTimeout: timeout.DefaultSetting(),
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := hedgePolicy(tc.hp, tc.rp)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestGRPCPolicy(t *testing.T) {
+	tests := map[string]struct {
+		gp      *contour_api_v1.GRPCPolicy
+		want    *GRPCPolicy
+		wantErr bool
+	}{
+		"nil grpc policy": {
+			gp:   nil,
+			want: nil,
+		},
+		"empty grpc policy": {
+			gp:   &contour_api_v1.GRPCPolicy{},
+			want: &GRPCPolicy{},
+		},
+		"max timeout and offset": {
+			gp: &contour_api_v1.GRPCPolicy{
+				MaxTimeout:    "30s",
+				TimeoutOffset: "50ms",
+			},
+			want: &GRPCPolicy{
+				MaxTimeout:    30 * time.Second,
+				TimeoutOffset: 50 * time.Millisecond,
+			},
+		},
+		"infinite max timeout": {
+			gp: &contour_api_v1.GRPCPolicy{
+				MaxTimeout: "infinity",
+			},
+			want: &GRPCPolicy{},
+		},
+		"invalid max timeout": {
+			gp: &contour_api_v1.GRPCPolicy{
+				MaxTimeout: "forever",
+			},
+			wantErr: true,
+		},
+		"invalid timeout offset": {
+			gp: &contour_api_v1.GRPCPolicy{
+				TimeoutOffset: "infinity",
+			},
+			wantErr: true,
+		},
+		"negative timeout offset": {
+			gp: &contour_api_v1.GRPCPolicy{
+				TimeoutOffset: "-1s",
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := grpcPolicy(tc.gp)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestStatsPolicy(t *testing.T) {
+	tests := map[string]struct {
+		sp      *contour_api_v1.StatsPolicy
+		want    string
+		wantErr bool
+	}{
+		"nil stats policy": {
+			sp:   nil,
+			want: "",
+		},
+		"virtual cluster name": {
+			sp:   &contour_api_v1.StatsPolicy{VirtualClusterName: "users_v1-api"},
+			want: "users_v1-api",
+		},
+		"empty virtual cluster name": {
+			sp:      &contour_api_v1.StatsPolicy{},
+			wantErr: true,
+		},
+		"virtual cluster name with dots": {
+			sp:      &contour_api_v1.StatsPolicy{VirtualClusterName: "users.v1"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := statsPolicy(tc.sp)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestTimeoutPolicy(t *testing.T) {
 	tests := map[string]struct {
 		tp      *contour_api_v1.TimeoutPolicy
@@ -250,6 +434,155 @@ func TestTimeoutPolicy(t *testing.T) {
 	}
 }
 
+func TestHTTPHealthCheckPolicy(t *testing.T) {
+	tests := map[string]struct {
+		hc      *contour_api_v1.HTTPHealthCheckPolicy
+		want    *HTTPHealthCheckPolicy
+		wantErr bool
+	}{
+		"nil health check policy": {
+			hc:   nil,
+			want: nil,
+		},
+		"path only": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+			},
+			want: &HTTPHealthCheckPolicy{
+				Path: "/healthz",
+			},
+		},
+		"expected statuses, request headers and jitter": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				ExpectedStatuses: []contour_api_v1.HTTPStatusRange{
+					{Start: 200, End: 300},
+					{Start: 401, End: 402},
+				},
+				RequestHeaders: []contour_api_v1.HeaderValue{
+					{Name: "x-health-check", Value: "100%"},
+				},
+				InitialJitterSeconds:  5,
+				IntervalJitterSeconds: 1,
+			},
+			want: &HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				ExpectedStatuses: []HTTPStatusRange{
+					{Start: 200, End: 300},
+					{Start: 401, End: 402},
+				},
+				RequestHeaders: map[string]string{"X-Health-Check": "100%%"},
+				InitialJitter:  5 * time.Second,
+				IntervalJitter: time.Second,
+			},
+		},
+		"empty expected status range": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path:             "/healthz",
+				ExpectedStatuses: []contour_api_v1.HTTPStatusRange{{Start: 300, End: 300}},
+			},
+			wantErr: true,
+		},
+		"expected status range out of bounds": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path:             "/healthz",
+				ExpectedStatuses: []contour_api_v1.HTTPStatusRange{{Start: 500, End: 700}},
+			},
+			wantErr: true,
+		},
+		"duplicate request header": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path: "/healthz",
+				RequestHeaders: []contour_api_v1.HeaderValue{
+					{Name: "x-health-check", Value: "a"},
+					{Name: "X-Health-Check", Value: "b"},
+				},
+			},
+			wantErr: true,
+		},
+		"host request header": {
+			hc: &contour_api_v1.HTTPHealthCheckPolicy{
+				Path:           "/healthz",
+				RequestHeaders: []contour_api_v1.HeaderValue{{Name: "host", Value: "example.com"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := httpHealthCheckPolicy(tc.hc)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestTimeoutPolicyWithinLimits(t *testing.T) {
+	tests := map[string]struct {
+		tp          TimeoutPolicy
+		maxResponse time.Duration
+		maxIdle     time.Duration
+		wantErr     bool
+	}{
+		"no limits": {
+			tp: TimeoutPolicy{
+				ResponseTimeout: timeout.DisabledSetting(),
+				IdleTimeout:     timeout.DurationSetting(time.Hour),
+			},
+		},
+		"default timeouts": {
+			tp:          TimeoutPolicy{},
+			maxResponse: time.Second,
+			maxIdle:     time.Second,
+		},
+		"within limits": {
+			tp: TimeoutPolicy{
+				ResponseTimeout: timeout.DurationSetting(30 * time.Second),
+				IdleTimeout:     timeout.DurationSetting(time.Minute),
+			},
+			maxResponse: 30 * time.Second,
+			maxIdle:     5 * time.Minute,
+		},
+		"response timeout exceeds limit": {
+			tp: TimeoutPolicy{
+				ResponseTimeout: timeout.DurationSetting(31 * time.Second),
+			},
+			maxResponse: 30 * time.Second,
+			wantErr:     true,
+		},
+		"response timeout disabled": {
+			tp: TimeoutPolicy{
+				ResponseTimeout: timeout.DisabledSetting(),
+			},
+			maxResponse: 30 * time.Second,
+			wantErr:     true,
+		},
+		"idle timeout exceeds limit": {
+			tp: TimeoutPolicy{
+				IdleTimeout: timeout.DurationSetting(time.Hour),
+			},
+			maxIdle: 5 * time.Minute,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := timeoutPolicyWithinLimits(tc.tp, tc.maxResponse, tc.maxIdle)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestLoadBalancerPolicy(t *testing.T) {
 	tests := map[string]struct {
 		lbp  *contour_api_v1.LoadBalancerPolicy
@@ -721,6 +1054,63 @@ func TestRateLimitPolicy(t *testing.T) {
 				},
 			},
 		},
+		"global - metadata": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									Metadata: &contour_api_v1.MetadataDescriptor{
+										DescriptorKey: "tenant",
+										Namespace:     "envoy.filters.http.ext_authz",
+										Path:          []string{"tenant"},
+										DefaultValue:  "anonymous",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									Metadata: &MetadataDescriptorEntry{
+										Key:          "tenant",
+										Namespace:    "envoy.filters.http.ext_authz",
+										Path:         []string{"tenant"},
+										DefaultValue: "anonymous",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"global - metadata without a path": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									Metadata: &contour_api_v1.MetadataDescriptor{
+										DescriptorKey: "tenant",
+										Namespace:     "envoy.filters.http.ext_authz",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: "rate limit metadata descriptor entry must have a descriptor key, namespace and path",
+		},
 		"global - multiple descriptor entries set": {
 			in: &contour_api_v1.RateLimitPolicy{
 				Global: &contour_api_v1.GlobalRateLimitPolicy{
@@ -799,12 +1189,8 @@ func TestRateLimitPolicy(t *testing.T) {
 				},
 			},
 		},
-		"global and local": {
+		"global - descriptor stage": {
 			in: &contour_api_v1.RateLimitPolicy{
-				Local: &contour_api_v1.LocalRateLimitPolicy{
-					Requests: 20,
-					Unit:     "second",
-				},
 				Global: &contour_api_v1.GlobalRateLimitPolicy{
 					Descriptors: []contour_api_v1.RateLimitDescriptor{
 						{
@@ -813,16 +1199,62 @@ func TestRateLimitPolicy(t *testing.T) {
 									RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
 								},
 							},
+							Stage: 2,
 						},
 					},
 				},
 			},
 			want: &RateLimitPolicy{
-				Local: &LocalRateLimitPolicy{
-					MaxTokens:     20,
-					TokensPerFill: 20,
-					FillInterval:  time.Second,
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &RemoteAddressDescriptorEntry{},
+								},
+							},
+							Stage: 2,
+						},
+					},
+				},
+			},
+		},
+		"global - descriptor stage out of range": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+								},
+							},
+							Stage: 11,
+						},
+					},
+				},
+			},
+			wantErr: "rate limit descriptor stage 11 must be between 0 and 10",
+		},
+		"global - descriptor limit": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+								},
+							},
+							Limit: &contour_api_v1.RateLimitDescriptorLimit{
+								Requests: 100,
+								Unit:     "minute",
+							},
+						},
+					},
 				},
+			},
+			want: &RateLimitPolicy{
 				Global: &GlobalRateLimitPolicy{
 					Descriptors: []*RateLimitDescriptor{
 						{
@@ -831,15 +1263,111 @@ func TestRateLimitPolicy(t *testing.T) {
 									RemoteAddress: &RemoteAddressDescriptorEntry{},
 								},
 							},
+							Limit: &RateLimitDescriptorLimit{
+								Requests: 100,
+								Unit:     "minute",
+							},
 						},
 					},
 				},
 			},
 		},
-	}
-
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
+		"global - descriptor limit with invalid unit": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+								},
+							},
+							Limit: &contour_api_v1.RateLimitDescriptorLimit{
+								Requests: 100,
+								Unit:     "week",
+							},
+						},
+					},
+				},
+			},
+			wantErr: `invalid rate limit descriptor limit unit "week"`,
+		},
+		"global - no descriptors": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{},
+			},
+			wantErr: "global rate limit policy must define at least one descriptor",
+		},
+		"global - disabled": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Disabled: true,
+				},
+			},
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Disabled: true,
+				},
+			},
+		},
+		"global - disabled with descriptors": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Disabled: true,
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: "disabled global rate limit policy cannot define or inherit descriptors",
+		},
+		"global and local": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Local: &contour_api_v1.LocalRateLimitPolicy{
+					Requests: 20,
+					Unit:     "second",
+				},
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{
+						{
+							Entries: []contour_api_v1.RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &RateLimitPolicy{
+				Local: &LocalRateLimitPolicy{
+					MaxTokens:     20,
+					TokensPerFill: 20,
+					FillInterval:  time.Second,
+				},
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &RemoteAddressDescriptorEntry{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
 			rlp, err := rateLimitPolicy(tc.in)
 
 			if tc.wantErr != "" {
@@ -850,3 +1378,892 @@ func TestRateLimitPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestVirtualHostRateLimitPolicy(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.RateLimitPolicy
+		wantErr string
+	}{
+		"disabled": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Disabled: true,
+				},
+			},
+			wantErr: "global rate limit policy can only be disabled on routes",
+		},
+		"inherit virtual host policy": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					InheritVirtualHostPolicy: true,
+				},
+			},
+			wantErr: "global rate limit policy can only inherit the virtual host policy on routes",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := virtualHostRateLimitPolicy(tc.in)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestDefaultLocalRateLimitPolicy(t *testing.T) {
+	def := &LocalRateLimitPolicy{
+		MaxTokens:     10,
+		TokensPerFill: 10,
+		FillInterval:  time.Second,
+	}
+	local := &LocalRateLimitPolicy{
+		MaxTokens:     5,
+		TokensPerFill: 5,
+		FillInterval:  time.Minute,
+	}
+	global := &GlobalRateLimitPolicy{
+		Descriptors: []*RateLimitDescriptor{{
+			Entries: []RateLimitDescriptorEntry{{
+				RemoteAddress: &RemoteAddressDescriptorEntry{},
+			}},
+		}},
+	}
+
+	tests := map[string]struct {
+		rlp  *RateLimitPolicy
+		def  *LocalRateLimitPolicy
+		want *RateLimitPolicy
+	}{
+		"no default": {
+			rlp:  &RateLimitPolicy{Global: global},
+			want: &RateLimitPolicy{Global: global},
+		},
+		"no policy": {
+			def:  def,
+			want: &RateLimitPolicy{Local: def},
+		},
+		"global policy only": {
+			rlp:  &RateLimitPolicy{Global: global},
+			def:  def,
+			want: &RateLimitPolicy{Local: def, Global: global},
+		},
+		"local policy overrides default": {
+			rlp:  &RateLimitPolicy{Local: local},
+			def:  def,
+			want: &RateLimitPolicy{Local: local},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, defaultLocalRateLimitPolicy(tc.rlp, tc.def))
+		})
+	}
+}
+
+func TestRouteRateLimitPolicy(t *testing.T) {
+	remoteAddress := contour_api_v1.RateLimitDescriptor{
+		Entries: []contour_api_v1.RateLimitDescriptorEntry{
+			{
+				RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+			},
+		},
+	}
+	genericKey := contour_api_v1.RateLimitDescriptor{
+		Entries: []contour_api_v1.RateLimitDescriptorEntry{
+			{
+				GenericKey: &contour_api_v1.GenericKeyDescriptor{
+					Key:   "route",
+					Value: "foo",
+				},
+			},
+		},
+		Stage: 1,
+	}
+	sameStageKey := contour_api_v1.RateLimitDescriptor{
+		Entries: []contour_api_v1.RateLimitDescriptorEntry{
+			{
+				GenericKey: &contour_api_v1.GenericKeyDescriptor{
+					Key:   "route",
+					Value: "bar",
+				},
+			},
+		},
+	}
+	vhost := &contour_api_v1.RateLimitPolicy{
+		Global: &contour_api_v1.GlobalRateLimitPolicy{
+			Descriptors: []contour_api_v1.RateLimitDescriptor{remoteAddress},
+		},
+	}
+
+	tests := map[string]struct {
+		in    *contour_api_v1.RateLimitPolicy
+		vhost *contour_api_v1.RateLimitPolicy
+		want  *RateLimitPolicy
+	}{
+		"override virtual host policy in the same stage": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{sameStageKey},
+				},
+			},
+			vhost: vhost,
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									GenericKey: &GenericKeyDescriptorEntry{Key: "route", Value: "bar"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"compose with virtual host policy in other stages": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []contour_api_v1.RateLimitDescriptor{genericKey},
+				},
+			},
+			vhost: vhost,
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &RemoteAddressDescriptorEntry{},
+								},
+							},
+						},
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									GenericKey: &GenericKeyDescriptorEntry{Key: "route", Value: "foo"},
+								},
+							},
+							Stage: 1,
+						},
+					},
+				},
+			},
+		},
+		"inherit virtual host policy": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					InheritVirtualHostPolicy: true,
+					Descriptors:              []contour_api_v1.RateLimitDescriptor{genericKey},
+				},
+			},
+			vhost: vhost,
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									RemoteAddress: &RemoteAddressDescriptorEntry{},
+								},
+							},
+						},
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									GenericKey: &GenericKeyDescriptorEntry{Key: "route", Value: "foo"},
+								},
+							},
+							Stage: 1,
+						},
+					},
+				},
+			},
+		},
+		"inherit without virtual host policy": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					InheritVirtualHostPolicy: true,
+				},
+			},
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{},
+			},
+		},
+		"disabled": {
+			in: &contour_api_v1.RateLimitPolicy{
+				Global: &contour_api_v1.GlobalRateLimitPolicy{
+					Disabled: true,
+				},
+			},
+			vhost: vhost,
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Disabled: true,
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rlp, err := routeRateLimitPolicy(tc.in, tc.vhost)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, rlp)
+		})
+	}
+}
+
+func TestTrafficSplitPolicy(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.TrafficSplitPolicy
+		want    *TrafficSplitPolicy
+		wantErr bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"header and cookie": {
+			in: &contour_api_v1.TrafficSplitPolicy{
+				HeaderName: "X-Canary",
+				CookieName: "canary",
+			},
+			want: &TrafficSplitPolicy{
+				HeaderName: "X-Canary",
+				CookieName: "canary",
+			},
+		},
+		"cookie only": {
+			in: &contour_api_v1.TrafficSplitPolicy{
+				CookieName: "canary",
+			},
+			want: &TrafficSplitPolicy{
+				CookieName: "canary",
+			},
+		},
+		"no header or cookie": {
+			in:      &contour_api_v1.TrafficSplitPolicy{},
+			wantErr: true,
+		},
+		"invalid header name": {
+			in: &contour_api_v1.TrafficSplitPolicy{
+				HeaderName: "X Canary",
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tsp, err := trafficSplitPolicy(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, tsp)
+		})
+	}
+}
+
+func TestTrafficShiftPolicy(t *testing.T) {
+	services := []contour_api_v1.Service{
+		{Name: "stable", Port: 80},
+		{Name: "canary", Port: 80},
+		{Name: "mirror", Port: 80, Mirror: true},
+	}
+
+	tests := map[string]struct {
+		in       *contour_api_v1.TrafficShiftPolicy
+		services []contour_api_v1.Service
+		want     *TrafficShiftPolicy
+		wantErr  bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"valid": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{10, 50, 100},
+				Interval: "5m",
+			},
+			services: services,
+			want: &TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{10, 50, 100},
+				Interval: 5 * time.Minute,
+			},
+		},
+		"same from and to": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "stable",
+				Steps:    []uint32{100},
+				Interval: "5m",
+			},
+			services: services,
+			wantErr:  true,
+		},
+		"no steps": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Interval: "5m",
+			},
+			services: services,
+			wantErr:  true,
+		},
+		"step over 100": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{50, 101},
+				Interval: "5m",
+			},
+			services: services,
+			wantErr:  true,
+		},
+		"invalid interval": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{100},
+				Interval: "soon",
+			},
+			services: services,
+			wantErr:  true,
+		},
+		"zero interval": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{100},
+				Interval: "0s",
+			},
+			services: services,
+			wantErr:  true,
+		},
+		"missing service": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{100},
+				Interval: "5m",
+			},
+			services: services[:1],
+			wantErr:  true,
+		},
+		"extra service": {
+			in: &contour_api_v1.TrafficShiftPolicy{
+				From:     "stable",
+				To:       "canary",
+				Steps:    []uint32{100},
+				Interval: "5m",
+			},
+			services: append([]contour_api_v1.Service{{Name: "other", Port: 80}}, services...),
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tsp, err := trafficShiftPolicy(tc.in, tc.services)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, tsp)
+		})
+	}
+}
+
+func TestBackupServicesValid(t *testing.T) {
+	tests := map[string]struct {
+		services []contour_api_v1.Service
+		wantErr  bool
+	}{
+		"no backups": {
+			services: []contour_api_v1.Service{
+				{Name: "primary", Port: 80},
+				{Name: "mirror", Port: 80, Mirror: true},
+			},
+		},
+		"backup": {
+			services: []contour_api_v1.Service{
+				{Name: "backup", Port: 80, Backup: true},
+				{Name: "primary", Port: 80},
+			},
+		},
+		"only backups": {
+			services: []contour_api_v1.Service{
+				{Name: "backup", Port: 80, Backup: true},
+				{Name: "mirror", Port: 80, Mirror: true},
+			},
+			wantErr: true,
+		},
+		"backup mirror": {
+			services: []contour_api_v1.Service{
+				{Name: "primary", Port: 80},
+				{Name: "backup", Port: 80, Backup: true, Mirror: true},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := backupServicesValid(tc.services)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSetBackups(t *testing.T) {
+	primary := &Service{Weighted: WeightedService{ServiceName: "primary", ServiceNamespace: "default"}}
+	backup := &Service{Weighted: WeightedService{ServiceName: "backup", ServiceNamespace: "default"}}
+	external := &Service{Weighted: WeightedService{ServiceName: "external", ServiceNamespace: "default"}, ExternalName: "external.example.com"}
+	static := &Service{Weighted: WeightedService{ServiceName: "static", ServiceNamespace: "default"}, StaticEndpoints: []StaticEndpoint{{Address: "10.0.0.10", Port: 80}}}
+
+	tests := map[string]struct {
+		cluster *Cluster
+		backups []backupService
+		want    []*Service
+		wantErr bool
+	}{
+		"no backups": {
+			cluster: &Cluster{Upstream: primary},
+		},
+		"backups": {
+			cluster: &Cluster{Upstream: primary, Protocol: "h2c"},
+			backups: []backupService{
+				{name: "backup", service: backup, protocol: "h2c"},
+				{name: "other", service: backup, protocol: "h2c"},
+			},
+			want: []*Service{backup, backup},
+		},
+		"protocol mismatch": {
+			cluster: &Cluster{Upstream: primary, Protocol: "h2c"},
+			backups: []backupService{{name: "backup", service: backup}},
+			wantErr: true,
+		},
+		"externalName backup": {
+			cluster: &Cluster{Upstream: primary},
+			backups: []backupService{{name: "external", service: external}},
+			wantErr: true,
+		},
+		"externalName backed up": {
+			cluster: &Cluster{Upstream: external},
+			backups: []backupService{{name: "backup", service: backup}},
+			wantErr: true,
+		},
+		"static endpoints backup": {
+			cluster: &Cluster{Upstream: primary},
+			backups: []backupService{{name: "static", service: static}},
+			wantErr: true,
+		},
+		"static endpoints backed up": {
+			cluster: &Cluster{Upstream: static},
+			backups: []backupService{{name: "backup", service: backup}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := setBackups([]*Cluster{tc.cluster}, tc.backups)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, tc.cluster.Backups)
+		})
+	}
+}
+
+func TestAuthorizationContext(t *testing.T) {
+	route := &Route{
+		PathMatchCondition: &PrefixMatchCondition{Prefix: "/api"},
+		HeaderMatchConditions: []HeaderMatchCondition{{
+			Name:      ":method",
+			Value:     "POST",
+			MatchType: HeaderMatchTypeExact,
+		}},
+	}
+
+	tests := map[string]struct {
+		vhost map[string]string
+		route map[string]string
+		want  map[string]string
+	}{
+		"no context": {
+			want: nil,
+		},
+		"vhost context is inherited": {
+			vhost: map[string]string{"realm": "example"},
+			want:  map[string]string{"realm": "example"},
+		},
+		"route context overrides vhost context": {
+			vhost: map[string]string{"realm": "example", "scope": "read"},
+			route: map[string]string{"scope": "write"},
+			want:  map[string]string{"realm": "example", "scope": "write"},
+		},
+		"empty route value removes vhost entry": {
+			vhost: map[string]string{"realm": "example", "scope": "read"},
+			route: map[string]string{"scope": ""},
+			want:  map[string]string{"realm": "example"},
+		},
+		"all entries removed": {
+			vhost: map[string]string{"scope": "read"},
+			route: map[string]string{"scope": ""},
+			want:  nil,
+		},
+		"variables are expanded after merging": {
+			vhost: map[string]string{"resource": "%CONTOUR_VHOST%%CONTOUR_PATH%"},
+			route: map[string]string{"action": "%CONTOUR_METHOD%", "other": "%CONTOUR_UNKNOWN%"},
+			want: map[string]string{
+				"resource": "example.com/api",
+				"action":   "POST",
+				"other":    "%CONTOUR_UNKNOWN%",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := authorizationContext(tc.vhost, tc.route, "example.com", route)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestConnectionTimeouts(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.ConnectionTimeoutPolicy
+		want    *ConnectionTimeouts
+		wantErr bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"all timeouts": {
+			in: &contour_api_v1.ConnectionTimeoutPolicy{
+				MaxConnectionDuration:         "1h",
+				ConnectionShutdownGracePeriod: "30s",
+				DelayedCloseTimeout:           "infinity",
+			},
+			want: &ConnectionTimeouts{
+				MaxConnectionDuration:         timeout.DurationSetting(time.Hour),
+				ConnectionShutdownGracePeriod: timeout.DurationSetting(30 * time.Second),
+				DelayedClose:                  timeout.DisabledSetting(),
+			},
+		},
+		"unset timeouts use the default": {
+			in: &contour_api_v1.ConnectionTimeoutPolicy{
+				MaxConnectionDuration: "1h",
+			},
+			want: &ConnectionTimeouts{
+				MaxConnectionDuration:         timeout.DurationSetting(time.Hour),
+				ConnectionShutdownGracePeriod: timeout.DefaultSetting(),
+				DelayedClose:                  timeout.DefaultSetting(),
+			},
+		},
+		"invalid timeout": {
+			in: &contour_api_v1.ConnectionTimeoutPolicy{
+				DelayedCloseTimeout: "forever",
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ct, err := connectionTimeouts(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, ct)
+		})
+	}
+}
+
+func TestHSTSPolicy(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.HSTSPolicy
+		want    *HSTSPolicy
+		wantErr bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"max age": {
+			in: &contour_api_v1.HSTSPolicy{
+				MaxAge: "1h",
+			},
+			want: &HSTSPolicy{
+				MaxAge: time.Hour,
+			},
+		},
+		"max age is rounded down to seconds": {
+			in: &contour_api_v1.HSTSPolicy{
+				MaxAge: "1.5s",
+			},
+			want: &HSTSPolicy{
+				MaxAge: time.Second,
+			},
+		},
+		"preload": {
+			in: &contour_api_v1.HSTSPolicy{
+				MaxAge:            "8760h",
+				IncludeSubDomains: true,
+				Preload:           true,
+			},
+			want: &HSTSPolicy{
+				MaxAge:            8760 * time.Hour,
+				IncludeSubDomains: true,
+				Preload:           true,
+			},
+		},
+		"preload without includeSubDomains": {
+			in: &contour_api_v1.HSTSPolicy{
+				MaxAge:  "8760h",
+				Preload: true,
+			},
+			wantErr: true,
+		},
+		"preload with short max age": {
+			in: &contour_api_v1.HSTSPolicy{
+				MaxAge:            "24h",
+				IncludeSubDomains: true,
+				Preload:           true,
+			},
+			wantErr: true,
+		},
+		"invalid max age": {
+			in: &contour_api_v1.HSTSPolicy{
+				MaxAge: "forever",
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			hsts, err := hstsPolicy(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, hsts)
+		})
+	}
+}
+
+func TestHeaderLimits(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.HeaderLimitsPolicy
+		want    *HeaderLimits
+		wantErr bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"all limits": {
+			in: &contour_api_v1.HeaderLimitsPolicy{
+				MaxRequestHeadersKB:    96,
+				MaxRequestHeadersCount: 200,
+			},
+			want: &HeaderLimits{
+				MaxRequestHeadersKB:    96,
+				MaxRequestHeadersCount: 200,
+			},
+		},
+		"unset limits use the global limits": {
+			in: &contour_api_v1.HeaderLimitsPolicy{
+				MaxRequestHeadersCount: 50,
+			},
+			want: &HeaderLimits{
+				MaxRequestHeadersCount: 50,
+			},
+		},
+		"headers size too large": {
+			in: &contour_api_v1.HeaderLimitsPolicy{
+				MaxRequestHeadersKB: 8193,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			limits, err := headerLimits(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, limits)
+		})
+	}
+}
+
+func TestDisabledFilters(t *testing.T) {
+	tests := map[string]struct {
+		in      []contour_api_v1.HTTPFilterName
+		rlp     *RateLimitPolicy
+		want    []HTTPFilter
+		wantErr bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"duplicate filters": {
+			in: []contour_api_v1.HTTPFilterName{
+				contour_api_v1.HTTPFilterCORS,
+				contour_api_v1.HTTPFilterExtAuthz,
+				contour_api_v1.HTTPFilterCORS,
+			},
+			want: []HTTPFilter{HTTPFilterCORS, HTTPFilterExtAuthz},
+		},
+		"rate limit filters disabled by the rate limit policy": {
+			in: []contour_api_v1.HTTPFilterName{
+				contour_api_v1.HTTPFilterLocalRateLimit,
+				contour_api_v1.HTTPFilterRateLimit,
+			},
+			rlp: &RateLimitPolicy{
+				Local:  &LocalRateLimitPolicy{Disabled: true},
+				Global: &GlobalRateLimitPolicy{Disabled: true},
+			},
+			want: []HTTPFilter{HTTPFilterLocalRateLimit, HTTPFilterRateLimit},
+		},
+		"local rate limit filter disabled with a local rate limit policy": {
+			in: []contour_api_v1.HTTPFilterName{contour_api_v1.HTTPFilterLocalRateLimit},
+			rlp: &RateLimitPolicy{
+				Local: &LocalRateLimitPolicy{MaxTokens: 10},
+			},
+			wantErr: true,
+		},
+		"global rate limit filter disabled with a global rate limit policy": {
+			in: []contour_api_v1.HTTPFilterName{contour_api_v1.HTTPFilterRateLimit},
+			rlp: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{{}},
+				},
+			},
+			wantErr: true,
+		},
+		"unsupported filter": {
+			in:      []contour_api_v1.HTTPFilterName{"compressor"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			filters, err := disabledFilters(tc.in, tc.rlp)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, filters)
+		})
+	}
+}
+
+func TestDNSPolicy(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.DNSPolicy
+		want    *DNSPolicy
+		wantErr bool
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"logical with resolvers": {
+			in: &contour_api_v1.DNSPolicy{
+				DiscoveryType: "Logical",
+				Resolvers:     []string{"10.96.0.10", "10.96.0.11:5353", "[fd00::a]:53"},
+			},
+			want: &DNSPolicy{
+				DiscoveryType: DNSDiscoveryTypeLogical,
+				Resolvers: []DNSResolver{
+					{Address: "10.96.0.10", Port: 53},
+					{Address: "10.96.0.11", Port: 5353},
+					{Address: "fd00::a", Port: 53},
+				},
+			},
+		},
+		"strict": {
+			in: &contour_api_v1.DNSPolicy{
+				DiscoveryType: "Strict",
+			},
+			want: &DNSPolicy{
+				DiscoveryType: DNSDiscoveryTypeStrict,
+			},
+		},
+		"lookup family": {
+			in: &contour_api_v1.DNSPolicy{
+				LookupFamily: "v6",
+			},
+			want: &DNSPolicy{
+				LookupFamily: "v6",
+			},
+		},
+		"invalid discovery type": {
+			in: &contour_api_v1.DNSPolicy{
+				DiscoveryType: "Static",
+			},
+			wantErr: true,
+		},
+		"invalid lookup family": {
+			in: &contour_api_v1.DNSPolicy{
+				LookupFamily: "ipv6",
+			},
+			wantErr: true,
+		},
+		"resolver is not an IP address": {
+			in: &contour_api_v1.DNSPolicy{
+				Resolvers: []string{"kube-dns.kube-system:53"},
+			},
+			wantErr: true,
+		},
+		"resolver port out of range": {
+			in: &contour_api_v1.DNSPolicy{
+				Resolvers: []string{"10.96.0.10:65536"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dp, err := dnsPolicy(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, dp)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"fmt"
+	"sync"
+)
+
+// processorRegistry holds the custom processors registered
+// by programs that embed Contour.
+var processorRegistry struct {
+	mu         sync.Mutex
+	names      map[string]bool
+	processors []namedProcessor
+}
+
+type namedProcessor struct {
+	name      string
+	processor Processor
+}
+
+// RegisterProcessor registers p under name so that it runs as part of
+// every DAG build, after the Ingress, HTTPProxy and Gateway API
+// processors have added their virtual hosts and routes, and before
+// the ACME, filter exempt and listener processors.
+//
+// Registered processors run in registration order. The names in after
+// must refer to processors that have already been registered, so a
+// processor is guaranteed to run after each of them. An error is
+// returned if name is empty or already registered, if p is nil, or
+// if any name in after is unknown.
+func RegisterProcessor(name string, p Processor, after ...string) error {
+	processorRegistry.mu.Lock()
+	defer processorRegistry.mu.Unlock()
+
+	if name == "" {
+		return fmt.Errorf("processor name must be specified")
+	}
+	if p == nil {
+		return fmt.Errorf("processor %q must not be nil", name)
+	}
+	if processorRegistry.names[name] {
+		return fmt.Errorf("processor %q is already registered", name)
+	}
+	for _, dep := range after {
+		if !processorRegistry.names[dep] {
+			return fmt.Errorf("processor %q must be registered after %q, which is not registered", name, dep)
+		}
+	}
+
+	if processorRegistry.names == nil {
+		processorRegistry.names = map[string]bool{}
+	}
+	processorRegistry.names[name] = true
+	processorRegistry.processors = append(processorRegistry.processors, namedProcessor{
+		name:      name,
+		processor: p,
+	})
+	return nil
+}
+
+// RegisteredProcessors returns the registered processors
+// in the order they should run.
+func RegisteredProcessors() []Processor {
+	processorRegistry.mu.Lock()
+	defer processorRegistry.mu.Unlock()
+
+	processors := make([]Processor, 0, len(processorRegistry.processors))
+	for _, np := range processorRegistry.processors {
+		processors = append(processors, np.processor)
+	}
+	return processors
+}
+
+// resetProcessorRegistry removes all registered processors.
+// It is intended for tests.
+func resetProcessorRegistry() {
+	processorRegistry.mu.Lock()
+	defer processorRegistry.mu.Unlock()
+
+	processorRegistry.names = nil
+	processorRegistry.processors = nil
+}
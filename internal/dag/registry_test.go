@@ -0,0 +1,49 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterProcessor(t *testing.T) {
+	defer resetProcessorRegistry()
+
+	var order []string
+	record := func(name string) Processor {
+		return ProcessorFunc(func(*DAG, *KubernetesCache) {
+			order = append(order, name)
+		})
+	}
+
+	require.NoError(t, RegisterProcessor("defaults", record("defaults")))
+	require.NoError(t, RegisterProcessor("audit", record("audit"), "defaults"))
+
+	assert.EqualError(t, RegisterProcessor("", record("empty")),
+		"processor name must be specified")
+	assert.EqualError(t, RegisterProcessor("nil", nil),
+		`processor "nil" must not be nil`)
+	assert.EqualError(t, RegisterProcessor("defaults", record("again")),
+		`processor "defaults" is already registered`)
+	assert.EqualError(t, RegisterProcessor("late", record("late"), "missing"),
+		`processor "late" must be registered after "missing", which is not registered`)
+
+	builder := Builder{Processors: RegisteredProcessors()}
+	builder.Build()
+
+	assert.Equal(t, []string{"defaults", "audit"}, order)
+}
@@ -15,18 +15,268 @@ package dag
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"strings"
 
+	"golang.org/x/crypto/pkcs12"
 	v1 "k8s.io/api/core/v1"
 )
 
 // CACertificateKey is the key name for accessing TLS CA certificate bundles in Kubernetes Secrets.
 const CACertificateKey = "ca.crt"
 
+// PKCS12KeystoreKey is the key name for accessing PKCS#12 keystores in Kubernetes Secrets.
+const PKCS12KeystoreKey = "keystore.p12"
+
+// PKCS12PasswordKey is the key name for accessing the password of a
+// PKCS#12 keystore in Kubernetes Secrets.
+const PKCS12PasswordKey = "keystore.password"
+
+// normalizeSecret returns the secret in the form that Contour serves
+// to Envoy. Generic secrets holding a PKCS#12 keystore are converted
+// to TLS secrets, and the certificates of TLS secrets are reordered
+// so that the serving certificate comes first, followed by each of
+// its issuers in turn. Any other secret is returned unchanged.
+func normalizeSecret(secret *v1.Secret) (*v1.Secret, error) {
+	switch secret.Type {
+	case v1.SecretTypeOpaque, "":
+		keystore, ok := secret.Data[PKCS12KeystoreKey]
+		if !ok {
+			return secret, nil
+		}
+
+		// As for other generic secrets, a PEM certificate or key
+		// means this isn't a secret for Contour.
+		if _, ok := secret.Data[v1.TLSCertKey]; ok {
+			return secret, nil
+		}
+		if _, ok := secret.Data[v1.TLSPrivateKeyKey]; ok {
+			return secret, nil
+		}
+
+		cert, key, err := decodePKCS12(keystore, string(secret.Data[PKCS12PasswordKey]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#12 keystore: %v", err)
+		}
+
+		converted := secret.DeepCopy()
+		converted.Type = v1.SecretTypeTLS
+		converted.Data = map[string][]byte{
+			v1.TLSCertKey:       cert,
+			v1.TLSPrivateKeyKey: key,
+		}
+		if ca, ok := secret.Data[CACertificateKey]; ok {
+			converted.Data[CACertificateKey] = ca
+		}
+		secret = converted
+	case v1.SecretTypeTLS:
+	default:
+		return secret, nil
+	}
+
+	// Certificates that can't be parsed are left for
+	// isValidSecret to report.
+	certs, err := parseCertificates(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		return secret, nil
+	}
+
+	ordered := orderCertificates(certs, secret.Data[v1.TLSPrivateKeyKey])
+	if sameOrder(certs, ordered) {
+		return secret, nil
+	}
+
+	var data []byte
+	for _, cert := range ordered {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+
+	normalized := secret.DeepCopy()
+	normalized.Data[v1.TLSCertKey] = data
+	return normalized, nil
+}
+
+// decodePKCS12 decodes a PKCS#12 keystore, returning its certificates
+// and its private key as PEM.
+func decodePKCS12(keystore []byte, password string) ([]byte, []byte, error) {
+	blocks, err := pkcs12.ToPEM(keystore, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cert, key []byte
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert = append(cert, pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes})...)
+		case "PRIVATE KEY":
+			if key != nil {
+				return nil, nil, errors.New("multiple private keys")
+			}
+
+			// ToPEM labels every key as a PKCS#8 key, but
+			// encodes it in its PKCS#1 or SEC 1 form.
+			pk, err := parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			der, err := x509.MarshalPKCS8PrivateKey(pk)
+			if err != nil {
+				return nil, nil, err
+			}
+			key = pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der})
+		}
+	}
+
+	if cert == nil {
+		return nil, nil, errors.New("failed to locate certificate")
+	}
+	if key == nil {
+		return nil, nil, errors.New("failed to locate private key")
+	}
+
+	return cert, key, nil
+}
+
+// parsePrivateKey parses a DER private key in any of the
+// PKCS#1, PKCS#8 or SEC 1 forms.
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key")
+}
+
+// publicKeyOf returns the public key of the first private key of a PEM
+// bundle, or nil if it has no private key that can be parsed.
+func publicKeyOf(data []byte) crypto.PublicKey {
+	for containsPEMHeader(data) {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if key, err := parsePrivateKey(block.Bytes); err == nil {
+			if signer, ok := key.(crypto.Signer); ok {
+				return signer.Public()
+			}
+		}
+	}
+	return nil
+}
+
+// parseCertificates parses the certificates of a PEM bundle.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for containsPEMHeader(data) {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("failed to parse PEM block")
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected block type '%s'", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// issuedBy returns true if cert was signed by issuer.
+func issuedBy(cert, issuer *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, issuer.RawSubject) && cert.CheckSignatureFrom(issuer) == nil
+}
+
+// orderCertificates orders certs from the certificate of the private
+// key in the PEM bundle key, or the first certificate if there is no
+// such certificate, up through each of its issuers. Certificates that
+// are not in that chain are kept after it, in their original order.
+func orderCertificates(certs []*x509.Certificate, key []byte) []*x509.Certificate {
+	if len(certs) == 0 {
+		return certs
+	}
+
+	leaf := 0
+	if pub, ok := publicKeyOf(key).(interface{ Equal(crypto.PublicKey) bool }); ok {
+		for i, cert := range certs {
+			if pub.Equal(cert.PublicKey) {
+				leaf = i
+				break
+			}
+		}
+	}
+
+	ordered := []*x509.Certificate{certs[leaf]}
+	remaining := make([]*x509.Certificate, 0, len(certs)-1)
+	remaining = append(remaining, certs[:leaf]...)
+	remaining = append(remaining, certs[leaf+1:]...)
+
+	for {
+		last := ordered[len(ordered)-1]
+		next := -1
+		for i, cert := range remaining {
+			if issuedBy(last, cert) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		ordered = append(ordered, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	return append(ordered, remaining...)
+}
+
+func sameOrder(a, b []*x509.Certificate) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCertificateChain validates that each certificate of a PEM
+// bundle, after the first, is the issuer of the certificate before it.
+// Since normalizeSecret orders the chain of the serving certificate
+// first, a certificate outside of that chain usually means that an
+// intermediate certificate is missing from the bundle.
+func validateCertificateChain(data []byte) error {
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < len(certs); i++ {
+		if !issuedBy(certs[i-1], certs[i]) {
+			return fmt.Errorf("incomplete certificate chain: certificate %q is not the issuer of certificate %q, an intermediate certificate may be missing",
+				certs[i].Subject, certs[i-1].Subject)
+		}
+	}
+
+	return nil
+}
+
 // isValidSecret returns true if the secret is interesting and well
 // formed. TLS certificate/key pairs must be secrets of type
 // "kubernetes.io/tls". Certificate bundles may be "kubernetes.io/tls"
@@ -14,12 +14,14 @@
 package dag
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -229,6 +231,135 @@ func TestIsValidSecret(t *testing.T) {
 	}
 }
 
+func TestNormalizeSecret(t *testing.T) {
+	tests := map[string]struct {
+		secret *v1.Secret
+		want   *v1.Secret
+	}{
+		"TLS Secret, ordered chain": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.WILDCARD_CERT, fixture.CA_CERT), fixture.WILDCARD_KEY),
+			},
+			want: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.WILDCARD_CERT, fixture.CA_CERT), fixture.WILDCARD_KEY),
+			},
+		},
+		"TLS Secret, issuer before serving certificate": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.CA_CERT, fixture.WILDCARD_CERT), fixture.WILDCARD_KEY),
+			},
+			want: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.WILDCARD_CERT, fixture.CA_CERT), fixture.WILDCARD_KEY),
+			},
+		},
+		"TLS Secret, unrelated certificate after serving certificate": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.CERTIFICATE, fixture.CA_CERT), fixture.RSA_PRIVATE_KEY),
+			},
+			want: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.CERTIFICATE, fixture.CA_CERT), fixture.RSA_PRIVATE_KEY),
+			},
+		},
+		"TLS Secret, unrelated certificate before serving certificate": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.CA_CERT, fixture.CERTIFICATE), fixture.RSA_PRIVATE_KEY),
+			},
+			want: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(pemBundle(fixture.CERTIFICATE, fixture.CA_CERT), fixture.RSA_PRIVATE_KEY),
+			},
+		},
+		"Opaque Secret, CA Cert": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeOpaque,
+				Data: map[string][]byte{
+					CACertificateKey: []byte(fixture.CA_CERT),
+				},
+			},
+			want: &v1.Secret{
+				Type: v1.SecretTypeOpaque,
+				Data: map[string][]byte{
+					CACertificateKey: []byte(fixture.CA_CERT),
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := normalizeSecret(tc.secret)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNormalizePKCS12Secret(t *testing.T) {
+	keystore, err := base64.StdEncoding.DecodeString(fixture.PKCS12_KEYSTORE)
+	require.NoError(t, err)
+
+	secret := &v1.Secret{
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			PKCS12KeystoreKey: keystore,
+			PKCS12PasswordKey: []byte("contour"),
+		},
+	}
+
+	got, err := normalizeSecret(secret)
+	require.NoError(t, err)
+
+	assert.Equal(t, v1.SecretTypeTLS, got.Type)
+	assert.Equal(t, pemBundle(fixture.WILDCARD_CERT, fixture.CA_CERT), string(got.Data[v1.TLSCertKey]))
+	assert.Equal(t, publicKeyOf([]byte(fixture.WILDCARD_KEY)), publicKeyOf(got.Data[v1.TLSPrivateKeyKey]))
+	assert.NotContains(t, got.Data, PKCS12KeystoreKey)
+	assert.NotContains(t, got.Data, PKCS12PasswordKey)
+
+	valid, err := isValidSecret(got)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+	assert.NoError(t, validSecret(got))
+
+	// The keystore of the original secret is left alone.
+	assert.Equal(t, v1.SecretTypeOpaque, secret.Type)
+	assert.Equal(t, keystore, secret.Data[PKCS12KeystoreKey])
+
+	secret.Data[PKCS12PasswordKey] = []byte("wrong")
+	_, err = normalizeSecret(secret)
+	assert.Error(t, err)
+}
+
+func TestValidateCertificateChain(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		err  error
+	}{
+		"single certificate": {
+			data: fixture.CERTIFICATE,
+		},
+		"certificate plus issuer": {
+			data: pemBundle(fixture.WILDCARD_CERT, fixture.CA_CERT),
+		},
+		"certificate plus unrelated certificate": {
+			data: pemBundle(fixture.CERTIFICATE, fixture.CA_CERT),
+			err:  errors.New(`incomplete certificate chain: certificate "CN=contour,O=Project Contour" is not the issuer of certificate "CN=boring-wozniak.example.com", an intermediate certificate may be missing`),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.err, validateCertificateChain([]byte(tc.data)))
+		})
+	}
+}
+
 func secretdata(cert, key string) map[string][]byte {
 	return map[string][]byte{
 		v1.TLSCertKey:       []byte(cert),
@@ -15,6 +15,7 @@ package dag
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -37,6 +38,7 @@ func TestDAGStatus(t *testing.T) {
 	type testcase struct {
 		objs                []interface{}
 		fallbackCertificate *types.NamespacedName
+		maxResponseTimeout  time.Duration
 		want                map[types.NamespacedName]contour_api_v1.DetailedCondition
 	}
 
@@ -55,6 +57,7 @@ func TestDAGStatus(t *testing.T) {
 					},
 					&HTTPProxyProcessor{
 						FallbackCertificate: tc.fallbackCertificate,
+						MaxResponseTimeout:  tc.maxResponseTimeout,
 					},
 					&GatewayAPIProcessor{
 						FieldLogger: fixture.NewTestLogger(t),
@@ -682,7 +685,8 @@ func TestDAGStatus(t *testing.T) {
 				WithError(contour_api_v1.ConditionTypeVirtualHostError, "FQDNNotSpecified", "Spec.VirtualHost.Fqdn must be specified"),
 			{Name: proxyIncludedChildValid.Name, Namespace: proxyIncludedChildValid.Namespace}: fixture.NewValidCondition().
 				WithGeneration(proxyIncludedChildValid.Generation).
-				Orphaned(),
+				OrphanedWithWarning("ParentNotIncluded",
+					"HTTPProxy roots/invalidParent includes this HTTPProxy, but is neither a valid root HTTPProxy nor part of a delegation chain from one"),
 		},
 	})
 
@@ -860,6 +864,37 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	proxyAliasExampleCom := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "alias-example",
+			Namespace: "roots",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:    "example.net",
+				Aliases: []string{"example.com"},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "conflicting proxies due to alias reuse", testcase{
+		objs: []interface{}{proxyValidExampleCom, proxyAliasExampleCom},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyValidExampleCom.Name, Namespace: proxyValidExampleCom.Namespace}: fixture.NewValidCondition().
+				WithGeneration(proxyValidExampleCom.Generation).
+				WithError(contour_api_v1.ConditionTypeVirtualHostError, "DuplicateVhost", `fqdn "example.com" is used in multiple HTTPProxies: roots/alias-example, roots/example-com`),
+			{Name: proxyAliasExampleCom.Name, Namespace: proxyAliasExampleCom.Namespace}: fixture.NewValidCondition().
+				WithGeneration(proxyAliasExampleCom.Generation).
+				WithError(contour_api_v1.ConditionTypeVirtualHostError, "DuplicateVhost", `fqdn "example.com" is used in multiple HTTPProxies: roots/alias-example, roots/example-com`),
+		},
+	})
+
 	proxyRootIncludesRoot := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "root-blog",
@@ -1176,7 +1211,9 @@ func TestDAGStatus(t *testing.T) {
 				WithGeneration(proxyInvalidDuplicateIncludeCondtionHeaders.Generation).WithError(contour_api_v1.ConditionTypeRouteError, "HeaderMatchConditionsNotValid", "cannot specify duplicate header 'exact match' conditions in the same route"),
 			{Name: proxyValidDelegatedRoots.Name,
 				Namespace: proxyValidDelegatedRoots.Namespace}: fixture.NewValidCondition().
-				WithGeneration(proxyValidDelegatedRoots.Generation).Orphaned(),
+				WithGeneration(proxyValidDelegatedRoots.Generation).
+				OrphanedWithWarning("IncludeNotValid",
+					"HTTPProxy roots/example includes this HTTPProxy, but the include is not valid: cannot specify duplicate header 'exact match' conditions in the same route"),
 		},
 	})
 
@@ -1305,7 +1342,8 @@ func TestDAGStatus(t *testing.T) {
 				WithError(contour_api_v1.ConditionTypeIncludeError, "PathMatchConditionsNotValid", "include: more than one prefix is not allowed in a condition block"),
 			{Name: proxyValidChildTeamA.Name, Namespace: proxyValidChildTeamA.Namespace}: fixture.NewValidCondition().
 				WithGeneration(proxyValidChildTeamA.Generation).
-				Orphaned(),
+				OrphanedWithWarning("IncludeNotValid",
+					"HTTPProxy roots/www includes this HTTPProxy, but the include is not valid: more than one prefix is not allowed in a condition block"),
 		},
 	})
 
@@ -1374,7 +1412,41 @@ func TestDAGStatus(t *testing.T) {
 			{Name: proxyInvalidIncludePrefixNoSlash.Name, Namespace: proxyInvalidIncludePrefixNoSlash.Namespace}: fixture.NewValidCondition().
 				WithError(contour_api_v1.ConditionTypeIncludeError, "PathMatchConditionsNotValid", "include: prefix conditions must start with /, api was supplied"),
 			{Name: proxyValidChildTeamA.Name, Namespace: proxyValidChildTeamA.Namespace}: fixture.NewValidCondition().
-				Orphaned(),
+				OrphanedWithWarning("IncludeNotValid",
+					"HTTPProxy roots/www includes this HTTPProxy, but the include is not valid: prefix conditions must start with /, api was supplied"),
+		},
+	})
+
+	proxyIncludeWrongNamespace := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "www",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []contour_api_v1.Include{{
+				Name:      "child",
+				Namespace: "teamb",
+			}},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy includes child from the wrong namespace", testcase{
+		objs: []interface{}{proxyIncludeWrongNamespace, proxyValidChildTeamA, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyIncludeWrongNamespace.Name, Namespace: proxyIncludeWrongNamespace.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeIncludeError, "IncludeNotFound", "include teamb/child not found"),
+			{Name: proxyValidChildTeamA.Name, Namespace: proxyValidChildTeamA.Namespace}: fixture.NewValidCondition().
+				OrphanedWithWarning("IncludeNotFound",
+					"HTTPProxy roots/www includes teamb/child, which does not exist; did you mean this HTTPProxy?"),
 		},
 	})
 
@@ -2070,6 +2142,43 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	optionalClientValidationSkipped := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: "ssl-cert",
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						Mode:                     contour_api_v1.ClientCertificateModeOptional,
+						SkipClientCertValidation: true,
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "clientValidation optional mode with skipClientCertValidation", testcase{
+		objs: []interface{}{optionalClientValidationSkipped, fixture.SecretRootsCert, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: optionalClientValidationSkipped.Name,
+				Namespace: optionalClientValidationSkipped.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid", `Spec.VirtualHost.TLS client validation is invalid: mode "Optional" cannot be combined with skipClientCertValidation`),
+		},
+	})
+
 	fallbackCertificateWithClientValidation := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: "roots",
@@ -2552,6 +2661,42 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	excessiveResponseTimeout := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "excessive-timeouts",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{
+				{
+					Services: []contour_api_v1.Service{
+						{
+							Name: fixture.ServiceRootsKuard.Name,
+						},
+					},
+					TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+						Response: "5m",
+					},
+				},
+			},
+		},
+	}
+
+	run(t, "proxy with response timeout above the configured maximum is invalid", testcase{
+		objs:               []interface{}{excessiveResponseTimeout, fixture.ServiceRootsKuard},
+		maxResponseTimeout: time.Minute,
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      excessiveResponseTimeout.Name,
+				Namespace: excessiveResponseTimeout.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "TimeoutPolicyExceedsLimit",
+				`route.timeoutPolicy response timeout 5m0s exceeds the maximum of 1m0s`),
+		},
+	})
+
 	// issue 3197: Fallback and passthrough HTTPProxy directive should emit a config error
 	tlsPassthroughAndFallback := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2625,6 +2770,104 @@ func TestDAGStatus(t *testing.T) {
 	})
 }
 
+func TestDAGStatusRouteConflict(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "home",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	route := func(prefix string) contour_api_v1.Route {
+		return contour_api_v1.Route{
+			Conditions: []contour_api_v1.MatchCondition{{Prefix: prefix}},
+			Services:   []contour_api_v1.Service{{Name: "home", Port: 8080}},
+		}
+	}
+
+	// child is older than parent, so its route wins.
+	child := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "child",
+			Namespace:         "roots",
+			CreationTimestamp: metav1.NewTime(time.Unix(100, 0)),
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{route("/foo")},
+		},
+	}
+
+	parent := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "parent",
+			Namespace:         "roots",
+			CreationTimestamp: metav1.NewTime(time.Unix(200, 0)),
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "example.com"},
+			Includes: []contour_api_v1.Include{{
+				Name:      "child",
+				Namespace: "roots",
+			}},
+			Routes: []contour_api_v1.Route{route("/foo"), route("/bar")},
+		},
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+	for _, o := range []interface{}{svc, child, parent} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	got := map[types.NamespacedName]*status.ProxyUpdate{}
+	for _, pu := range dag.StatusCache.GetProxyUpdates() {
+		got[pu.Fullname] = pu
+	}
+
+	// Both proxies remain valid.
+	for _, name := range []types.NamespacedName{
+		{Namespace: "roots", Name: "child"},
+		{Namespace: "roots", Name: "parent"},
+	} {
+		if assert.Contains(t, got, name) {
+			assert.Equal(t, contour_api_v1.ConditionTrue, got[name].Conditions[status.ValidCondition].Status)
+		}
+	}
+
+	// Only the younger parent is conflicted.
+	assert.NotContains(t, got[types.NamespacedName{Namespace: "roots", Name: "child"}].Conditions, status.ConflictedCondition)
+
+	conflicted := got[types.NamespacedName{Namespace: "roots", Name: "parent"}].Conditions[status.ConflictedCondition]
+	if assert.NotNil(t, conflicted) {
+		assert.Equal(t, contour_api_v1.ConditionTrue, conflicted.Status)
+		if assert.Len(t, conflicted.Errors, 1) {
+			assert.Equal(t, "RouteConflict", conflicted.Errors[0].Reason)
+			assert.Contains(t, conflicted.Errors[0].Message, "roots/child")
+		}
+	}
+
+	// The unconflicted route of the parent is still programmed.
+	vh := dag.GetVirtualHost("example.com")
+	if assert.NotNil(t, vh) {
+		assert.Len(t, vh.Routes, 2)
+	}
+}
+
 func validGatewayStatusUpdate(listenerName string, kind gatewayapi_v1alpha2.Kind, attachedRoutes int) []*status.GatewayStatusUpdate {
 	return []*status.GatewayStatusUpdate{
 		{
@@ -3738,6 +3981,60 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 			},
 		}},
 	})
+
+	run(t, "invalid allowed route kind sets the listener's ResolvedRefs condition", testcase{
+		objs: []interface{}{},
+		gateway: &gatewayapi_v1alpha2.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "contour",
+				Namespace: "projectcontour",
+			},
+			Spec: gatewayapi_v1alpha2.GatewaySpec{
+				Listeners: []gatewayapi_v1alpha2.Listener{{
+					Name:     "http",
+					Port:     80,
+					Protocol: gatewayapi_v1alpha2.HTTPProtocolType,
+					AllowedRoutes: &gatewayapi_v1alpha2.AllowedRoutes{
+						Namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
+							From: gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromAll),
+						},
+						Kinds: []gatewayapi_v1alpha2.RouteGroupKind{
+							{Kind: "HTTPRoute"},
+							{Kind: "FooRoute"},
+						},
+					},
+				}},
+			},
+		},
+		wantGatewayStatusUpdate: []*status.GatewayStatusUpdate{{
+			FullName: types.NamespacedName{Namespace: "projectcontour", Name: "contour"},
+			Conditions: map[gatewayapi_v1alpha2.GatewayConditionType]metav1.Condition{
+				gatewayapi_v1alpha2.GatewayConditionReady: {
+					Type:    string(gatewayapi_v1alpha2.GatewayConditionReady),
+					Status:  contour_api_v1.ConditionTrue,
+					Reason:  status.ReasonValidGateway,
+					Message: status.MessageValidGateway,
+				},
+			},
+			ListenerStatus: map[string]*gatewayapi_v1alpha2.ListenerStatus{
+				"http": {
+					Name: "http",
+					SupportedKinds: []gatewayapi_v1alpha2.RouteGroupKind{
+						{
+							Group: gatewayapi.GroupPtr(gatewayapi_v1alpha2.GroupName),
+							Kind:  gatewayapi_v1alpha2.Kind("HTTPRoute"),
+						},
+					},
+					Conditions: []metav1.Condition{{
+						Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
+						Status:  metav1.ConditionFalse,
+						Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidRouteKinds),
+						Message: "Listener.AllowedRoutes.Kind \"FooRoute\" not supported",
+					}},
+				},
+			},
+		}},
+	})
 }
 
 func TestGatewayAPITLSRouteDAGStatus(t *testing.T) {
@@ -12,15 +12,21 @@
 // limitations under the License.
 
 // Package debug provides http endpoints for healthcheck, metrics,
-// and pprof debugging.
+// pprof debugging, runtime log level changes, leader election state,
+// readiness, TLS configuration reports, routing explanations, route
+// tables of virtual hosts, reports of ignored objects, the running
+// configuration and the xDS streams of the connected Envoys.
 package debug
 
 import (
 	"net/http"
 	"net/http/pprof"
 
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/httpsvc"
+	"github.com/projectcontour/contour/internal/xds"
+	"github.com/sirupsen/logrus"
 )
 
 // Service serves various http endpoints including /debug/pprof.
@@ -28,6 +34,43 @@ type Service struct {
 	httpsvc.Service
 
 	Builder *dag.Builder
+
+	// Logger is the logger whose level can be changed
+	// at runtime via the /debug/loglevel endpoint. If nil,
+	// the endpoint is not registered.
+	Logger *logrus.Logger
+
+	// LogLevelToken, if set, is the bearer token required
+	// to access the /debug/loglevel endpoint.
+	LogLevelToken string
+
+	// Leadership, if set, serves the leader election
+	// state at the /debug/leadership endpoint.
+	Leadership http.Handler
+
+	// Readiness, if set, serves the readiness stage
+	// at the /debug/readiness endpoint.
+	Readiness http.Handler
+
+	// MinimumTLSVersion and CipherSuites are the TLS
+	// defaults of the Envoy listeners, reported by the
+	// /debug/tls endpoint.
+	MinimumTLSVersion string
+	CipherSuites      []string
+
+	// XDSLagging, if set, serves the Envoys that have not
+	// applied the latest xDS snapshot at /debug/xds/lagging.
+	XDSLagging http.Handler
+
+	// XDSConnections, if set, are the xDS streams of the
+	// connected Envoys, reported at /debug/xds. If the debug
+	// service requires a token, they can also be disconnected
+	// at /debug/xds/disconnect.
+	XDSConnections *xds.Connections
+
+	// Configuration, if set, is the ContourConfiguration
+	// served at the /debug/config endpoint.
+	Configuration *contour_api_v1alpha1.ContourConfigurationSpec
 }
 
 // Start fulfills the g.Start contract.
@@ -35,6 +78,35 @@ type Service struct {
 func (svc *Service) Start(stop <-chan struct{}) error {
 	registerProfile(&svc.ServeMux)
 	registerDotWriter(&svc.ServeMux, svc.Builder)
+	registerLogLevel(&svc.ServeMux, svc.Logger, svc.LogLevelToken)
+	svc.ServeMux.Handle(TLSReportPath, &tlsReportHandler{
+		builder:       svc.Builder,
+		minTLSVersion: svc.MinimumTLSVersion,
+		cipherSuites:  svc.CipherSuites,
+	})
+	svc.ServeMux.Handle(WhyPath, &whyHandler{builder: svc.Builder})
+	svc.ServeMux.Handle(IgnoredPath, &ignoredHandler{builder: svc.Builder})
+	svc.ServeMux.Handle(RoutesPath, &routesHandler{builder: svc.Builder})
+	if svc.Configuration != nil {
+		svc.ServeMux.Handle(ConfigPath, &configHandler{config: svc.Configuration})
+	}
+	if svc.Leadership != nil {
+		svc.ServeMux.Handle("/debug/leadership", svc.Leadership)
+	}
+	if svc.Readiness != nil {
+		svc.ServeMux.Handle("/debug/readiness", svc.Readiness)
+	}
+	if svc.XDSConnections != nil {
+		svc.ServeMux.Handle(XDSPath, &xdsHandler{connections: svc.XDSConnections})
+		// Disconnecting Envoys disrupts them, so it
+		// isn't offered by an unauthenticated service.
+		if svc.Token != "" {
+			svc.ServeMux.Handle(XDSDisconnectPath, &xdsDisconnectHandler{connections: svc.XDSConnections})
+		}
+	}
+	if svc.XDSLagging != nil {
+		svc.ServeMux.Handle("/debug/xds/lagging", svc.XDSLagging)
+	}
 	return svc.Service.Start(stop)
 }
 
@@ -0,0 +1,84 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/projectcontour/contour/internal/dag"
+)
+
+// IgnoredPath is the path of the ignored objects report endpoint.
+const IgnoredPath = "/debug/ignored"
+
+// IgnoredReport lists the Kubernetes objects that Contour
+// does not program, such as root HTTPProxies outside the
+// root namespaces and orphaned HTTPProxies.
+type IgnoredReport struct {
+	Objects []IgnoredObject `json:"objects"`
+}
+
+// IgnoredObject is a Kubernetes object that is not
+// programmed, and why.
+type IgnoredObject struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+// ignoredHandler serves the ignored objects report of the DAG.
+type ignoredHandler struct {
+	builder *dag.Builder
+}
+
+func (h *ignoredHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Ignored(h.builder.Build())); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Ignored returns the ignored objects report of the DAG,
+// sorted by kind, namespace and name.
+func Ignored(d *dag.DAG) *IgnoredReport {
+	report := &IgnoredReport{
+		Objects: []IgnoredObject{},
+	}
+	for _, ignored := range d.Ignored {
+		report.Objects = append(report.Objects, IgnoredObject{
+			Kind:      ignored.Kind,
+			Namespace: ignored.Namespace,
+			Name:      ignored.Name,
+			Reason:    ignored.Reason,
+		})
+	}
+
+	sort.SliceStable(report.Objects, func(i, j int) bool {
+		a, b := report.Objects[i], report.Objects[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	return report
+}
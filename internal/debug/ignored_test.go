@@ -0,0 +1,57 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnored(t *testing.T) {
+	d := &dag.DAG{
+		Ignored: []dag.IgnoredObject{{
+			Origin: dag.Origin{Kind: "HTTPProxy", Namespace: "teams", Name: "www"},
+			Reason: "root HTTPProxy is not in a root namespace",
+		}, {
+			Origin: dag.Origin{Kind: "Ingress", Namespace: "teams", Name: "kuard"},
+			Reason: "Ingress is not in a root namespace",
+		}, {
+			Origin: dag.Origin{Kind: "HTTPProxy", Namespace: "default", Name: "blog"},
+			Reason: "HTTPProxy is not included by a root HTTPProxy",
+		}},
+	}
+
+	assert.Equal(t, &IgnoredReport{
+		Objects: []IgnoredObject{{
+			Kind:      "HTTPProxy",
+			Namespace: "default",
+			Name:      "blog",
+			Reason:    "HTTPProxy is not included by a root HTTPProxy",
+		}, {
+			Kind:      "HTTPProxy",
+			Namespace: "teams",
+			Name:      "www",
+			Reason:    "root HTTPProxy is not in a root namespace",
+		}, {
+			Kind:      "Ingress",
+			Namespace: "teams",
+			Name:      "kuard",
+			Reason:    "Ingress is not in a root namespace",
+		}},
+	}, Ignored(d))
+
+	assert.Equal(t, &IgnoredReport{Objects: []IgnoredObject{}}, Ignored(&dag.DAG{}))
+}
@@ -0,0 +1,85 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/projectcontour/contour/internal/httpsvc"
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevelPath is the path of the runtime log level endpoint.
+const LogLevelPath = "/debug/loglevel"
+
+// logLevelHandler reports and updates the level of a logrus.Logger.
+//
+// GET returns the current level. PUT or POST with a "level" query
+// or form parameter changes it. If token is non-empty, requests
+// must present it as a bearer token in the Authorization header.
+// If token is empty, the level can be read but not changed.
+type logLevelHandler struct {
+	logger *logrus.Logger
+	token  string
+}
+
+func (h *logLevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="contour"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		if h.token == "" {
+			http.Error(w, "changing the log level requires a debug log level token", http.StatusForbidden)
+			return
+		}
+
+		level, err := logrus.ParseLevel(r.FormValue("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if level != h.logger.GetLevel() {
+			h.logger.WithField("level", level).WithField("previous", h.logger.GetLevel()).Info("changing log level")
+			h.logger.SetLevel(level)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, h.logger.GetLevel())
+}
+
+func (h *logLevelHandler) authorized(r *http.Request) bool {
+	return httpsvc.Authorized(r, h.token)
+}
+
+func registerLogLevel(mux *http.ServeMux, logger *logrus.Logger, token string) {
+	if logger == nil {
+		return
+	}
+
+	mux.Handle(LogLevelPath, &logLevelHandler{
+		logger: logger,
+		token:  token,
+	})
+}
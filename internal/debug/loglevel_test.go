@@ -0,0 +1,122 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	type testcase struct {
+		method    string
+		query     string
+		token     string
+		wantCode  int
+		wantLevel logrus.Level
+	}
+
+	run := func(t *testing.T, name string, tc testcase) {
+		t.Helper()
+
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+
+			log := logrus.New()
+			log.SetLevel(logrus.InfoLevel)
+
+			h := &logLevelHandler{logger: log, token: "secret"}
+
+			req := httptest.NewRequest(tc.method, LogLevelPath+tc.query, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantLevel, log.GetLevel())
+			if rec.Code == http.StatusOK {
+				assert.Equal(t, tc.wantLevel.String(), strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+
+	run(t, "get level", testcase{
+		method:    http.MethodGet,
+		token:     "secret",
+		wantCode:  http.StatusOK,
+		wantLevel: logrus.InfoLevel,
+	})
+
+	run(t, "set level", testcase{
+		method:    http.MethodPut,
+		query:     "?level=debug",
+		token:     "secret",
+		wantCode:  http.StatusOK,
+		wantLevel: logrus.DebugLevel,
+	})
+
+	run(t, "invalid level", testcase{
+		method:    http.MethodPost,
+		query:     "?level=verbose",
+		token:     "secret",
+		wantCode:  http.StatusBadRequest,
+		wantLevel: logrus.InfoLevel,
+	})
+
+	run(t, "missing token", testcase{
+		method:    http.MethodPut,
+		query:     "?level=debug",
+		wantCode:  http.StatusUnauthorized,
+		wantLevel: logrus.InfoLevel,
+	})
+
+	run(t, "wrong token", testcase{
+		method:    http.MethodPut,
+		query:     "?level=debug",
+		token:     "guess",
+		wantCode:  http.StatusUnauthorized,
+		wantLevel: logrus.InfoLevel,
+	})
+
+	run(t, "bad method", testcase{
+		method:    http.MethodDelete,
+		token:     "secret",
+		wantCode:  http.StatusMethodNotAllowed,
+		wantLevel: logrus.InfoLevel,
+	})
+}
+
+func TestLogLevelHandlerWithoutToken(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+
+	h := &logLevelHandler{logger: log}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LogLevelPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "info", strings.TrimSpace(rec.Body.String()))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, LogLevelPath+"?level=debug", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, logrus.InfoLevel, log.GetLevel())
+}
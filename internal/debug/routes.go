@@ -0,0 +1,141 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/projectcontour/contour/internal/dag"
+)
+
+// RoutesPath is the path prefix of the endpoint that reports
+// the route table of a virtual host, given as the rest of the path.
+const RoutesPath = "/debug/routes/"
+
+// RoutesReport is the effective route table of a virtual host.
+type RoutesReport struct {
+	Host string `json:"host"`
+
+	// VirtualHosts are the virtual hosts that serve the Host,
+	// over HTTP and over TLS.
+	VirtualHosts []RoutesVirtualHost `json:"virtualHosts"`
+}
+
+// RoutesVirtualHost is the route table of a virtual host.
+type RoutesVirtualHost struct {
+	Name   string `json:"name"`
+	Secure bool   `json:"secure,omitempty"`
+
+	// Routes are the routes of the virtual host, in the order
+	// that Envoy matches them against a request.
+	Routes []RoutesEntry `json:"routes"`
+}
+
+// RoutesEntry is a route of a route table.
+type RoutesEntry struct {
+	Origin string `json:"origin,omitempty"`
+
+	// Match is the type of the path match: "prefix",
+	// "segment prefix", "exact" or "regex".
+	Match    string       `json:"match"`
+	Value    string       `json:"value"`
+	Headers  []string     `json:"headers,omitempty"`
+	Priority int32        `json:"priority,omitempty"`
+	Action   string       `json:"action"`
+	Clusters []WhyCluster `json:"clusters,omitempty"`
+}
+
+// routesHandler reports the route table of the virtual
+// host named by the path of the request.
+type routesHandler struct {
+	builder *dag.Builder
+}
+
+func (h *routesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := strings.TrimPrefix(r.URL.Path, RoutesPath)
+	if host == "" || strings.Contains(host, "/") {
+		http.Error(w, "missing or invalid host in path, expected "+RoutesPath+"<fqdn>", http.StatusBadRequest)
+		return
+	}
+
+	report := Routes(h.builder.Build(), host)
+	if len(report.VirtualHosts) == 0 {
+		http.Error(w, "no virtual host serves "+report.Host, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Routes returns the route tables of the virtual hosts of
+// the DAG that serve host, in the order that Envoy matches
+// the routes against a request.
+func Routes(d *dag.DAG, host string) *RoutesReport {
+	report := &RoutesReport{
+		Host:         strings.ToLower(host),
+		VirtualHosts: []RoutesVirtualHost{},
+	}
+
+	for _, secure := range []bool{false, true} {
+		vh := findVirtualHost(d, report.Host, secure)
+		if vh == nil {
+			continue
+		}
+
+		table := RoutesVirtualHost{
+			Name:   vh.Name,
+			Secure: secure,
+			Routes: []RoutesEntry{},
+		}
+		for _, r := range sortedRoutes(vh) {
+			match, value := pathMatch(r.PathMatchCondition)
+			table.Routes = append(table.Routes, RoutesEntry{
+				Origin:   origin(d, r),
+				Match:    match,
+				Value:    value,
+				Headers:  headerConditions(r),
+				Priority: r.Priority,
+				Action:   action(r, secure),
+				Clusters: clusters(r),
+			})
+		}
+		report.VirtualHosts = append(report.VirtualHosts, table)
+	}
+
+	return report
+}
+
+// pathMatch returns the type and value of a path match condition.
+func pathMatch(mc dag.MatchCondition) (string, string) {
+	switch c := mc.(type) {
+	case *dag.PrefixMatchCondition:
+		if c.PrefixMatchType == dag.PrefixMatchSegment {
+			return "segment prefix", c.Prefix
+		}
+		return "prefix", c.Prefix
+	case *dag.ExactMatchCondition:
+		return "exact", c.Path
+	case *dag.RegexMatchCondition:
+		return "regex", c.Regex
+	default:
+		return "", ""
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestRoutes(t *testing.T) {
+	cluster := func(name string, weight uint32) *dag.Cluster {
+		return &dag.Cluster{
+			Upstream: &dag.Service{
+				Weighted: dag.WeightedService{
+					ServiceName:      name,
+					ServiceNamespace: "default",
+					ServicePort:      v1.ServicePort{Port: 80},
+				},
+			},
+			Weight: weight,
+		}
+	}
+
+	root := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		Clusters:           []*dag.Cluster{cluster("web", 0)},
+	}
+	api := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api", PrefixMatchType: dag.PrefixMatchSegment},
+		Clusters:           []*dag.Cluster{cluster("api", 0)},
+	}
+	canary := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api", PrefixMatchType: dag.PrefixMatchSegment},
+		HeaderMatchConditions: []dag.HeaderMatchCondition{{
+			Name:      "X-Canary",
+			MatchType: dag.HeaderMatchTypePresent,
+		}},
+		Clusters: []*dag.Cluster{cluster("api-canary", 10), cluster("api", 90)},
+	}
+	health := &dag.Route{
+		PathMatchCondition: &dag.ExactMatchCondition{Path: "/healthz"},
+		DirectResponse:     &dag.DirectResponse{StatusCode: 200},
+	}
+	legacy := &dag.Route{
+		PathMatchCondition: &dag.RegexMatchCondition{Regex: "/v1/.*"},
+		Priority:           10,
+		Clusters:           []*dag.Cluster{cluster("legacy", 0)},
+	}
+
+	d := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: "ingress_http",
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "www.example.com",
+				Routes: map[string]*dag.Route{
+					"root":   root,
+					"api":    api,
+					"canary": canary,
+					"health": health,
+					"legacy": legacy,
+				},
+			}},
+		}},
+		Origins: map[*dag.Route]dag.Origin{
+			root:   {Kind: "HTTPProxy", Namespace: "default", Name: "www"},
+			api:    {Kind: "HTTPProxy", Namespace: "default", Name: "api"},
+			canary: {Kind: "HTTPProxy", Namespace: "default", Name: "api"},
+		},
+	}
+
+	assert.Equal(t, &RoutesReport{
+		Host: "www.example.com",
+		VirtualHosts: []RoutesVirtualHost{{
+			Name: "www.example.com",
+			Routes: []RoutesEntry{{
+				Match:    "regex",
+				Value:    "/v1/.*",
+				Priority: 10,
+				Action:   "proxy",
+				Clusters: []WhyCluster{{Service: "default/legacy:80"}},
+			}, {
+				Match:  "exact",
+				Value:  "/healthz",
+				Action: "respond with status 200",
+			}, {
+				Origin:  "HTTPProxy default/api",
+				Match:   "segment prefix",
+				Value:   "/api",
+				Headers: []string{"header: X-Canary present"},
+				Action:  "proxy",
+				Clusters: []WhyCluster{
+					{Service: "default/api-canary:80", Weight: 10},
+					{Service: "default/api:80", Weight: 90},
+				},
+			}, {
+				Origin:   "HTTPProxy default/api",
+				Match:    "segment prefix",
+				Value:    "/api",
+				Action:   "proxy",
+				Clusters: []WhyCluster{{Service: "default/api:80"}},
+			}, {
+				Origin:   "HTTPProxy default/www",
+				Match:    "prefix",
+				Value:    "/",
+				Action:   "proxy",
+				Clusters: []WhyCluster{{Service: "default/web:80"}},
+			}},
+		}},
+	}, Routes(d, "WWW.example.com"))
+
+	assert.Equal(t, &RoutesReport{
+		Host:         "api.example.com",
+		VirtualHosts: []RoutesVirtualHost{},
+	}, Routes(d, "api.example.com"))
+}
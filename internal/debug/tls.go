@@ -0,0 +1,192 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/projectcontour/contour/internal/dag"
+)
+
+// TLSReportPath is the path of the TLS compliance report endpoint.
+const TLSReportPath = "/debug/tls"
+
+// Client certificate modes of a secure virtual host.
+const (
+	ClientAuthNone               = "none"
+	ClientAuthRequired           = "required"
+	ClientAuthRequiredUnverified = "required-unverified"
+	ClientAuthOptional           = "optional"
+	ClientAuthOptionalUnverified = "optional-unverified"
+)
+
+// TLSReport is the TLS configuration of every secure virtual host.
+type TLSReport struct {
+	VirtualHosts []TLSVirtualHost `json:"virtualHosts"`
+}
+
+// TLSVirtualHost is the effective TLS configuration of a secure virtual host.
+type TLSVirtualHost struct {
+	Listener string `json:"listener"`
+	Name     string `json:"name"`
+
+	// Passthrough is true if TLS is not terminated by Envoy, in
+	// which case none of the other TLS details apply.
+	Passthrough bool `json:"passthrough,omitempty"`
+
+	MinTLSVersion string   `json:"minTLSVersion,omitempty"`
+	MaxTLSVersion string   `json:"maxTLSVersion,omitempty"`
+	CipherSuites  []string `json:"cipherSuites,omitempty"`
+	ClientAuth    string   `json:"clientAuth,omitempty"`
+
+	Certificate         *TLSCertificate `json:"certificate,omitempty"`
+	FallbackCertificate *TLSCertificate `json:"fallbackCertificate,omitempty"`
+}
+
+// TLSCertificate describes the leaf certificate of a TLS Secret.
+type TLSCertificate struct {
+	Secret    string     `json:"secret"`
+	Subject   string     `json:"subject,omitempty"`
+	Issuer    string     `json:"issuer,omitempty"`
+	DNSNames  []string   `json:"dnsNames,omitempty"`
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// tlsReportHandler writes the TLS configuration of every secure
+// virtual host of the DAG as JSON.
+type tlsReportHandler struct {
+	builder *dag.Builder
+
+	// minTLSVersion and cipherSuites are the listener
+	// defaults that virtual hosts can only make stricter.
+	minTLSVersion string
+	cipherSuites  []string
+}
+
+func (h *tlsReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(h.report(h.builder.Build())); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *tlsReportHandler) report(d *dag.DAG) *TLSReport {
+	report := &TLSReport{
+		VirtualHosts: []TLSVirtualHost{},
+	}
+
+	for _, listener := range d.Listeners {
+		for _, vh := range listener.SecureVirtualHosts {
+			report.VirtualHosts = append(report.VirtualHosts, h.virtualHost(listener.Name, vh))
+		}
+	}
+
+	sort.Slice(report.VirtualHosts, func(i, j int) bool {
+		a, b := report.VirtualHosts[i], report.VirtualHosts[j]
+		if a.Listener != b.Listener {
+			return a.Listener < b.Listener
+		}
+		return a.Name < b.Name
+	})
+
+	return report
+}
+
+func (h *tlsReportHandler) virtualHost(listener string, vh *dag.SecureVirtualHost) TLSVirtualHost {
+	tvh := TLSVirtualHost{
+		Listener: listener,
+		Name:     vh.Name,
+	}
+
+	if vh.Secret == nil {
+		tvh.Passthrough = true
+		return tvh
+	}
+
+	// As for the Envoy listener, the higher of the
+	// configured or requested TLS version applies.
+	tvh.MinTLSVersion = maxTLSVersion(h.minTLSVersion, vh.MinTLSVersion)
+	tvh.MaxTLSVersion = "1.3"
+	tvh.CipherSuites = h.cipherSuites
+	tvh.ClientAuth = clientAuth(vh.DownstreamValidation)
+	tvh.Certificate = certificate(vh.Secret)
+	if vh.FallbackCertificate != nil {
+		tvh.FallbackCertificate = certificate(vh.FallbackCertificate)
+	}
+
+	return tvh
+}
+
+// maxTLSVersion returns the higher of two TLS versions,
+// treating an unset version as TLS 1.2.
+func maxTLSVersion(a, b string) string {
+	if a == "1.3" || b == "1.3" {
+		return "1.3"
+	}
+	return "1.2"
+}
+
+func clientAuth(pvc *dag.PeerValidationContext) string {
+	switch {
+	case pvc == nil:
+		return ClientAuthNone
+	case pvc.OptionalClientCertificate && pvc.SkipClientCertValidation:
+		return ClientAuthOptionalUnverified
+	case pvc.OptionalClientCertificate:
+		return ClientAuthOptional
+	case pvc.SkipClientCertValidation:
+		return ClientAuthRequiredUnverified
+	case pvc.CACertificate != nil:
+		return ClientAuthRequired
+	default:
+		return ClientAuthNone
+	}
+}
+
+// certificate describes the first certificate of the
+// Secret, which is the leaf of a valid TLS Secret.
+func certificate(s *dag.Secret) *TLSCertificate {
+	c := &TLSCertificate{
+		Secret: s.Namespace() + "/" + s.Name(),
+	}
+
+	block, _ := pem.Decode(s.Cert())
+	if block == nil {
+		c.Error = "failed to locate certificate"
+		return c
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+
+	c.Subject = cert.Subject.String()
+	c.Issuer = cert.Issuer.String()
+	c.DNSNames = cert.DNSNames
+	c.NotBefore = &cert.NotBefore
+	c.NotAfter = &cert.NotAfter
+
+	return c
+}
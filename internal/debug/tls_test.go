@@ -0,0 +1,116 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestTLSReport(t *testing.T) {
+	secret := &dag.Secret{Object: fixture.SecretRootsCert}
+	ca := &dag.Secret{Object: &v1.Secret{
+		ObjectMeta: fixture.ObjectMeta("roots/ca"),
+		Data: map[string][]byte{
+			dag.CACertificateKey: []byte(fixture.CA_CERT),
+		},
+	}}
+
+	notBefore := time.Date(2019, time.December, 5, 1, 34, 33, 0, time.UTC)
+	notAfter := time.Date(2029, time.December, 2, 1, 34, 33, 0, time.UTC)
+	cert := &TLSCertificate{
+		Secret:    "roots/ssl-cert",
+		Subject:   "CN=boring-wozniak.example.com",
+		Issuer:    "CN=boring-wozniak.example.com",
+		NotBefore: &notBefore,
+		NotAfter:  &notAfter,
+	}
+
+	d := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: "ingress_https",
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{
+				VirtualHost: dag.VirtualHost{Name: "www.example.com"},
+				Secret:      secret,
+			}, {
+				VirtualHost:   dag.VirtualHost{Name: "api.example.com"},
+				MinTLSVersion: "1.3",
+				Secret:        secret,
+				DownstreamValidation: &dag.PeerValidationContext{
+					CACertificate: ca,
+				},
+			}, {
+				VirtualHost: dag.VirtualHost{Name: "mtls.example.com"},
+				Secret:      secret,
+				DownstreamValidation: &dag.PeerValidationContext{
+					SkipClientCertValidation: true,
+				},
+				FallbackCertificate: &dag.Secret{Object: &v1.Secret{
+					ObjectMeta: fixture.ObjectMeta("roots/invalid"),
+					Data: map[string][]byte{
+						v1.TLSCertKey: []byte("not a certificate"),
+					},
+				}},
+			}, {
+				VirtualHost: dag.VirtualHost{Name: "passthrough.example.com"},
+			}},
+		}},
+	}
+
+	h := &tlsReportHandler{
+		minTLSVersion: "1.2",
+		cipherSuites:  []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+	}
+
+	assert.Equal(t, &TLSReport{
+		VirtualHosts: []TLSVirtualHost{{
+			Listener:      "ingress_https",
+			Name:          "api.example.com",
+			MinTLSVersion: "1.3",
+			MaxTLSVersion: "1.3",
+			CipherSuites:  []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+			ClientAuth:    ClientAuthRequired,
+			Certificate:   cert,
+		}, {
+			Listener:      "ingress_https",
+			Name:          "mtls.example.com",
+			MinTLSVersion: "1.2",
+			MaxTLSVersion: "1.3",
+			CipherSuites:  []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+			ClientAuth:    ClientAuthRequiredUnverified,
+			Certificate:   cert,
+			FallbackCertificate: &TLSCertificate{
+				Secret: "roots/invalid",
+				Error:  "failed to locate certificate",
+			},
+		}, {
+			Listener:    "ingress_https",
+			Name:        "passthrough.example.com",
+			Passthrough: true,
+		}, {
+			Listener:      "ingress_https",
+			Name:          "www.example.com",
+			MinTLSVersion: "1.2",
+			MaxTLSVersion: "1.3",
+			CipherSuites:  []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+			ClientAuth:    ClientAuthNone,
+			Certificate:   cert,
+		}},
+	}, h.report(d))
+}
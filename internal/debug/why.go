@@ -0,0 +1,517 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/sorter"
+	"github.com/projectcontour/contour/internal/status"
+)
+
+// WhyPath is the path of the endpoint that explains
+// how a request is routed.
+const WhyPath = "/debug/why"
+
+// WhyRequest is a request whose routing is explained.
+type WhyRequest struct {
+	Host string
+	Path string
+
+	// Secure is true if the request is sent over TLS.
+	Secure bool
+
+	// Headers are the headers of the request, by
+	// name. The :authority header is always the Host.
+	Headers map[string]string
+}
+
+// WhyReport explains how a request is routed.
+type WhyReport struct {
+	Host   string `json:"host"`
+	Path   string `json:"path"`
+	Secure bool   `json:"secure,omitempty"`
+
+	// VirtualHost is the name of the virtual host that serves
+	// the request, which may be a wildcard. It is empty if no
+	// virtual host serves the Host.
+	VirtualHost string `json:"virtualHost,omitempty"`
+
+	// Route is the route that serves the request, if any.
+	Route *WhyRoute `json:"route,omitempty"`
+
+	// Alternatives are the routes and objects that could
+	// have served the request, and why they did not.
+	Alternatives []WhyAlternative `json:"alternatives,omitempty"`
+}
+
+// WhyRoute describes the route that serves a request.
+type WhyRoute struct {
+	Origin     string       `json:"origin,omitempty"`
+	Conditions []string     `json:"conditions"`
+	Action     string       `json:"action"`
+	Clusters   []WhyCluster `json:"clusters,omitempty"`
+	Policies   []string     `json:"policies,omitempty"`
+}
+
+// WhyCluster describes a cluster that a route sends requests to.
+type WhyCluster struct {
+	Service  string `json:"service"`
+	Weight   uint32 `json:"weight,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Mirror   bool   `json:"mirror,omitempty"`
+}
+
+// WhyAlternative is a route or object that did not serve a request.
+type WhyAlternative struct {
+	Origin     string   `json:"origin,omitempty"`
+	Conditions []string `json:"conditions,omitempty"`
+	Reason     string   `json:"reason"`
+}
+
+// whyHandler explains how the request given by the
+// host, path, secure and header query parameters is
+// routed by the DAG.
+type whyHandler struct {
+	builder *dag.Builder
+}
+
+func (h *whyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req := WhyRequest{
+		Host:    q.Get("host"),
+		Path:    q.Get("path"),
+		Headers: map[string]string{},
+	}
+	if req.Host == "" {
+		http.Error(w, "missing host parameter", http.StatusBadRequest)
+		return
+	}
+	if req.Path == "" {
+		req.Path = "/"
+	}
+	if secure := q.Get("secure"); secure != "" {
+		var err error
+		if req.Secure, err = strconv.ParseBool(secure); err != nil {
+			http.Error(w, fmt.Sprintf("invalid secure parameter %q", secure), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, header := range q["header"] {
+		name, value, err := ParseWhyHeader(header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Headers[name] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Explain(h.builder.Build(), req)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ParseWhyHeader parses a request header given as "name: value".
+// A header given as a name only is present with an empty value.
+func ParseWhyHeader(header string) (string, string, error) {
+	// Pseudo-headers such as :method start with a colon.
+	start := 0
+	if strings.HasPrefix(header, ":") {
+		start = 1
+	}
+
+	name, value := header, ""
+	if i := strings.Index(header[start:], ":"); i >= 0 {
+		name, value = header[:start+i], strings.TrimSpace(header[start+i+1:])
+	}
+
+	// Header names can't contain whitespace, so a name that does
+	// is a value given without a name, as in ": value".
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == start || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q", header)
+	}
+	return name, value, nil
+}
+
+// Explain explains how the DAG routes req: the virtual host and
+// route that serve it, and why the other routes that match its
+// path, or the HTTPProxies for its Host that are not valid, do not.
+func Explain(d *dag.DAG, req WhyRequest) *WhyReport {
+	host := strings.ToLower(req.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	path := req.Path
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	report := &WhyReport{
+		Host:   host,
+		Path:   path,
+		Secure: req.Secure,
+	}
+
+	headers := map[string]string{}
+	for name, value := range req.Headers {
+		headers[strings.ToLower(name)] = value
+	}
+	headers[":authority"] = req.Host
+
+	names := []string{host}
+	if i := strings.Index(host, "."); i > 0 {
+		names = append(names, "*"+host[i:])
+	}
+	report.Alternatives = append(report.Alternatives, proxyAlternatives(d, names)...)
+
+	vh := findVirtualHost(d, host, req.Secure)
+	if vh == nil {
+		if findVirtualHost(d, host, !req.Secure) != nil {
+			reason := "the virtual host for the host only serves requests over TLS"
+			if req.Secure {
+				reason = "the virtual host for the host does not serve requests over TLS"
+			}
+			report.Alternatives = append(report.Alternatives, WhyAlternative{Reason: reason})
+		}
+		return report
+	}
+	report.VirtualHost = vh.Name
+
+	// Envoy serves a request with the first route that matches,
+	// in the order that Contour programs them.
+	var matched *dag.Route
+	for _, r := range sortedRoutes(vh) {
+		if !pathMatches(r.PathMatchCondition, path) {
+			continue
+		}
+
+		alternative := WhyAlternative{
+			Origin:     origin(d, r),
+			Conditions: conditions(r),
+		}
+		switch {
+		case !headersMatch(r.HeaderMatchConditions, headers):
+			alternative.Reason = "the header conditions of the route do not match the request"
+		case matched != nil:
+			alternative.Reason = "a route that takes precedence also matches the request: " + strings.Join(conditions(matched), ", ")
+		default:
+			matched = r
+			report.Route = explainRoute(d, r, req.Secure)
+			continue
+		}
+		report.Alternatives = append(report.Alternatives, alternative)
+	}
+
+	return report
+}
+
+// sortedRoutes returns the routes of vh in the order
+// that Contour programs them.
+func sortedRoutes(vh *dag.VirtualHost) []*dag.Route {
+	routes := make([]*dag.Route, 0, len(vh.Routes))
+	for _, r := range vh.Routes {
+		routes = append(routes, r)
+	}
+
+	for _, r := range routes {
+		sort.Stable(sorter.For(r.HeaderMatchConditions))
+	}
+	sort.Stable(sorter.For(routes))
+
+	return routes
+}
+
+// findVirtualHost returns the virtual host of the DAG that serves
+// host, preferring an exact match of the name or one of the aliases
+// of a virtual host to a wildcard match.
+func findVirtualHost(d *dag.DAG, host string, secure bool) *dag.VirtualHost {
+	var vhosts []*dag.VirtualHost
+	for _, l := range d.Listeners {
+		if secure {
+			for _, svh := range l.SecureVirtualHosts {
+				vhosts = append(vhosts, &svh.VirtualHost)
+			}
+		} else {
+			vhosts = append(vhosts, l.VirtualHosts...)
+		}
+	}
+
+	var wildcard *dag.VirtualHost
+	for _, vh := range vhosts {
+		if vh.Name == host {
+			return vh
+		}
+		for _, alias := range vh.Aliases {
+			if alias == host {
+				return vh
+			}
+		}
+		if i := strings.Index(host, "."); i > 0 && vh.Name == "*"+host[i:] {
+			wildcard = vh
+		}
+	}
+
+	return wildcard
+}
+
+// proxyAlternatives returns the HTTPProxies for the virtual host names
+// that are not valid, or that lost a conflict with another HTTPProxy.
+func proxyAlternatives(d *dag.DAG, names []string) []WhyAlternative {
+	vhosts := map[string]bool{}
+	for _, name := range names {
+		vhosts[name] = true
+	}
+
+	var alternatives []WhyAlternative
+	for _, pu := range d.StatusCache.GetProxyUpdates() {
+		if !vhosts[pu.Vhost] {
+			continue
+		}
+		for _, cond := range []status.ConditionType{status.ValidCondition, status.ConflictedCondition} {
+			dc, ok := pu.Conditions[cond]
+			if !ok {
+				continue
+			}
+			for _, e := range dc.Errors {
+				alternatives = append(alternatives, WhyAlternative{
+					Origin: dag.Origin{Kind: "HTTPProxy", Namespace: pu.Fullname.Namespace, Name: pu.Fullname.Name}.String(),
+					Reason: e.Message,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		return alternatives[i].Origin < alternatives[j].Origin
+	})
+
+	return alternatives
+}
+
+func pathMatches(mc dag.MatchCondition, path string) bool {
+	switch mc := mc.(type) {
+	case *dag.PrefixMatchCondition:
+		if mc.PrefixMatchType == dag.PrefixMatchSegment {
+			prefix := strings.TrimSuffix(mc.Prefix, "/")
+			return path == prefix || strings.HasPrefix(path, prefix+"/")
+		}
+		return strings.HasPrefix(path, mc.Prefix)
+	case *dag.ExactMatchCondition:
+		return path == mc.Path
+	case *dag.RegexMatchCondition:
+		return fullMatch(mc.Regex, path)
+	default:
+		return false
+	}
+}
+
+func headersMatch(conds []dag.HeaderMatchCondition, headers map[string]string) bool {
+	for _, hc := range conds {
+		value, present := headers[strings.ToLower(hc.Name)]
+
+		var match bool
+		switch hc.MatchType {
+		case dag.HeaderMatchTypePresent:
+			match = present
+		case dag.HeaderMatchTypeExact:
+			match = present && value == hc.Value
+		case dag.HeaderMatchTypeContains:
+			match = present && strings.Contains(value, hc.Value)
+		case dag.HeaderMatchTypeRegex:
+			match = present && fullMatch(hc.Value, value)
+		}
+
+		if match == hc.Invert {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fullMatch reports whether the whole of s matches
+// the regular expression expr, as Envoy matches them.
+func fullMatch(expr, s string) bool {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+func origin(d *dag.DAG, r *dag.Route) string {
+	if o, ok := d.Origins[r]; ok {
+		return o.String()
+	}
+	return ""
+}
+
+func conditions(r *dag.Route) []string {
+	return append([]string{r.PathMatchCondition.String()}, headerConditions(r)...)
+}
+
+func headerConditions(r *dag.Route) []string {
+	var conds []string
+	for _, hc := range r.HeaderMatchConditions {
+		cond := "header: " + hc.Name + " " + hc.MatchType
+		if hc.Invert {
+			cond = "header: " + hc.Name + " not " + hc.MatchType
+		}
+		if hc.MatchType != dag.HeaderMatchTypePresent {
+			cond += " " + hc.Value
+		}
+		conds = append(conds, cond)
+	}
+	return conds
+}
+
+func explainRoute(d *dag.DAG, r *dag.Route, secure bool) *WhyRoute {
+	return &WhyRoute{
+		Origin:     origin(d, r),
+		Conditions: conditions(r),
+		Action:     action(r, secure),
+		Clusters:   clusters(r),
+		Policies:   policies(r),
+	}
+}
+
+// action describes what Envoy does with the requests for r.
+func action(r *dag.Route, secure bool) string {
+	switch {
+	case r.HTTPSUpgrade && !secure:
+		// Routes of secure virtual hosts are never upgraded.
+		return "redirect to HTTPS"
+	case r.Redirect != nil:
+		action := "redirect"
+		if r.Redirect.Scheme != "" {
+			action += " scheme " + r.Redirect.Scheme
+		}
+		if r.Redirect.Hostname != "" {
+			action += " host " + r.Redirect.Hostname
+		}
+		if r.Redirect.PortNumber > 0 {
+			action += fmt.Sprintf(" port %d", r.Redirect.PortNumber)
+		}
+		return action
+	case r.DirectResponse != nil:
+		return fmt.Sprintf("respond with status %d", r.DirectResponse.StatusCode)
+	default:
+		return "proxy"
+	}
+}
+
+// clusters describes the clusters that r sends requests
+// to, followed by its mirror cluster, if any.
+func clusters(r *dag.Route) []WhyCluster {
+	var cs []WhyCluster
+	for _, c := range r.Clusters {
+		cs = append(cs, explainCluster(c, false))
+	}
+	if r.MirrorPolicy != nil && r.MirrorPolicy.Cluster != nil {
+		cs = append(cs, explainCluster(r.MirrorPolicy.Cluster, true))
+	}
+	return cs
+}
+
+func explainCluster(c *dag.Cluster, mirror bool) WhyCluster {
+	w := c.Upstream.Weighted
+	return WhyCluster{
+		Service:  fmt.Sprintf("%s/%s:%d", w.ServiceNamespace, w.ServiceName, w.ServicePort.Port),
+		Weight:   c.Weight,
+		Protocol: c.Protocol,
+		Mirror:   mirror,
+	}
+}
+
+// policies describes the policies that apply to requests for r.
+func policies(r *dag.Route) []string {
+	var p []string
+
+	if r.PrefixRewrite != "" {
+		p = append(p, "prefix rewrite: "+r.PrefixRewrite)
+	}
+	if !r.TimeoutPolicy.ResponseTimeout.UseDefault() {
+		p = append(p, "response timeout: "+setting(r.TimeoutPolicy.ResponseTimeout.IsDisabled(), r.TimeoutPolicy.ResponseTimeout.Duration().String()))
+	}
+	if !r.TimeoutPolicy.IdleTimeout.UseDefault() {
+		p = append(p, "idle timeout: "+setting(r.TimeoutPolicy.IdleTimeout.IsDisabled(), r.TimeoutPolicy.IdleTimeout.Duration().String()))
+	}
+	if rp := r.RetryPolicy; rp != nil {
+		p = append(p, fmt.Sprintf("retry: %d on %s", rp.NumRetries, rp.RetryOn))
+	}
+	if r.HedgePolicy != nil {
+		p = append(p, "request hedging")
+	}
+	if r.Websocket {
+		p = append(p, "websocket")
+	}
+	if r.RequestHeadersPolicy != nil {
+		p = append(p, "request headers")
+	}
+	if r.ResponseHeadersPolicy != nil {
+		p = append(p, "response headers")
+	}
+	if len(r.CookieRewritePolicies) > 0 {
+		p = append(p, "cookie rewrite")
+	}
+	if len(r.RequestHashPolicies) > 0 {
+		p = append(p, "request hash load balancing")
+	}
+	if r.TrafficSplitPolicy != nil {
+		p = append(p, "traffic split pinning")
+	}
+	if rlp := r.RateLimitPolicy; rlp != nil {
+		switch {
+		case rlp.Local == nil:
+		case rlp.Local.Disabled:
+			p = append(p, "local rate limit disabled")
+		default:
+			p = append(p, "local rate limit")
+		}
+		switch {
+		case rlp.Global == nil:
+		case rlp.Global.Disabled:
+			p = append(p, "global rate limit disabled")
+		default:
+			p = append(p, "global rate limit")
+		}
+	}
+	if r.AuthDisabled {
+		p = append(p, "authorization disabled")
+	}
+	for _, f := range r.DisabledFilters {
+		p = append(p, "filter disabled: "+string(f))
+	}
+
+	return p
+}
+
+func setting(disabled bool, duration string) string {
+	if disabled {
+		return "disabled"
+	}
+	return duration
+}
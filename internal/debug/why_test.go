@@ -0,0 +1,237 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestExplain(t *testing.T) {
+	cluster := func(name string, weight uint32) *dag.Cluster {
+		return &dag.Cluster{
+			Upstream: &dag.Service{
+				Weighted: dag.WeightedService{
+					ServiceName:      name,
+					ServiceNamespace: "default",
+					ServicePort:      v1.ServicePort{Port: 80},
+				},
+			},
+			Weight: weight,
+		}
+	}
+
+	root := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		Clusters:           []*dag.Cluster{cluster("web", 0)},
+	}
+	api := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api"},
+		Clusters:           []*dag.Cluster{cluster("api", 0)},
+		TimeoutPolicy: dag.TimeoutPolicy{
+			ResponseTimeout: timeout.DurationSetting(5 * time.Second),
+		},
+	}
+	canary := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api"},
+		HeaderMatchConditions: []dag.HeaderMatchCondition{{
+			Name:      "X-Canary",
+			MatchType: dag.HeaderMatchTypePresent,
+		}},
+		Clusters:    []*dag.Cluster{cluster("api-canary", 10), cluster("api", 90)},
+		RetryPolicy: &dag.RetryPolicy{RetryOn: "5xx", NumRetries: 3},
+	}
+
+	d := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: "ingress_http",
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "www.example.com",
+				Routes: map[string]*dag.Route{
+					"root":   root,
+					"api":    api,
+					"canary": canary,
+				},
+			}},
+		}},
+		Origins: map[*dag.Route]dag.Origin{
+			root:   {Kind: "HTTPProxy", Namespace: "default", Name: "www"},
+			api:    {Kind: "HTTPProxy", Namespace: "default", Name: "api"},
+			canary: {Kind: "HTTPProxy", Namespace: "default", Name: "api"},
+		},
+	}
+
+	tests := map[string]struct {
+		req  WhyRequest
+		want *WhyReport
+	}{
+		"header conditions match": {
+			req: WhyRequest{
+				Host:    "WWW.example.com:80",
+				Path:    "/api/users?limit=10",
+				Headers: map[string]string{"x-canary": ""},
+			},
+			want: &WhyReport{
+				Host:        "www.example.com",
+				Path:        "/api/users",
+				VirtualHost: "www.example.com",
+				Route: &WhyRoute{
+					Origin:     "HTTPProxy default/api",
+					Conditions: []string{"prefix: /api type: string", "header: X-Canary present"},
+					Action:     "proxy",
+					Clusters: []WhyCluster{
+						{Service: "default/api-canary:80", Weight: 10},
+						{Service: "default/api:80", Weight: 90},
+					},
+					Policies: []string{"retry: 3 on 5xx"},
+				},
+				Alternatives: []WhyAlternative{{
+					Origin:     "HTTPProxy default/api",
+					Conditions: []string{"prefix: /api type: string"},
+					Reason:     "a route that takes precedence also matches the request: prefix: /api type: string, header: X-Canary present",
+				}, {
+					Origin:     "HTTPProxy default/www",
+					Conditions: []string{"prefix: / type: string"},
+					Reason:     "a route that takes precedence also matches the request: prefix: /api type: string, header: X-Canary present",
+				}},
+			},
+		},
+		"header conditions do not match": {
+			req: WhyRequest{
+				Host: "www.example.com",
+				Path: "/api",
+			},
+			want: &WhyReport{
+				Host:        "www.example.com",
+				Path:        "/api",
+				VirtualHost: "www.example.com",
+				Route: &WhyRoute{
+					Origin:     "HTTPProxy default/api",
+					Conditions: []string{"prefix: /api type: string"},
+					Action:     "proxy",
+					Clusters:   []WhyCluster{{Service: "default/api:80"}},
+					Policies:   []string{"response timeout: 5s"},
+				},
+				Alternatives: []WhyAlternative{{
+					Origin:     "HTTPProxy default/api",
+					Conditions: []string{"prefix: /api type: string", "header: X-Canary present"},
+					Reason:     "the header conditions of the route do not match the request",
+				}, {
+					Origin:     "HTTPProxy default/www",
+					Conditions: []string{"prefix: / type: string"},
+					Reason:     "a route that takes precedence also matches the request: prefix: /api type: string",
+				}},
+			},
+		},
+		"only the root route matches": {
+			req: WhyRequest{
+				Host: "www.example.com",
+				Path: "/",
+			},
+			want: &WhyReport{
+				Host:        "www.example.com",
+				Path:        "/",
+				VirtualHost: "www.example.com",
+				Route: &WhyRoute{
+					Origin:     "HTTPProxy default/www",
+					Conditions: []string{"prefix: / type: string"},
+					Action:     "proxy",
+					Clusters:   []WhyCluster{{Service: "default/web:80"}},
+				},
+			},
+		},
+		"no virtual host over TLS": {
+			req: WhyRequest{
+				Host:   "www.example.com",
+				Path:   "/",
+				Secure: true,
+			},
+			want: &WhyReport{
+				Host:   "www.example.com",
+				Path:   "/",
+				Secure: true,
+				Alternatives: []WhyAlternative{{
+					Reason: "the virtual host for the host does not serve requests over TLS",
+				}},
+			},
+		},
+		"unknown host": {
+			req: WhyRequest{
+				Host: "api.example.com",
+				Path: "/",
+			},
+			want: &WhyReport{
+				Host: "api.example.com",
+				Path: "/",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Explain(d, tc.req))
+		})
+	}
+}
+
+func TestParseWhyHeader(t *testing.T) {
+	tests := map[string]struct {
+		header    string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		"name and value": {
+			header:    "X-Canary: true",
+			wantName:  "x-canary",
+			wantValue: "true",
+		},
+		"name only": {
+			header:   "X-Canary",
+			wantName: "x-canary",
+		},
+		"pseudo-header": {
+			header:    ":method: POST",
+			wantName:  ":method",
+			wantValue: "POST",
+		},
+		"value with a colon": {
+			header:    "Referer: https://example.com",
+			wantName:  "referer",
+			wantValue: "https://example.com",
+		},
+		"no name": {
+			header:  ": value",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotName, gotValue, err := ParseWhyHeader(tc.header)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantName, gotName)
+			assert.Equal(t, tc.wantValue, gotValue)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/projectcontour/contour/internal/xds"
+)
+
+const (
+	// XDSPath is the path of the endpoint that reports
+	// the xDS streams of the connected Envoys.
+	XDSPath = "/debug/xds"
+
+	// XDSDisconnectPath is the path of the endpoint that
+	// disconnects the xDS streams of the Envoy node given
+	// by the "node" query parameter.
+	XDSDisconnectPath = "/debug/xds/disconnect"
+)
+
+// XDSReport lists the xDS streams of the connected Envoys.
+type XDSReport struct {
+	Streams []xds.ConnectionInfo `json:"streams"`
+}
+
+// xdsHandler serves the xDS streams of the connected Envoys.
+type xdsHandler struct {
+	connections *xds.Connections
+}
+
+func (h *xdsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &XDSReport{Streams: h.connections.Info()})
+}
+
+// xdsDisconnectHandler disconnects the xDS streams of an Envoy
+// node, so that it reconnects and receives every resource again.
+type xdsDisconnectHandler struct {
+	connections *xds.Connections
+}
+
+func (h *xdsDisconnectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	node := r.URL.Query().Get("node")
+	if node == "" {
+		http.Error(w, "missing node query parameter", http.StatusBadRequest)
+		return
+	}
+
+	n := h.connections.Disconnect(node)
+	if n == 0 {
+		http.Error(w, "no xDS streams of node "+node, http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"node":         node,
+		"disconnected": n,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -17,8 +17,12 @@ package envoy
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
+	"path"
+	"path/filepath"
+	"time"
 
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
@@ -82,6 +86,35 @@ type BootstrapConfig struct {
 	// ResourcesDir is the directory where out of line Envoy resources can be placed.
 	ResourcesDir string
 
+	// SDSTLSCertificatePath and SDSValidationContextPath are the filenames
+	// of the SDS resources for the xDS client certificate and the trusted
+	// CA. If empty, they are written to the "sds" subdirectory of ResourcesDir.
+	// Setting either enables SDS resources without a ResourcesDir.
+	SDSTLSCertificatePath    string
+	SDSValidationContextPath string
+
+	// GrpcWatchedDirectory is the directory Envoy watches to reload the xDS
+	// client certificate, key and CA bundle. Kubernetes updates a volume by
+	// swapping a symlink in the root of its mount, so this must be the mount
+	// root when the files are projected into subdirectories of it, e.g. by a
+	// projected volume that also holds a service account token. If empty,
+	// the directory of each file is watched.
+	GrpcWatchedDirectory string
+
+	// FileMode is the mode of the configuration files that are written.
+	// Defaults to 0644.
+	FileMode os.FileMode
+
+	// DirMode is the mode of the SDS resource directories that are created.
+	// Defaults to 0777.
+	DirMode os.FileMode
+
+	// SDSRefreshInterval is how often the xDS client certificate, key and
+	// CA bundle are checked for changes after the configuration is written.
+	// When any of them change, the SDS resources are written again so that
+	// Envoy reloads them. If zero, the configuration is written once.
+	SDSRefreshInterval time.Duration
+
 	// SkipFilePathCheck specifies whether to skip checking whether files
 	// referenced in the configuration actually exist. This option is for
 	// testing only.
@@ -90,6 +123,72 @@ type BootstrapConfig struct {
 	// DNSLookupFamily specifies DNS Resolution Policy to use for Envoy -> Contour cluster name lookup.
 	// Either v4, v6 or auto.
 	DNSLookupFamily string
+
+	// MaximumHeapSizeBytes is the maximum heap size of the Envoy process
+	// monitored by the overload manager. If zero, the overload manager
+	// is not configured.
+	MaximumHeapSizeBytes uint64
+
+	// ShrinkHeapThreshold is the fraction of MaximumHeapSizeBytes above
+	// which Envoy releases free memory back to the system.
+	// Defaults to 0.95.
+	ShrinkHeapThreshold float64
+
+	// StopAcceptingRequestsThreshold is the fraction of MaximumHeapSizeBytes
+	// above which Envoy stops accepting new requests and connections.
+	// Defaults to 0.98.
+	StopAcceptingRequestsThreshold float64
+
+	// MaximumDownstreamConnections is the limit on the number of active
+	// downstream connections across all listeners. If zero, the number
+	// of connections is unlimited.
+	MaximumDownstreamConnections uint64
+
+	// StatsdAddress is the IP:port of a statsd server that Envoy
+	// sends its stats to over UDP. If empty, no statsd sink is configured.
+	StatsdAddress string
+
+	// DogStatsdAddress is the IP:port of a DogStatsD server that Envoy
+	// sends its stats, with tags, to over UDP. If empty, no DogStatsD
+	// sink is configured.
+	DogStatsdAddress string
+
+	// StatsPrefix is prepended to the names of stats sent to the statsd
+	// and DogStatsD sinks. Defaults to Envoy's default of "envoy".
+	StatsPrefix string
+
+	// StatsFlushInterval is how often Envoy flushes stats to the sinks.
+	// If zero, Envoy's default of 5s is used.
+	StatsFlushInterval time.Duration
+
+	// StatsInclusionRegexes restricts the stats Envoy creates to those
+	// whose names match one of the regular expressions. If empty, all
+	// stats are created.
+	StatsInclusionRegexes []string
+
+	// AdminListenerAddress is the address of the static listener which
+	// exposes the allowlisted admin paths. Defaults to 127.0.0.1.
+	AdminListenerAddress string
+
+	// AdminListenerPort is the port of the static listener which exposes
+	// the allowlisted admin paths. If zero, the admin interface is only
+	// reachable through its Unix socket.
+	AdminListenerPort int
+
+	// AdminListenerPaths are the admin interface paths that may be
+	// requested, with GET, through the admin listener.
+	AdminListenerPaths []string
+
+	// AdminListenerCertFile and AdminListenerKeyFile are the filenames
+	// of the certificate and key the admin listener serves TLS with.
+	// If empty, the admin listener serves plain HTTP.
+	AdminListenerCertFile string
+	AdminListenerKeyFile  string
+
+	// AdminListenerCAFile is the filename of the CA bundle used to
+	// verify client certificates presented to the admin listener.
+	// If empty, client certificates are not required.
+	AdminListenerCAFile string
 }
 
 // GetXdsAddress returns the address configured or defaults to "127.0.0.1"
@@ -109,6 +208,30 @@ func (c *BootstrapConfig) GetAdminAccessLogPath() string {
 	return stringOrDefault(c.AdminAccessLogPath, "/dev/null")
 }
 
+// GetSDSTLSCertificatePath returns the configured SDS TLS certificate resource
+// path or defaults to "xds-tls-certificate.json" in the SDS subdirectory of ResourcesDir.
+func (c *BootstrapConfig) GetSDSTLSCertificatePath() string {
+	return stringOrDefault(c.SDSTLSCertificatePath, path.Join(c.ResourcesDir, SDSResourcesSubdirectory, SDSTLSCertificateFile))
+}
+
+// GetSDSValidationContextPath returns the configured SDS validation context resource
+// path or defaults to "xds-validation-context.json" in the SDS subdirectory of ResourcesDir.
+func (c *BootstrapConfig) GetSDSValidationContextPath() string {
+	return stringOrDefault(c.SDSValidationContextPath, path.Join(c.ResourcesDir, SDSResourcesSubdirectory, SDSValidationContextFile))
+}
+
+// UseSDSResources returns whether the xDS client certificate and
+// CA bundle are referenced through SDS resource files.
+func (c *BootstrapConfig) UseSDSResources() bool {
+	return c.ResourcesDir != "" || c.SDSTLSCertificatePath != "" || c.SDSValidationContextPath != ""
+}
+
+// GetFileMode returns the configured file mode or defaults to 0644
+func (c *BootstrapConfig) GetFileMode() os.FileMode { return modeOrDefault(c.FileMode, 0644) }
+
+// GetDirMode returns the configured directory mode or defaults to 0777
+func (c *BootstrapConfig) GetDirMode() os.FileMode { return modeOrDefault(c.DirMode, 0777) }
+
 // GetDNSLookupFamily returns the configured dns lookup family or defaults to "auto"
 func (c *BootstrapConfig) GetDNSLookupFamily() string {
 	return stringOrDefault(c.DNSLookupFamily, "auto")
@@ -125,6 +248,21 @@ func ValidAdminAddress(address string) error {
 	return nil
 }
 
+// GetAdminListenerAddress returns the admin listener address configured or defaults to "127.0.0.1"
+func (c *BootstrapConfig) GetAdminListenerAddress() string {
+	return stringOrDefault(c.AdminListenerAddress, "127.0.0.1")
+}
+
+// GetShrinkHeapThreshold returns the configured shrink heap threshold or defaults to 0.95
+func (c *BootstrapConfig) GetShrinkHeapThreshold() float64 {
+	return floatOrDefault(c.ShrinkHeapThreshold, 0.95)
+}
+
+// GetStopAcceptingRequestsThreshold returns the configured stop accepting requests threshold or defaults to 0.98
+func (c *BootstrapConfig) GetStopAcceptingRequestsThreshold() float64 {
+	return floatOrDefault(c.StopAcceptingRequestsThreshold, 0.98)
+}
+
 func stringOrDefault(s, def string) string {
 	if s == "" {
 		return def
@@ -139,21 +277,56 @@ func intOrDefault(i, def int) int {
 	return i
 }
 
-func WriteConfig(filename string, config proto.Message) (err error) {
-	var out *os.File
+func modeOrDefault(m, def os.FileMode) os.FileMode {
+	if m == 0 {
+		return def
+	}
+	return m
+}
+
+func floatOrDefault(f, def float64) float64 {
+	if f == 0 {
+		return def
+	}
+	return f
+}
+
+// WriteConfig writes out a json representation of the config
+// to the file named filename with the given mode. The file is
+// replaced atomically, by renaming a temporary file written in
+// the same directory, so that a reader such as Envoy, which
+// reloads file based resources when they are moved into place,
+// never observes a partially written file. If filename is "-",
+// the configuration is written to standard output.
+func WriteConfig(filename string, config proto.Message, mode os.FileMode) (err error) {
+	m := &jsonpb.Marshaler{OrigName: true}
 
 	if filename == "-" {
-		out = os.Stdout
-	} else {
-		out, err = os.Create(filename)
+		return m.Marshal(os.Stdout, config)
+	}
+
+	out, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".")
+	if err != nil {
+		return err
+	}
+	defer func() {
 		if err != nil {
-			return
+			out.Close()
+			os.Remove(out.Name())
 		}
-		defer func() {
-			err = out.Close()
-		}()
-	}
+	}()
 
-	m := &jsonpb.Marshaler{OrigName: true}
-	return m.Marshal(out, config)
+	if err = m.Marshal(out, config); err != nil {
+		return err
+	}
+	if err = out.Chmod(mode); err != nil {
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), filename)
 }
@@ -15,9 +15,14 @@ package envoy
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidAdminAddress(t *testing.T) {
@@ -40,3 +45,26 @@ func TestValidAdminAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "config.json")
+	require.NoError(t, WriteConfig(filename, &wrappers.StringValue{Value: "first"}, 0644))
+	require.NoError(t, WriteConfig(filename, &wrappers.StringValue{Value: "second"}, 0600))
+
+	data, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, `"second"`, string(data))
+
+	fi, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	// No temporary files are left behind.
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -17,10 +17,12 @@ import (
 	"crypto/sha1" // nolint:gosec
 	"crypto/sha256"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/projectcontour/contour/internal/dag"
+	v1 "k8s.io/api/core/v1"
 )
 
 // Clustername returns the name of the CDS cluster for this service.
@@ -41,10 +43,51 @@ func Clustername(cluster *dag.Cluster) string {
 			buf += strconv.Itoa(int(hc.HealthyThreshold))
 		}
 		buf += hc.Path
+		if hc.Port > 0 {
+			buf += strconv.Itoa(int(hc.Port))
+		}
+		for _, r := range hc.ExpectedStatuses {
+			buf += strconv.Itoa(int(r.Start)) + "-" + strconv.Itoa(int(r.End))
+		}
+		headers := make([]string, 0, len(hc.RequestHeaders))
+		for name, value := range hc.RequestHeaders {
+			headers = append(headers, name+":"+value)
+		}
+		sort.Strings(headers)
+		buf += strings.Join(headers, "")
+		if hc.InitialJitter > 0 {
+			buf += hc.InitialJitter.String()
+		}
+		if hc.IntervalJitter > 0 {
+			buf += hc.IntervalJitter.String()
+		}
+	}
+	if hc := cluster.TCPHealthCheckPolicy; hc != nil && hc.Port > 0 {
+		buf += strconv.Itoa(int(hc.Port))
 	}
 	if uv := cluster.UpstreamValidation; uv != nil {
-		buf += uv.CACertificate.Object.ObjectMeta.Name
+		if uv.CACertificate != nil {
+			buf += uv.CACertificate.Object.ObjectMeta.Name
+		}
 		buf += uv.SubjectName
+		buf += strings.Join(uv.SPKIHashes, "")
+	}
+	if dp := cluster.DNSPolicy; dp != nil {
+		buf += dp.DiscoveryType
+		for _, r := range dp.Resolvers {
+			buf += r.Address + ":" + strconv.Itoa(int(r.Port))
+		}
+		buf += dp.LookupFamily
+	}
+	for _, ep := range service.StaticEndpoints {
+		buf += ep.Address + ":" + strconv.Itoa(int(ep.Port))
+	}
+	for _, b := range cluster.Backups {
+		buf += b.Weighted.ServiceNamespace + "/" + b.Weighted.ServiceName + ":" + strconv.Itoa(int(b.Weighted.ServicePort.Port))
+	}
+	if service.Weighted.ServicePort.Protocol == v1.ProtocolUDP {
+		// A service may use the same port number for TCP and UDP.
+		buf += string(v1.ProtocolUDP)
 	}
 
 	// This isn't a crypto hash, we just want a unique name.
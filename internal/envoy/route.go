@@ -75,6 +75,7 @@ func SingleSimpleCluster(route *dag.Route) bool {
 	if cluster.ResponseHeadersPolicy == nil {
 		// no response headers policy
 	} else if len(cluster.ResponseHeadersPolicy.Set) != 0 ||
+		len(cluster.ResponseHeadersPolicy.Add) != 0 ||
 		len(cluster.ResponseHeadersPolicy.Remove) != 0 {
 		return false
 	}
@@ -14,6 +14,8 @@
 package v3
 
 import (
+	"strings"
+
 	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
@@ -64,7 +66,21 @@ func FileAccessLogJSON(path string, fields contour_api_v1alpha1.AccessLogFields,
 	}
 
 	for k, v := range fields.AsFieldMap() {
-		jsonformat.Fields[k] = sv(v)
+		// Dotted field names are nested, so "http.method" is
+		// logged as {"http":{"method":...}}.
+		names := strings.Split(k, ".")
+		parent := jsonformat
+		for _, name := range names[:len(names)-1] {
+			child, ok := parent.Fields[name].GetKind().(*_struct.Value_StructValue)
+			if !ok {
+				child = &_struct.Value_StructValue{
+					StructValue: &_struct.Struct{Fields: make(map[string]*_struct.Value)},
+				}
+				parent.Fields[name] = &_struct.Value{Kind: child}
+			}
+			parent = child.StructValue
+		}
+		parent.Fields[names[len(names)-1]] = sv(v)
 	}
 
 	return []*envoy_accesslog_v3.AccessLog{{
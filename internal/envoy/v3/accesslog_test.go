@@ -168,6 +168,54 @@ func TestJSONFileAccessLog(t *testing.T) {
 			},
 			},
 		},
+		"dotted fields should be nested": {
+			path: "/dev/stdout",
+			headers: contour_api_v1alpha1.AccessLogFields([]string{
+				"@timestamp",
+				"http.method=%REQ(:METHOD)%",
+				"http.path=%REQ(X-ENVOY-ORIGINAL-PATH?:PATH)%",
+				"upstream.cluster=%UPSTREAM_CLUSTER%",
+			}),
+			want: []*envoy_accesslog_v3.AccessLog{{
+				Name: wellknown.FileAccessLog,
+				ConfigType: &envoy_accesslog_v3.AccessLog_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_file_v3.FileAccessLog{
+						Path: "/dev/stdout",
+						AccessLogFormat: &envoy_file_v3.FileAccessLog_LogFormat{
+							LogFormat: &envoy_config_core_v3.SubstitutionFormatString{
+								Format: &envoy_config_core_v3.SubstitutionFormatString_JsonFormat{
+									JsonFormat: &_struct.Struct{
+										Fields: map[string]*_struct.Value{
+											"@timestamp": sv("%START_TIME%"),
+											"http": {
+												Kind: &_struct.Value_StructValue{
+													StructValue: &_struct.Struct{
+														Fields: map[string]*_struct.Value{
+															"method": sv("%REQ(:METHOD)%"),
+															"path":   sv("%REQ(X-ENVOY-ORIGINAL-PATH?:PATH)%"),
+														},
+													},
+												},
+											},
+											"upstream": {
+												Kind: &_struct.Value_StructValue{
+													StructValue: &_struct.Struct{
+														Fields: map[string]*_struct.Value{
+															"cluster": sv("%UPSTREAM_CLUSTER%"),
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -44,7 +44,8 @@ func UpstreamTLSContext(peerValidationContext *dag.PeerValidationContext, sni st
 		Sni: sni,
 	}
 
-	if peerValidationContext.GetCACertificate() != nil && len(peerValidationContext.GetSubjectName()) > 0 {
+	spkiHashes := peerValidationContext.GetSPKIHashes()
+	if (peerValidationContext.GetCACertificate() != nil && len(peerValidationContext.GetSubjectName()) > 0) || len(spkiHashes) > 0 {
 		// We have to explicitly assign the value from validationContext
 		// to context.CommonTlsContext.ValidationContextType because the
 		// latter is an interface. Returning nil from validationContext
@@ -53,6 +54,9 @@ func UpstreamTLSContext(peerValidationContext *dag.PeerValidationContext, sni st
 		// to explode later on.
 		vc := validationContext(peerValidationContext.GetCACertificate(), peerValidationContext.GetSubjectName(), false)
 		if vc != nil {
+			// Without a CA, the upstream certificate is
+			// only checked against the pinned hashes.
+			vc.ValidationContext.VerifyCertificateSpki = spkiHashes
 			context.CommonTlsContext.ValidationContextType = vc
 		}
 	}
@@ -111,13 +115,28 @@ func DownstreamTLSContext(serverSecret *dag.Secret, tlsMinProtoVersion envoy_v3_
 		vc := validationContext(peerValidationContext.GetCACertificate(), "", peerValidationContext.SkipClientCertValidation)
 		if vc != nil {
 			context.CommonTlsContext.ValidationContextType = vc
-			context.RequireClientCertificate = protobuf.Bool(true)
+			context.RequireClientCertificate = protobuf.Bool(!peerValidationContext.OptionalClientCertificate)
 		}
 	}
 
 	return context
 }
 
+func http1ProtocolOptions(options *envoy_api_v3_core.Http1ProtocolOptions) map[string]*any.Any {
+	return map[string]*any.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
+			&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+				UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+					ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+						ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+							HttpProtocolOptions: options,
+						},
+					},
+				},
+			}),
+	}
+}
+
 func http2ProtocolOptions() map[string]*any.Any {
 	return map[string]*any.Any{
 		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
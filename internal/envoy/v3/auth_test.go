@@ -96,6 +96,28 @@ func TestUpstreamTLSContext(t *testing.T) {
 				},
 			},
 		},
+		"no alpn, spki hashes without ca": {
+			validation: &dag.PeerValidationContext{
+				SPKIHashes: []string{"EOCKQZ6FDroeu6GP3SjrfsG36LqpvMO5c+K4iR7HJr4="},
+			},
+			want: &envoy_v3_tls.UpstreamTlsContext{
+				CommonTlsContext: &envoy_v3_tls.CommonTlsContext{
+					ValidationContextType: &envoy_v3_tls.CommonTlsContext_ValidationContext{
+						ValidationContext: &envoy_v3_tls.CertificateValidationContext{
+							VerifyCertificateSpki: []string{"EOCKQZ6FDroeu6GP3SjrfsG36LqpvMO5c+K4iR7HJr4="},
+						},
+					},
+				},
+			},
+		},
+		"no alpn, insecure skip verify": {
+			validation: &dag.PeerValidationContext{
+				InsecureSkipVerify: true,
+			},
+			want: &envoy_v3_tls.UpstreamTlsContext{
+				CommonTlsContext: &envoy_v3_tls.CommonTlsContext{},
+			},
+		},
 		"external name sni": {
 			externalName: "projectcontour.local",
 			want: &envoy_v3_tls.UpstreamTlsContext{
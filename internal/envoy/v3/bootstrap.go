@@ -18,8 +18,10 @@ package v3
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -29,12 +31,20 @@ import (
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_metrics_v3 "github.com/envoyproxy/go-control-plane/envoy/config/metrics/v3"
+	envoy_overload_v3 "github.com/envoyproxy/go-control-plane/envoy/config/overload/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_fixed_heap_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/resource_monitors/fixed_heap/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
+	_struct "github.com/golang/protobuf/ptypes/struct"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
 )
@@ -47,19 +57,93 @@ func WriteBootstrap(c *envoy.BootstrapConfig) error {
 		return err
 	}
 
-	if c.ResourcesDir != "" {
-		// Setting permissions to 0777 explicitly.
+	if c.UseSDSResources() {
+		// Setting permissions to 0777 by default.
 		// Refer Issue: https://github.com/projectcontour/contour/issues/3264
 		// The secrets in this directory are "pointers" to actual secrets
 		// mounted from Kubernetes secrets; that means the actual secrets aren't 0777
-		if err := os.MkdirAll(path.Join(c.ResourcesDir, "sds"), 0777); err != nil {
-			return err
+		for _, f := range []string{c.GetSDSTLSCertificatePath(), c.GetSDSValidationContextPath()} {
+			if err := os.MkdirAll(path.Dir(f), c.GetDirMode()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeConfigs(c, steps)
+}
+
+// WatchSDSResources checks the xDS client certificate, key and CA bundle
+// for changes every SDSRefreshInterval, until stop is closed, and writes
+// the SDS resources again when they do. Envoy watches the SDS resource
+// files, so this makes it reload certificates that are rotated in place
+// rather than by swapping a symlink in the watched directory.
+func WatchSDSResources(c *envoy.BootstrapConfig, stop <-chan struct{}) error {
+	w := newSDSWatcher(c)
+
+	ticker := time.NewTicker(c.SDSRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := w.refresh(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sdsWatcher writes the SDS resources of config when the
+// files they refer to change.
+type sdsWatcher struct {
+	config *envoy.BootstrapConfig
+	last   string
+}
+
+func newSDSWatcher(c *envoy.BootstrapConfig) *sdsWatcher {
+	return &sdsWatcher{
+		config: c,
+		last:   fingerprint(c.GrpcClientCert, c.GrpcClientKey, c.GrpcCABundle),
+	}
+}
+
+// refresh writes the SDS resources if the files they refer to
+// have changed since the watcher was created or last refreshed.
+func (w *sdsWatcher) refresh() error {
+	current := fingerprint(w.config.GrpcClientCert, w.config.GrpcClientKey, w.config.GrpcCABundle)
+	if current == w.last {
+		return nil
+	}
+
+	if err := writeConfigs(w.config, sdsSteps); err != nil {
+		return err
+	}
+	w.last = current
+	return nil
+}
+
+// fingerprint summarizes the size and modification time of files,
+// following symlinks, so that any update to them changes it.
+func fingerprint(files ...string) string {
+	var b strings.Builder
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:missing;", f)
+			continue
 		}
+		fmt.Fprintf(&b, "%s:%d:%d;", f, fi.Size(), fi.ModTime().UnixNano())
 	}
+	return b.String()
+}
 
-	// Write all configuration files out to filesystem.
+// writeConfigs writes the configuration files of steps out to filesystem.
+func writeConfigs(c *envoy.BootstrapConfig, steps []bootstrapf) error {
 	for _, step := range steps {
-		if err := envoy.WriteConfig(step(c)); err != nil {
+		filename, config := step(c)
+		if err := envoy.WriteConfig(filename, config, c.GetFileMode()); err != nil {
 			return err
 		}
 	}
@@ -69,10 +153,44 @@ func WriteBootstrap(c *envoy.BootstrapConfig) error {
 
 type bootstrapf func(*envoy.BootstrapConfig) (string, proto.Message)
 
+// sdsSteps create the SDS resources for the xDS client certificate and
+// key for authenticating Envoy towards Contour, and for the trusted CA
+// certificate for validating Contour server certificate.
+var sdsSteps = []bootstrapf{
+	func(c *envoy.BootstrapConfig) (string, proto.Message) {
+		return c.GetSDSTLSCertificatePath(), tlsCertificateSdsSecretConfig(c)
+	},
+	func(c *envoy.BootstrapConfig) (string, proto.Message) {
+		return c.GetSDSValidationContextPath(), validationContextSdsSecretConfig(c)
+	},
+}
+
 // bootstrap creates a new v3 bootstrap configuration and associated resource files.
 func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 	var steps []bootstrapf
 
+	if err := validateOverload(c); err != nil {
+		return nil, err
+	}
+
+	if err := validateStats(c); err != nil {
+		return nil, err
+	}
+
+	if err := validateAdminListener(c); err != nil {
+		return nil, err
+	}
+
+	if c.SDSRefreshInterval < 0 {
+		return nil, fmt.Errorf("invalid SDS refresh interval %s", c.SDSRefreshInterval)
+	}
+
+	if c.SDSRefreshInterval > 0 && (!c.UseSDSResources() || c.GrpcClientCert == "") {
+		return nil, fmt.Errorf(
+			"%q requires the TLS parameters and SDS resources to be written to %q",
+			"--sds-refresh-interval", "--resources-dir")
+	}
+
 	if c.GrpcClientCert == "" && c.GrpcClientKey == "" && c.GrpcCABundle == "" {
 		steps = append(steps,
 			func(*envoy.BootstrapConfig) (string, proto.Message) {
@@ -90,24 +208,12 @@ func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 				"--envoy-cafile", "--envoy-cert-file", "--envoy-key-file")
 		}
 
-		if !c.SkipFilePathCheck {
-			// If the TLS secrets aren't set up properly,
-			// some files may not be present. In this case,
-			// envoy will reject the bootstrap configuration,
-			// but there is no way to detect and fix that. If
-			// we check and fail here, that is visible in the
-			// Pod lifecycle and therefore fixable.
-			fi, err := os.Stat(f)
-			if err != nil {
-				return nil, err
-			}
-			if fi.Size() == 0 {
-				return nil, fmt.Errorf("%q is empty", f)
-			}
+		if err := checkFile(c, f); err != nil {
+			return nil, err
 		}
 	}
 
-	if c.ResourcesDir == "" {
+	if !c.UseSDSResources() {
 		// For backwards compatibility, the old behavior
 		// is to use direct certificate and key file paths in
 		// bootstrap config. Envoy does not support rotation
@@ -129,23 +235,15 @@ func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 	// but for xDS connection itself, bootstrapping is done by storing the SDS resources in a local filesystem.
 	// Envoy will monitor and reload the resource files and the certificate and key files referred from the SDS resources.
 	//
-	// Two files are written to ResourcesDir:
+	// Two files are written, by default to ResourcesDir:
 	// - SDS resource for xDS client certificate and key for authenticating Envoy towards Contour.
 	// - SDS resource for trusted CA certificate for validating Contour server certificate.
-	sdsTLSCertificatePath := path.Join(c.ResourcesDir, envoy.SDSResourcesSubdirectory, envoy.SDSTLSCertificateFile)
-	sdsValidationContextPath := path.Join(c.ResourcesDir, envoy.SDSResourcesSubdirectory, envoy.SDSValidationContextFile)
-
+	steps = append(steps, sdsSteps...)
 	steps = append(steps,
-		func(*envoy.BootstrapConfig) (string, proto.Message) {
-			return sdsTLSCertificatePath, tlsCertificateSdsSecretConfig(c)
-		},
-		func(*envoy.BootstrapConfig) (string, proto.Message) {
-			return sdsValidationContextPath, validationContextSdsSecretConfig(c)
-		},
 		func(*envoy.BootstrapConfig) (string, proto.Message) {
 			b := bootstrapConfig(c)
 			b.StaticResources.Clusters[0].TransportSocket = UpstreamTLSTransportSocket(
-				upstreamSdsTLSContext(sdsTLSCertificatePath, sdsValidationContextPath))
+				upstreamSdsTLSContext(c.GetSDSTLSCertificatePath(), c.GetSDSValidationContextPath()))
 			return c.Path, b
 		},
 	)
@@ -153,6 +251,29 @@ func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 	return steps, nil
 }
 
+// checkFile checks that the file referenced by the bootstrap
+// configuration exists and is not empty.
+func checkFile(c *envoy.BootstrapConfig, f string) error {
+	if c.SkipFilePathCheck {
+		return nil
+	}
+
+	// If the TLS secrets aren't set up properly,
+	// some files may not be present. In this case,
+	// envoy will reject the bootstrap configuration,
+	// but there is no way to detect and fix that. If
+	// we check and fail here, that is visible in the
+	// Pod lifecycle and therefore fixable.
+	fi, err := os.Stat(f)
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 {
+		return fmt.Errorf("%q is empty", f)
+	}
+	return nil
+}
+
 func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.Bootstrap {
 	return &envoy_bootstrap_v3.Bootstrap{
 		DynamicResources: &envoy_bootstrap_v3.Bootstrap_DynamicResources{
@@ -209,11 +330,433 @@ func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.Bootstrap {
 					),
 				},
 			}},
+			Listeners: adminListeners(c),
+			Secrets:   staticSecrets(c),
 		},
 		Admin: &envoy_bootstrap_v3.Admin{
 			AccessLog: adminAccessLog(c.GetAdminAccessLogPath()),
 			Address:   UnixSocketAddress(c.GetAdminAddress(), c.GetAdminPort()),
 		},
+		OverloadManager:    overloadManager(c),
+		LayeredRuntime:     layeredRuntime(c),
+		StatsSinks:         statsSinks(c),
+		StatsFlushInterval: statsFlushInterval(c),
+		StatsConfig:        statsConfig(c),
+	}
+}
+
+// validateOverload checks that the overload manager thresholds are
+// fractions of the maximum heap size and are correctly ordered.
+func validateOverload(c *envoy.BootstrapConfig) error {
+	shrink, stop := c.GetShrinkHeapThreshold(), c.GetStopAcceptingRequestsThreshold()
+
+	if shrink <= 0 || shrink > 1 {
+		return fmt.Errorf("invalid value %v for %s, must be greater than 0 and at most 1", shrink, "--overload-shrink-heap-threshold")
+	}
+	if stop <= 0 || stop > 1 {
+		return fmt.Errorf("invalid value %v for %s, must be greater than 0 and at most 1", stop, "--overload-stop-accepting-threshold")
+	}
+
+	if shrink > stop {
+		return fmt.Errorf("%s must not be greater than %s",
+			"--overload-shrink-heap-threshold", "--overload-stop-accepting-threshold")
+	}
+
+	return nil
+}
+
+// validateAdminListener checks that the admin listener has paths to
+// expose and a complete set of TLS files.
+func validateAdminListener(c *envoy.BootstrapConfig) error {
+	if c.AdminListenerPort == 0 {
+		return nil
+	}
+
+	if len(c.AdminListenerPaths) == 0 {
+		return fmt.Errorf("you must supply at least one %q when %q is set",
+			"--admin-listener-path", "--admin-listener-port")
+	}
+
+	for _, p := range c.AdminListenerPaths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("invalid value %q for %s, must start with \"/\"", p, "--admin-listener-path")
+		}
+	}
+
+	if (c.AdminListenerCertFile == "") != (c.AdminListenerKeyFile == "") {
+		return fmt.Errorf("you must supply both %q and %q, or neither of them",
+			"--admin-listener-cert-file", "--admin-listener-key-file")
+	}
+
+	if c.AdminListenerCAFile != "" && c.AdminListenerCertFile == "" {
+		return fmt.Errorf("%q requires %q and %q",
+			"--admin-listener-cafile", "--admin-listener-cert-file", "--admin-listener-key-file")
+	}
+
+	for _, f := range []string{c.AdminListenerCertFile, c.AdminListenerKeyFile, c.AdminListenerCAFile} {
+		if f == "" {
+			continue
+		}
+		if err := checkFile(c, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// adminListeners returns the static listener which exposes the
+// allowlisted admin paths, or nil if no admin listener port is set.
+func adminListeners(c *envoy.BootstrapConfig) []*envoy_listener_v3.Listener {
+	if c.AdminListenerPort == 0 {
+		return nil
+	}
+
+	var transportSocket *envoy_core_v3.TransportSocket
+	if c.AdminListenerCertFile != "" {
+		transportSocket = DownstreamTLSTransportSocket(adminListenerTLSContext(c))
+	}
+
+	return []*envoy_listener_v3.Listener{{
+		// This name must differ from the "envoy-admin" listener
+		// Contour serves over LDS, as static listeners cannot be
+		// replaced dynamically.
+		Name:          "envoy-admin-allowlist",
+		Address:       SocketAddress(c.GetAdminListenerAddress(), c.AdminListenerPort),
+		SocketOptions: TCPKeepaliveSocketOptions(),
+		FilterChains:  filterChain("envoy-admin-allowlist", transportSocket, routeForAdminPaths(c.AdminListenerPaths...)),
+	}}
+}
+
+// routeForAdminPaths creates a static RouteConfig that forwards GET
+// requests for exactly the given paths to the Envoy admin interface.
+// All other requests are answered with a 404 by the router.
+func routeForAdminPaths(paths ...string) *http.HttpConnectionManager_RouteConfig {
+	config := &http.HttpConnectionManager_RouteConfig{
+		RouteConfig: &envoy_route_v3.RouteConfiguration{
+			VirtualHosts: []*envoy_route_v3.VirtualHost{{
+				Name:    "backend",
+				Domains: []string{"*"},
+			}},
+		},
+	}
+
+	for _, p := range paths {
+		config.RouteConfig.VirtualHosts[0].Routes = append(config.RouteConfig.VirtualHosts[0].Routes,
+			&envoy_route_v3.Route{
+				Match: &envoy_route_v3.RouteMatch{
+					PathSpecifier: &envoy_route_v3.RouteMatch_Path{
+						Path: p,
+					},
+					Headers: []*envoy_route_v3.HeaderMatcher{{
+						Name: ":method",
+						HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_ExactMatch{
+							ExactMatch: "GET",
+						},
+					}},
+				},
+				Action: &envoy_route_v3.Route_Route{
+					Route: &envoy_route_v3.RouteAction{
+						ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+							Cluster: "envoy-admin",
+						},
+					},
+				},
+			},
+		)
+	}
+	return config
+}
+
+// adminListenerTLSContext returns the TLS context for the admin
+// listener, requiring client certificates when a CA is configured.
+func adminListenerTLSContext(c *envoy.BootstrapConfig) *envoy_tls_v3.DownstreamTlsContext {
+	context := &envoy_tls_v3.DownstreamTlsContext{
+		CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
+			TlsParams: &envoy_tls_v3.TlsParameters{
+				TlsMinimumProtocolVersion: envoy_tls_v3.TlsParameters_TLSv1_2,
+				TlsMaximumProtocolVersion: envoy_tls_v3.TlsParameters_TLSv1_3,
+			},
+			TlsCertificates: []*envoy_tls_v3.TlsCertificate{{
+				CertificateChain: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_Filename{
+						Filename: c.AdminListenerCertFile,
+					},
+				},
+				PrivateKey: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_Filename{
+						Filename: c.AdminListenerKeyFile,
+					},
+				},
+			}},
+		},
+	}
+
+	if c.AdminListenerCAFile != "" {
+		context.CommonTlsContext.ValidationContextType = &envoy_tls_v3.CommonTlsContext_ValidationContext{
+			ValidationContext: &envoy_tls_v3.CertificateValidationContext{
+				TrustedCa: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_Filename{
+						Filename: c.AdminListenerCAFile,
+					},
+				},
+			},
+		}
+		context.RequireClientCertificate = protobuf.Bool(true)
+	}
+
+	return context
+}
+
+// validateStats checks that the stats sink addresses, flush interval
+// and stats matcher regular expressions are acceptable to Envoy.
+func validateStats(c *envoy.BootstrapConfig) error {
+	for flag, address := range map[string]string{
+		"--statsd-address":    c.StatsdAddress,
+		"--dogstatsd-address": c.DogStatsdAddress,
+	} {
+		if address == "" {
+			continue
+		}
+		if _, _, err := parseIPPort(address); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", address, flag, err)
+		}
+	}
+
+	// Envoy requires the flush interval to be between 1ms and 5m.
+	if c.StatsFlushInterval != 0 && (c.StatsFlushInterval < time.Millisecond || c.StatsFlushInterval > 5*time.Minute) {
+		return fmt.Errorf("invalid value %s for %s, must be between 1ms and 5m", c.StatsFlushInterval, "--stats-flush-interval")
+	}
+
+	for _, re := range c.StatsInclusionRegexes {
+		if _, err := regexp.Compile(re); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", re, "--stats-inclusion-regex", err)
+		}
+	}
+
+	return nil
+}
+
+// parseIPPort splits an IP:port address. Envoy's stats sinks do not
+// resolve hostnames, so the host must be an IP address.
+func parseIPPort(address string) (string, int, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, err
+	}
+	if net.ParseIP(host) == nil {
+		return "", 0, fmt.Errorf("%q is not an IP address", host)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return "", 0, fmt.Errorf("%q is not a valid port", port)
+	}
+	return host, p, nil
+}
+
+// udpAddress returns a UDP socket address for the IP:port address.
+func udpAddress(address string) *envoy_core_v3.Address {
+	host, port, _ := parseIPPort(address)
+
+	return &envoy_core_v3.Address{
+		Address: &envoy_core_v3.Address_SocketAddress{
+			SocketAddress: &envoy_core_v3.SocketAddress{
+				Protocol: envoy_core_v3.SocketAddress_UDP,
+				Address:  host,
+				PortSpecifier: &envoy_core_v3.SocketAddress_PortValue{
+					PortValue: uint32(port),
+				},
+			},
+		},
+	}
+}
+
+// statsSinks returns the statsd and DogStatsD sinks configured.
+func statsSinks(c *envoy.BootstrapConfig) []*envoy_metrics_v3.StatsSink {
+	var sinks []*envoy_metrics_v3.StatsSink
+
+	if c.StatsdAddress != "" {
+		sinks = append(sinks, &envoy_metrics_v3.StatsSink{
+			Name: "envoy.stat_sinks.statsd",
+			ConfigType: &envoy_metrics_v3.StatsSink_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_metrics_v3.StatsdSink{
+					StatsdSpecifier: &envoy_metrics_v3.StatsdSink_Address{
+						Address: udpAddress(c.StatsdAddress),
+					},
+					Prefix: c.StatsPrefix,
+				}),
+			},
+		})
+	}
+
+	if c.DogStatsdAddress != "" {
+		sinks = append(sinks, &envoy_metrics_v3.StatsSink{
+			Name: "envoy.stat_sinks.dog_statsd",
+			ConfigType: &envoy_metrics_v3.StatsSink_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_metrics_v3.DogStatsdSink{
+					DogStatsdSpecifier: &envoy_metrics_v3.DogStatsdSink_Address{
+						Address: udpAddress(c.DogStatsdAddress),
+					},
+					Prefix: c.StatsPrefix,
+				}),
+			},
+		})
+	}
+
+	return sinks
+}
+
+func statsFlushInterval(c *envoy.BootstrapConfig) *duration.Duration {
+	if c.StatsFlushInterval == 0 {
+		return nil
+	}
+	return protobuf.Duration(c.StatsFlushInterval)
+}
+
+// statsConfig returns the stats matcher which restricts the stats
+// Envoy creates to those matching the inclusion regexes.
+func statsConfig(c *envoy.BootstrapConfig) *envoy_metrics_v3.StatsConfig {
+	if len(c.StatsInclusionRegexes) == 0 {
+		return nil
+	}
+
+	var patterns []*envoy_matcher_v3.StringMatcher
+	for _, re := range c.StatsInclusionRegexes {
+		patterns = append(patterns, &envoy_matcher_v3.StringMatcher{
+			MatchPattern: &envoy_matcher_v3.StringMatcher_SafeRegex{
+				SafeRegex: SafeRegexMatch(re),
+			},
+		})
+	}
+
+	return &envoy_metrics_v3.StatsConfig{
+		StatsMatcher: &envoy_metrics_v3.StatsMatcher{
+			StatsMatcher: &envoy_metrics_v3.StatsMatcher_InclusionList{
+				InclusionList: &envoy_matcher_v3.ListStringMatcher{
+					Patterns: patterns,
+				},
+			},
+		},
+	}
+}
+
+// overloadManager returns the overload manager configuration which
+// protects Envoy from exhausting its memory. It returns nil when no
+// maximum heap size is configured.
+func overloadManager(c *envoy.BootstrapConfig) *envoy_overload_v3.OverloadManager {
+	if c.MaximumHeapSizeBytes == 0 {
+		return nil
+	}
+
+	const fixedHeap = "envoy.resource_monitors.fixed_heap"
+
+	trigger := func(threshold float64) []*envoy_overload_v3.Trigger {
+		return []*envoy_overload_v3.Trigger{{
+			Name: fixedHeap,
+			TriggerOneof: &envoy_overload_v3.Trigger_Threshold{
+				Threshold: &envoy_overload_v3.ThresholdTrigger{
+					Value: threshold,
+				},
+			},
+		}}
+	}
+
+	return &envoy_overload_v3.OverloadManager{
+		RefreshInterval: protobuf.Duration(250 * time.Millisecond),
+		ResourceMonitors: []*envoy_overload_v3.ResourceMonitor{{
+			Name: fixedHeap,
+			ConfigType: &envoy_overload_v3.ResourceMonitor_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_fixed_heap_v3.FixedHeapConfig{
+					MaxHeapSizeBytes: c.MaximumHeapSizeBytes,
+				}),
+			},
+		}},
+		Actions: []*envoy_overload_v3.OverloadAction{{
+			Name:     "envoy.overload_actions.shrink_heap",
+			Triggers: trigger(c.GetShrinkHeapThreshold()),
+		}, {
+			Name:     "envoy.overload_actions.stop_accepting_requests",
+			Triggers: trigger(c.GetStopAcceptingRequestsThreshold()),
+		}, {
+			Name:     "envoy.overload_actions.stop_accepting_connections",
+			Triggers: trigger(c.GetStopAcceptingRequestsThreshold()),
+		}},
+	}
+}
+
+// staticSecrets returns the static secret holding the certificate and
+// key Envoy uses to connect to Contour, so that the stats listener can
+// be served over TLS with the xDS certificate. It returns nil if the
+// connection to Contour is not secured with TLS.
+func staticSecrets(c *envoy.BootstrapConfig) []*envoy_tls_v3.Secret {
+	if c.GrpcClientCert == "" || c.GrpcClientKey == "" {
+		return nil
+	}
+
+	return []*envoy_tls_v3.Secret{{
+		Name: XDSCertificateSecretName,
+		Type: &envoy_tls_v3.Secret_TlsCertificate{
+			TlsCertificate: &envoy_tls_v3.TlsCertificate{
+				CertificateChain: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_Filename{
+						Filename: c.GrpcClientCert,
+					},
+				},
+				PrivateKey: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_Filename{
+						Filename: c.GrpcClientKey,
+					},
+				},
+			},
+		},
+	}}
+}
+
+// layeredRuntime returns the runtime configuration of Envoy. The
+// dynamic layer is fetched from Contour over RTDS, so that runtime
+// values such as connection limits can be changed without changing
+// the bootstrap. The static layer holds the global downstream
+// connection limit, if configured, and is overridden by the dynamic
+// layer.
+func layeredRuntime(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.LayeredRuntime {
+	var layers []*envoy_bootstrap_v3.RuntimeLayer
+
+	if c.MaximumDownstreamConnections > 0 {
+		layers = append(layers, &envoy_bootstrap_v3.RuntimeLayer{
+			Name: "static_layer",
+			LayerSpecifier: &envoy_bootstrap_v3.RuntimeLayer_StaticLayer{
+				StaticLayer: &_struct.Struct{
+					Fields: map[string]*_struct.Value{
+						GlobalDownstreamMaxConnectionsKey: {
+							Kind: &_struct.Value_NumberValue{
+								NumberValue: float64(c.MaximumDownstreamConnections),
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	layers = append(layers, &envoy_bootstrap_v3.RuntimeLayer{
+		Name: DynamicRuntimeLayerName,
+		LayerSpecifier: &envoy_bootstrap_v3.RuntimeLayer_RtdsLayer_{
+			RtdsLayer: &envoy_bootstrap_v3.RuntimeLayer_RtdsLayer{
+				Name:       DynamicRuntimeLayerName,
+				RtdsConfig: ConfigSource("contour"),
+			},
+		},
+	}, &envoy_bootstrap_v3.RuntimeLayer{
+		// Keep the admin layer so that runtime values can
+		// still be changed through the admin interface.
+		Name: "admin_layer",
+		LayerSpecifier: &envoy_bootstrap_v3.RuntimeLayer_AdminLayer_{
+			AdminLayer: &envoy_bootstrap_v3.RuntimeLayer_AdminLayer{},
+		},
+	})
+
+	return &envoy_bootstrap_v3.LayeredRuntime{
+		Layers: layers,
 	}
 }
 
@@ -300,7 +843,9 @@ func upstreamSdsTLSContext(certificateSdsFile, validationSdsFile string) *envoy_
 }
 
 // tlsCertificateSdsSecretConfig creates DiscoveryResponse with file based SDS resource
-// including paths to TLS certificates and key
+// including paths to TLS certificates and key. The directory holding the certificate
+// is watched so that Envoy reloads the certificate and key when a mounted Secret is
+// updated, which Kubernetes does by atomically swapping a symlink in that directory.
 func tlsCertificateSdsSecretConfig(c *envoy.BootstrapConfig) *envoy_service_discovery_v3.DiscoveryResponse {
 	secret := &envoy_tls_v3.Secret{
 		Name: "contour_xds_tls_certificate",
@@ -316,6 +861,9 @@ func tlsCertificateSdsSecretConfig(c *envoy.BootstrapConfig) *envoy_service_disc
 						Filename: c.GrpcClientKey,
 					},
 				},
+				WatchedDirectory: &envoy_core_v3.WatchedDirectory{
+					Path: watchedDirectory(c, c.GrpcClientCert),
+				},
 			},
 		},
 	}
@@ -325,8 +873,17 @@ func tlsCertificateSdsSecretConfig(c *envoy.BootstrapConfig) *envoy_service_disc
 	}
 }
 
+// watchedDirectory returns the directory Envoy watches to reload
+// the file f, which is the configured GrpcWatchedDirectory, if any.
+func watchedDirectory(c *envoy.BootstrapConfig, f string) string {
+	if c.GrpcWatchedDirectory != "" {
+		return c.GrpcWatchedDirectory
+	}
+	return path.Dir(f)
+}
+
 // validationContextSdsSecretConfig creates DiscoveryResponse with file based SDS resource
-// including path to CA certificate bundle
+// including path to CA certificate bundle, whose directory is watched for updates.
 func validationContextSdsSecretConfig(c *envoy.BootstrapConfig) *envoy_service_discovery_v3.DiscoveryResponse {
 	secret := &envoy_tls_v3.Secret{
 		Name: "contour_xds_tls_validation_context",
@@ -337,6 +894,9 @@ func validationContextSdsSecretConfig(c *envoy.BootstrapConfig) *envoy_service_d
 						Filename: c.GrpcCABundle,
 					},
 				},
+				WatchedDirectory: &envoy_core_v3.WatchedDirectory{
+					Path: watchedDirectory(c, c.GrpcCABundle),
+				},
 				MatchSubjectAltNames: []*envoy_matcher_v3.StringMatcher{{
 					MatchPattern: &envoy_matcher_v3.StringMatcher_Exact{
 						Exact: "contour",
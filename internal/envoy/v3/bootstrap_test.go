@@ -14,8 +14,11 @@
 package v3
 
 import (
-	"path"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	envoy_bootstrap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -171,6 +174,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -313,6 +344,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -455,6 +514,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -598,6 +685,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -741,6 +856,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -884,6 +1027,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -1029,6 +1200,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -1043,6 +1242,19 @@ func TestBootstrap(t *testing.T) {
 			},
 			wantedBootstrapConfig: `{
   "static_resources": {
+    "secrets": [
+      {
+        "name": "envoy_xds_certificate",
+        "tls_certificate": {
+          "certificate_chain": {
+            "filename": "client.cert"
+          },
+          "private_key": {
+            "filename": "client.key"
+          }
+        }
+      }
+    ],
     "clusters": [
       {
         "name": "contour",
@@ -1205,6 +1417,34 @@ func TestBootstrap(t *testing.T) {
         "mode": "420"
       }
     }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
   }
 }`,
 		},
@@ -1220,6 +1460,19 @@ func TestBootstrap(t *testing.T) {
 			},
 			wantedBootstrapConfig: `{
         "static_resources": {
+          "secrets": [
+            {
+              "name": "envoy_xds_certificate",
+              "tls_certificate": {
+                "certificate_chain": {
+                  "filename": "client.cert"
+                },
+                "private_key": {
+                  "filename": "client.key"
+                }
+              }
+            }
+          ],
           "clusters": [
             {
               "name": "contour",
@@ -1378,7 +1631,35 @@ func TestBootstrap(t *testing.T) {
 			   "mode": "420"
 			}
           }
+        },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
         }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
+  }
     }`,
 			wantedTLSCertificateConfig: `{
       "resources": [
@@ -1391,6 +1672,9 @@ func TestBootstrap(t *testing.T) {
             },
             "private_key": {
               "filename": "client.key"
+            },
+            "watched_directory": {
+              "path": "."
             }
           }
         }
@@ -1405,6 +1689,9 @@ func TestBootstrap(t *testing.T) {
             "trusted_ca": {
               "filename": "CA.cert"
             },
+            "watched_directory": {
+              "path": "."
+            },
             "match_subject_alt_names": [
               {
                 "exact": "contour"
@@ -1415,56 +1702,1013 @@ func TestBootstrap(t *testing.T) {
       ]
     }`,
 		},
-		"return error when not providing all certificate related parameters": {
+		"--overload-max-heap=2147483648 --overload-max-downstream-connections=50000": {
 			config: envoy.BootstrapConfig{
-				Path:           "envoy.json",
-				Namespace:      "testing-ns",
-				ResourcesDir:   "resources",
-				GrpcClientCert: "client.cert",
-				GrpcClientKey:  "client.key",
+				Path:                         "envoy.json",
+				Namespace:                    "testing-ns",
+				MaximumHeapSizeBytes:         2147483648,
+				MaximumDownstreamConnections: 50000,
 			},
-			wantedError: true,
-		}}
-
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			steps, gotError := bootstrap(&tc.config)
-			assert.Equal(t, gotError != nil, tc.wantedError)
-
-			gotConfigs := map[string]proto.Message{}
-			for _, step := range steps {
-				path, config := step(&tc.config)
-				gotConfigs[path] = config
-			}
-
-			sdsTLSCertificatePath := path.Join(tc.config.ResourcesDir, envoy.SDSResourcesSubdirectory, envoy.SDSTLSCertificateFile)
-			sdsValidationContextPath := path.Join(tc.config.ResourcesDir, envoy.SDSResourcesSubdirectory, envoy.SDSValidationContextFile)
-
-			if tc.wantedBootstrapConfig != "" {
-				want := new(envoy_bootstrap_v3.Bootstrap)
-				unmarshal(t, tc.wantedBootstrapConfig, want)
-				protobuf.ExpectEqual(t, want, gotConfigs[tc.config.Path])
-				delete(gotConfigs, tc.config.Path)
-			}
-
-			if tc.wantedTLSCertificateConfig != "" {
-				want := new(envoy_service_discovery_v3.DiscoveryResponse)
-				unmarshal(t, tc.wantedTLSCertificateConfig, want)
-				protobuf.ExpectEqual(t, want, gotConfigs[sdsTLSCertificatePath])
-				delete(gotConfigs, sdsTLSCertificatePath)
-			}
-
-			if tc.wantedValidationContextConfig != "" {
-				want := new(envoy_service_discovery_v3.DiscoveryResponse)
-				unmarshal(t, tc.wantedValidationContextConfig, want)
-				protobuf.ExpectEqual(t, want, gotConfigs[sdsValidationContextPath])
-				delete(gotConfigs, sdsValidationContextPath)
-			}
-
-			if len(gotConfigs) > 0 {
-				t.Fatalf("got more configs than wanted: %s", gotConfigs)
-			}
-		})
+			wantedBootstrapConfig: `{
+  "static_resources": {
+    "clusters": [
+      {
+        "name": "contour",
+        "alt_stat_name": "testing-ns_contour_8001",
+        "type": "STATIC",
+        "connect_timeout": "5s",
+        "load_assignment": {
+          "cluster_name": "contour",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 8001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        },
+        "circuit_breakers": {
+          "thresholds": [
+            {
+              "priority": "HIGH",
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            },
+            {
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            }
+          ]
+        },
+        "typed_extension_protocol_options": {
+          "envoy.extensions.upstreams.http.v3.HttpProtocolOptions": {
+            "@type": "type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions",
+            "explicit_http_config": {
+              "http2_protocol_options": {}
+            }
+          }
+        },
+        "upstream_connection_options": {
+          "tcp_keepalive": {
+            "keepalive_probes": 3,
+            "keepalive_time": 30,
+            "keepalive_interval": 5
+          }
+        }
+      },
+      {
+        "name": "envoy-admin",
+        "alt_stat_name": "testing-ns_envoy-admin_9001",
+        "type": "STATIC",
+        "connect_timeout": "0.250s",
+        "load_assignment": {
+          "cluster_name": "envoy-admin",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "pipe": {
+                        "path": "/admin/admin.sock",
+                        "mode": "420"
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        }
+      }
+    ]
+  },
+  "dynamic_resources": {
+    "lds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+	  "resource_api_version": "V3"
+    },
+    "cds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+ 	  "resource_api_version": "V3"
+    }
+  },
+  "admin": {
+    "access_log": [
+      {
+        "name": "envoy.access_loggers.file",
+        "typed_config": {
+          "@type": "type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog",
+          "path": "/dev/null"
+        }
+      }
+    ],
+    "address": {
+   	 "pipe": {
+        "path": "/admin/admin.sock",
+        "mode": "420"
+      }
+    }
+  },
+  "overload_manager": {
+    "refresh_interval": "0.250s",
+    "resource_monitors": [
+      {
+        "name": "envoy.resource_monitors.fixed_heap",
+        "typed_config": {
+          "@type": "type.googleapis.com/envoy.extensions.resource_monitors.fixed_heap.v3.FixedHeapConfig",
+          "max_heap_size_bytes": "2147483648"
+        }
+      }
+    ],
+    "actions": [
+      {
+        "name": "envoy.overload_actions.shrink_heap",
+        "triggers": [
+          {
+            "name": "envoy.resource_monitors.fixed_heap",
+            "threshold": {
+              "value": 0.95
+            }
+          }
+        ]
+      },
+      {
+        "name": "envoy.overload_actions.stop_accepting_requests",
+        "triggers": [
+          {
+            "name": "envoy.resource_monitors.fixed_heap",
+            "threshold": {
+              "value": 0.98
+            }
+          }
+        ]
+      },
+      {
+        "name": "envoy.overload_actions.stop_accepting_connections",
+        "triggers": [
+          {
+            "name": "envoy.resource_monitors.fixed_heap",
+            "threshold": {
+              "value": 0.98
+            }
+          }
+        ]
+      }
+    ]
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "static_layer",
+        "static_layer": {
+          "overload.global_downstream_max_connections": 50000
+        }
+      },
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
+  }
+}`,
+		},
+		"return error when shrink heap threshold is greater than stop accepting threshold": {
+			config: envoy.BootstrapConfig{
+				Path:                           "envoy.json",
+				Namespace:                      "testing-ns",
+				MaximumHeapSizeBytes:           2147483648,
+				ShrinkHeapThreshold:            0.99,
+				StopAcceptingRequestsThreshold: 0.9,
+			},
+			wantedError: true,
+		},
+		"return error when stop accepting threshold is greater than 1": {
+			config: envoy.BootstrapConfig{
+				Path:                           "envoy.json",
+				Namespace:                      "testing-ns",
+				MaximumHeapSizeBytes:           2147483648,
+				StopAcceptingRequestsThreshold: 1.5,
+			},
+			wantedError: true,
+		},
+		"--statsd-address=10.0.0.1:8125 --dogstatsd-address=10.0.0.2:8125 --stats-prefix=edge --stats-flush-interval=10s --stats-inclusion-regex=^cluster\\.": {
+			config: envoy.BootstrapConfig{
+				Path:                  "envoy.json",
+				Namespace:             "testing-ns",
+				StatsdAddress:         "10.0.0.1:8125",
+				DogStatsdAddress:      "10.0.0.2:8125",
+				StatsPrefix:           "edge",
+				StatsFlushInterval:    10 * time.Second,
+				StatsInclusionRegexes: []string{"^cluster\\."},
+			},
+			wantedBootstrapConfig: `{
+  "static_resources": {
+    "clusters": [
+      {
+        "name": "contour",
+        "alt_stat_name": "testing-ns_contour_8001",
+        "type": "STATIC",
+        "connect_timeout": "5s",
+        "load_assignment": {
+          "cluster_name": "contour",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 8001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        },
+        "circuit_breakers": {
+          "thresholds": [
+            {
+              "priority": "HIGH",
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            },
+            {
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            }
+          ]
+        },
+        "typed_extension_protocol_options": {
+          "envoy.extensions.upstreams.http.v3.HttpProtocolOptions": {
+            "@type": "type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions",
+            "explicit_http_config": {
+              "http2_protocol_options": {}
+            }
+          }
+        },
+        "upstream_connection_options": {
+          "tcp_keepalive": {
+            "keepalive_probes": 3,
+            "keepalive_time": 30,
+            "keepalive_interval": 5
+          }
+        }
+      },
+      {
+        "name": "envoy-admin",
+        "alt_stat_name": "testing-ns_envoy-admin_9001",
+        "type": "STATIC",
+        "connect_timeout": "0.250s",
+        "load_assignment": {
+          "cluster_name": "envoy-admin",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "pipe": {
+                        "path": "/admin/admin.sock",
+                        "mode": "420"
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        }
+      }
+    ]
+  },
+  "dynamic_resources": {
+    "lds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+	  "resource_api_version": "V3"
+    },
+    "cds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+ 	  "resource_api_version": "V3"
+    }
+  },
+  "admin": {
+    "access_log": [
+      {
+        "name": "envoy.access_loggers.file",
+        "typed_config": {
+          "@type": "type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog",
+          "path": "/dev/null"
+        }
+      }
+    ],
+    "address": {
+   	 "pipe": {
+        "path": "/admin/admin.sock",
+        "mode": "420"
+      }
+    }
+  },
+  "stats_sinks": [
+    {
+      "name": "envoy.stat_sinks.statsd",
+      "typed_config": {
+        "@type": "type.googleapis.com/envoy.config.metrics.v3.StatsdSink",
+        "address": {
+          "socket_address": {
+            "protocol": "UDP",
+            "address": "10.0.0.1",
+            "port_value": 8125
+          }
+        },
+        "prefix": "edge"
+      }
+    },
+    {
+      "name": "envoy.stat_sinks.dog_statsd",
+      "typed_config": {
+        "@type": "type.googleapis.com/envoy.config.metrics.v3.DogStatsdSink",
+        "address": {
+          "socket_address": {
+            "protocol": "UDP",
+            "address": "10.0.0.2",
+            "port_value": 8125
+          }
+        },
+        "prefix": "edge"
+      }
+    }
+  ],
+  "stats_flush_interval": "10s",
+  "stats_config": {
+    "stats_matcher": {
+      "inclusion_list": {
+        "patterns": [
+          {
+            "safe_regex": {
+              "google_re2": {},
+              "regex": "^cluster\\."
+            }
+          }
+        ]
+      }
+    }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
+  }
+}`,
+		},
+		"return error when statsd address is not an IP address": {
+			config: envoy.BootstrapConfig{
+				Path:          "envoy.json",
+				Namespace:     "testing-ns",
+				StatsdAddress: "statsd:8125",
+			},
+			wantedError: true,
+		},
+		"return error when stats flush interval is too long": {
+			config: envoy.BootstrapConfig{
+				Path:               "envoy.json",
+				Namespace:          "testing-ns",
+				StatsFlushInterval: time.Hour,
+			},
+			wantedError: true,
+		},
+		"return error when stats inclusion regex is invalid": {
+			config: envoy.BootstrapConfig{
+				Path:                  "envoy.json",
+				Namespace:             "testing-ns",
+				StatsInclusionRegexes: []string{"cluster\\.(foo"},
+			},
+			wantedError: true,
+		},
+		"--admin-listener-port=9002 --admin-listener-path=/ready --admin-listener-path=/stats/prometheus --admin-listener-cert-file=admin.crt --admin-listener-key-file=admin.key --admin-listener-cafile=ca.crt": {
+			config: envoy.BootstrapConfig{
+				Path:                  "envoy.json",
+				Namespace:             "testing-ns",
+				AdminListenerPort:     9002,
+				AdminListenerPaths:    []string{"/ready", "/stats/prometheus"},
+				AdminListenerCertFile: "admin.crt",
+				AdminListenerKeyFile:  "admin.key",
+				AdminListenerCAFile:   "ca.crt",
+				SkipFilePathCheck:     true,
+			},
+			wantedBootstrapConfig: `{
+  "static_resources": {
+    "clusters": [
+      {
+        "name": "contour",
+        "alt_stat_name": "testing-ns_contour_8001",
+        "type": "STATIC",
+        "connect_timeout": "5s",
+        "load_assignment": {
+          "cluster_name": "contour",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 8001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        },
+        "circuit_breakers": {
+          "thresholds": [
+            {
+              "priority": "HIGH",
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            },
+            {
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            }
+          ]
+        },
+        "typed_extension_protocol_options": {
+          "envoy.extensions.upstreams.http.v3.HttpProtocolOptions": {
+            "@type": "type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions",
+            "explicit_http_config": {
+              "http2_protocol_options": {}
+            }
+          }
+        },
+        "upstream_connection_options": {
+          "tcp_keepalive": {
+            "keepalive_probes": 3,
+            "keepalive_time": 30,
+            "keepalive_interval": 5
+          }
+        }
+      },
+      {
+        "name": "envoy-admin",
+        "alt_stat_name": "testing-ns_envoy-admin_9001",
+        "type": "STATIC",
+        "connect_timeout": "0.250s",
+        "load_assignment": {
+          "cluster_name": "envoy-admin",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "pipe": {
+                        "path": "/admin/admin.sock",
+                        "mode": "420"
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        }
+      }
+    ],
+    "listeners": [
+      {
+        "name": "envoy-admin-allowlist",
+        "address": {
+          "socket_address": {
+            "address": "127.0.0.1",
+            "port_value": 9002
+          }
+        },
+        "filter_chains": [
+          {
+            "filters": [
+              {
+                "name": "envoy.filters.network.http_connection_manager",
+                "typed_config": {
+                  "@type": "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+                  "stat_prefix": "envoy-admin-allowlist",
+                  "route_config": {
+                    "virtual_hosts": [
+                      {
+                        "name": "backend",
+                        "domains": [
+                          "*"
+                        ],
+                        "routes": [
+                    {
+                      "match": {
+                        "path": "/ready",
+                        "headers": [
+                          {
+                            "name": ":method",
+                            "exact_match": "GET"
+                          }
+                        ]
+                      },
+                      "route": {
+                        "cluster": "envoy-admin"
+                      }
+                    },
+                    {
+                      "match": {
+                        "path": "/stats/prometheus",
+                        "headers": [
+                          {
+                            "name": ":method",
+                            "exact_match": "GET"
+                          }
+                        ]
+                      },
+                      "route": {
+                        "cluster": "envoy-admin"
+                      }
+                    }
+                        ]
+                      }
+                    ]
+                  },
+                  "http_filters": [
+                    {
+                      "name": "envoy.filters.http.router"
+                    }
+                  ],
+                  "normalize_path": true
+                }
+              }
+            ],
+            "transport_socket": {
+              "name": "envoy.transport_sockets.tls",
+              "typed_config": {
+                "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.DownstreamTlsContext",
+                "common_tls_context": {
+                  "tls_params": {
+                    "tls_minimum_protocol_version": "TLSv1_2",
+                    "tls_maximum_protocol_version": "TLSv1_3"
+                  },
+                  "tls_certificates": [
+                    {
+                      "certificate_chain": {
+                        "filename": "admin.crt"
+                      },
+                      "private_key": {
+                        "filename": "admin.key"
+                      }
+                    }
+                  ],
+                  "validation_context": {
+                    "trusted_ca": {
+                      "filename": "ca.crt"
+                    }
+                  }
+                },
+                "require_client_certificate": true
+              }
+            }
+          }
+        ],
+        "socket_options": [
+          {
+            "description": "Enable TCP keep-alive",
+            "level": "1",
+            "name": "9",
+            "int_value": "1",
+            "state": "STATE_LISTENING"
+          },
+          {
+            "description": "TCP keep-alive initial idle time",
+            "level": "6",
+            "name": "4",
+            "int_value": "45",
+            "state": "STATE_LISTENING"
+          },
+          {
+            "description": "TCP keep-alive time between probes",
+            "level": "6",
+            "name": "5",
+            "int_value": "5",
+            "state": "STATE_LISTENING"
+          },
+          {
+            "description": "TCP keep-alive probe count",
+            "level": "6",
+            "name": "6",
+            "int_value": "9",
+            "state": "STATE_LISTENING"
+          }
+        ]
+      }
+    ]
+  },
+  "dynamic_resources": {
+    "lds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+	  "resource_api_version": "V3"
+    },
+    "cds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+ 	  "resource_api_version": "V3"
+    }
+  },
+  "admin": {
+    "access_log": [
+      {
+        "name": "envoy.access_loggers.file",
+        "typed_config": {
+          "@type": "type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog",
+          "path": "/dev/null"
+        }
+      }
+    ],
+    "address": {
+   	 "pipe": {
+        "path": "/admin/admin.sock",
+        "mode": "420"
+      }
+    }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "dynamic",
+        "rtds_layer": {
+          "name": "dynamic",
+          "rtds_config": {
+            "api_config_source": {
+              "api_type": "GRPC",
+              "transport_api_version": "V3",
+              "grpc_services": [
+                {
+                  "envoy_grpc": {
+                    "cluster_name": "contour"
+                  }
+                }
+              ]
+            },
+            "resource_api_version": "V3"
+          }
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
+  }
+}`,
+		},
+		"return error when admin listener has no paths": {
+			config: envoy.BootstrapConfig{
+				Path:              "envoy.json",
+				Namespace:         "testing-ns",
+				AdminListenerPort: 9002,
+			},
+			wantedError: true,
+		},
+		"return error when admin listener has a cert but no key": {
+			config: envoy.BootstrapConfig{
+				Path:                  "envoy.json",
+				Namespace:             "testing-ns",
+				AdminListenerPort:     9002,
+				AdminListenerPaths:    []string{"/ready"},
+				AdminListenerCertFile: "admin.crt",
+				SkipFilePathCheck:     true,
+			},
+			wantedError: true,
+		},
+		"return error when not providing all certificate related parameters": {
+			config: envoy.BootstrapConfig{
+				Path:           "envoy.json",
+				Namespace:      "testing-ns",
+				ResourcesDir:   "resources",
+				GrpcClientCert: "client.cert",
+				GrpcClientKey:  "client.key",
+			},
+			wantedError: true,
+		},
+		"return error when refreshing SDS resources without a resources dir": {
+			config: envoy.BootstrapConfig{
+				Path:               "envoy.json",
+				Namespace:          "testing-ns",
+				GrpcCABundle:       "CA.cert",
+				GrpcClientCert:     "client.cert",
+				GrpcClientKey:      "client.key",
+				SDSRefreshInterval: time.Minute,
+				SkipFilePathCheck:  true,
+			},
+			wantedError: true,
+		}}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			steps, gotError := bootstrap(&tc.config)
+			assert.Equal(t, gotError != nil, tc.wantedError)
+
+			gotConfigs := map[string]proto.Message{}
+			for _, step := range steps {
+				path, config := step(&tc.config)
+				gotConfigs[path] = config
+			}
+
+			sdsTLSCertificatePath := tc.config.GetSDSTLSCertificatePath()
+			sdsValidationContextPath := tc.config.GetSDSValidationContextPath()
+
+			if tc.wantedBootstrapConfig != "" {
+				want := new(envoy_bootstrap_v3.Bootstrap)
+				unmarshal(t, tc.wantedBootstrapConfig, want)
+				protobuf.ExpectEqual(t, want, gotConfigs[tc.config.Path])
+				delete(gotConfigs, tc.config.Path)
+			}
+
+			if tc.wantedTLSCertificateConfig != "" {
+				want := new(envoy_service_discovery_v3.DiscoveryResponse)
+				unmarshal(t, tc.wantedTLSCertificateConfig, want)
+				protobuf.ExpectEqual(t, want, gotConfigs[sdsTLSCertificatePath])
+				delete(gotConfigs, sdsTLSCertificatePath)
+			}
+
+			if tc.wantedValidationContextConfig != "" {
+				want := new(envoy_service_discovery_v3.DiscoveryResponse)
+				unmarshal(t, tc.wantedValidationContextConfig, want)
+				protobuf.ExpectEqual(t, want, gotConfigs[sdsValidationContextPath])
+				delete(gotConfigs, sdsValidationContextPath)
+			}
+
+			if len(gotConfigs) > 0 {
+				t.Fatalf("got more configs than wanted: %s", gotConfigs)
+			}
+		})
+	}
+}
+
+func TestBootstrapSDSPaths(t *testing.T) {
+	c := &envoy.BootstrapConfig{
+		Path:                     "envoy.json",
+		Namespace:                "testing-ns",
+		SDSTLSCertificatePath:    "/sds/certificate.json",
+		SDSValidationContextPath: "/sds/ca.json",
+		GrpcCABundle:             "/certs/ca/ca.crt",
+		GrpcClientCert:           "/certs/tls/tls.crt",
+		GrpcClientKey:            "/certs/tls/tls.key",
+		GrpcWatchedDirectory:     "/certs",
+		SkipFilePathCheck:        true,
+	}
+
+	steps, err := bootstrap(c)
+	checkErr(t, err)
+
+	got := map[string]proto.Message{}
+	for _, step := range steps {
+		path, config := step(c)
+		got[path] = config
+	}
+	assert.Len(t, got, 3)
+
+	tlsCertificate := new(envoy_service_discovery_v3.DiscoveryResponse)
+	unmarshal(t, `{
+  "resources": [
+    {
+      "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret",
+      "name": "contour_xds_tls_certificate",
+      "tls_certificate": {
+        "certificate_chain": {
+          "filename": "/certs/tls/tls.crt"
+        },
+        "private_key": {
+          "filename": "/certs/tls/tls.key"
+        },
+        "watched_directory": {
+          "path": "/certs"
+        }
+      }
+    }
+  ]
+}`, tlsCertificate)
+	protobuf.ExpectEqual(t, tlsCertificate, got["/sds/certificate.json"])
+
+	validationContext := new(envoy_service_discovery_v3.DiscoveryResponse)
+	unmarshal(t, `{
+  "resources": [
+    {
+      "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret",
+      "name": "contour_xds_tls_validation_context",
+      "validation_context": {
+        "trusted_ca": {
+          "filename": "/certs/ca/ca.crt"
+        },
+        "watched_directory": {
+          "path": "/certs"
+        },
+        "match_subject_alt_names": [
+          {
+            "exact": "contour"
+          }
+        ]
+      }
+    }
+  ]
+}`, validationContext)
+	protobuf.ExpectEqual(t, validationContext, got["/sds/ca.json"])
+
+	b := got["envoy.json"].(*envoy_bootstrap_v3.Bootstrap)
+	protobuf.ExpectEqual(t,
+		UpstreamTLSTransportSocket(upstreamSdsTLSContext("/sds/certificate.json", "/sds/ca.json")),
+		b.StaticResources.Clusters[0].TransportSocket)
+}
+
+func TestWriteBootstrapFileModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootstrap")
+	checkErr(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &envoy.BootstrapConfig{
+		Path:              filepath.Join(dir, "envoy.json"),
+		Namespace:         "testing-ns",
+		ResourcesDir:      filepath.Join(dir, "resources"),
+		GrpcCABundle:      "CA.cert",
+		GrpcClientCert:    "client.cert",
+		GrpcClientKey:     "client.key",
+		FileMode:          0600,
+		DirMode:           0700,
+		SkipFilePathCheck: true,
+	}
+	checkErr(t, WriteBootstrap(c))
+
+	for f, want := range map[string]os.FileMode{
+		c.Path:                          0600,
+		c.GetSDSTLSCertificatePath():    0600,
+		c.GetSDSValidationContextPath(): 0600,
+		filepath.Join(dir, "resources", envoy.SDSResourcesSubdirectory): 0700,
+	} {
+		fi, err := os.Stat(f)
+		checkErr(t, err)
+		assert.Equal(t, want, fi.Mode().Perm(), f)
+	}
+}
+
+func TestSDSWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sds")
+	checkErr(t, err)
+	defer os.RemoveAll(dir)
+
+	c := &envoy.BootstrapConfig{
+		SDSTLSCertificatePath:    filepath.Join(dir, "certificate.json"),
+		SDSValidationContextPath: filepath.Join(dir, "ca.json"),
+		GrpcCABundle:             filepath.Join(dir, "ca.crt"),
+		GrpcClientCert:           filepath.Join(dir, "tls.crt"),
+		GrpcClientKey:            filepath.Join(dir, "tls.key"),
+	}
+	for _, f := range []string{c.GrpcCABundle, c.GrpcClientCert, c.GrpcClientKey} {
+		checkErr(t, ioutil.WriteFile(f, []byte("old"), 0600))
+	}
+
+	w := newSDSWatcher(c)
+
+	// Unchanged files do not write the SDS resources.
+	checkErr(t, w.refresh())
+	_, err = os.Stat(c.SDSTLSCertificatePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// Rotating the certificate writes both SDS resources.
+	checkErr(t, ioutil.WriteFile(c.GrpcClientCert, []byte("rotated"), 0600))
+	checkErr(t, w.refresh())
+	for _, f := range []string{c.SDSTLSCertificatePath, c.SDSValidationContextPath} {
+		_, err := os.Stat(f)
+		checkErr(t, err)
 	}
 }
 
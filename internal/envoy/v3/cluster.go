@@ -48,15 +48,55 @@ func Cluster(c *dag.Cluster) *envoy_cluster_v3.Cluster {
 	cluster.HealthChecks = edshealthcheck(c)
 	cluster.DnsLookupFamily = parseDNSLookupFamily(c.DNSLookupFamily)
 
-	switch len(service.ExternalName) {
-	case 0:
+	switch {
+	case len(service.StaticEndpoints) > 0:
+		// static endpoints set, the endpoints are part of the cluster
+		var addrs []*envoy_core_v3.Address
+		for _, ep := range service.StaticEndpoints {
+			addrs = append(addrs, SocketAddress(ep.Address, int(ep.Port)))
+		}
+		cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_STATIC)
+		cluster.LoadAssignment = ClusterLoadAssignment(c.ClusterLoadAssignmentName(), addrs...)
+		setHealthCheckPort(cluster.LoadAssignment, c.HealthCheckPort())
+	case len(service.ExternalName) == 0:
 		// external name not set, cluster will be discovered via EDS
 		cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS)
-		cluster.EdsClusterConfig = edsconfig("contour", service)
+		cluster.EdsClusterConfig = edsconfig("contour", c)
 	default:
 		// external name set, use hard coded DNS name
 		cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS)
 		cluster.LoadAssignment = StaticClusterLoadAssignment(service)
+
+		// The DNS names of the backups follow that of the
+		// Upstream, each at the next lower priority.
+		for i, b := range c.Backups {
+			lle := Endpoints(SocketAddress(b.ExternalName, int(b.Weighted.ServicePort.Port)))
+			lle[0].Priority = uint32(i + 1)
+			cluster.LoadAssignment.Endpoints = append(cluster.LoadAssignment.Endpoints, lle...)
+		}
+		if len(c.Backups) > 0 {
+			cluster.LoadAssignment.Policy = FailoverPolicy()
+		}
+
+		setHealthCheckPort(cluster.LoadAssignment, c.HealthCheckPort())
+
+		// The DNS policy of the cluster overrides the one set
+		// by the Service annotations.
+		dnsPolicy := c.DNSPolicy
+		if dnsPolicy == nil {
+			dnsPolicy = service.DNSPolicy
+		}
+		if dnsPolicy != nil {
+			if dnsPolicy.DiscoveryType == dag.DNSDiscoveryTypeLogical {
+				cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_LOGICAL_DNS)
+			}
+			for _, r := range dnsPolicy.Resolvers {
+				cluster.DnsResolvers = append(cluster.DnsResolvers, SocketAddress(r.Address, int(r.Port)))
+			}
+			if dnsPolicy.LookupFamily != "" {
+				cluster.DnsLookupFamily = parseDNSLookupFamily(dnsPolicy.LookupFamily)
+			}
+		}
 	}
 
 	// Drain connections immediately if using healthchecks and the endpoint is known to be removed
@@ -146,6 +186,42 @@ func ExtensionCluster(ext *dag.ExtensionCluster) *envoy_cluster_v3.Cluster {
 	return cluster
 }
 
+// StaticCluster returns a cluster whose endpoints are the given
+// socket addresses, rather than discovered with EDS. If any of the
+// addresses is not an IP, the addresses are resolved with DNS.
+func StaticCluster(name string, protocol string, addrs ...*envoy_core_v3.Address) *envoy_cluster_v3.Cluster {
+	cluster := clusterDefaults()
+
+	cluster.Name = name
+	cluster.AltStatName = name
+	cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_STATIC)
+	for _, addr := range addrs {
+		if net.ParseIP(addr.GetSocketAddress().GetAddress()) == nil {
+			cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS)
+		}
+	}
+	cluster.LoadAssignment = ClusterLoadAssignment(name, addrs...)
+
+	if protocol == "h2c" {
+		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
+	}
+
+	return cluster
+}
+
+// PreserveHeaderCase configures an HTTP/1 cluster to preserve the
+// case of the header names of requests, rather than lowercasing them.
+// Clusters that have protocol options, i.e. that use HTTP/2, are not
+// changed.
+func PreserveHeaderCase(cluster *envoy_cluster_v3.Cluster) {
+	if len(cluster.TypedExtensionProtocolOptions) > 0 {
+		return
+	}
+	cluster.TypedExtensionProtocolOptions = http1ProtocolOptions(&envoy_core_v3.Http1ProtocolOptions{
+		HeaderKeyFormat: preserveCaseHeaderKeyFormat(),
+	})
+}
+
 // StaticClusterLoadAssignment creates a *envoy_endpoint_v3.ClusterLoadAssignment pointing to the external DNS address of the service
 func StaticClusterLoadAssignment(service *dag.Service) *envoy_endpoint_v3.ClusterLoadAssignment {
 	addr := SocketAddress(service.ExternalName, int(service.Weighted.ServicePort.Port))
@@ -158,13 +234,25 @@ func StaticClusterLoadAssignment(service *dag.Service) *envoy_endpoint_v3.Cluste
 	}
 }
 
-func edsconfig(cluster string, service *dag.Service) *envoy_cluster_v3.Cluster_EdsClusterConfig {
+// setHealthCheckPort sets the port that the endpoints of a cluster load
+// assignment are health checked on. A zero port is ignored.
+func setHealthCheckPort(cla *envoy_endpoint_v3.ClusterLoadAssignment, port uint32) {
+	if port == 0 {
+		return
+	}
+	for _, lle := range cla.Endpoints {
+		for _, lb := range lle.LbEndpoints {
+			lb.GetEndpoint().HealthCheckConfig = &envoy_endpoint_v3.Endpoint_HealthCheckConfig{
+				PortValue: port,
+			}
+		}
+	}
+}
+
+func edsconfig(cluster string, c *dag.Cluster) *envoy_cluster_v3.Cluster_EdsClusterConfig {
 	return &envoy_cluster_v3.Cluster_EdsClusterConfig{
-		EdsConfig: ConfigSource(cluster),
-		ServiceName: xds.ClusterLoadAssignmentName(
-			types.NamespacedName{Name: service.Weighted.ServiceName, Namespace: service.Weighted.ServiceNamespace},
-			service.Weighted.ServicePort.Name,
-		),
+		EdsConfig:   ConfigSource(cluster),
+		ServiceName: c.ClusterLoadAssignmentName(),
 	}
 }
 
@@ -206,6 +294,25 @@ func ClusterCommonLBConfig() *envoy_cluster_v3.Cluster_CommonLbConfig {
 	}
 }
 
+// CommonLBConfig sets the healthy panic threshold, as a percentage,
+// and the minimum number of hosts for zone aware routing of the
+// cluster. A zero minimum cluster size leaves Envoy's default.
+func CommonLBConfig(cluster *envoy_cluster_v3.Cluster, healthyPanicThreshold uint32, zoneAwareMinClusterSize uint64) {
+	if cluster.CommonLbConfig == nil {
+		cluster.CommonLbConfig = ClusterCommonLBConfig()
+	}
+	cluster.CommonLbConfig.HealthyPanicThreshold = &envoy_type.Percent{
+		Value: float64(healthyPanicThreshold),
+	}
+	if zoneAwareMinClusterSize > 0 {
+		cluster.CommonLbConfig.LocalityConfigSpecifier = &envoy_cluster_v3.Cluster_CommonLbConfig_ZoneAwareLbConfig_{
+			ZoneAwareLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig_ZoneAwareLbConfig{
+				MinClusterSize: protobuf.UInt64(zoneAwareMinClusterSize),
+			},
+		}
+	}
+}
+
 // ConfigSource returns a *envoy_core_v3.ConfigSource for cluster.
 func ConfigSource(cluster string) *envoy_core_v3.ConfigSource {
 	return &envoy_core_v3.ConfigSource{
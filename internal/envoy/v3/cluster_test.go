@@ -19,6 +19,8 @@ import (
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_preserve_case_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/http/header_formatters/preserve_case/v3"
 	envoy_extensions_upstream_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/golang/protobuf/proto"
@@ -83,6 +85,30 @@ func TestCluster(t *testing.T) {
 		},
 	}
 
+	backup := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-backup",
+			Namespace: "default",
+		},
+		Spec: s1.Spec,
+	}
+
+	externalBackup := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-backup",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			ExternalName: "backup.foo.io",
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       443,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
 	secret := &dag.Secret{
 		Object: &v1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -190,6 +216,71 @@ func TestCluster(t *testing.T) {
 				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
 			},
 		},
+		"service with backup": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				Backups:  []*dag.Service{service(backup)},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/b0c3f2f2e1",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http/backup-e6740cf10d",
+				},
+			},
+		},
+		"externalName service with backup": {
+			cluster: &dag.Cluster{
+				Upstream: service(s2),
+				Backups:  []*dag.Service{service(externalBackup)},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/b0c3f2f2e1",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS),
+				LoadAssignment: &envoy_endpoint_v3.ClusterLoadAssignment{
+					ClusterName: "default/kuard/http",
+					Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+						LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+							LBEndpoint(SocketAddress("foo.io", 443)),
+						},
+					}, {
+						LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+							LBEndpoint(SocketAddress("backup.foo.io", 443)),
+						},
+						Priority: 1,
+					}},
+					Policy: FailoverPolicy(),
+				},
+			},
+		},
+		"static endpoints": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "legacy",
+						ServiceNamespace: "default",
+						ServicePort:      v1.ServicePort{Protocol: "TCP", Port: 8080},
+					},
+					StaticEndpoints: []dag.StaticEndpoint{
+						{Address: "10.0.0.10", Port: 8080},
+						{Address: "10.0.0.11", Port: 8443},
+					},
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/legacy/8080/9e51691ab3",
+				AltStatName:          "default_legacy_8080",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STATIC),
+				LoadAssignment: ClusterLoadAssignment("default/legacy",
+					SocketAddress("10.0.0.10", 8080),
+					SocketAddress("10.0.0.11", 8443),
+				),
+			},
+		},
 		"externalName service - dns-lookup-family v4": {
 			cluster: &dag.Cluster{
 				Upstream:        service(s2),
@@ -242,6 +333,66 @@ func TestCluster(t *testing.T) {
 				DnsLookupFamily:      envoy_cluster_v3.Cluster_AUTO,
 			},
 		},
+		"externalName service - logical dns with resolvers": {
+			cluster: &dag.Cluster{
+				Upstream: service(s2),
+				DNSPolicy: &dag.DNSPolicy{
+					DiscoveryType: dag.DNSDiscoveryTypeLogical,
+					Resolvers: []dag.DNSResolver{
+						{Address: "10.96.0.10", Port: 53},
+						{Address: "10.96.0.11", Port: 5353},
+					},
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/2c263a03f9",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_LOGICAL_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+				DnsLookupFamily:      envoy_cluster_v3.Cluster_AUTO,
+				DnsResolvers: []*envoy_core_v3.Address{
+					SocketAddress("10.96.0.10", 53),
+					SocketAddress("10.96.0.11", 5353),
+				},
+			},
+		},
+		"externalName service - dns policy lookup family": {
+			cluster: &dag.Cluster{
+				Upstream:        service(s2),
+				DNSLookupFamily: "v4",
+				DNSPolicy: &dag.DNSPolicy{
+					LookupFamily: "v6",
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/39bcc1930b",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+				DnsLookupFamily:      envoy_cluster_v3.Cluster_V6_ONLY,
+			},
+		},
+		"externalName service - dns policy from service": {
+			cluster: &dag.Cluster{
+				Upstream: func() *dag.Service {
+					svc := service(s2)
+					svc.DNSPolicy = &dag.DNSPolicy{
+						Resolvers: []dag.DNSResolver{{Address: "10.96.0.10", Port: 53}},
+					}
+					return svc
+				}(),
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+				DnsLookupFamily:      envoy_cluster_v3.Cluster_AUTO,
+				DnsResolvers: []*envoy_core_v3.Address{
+					SocketAddress("10.96.0.10", 53),
+				},
+			},
+		},
 		"tls upstream": {
 			cluster: &dag.Cluster{
 				Upstream: service(s1, "tls"),
@@ -489,6 +640,33 @@ func TestCluster(t *testing.T) {
 				}},
 			},
 		},
+		"tcp service with healthcheck port": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				TCPHealthCheckPolicy: &dag.TCPHealthCheckPolicy{
+					Port: 8081,
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/ef2792074b",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http/healthcheck-8081",
+				},
+				IgnoreHealthOnHostRemoval: true,
+				HealthChecks: []*envoy_core_v3.HealthCheck{{
+					Timeout:            durationOrDefault(0, envoy.HCTimeout),
+					Interval:           durationOrDefault(0, envoy.HCInterval),
+					UnhealthyThreshold: protobuf.UInt32OrDefault(0, envoy.HCUnhealthyThreshold),
+					HealthyThreshold:   protobuf.UInt32OrDefault(0, envoy.HCHealthyThreshold),
+					HealthChecker: &envoy_core_v3.HealthCheck_TcpHealthCheck_{
+						TcpHealthCheck: &envoy_core_v3.HealthCheck_TcpHealthCheck{},
+					},
+				}},
+			},
+		},
 		"use client certificate to authentication towards backend": {
 			cluster: &dag.Cluster{
 				Upstream:          service(s1, "tls"),
@@ -672,6 +850,121 @@ func TestClusterCommonLBConfig(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestCommonLBConfig(t *testing.T) {
+	cluster := &envoy_cluster_v3.Cluster{Name: "panic", CommonLbConfig: ClusterCommonLBConfig()}
+	CommonLBConfig(cluster, 50, 0)
+	protobuf.ExpectEqual(t, &envoy_cluster_v3.Cluster{
+		Name: "panic",
+		CommonLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig{
+			HealthyPanicThreshold: &envoy_type.Percent{Value: 50},
+		},
+	}, cluster)
+
+	// Static clusters may have no common load balancer config.
+	cluster = &envoy_cluster_v3.Cluster{Name: "zone-aware"}
+	CommonLBConfig(cluster, 0, 10)
+	protobuf.ExpectEqual(t, &envoy_cluster_v3.Cluster{
+		Name: "zone-aware",
+		CommonLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig{
+			HealthyPanicThreshold: &envoy_type.Percent{Value: 0},
+			LocalityConfigSpecifier: &envoy_cluster_v3.Cluster_CommonLbConfig_ZoneAwareLbConfig_{
+				ZoneAwareLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig_ZoneAwareLbConfig{
+					MinClusterSize: protobuf.UInt64(10),
+				},
+			},
+		},
+	}, cluster)
+}
+
+func TestStaticCluster(t *testing.T) {
+	tests := map[string]struct {
+		protocol string
+		addrs    []*envoy_core_v3.Address
+		want     *envoy_cluster_v3.Cluster
+	}{
+		"ip addresses": {
+			addrs: []*envoy_core_v3.Address{
+				SocketAddress("10.0.0.1", 8125),
+				SocketAddress("10.0.0.2", 8125),
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "statsd",
+				AltStatName:          "statsd",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STATIC),
+				LoadAssignment: ClusterLoadAssignment("statsd",
+					SocketAddress("10.0.0.1", 8125),
+					SocketAddress("10.0.0.2", 8125),
+				),
+			},
+		},
+		"dns name with h2c": {
+			protocol: "h2c",
+			addrs: []*envoy_core_v3.Address{
+				SocketAddress("10.0.0.1", 4317),
+				SocketAddress("collector.example.com", 4317),
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "statsd",
+				AltStatName:          "statsd",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS),
+				LoadAssignment: ClusterLoadAssignment("statsd",
+					SocketAddress("10.0.0.1", 4317),
+					SocketAddress("collector.example.com", 4317),
+				),
+				TypedExtensionProtocolOptions: http2ProtocolOptions(),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			want := clusterDefaults()
+			proto.Merge(want, tc.want)
+			protobuf.ExpectEqual(t, want, StaticCluster("statsd", tc.protocol, tc.addrs...))
+		})
+	}
+}
+
+func TestPreserveHeaderCase(t *testing.T) {
+	http1 := &envoy_cluster_v3.Cluster{Name: "http1"}
+	PreserveHeaderCase(http1)
+	protobuf.ExpectEqual(t, &envoy_cluster_v3.Cluster{
+		Name: "http1",
+		TypedExtensionProtocolOptions: map[string]*any.Any{
+			"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
+				&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+					UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+						ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+							ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+								HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+									HeaderKeyFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat{
+										HeaderFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat_StatefulFormatter{
+											StatefulFormatter: &envoy_core_v3.TypedExtensionConfig{
+												Name:        "envoy.http.stateful_header_formatters.preserve_case",
+												TypedConfig: protobuf.MustMarshalAny(&envoy_preserve_case_v3.PreserveCaseFormatterConfig{}),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				}),
+		},
+	}, http1)
+
+	// HTTP/2 clusters have no header case to preserve.
+	http2 := &envoy_cluster_v3.Cluster{
+		Name:                          "http2",
+		TypedExtensionProtocolOptions: http2ProtocolOptions(),
+	}
+	PreserveHeaderCase(http2)
+	protobuf.ExpectEqual(t, &envoy_cluster_v3.Cluster{
+		Name:                          "http2",
+		TypedExtensionProtocolOptions: http2ProtocolOptions(),
+	}, http2)
+}
+
 func service(s *v1.Service, protocols ...string) *dag.Service {
 	protocol := ""
 	if len(protocols) > 0 {
@@ -49,6 +49,18 @@ func WeightedEndpoints(weight uint32, addrs ...*envoy_core_v3.Address) []*envoy_
 	return lbendpoints
 }
 
+// FailoverPolicy returns the *envoy_endpoint_v3.ClusterLoadAssignment_Policy
+// of a cluster with endpoints at lower priorities that back up those at
+// priority zero. The overprovisioning factor is large enough that the
+// traffic of the cluster is only sent to a lower priority when none of
+// the endpoints of the higher priorities are healthy, rather than as
+// soon as some of them are unhealthy.
+func FailoverPolicy() *envoy_endpoint_v3.ClusterLoadAssignment_Policy {
+	return &envoy_endpoint_v3.ClusterLoadAssignment_Policy{
+		OverprovisioningFactor: protobuf.UInt32(1000000),
+	}
+}
+
 // ClusterLoadAssignment returns a *envoy_endpoint_v3.ClusterLoadAssignment with a single
 // LocalityLbEndpoints of the supplied addresses.
 func ClusterLoadAssignment(name string, addrs ...*envoy_core_v3.Address) *envoy_endpoint_v3.ClusterLoadAssignment {
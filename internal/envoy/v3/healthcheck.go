@@ -17,6 +17,7 @@ import (
 	"time"
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -33,15 +34,27 @@ func httpHealthCheck(cluster *dag.Cluster) *envoy_core_v3.HealthCheck {
 
 	// TODO(dfc) why do we need to specify our own default, what is the default
 	// that envoy applies if these fields are left nil?
+	var expectedStatuses []*envoy_type_v3.Int64Range
+	for _, r := range hc.ExpectedStatuses {
+		expectedStatuses = append(expectedStatuses, &envoy_type_v3.Int64Range{
+			Start: r.Start,
+			End:   r.End,
+		})
+	}
+
 	return &envoy_core_v3.HealthCheck{
 		Timeout:            durationOrDefault(hc.Timeout, envoy.HCTimeout),
 		Interval:           durationOrDefault(hc.Interval, envoy.HCInterval),
+		InitialJitter:      durationOrNil(hc.InitialJitter),
+		IntervalJitter:     durationOrNil(hc.IntervalJitter),
 		UnhealthyThreshold: protobuf.UInt32OrDefault(hc.UnhealthyThreshold, envoy.HCUnhealthyThreshold),
 		HealthyThreshold:   protobuf.UInt32OrDefault(hc.HealthyThreshold, envoy.HCHealthyThreshold),
 		HealthChecker: &envoy_core_v3.HealthCheck_HttpHealthCheck_{
 			HttpHealthCheck: &envoy_core_v3.HealthCheck_HttpHealthCheck{
-				Path: hc.Path,
-				Host: host,
+				Path:                hc.Path,
+				Host:                host,
+				ExpectedStatuses:    expectedStatuses,
+				RequestHeadersToAdd: HeaderValueList(hc.RequestHeaders, false),
 			},
 		},
 	}
@@ -68,3 +81,10 @@ func durationOrDefault(d, def time.Duration) *duration.Duration {
 	}
 	return protobuf.Duration(def)
 }
+
+func durationOrNil(d time.Duration) *duration.Duration {
+	if d != 0 {
+		return protobuf.Duration(d)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
@@ -90,6 +91,42 @@ func TestHealthCheck(t *testing.T) {
 				},
 			},
 		},
+		"healthcheck with expected statuses, headers and jitter": {
+			cluster: &dag.Cluster{
+				HTTPHealthCheckPolicy: &dag.HTTPHealthCheckPolicy{
+					Path:             "/healthy",
+					ExpectedStatuses: []dag.HTTPStatusRange{{Start: 200, End: 300}, {Start: 401, End: 402}},
+					RequestHeaders:   map[string]string{"X-Health-Check": "contour"},
+					InitialJitter:    5 * time.Second,
+					IntervalJitter:   time.Second,
+				},
+			},
+			want: &envoy_core_v3.HealthCheck{
+				Timeout:            protobuf.Duration(envoy.HCTimeout),
+				Interval:           protobuf.Duration(envoy.HCInterval),
+				InitialJitter:      protobuf.Duration(5 * time.Second),
+				IntervalJitter:     protobuf.Duration(time.Second),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_core_v3.HealthCheck_HttpHealthCheck_{
+					HttpHealthCheck: &envoy_core_v3.HealthCheck_HttpHealthCheck{
+						Path: "/healthy",
+						Host: "contour-envoy-healthcheck",
+						ExpectedStatuses: []*envoy_type_v3.Int64Range{
+							{Start: 200, End: 300},
+							{Start: 401, End: 402},
+						},
+						RequestHeadersToAdd: []*envoy_core_v3.HeaderValueOption{{
+							Header: &envoy_core_v3.HeaderValue{
+								Key:   "X-Health-Check",
+								Value: "contour",
+							},
+							Append: protobuf.Bool(false),
+						}},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -29,12 +29,16 @@ import (
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
 	envoy_extensions_filters_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_proxy_protocol_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/proxy_protocol/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	udp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
+	envoy_preserve_case_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/http/header_formatters/preserve_case/v3"
 	envoy_extensions_http_original_ip_detection_xff_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/http/original_ip_detection/xff/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -107,6 +111,19 @@ func CodecForVersions(versions ...HTTPVersionType) HTTPVersionType {
 	}
 }
 
+// preserveCaseHeaderKeyFormat returns the HTTP/1 header key format
+// that preserves the case of header names as they were received.
+func preserveCaseHeaderKeyFormat() *envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat {
+	return &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat{
+		HeaderFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat_StatefulFormatter{
+			StatefulFormatter: &envoy_core_v3.TypedExtensionConfig{
+				Name:        "envoy.http.stateful_header_formatters.preserve_case",
+				TypedConfig: protobuf.MustMarshalAny(&envoy_preserve_case_v3.PreserveCaseFormatterConfig{}),
+			},
+		},
+	}
+}
+
 // TLSInspector returns a new TLS inspector listener filter.
 func TLSInspector() *envoy_listener_v3.ListenerFilter {
 	return &envoy_listener_v3.ListenerFilter{
@@ -114,10 +131,53 @@ func TLSInspector() *envoy_listener_v3.ListenerFilter {
 	}
 }
 
-// ProxyProtocol returns a new Proxy Protocol listener filter.
-func ProxyProtocol() *envoy_listener_v3.ListenerFilter {
+// ProxyProtocolTLV is a PROXY protocol v2 TLV to extract
+// into the dynamic metadata of a connection.
+type ProxyProtocolTLV struct {
+	// Type is the type of the TLV.
+	Type uint32
+
+	// MetadataNamespace is the dynamic metadata namespace to
+	// store the TLV value in. If empty, the namespace of the
+	// PROXY protocol filter is used.
+	MetadataNamespace string
+
+	// Key is the dynamic metadata key to store the TLV value in.
+	Key string
+}
+
+// ProxyProtocol returns a new Proxy Protocol listener filter,
+// extracting the supplied TLVs into dynamic metadata.
+func ProxyProtocol(tlvs ...ProxyProtocolTLV) *envoy_listener_v3.ListenerFilter {
+	if len(tlvs) == 0 {
+		return &envoy_listener_v3.ListenerFilter{
+			Name: wellknown.ProxyProtocol,
+		}
+	}
+
+	var rules []*envoy_proxy_protocol_v3.ProxyProtocol_Rule
+	for _, tlv := range tlvs {
+		namespace := tlv.MetadataNamespace
+		if namespace == "" {
+			namespace = wellknown.ProxyProtocol
+		}
+
+		rules = append(rules, &envoy_proxy_protocol_v3.ProxyProtocol_Rule{
+			TlvType: tlv.Type,
+			OnTlvPresent: &envoy_proxy_protocol_v3.ProxyProtocol_KeyValuePair{
+				MetadataNamespace: namespace,
+				Key:               tlv.Key,
+			},
+		})
+	}
+
 	return &envoy_listener_v3.ListenerFilter{
 		Name: wellknown.ProxyProtocol,
+		ConfigType: &envoy_listener_v3.ListenerFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_proxy_protocol_v3.ProxyProtocol{
+				Rules: rules,
+			}),
+		},
 	}
 }
 
@@ -153,6 +213,11 @@ type httpConnectionManagerBuilder struct {
 	filters                       []*http.HttpFilter
 	codec                         HTTPVersionType // Note the zero value is AUTO, which is the default we want.
 	allowChunkedLength            bool
+	defaultHostForHTTP10          string
+	preserveHeaderCase            bool
+	maxRequestHeadersKB           uint32
+	maxRequestHeadersCount        uint32
+	rejectInvalidRequests         bool
 }
 
 // RouteConfigName sets the name of the RDS element that contains
@@ -225,6 +290,42 @@ func (b *httpConnectionManagerBuilder) AllowChunkedLength(enabled bool) *httpCon
 	return b
 }
 
+// DefaultHostForHTTP10 sets the host that HTTP/1.0 requests without
+// a Host header are handled as. If empty, such requests are rejected.
+func (b *httpConnectionManagerBuilder) DefaultHostForHTTP10(host string) *httpConnectionManagerBuilder {
+	b.defaultHostForHTTP10 = host
+	return b
+}
+
+// PreserveHeaderCase sets whether the case of HTTP/1 header names
+// is preserved, rather than lowercased.
+func (b *httpConnectionManagerBuilder) PreserveHeaderCase(enabled bool) *httpConnectionManagerBuilder {
+	b.preserveHeaderCase = enabled
+	return b
+}
+
+// MaxRequestHeadersKB sets the maximum size, in KiB, of the
+// headers of a request. If zero, Envoy's default applies.
+func (b *httpConnectionManagerBuilder) MaxRequestHeadersKB(size uint32) *httpConnectionManagerBuilder {
+	b.maxRequestHeadersKB = size
+	return b
+}
+
+// MaxRequestHeadersCount sets the maximum number of headers
+// of a request. If zero, Envoy's default applies.
+func (b *httpConnectionManagerBuilder) MaxRequestHeadersCount(count uint32) *httpConnectionManagerBuilder {
+	b.maxRequestHeadersCount = count
+	return b
+}
+
+// RejectInvalidRequests sets whether invalid requests, e.g. that
+// exceed the header limits, are rejected without closing their
+// connection.
+func (b *httpConnectionManagerBuilder) RejectInvalidRequests(enabled bool) *httpConnectionManagerBuilder {
+	b.rejectInvalidRequests = enabled
+	return b
+}
+
 func (b *httpConnectionManagerBuilder) DefaultFilters() *httpConnectionManagerBuilder {
 
 	// Add a default set of ordered http filters.
@@ -337,6 +438,15 @@ func (b *httpConnectionManagerBuilder) AddFilter(f *http.HttpFilter) *httpConnec
 	return b
 }
 
+// AddFilters appends each of the given filters, in order, as if by AddFilter.
+func (b *httpConnectionManagerBuilder) AddFilters(filters ...*http.HttpFilter) *httpConnectionManagerBuilder {
+	for _, f := range filters {
+		b.AddFilter(f)
+	}
+
+	return b
+}
+
 // Validate runs builtin validation rules against the current builder state.
 func (b *httpConnectionManagerBuilder) Validate() error {
 
@@ -385,8 +495,9 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_listener_v3.Filter {
 		HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
 			// Enable support for HTTP/1.0 requests that carry
 			// a Host: header. See #537.
-			AcceptHttp_10:      true,
-			AllowChunkedLength: b.allowChunkedLength,
+			AcceptHttp_10:         true,
+			DefaultHostForHttp_10: b.defaultHostForHTTP10,
+			AllowChunkedLength:    b.allowChunkedLength,
 		},
 		UseRemoteAddress: protobuf.Bool(true),
 		NormalizePath:    protobuf.Bool(true),
@@ -409,6 +520,22 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_listener_v3.Filter {
 		DelayedCloseTimeout: envoy.Timeout(b.delayedCloseTimeout),
 	}
 
+	if b.preserveHeaderCase {
+		cm.HttpProtocolOptions.HeaderKeyFormat = preserveCaseHeaderKeyFormat()
+	}
+
+	if b.maxRequestHeadersKB > 0 {
+		cm.MaxRequestHeadersKb = protobuf.UInt32(b.maxRequestHeadersKB)
+	}
+
+	if b.maxRequestHeadersCount > 0 {
+		cm.CommonHttpProtocolOptions.MaxHeadersCount = protobuf.UInt32(b.maxRequestHeadersCount)
+	}
+
+	if b.rejectInvalidRequests {
+		cm.StreamErrorOnInvalidHttpMessage = protobuf.Bool(true)
+	}
+
 	// Max connection duration is infinite/disabled by default in Envoy, so if the timeout setting
 	// indicates to either disable or use default, don't pass a value at all. Note that unlike other
 	// Envoy timeouts, explicitly passing a 0 here *would not* disable the timeout; it needs to be
@@ -530,6 +657,31 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.
 	}
 }
 
+// UDPListener returns a new envoy_listener_v3.Listener for the supplied
+// address and port, that forwards the datagrams it receives to the
+// cluster of the UDP proxy.
+func UDPListener(name, address string, port int, proxy *dag.UDPProxy) *envoy_listener_v3.Listener {
+	udpProxy := &udp.UdpProxyConfig{
+		StatPrefix: name,
+		RouteSpecifier: &udp.UdpProxyConfig_Cluster{
+			Cluster: envoy.Clustername(proxy.Cluster),
+		},
+	}
+
+	return &envoy_listener_v3.Listener{
+		Name:    name,
+		Address: UDPSocketAddress(address, port),
+		ListenerFilters: ListenerFilters(
+			&envoy_listener_v3.ListenerFilter{
+				Name: "envoy.filters.udp_listener.udp_proxy",
+				ConfigType: &envoy_listener_v3.ListenerFilter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(udpProxy),
+				},
+			},
+		),
+	}
+}
+
 // UnixSocketAddress creates a new Unix Socket envoy_core_v3.Address.
 func UnixSocketAddress(address string, port int) *envoy_core_v3.Address {
 	return &envoy_core_v3.Address{
@@ -571,6 +723,42 @@ func SocketAddress(address string, port int) *envoy_core_v3.Address {
 	}
 }
 
+// UDPSocketAddress creates a new UDP envoy_core_v3.Address.
+func UDPSocketAddress(address string, port int) *envoy_core_v3.Address {
+	addr := SocketAddress(address, port)
+	addr.GetSocketAddress().Protocol = envoy_core_v3.SocketAddress_UDP
+	return addr
+}
+
+// DualStackListener returns a copy of l bound to the wildcard address of
+// the other IP family, or nil if l is not bound to the IPv4 or IPv6
+// wildcard address. Since IPv4 clients are then served by a separate
+// listener, IPv4 compatibility is disabled on whichever of l and its copy
+// is bound to "::" so that the two do not conflict, and IPv4 client
+// addresses are not reported as IPv4-mapped IPv6 addresses.
+func DualStackListener(l *envoy_listener_v3.Listener) *envoy_listener_v3.Listener {
+	sa := l.GetAddress().GetSocketAddress()
+
+	var name, address string
+	switch sa.GetAddress() {
+	case "0.0.0.0":
+		name, address = l.Name+"_ipv6", "::"
+	case "::":
+		name, address = l.Name+"_ipv4", "0.0.0.0"
+		sa.Ipv4Compat = false
+	default:
+		return nil
+	}
+
+	dual := proto.Clone(l).(*envoy_listener_v3.Listener)
+	dual.Name = name
+	dual.Address = SocketAddress(address, int(sa.GetPortValue()))
+	dual.Address.GetSocketAddress().Protocol = sa.GetProtocol()
+	dual.Address.GetSocketAddress().Ipv4Compat = false
+
+	return dual
+}
+
 // Filters returns a []*envoy_listener_v3.Filter for the supplied filters.
 func Filters(filters ...*envoy_listener_v3.Filter) []*envoy_listener_v3.Filter {
 	if len(filters) == 0 {
@@ -596,12 +784,12 @@ func FilterChains(filters ...*envoy_listener_v3.Filter) []*envoy_listener_v3.Fil
 	}
 }
 
-func FilterMisdirectedRequests(fqdn string) *http.HttpFilter {
+func FilterMisdirectedRequests(fqdn string, aliases ...string) *http.HttpFilter {
 	var target string
 
-	if strings.HasPrefix(fqdn, "*.") {
-		// When we have a wildcard hostname, we will have already matched
-		// the filter chain on an SNI that falls under the wildcard so we
+	if strings.HasPrefix(fqdn, "*.") || len(aliases) > 0 {
+		// When we have a wildcard hostname, or aliases, we will have
+		// already matched the filter chain on one of its SNI names so we
 		// retrieve that and make sure the :authority header matches.
 		// See: https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/lua_filter#requestedservername
 		target = "request_handle:streamInfo():requestedServerName()"
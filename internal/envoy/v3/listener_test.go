@@ -23,8 +23,10 @@ import (
 	envoy_compressor_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/compressor/v3"
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_proxy_protocol_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/proxy_protocol/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	envoy_preserve_case_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/http/header_formatters/preserve_case/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/ptypes/any"
@@ -145,6 +147,55 @@ func TestListener(t *testing.T) {
 	}
 }
 
+func TestProxyProtocol(t *testing.T) {
+	tests := map[string]struct {
+		tlvs []ProxyProtocolTLV
+		want *envoy_listener_v3.ListenerFilter
+	}{
+		"no tlvs": {
+			want: &envoy_listener_v3.ListenerFilter{
+				Name: wellknown.ProxyProtocol,
+			},
+		},
+		"tlvs": {
+			tlvs: []ProxyProtocolTLV{{
+				Type: 0xEA,
+				Key:  "vpce_id",
+			}, {
+				Type:              0x05,
+				MetadataNamespace: "tenant",
+				Key:               "unique_id",
+			}},
+			want: &envoy_listener_v3.ListenerFilter{
+				Name: wellknown.ProxyProtocol,
+				ConfigType: &envoy_listener_v3.ListenerFilter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_proxy_protocol_v3.ProxyProtocol{
+						Rules: []*envoy_proxy_protocol_v3.ProxyProtocol_Rule{{
+							TlvType: 0xEA,
+							OnTlvPresent: &envoy_proxy_protocol_v3.ProxyProtocol_KeyValuePair{
+								MetadataNamespace: "envoy.filters.listener.proxy_protocol",
+								Key:               "vpce_id",
+							},
+						}, {
+							TlvType: 0x05,
+							OnTlvPresent: &envoy_proxy_protocol_v3.ProxyProtocol_KeyValuePair{
+								MetadataNamespace: "tenant",
+								Key:               "unique_id",
+							},
+						}},
+					}),
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			protobuf.ExpectEqual(t, tc.want, ProxyProtocol(tc.tlvs...))
+		})
+	}
+}
+
 func TestSocketAddress(t *testing.T) {
 	const (
 		addr = "foo.example.com"
@@ -181,6 +232,61 @@ func TestSocketAddress(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestDualStackListener(t *testing.T) {
+	ipv6Only := func(port int) *envoy_core_v3.Address {
+		addr := SocketAddress("::", port)
+		addr.GetSocketAddress().Ipv4Compat = false
+		return addr
+	}
+
+	tests := map[string]struct {
+		listener *envoy_listener_v3.Listener
+		want     *envoy_listener_v3.Listener
+		wantAddr *envoy_core_v3.Address
+	}{
+		"ipv4 wildcard": {
+			listener: &envoy_listener_v3.Listener{
+				Name:          "stats",
+				Address:       SocketAddress("0.0.0.0", 8002),
+				SocketOptions: TCPKeepaliveSocketOptions(),
+			},
+			want: &envoy_listener_v3.Listener{
+				Name:          "stats_ipv6",
+				Address:       ipv6Only(8002),
+				SocketOptions: TCPKeepaliveSocketOptions(),
+			},
+			wantAddr: SocketAddress("0.0.0.0", 8002),
+		},
+		"ipv6 wildcard": {
+			listener: &envoy_listener_v3.Listener{
+				Name:    "ingress_http",
+				Address: SocketAddress("::", 8080),
+			},
+			want: &envoy_listener_v3.Listener{
+				Name:    "ingress_http_ipv4",
+				Address: SocketAddress("0.0.0.0", 8080),
+			},
+			wantAddr: ipv6Only(8080),
+		},
+		"specific address": {
+			listener: &envoy_listener_v3.Listener{
+				Name:    "ingress_http",
+				Address: SocketAddress("10.0.0.1", 8080),
+			},
+			want:     nil,
+			wantAddr: SocketAddress("10.0.0.1", 8080),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := DualStackListener(tc.listener)
+			protobuf.ExpectEqual(t, tc.want, got)
+			protobuf.ExpectEqual(t, tc.wantAddr, tc.listener.Address)
+		})
+	}
+}
+
 func TestDownstreamTLSContext(t *testing.T) {
 	const subjectName = "client-subject-name"
 	ca := []byte("client-ca-cert")
@@ -303,6 +409,11 @@ func TestDownstreamTLSContext(t *testing.T) {
 		},
 	}
 
+	peerValidationContextOptionalClientCertificate := &dag.PeerValidationContext{
+		CACertificate:             peerValidationContext.CACertificate,
+		OptionalClientCertificate: true,
+	}
+
 	tests := map[string]struct {
 		got  *envoy_tls_v3.DownstreamTlsContext
 		want *envoy_tls_v3.DownstreamTlsContext
@@ -365,6 +476,18 @@ func TestDownstreamTLSContext(t *testing.T) {
 				RequireClientCertificate: protobuf.Bool(true),
 			},
 		},
+		"optional client certificate": {
+			DownstreamTLSContext(serverSecret, envoy_tls_v3.TlsParameters_TLSv1_2, cipherSuites, peerValidationContextOptionalClientCertificate, "h2", "http/1.1"),
+			&envoy_tls_v3.DownstreamTlsContext{
+				CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
+					TlsParams:                      tlsParams,
+					TlsCertificateSdsSecretConfigs: tlsCertificateSdsSecretConfigs,
+					AlpnProtocols:                  alpnProtocols,
+					ValidationContextType:          validationContext,
+				},
+				RequireClientCertificate: protobuf.Bool(false),
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -385,6 +508,11 @@ func TestHTTPConnectionManager(t *testing.T) {
 		delayedCloseTimeout           timeout.Setting
 		connectionShutdownGracePeriod timeout.Setting
 		allowChunkedLength            bool
+		defaultHostForHTTP10          string
+		preserveHeaderCase            bool
+		maxRequestHeadersKB           uint32
+		maxRequestHeadersCount        uint32
+		rejectInvalidRequests         bool
 		want                          *envoy_listener_v3.Filter
 	}{
 		"default": {
@@ -1279,6 +1407,321 @@ func TestHTTPConnectionManager(t *testing.T) {
 				},
 			},
 		},
+		"default host for HTTP/1.0": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout", "", nil),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			defaultHostForHTTP10:          "www.example.com",
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "envoy.filters.http.lua",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&lua.Lua{
+									InlineCode: "-- Placeholder for per-Route or per-Cluster overrides.",
+								}),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10:         true,
+							DefaultHostForHttp_10: "www.example.com",
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout", "", nil),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
+					}),
+				},
+			},
+		},
+		"preserve header case": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout", "", nil),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			preserveHeaderCase:            true,
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "envoy.filters.http.lua",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&lua.Lua{
+									InlineCode: "-- Placeholder for per-Route or per-Cluster overrides.",
+								}),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+							HeaderKeyFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat{
+								HeaderFormat: &envoy_core_v3.Http1ProtocolOptions_HeaderKeyFormat_StatefulFormatter{
+									StatefulFormatter: &envoy_core_v3.TypedExtensionConfig{
+										Name:        "envoy.http.stateful_header_formatters.preserve_case",
+										TypedConfig: protobuf.MustMarshalAny(&envoy_preserve_case_v3.PreserveCaseFormatterConfig{}),
+									},
+								},
+							},
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout", "", nil),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
+					}),
+				},
+			},
+		},
+		"header limits": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout", "", nil),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			maxRequestHeadersKB:           96,
+			maxRequestHeadersCount:        200,
+			rejectInvalidRequests:         true,
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "envoy.filters.http.lua",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&lua.Lua{
+									InlineCode: "-- Placeholder for per-Route or per-Cluster overrides.",
+								}),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+							MaxHeadersCount: protobuf.UInt32(200),
+						},
+						MaxRequestHeadersKb:             protobuf.UInt32(96),
+						StreamErrorOnInvalidHttpMessage: protobuf.Bool(true),
+						AccessLog:                       FileAccessLogEnvoy("/dev/stdout", "", nil),
+						UseRemoteAddress:                protobuf.Bool(true),
+						NormalizePath:                   protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
+					}),
+				},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -1293,6 +1736,11 @@ func TestHTTPConnectionManager(t *testing.T) {
 				DelayedCloseTimeout(tc.delayedCloseTimeout).
 				ConnectionShutdownGracePeriod(tc.connectionShutdownGracePeriod).
 				AllowChunkedLength(tc.allowChunkedLength).
+				DefaultHostForHTTP10(tc.defaultHostForHTTP10).
+				PreserveHeaderCase(tc.preserveHeaderCase).
+				MaxRequestHeadersKB(tc.maxRequestHeadersKB).
+				MaxRequestHeadersCount(tc.maxRequestHeadersCount).
+				RejectInvalidRequests(tc.rejectInvalidRequests).
 				DefaultFilters().
 				Get()
 
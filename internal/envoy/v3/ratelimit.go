@@ -20,6 +20,7 @@ import (
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	ratelimit_filter_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_type_metadata_v3 "github.com/envoyproxy/go-control-plane/envoy/type/metadata/v3"
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/ptypes/any"
@@ -38,6 +39,14 @@ func LocalRateLimitConfig(config *dag.LocalRateLimitPolicy, statPrefix string) *
 		return nil
 	}
 
+	// Leaving FilterEnabled unset turns the filter off, overriding
+	// any less specific config.
+	if config.Disabled {
+		return protobuf.MustMarshalAny(&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+			StatPrefix: statPrefix,
+		})
+	}
+
 	c := &envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
 		StatPrefix: statPrefix,
 		TokenBucket: &envoy_type_v3.TokenBucket{
@@ -110,15 +119,50 @@ func GlobalRateLimits(descriptors []*dag.RateLimitDescriptor) []*envoy_route_v3.
 						RemoteAddress: &envoy_route_v3.RateLimit_Action_RemoteAddress{},
 					},
 				})
+			case entry.Metadata != nil:
+				rl.Actions = append(rl.Actions, &envoy_route_v3.RateLimit_Action{
+					ActionSpecifier: &envoy_route_v3.RateLimit_Action_Metadata{
+						Metadata: &envoy_route_v3.RateLimit_Action_MetaData{
+							DescriptorKey: entry.Metadata.Key,
+							MetadataKey:   metadataKey(entry.Metadata.Namespace, entry.Metadata.Path),
+							DefaultValue:  entry.Metadata.DefaultValue,
+							Source:        envoy_route_v3.RateLimit_Action_MetaData_DYNAMIC,
+						},
+					},
+				})
 			}
 		}
 
+		if descriptor.Stage > 0 {
+			rl.Stage = wrapperspb.UInt32(descriptor.Stage)
+		}
+
 		rateLimits = append(rateLimits, &rl)
 	}
 
 	return rateLimits
 }
 
+// metadataKey returns the key of the metadata value at
+// path within the namespace.
+func metadataKey(namespace string, path []string) *envoy_type_metadata_v3.MetadataKey {
+	key := &envoy_type_metadata_v3.MetadataKey{Key: namespace}
+	for _, p := range path {
+		key.Path = append(key.Path, &envoy_type_metadata_v3.MetadataKey_PathSegment{
+			Segment: &envoy_type_metadata_v3.MetadataKey_PathSegment_Key{Key: p},
+		})
+	}
+	return key
+}
+
+// GlobalRateLimitsDisabled returns a per-route config for the HTTP
+// global rate limit filter that ignores the virtual host's rate limits.
+func GlobalRateLimitsDisabled() *any.Any {
+	return protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimitPerRoute{
+		VhRateLimits: ratelimit_filter_v3.RateLimitPerRoute_IGNORE,
+	})
+}
+
 // GlobalRateLimitConfig stores configuration for
 // an HTTP global rate limiting filter.
 type GlobalRateLimitConfig struct {
@@ -127,20 +171,41 @@ type GlobalRateLimitConfig struct {
 	Timeout                 timeout.Setting
 	Domain                  string
 	EnableXRateLimitHeaders bool
+
+	// Stages are the rate limit stages to configure a filter
+	// for. If empty, a single filter for stage 0 is configured.
+	Stages []uint32
 }
 
-// GlobalRateLimitFilter returns a configured HTTP global rate limit filter,
-// or nil if config is nil.
-func GlobalRateLimitFilter(config *GlobalRateLimitConfig) *http.HttpFilter {
+// GlobalRateLimitFilters returns a configured HTTP global rate limit
+// filter for each of the configured stages, or nil if config is nil.
+// Every filter shares the same name, so per-route configuration of
+// the rate limit filter applies to all stages.
+func GlobalRateLimitFilters(config *GlobalRateLimitConfig) []*http.HttpFilter {
 	if config == nil {
 		return nil
 	}
 
+	stages := config.Stages
+	if len(stages) == 0 {
+		stages = []uint32{0}
+	}
+
+	filters := make([]*http.HttpFilter, 0, len(stages))
+	for _, stage := range stages {
+		filters = append(filters, globalRateLimitFilter(config, stage))
+	}
+
+	return filters
+}
+
+func globalRateLimitFilter(config *GlobalRateLimitConfig, stage uint32) *http.HttpFilter {
 	return &http.HttpFilter{
 		Name: wellknown.HTTPRateLimit,
 		ConfigType: &http.HttpFilter_TypedConfig{
 			TypedConfig: protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimit{
 				Domain:          config.Domain,
+				Stage:           stage,
 				Timeout:         envoy.Timeout(config.Timeout),
 				FailureModeDeny: !config.FailOpen,
 				RateLimitService: &ratelimit_config_v3.RateLimitServiceConfig{
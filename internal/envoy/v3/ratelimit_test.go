@@ -23,6 +23,7 @@ import (
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	ratelimit_filter_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_type_metadata_v3 "github.com/envoyproxy/go-control-plane/envoy/type/metadata/v3"
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/projectcontour/contour/internal/dag"
@@ -83,6 +84,16 @@ func TestLocalRateLimitConfig(t *testing.T) {
 					},
 				}),
 		},
+		"disabled config": {
+			policy: &dag.LocalRateLimitPolicy{
+				Disabled: true,
+			},
+			statPrefix: "stat-prefix",
+			want: protobuf.MustMarshalAny(
+				&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+					StatPrefix: "stat-prefix",
+				}),
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -101,6 +112,68 @@ func TestGlobalRateLimits(t *testing.T) {
 			descriptors: nil,
 			want:        nil,
 		},
+		"descriptor with stage": {
+			descriptors: []*dag.RateLimitDescriptor{
+				{
+					Entries: []dag.RateLimitDescriptorEntry{
+						{
+							RemoteAddress: &dag.RemoteAddressDescriptorEntry{},
+						},
+					},
+					Stage: 3,
+				},
+			},
+			want: []*envoy_route_v3.RateLimit{
+				{
+					Stage: wrapperspb.UInt32(3),
+					Actions: []*envoy_route_v3.RateLimit_Action{
+						{
+							ActionSpecifier: &envoy_route_v3.RateLimit_Action_RemoteAddress_{
+								RemoteAddress: &envoy_route_v3.RateLimit_Action_RemoteAddress{},
+							},
+						},
+					},
+				},
+			},
+		},
+		"metadata descriptor": {
+			descriptors: []*dag.RateLimitDescriptor{
+				{
+					Entries: []dag.RateLimitDescriptorEntry{
+						{
+							Metadata: &dag.MetadataDescriptorEntry{
+								Key:          "tenant",
+								Namespace:    "envoy.filters.http.ext_authz",
+								Path:         []string{"identity", "tenant"},
+								DefaultValue: "anonymous",
+							},
+						},
+					},
+				},
+			},
+			want: []*envoy_route_v3.RateLimit{
+				{
+					Actions: []*envoy_route_v3.RateLimit_Action{
+						{
+							ActionSpecifier: &envoy_route_v3.RateLimit_Action_Metadata{
+								Metadata: &envoy_route_v3.RateLimit_Action_MetaData{
+									DescriptorKey: "tenant",
+									MetadataKey: &envoy_type_metadata_v3.MetadataKey{
+										Key: "envoy.filters.http.ext_authz",
+										Path: []*envoy_type_metadata_v3.MetadataKey_PathSegment{
+											{Segment: &envoy_type_metadata_v3.MetadataKey_PathSegment_Key{Key: "identity"}},
+											{Segment: &envoy_type_metadata_v3.MetadataKey_PathSegment_Key{Key: "tenant"}},
+										},
+									},
+									DefaultValue: "anonymous",
+									Source:       envoy_route_v3.RateLimit_Action_MetaData_DYNAMIC,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"normal descriptors": {
 			descriptors: []*dag.RateLimitDescriptor{
 				{
@@ -238,10 +311,10 @@ func TestGlobalRateLimits(t *testing.T) {
 
 }
 
-func TestGlobalRateLimitFilter(t *testing.T) {
+func TestGlobalRateLimitFilters(t *testing.T) {
 	tests := map[string]struct {
 		cfg  *GlobalRateLimitConfig
-		want *http.HttpFilter
+		want []*http.HttpFilter
 	}{
 		"nil config produces nil filter": {
 			cfg:  nil,
@@ -254,7 +327,7 @@ func TestGlobalRateLimitFilter(t *testing.T) {
 				Domain:           "domain",
 				FailOpen:         false,
 			},
-			want: &http.HttpFilter{
+			want: []*http.HttpFilter{{
 				Name: wellknown.HTTPRateLimit,
 				ConfigType: &http.HttpFilter_TypedConfig{
 					TypedConfig: protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimit{
@@ -273,7 +346,7 @@ func TestGlobalRateLimitFilter(t *testing.T) {
 						},
 					}),
 				},
-			},
+			}},
 		},
 		"all fields configured correctly with FailOpen=true": {
 			cfg: &GlobalRateLimitConfig{
@@ -282,7 +355,7 @@ func TestGlobalRateLimitFilter(t *testing.T) {
 				Domain:           "domain",
 				FailOpen:         true,
 			},
-			want: &http.HttpFilter{
+			want: []*http.HttpFilter{{
 				Name: wellknown.HTTPRateLimit,
 				ConfigType: &http.HttpFilter_TypedConfig{
 					TypedConfig: protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimit{
@@ -301,7 +374,7 @@ func TestGlobalRateLimitFilter(t *testing.T) {
 						},
 					}),
 				},
-			},
+			}},
 		},
 		"EnableXRateLimitHeaders=true is configured correctly": {
 			cfg: &GlobalRateLimitConfig{
@@ -311,7 +384,7 @@ func TestGlobalRateLimitFilter(t *testing.T) {
 				FailOpen:                true,
 				EnableXRateLimitHeaders: true,
 			},
-			want: &http.HttpFilter{
+			want: []*http.HttpFilter{{
 				Name: wellknown.HTTPRateLimit,
 				ConfigType: &http.HttpFilter_TypedConfig{
 					TypedConfig: protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimit{
@@ -331,13 +404,69 @@ func TestGlobalRateLimitFilter(t *testing.T) {
 						EnableXRatelimitHeaders: ratelimit_filter_v3.RateLimit_DRAFT_VERSION_03,
 					}),
 				},
+			}},
+		},
+		"a filter is configured for each stage": {
+			cfg: &GlobalRateLimitConfig{
+				ExtensionService: k8s.NamespacedNameFrom("projectcontour/ratelimit"),
+				Timeout:          timeout.DurationSetting(7 * time.Second),
+				Domain:           "domain",
+				Stages:           []uint32{0, 1},
 			},
+			want: []*http.HttpFilter{{
+				Name: wellknown.HTTPRateLimit,
+				ConfigType: &http.HttpFilter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimit{
+						Domain:          "domain",
+						Timeout:         protobuf.Duration(7 * time.Second),
+						FailureModeDeny: true,
+						RateLimitService: &ratelimit_config_v3.RateLimitServiceConfig{
+							GrpcService: &envoy_core_v3.GrpcService{
+								TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+										ClusterName: "extension/projectcontour/ratelimit",
+									},
+								},
+							},
+							TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+						},
+					}),
+				},
+			}, {
+				Name: wellknown.HTTPRateLimit,
+				ConfigType: &http.HttpFilter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimit{
+						Domain:          "domain",
+						Stage:           1,
+						Timeout:         protobuf.Duration(7 * time.Second),
+						FailureModeDeny: true,
+						RateLimitService: &ratelimit_config_v3.RateLimitServiceConfig{
+							GrpcService: &envoy_core_v3.GrpcService{
+								TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+										ClusterName: "extension/projectcontour/ratelimit",
+									},
+								},
+							},
+							TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+						},
+					}),
+				},
+			}},
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			assert.Equal(t, tc.want, GlobalRateLimitFilter(tc.cfg))
+			assert.Equal(t, tc.want, GlobalRateLimitFilters(tc.cfg))
 		})
 	}
 }
+
+func TestGlobalRateLimitsDisabled(t *testing.T) {
+	want := protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimitPerRoute{
+		VhRateLimits: ratelimit_filter_v3.RateLimitPerRoute_IGNORE,
+	})
+
+	assert.Equal(t, want, GlobalRateLimitsDisabled())
+}
@@ -18,14 +18,21 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_config_filter_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
 	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_retry_omit_canary_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/omit_canary_hosts/v3"
+	envoy_retry_previous_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	envoy_retry_previous_priorities_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectcontour/contour/internal/dag"
@@ -156,6 +163,7 @@ func RouteRedirect(redirect *dag.Redirect) *envoy_route_v3.Route_Redirect {
 func RouteRoute(r *dag.Route) *envoy_route_v3.Route_Route {
 	ra := envoy_route_v3.RouteAction{
 		RetryPolicy:           retryPolicy(r),
+		HedgePolicy:           hedgePolicy(r),
 		Timeout:               envoy.Timeout(r.TimeoutPolicy.ResponseTimeout),
 		IdleTimeout:           envoy.Timeout(r.TimeoutPolicy.IdleTimeout),
 		PrefixRewrite:         r.PrefixRewrite,
@@ -163,6 +171,14 @@ func RouteRoute(r *dag.Route) *envoy_route_v3.Route_Route {
 		RequestMirrorPolicies: mirrorPolicy(r),
 	}
 
+	if r.GRPCPolicy != nil {
+		// The deadlines of gRPC requests are enforced as the
+		// max stream duration, so that the response timeout
+		// doesn't cut them short.
+		ra.MaxStreamDuration = grpcMaxStreamDuration(r)
+		ra.Timeout = protobuf.Duration(0)
+	}
+
 	if r.RateLimitPolicy != nil && r.RateLimitPolicy.Global != nil {
 		ra.RateLimits = GlobalRateLimits(r.RateLimitPolicy.Global.Descriptors)
 	}
@@ -174,6 +190,12 @@ func RouteRoute(r *dag.Route) *envoy_route_v3.Route_Route {
 		}
 	}
 
+	// A route's CORS policy takes the place of the virtual
+	// host's, so a disabled policy turns the filter off.
+	if r.FilterDisabled(dag.HTTPFilterCORS) {
+		ra.Cors = CORSPolicyDisabled()
+	}
+
 	if r.Websocket {
 		ra.UpgradeConfigs = append(ra.UpgradeConfigs,
 			&envoy_route_v3.RouteAction_UpgradeConfig{
@@ -246,6 +268,27 @@ func mirrorPolicy(r *dag.Route) []*envoy_route_v3.RouteAction_RequestMirrorPolic
 	}}
 }
 
+// grpcMaxStreamDuration returns the max stream duration of the
+// route, which is the deadline gRPC clients set with the grpc-timeout
+// request header, subject to the route's gRPC policy.
+func grpcMaxStreamDuration(r *dag.Route) *envoy_route_v3.RouteAction_MaxStreamDuration {
+	msd := &envoy_route_v3.RouteAction_MaxStreamDuration{
+		// Zero uses the deadline of the header without limit.
+		GrpcTimeoutHeaderMax: protobuf.Duration(r.GRPCPolicy.MaxTimeout),
+	}
+	if r.GRPCPolicy.TimeoutOffset > 0 {
+		msd.GrpcTimeoutHeaderOffset = protobuf.Duration(r.GRPCPolicy.TimeoutOffset)
+	}
+
+	// Requests without a deadline are still subject to
+	// an explicit response timeout.
+	if rt := r.TimeoutPolicy.ResponseTimeout; !rt.UseDefault() && !rt.IsDisabled() {
+		msd.MaxStreamDuration = protobuf.Duration(rt.Duration())
+	}
+
+	return msd
+}
+
 func retryPolicy(r *dag.Route) *envoy_route_v3.RetryPolicy {
 	if r.RetryPolicy == nil {
 		return nil
@@ -263,9 +306,48 @@ func retryPolicy(r *dag.Route) *envoy_route_v3.RetryPolicy {
 	}
 	rp.PerTryTimeout = envoy.Timeout(r.RetryPolicy.PerTryTimeout)
 
+	if r.RetryPolicy.AvoidPreviousHosts {
+		rp.RetryHostPredicate = append(rp.RetryHostPredicate, &envoy_route_v3.RetryPolicy_RetryHostPredicate{
+			Name: "envoy.retry_host_predicates.previous_hosts",
+			ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_hosts_v3.PreviousHostsPredicate{}),
+			},
+		})
+	}
+	if r.RetryPolicy.AvoidCanaryHosts {
+		rp.RetryHostPredicate = append(rp.RetryHostPredicate, &envoy_route_v3.RetryPolicy_RetryHostPredicate{
+			Name: "envoy.retry_host_predicates.omit_canary_hosts",
+			ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_retry_omit_canary_hosts_v3.OmitCanaryHostsPredicate{}),
+			},
+		})
+	}
+	rp.HostSelectionRetryMaxAttempts = r.RetryPolicy.HostSelectionMaxAttempts
+
+	if freq := r.RetryPolicy.PreviousPrioritiesUpdateFrequency; freq > 0 {
+		rp.RetryPriority = &envoy_route_v3.RetryPolicy_RetryPriority{
+			Name: "envoy.retry_priorities.previous_priorities",
+			ConfigType: &envoy_route_v3.RetryPolicy_RetryPriority_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_priorities_v3.PreviousPrioritiesConfig{
+					UpdateFrequency: freq,
+				}),
+			},
+		}
+	}
+
 	return rp
 }
 
+func hedgePolicy(r *dag.Route) *envoy_route_v3.HedgePolicy {
+	if r.HedgePolicy == nil {
+		return nil
+	}
+
+	return &envoy_route_v3.HedgePolicy{
+		HedgeOnPerTryTimeout: r.HedgePolicy.HedgeOnPerTryTimeout,
+	}
+}
+
 // UpgradeHTTPS returns a route Action that redirects the request to HTTPS.
 func UpgradeHTTPS() *envoy_route_v3.Route_Redirect {
 	return &envoy_route_v3.Route_Redirect{
@@ -277,6 +359,26 @@ func UpgradeHTTPS() *envoy_route_v3.Route_Redirect {
 	}
 }
 
+// UpgradeHTTPSRedirect returns a route Action that redirects the request to
+// HTTPS using the status code and port of the given policy, if any.
+func UpgradeHTTPSRedirect(policy *dag.HTTPSRedirectPolicy) *envoy_route_v3.Route_Redirect {
+	r := UpgradeHTTPS()
+	if policy == nil {
+		return r
+	}
+
+	if policy.PortNumber > 0 {
+		r.Redirect.PortRedirect = policy.PortNumber
+	}
+
+	// Envoy's default is a 301 if not otherwise specified.
+	if policy.StatusCode == 308 {
+		r.Redirect.ResponseCode = envoy_route_v3.RedirectAction_PERMANENT_REDIRECT
+	}
+
+	return r
+}
+
 // HeaderValueList creates a list of Envoy HeaderValueOptions from the provided map.
 func HeaderValueList(hvm map[string]string, app bool) []*envoy_core_v3.HeaderValueOption {
 	var hvs []*envoy_core_v3.HeaderValueOption
@@ -293,14 +395,62 @@ func HeaderValueList(hvm map[string]string, app bool) []*envoy_core_v3.HeaderVal
 		})
 	}
 
-	sort.Slice(hvs, func(i, j int) bool {
-		return hvs[i].Header.Key < hvs[j].Header.Key
-	})
-
+	sort.Stable(sorter.For(hvs))
 	return hvs
 }
 
+// StrictTransportSecurity returns the value of the
+// Strict-Transport-Security header for the HSTS policy.
+func StrictTransportSecurity(policy *dag.HSTSPolicy) string {
+	value := fmt.Sprintf("max-age=%d", int64(policy.MaxAge/time.Second))
+	if policy.IncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if policy.Preload {
+		value += "; preload"
+	}
+	return value
+}
+
 // weightedClusters returns a route.WeightedCluster for multiple services.
+// ClientCertificateStatusRoutes returns the routes with the
+// X-Client-Cert-Present and X-Client-Cert-Verified request headers
+// set. Envoy cannot set a header from the result of client certificate
+// validation, so each route that proxies requests is preceded by copies
+// that only match requests with a verified, and with an unverified,
+// client certificate.
+func ClientCertificateStatusRoutes(routes []*envoy_route_v3.Route) []*envoy_route_v3.Route {
+	var statusRoutes []*envoy_route_v3.Route
+	for _, r := range routes {
+		if _, ok := r.Action.(*envoy_route_v3.Route_Route); !ok {
+			statusRoutes = append(statusRoutes, r)
+			continue
+		}
+
+		statusRoutes = append(statusRoutes,
+			clientCertificateStatusRoute(r, &envoy_route_v3.RouteMatch_TlsContextMatchOptions{
+				Presented: protobuf.Bool(true),
+				Validated: protobuf.Bool(true),
+			}, true, true),
+			clientCertificateStatusRoute(r, &envoy_route_v3.RouteMatch_TlsContextMatchOptions{
+				Presented: protobuf.Bool(true),
+			}, true, false),
+			clientCertificateStatusRoute(r, nil, false, false),
+		)
+	}
+	return statusRoutes
+}
+
+func clientCertificateStatusRoute(r *envoy_route_v3.Route, tlsContext *envoy_route_v3.RouteMatch_TlsContextMatchOptions, present, verified bool) *envoy_route_v3.Route {
+	rt := proto.Clone(r).(*envoy_route_v3.Route)
+	rt.Match.TlsContext = tlsContext
+	rt.RequestHeadersToAdd = append(rt.RequestHeadersToAdd, HeaderValueList(map[string]string{
+		"X-Client-Cert-Present":  strconv.FormatBool(present),
+		"X-Client-Cert-Verified": strconv.FormatBool(verified),
+	}, false)...)
+	return rt
+}
+
 func weightedClusters(route *dag.Route) *envoy_route_v3.WeightedCluster {
 	var wc envoy_route_v3.WeightedCluster
 	var total uint32
@@ -316,7 +466,7 @@ func weightedClusters(route *dag.Route) *envoy_route_v3.WeightedCluster {
 			c.RequestHeadersToRemove = cluster.RequestHeadersPolicy.Remove
 		}
 		if cluster.ResponseHeadersPolicy != nil {
-			c.ResponseHeadersToAdd = HeaderValueList(cluster.ResponseHeadersPolicy.Set, false)
+			c.ResponseHeadersToAdd = append(HeaderValueList(cluster.ResponseHeadersPolicy.Set, false), HeaderValueList(cluster.ResponseHeadersPolicy.Add, true)...)
 			c.ResponseHeadersToRemove = cluster.ResponseHeadersPolicy.Remove
 		}
 		if len(route.CookieRewritePolicies) > 0 || len(cluster.CookieRewritePolicies) > 0 {
@@ -349,6 +499,59 @@ func VirtualHost(hostname string, routes ...*envoy_route_v3.Route) *envoy_route_
 	}
 }
 
+// VirtualClusters returns the virtual clusters of the routes that set a
+// virtual cluster name. A virtual cluster matches the requests of its
+// route by their :path header, which, unlike the path matched by the
+// route, includes the query string.
+func VirtualClusters(routes []*dag.Route) []*envoy_route_v3.VirtualCluster {
+	var vclusters []*envoy_route_v3.VirtualCluster
+	for _, route := range routes {
+		if route.VirtualClusterName == "" {
+			continue
+		}
+
+		var headers []*envoy_route_v3.HeaderMatcher
+		if regex := pathHeaderRegex(route.PathMatchCondition); regex != "" {
+			headers = append(headers, &envoy_route_v3.HeaderMatcher{
+				Name: ":path",
+				HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+					SafeRegexMatch: SafeRegexMatch(regex),
+				},
+			})
+		}
+
+		vclusters = append(vclusters, &envoy_route_v3.VirtualCluster{
+			Name:    route.VirtualClusterName,
+			Headers: append(headers, headerMatcher(route.HeaderMatchConditions)...),
+		})
+	}
+	return vclusters
+}
+
+// pathHeaderRegex returns a regex matching the :path header of the
+// requests matched by the path match condition, or "" if it matches
+// every request.
+func pathHeaderRegex(cond dag.MatchCondition) string {
+	const query = `(\?.*)?`
+
+	switch c := cond.(type) {
+	case *dag.RegexMatchCondition:
+		return "(" + c.Regex + ")" + query
+	case *dag.PrefixMatchCondition:
+		if c.PrefixMatchType == dag.PrefixMatchSegment {
+			return regexp.QuoteMeta(c.Prefix) + prefixPathMatchSegmentRegex + query
+		}
+		if c.Prefix == "/" {
+			return ""
+		}
+		return regexp.QuoteMeta(c.Prefix) + ".*"
+	case *dag.ExactMatchCondition:
+		return regexp.QuoteMeta(c.Path) + query
+	default:
+		return ""
+	}
+}
+
 // CORSVirtualHost creates a new route.VirtualHost with a CORS policy.
 func CORSVirtualHost(hostname string, corspolicy *envoy_route_v3.CorsPolicy, routes ...*envoy_route_v3.Route) *envoy_route_v3.VirtualHost {
 	vh := VirtualHost(hostname, routes...)
@@ -399,6 +602,41 @@ func CORSPolicy(cp *dag.CORSPolicy) *envoy_route_v3.CorsPolicy {
 	return rcp
 }
 
+// CORSPolicyDisabled returns a CORS policy that turns
+// the CORS filter off.
+func CORSPolicyDisabled() *envoy_route_v3.CorsPolicy {
+	return &envoy_route_v3.CorsPolicy{
+		EnabledSpecifier: &envoy_route_v3.CorsPolicy_FilterEnabled{
+			FilterEnabled: &envoy_core_v3.RuntimeFractionalPercent{
+				DefaultValue: &envoy_type_v3.FractionalPercent{
+					Numerator:   0,
+					Denominator: envoy_type_v3.FractionalPercent_HUNDRED,
+				},
+			},
+		},
+	}
+}
+
+// RouteFiltersDisabled returns the per-route configs that turn
+// off the route's disabled HTTP filters, keyed by filter name.
+// The CORS filter is turned off by the route's CORS policy.
+func RouteFiltersDisabled(r *dag.Route, statPrefix string) map[string]*any.Any {
+	configs := map[string]*any.Any{}
+
+	for _, f := range r.DisabledFilters {
+		switch f {
+		case dag.HTTPFilterExtAuthz:
+			configs["envoy.filters.http.ext_authz"] = RouteAuthzDisabled()
+		case dag.HTTPFilterLocalRateLimit:
+			configs["envoy.filters.http.local_ratelimit"] = LocalRateLimitConfig(&dag.LocalRateLimitPolicy{Disabled: true}, statPrefix)
+		case dag.HTTPFilterRateLimit:
+			configs["envoy.filters.http.ratelimit"] = GlobalRateLimitsDisabled()
+		}
+	}
+
+	return configs
+}
+
 func Headers(first *envoy_core_v3.HeaderValueOption, rest ...*envoy_core_v3.HeaderValueOption) []*envoy_core_v3.HeaderValueOption {
 	return append([]*envoy_core_v3.HeaderValueOption{first}, rest...)
 }
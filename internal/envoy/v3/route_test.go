@@ -19,6 +19,9 @@ import (
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_retry_omit_canary_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/omit_canary_hosts/v3"
+	envoy_retry_previous_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	envoy_retry_previous_priorities_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectcontour/contour/internal/dag"
@@ -296,6 +299,120 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"hedge on per try timeout": {
+			route: &dag.Route{
+				RetryPolicy: &dag.RetryPolicy{
+					RetryOn:       "5xx",
+					NumRetries:    2,
+					PerTryTimeout: timeout.DurationSetting(100 * time.Millisecond),
+				},
+				HedgePolicy: &dag.HedgePolicy{
+					HedgeOnPerTryTimeout: true,
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RetryPolicy: &envoy_route_v3.RetryPolicy{
+						RetryOn:       "5xx",
+						NumRetries:    protobuf.UInt32(2),
+						PerTryTimeout: protobuf.Duration(100 * time.Millisecond),
+					},
+					HedgePolicy: &envoy_route_v3.HedgePolicy{
+						HedgeOnPerTryTimeout: true,
+					},
+				},
+			},
+		},
+		"retry host predicates and priority": {
+			route: &dag.Route{
+				RetryPolicy: &dag.RetryPolicy{
+					RetryOn:                           "5xx",
+					NumRetries:                        3,
+					AvoidPreviousHosts:                true,
+					AvoidCanaryHosts:                  true,
+					HostSelectionMaxAttempts:          5,
+					PreviousPrioritiesUpdateFrequency: 2,
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RetryPolicy: &envoy_route_v3.RetryPolicy{
+						RetryOn:    "5xx",
+						NumRetries: protobuf.UInt32(3),
+						RetryHostPredicate: []*envoy_route_v3.RetryPolicy_RetryHostPredicate{{
+							Name: "envoy.retry_host_predicates.previous_hosts",
+							ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_hosts_v3.PreviousHostsPredicate{}),
+							},
+						}, {
+							Name: "envoy.retry_host_predicates.omit_canary_hosts",
+							ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_retry_omit_canary_hosts_v3.OmitCanaryHostsPredicate{}),
+							},
+						}},
+						HostSelectionRetryMaxAttempts: 5,
+						RetryPriority: &envoy_route_v3.RetryPolicy_RetryPriority{
+							Name: "envoy.retry_priorities.previous_priorities",
+							ConfigType: &envoy_route_v3.RetryPolicy_RetryPriority_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_priorities_v3.PreviousPrioritiesConfig{
+									UpdateFrequency: 2,
+								}),
+							},
+						},
+					},
+				},
+			},
+		},
+		"grpc policy": {
+			route: &dag.Route{
+				GRPCPolicy: &dag.GRPCPolicy{
+					MaxTimeout:    30 * time.Second,
+					TimeoutOffset: 50 * time.Millisecond,
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					Timeout: protobuf.Duration(0),
+					MaxStreamDuration: &envoy_route_v3.RouteAction_MaxStreamDuration{
+						GrpcTimeoutHeaderMax:    protobuf.Duration(30 * time.Second),
+						GrpcTimeoutHeaderOffset: protobuf.Duration(50 * time.Millisecond),
+					},
+				},
+			},
+		},
+		"grpc policy with response timeout": {
+			route: &dag.Route{
+				TimeoutPolicy: dag.TimeoutPolicy{
+					ResponseTimeout: timeout.DurationSetting(10 * time.Second),
+				},
+				GRPCPolicy: &dag.GRPCPolicy{},
+				Clusters:   []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					Timeout: protobuf.Duration(0),
+					MaxStreamDuration: &envoy_route_v3.RouteAction_MaxStreamDuration{
+						MaxStreamDuration:    protobuf.Duration(10 * time.Second),
+						GrpcTimeoutHeaderMax: protobuf.Duration(0),
+					},
+				},
+			},
+		},
 		"retriable status codes: 502, 503, 504": {
 			route: &dag.Route{
 				RetryPolicy: &dag.RetryPolicy{
@@ -757,6 +874,69 @@ func TestWeightedClusters(t *testing.T) {
 				TotalWeight: protobuf.UInt32(100),
 			},
 		},
+		"multiple weighted services adding response headers": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{{
+					Upstream: &dag.Service{
+						Weighted: dag.WeightedService{
+							Weight:           1,
+							ServiceName:      "kuard",
+							ServiceNamespace: "default",
+							ServicePort: v1.ServicePort{
+								Port: 8080,
+							},
+						},
+					},
+					ResponseHeadersPolicy: &dag.HeadersPolicy{
+						Add: map[string]string{"Set-Cookie": "canary=kuard; Path=/"},
+					},
+					Weight: 90,
+				}, {
+					Upstream: &dag.Service{
+						Weighted: dag.WeightedService{
+							Weight:           1,
+							ServiceName:      "nginx",
+							ServiceNamespace: "default",
+							ServicePort: v1.ServicePort{
+								Port: 8080,
+							},
+						},
+					},
+					ResponseHeadersPolicy: &dag.HeadersPolicy{
+						Add: map[string]string{"Set-Cookie": "canary=nginx; Path=/"},
+					},
+					Weight: 10,
+				}},
+			},
+			want: &envoy_route_v3.WeightedCluster{
+				Clusters: []*envoy_route_v3.WeightedCluster_ClusterWeight{{
+					Name:   "default/kuard/8080/da39a3ee5e",
+					Weight: protobuf.UInt32(90),
+					ResponseHeadersToAdd: []*envoy_core_v3.HeaderValueOption{{
+						Header: &envoy_core_v3.HeaderValue{
+							Key:   "Set-Cookie",
+							Value: "canary=kuard; Path=/",
+						},
+						Append: &wrappers.BoolValue{
+							Value: true,
+						},
+					}},
+				}, {
+					Name:   "default/nginx/8080/da39a3ee5e",
+					Weight: protobuf.UInt32(10),
+					ResponseHeadersToAdd: []*envoy_core_v3.HeaderValueOption{{
+						Header: &envoy_core_v3.HeaderValue{
+							Key:   "Set-Cookie",
+							Value: "canary=nginx; Path=/",
+						},
+						Append: &wrappers.BoolValue{
+							Value: true,
+						},
+					}},
+				}},
+				TotalWeight: protobuf.UInt32(100),
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -816,6 +996,87 @@ func TestRouteConfiguration(t *testing.T) {
 	}
 }
 
+func TestStrictTransportSecurity(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.HSTSPolicy
+		want   string
+	}{
+		"max age": {
+			policy: &dag.HSTSPolicy{MaxAge: time.Hour},
+			want:   "max-age=3600",
+		},
+		"include subdomains": {
+			policy: &dag.HSTSPolicy{MaxAge: time.Hour, IncludeSubDomains: true},
+			want:   "max-age=3600; includeSubDomains",
+		},
+		"preload": {
+			policy: &dag.HSTSPolicy{MaxAge: 8760 * time.Hour, IncludeSubDomains: true, Preload: true},
+			want:   "max-age=31536000; includeSubDomains; preload",
+		},
+		"zero max age": {
+			policy: &dag.HSTSPolicy{},
+			want:   "max-age=0",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, StrictTransportSecurity(tc.policy))
+		})
+	}
+}
+
+func TestClientCertificateStatusRoutes(t *testing.T) {
+	match := func(tlsContext *envoy_route_v3.RouteMatch_TlsContextMatchOptions) *envoy_route_v3.RouteMatch {
+		return &envoy_route_v3.RouteMatch{
+			PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{Prefix: "/"},
+			TlsContext:    tlsContext,
+		}
+	}
+	action := &envoy_route_v3.Route_Route{
+		Route: &envoy_route_v3.RouteAction{
+			ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: "default/kuard/8080/da39a3ee5e"},
+		},
+	}
+	headers := func(present, verified string) []*envoy_core_v3.HeaderValueOption {
+		return []*envoy_core_v3.HeaderValueOption{{
+			Header: &envoy_core_v3.HeaderValue{Key: "X-Client-Cert-Present", Value: present},
+			Append: protobuf.Bool(false),
+		}, {
+			Header: &envoy_core_v3.HeaderValue{Key: "X-Client-Cert-Verified", Value: verified},
+			Append: protobuf.Bool(false),
+		}}
+	}
+	redirect := &envoy_route_v3.Route{
+		Match:  match(nil),
+		Action: UpgradeHTTPS(),
+	}
+
+	got := ClientCertificateStatusRoutes([]*envoy_route_v3.Route{{
+		Match:  match(nil),
+		Action: action,
+	}, redirect})
+
+	protobuf.ExpectEqual(t, []*envoy_route_v3.Route{{
+		Match: match(&envoy_route_v3.RouteMatch_TlsContextMatchOptions{
+			Presented: protobuf.Bool(true),
+			Validated: protobuf.Bool(true),
+		}),
+		Action:              action,
+		RequestHeadersToAdd: headers("true", "true"),
+	}, {
+		Match: match(&envoy_route_v3.RouteMatch_TlsContextMatchOptions{
+			Presented: protobuf.Bool(true),
+		}),
+		Action:              action,
+		RequestHeadersToAdd: headers("true", "false"),
+	}, {
+		Match:               match(nil),
+		Action:              action,
+		RequestHeadersToAdd: headers("false", "false"),
+	}, redirect}, got)
+}
+
 func TestVirtualHost(t *testing.T) {
 	tests := map[string]struct {
 		hostname string
@@ -905,6 +1166,95 @@ func TestCORSVirtualHost(t *testing.T) {
 	}
 }
 
+func TestVirtualClusters(t *testing.T) {
+	pathMatcher := func(regex string) *envoy_route_v3.HeaderMatcher {
+		return &envoy_route_v3.HeaderMatcher{
+			Name: ":path",
+			HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+				SafeRegexMatch: SafeRegexMatch(regex),
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		routes []*dag.Route
+		want   []*envoy_route_v3.VirtualCluster
+	}{
+		"no virtual cluster name": {
+			routes: []*dag.Route{{
+				PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api"},
+			}},
+			want: nil,
+		},
+		"root prefix": {
+			routes: []*dag.Route{{
+				PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+				VirtualClusterName: "all",
+			}},
+			want: []*envoy_route_v3.VirtualCluster{{
+				Name: "all",
+			}},
+		},
+		"string prefix": {
+			routes: []*dag.Route{{
+				PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api.v1"},
+				VirtualClusterName: "api",
+			}},
+			want: []*envoy_route_v3.VirtualCluster{{
+				Name:    "api",
+				Headers: []*envoy_route_v3.HeaderMatcher{pathMatcher(`/api\.v1.*`)},
+			}},
+		},
+		"segment prefix": {
+			routes: []*dag.Route{{
+				PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/api", PrefixMatchType: dag.PrefixMatchSegment},
+				VirtualClusterName: "api",
+			}},
+			want: []*envoy_route_v3.VirtualCluster{{
+				Name:    "api",
+				Headers: []*envoy_route_v3.HeaderMatcher{pathMatcher(`/api((\/).*)?(\?.*)?`)},
+			}},
+		},
+		"exact path and headers": {
+			routes: []*dag.Route{{
+				PathMatchCondition: &dag.ExactMatchCondition{Path: "/login"},
+				HeaderMatchConditions: []dag.HeaderMatchCondition{{
+					Name:      ":method",
+					Value:     "POST",
+					MatchType: dag.HeaderMatchTypeExact,
+				}},
+				VirtualClusterName: "login",
+			}},
+			want: []*envoy_route_v3.VirtualCluster{{
+				Name: "login",
+				Headers: []*envoy_route_v3.HeaderMatcher{
+					pathMatcher(`/login(\?.*)?`),
+					{
+						Name:                 ":method",
+						HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_ExactMatch{ExactMatch: "POST"},
+					},
+				},
+			}},
+		},
+		"regex": {
+			routes: []*dag.Route{{
+				PathMatchCondition: &dag.RegexMatchCondition{Regex: "/users/[0-9]+"},
+				VirtualClusterName: "users",
+			}},
+			want: []*envoy_route_v3.VirtualCluster{{
+				Name:    "users",
+				Headers: []*envoy_route_v3.HeaderMatcher{pathMatcher(`(/users/[0-9]+)(\?.*)?`)},
+			}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := VirtualClusters(tc.routes)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
 func TestCORSPolicy(t *testing.T) {
 	tests := map[string]struct {
 		cp   *dag.CORSPolicy
@@ -1061,6 +1411,57 @@ func TestUpgradeHTTPS(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestUpgradeHTTPSRedirect(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.HTTPSRedirectPolicy
+		want   *envoy_route_v3.Route_Redirect
+	}{
+		"no policy": {
+			policy: nil,
+			want: &envoy_route_v3.Route_Redirect{
+				Redirect: &envoy_route_v3.RedirectAction{
+					SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_HttpsRedirect{
+						HttpsRedirect: true,
+					},
+				},
+			},
+		},
+		"status code 301": {
+			policy: &dag.HTTPSRedirectPolicy{
+				StatusCode: 301,
+			},
+			want: &envoy_route_v3.Route_Redirect{
+				Redirect: &envoy_route_v3.RedirectAction{
+					SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_HttpsRedirect{
+						HttpsRedirect: true,
+					},
+				},
+			},
+		},
+		"status code 308 and port": {
+			policy: &dag.HTTPSRedirectPolicy{
+				StatusCode: 308,
+				PortNumber: 8443,
+			},
+			want: &envoy_route_v3.Route_Redirect{
+				Redirect: &envoy_route_v3.RedirectAction{
+					SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_HttpsRedirect{
+						HttpsRedirect: true,
+					},
+					PortRedirect: 8443,
+					ResponseCode: envoy_route_v3.RedirectAction_PERMANENT_REDIRECT,
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, UpgradeHTTPSRedirect(tc.policy))
+		})
+	}
+}
+
 func TestRouteMatch(t *testing.T) {
 	tests := map[string]struct {
 		route *dag.Route
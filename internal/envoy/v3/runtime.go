@@ -0,0 +1,53 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	envoy_service_runtime_v3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+)
+
+const (
+	// DynamicRuntimeLayerName is the name of the runtime layer
+	// that Envoy fetches from Contour over RTDS.
+	DynamicRuntimeLayerName = "dynamic"
+
+	// GlobalDownstreamMaxConnectionsKey is the runtime key holding
+	// the maximum number of downstream connections of all listeners.
+	GlobalDownstreamMaxConnectionsKey = "overload.global_downstream_max_connections"
+)
+
+// ListenerConnectionLimitKey returns the runtime key holding the
+// maximum number of downstream connections of the named listener.
+func ListenerConnectionLimitKey(listener string) string {
+	return "envoy.resource_limits.listener." + listener + ".connection_limit"
+}
+
+// RuntimeLayer returns the dynamic runtime layer holding the supplied
+// numeric runtime values.
+func RuntimeLayer(values map[string]uint32) *envoy_service_runtime_v3.Runtime {
+	fields := make(map[string]*_struct.Value, len(values))
+	for key, value := range values {
+		fields[key] = &_struct.Value{
+			Kind: &_struct.Value_NumberValue{NumberValue: float64(value)},
+		}
+	}
+
+	return &envoy_service_runtime_v3.Runtime{
+		Name: DynamicRuntimeLayerName,
+		Layer: &_struct.Struct{
+			Fields: fields,
+		},
+	}
+}
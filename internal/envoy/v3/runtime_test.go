@@ -0,0 +1,66 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_service_runtime_v3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenerConnectionLimitKey(t *testing.T) {
+	assert.Equal(t, "envoy.resource_limits.listener.ingress_http.connection_limit", ListenerConnectionLimitKey("ingress_http"))
+}
+
+func TestRuntimeLayer(t *testing.T) {
+	tests := map[string]struct {
+		values map[string]uint32
+		want   *envoy_service_runtime_v3.Runtime
+	}{
+		"no values": {
+			want: &envoy_service_runtime_v3.Runtime{
+				Name:  "dynamic",
+				Layer: &_struct.Struct{Fields: map[string]*_struct.Value{}},
+			},
+		},
+		"connection limits": {
+			values: map[string]uint32{
+				"overload.global_downstream_max_connections":                   50000,
+				"envoy.resource_limits.listener.ingress_http.connection_limit": 1000,
+			},
+			want: &envoy_service_runtime_v3.Runtime{
+				Name: "dynamic",
+				Layer: &_struct.Struct{
+					Fields: map[string]*_struct.Value{
+						"overload.global_downstream_max_connections": {
+							Kind: &_struct.Value_NumberValue{NumberValue: 50000},
+						},
+						"envoy.resource_limits.listener.ingress_http.connection_limit": {
+							Kind: &_struct.Value_NumberValue{NumberValue: 1000},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			protobuf.ExpectEqual(t, tc.want, RuntimeLayer(tc.values))
+		})
+	}
+}
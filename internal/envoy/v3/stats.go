@@ -27,14 +27,29 @@ import (
 const metricsServerCertSDSName = "metrics-tls-certificate"
 const metricsCaBundleSDSName = "metrics-ca-certificate"
 
+// XDSCertificateSecretName is the name of the static secret, written
+// into the bootstrap configuration, that holds the client certificate
+// and key Envoy uses to connect to Contour.
+const XDSCertificateSecretName = "envoy_xds_certificate"
+
+// defaultStatsPaths are the admin interface prefixes exposed by the
+// stats listener when MetricsConfig.Paths is empty.
+var defaultStatsPaths = []string{"/stats"}
+
 // StatsListeners returns an array of *envoy_listener_v3.Listeners,
 // either single HTTP listener or HTTP and HTTPS listeners depending on config.
 // The listeners are configured to serve:
-//   - prometheus metrics on /stats (either over HTTP or HTTPS)
+//   - prometheus metrics on /stats, or on the prefixes listed in
+//     metrics.Paths (either over HTTP or HTTPS)
 //   - readiness probe on /ready (always over HTTP)
 func StatsListeners(metrics contour_api_v1alpha1.MetricsConfig, health contour_api_v1alpha1.HealthConfig) []*envoy_listener_v3.Listener {
 	var listeners []*envoy_listener_v3.Listener
 
+	paths := metrics.Paths
+	if len(paths) == 0 {
+		paths = defaultStatsPaths
+	}
+
 	switch {
 	// Create HTTPS listener for metrics and HTTP listener for health.
 	case metrics.TLS != nil:
@@ -44,7 +59,8 @@ func StatsListeners(metrics contour_api_v1alpha1.MetricsConfig, health contour_a
 			SocketOptions: TCPKeepaliveSocketOptions(),
 			FilterChains: filterChain("stats",
 				DownstreamTLSTransportSocket(
-					downstreamTLSContext(metrics.TLS.CAFile != "")), routeForAdminInterface("/stats")),
+					downstreamTLSContext(metrics.TLS.UseXDSCertificate, metrics.TLS.CAFile != "")),
+				routeForAdminInterface(paths...)),
 		}, {
 			Name:          "health",
 			Address:       SocketAddress(health.Address, health.Port),
@@ -59,7 +75,7 @@ func StatsListeners(metrics contour_api_v1alpha1.MetricsConfig, health contour_a
 			Name:          "stats-health",
 			Address:       SocketAddress(metrics.Address, metrics.Port),
 			SocketOptions: TCPKeepaliveSocketOptions(),
-			FilterChains:  filterChain("stats", nil, routeForAdminInterface("/ready", "/stats")),
+			FilterChains:  filterChain("stats", nil, routeForAdminInterface(append([]string{"/ready"}, paths...)...)),
 		}}
 
 	// Create separate HTTP listeners for metrics and health.
@@ -68,7 +84,7 @@ func StatsListeners(metrics contour_api_v1alpha1.MetricsConfig, health contour_a
 			Name:          "stats",
 			Address:       SocketAddress(metrics.Address, metrics.Port),
 			SocketOptions: TCPKeepaliveSocketOptions(),
-			FilterChains:  filterChain("stats", nil, routeForAdminInterface("/stats")),
+			FilterChains:  filterChain("stats", nil, routeForAdminInterface(paths...)),
 		}, {
 			Name:          "health",
 			Address:       SocketAddress(health.Address, health.Port),
@@ -158,8 +174,9 @@ func routeForAdminInterface(prefixes ...string) *http.HttpConnectionManager_Rout
 }
 
 // downstreamTLSContext creates TLS context when HTTPS is used to protect Envoy stats endpoint.
-// Certificates and key are hardcoded to the SDS secrets which are returned by StatsSecrets.
-func downstreamTLSContext(clientValidation bool) *envoy_tls_v3.DownstreamTlsContext {
+// Certificates and key are hardcoded to the SDS secrets which are returned by StatsSecrets,
+// or, if useXDSCertificate is set, to the static secret written by the bootstrap.
+func downstreamTLSContext(useXDSCertificate, clientValidation bool) *envoy_tls_v3.DownstreamTlsContext {
 	context := &envoy_tls_v3.DownstreamTlsContext{
 		CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
 			TlsParams: &envoy_tls_v3.TlsParameters{
@@ -173,6 +190,13 @@ func downstreamTLSContext(clientValidation bool) *envoy_tls_v3.DownstreamTlsCont
 		},
 	}
 
+	if useXDSCertificate {
+		// A secret config with only a name refers to a static secret.
+		context.CommonTlsContext.TlsCertificateSdsSecretConfigs = []*envoy_tls_v3.SdsSecretConfig{{
+			Name: XDSCertificateSecretName,
+		}}
+	}
+
 	if clientValidation {
 		context.CommonTlsContext.ValidationContextType = &envoy_tls_v3.CommonTlsContext_ValidationContextSdsSecretConfig{
 			ValidationContextSdsSecretConfig: &envoy_tls_v3.SdsSecretConfig{
@@ -191,7 +215,7 @@ func StatsSecrets(metricsTLS *contour_api_v1alpha1.MetricsTLS) []*envoy_tls_v3.S
 	secrets := []*envoy_tls_v3.Secret{}
 
 	if metricsTLS != nil {
-		if metricsTLS.CertFile != "" && metricsTLS.KeyFile != "" {
+		if !metricsTLS.UseXDSCertificate && metricsTLS.CertFile != "" && metricsTLS.KeyFile != "" {
 			secrets = append(secrets, &envoy_tls_v3.Secret{
 				Name: metricsServerCertSDSName,
 				Type: &envoy_tls_v3.Secret_TlsCertificate{
@@ -340,6 +340,99 @@ func TestStatsListeners(t *testing.T) {
 			SocketOptions: TCPKeepaliveSocketOptions(),
 		}}})
 
+	run(t, "stats-over-https-with-xds-certificate-and-allowed-paths", testcase{
+		metrics: contour_api_v1alpha1.MetricsConfig{
+			Address: "127.0.0.127",
+			Port:    8123,
+			Paths:   []string{"/stats/prometheus"},
+			TLS: &contour_api_v1alpha1.MetricsTLS{
+				UseXDSCertificate: true,
+			},
+		},
+		health: contour_api_v1alpha1.HealthConfig{
+			Address: "127.0.0.127",
+			Port:    8124},
+		want: []*envoy_listener_v3.Listener{{
+			Name:    "stats",
+			Address: SocketAddress("127.0.0.127", 8123),
+			FilterChains: []*envoy_listener_v3.FilterChain{{
+				Filters: []*envoy_listener_v3.Filter{{
+					Name: wellknown.HTTPConnectionManager,
+					ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+						TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+							StatPrefix: "stats",
+							RouteSpecifier: &http.HttpConnectionManager_RouteConfig{
+								RouteConfig: &envoy_route_v3.RouteConfiguration{
+									VirtualHosts: []*envoy_route_v3.VirtualHost{{
+										Name:    "backend",
+										Domains: []string{"*"},
+										Routes: []*envoy_route_v3.Route{{
+											Match: &envoy_route_v3.RouteMatch{
+												PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{
+													Prefix: "/stats/prometheus",
+												},
+											},
+											Action: &envoy_route_v3.Route_Route{
+												Route: &envoy_route_v3.RouteAction{
+													ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+														Cluster: "envoy-admin",
+													},
+												},
+											},
+										}},
+									}},
+								},
+							},
+							HttpFilters: []*http.HttpFilter{{
+								Name: wellknown.Router,
+							}},
+							NormalizePath: protobuf.Bool(true),
+						}),
+					},
+				}},
+				TransportSocket: DownstreamTLSTransportSocket(
+					&envoy_tls_v3.DownstreamTlsContext{
+						CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
+							TlsParams: &envoy_tls_v3.TlsParameters{
+								TlsMinimumProtocolVersion: envoy_tls_v3.TlsParameters_TLSv1_3,
+								TlsMaximumProtocolVersion: envoy_tls_v3.TlsParameters_TLSv1_3,
+							},
+							TlsCertificateSdsSecretConfigs: []*envoy_tls_v3.SdsSecretConfig{{
+								Name: "envoy_xds_certificate",
+							}},
+						},
+					},
+				),
+			}},
+			SocketOptions: TCPKeepaliveSocketOptions(),
+		}, {
+			Name:    "health",
+			Address: SocketAddress("127.0.0.127", 8124),
+			FilterChains: FilterChains(
+				&envoy_listener_v3.Filter{
+					Name: wellknown.HTTPConnectionManager,
+					ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+						TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+							StatPrefix: "stats",
+							RouteSpecifier: &http.HttpConnectionManager_RouteConfig{
+								RouteConfig: &envoy_route_v3.RouteConfiguration{
+									VirtualHosts: []*envoy_route_v3.VirtualHost{{
+										Name:    "backend",
+										Domains: []string{"*"},
+										Routes:  []*envoy_route_v3.Route{readyRoute},
+									}},
+								},
+							},
+							HttpFilters: []*http.HttpFilter{{
+								Name: wellknown.Router,
+							}},
+							NormalizePath: protobuf.Bool(true),
+						}),
+					},
+				},
+			),
+			SocketOptions: TCPKeepaliveSocketOptions(),
+		}}})
 }
 
 func TestStatsTLSSecrets(t *testing.T) {
@@ -414,4 +507,22 @@ func TestStatsTLSSecrets(t *testing.T) {
 			},
 		}},
 	})
+	run(t, "xds-certificate-with-client-authentication", testcase{
+		metricsTLS: contour_api_v1alpha1.MetricsTLS{
+			UseXDSCertificate: true,
+			CAFile:            "cabundle",
+		},
+		want: []*envoy_tls_v3.Secret{{
+			Name: "metrics-ca-certificate",
+			Type: &envoy_tls_v3.Secret_ValidationContext{
+				ValidationContext: &envoy_tls_v3.CertificateValidationContext{
+					TrustedCa: &envoy_core_v3.DataSource{
+						Specifier: &envoy_core_v3.DataSource_Filename{
+							Filename: "cabundle",
+						},
+					},
+				},
+			},
+		}},
+	})
 }
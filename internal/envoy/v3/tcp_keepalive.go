@@ -18,11 +18,37 @@ import (
 	"github.com/projectcontour/contour/internal/envoy"
 )
 
+// Default TCP keep-alive settings of listeners, in seconds for the
+// idle time and probe interval.
+const (
+	defaultTCPKeepaliveIdle     = 45
+	defaultTCPKeepaliveInterval = 5
+	defaultTCPKeepaliveProbes   = 9
+)
+
 func TCPKeepaliveSocketOptions() []*envoy_core_v3.SocketOption {
 
 	// Note: TCP_KEEPIDLE + (TCP_KEEPINTVL * TCP_KEEPCNT) must be greater than
 	// the grpc.KeepaliveParams time + timeout (currently 60 + 20 = 80 seconds)
 	// otherwise TestGRPC/StreamClusters fails.
+	return TCPKeepaliveSocketOptionsWith(defaultTCPKeepaliveIdle, defaultTCPKeepaliveInterval, defaultTCPKeepaliveProbes)
+}
+
+// TCPKeepaliveSocketOptionsWith returns the socket options that enable
+// TCP keep-alive with the given idle time and probe interval, both in
+// seconds, and probe count. Zero values default to those of
+// TCPKeepaliveSocketOptions.
+func TCPKeepaliveSocketOptionsWith(idle, interval, probes uint32) []*envoy_core_v3.SocketOption {
+	if idle == 0 {
+		idle = defaultTCPKeepaliveIdle
+	}
+	if interval == 0 {
+		interval = defaultTCPKeepaliveInterval
+	}
+	if probes == 0 {
+		probes = defaultTCPKeepaliveProbes
+	}
+
 	return []*envoy_core_v3.SocketOption{
 		// Enable TCP keep-alive.
 		{
@@ -38,7 +64,7 @@ func TCPKeepaliveSocketOptions() []*envoy_core_v3.SocketOption {
 			Description: "TCP keep-alive initial idle time",
 			Level:       envoy.IPPROTO_TCP,
 			Name:        envoy.TCP_KEEPIDLE,
-			Value:       &envoy_core_v3.SocketOption_IntValue{IntValue: 45},
+			Value:       &envoy_core_v3.SocketOption_IntValue{IntValue: int64(idle)},
 			State:       envoy_core_v3.SocketOption_STATE_LISTENING,
 		},
 		// The time (in seconds) between individual keepalive probes.
@@ -46,7 +72,7 @@ func TCPKeepaliveSocketOptions() []*envoy_core_v3.SocketOption {
 			Description: "TCP keep-alive time between probes",
 			Level:       envoy.IPPROTO_TCP,
 			Name:        envoy.TCP_KEEPINTVL,
-			Value:       &envoy_core_v3.SocketOption_IntValue{IntValue: 5},
+			Value:       &envoy_core_v3.SocketOption_IntValue{IntValue: int64(interval)},
 			State:       envoy_core_v3.SocketOption_STATE_LISTENING,
 		},
 		// The maximum number of TCP keep-alive probes to send before
@@ -56,7 +82,7 @@ func TCPKeepaliveSocketOptions() []*envoy_core_v3.SocketOption {
 			Description: "TCP keep-alive probe count",
 			Level:       envoy.IPPROTO_TCP,
 			Name:        envoy.TCP_KEEPCNT,
-			Value:       &envoy_core_v3.SocketOption_IntValue{IntValue: 9},
+			Value:       &envoy_core_v3.SocketOption_IntValue{IntValue: int64(probes)},
 			State:       envoy_core_v3.SocketOption_STATE_LISTENING,
 		},
 	}
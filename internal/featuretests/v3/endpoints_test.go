@@ -241,11 +241,11 @@ func TestEndpointFilter(t *testing.T) {
 		),
 	})
 
+	// There is no "bar" port, so the cluster is left
+	// out rather than sent an empty load assignment.
 	c.Request(endpointType, "default/kuard/bar").Equals(&envoy_discovery_v3.DiscoveryResponse{
-		TypeUrl: endpointType,
-		Resources: resources(t,
-			envoy_v3.ClusterLoadAssignment("default/kuard/bar"),
-		),
+		TypeUrl:   endpointType,
+		Resources: nil,
 	})
 }
 
@@ -23,6 +23,7 @@ import (
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes/any"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/dag"
@@ -32,6 +33,7 @@ import (
 	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/projectcontour/contour/internal/protobuf"
 	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -496,6 +498,126 @@ func globalRateLimitVhostAndRouteRateLimitDefined(t *testing.T, rh cache.Resourc
 	}
 }
 
+func globalRateLimitRouteOverridesVhostRateLimit(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	p := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "proxy1",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "foo.com",
+				RateLimitPolicy: &contour_api_v1.RateLimitPolicy{
+					Global: &contour_api_v1.GlobalRateLimitPolicy{
+						Descriptors: []contour_api_v1.RateLimitDescriptor{
+							{
+								Entries: []contour_api_v1.RateLimitDescriptorEntry{
+									{
+										RemoteAddress: &contour_api_v1.RemoteAddressDescriptor{},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{
+				{
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/s1",
+					}},
+					Services: []contour_api_v1.Service{
+						{
+							Name: "s1",
+							Port: 80,
+						},
+					},
+					RateLimitPolicy: &contour_api_v1.RateLimitPolicy{
+						Global: &contour_api_v1.GlobalRateLimitPolicy{
+							InheritVirtualHostPolicy: true,
+							Descriptors: []contour_api_v1.RateLimitDescriptor{
+								{
+									Entries: []contour_api_v1.RateLimitDescriptorEntry{
+										{
+											GenericKey: &contour_api_v1.GenericKeyDescriptor{Value: "generic-key-value"},
+										},
+									},
+									Stage: 1,
+								},
+							},
+						},
+					},
+				},
+				{
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/s2",
+					}},
+					Services: []contour_api_v1.Service{
+						{
+							Name: "s2",
+							Port: 80,
+						},
+					},
+					RateLimitPolicy: &contour_api_v1.RateLimitPolicy{
+						Global: &contour_api_v1.GlobalRateLimitPolicy{
+							Disabled: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rh.OnAdd(p)
+	c.Status(p).IsValid()
+
+	remoteAddress := &envoy_route_v3.RateLimit{
+		Actions: []*envoy_route_v3.RateLimit_Action{
+			{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_RemoteAddress_{
+					RemoteAddress: &envoy_route_v3.RateLimit_Action_RemoteAddress{},
+				},
+			},
+		},
+	}
+
+	vhost := envoy_v3.VirtualHost("foo.com",
+		&envoy_route_v3.Route{
+			Match:  routePrefix("/s2"),
+			Action: routeCluster("default/s2/80/da39a3ee5e"),
+			TypedPerFilterConfig: map[string]*any.Any{
+				"envoy.filters.http.ratelimit": protobuf.MustMarshalAny(&ratelimit_filter_v3.RateLimitPerRoute{
+					VhRateLimits: ratelimit_filter_v3.RateLimitPerRoute_IGNORE,
+				}),
+			},
+		},
+		&envoy_route_v3.Route{
+			Match: routePrefix("/s1"),
+			Action: routeCluster("default/s1/80/da39a3ee5e", func(r *envoy_route_v3.Route_Route) {
+				r.Route.RateLimits = []*envoy_route_v3.RateLimit{
+					remoteAddress,
+					{
+						Stage: wrapperspb.UInt32(1),
+						Actions: []*envoy_route_v3.RateLimit_Action{
+							{
+								ActionSpecifier: &envoy_route_v3.RateLimit_Action_GenericKey_{
+									GenericKey: &envoy_route_v3.RateLimit_Action_GenericKey{DescriptorValue: "generic-key-value"},
+								},
+							},
+						},
+					},
+				}
+			}),
+		},
+	)
+	vhost.RateLimits = []*envoy_route_v3.RateLimit{remoteAddress}
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl:   routeType,
+		Resources: resources(t, envoy_v3.RouteConfiguration("ingress_http", vhost)),
+	})
+}
+
 func globalRateLimitMultipleDescriptorsAndEntries(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
 	p := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -656,6 +778,7 @@ func TestGlobalRateLimiting(t *testing.T) {
 		},
 
 		"MultipleDescriptorsAndEntriesDefined": globalRateLimitMultipleDescriptorsAndEntries,
+		"RouteOverridesVirtualHostRateLimit":   globalRateLimitRouteOverridesVhostRateLimit,
 	}
 
 	for n, f := range subtests {
@@ -0,0 +1,108 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+// golden file helpers
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites golden files with the current xDS output of the
+// tests that compare against them, for example:
+//
+//	go test ./internal/featuretests/v3 -run TestGolden -update
+//
+// Review the resulting changes to testdata like any other change.
+var updateGolden = flag.Bool("update", false, "rewrite golden files with the current xDS output")
+
+// goldenTypes are the xDS resource types recorded in a
+// golden file, in the order they are written.
+var goldenTypes = []string{
+	listenerType,
+	routeType,
+	clusterType,
+	endpointType,
+	secretType,
+}
+
+// Golden compares the complete xDS output of Contour with the golden
+// file testdata/<name>.golden, and fails the test with a diff if they
+// differ. If the -update flag is set, the golden file is rewritten
+// with the current output instead.
+func (c *Contour) Golden(name string) *Contour {
+	c.Helper()
+
+	got, err := c.snapshot()
+	require.NoError(c, err)
+
+	diff, err := compareGolden(filepath.Join("testdata", name+".golden"), got, *updateGolden)
+	require.NoError(c, err)
+	if diff != "" {
+		c.Errorf("xDS output differs from golden file %q (-want +got):\n%s", name, diff)
+	}
+
+	return c
+}
+
+// snapshot renders the resources of every xDS type as JSON.
+func (c *Contour) snapshot() ([]byte, error) {
+	c.Helper()
+
+	m := jsonpb.Marshaler{Indent: "  ", OrigName: true}
+
+	var buf bytes.Buffer
+	for _, typ := range goldenTypes {
+		fmt.Fprintf(&buf, "# %s\n", typ)
+		for _, r := range c.Request(typ).Resources {
+			if err := m.Marshal(&buf, r); err != nil {
+				return nil, fmt.Errorf("failed to marshal %s: %w", typ, err)
+			}
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compareGolden returns the difference between the golden file at path
+// and got, or rewrites the golden file with got if update is true.
+func compareGolden(path string, got []byte, update bool) (string, error) {
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
+		}
+		return "", ioutil.WriteFile(path, got, 0644) // nolint:gosec
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("golden file %s does not exist, run the test with -update to create it", path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return cmp.Diff(string(want), string(got)), nil
+}
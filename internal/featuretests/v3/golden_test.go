@@ -0,0 +1,47 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "scenario.golden")
+
+	// A missing golden file is an error.
+	_, err := compareGolden(path, []byte("a\n"), false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "-update")
+
+	// Updating creates the golden file.
+	diff, err := compareGolden(path, []byte("a\nb\n"), true)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// Matching output has no diff.
+	diff, err = compareGolden(path, []byte("a\nb\n"), false)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// Differing output is reported.
+	diff, err = compareGolden(path, []byte("a\nc\n"), false)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "b")
+	assert.Contains(t, diff, "c")
+}
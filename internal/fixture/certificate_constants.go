@@ -299,4 +299,80 @@ fvDWUEECgYEAzOk24/WyJSaJ2yQDcRURxQ9chrhFRBSnzgNyG14TPZRNljSUoxCz
 3tcXbHydB69NQY5iTc5COS5AksJJRQL08Aejq++ROuNH8nHv3i8td3n5f+0QFc1c
 8qhtEsV8xM2J5UYUKZH5aE89vCPRFVOGEx+F8sqHt0rjBd6j8jIkuLo=
 -----END RSA PRIVATE KEY-----`
+	// PKCS12_KEYSTORE is a base64 encoded PKCS#12 keystore holding
+	// WILDCARD_KEY and the chain of WILDCARD_CERT and CA_CERT. Its
+	// password is "contour".
+	// cat certs/wildcardcert.pem certs/CAcert.pem > certs/wildcardchain.pem
+	// openssl pkcs12 -export -inkey certs/wildcardkey.pem -in certs/wildcardchain.pem -passout pass:contour -keypbe PBE-SHA1-3DES -certpbe PBE-SHA1-3DES -macalg sha1 -out certs/wildcard.p12
+	PKCS12_KEYSTORE = `
+MIIM8QIBAzCCDLcGCSqGSIb3DQEHAaCCDKgEggykMIIMoDCCB1cGCSqGSIb3DQEH
+BqCCB0gwggdEAgEAMIIHPQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQMwDgQIJhtk
+Mhh2Q8cCAggAgIIHECP5PPr+baMxK0Fx26aQwIbDl/EfXHbUyxfTmQgGpuszd3YP
+dXIlKg5IccY5pMcfAQ7/Qm44KGpGonOJn1YY//R/TmtyV5VuiJ5iq5mXdp4DpM3c
+OthIbhf4hGBDQ1h9IlX8IkmOm6gbpY5zorqX0KohFqdaGa0FXTK9LHeOn97mjNmj
+kjNuVXc6nrqA9kv3i1L+ms+RN3OZzBezHOgbRTsSbV8y5FVPa5xmjhISViqKTcJC
+JCXbovy4UHD31nYMxkIAPdWtDJ3t2aTpJrAL8QEiqUSN2fmWcK50ifb3b2pyMrAO
+eiEfHaQZA1PDvWZ1v7oK+2RYTe/zGXs3dMNCacdcL/jLBATF6V/9FOrbyiN5qvlv
+16k1pzqVbue4EwxZA2h811M8KKdqxGVlepulmPP57jVXi1MkSIHhBp1OFEcHtq7z
+T+MVJwx3DETukpQAzW+PtJv1DF7ARf27a7KubJY049S95GSj9OcA14nxfcOeZG4z
+TqdmVJLZSnLNTZ4ZgSRHetD6ZruBCyvTwqaGEHmBXecoxgoCrcRqx0D1nuRuj2Pf
+QtufZPo6KkKzH1tshRrIIfNbB7lY7ZY8aAjtl9h/LmL0/WJ+pFBr7FP8OSxIkvm0
+dbVdyCdKEp8QYii14ALvoEnJpW7b4ar2wUg1/RBrR/JvCXzwHNYVc0WXHkaAWZhO
+6ZQnGh8nttpJWtQZmY9wEbPxvTliV64PDukp5CWOx5EYqM11VczZSbsD8c5FXsAR
+zvwaNu3Qp6knLRMmHm455NGJeP0AlmtC5aRoiJ//9/TPSprV5EumwPyeGs67ERy6
+2NAmLJlxeSFYk0dih8lV9xuQUFLOSoVp/dHWsB7d5fmO8gvxibD5qSFVmlim3Zbs
+t/IRiiITEd42WLuPzN+LjccXmIgsU7jZBCJICe4AVmH/MbY4POSR9GTRB61VZgPf
+YIg5KqbU/Iuir0VjQLe9yEcQJshYmi0nMoii/xv9E6lWWFFMjWgWa3SU0ayUHlJa
+YH2hZdMSx6atbvjhwYgg34aLxu3027avYwMGpYajy/rh17jPOGvuKslNn08ELZ05
+rW3b3w4sY3QHsvT11R91iLVEy+ZlUSi7QwKQnRU9/KheEBpZkoF4Y/lhavAsy8OB
+oCnmR4H34CwLcUQLalrdPo2W6Jam4vQjtPCW+PryWRTcZ/0gz/X6u4+zPup18nJ0
+D8skwH/IKrlc7CBYid1ZTnkKa+8CzExy5sg+gcSP8QCd8ggD5VkkyzdcgIFojNjF
+U5bNd0UMldwRSM2cyW+78oSxR00lOr46aix23KhtGIGwGnTV0haloxUBit2eMuKe
+Fq7eSupBrSRRZJQe8UKnfsbD5TMIFBmNW9VPRqGjovQm8WAdGRU6kNOw8TdiS+Ul
+mVXkVbUdiMVHcn9AoeoCjBpGDjxu2Z+ICFYLOKXVxbQTeS0uothoq2cc1dFAjk/8
+DODxsp5G3Lz+aR9ohGezLpBPQZBovRl2P56fdPxeWFYe1dB6ZAj6As2sncyoce5r
+60Jc/As8gInej++NWYc/nQFSh6Tu+PU0i1/xCTXfC1r/zLJx1+4iQwCXlOTlGdZj
+4Wu4wEZ3BqWnInn7SiVZa137nE7LF7seMgv2X5GSjVzOy8ZitKsRRRUQ38M2QHZe
+IsvEFHLwmVLUCZg3KHad541K2p6+P6MjZG05lHF5fokezActrzU96c55WRoSQjGy
+2XYvySMbusE78zukBQqrvoN9f9hNBqLS30oyjM5MUJkAbLQPczTkVqXbbaMwaZHN
+C12SVtJ7NsSHm01PkhZxQrbUGmOn09c4ZuxkxiQaSW4CR9OLMNOZfQV9mcFW6Fqc
+5fgDpmaqagUHBAej7novWr/bKZBw5KdxNiD0/czUnBx07qMyvsf5kYlfPKSaAmLt
+vTH5g9pz2alCXn2/6qcrJuPUscRK1Ntq2Gb4OeLQtvwBeiCF5XYxBDeljz4JI+XI
+4nY6hTXZ1b88spAY5PXBq1Fzl49xReQGVtYe81ETeMZKxVmR4GLlWAJ/htlPFK3Z
+IlLf7XYPjpzYJ74VHR8L0dmfRNZNgypbjj8VpLx+FAYkwPvbMCaOAzJr8V8Io3vo
+Bz+1yVpotO6vUPjGTUtcF4ThRdGrrJ/ANT6f+lHD1SAj6N0vwejvV5t3p+fsfJ64
+l2+5SsHHKp9rPjT7UnmAjDMlixyVujyt+ZUaCs7K2HlWYzEBzNBERQQm2MS6DSjc
+/PEGOBFoyGmIcGOJ44Re5ULgFx3i681SOjK+0IT66JE5+6WZWg+jXHyEqUEnQHLx
+ijCwObePSA3S79myWaujvieiaQyTN45jfdU7EmjYzx7lvm9v/cUfLqQUJLq0iuDJ
+5ZZbW9vKOPi8V5HiJX0VMkxgdZAfNz/dreQDuqaJqKYQz5FHyExfMjh1qsNpMIIF
+QQYJKoZIhvcNAQcBoIIFMgSCBS4wggUqMIIFJgYLKoZIhvcNAQwKAQKgggTuMIIE
+6jAcBgoqhkiG9w0BDAEDMA4ECAW+LscB+W6HAgIIAASCBMjIabqJetqPuiA1IfOH
+v6o+PPRfmdm8+RKjnmZ02WjAzP0FNvnql0Un/Li0zCxeNGg0Ud2N5HJ1TCcCtzjF
+o8549lOGDdUkJRG9RX0OJ3WHX7omW/7Kl/AvkIuuq5CrZi68oAEAHo+XCXPBrp7u
+GjY2kHfbpNugP3DWxNUoYmCZG7bTQG+uZy8eg/DEF0B9anH1tzIIoeWAV79hfToo
+TRYkwVdD6qjIXbZdnAOtIGXADxO2Y1O057EQk8YMXAN2Z7HAPPzaGEwxfbsUn4g0
+Ja0PdUal40EivjJ1YNjm+ilMK/qQ4zVFKFf6x3f9tIjjjTDyBpazrX1uo7tlFY7k
+iUWyV+WWX+MiPqxEAFpM9KSLN2Ul1Xoe6gobMJbmYKUtkwkBM/IOIAe46Jr5Dw8E
+XOBkfhZMv4QxFLffgg+NDxRgwAD4+JKyEEe0X7SKWb++Ly2BRlQXeEA5II6DX4TY
+K1czt9Nde1WXt/kNuVkh4t/UZYiTpl2tPtIR2hvCG0b0QDoHaZbugVGx9vd9gWE+
+zml2vAePILC6rRnNom8K19+fnUrZNlKepB+aQh1wdwVjDzu/+5Vp98Syka3L72R1
+cmmsEwsuZcKMcABg+uxJ8BsIyAS5wzgnbwHsuhtyD5JSTWFwmQNNpfoYr2XTBU57
+b5N/Xlqp+0Wrw0N7YEtTY3br0TcEQm1eV5Q32enAz9ivxP9fhwcnymLXQ5LNM37R
+49yOVFlreZX2ZOu2vIcIiLgELntjRDpKbHsu3UtNT1q5O1j85d9HlkNarPzqjvc7
+G23DeVcNLfZaW3+YYpl7yZlGvrX5TYdOHGgGcdjAcc0mnIMlR83t+9cdE5NBHEse
++3G8Y1h7X1sQtLgLmF3rvxv9GZgQwP0hnM3hAQ5apx+InE1zrMtUwNmEXhFqqzD7
+YGmAtlPct8w4/ZUN/a9hARmaskNvUNPU9xu/KajB6VqKB8TpAsPPLfzSH9BUEooh
+Nxvh7n/cxSYHNH6rV1NcFCGfSA/cKJoSrlj0JfPYAUzViqNoITMbneJ10+tyttst
+mbBK6D9JWc0TRFc/oi8WsUqg8TDVSayadOw2eRg6gU/pAqDwcc+YbTw339z0jQ8O
+tWbrvyMhBwtdwJYAjhzjE3GkdRHSnKqz6zYBnOwVGlEVOKLc6QEJKhK0HbcySTNE
+kDF3hvbjm3/AjrbIiwYX8UN4dJnu5yX9piBxjFrla6PKAZKh7nXIKFOaKIKgRJGv
+st3khmVgbW+NECU0yvFk84c6HBwmj93O7fdZm3702HpUZufXAGT/8l0CoQjk0KNH
+eREzmzo4KehN0HlbNk1/SM7XEu0bxs85aBWHkhonVsIjMoiXtgCbgqOt8WTpcua+
+ZQK4PuzqR4ARK1XHhaFP9bWNPzBk1tZZxoCmuGkK7hceBTtoDh5oQIbuQIkk17xK
+u9i0FLHWWcsPX77GPPusnGB8+mth3obm3t08QfU7o2erLmezkOvIcNg6aMsqIx18
+vYmhhlC9KdNirUVcrjfxRPxg+/XfYfxZ4O0leUx2KmZDMx1kNZ06cN/bJmfCs8OZ
+99VL3oMonCt2koY2O/J6IMKTRKhu6IrAa5zm+dmZ9q8AHPEEWz3Vkk3jVmMJM7Gf
+vQZT6H67jL0rkVUxJTAjBgkqhkiG9w0BCRUxFgQUmMB6D9/3/wl+N0C3MxtHTynH
+COkwMTAhMAkGBSsOAwIaBQAEFGEyz7pJQBp2q+5tRN44c1z5QYtBBAjof3F/zOH0
+1QICCAA=`
 )
@@ -54,6 +54,15 @@ func (dcb *DetailedConditionBuilder) Orphaned() v1.DetailedCondition {
 	return *dc
 }
 
+func (dcb *DetailedConditionBuilder) OrphanedWithWarning(reason, message string) v1.DetailedCondition {
+
+	dc := (*v1.DetailedCondition)(dcb)
+	dc.AddError(v1.ConditionTypeOrphanedError, "Orphaned", "this HTTPProxy is not part of a delegation chain from a root HTTPProxy")
+	dc.AddWarning(v1.ConditionTypeOrphanedError, reason, message)
+
+	return *dc
+}
+
 func (dcb *DetailedConditionBuilder) WithError(errorType string, reason, message string) v1.DetailedCondition {
 
 	dc := (*v1.DetailedCondition)(dcb)
@@ -182,3 +182,11 @@ func TLSRouteBackendRef(serviceName string, port int, weight *int32) []gatewayap
 		},
 	}
 }
+
+func UDPRouteBackendRef(serviceName string, port int) []gatewayapi_v1alpha2.BackendRef {
+	return []gatewayapi_v1alpha2.BackendRef{
+		{
+			BackendObjectReference: ServiceBackendObjectRef(serviceName, port),
+		},
+	}
+}
@@ -22,7 +22,9 @@ import (
 )
 
 // Handler returns a http Handler for a health endpoint.
-func Handler(client *kubernetes.Clientset) http.Handler {
+// The endpoint fails if the Kubernetes API server cannot
+// be reached, or if any of the supplied checks fail.
+func Handler(client *kubernetes.Clientset, checks ...func() error) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Try and lookup Kubernetes server version as a quick and dirty check
 		_, err := client.ServerVersion()
@@ -31,6 +33,12 @@ func Handler(client *kubernetes.Clientset) http.Handler {
 			http.Error(w, msg, http.StatusServiceUnavailable)
 			return
 		}
+		for _, check := range checks {
+			if err := check(); err != nil {
+				http.Error(w, fmt.Sprintf("Failed Check: %v", err), http.StatusServiceUnavailable)
+				return
+			}
+		}
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintln(w, "OK")
 	})
@@ -17,6 +17,7 @@ package httpsvc
 
 import (
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -24,6 +25,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -39,6 +41,10 @@ type Service struct {
 	Cert     string
 	Key      string
 
+	// Token, if set, is the bearer token required
+	// to access every endpoint of the service.
+	Token string
+
 	logrus.FieldLogger
 	http.ServeMux
 }
@@ -72,7 +78,7 @@ func (svc *Service) Start(stop <-chan struct{}) (err error) {
 
 	s := http.Server{
 		Addr:           net.JoinHostPort(svc.Addr, strconv.Itoa(svc.Port)),
-		Handler:        &svc.ServeMux,
+		Handler:        RequireBearerToken(svc.Token, &svc.ServeMux),
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   5 * time.Minute, // allow for long trace requests
 		MaxHeaderBytes: 1 << 11,         // 8kb should be enough for anyone
@@ -143,3 +149,39 @@ func (svc *Service) tlsConfig() (*tls.Config, error) {
 		},
 	}, nil
 }
+
+// RequireBearerToken returns a handler that serves h only to requests
+// that present token as a bearer token in the Authorization header.
+// Other requests are answered with 401 Unauthorized. If token is
+// empty, h is returned.
+func RequireBearerToken(token string, h http.Handler) http.Handler {
+	if token == "" {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="contour"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Authorized reports whether r presents token as a bearer token in
+// the Authorization header. Every request is authorized if token is
+// empty.
+func Authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, prefix)), []byte(token)) == 1
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/x509"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -131,3 +132,55 @@ func TestHTTPSService(t *testing.T) {
 	cancel()
 	<-done
 }
+
+func TestRequireBearerToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := map[string]struct {
+		token  string
+		header string
+		want   int
+	}{
+		"no token required": {
+			want: http.StatusOK,
+		},
+		"valid token": {
+			token:  "secret",
+			header: "Bearer secret",
+			want:   http.StatusOK,
+		},
+		"missing token": {
+			token: "secret",
+			want:  http.StatusUnauthorized,
+		},
+		"wrong token": {
+			token:  "secret",
+			header: "Bearer guess",
+			want:   http.StatusUnauthorized,
+		},
+		"not a bearer token": {
+			token:  "secret",
+			header: "Basic secret",
+			want:   http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireBearerToken(tc.token, ok).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code)
+			if tc.want == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="contour"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -49,6 +49,10 @@ func KindOf(obj interface{}) string {
 			return "TLSCertificateDelegation"
 		case *v1alpha1.ExtensionService:
 			return "ExtensionService"
+		case *v1alpha1.TrafficWeights:
+			return "TrafficWeights"
+		case *v1alpha1.ContourPolicy:
+			return "ContourPolicy"
 		case *unstructured.Unstructured:
 			return obj.GetKind()
 		default:
@@ -74,7 +78,7 @@ func VersionOf(obj interface{}) string {
 			return networking_v1.SchemeGroupVersion.String()
 		case *contour_api_v1.HTTPProxy, *contour_api_v1.TLSCertificateDelegation:
 			return contour_api_v1.GroupVersion.String()
-		case *v1alpha1.ExtensionService:
+		case *v1alpha1.ExtensionService, *v1alpha1.TrafficWeights, *v1alpha1.ContourPolicy:
 			return v1alpha1.GroupVersion.String()
 		case *unstructured.Unstructured:
 			return obj.GetAPIVersion()
@@ -24,6 +24,7 @@ import (
 	"github.com/bombsimon/logrusr"
 	"github.com/go-logr/logr"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klog "k8s.io/klog/v2"
 )
 
@@ -116,3 +117,23 @@ func (l *callDepthLogr) Error(err error, msg string, keysAndValues ...interface{
 func (l *callDepthLogr) V(level int) logr.Logger {
 	return l
 }
+
+// LogFields returns the structured logging fields that identify the
+// given Kubernetes object by kind, namespace and name. Using these
+// fields consistently lets log lines about a resource be correlated
+// regardless of which component emitted them.
+func LogFields(obj metav1.Object) logrus.Fields {
+	fields := logrus.Fields{
+		"name": obj.GetName(),
+	}
+
+	if kind := KindOf(obj); kind != "" {
+		fields["kind"] = kind
+	}
+
+	if ns := obj.GetNamespace(); ns != "" {
+		fields["namespace"] = ns
+	}
+
+	return fields
+}
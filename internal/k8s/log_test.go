@@ -24,9 +24,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klog "k8s.io/klog/v2"
 )
 
@@ -171,3 +174,20 @@ func TestMultipleLogLevelOptions(t *testing.T) {
 	assert.True(t, klog.V(3).Enabled())
 	assert.False(t, klog.V(5).Enabled())
 }
+
+func TestLogFields(t *testing.T) {
+	assert.Equal(t, logrus.Fields{
+		"kind":      "Service",
+		"name":      "kuard",
+		"namespace": "default",
+	}, LogFields(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "kuard", Namespace: "default"},
+	}))
+
+	assert.Equal(t, logrus.Fields{
+		"kind": "Namespace",
+		"name": "default",
+	}, LogFields(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+	}))
+}
@@ -15,13 +15,26 @@ package k8s
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/projectcontour/contour/internal/metrics"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
+// statusFieldOwner is the field manager used to
+// server-side apply status updates.
+const statusFieldOwner = "contour"
+
+// maxStatusUpdateRetries is the number of times a failed
+// status update is retried before it is dropped.
+const maxStatusUpdateRetries = 10
+
 // StatusUpdate contains an all the information needed to change an object's status to perform a specific update.
 // Send down a channel to the goroutine that actually writes the changes back.
 type StatusUpdate struct {
@@ -59,44 +72,210 @@ func (m StatusMutatorFunc) Mutate(old client.Object) client.Object {
 }
 
 // StatusUpdateHandler holds the details required to actually write an Update back to the referenced object.
+//
+// Updates are queued per object, so that a burst of updates to the same
+// object results in a single write of the latest one. Failed writes are
+// retried with exponential backoff, and the overall rate of writes is
+// limited to avoid being throttled by the API server.
 type StatusUpdateHandler struct {
 	Log           logrus.FieldLogger
 	Client        client.Client
 	UpdateChannel chan StatusUpdate
 	LeaderElected chan struct{}
 	IsLeader      bool
+
+	// RateLimiter limits how fast status writes, and retries of
+	// failed writes, are made. If nil, the default controller rate
+	// limiter is used.
+	RateLimiter workqueue.RateLimiter
+
+	// Metrics, if set, records the depth of the update queue
+	// and the latency of status writes.
+	Metrics *metrics.Metrics
+
+	queue   workqueue.RateLimitingInterface
+	mu      sync.Mutex
+	pending map[statusKey]StatusUpdate
+}
+
+// statusKey identifies the object a StatusUpdate applies to.
+type statusKey struct {
+	kind string
+	name types.NamespacedName
+}
+
+func keyFor(upd StatusUpdate) statusKey {
+	key := statusKey{name: upd.NamespacedName}
+	if upd.Resource != nil {
+		key.kind = KindOf(upd.Resource)
+	}
+	return key
 }
 
-func (suh *StatusUpdateHandler) apply(upd StatusUpdate) {
-	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		obj := upd.Resource
+// apply writes the status update, returning false if
+// the object's status was already up to date.
+func (suh *StatusUpdateHandler) apply(upd StatusUpdate) (bool, error) {
+	obj := upd.Resource
+
+	// Get the resource.
+	if err := suh.Client.Get(context.Background(), upd.NamespacedName, obj); err != nil {
+		return false, err
+	}
+
+	newObj := upd.Mutator.Mutate(obj)
 
-		// Get the resource.
-		if err := suh.Client.Get(context.Background(), upd.NamespacedName, obj); err != nil {
-			return err
+	if isStatusEqual(obj, newObj) {
+		suh.logFor(upd).Debug("update was a no-op")
+		return false, nil
+	}
+
+	// Server-side apply the status, so that we take ownership
+	// of the status fields we write and do not need to send
+	// the object's resource version.
+	gvk, err := apiutil.GVKForObject(newObj, suh.Client.Scheme())
+	if err != nil {
+		return false, err
+	}
+	newObj.GetObjectKind().SetGroupVersionKind(gvk)
+	newObj.SetResourceVersion("")
+	newObj.SetManagedFields(nil)
+
+	err = suh.Client.Status().Patch(context.Background(), newObj, client.Apply,
+		client.FieldOwner(statusFieldOwner), client.ForceOwnership)
+	return err == nil, err
+}
+
+// retryable returns true if a status write that
+// failed with err may succeed if retried.
+func retryable(err error) bool {
+	return errors.IsConflict(err) ||
+		errors.IsTooManyRequests(err) ||
+		errors.IsServerTimeout(err) ||
+		errors.IsTimeout(err) ||
+		errors.IsInternalError(err) ||
+		errors.IsServiceUnavailable(err)
+}
+
+// logFor returns a logger annotated with the kind, name and
+// namespace of the object the update applies to.
+func (suh *StatusUpdateHandler) logFor(upd StatusUpdate) logrus.FieldLogger {
+	log := suh.Log.WithField("name", upd.NamespacedName.Name).
+		WithField("namespace", upd.NamespacedName.Namespace)
+
+	if upd.Resource != nil {
+		if kind := KindOf(upd.Resource); kind != "" {
+			log = log.WithField("kind", kind)
 		}
+	}
 
-		newObj := upd.Mutator.Mutate(obj)
+	return log
+}
 
-		if isStatusEqual(obj, newObj) {
-			suh.Log.WithField("name", upd.NamespacedName.Name).
-				WithField("namespace", upd.NamespacedName.Namespace).
-				Debug("update was a no-op")
-			return nil
+// enqueue queues upd, replacing any update of the
+// same object that has not been written yet.
+func (suh *StatusUpdateHandler) enqueue(upd StatusUpdate) {
+	key := keyFor(upd)
+
+	suh.mu.Lock()
+	suh.pending[key] = upd
+	suh.mu.Unlock()
+
+	suh.queue.Add(key)
+	suh.setQueueDepth()
+}
+
+func (suh *StatusUpdateHandler) setQueueDepth() {
+	if suh.Metrics != nil {
+		suh.Metrics.SetStatusUpdateQueueDepth(suh.queue.Len())
+	}
+}
+
+// processNext writes the next queued update. It returns
+// false once the queue has been shut down.
+func (suh *StatusUpdateHandler) processNext() bool {
+	item, shutdown := suh.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer suh.queue.Done(item)
+	defer suh.setQueueDepth()
+
+	key := item.(statusKey)
+
+	suh.mu.Lock()
+	upd, ok := suh.pending[key]
+	delete(suh.pending, key)
+	suh.mu.Unlock()
+
+	if !ok {
+		suh.queue.Forget(key)
+		return true
+	}
+
+	start := time.Now()
+	updated, err := suh.apply(upd)
+
+	result := "updated"
+	switch {
+	case err != nil:
+		result = "error"
+	case !updated:
+		result = "noop"
+	}
+	if suh.Metrics != nil {
+		suh.Metrics.ObserveStatusUpdate(key.kind, result, time.Since(start))
+	}
+
+	if err == nil {
+		suh.queue.Forget(key)
+		return true
+	}
+
+	if retryable(err) && suh.queue.NumRequeues(key) < maxStatusUpdateRetries {
+		suh.logFor(upd).WithError(err).Debug("retrying status update")
+
+		// Retry, unless a newer update has been queued meanwhile.
+		suh.mu.Lock()
+		if _, ok := suh.pending[key]; !ok {
+			suh.pending[key] = upd
 		}
+		suh.mu.Unlock()
 
-		return suh.Client.Status().Update(context.Background(), newObj)
-	}); err != nil {
-		suh.Log.WithError(err).
-			WithField("name", upd.NamespacedName.Name).
-			WithField("namespace", upd.NamespacedName.Namespace).
-			Error("unable to update status")
+		if suh.Metrics != nil {
+			suh.Metrics.SetStatusUpdateRetriesTotal(key.kind)
+		}
+		suh.queue.AddRateLimited(key)
+		return true
 	}
+
+	suh.logFor(upd).WithError(err).Error("unable to update status")
+	suh.queue.Forget(key)
+	return true
 }
 
 // Start runs the goroutine to perform status writes.
 // Until the Contour is elected leader, will drop updates on the floor.
 func (suh *StatusUpdateHandler) Start(stop <-chan struct{}) error {
+	rl := suh.RateLimiter
+	if rl == nil {
+		rl = workqueue.DefaultControllerRateLimiter()
+	}
+	suh.queue = workqueue.NewRateLimitingQueue(rl)
+	suh.pending = map[statusKey]StatusUpdate{}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for suh.processNext() {
+		}
+	}()
+
+	defer func() {
+		suh.queue.ShutDown()
+		wg.Wait()
+	}()
+
 	for {
 		select {
 		case <-stop:
@@ -108,17 +287,13 @@ func (suh *StatusUpdateHandler) Start(stop <-chan struct{}) error {
 			suh.LeaderElected = nil
 		case upd := <-suh.UpdateChannel:
 			if !suh.IsLeader {
-				suh.Log.WithField("name", upd.NamespacedName.Name).
-					WithField("namespace", upd.NamespacedName.Namespace).
-					Debug("not leader, not applying update")
+				suh.logFor(upd).Debug("not leader, not applying update")
 				continue
 			}
 
-			suh.Log.WithField("name", upd.NamespacedName.Name).
-				WithField("namespace", upd.NamespacedName.Namespace).
-				Debug("received a status update")
+			suh.logFor(upd).Debug("received a status update")
 
-			suh.apply(upd)
+			suh.enqueue(upd)
 		}
 
 	}
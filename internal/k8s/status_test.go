@@ -0,0 +1,83 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStatusUpdateHandlerCoalescesUpdates(t *testing.T) {
+	scheme, err := NewContourScheme()
+	require.NoError(t, err)
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	suh := &StatusUpdateHandler{
+		Log:     log,
+		Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(proxy).Build(),
+		queue:   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		pending: map[statusKey]StatusUpdate{},
+	}
+
+	var calls []string
+	mutator := func(name string) StatusMutator {
+		return StatusMutatorFunc(func(obj client.Object) client.Object {
+			calls = append(calls, name)
+			return obj
+		})
+	}
+
+	suh.enqueue(NewStatusUpdate("example", "default", &contour_api_v1.HTTPProxy{}, mutator("first")))
+	suh.enqueue(NewStatusUpdate("example", "default", &contour_api_v1.HTTPProxy{}, mutator("second")))
+	assert.Equal(t, 1, suh.queue.Len())
+
+	// Only the latest update is written.
+	assert.True(t, suh.processNext())
+	assert.Equal(t, []string{"second"}, calls)
+	assert.Equal(t, 0, suh.queue.Len())
+
+	suh.queue.ShutDown()
+	assert.False(t, suh.processNext())
+}
+
+func TestStatusUpdateRetryable(t *testing.T) {
+	gr := schema.GroupResource{Group: "projectcontour.io", Resource: "httpproxies"}
+
+	assert.True(t, retryable(api_errors.NewConflict(gr, "example", errors.New("modified"))))
+	assert.True(t, retryable(api_errors.NewTooManyRequests("slow down", 1)))
+	assert.True(t, retryable(api_errors.NewServerTimeout(gr, "patch", 1)))
+	assert.False(t, retryable(api_errors.NewNotFound(gr, "example")))
+	assert.False(t, retryable(api_errors.NewForbidden(gr, "example", errors.New("denied"))))
+}
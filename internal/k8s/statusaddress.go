@@ -67,10 +67,8 @@ func (s *StatusAddressUpdater) OnAdd(obj interface{}) {
 	}
 
 	logNoMatch := func(logger logrus.FieldLogger, obj metav1.Object) {
-		logger.WithField("name", obj.GetName()).
-			WithField("namespace", obj.GetNamespace()).
+		logger.WithFields(LogFields(obj)).
 			WithField("ingress-class-annotation", annotation.IngressClass(obj)).
-			WithField("kind", KindOf(obj)).
 			WithField("target-ingress-class", s.IngressClassName).
 			Debug("unmatched ingress class, skipping status address update")
 	}
@@ -162,8 +160,7 @@ func (s *ServiceStatusLoadBalancerWatcher) OnAdd(obj interface{}) {
 	if svc.Name != s.ServiceName {
 		return
 	}
-	s.Log.WithField("name", svc.Name).
-		WithField("namespace", svc.Namespace).
+	s.Log.WithFields(LogFields(svc)).
 		Debug("received new service address")
 
 	s.notify(svc.Status.LoadBalancer)
@@ -178,8 +175,7 @@ func (s *ServiceStatusLoadBalancerWatcher) OnUpdate(oldObj, newObj interface{})
 	if svc.Name != s.ServiceName {
 		return
 	}
-	s.Log.WithField("name", svc.Name).
-		WithField("namespace", svc.Namespace).
+	s.Log.WithFields(LogFields(svc)).
 		Debug("received new service address")
 
 	s.notify(svc.Status.LoadBalancer)
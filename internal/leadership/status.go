@@ -0,0 +1,188 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package leadership tracks the leader election state of Contour.
+package leadership
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/projectcontour/contour/internal/metrics"
+)
+
+// Elector is the subset of a leaderelection.LeaderElector
+// used to check the health of the held lease.
+type Elector interface {
+	Check(maxTolerableExpiredLease time.Duration) error
+}
+
+// Status records the leader election state of this Contour.
+// It is safe for concurrent use. The zero value describes a
+// Contour running with leader election disabled, which
+// always considers itself the leader.
+type Status struct {
+	// Metrics, if set, records whether this Contour is the leader.
+	Metrics *metrics.Metrics
+
+	mu       sync.Mutex
+	enabled  bool
+	identity string
+	leader   string
+	isLeader bool
+	since    time.Time
+	elector  Elector
+
+	// now is used in place of time.Now in tests.
+	now func() time.Time
+}
+
+// StatusInfo is the leader election state returned
+// by the /debug/leadership endpoint.
+type StatusInfo struct {
+	Enabled            bool      `json:"enabled"`
+	Identity           string    `json:"identity,omitempty"`
+	Leader             string    `json:"leader,omitempty"`
+	IsLeader           bool      `json:"isLeader"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// Enable records that this Contour is taking part in leader
+// election under identity, using e to check the held lease.
+func (s *Status) Enable(identity string, e Elector) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.enabled = true
+	s.identity = identity
+	s.elector = e
+	s.setLeader(false)
+}
+
+// Disable records that leader election is disabled, in
+// which case this Contour is always the leader.
+func (s *Status) Disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.enabled = false
+	s.setLeader(true)
+}
+
+// NewLeader records that identity has been observed as the leader.
+func (s *Status) NewLeader(identity string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.leader = identity
+}
+
+// StartedLeading records that this Contour was elected leader.
+func (s *Status) StartedLeading() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.leader = s.identity
+	s.setLeader(true)
+}
+
+// StoppedLeading records that this Contour lost the leadership.
+func (s *Status) StoppedLeading() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leader == s.identity {
+		s.leader = ""
+	}
+	s.setLeader(false)
+}
+
+// setLeader must be called with s.mu held.
+func (s *Status) setLeader(leader bool) {
+	if s.isLeader != leader || s.since.IsZero() {
+		s.since = s.clock()
+	}
+	s.isLeader = leader
+
+	if s.Metrics != nil {
+		s.Metrics.SetIsLeader(leader)
+	}
+}
+
+// Info returns a snapshot of the leader election state.
+func (s *Status) Info() StatusInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.enabled {
+		return StatusInfo{
+			IsLeader:           true,
+			LastTransitionTime: s.since,
+		}
+	}
+
+	return StatusInfo{
+		Enabled:            true,
+		Identity:           s.identity,
+		Leader:             s.leader,
+		IsLeader:           s.isLeader,
+		LastTransitionTime: s.since,
+	}
+}
+
+// Check returns an error if leader election has been lost for
+// longer than timeout: either no leader has been observed since
+// this Contour last lost or gave up the lease, or this Contour
+// believes it is the leader but has failed to renew its lease.
+// A zero timeout disables the check.
+func (s *Status) Check(timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.enabled {
+		return nil
+	}
+	if s.leader == "" && !s.isLeader {
+		if lost := s.clock().Sub(s.since); lost > timeout {
+			return fmt.Errorf("no leader elected for %s", lost.Round(time.Second))
+		}
+	}
+	if s.elector != nil {
+		return s.elector.Check(timeout)
+	}
+	return nil
+}
+
+// ServeHTTP writes the leader election state as JSON.
+func (s *Status) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s.Info()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Status) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,102 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leadership
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type elector struct {
+	err error
+}
+
+func (e *elector) Check(time.Duration) error {
+	return e.err
+}
+
+func TestStatusCheck(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := &Status{now: func() time.Time { return now }}
+
+	// Leader election disabled is always ready.
+	s.Disable()
+	assert.NoError(t, s.Check(time.Second))
+
+	e := &elector{}
+	s.Enable("contour-a", e)
+
+	// No leader observed yet, within the timeout.
+	now = now.Add(5 * time.Second)
+	assert.NoError(t, s.Check(10*time.Second))
+
+	// No leader observed for longer than the timeout.
+	now = now.Add(10 * time.Second)
+	assert.EqualError(t, s.Check(10*time.Second), "no leader elected for 15s")
+
+	// A zero timeout disables the check.
+	assert.NoError(t, s.Check(0))
+
+	// Another Contour is the leader.
+	s.NewLeader("contour-b")
+	assert.NoError(t, s.Check(10*time.Second))
+
+	// This Contour is the leader but cannot renew its lease.
+	s.StartedLeading()
+	e.err = errors.New("failed election to renew leadership on lease leader-elect")
+	assert.EqualError(t, s.Check(10*time.Second), e.err.Error())
+}
+
+func TestStatusServeHTTP(t *testing.T) {
+	now := time.Unix(1000, 0).UTC()
+	s := &Status{now: func() time.Time { return now }}
+	s.Enable("contour-a", nil)
+	s.NewLeader("contour-b")
+
+	get := func() StatusInfo {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/leadership", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var info StatusInfo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+		return info
+	}
+
+	assert.Equal(t, StatusInfo{
+		Enabled:            true,
+		Identity:           "contour-a",
+		Leader:             "contour-b",
+		IsLeader:           false,
+		LastTransitionTime: now,
+	}, get())
+
+	now = now.Add(time.Minute)
+	s.StartedLeading()
+
+	assert.Equal(t, StatusInfo{
+		Enabled:            true,
+		Identity:           "contour-a",
+		Leader:             "contour-a",
+		IsLeader:           true,
+		LastTransitionTime: now,
+	}, get())
+}
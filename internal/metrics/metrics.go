@@ -27,28 +27,46 @@ import (
 type Metrics struct {
 	buildInfoGauge *prometheus.GaugeVec
 
-	proxyTotalGauge     *prometheus.GaugeVec
-	proxyRootTotalGauge *prometheus.GaugeVec
-	proxyInvalidGauge   *prometheus.GaugeVec
-	proxyValidGauge     *prometheus.GaugeVec
-	proxyOrphanedGauge  *prometheus.GaugeVec
+	proxyTotalGauge      *prometheus.GaugeVec
+	proxyRootTotalGauge  *prometheus.GaugeVec
+	proxyInvalidGauge    *prometheus.GaugeVec
+	proxyValidGauge      *prometheus.GaugeVec
+	proxyOrphanedGauge   *prometheus.GaugeVec
+	proxyConflictedGauge *prometheus.GaugeVec
 
 	dagRebuildGauge             *prometheus.GaugeVec
 	dagRebuildTotal             prometheus.Counter
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	EventHandlerOperations      *prometheus.CounterVec
 
+	statusUpdateQueueDepthGauge   prometheus.Gauge
+	statusUpdateDurationHistogram *prometheus.HistogramVec
+	statusUpdateRetriesTotal      *prometheus.CounterVec
+
+	isLeaderGauge prometheus.Gauge
+
+	secretCertificateExpiryGauge *prometheus.GaugeVec
+
+	cacheObjectsGauge  *prometheus.GaugeVec
+	dagVerticesGauge   *prometheus.GaugeVec
+	xdsCacheBytesGauge *prometheus.GaugeVec
+
+	xdsLaggingEnvoysGauge prometheus.Gauge
+	xdsStreamsGauge       *prometheus.GaugeVec
+
 	// Keep a local cache of metrics for comparison on updates
-	proxyMetricCache *RouteMetric
+	proxyMetricCache       *RouteMetric
+	secretCertificateCache map[SecretMeta]time.Time
 }
 
 // RouteMetric stores various metrics for HTTPProxy objects
 type RouteMetric struct {
-	Total    map[Meta]int
-	Valid    map[Meta]int
-	Invalid  map[Meta]int
-	Orphaned map[Meta]int
-	Root     map[Meta]int
+	Total      map[Meta]int
+	Valid      map[Meta]int
+	Invalid    map[Meta]int
+	Orphaned   map[Meta]int
+	Root       map[Meta]int
+	Conflicted map[Meta]int
 }
 
 // Meta holds the vhost and namespace of a metric object
@@ -56,19 +74,40 @@ type Meta struct {
 	VHost, Namespace string
 }
 
+// SecretMeta holds the name and namespace of a Secret metric object
+type SecretMeta struct {
+	Name, Namespace string
+}
+
 const (
 	BuildInfoGauge = "contour_build_info"
 
-	HTTPProxyTotalGauge     = "contour_httpproxy"
-	HTTPProxyRootTotalGauge = "contour_httpproxy_root"
-	HTTPProxyInvalidGauge   = "contour_httpproxy_invalid"
-	HTTPProxyValidGauge     = "contour_httpproxy_valid"
-	HTTPProxyOrphanedGauge  = "contour_httpproxy_orphaned"
+	HTTPProxyTotalGauge      = "contour_httpproxy"
+	HTTPProxyRootTotalGauge  = "contour_httpproxy_root"
+	HTTPProxyInvalidGauge    = "contour_httpproxy_invalid"
+	HTTPProxyValidGauge      = "contour_httpproxy_valid"
+	HTTPProxyOrphanedGauge   = "contour_httpproxy_orphaned"
+	HTTPProxyConflictedGauge = "contour_httpproxy_conflicted"
 
 	DAGRebuildGauge             = "contour_dagrebuild_timestamp"
 	DAGRebuildTotal             = "contour_dagrebuild_total"
 	cacheHandlerOnUpdateSummary = "contour_cachehandler_onupdate_duration_seconds"
 	eventHandlerOperations      = "contour_eventhandler_operation_total"
+
+	StatusUpdateQueueDepthGauge = "contour_status_update_queue_depth"
+	StatusUpdateDuration        = "contour_status_update_duration_seconds"
+	StatusUpdateRetriesTotal    = "contour_status_update_retries_total"
+
+	IsLeaderGauge = "contour_is_leader"
+
+	SecretCertificateExpiryGauge = "contour_secret_certificate_expiry_timestamp"
+
+	CacheObjectsGauge  = "contour_cache_objects"
+	DAGVerticesGauge   = "contour_dag_vertices"
+	XDSCacheBytesGauge = "contour_xds_cache_bytes"
+
+	XDSLaggingEnvoysGauge = "contour_xds_lagging_envoys"
+	XDSStreamsGauge       = "contour_xds_streams"
 )
 
 // NewMetrics creates a new set of metrics and registers them with
@@ -122,6 +161,13 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"namespace"},
 		),
+		proxyConflictedGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: HTTPProxyConflictedGauge,
+				Help: "Total number of HTTPProxies with routes dropped because they conflict with routes of other HTTPProxies.",
+			},
+			[]string{"namespace", "vhost"},
+		),
 		dagRebuildGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: DAGRebuildGauge,
@@ -147,6 +193,73 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"op", "kind"},
 		),
+		statusUpdateQueueDepthGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: StatusUpdateQueueDepthGauge,
+				Help: "Number of objects with status updates waiting to be written.",
+			},
+		),
+		statusUpdateDurationHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: StatusUpdateDuration,
+				Help: "Time taken to write status updates, by object kind and result.",
+			},
+			[]string{"kind", "result"},
+		),
+		statusUpdateRetriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: StatusUpdateRetriesTotal,
+				Help: "Total number of status updates that were retried after failing, by object kind.",
+			},
+			[]string{"kind"},
+		),
+		isLeaderGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: IsLeaderGauge,
+				Help: "Whether this Contour is the elected leader (1) or not (0).",
+			},
+		),
+		secretCertificateExpiryGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: SecretCertificateExpiryGauge,
+				Help: "Timestamp at which the serving certificate of a Secret in use by Envoy expires.",
+			},
+			[]string{"namespace", "name"},
+		),
+		cacheObjectsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: CacheObjectsGauge,
+				Help: "Number of Kubernetes objects in Contour's cache, by kind.",
+			},
+			[]string{"kind"},
+		),
+		dagVerticesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: DAGVerticesGauge,
+				Help: "Number of vertices of the last DAG built, by kind.",
+			},
+			[]string{"kind"},
+		),
+		xdsCacheBytesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: XDSCacheBytesGauge,
+				Help: "Size in bytes of the resources of the last xDS snapshot, by resource type.",
+			},
+			[]string{"type"},
+		),
+		xdsLaggingEnvoysGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: XDSLaggingEnvoysGauge,
+				Help: "Number of Envoys that have not applied the latest xDS snapshot within the drift threshold.",
+			},
+		),
+		xdsStreamsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: XDSStreamsGauge,
+				Help: "Number of open xDS streams of each connected Envoy, by node ID.",
+			},
+			[]string{"node_id"},
+		),
 	}
 	m.buildInfoGauge.WithLabelValues(build.Branch, build.Sha, build.Version).Set(1)
 	m.register(registry)
@@ -162,10 +275,21 @@ func (m *Metrics) register(registry *prometheus.Registry) {
 		m.proxyInvalidGauge,
 		m.proxyValidGauge,
 		m.proxyOrphanedGauge,
+		m.proxyConflictedGauge,
 		m.dagRebuildGauge,
 		m.dagRebuildTotal,
 		m.CacheHandlerOnUpdateSummary,
 		m.EventHandlerOperations,
+		m.statusUpdateQueueDepthGauge,
+		m.statusUpdateDurationHistogram,
+		m.statusUpdateRetriesTotal,
+		m.isLeaderGauge,
+		m.secretCertificateExpiryGauge,
+		m.cacheObjectsGauge,
+		m.dagVerticesGauge,
+		m.xdsCacheBytesGauge,
+		m.xdsLaggingEnvoysGauge,
+		m.xdsStreamsGauge,
 	)
 }
 
@@ -179,16 +303,27 @@ func (m *Metrics) Zero() {
 	}
 
 	zeroes := RouteMetric{
-		Total:    map[Meta]int{meta: 0},
-		Valid:    map[Meta]int{meta: 0},
-		Invalid:  map[Meta]int{meta: 0},
-		Orphaned: map[Meta]int{meta: 0},
-		Root:     map[Meta]int{meta: 0},
+		Total:      map[Meta]int{meta: 0},
+		Valid:      map[Meta]int{meta: 0},
+		Invalid:    map[Meta]int{meta: 0},
+		Orphaned:   map[Meta]int{meta: 0},
+		Root:       map[Meta]int{meta: 0},
+		Conflicted: map[Meta]int{meta: 0},
 	}
 
 	m.SetDAGLastRebuilt(time.Now())
 	m.SetHTTPProxyMetric(zeroes)
 	m.EventHandlerOperations.WithLabelValues("add", "Secret").Inc()
+	m.SetStatusUpdateQueueDepth(0)
+	m.ObserveStatusUpdate("HTTPProxy", "updated", 0)
+	m.SetStatusUpdateRetriesTotal("HTTPProxy")
+	m.SetIsLeader(false)
+	m.SetSecretCertificateExpiry(map[SecretMeta]time.Time{{}: time.Now()})
+	m.SetCacheObjects(map[string]int{"HTTPProxy": 0})
+	m.SetDAGVertices(map[string]int{"Route": 0})
+	m.SetXDSCacheBytes(map[string]int{"cluster": 0})
+	m.SetXDSLaggingEnvoys(0)
+	m.SetXDSStreams("envoy", 1)
 
 	prometheus.NewTimer(m.CacheHandlerOnUpdateSummary).ObserveDuration()
 }
@@ -203,6 +338,89 @@ func (m *Metrics) SetDAGRebuiltTotal() {
 	m.dagRebuildTotal.Inc()
 }
 
+// SetStatusUpdateQueueDepth records the number of
+// objects with status updates waiting to be written.
+func (m *Metrics) SetStatusUpdateQueueDepth(depth int) {
+	m.statusUpdateQueueDepthGauge.Set(float64(depth))
+}
+
+// ObserveStatusUpdate records the time taken to write
+// a status update of an object of the given kind.
+func (m *Metrics) ObserveStatusUpdate(kind, result string, d time.Duration) {
+	m.statusUpdateDurationHistogram.WithLabelValues(kind, result).Observe(d.Seconds())
+}
+
+// SetStatusUpdateRetriesTotal records that a status update
+// of an object of the given kind is being retried.
+func (m *Metrics) SetStatusUpdateRetriesTotal(kind string) {
+	m.statusUpdateRetriesTotal.WithLabelValues(kind).Inc()
+}
+
+// SetIsLeader records whether this Contour is the elected leader.
+func (m *Metrics) SetIsLeader(leader bool) {
+	if leader {
+		m.isLeaderGauge.Set(1)
+	} else {
+		m.isLeaderGauge.Set(0)
+	}
+}
+
+// SetSecretCertificateExpiry records the expiry time of the
+// serving certificate of each Secret in use, and removes those
+// of the Secrets that are no longer in use.
+func (m *Metrics) SetSecretCertificateExpiry(expiries map[SecretMeta]time.Time) {
+	for meta, notAfter := range expiries {
+		m.secretCertificateExpiryGauge.WithLabelValues(meta.Namespace, meta.Name).Set(float64(notAfter.Unix()))
+		delete(m.secretCertificateCache, meta)
+	}
+
+	for meta := range m.secretCertificateCache {
+		m.secretCertificateExpiryGauge.DeleteLabelValues(meta.Namespace, meta.Name)
+	}
+
+	m.secretCertificateCache = expiries
+}
+
+// SetCacheObjects records the number of Kubernetes
+// objects in Contour's cache, by kind.
+func (m *Metrics) SetCacheObjects(counts map[string]int) {
+	for kind, count := range counts {
+		m.cacheObjectsGauge.WithLabelValues(kind).Set(float64(count))
+	}
+}
+
+// SetDAGVertices records the number of vertices
+// of the last DAG built, by kind.
+func (m *Metrics) SetDAGVertices(counts map[string]int) {
+	for kind, count := range counts {
+		m.dagVerticesGauge.WithLabelValues(kind).Set(float64(count))
+	}
+}
+
+// SetXDSCacheBytes records the size in bytes of the resources
+// of the last xDS snapshot, by resource type.
+func (m *Metrics) SetXDSCacheBytes(sizes map[string]int) {
+	for typ, size := range sizes {
+		m.xdsCacheBytesGauge.WithLabelValues(typ).Set(float64(size))
+	}
+}
+
+// SetXDSLaggingEnvoys records the number of Envoys that have
+// not applied the latest xDS snapshot within the drift threshold.
+func (m *Metrics) SetXDSLaggingEnvoys(n int) {
+	m.xdsLaggingEnvoysGauge.Set(float64(n))
+}
+
+// SetXDSStreams records the number of open xDS streams of
+// an Envoy node. Nodes with no open streams are removed.
+func (m *Metrics) SetXDSStreams(nodeID string, streams int) {
+	if streams == 0 {
+		m.xdsStreamsGauge.DeleteLabelValues(nodeID)
+		return
+	}
+	m.xdsStreamsGauge.WithLabelValues(nodeID).Set(float64(streams))
+}
+
 // SetHTTPProxyMetric sets metric values for a set of HTTPProxies
 func (m *Metrics) SetHTTPProxyMetric(metrics RouteMetric) {
 	// Process metrics
@@ -226,6 +444,10 @@ func (m *Metrics) SetHTTPProxyMetric(metrics RouteMetric) {
 		m.proxyRootTotalGauge.WithLabelValues(meta.Namespace).Set(float64(value))
 		delete(m.proxyMetricCache.Root, meta)
 	}
+	for meta, value := range metrics.Conflicted {
+		m.proxyConflictedGauge.WithLabelValues(meta.Namespace, meta.VHost).Set(float64(value))
+		delete(m.proxyMetricCache.Conflicted, meta)
+	}
 
 	// All metrics processed, now remove what's left as they are not needed
 	for meta := range m.proxyMetricCache.Total {
@@ -243,13 +465,17 @@ func (m *Metrics) SetHTTPProxyMetric(metrics RouteMetric) {
 	for meta := range m.proxyMetricCache.Root {
 		m.proxyRootTotalGauge.DeleteLabelValues(meta.Namespace)
 	}
+	for meta := range m.proxyMetricCache.Conflicted {
+		m.proxyConflictedGauge.DeleteLabelValues(meta.Namespace, meta.VHost)
+	}
 
 	m.proxyMetricCache = &RouteMetric{
-		Total:    metrics.Total,
-		Invalid:  metrics.Invalid,
-		Valid:    metrics.Valid,
-		Orphaned: metrics.Orphaned,
-		Root:     metrics.Root,
+		Total:      metrics.Total,
+		Invalid:    metrics.Invalid,
+		Valid:      metrics.Valid,
+		Orphaned:   metrics.Orphaned,
+		Root:       metrics.Root,
+		Conflicted: metrics.Conflicted,
 	}
 }
 
@@ -689,3 +689,57 @@ func TestRemoveProxyMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSecretCertificateExpiry(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	gather := func() []*io_prometheus_client.Metric {
+		t.Helper()
+
+		gathering, err := r.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []*io_prometheus_client.Metric{}
+		for _, mf := range gathering {
+			if mf.GetName() == SecretCertificateExpiryGauge {
+				got = mf.Metric
+			}
+		}
+		return got
+	}
+
+	metric := func(namespace, name string, value float64) *io_prometheus_client.Metric {
+		return &io_prometheus_client.Metric{
+			Label: []*io_prometheus_client.LabelPair{{
+				Name:  func() *string { i := "name"; return &i }(),
+				Value: func() *string { i := name; return &i }(),
+			}, {
+				Name:  func() *string { i := "namespace"; return &i }(),
+				Value: func() *string { i := namespace; return &i }(),
+			}},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := value; return &i }(),
+			},
+		}
+	}
+
+	m.SetSecretCertificateExpiry(map[SecretMeta]time.Time{
+		{Name: "secret-a", Namespace: "default"}: time.Date(2029, 12, 2, 1, 34, 33, 0, time.UTC),
+		{Name: "secret-b", Namespace: "default"}: time.Date(2026, 11, 7, 10, 55, 51, 0, time.UTC),
+	})
+	assert.Equal(t, []*io_prometheus_client.Metric{
+		metric("default", "secret-a", 1.890869673e+09),
+		metric("default", "secret-b", 1.794048951e+09),
+	}, gather())
+
+	// Secrets that are no longer in use are removed.
+	m.SetSecretCertificateExpiry(map[SecretMeta]time.Time{
+		{Name: "secret-b", Namespace: "default"}: time.Date(2027, 2, 5, 10, 55, 51, 0, time.UTC),
+	})
+	assert.Equal(t, []*io_prometheus_client.Metric{
+		metric("default", "secret-b", 1.801824951e+09),
+	}, gather())
+}
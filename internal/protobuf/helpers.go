@@ -17,6 +17,7 @@ package protobuf
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/golang/protobuf/jsonpb"
@@ -62,6 +63,13 @@ func UInt32OrNil(val uint32) *wrappers.UInt32Value {
 	}
 }
 
+// UInt64 converts a uint64 to a pointer to a wrappers.UInt64Value.
+func UInt64(val uint64) *wrappers.UInt64Value {
+	return &wrappers.UInt64Value{
+		Value: val,
+	}
+}
+
 // Bool converts a bool to a pointer to a wrappers.BoolValue.
 func Bool(val bool) *wrappers.BoolValue {
 	return &wrappers.BoolValue{
@@ -69,24 +77,55 @@ func Bool(val bool) *wrappers.BoolValue {
 	}
 }
 
+// messagesPool holds the backing arrays of slices that were
+// returned by AsMessages and handed back with PutMessages.
+var messagesPool sync.Pool
+
 // AsMessages casts the given slice of values (that implement the proto.Message
 // interface) to a slice of proto.Message. If the length of the slice is 0, it
-// returns nil.
+// returns nil. The returned slice may reuse the backing array of a slice
+// previously passed to PutMessages.
 func AsMessages(messages interface{}) []proto.Message {
 	v := reflect.ValueOf(messages)
-	if v.Len() == 0 {
+	n := v.Len()
+	if n == 0 {
 		return nil
 	}
 
-	protos := make([]proto.Message, v.Len())
-
-	for i := range protos {
-		protos[i] = v.Index(i).Interface().(proto.Message)
+	protos := getMessages(n)
+	for i := 0; i < n; i++ {
+		protos = append(protos, v.Index(i).Interface().(proto.Message))
 	}
 
 	return protos
 }
 
+// PutMessages hands the backing array of messages back for reuse by
+// later calls to AsMessages. The caller must own messages, and must not
+// use it after calling PutMessages.
+func PutMessages(messages []proto.Message) {
+	if cap(messages) == 0 {
+		return
+	}
+
+	// Drop the references so that pooled slices do
+	// not keep stale messages alive.
+	for i := range messages {
+		messages[i] = nil
+	}
+	messages = messages[:0]
+	messagesPool.Put(&messages)
+}
+
+// getMessages returns an empty slice with room for at least n
+// messages, reusing a pooled backing array if one is large enough.
+func getMessages(n int) []proto.Message {
+	if p, ok := messagesPool.Get().(*[]proto.Message); ok && cap(*p) >= n {
+		return (*p)[:0]
+	}
+	return make([]proto.Message, 0, n)
+}
+
 // MustMarshalAny marshals a protobuf into an any.Any type, panicking
 // if that operation fails.
 func MustMarshalAny(pb proto.Message) *any.Any {
@@ -16,6 +16,7 @@ package protobuf
 import (
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/stretchr/testify/assert"
 )
@@ -29,3 +30,28 @@ func TestU32Default(t *testing.T) {
 	assert.Equal(t, UInt32(99), UInt32OrDefault(0, 99))
 	assert.Equal(t, UInt32(1), UInt32OrDefault(1, 99))
 }
+
+func TestAsMessages(t *testing.T) {
+	assert.Nil(t, AsMessages([]*wrappers.UInt32Value{}))
+
+	values := []*wrappers.UInt32Value{UInt32(1), UInt32(2)}
+	assert.Equal(t, []proto.Message{UInt32(1), UInt32(2)}, AsMessages(values))
+}
+
+func TestPutMessages(t *testing.T) {
+	messages := AsMessages([]*wrappers.UInt32Value{UInt32(1), UInt32(2)})
+	backing := messages[:cap(messages)]
+
+	PutMessages(messages)
+
+	// Handing a slice back drops its references.
+	for _, m := range backing {
+		assert.Nil(t, m)
+	}
+
+	// Reused slices hold only the new messages.
+	assert.Equal(t, []proto.Message{UInt32(3)}, AsMessages([]*wrappers.UInt32Value{UInt32(3)}))
+
+	// Empty slices are ignored.
+	PutMessages(nil)
+}
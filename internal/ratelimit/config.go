@@ -0,0 +1,166 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit generates the configuration of the Envoy rate
+// limit service (https://github.com/envoyproxy/ratelimit) from the
+// global rate limit policies in the DAG.
+package ratelimit
+
+import (
+	"sort"
+
+	"github.com/projectcontour/contour/internal/dag"
+)
+
+// Config is the configuration of the rate limit service for a domain.
+type Config struct {
+	Domain      string        `yaml:"domain"`
+	Descriptors []*Descriptor `yaml:"descriptors,omitempty"`
+}
+
+// Descriptor is a node in the rate limit service's descriptor tree.
+// An empty Value matches any value of the key.
+type Descriptor struct {
+	Key         string        `yaml:"key"`
+	Value       string        `yaml:"value,omitempty"`
+	RateLimit   *RateLimit    `yaml:"rate_limit,omitempty"`
+	Descriptors []*Descriptor `yaml:"descriptors,omitempty"`
+}
+
+// RateLimit is the limit applied to requests matching a descriptor.
+type RateLimit struct {
+	Unit            string `yaml:"unit"`
+	RequestsPerUnit uint32 `yaml:"requests_per_unit"`
+}
+
+// NewConfig returns the rate limit service configuration for the domain,
+// built from the descriptors of the global rate limit policies of the
+// DAG's virtual hosts and routes that define a limit.
+//
+// Policies are visited in order of virtual host name and route conditions.
+// When several descriptors with the same entries define a limit, the first
+// one visited wins.
+func NewConfig(domain string, d *dag.DAG) *Config {
+	root := &Descriptor{}
+
+	for _, vhost := range virtualHosts(d) {
+		addPolicy(root, vhost.RateLimitPolicy)
+
+		keys := make([]string, 0, len(vhost.Routes))
+		for key := range vhost.Routes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			addPolicy(root, vhost.Routes[key].RateLimitPolicy)
+		}
+	}
+
+	sortDescriptors(root.Descriptors)
+
+	return &Config{
+		Domain:      domain,
+		Descriptors: root.Descriptors,
+	}
+}
+
+// virtualHosts returns the insecure and secure virtual hosts of the DAG,
+// sorted by name with the insecure virtual host first.
+func virtualHosts(d *dag.DAG) []*dag.VirtualHost {
+	var vhosts []*dag.VirtualHost
+	for _, vh := range d.VirtualHosts {
+		vhosts = append(vhosts, vh)
+	}
+	for _, svh := range d.SecureVirtualHosts {
+		vhosts = append(vhosts, &svh.VirtualHost)
+	}
+
+	sort.SliceStable(vhosts, func(i, j int) bool {
+		return vhosts[i].Name < vhosts[j].Name
+	})
+
+	return vhosts
+}
+
+func addPolicy(root *Descriptor, rlp *dag.RateLimitPolicy) {
+	if rlp == nil || rlp.Global == nil {
+		return
+	}
+
+	for _, d := range rlp.Global.Descriptors {
+		if d.Limit == nil || len(d.Entries) == 0 {
+			continue
+		}
+
+		node := root
+		for _, entry := range d.Entries {
+			key, value := descriptorEntry(entry)
+			node = child(node, key, value)
+		}
+
+		if node.RateLimit == nil {
+			node.RateLimit = &RateLimit{
+				Unit:            d.Limit.Unit,
+				RequestsPerUnit: d.Limit.Requests,
+			}
+		}
+	}
+}
+
+// descriptorEntry returns the key and value that Envoy sends to the
+// rate limit service for the entry. The value is empty if it comes
+// from the request.
+func descriptorEntry(entry dag.RateLimitDescriptorEntry) (string, string) {
+	switch {
+	case entry.GenericKey != nil:
+		key := entry.GenericKey.Key
+		if key == "" {
+			key = "generic_key"
+		}
+		return key, entry.GenericKey.Value
+	case entry.HeaderMatch != nil:
+		return entry.HeaderMatch.Key, ""
+	case entry.HeaderValueMatch != nil:
+		return "header_match", entry.HeaderValueMatch.Value
+	case entry.Metadata != nil:
+		return entry.Metadata.Key, ""
+	default:
+		return "remote_address", ""
+	}
+}
+
+func child(parent *Descriptor, key, value string) *Descriptor {
+	for _, c := range parent.Descriptors {
+		if c.Key == key && c.Value == value {
+			return c
+		}
+	}
+
+	c := &Descriptor{Key: key, Value: value}
+	parent.Descriptors = append(parent.Descriptors, c)
+	return c
+}
+
+func sortDescriptors(descriptors []*Descriptor) {
+	sort.Slice(descriptors, func(i, j int) bool {
+		if descriptors[i].Key != descriptors[j].Key {
+			return descriptors[i].Key < descriptors[j].Key
+		}
+		return descriptors[i].Value < descriptors[j].Value
+	})
+
+	for _, d := range descriptors {
+		sortDescriptors(d.Descriptors)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNewConfig(t *testing.T) {
+	genericKey := func(key, value string) dag.RateLimitDescriptorEntry {
+		return dag.RateLimitDescriptorEntry{
+			GenericKey: &dag.GenericKeyDescriptorEntry{Key: key, Value: value},
+		}
+	}
+	remoteAddress := dag.RateLimitDescriptorEntry{
+		RemoteAddress: &dag.RemoteAddressDescriptorEntry{},
+	}
+	limit := func(requests uint32, unit string) *dag.RateLimitDescriptorLimit {
+		return &dag.RateLimitDescriptorLimit{Requests: requests, Unit: unit}
+	}
+	global := func(descriptors ...*dag.RateLimitDescriptor) *dag.RateLimitPolicy {
+		return &dag.RateLimitPolicy{
+			Global: &dag.GlobalRateLimitPolicy{Descriptors: descriptors},
+		}
+	}
+
+	d := &dag.DAG{
+		VirtualHosts: map[string]*dag.VirtualHost{
+			"a.example.com": {
+				Name: "a.example.com",
+				RateLimitPolicy: global(&dag.RateLimitDescriptor{
+					Entries: []dag.RateLimitDescriptorEntry{genericKey("", "a"), remoteAddress},
+					Limit:   limit(100, "minute"),
+				}),
+				Routes: map[string]*dag.Route{
+					"prefix: /": {
+						RateLimitPolicy: global(
+							// Same entries as the virtual host's descriptor,
+							// which is visited first and wins.
+							&dag.RateLimitDescriptor{
+								Entries: []dag.RateLimitDescriptorEntry{genericKey("", "a"), remoteAddress},
+								Limit:   limit(1, "second"),
+							},
+							// No limit, so not part of the configuration.
+							&dag.RateLimitDescriptor{
+								Entries: []dag.RateLimitDescriptorEntry{genericKey("", "b")},
+							},
+						),
+					},
+				},
+			},
+		},
+		SecureVirtualHosts: map[string]*dag.SecureVirtualHost{
+			"b.example.com": {
+				VirtualHost: dag.VirtualHost{
+					Name: "b.example.com",
+					Routes: map[string]*dag.Route{
+						"prefix: /api": {
+							RateLimitPolicy: global(&dag.RateLimitDescriptor{
+								Entries: []dag.RateLimitDescriptorEntry{
+									genericKey("", "a"),
+									{HeaderMatch: &dag.HeaderMatchDescriptorEntry{HeaderName: "X-Tenant", Key: "tenant"}},
+								},
+								Limit: limit(10, "hour"),
+							}),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	want := &Config{
+		Domain: "contour",
+		Descriptors: []*Descriptor{{
+			Key:   "generic_key",
+			Value: "a",
+			Descriptors: []*Descriptor{
+				{
+					Key:       "remote_address",
+					RateLimit: &RateLimit{Unit: "minute", RequestsPerUnit: 100},
+				},
+				{
+					Key:       "tenant",
+					RateLimit: &RateLimit{Unit: "hour", RequestsPerUnit: 10},
+				},
+			},
+		}},
+	}
+
+	assert.Equal(t, want, NewConfig("contour", d))
+
+	data, err := yaml.Marshal(NewConfig("contour", d))
+	assert.NoError(t, err)
+	assert.Equal(t, `domain: contour
+descriptors:
+- key: generic_key
+  value: a
+  descriptors:
+  - key: remote_address
+    rate_limit:
+      unit: minute
+      requests_per_unit: 100
+  - key: tenant
+    rate_limit:
+      unit: hour
+      requests_per_unit: 10
+`, string(data))
+
+	assert.Equal(t, &Config{Domain: "contour"}, NewConfig("contour", &dag.DAG{}))
+}
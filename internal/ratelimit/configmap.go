@@ -0,0 +1,134 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMapKey is the key of the ConfigMap data holding the rate limit
+// service configuration.
+const ConfigMapKey = "contour.yaml"
+
+// ConfigMapWriter writes the rate limit service configuration generated
+// from each new DAG to a ConfigMap, from which the rate limit service
+// loads it.
+//
+// The configuration is only written once this Contour is elected
+// leader, and only when it has changed since the last write.
+type ConfigMapWriter struct {
+	log       logrus.FieldLogger
+	domain    string
+	configMap types.NamespacedName
+	client    kubernetes.Interface
+	isLeader  chan struct{}
+	pending   chan string
+}
+
+// NewConfigMapWriter returns a ConfigMapWriter that writes the
+// configuration of the domain to the ConfigMap once isLeader
+// becomes readable.
+func NewConfigMapWriter(log logrus.FieldLogger, domain string, configMap types.NamespacedName, client kubernetes.Interface, isLeader chan struct{}) *ConfigMapWriter {
+	return &ConfigMapWriter{
+		log:       log,
+		domain:    domain,
+		configMap: configMap,
+		client:    client,
+		isLeader:  isLeader,
+		pending:   make(chan string, 1),
+	}
+}
+
+// OnChange implements dag.Observer. It queues the configuration
+// generated from the DAG for writing, replacing any configuration
+// that has not been written yet.
+func (w *ConfigMapWriter) OnChange(d *dag.DAG) {
+	data, err := yaml.Marshal(NewConfig(w.domain, d))
+	if err != nil {
+		w.log.WithError(err).Error("failed to marshal rate limit service configuration")
+		return
+	}
+
+	select {
+	case <-w.pending:
+	default:
+	}
+	w.pending <- string(data)
+}
+
+// Start writes the queued configurations to the ConfigMap until stop
+// is closed.
+func (w *ConfigMapWriter) Start(stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return nil
+	case <-w.isLeader:
+	}
+
+	var written string
+	for {
+		select {
+		case <-stop:
+			return nil
+		case data := <-w.pending:
+			if data == written {
+				continue
+			}
+
+			if err := w.write(data); err != nil {
+				w.log.WithError(err).WithField("configmap", w.configMap).Error("failed to write rate limit service configuration")
+				continue
+			}
+			written = data
+		}
+	}
+}
+
+func (w *ConfigMapWriter) write(data string) error {
+	client := w.client.CoreV1().ConfigMaps(w.configMap.Namespace)
+
+	cm, err := client.Get(context.Background(), w.configMap.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = client.Create(context.Background(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      w.configMap.Name,
+				Namespace: w.configMap.Namespace,
+			},
+			Data: map[string]string{
+				ConfigMapKey: data,
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[ConfigMapKey] = data
+
+	_, err = client.Update(context.Background(), cm, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,161 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package readiness tracks how far Contour has got towards
+// serving its first complete configuration.
+package readiness
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Stage is a step on the way to Contour being ready.
+type Stage string
+
+const (
+	// StageCacheSync waits for the informer caches to sync.
+	StageCacheSync Stage = "WaitingForCacheSync"
+
+	// StageDAGBuild waits for the first DAG to be built
+	// from the synced caches.
+	StageDAGBuild Stage = "WaitingForDAGBuild"
+
+	// StageSnapshot waits for the xDS caches to be
+	// populated from that DAG.
+	StageSnapshot Stage = "WaitingForSnapshot"
+
+	// StageReady is reached once the xDS caches hold
+	// a complete configuration.
+	StageReady Stage = "Ready"
+)
+
+// Status records the readiness stage of this Contour.
+// It is safe for concurrent use.
+type Status struct {
+	mu      sync.Mutex
+	stage   Stage
+	started time.Time
+	since   time.Time
+	ready   chan struct{}
+
+	// now is used in place of time.Now in tests.
+	now func() time.Time
+}
+
+// StatusInfo is the readiness state returned by
+// the /debug/readiness endpoint.
+type StatusInfo struct {
+	Stage              Stage     `json:"stage"`
+	Ready              bool      `json:"ready"`
+	StartTime          time.Time `json:"startTime"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// NewStatus returns a Status waiting for the informer caches to sync.
+func NewStatus() *Status {
+	return newStatus(time.Now)
+}
+
+func newStatus(now func() time.Time) *Status {
+	s := &Status{
+		stage: StageCacheSync,
+		ready: make(chan struct{}),
+		now:   now,
+	}
+	s.started = s.now()
+	s.since = s.started
+	return s
+}
+
+// CachesSynced records that the informer caches have synced.
+func (s *Status) CachesSynced() {
+	s.advance(StageCacheSync, StageDAGBuild)
+}
+
+// DAGBuilt records that a DAG has been built. It only advances
+// the stage once the informer caches have synced, since a DAG
+// built before then may be missing objects.
+func (s *Status) DAGBuilt() {
+	s.advance(StageDAGBuild, StageSnapshot)
+}
+
+// SnapshotGenerated records that the xDS caches have been
+// populated from the DAG recorded by DAGBuilt.
+func (s *Status) SnapshotGenerated() {
+	s.advance(StageSnapshot, StageReady)
+}
+
+func (s *Status) advance(from, to Stage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stage != from {
+		return
+	}
+
+	s.stage = to
+	s.since = s.now()
+	if to == StageReady {
+		close(s.ready)
+	}
+}
+
+// Ready returns a channel that is closed once the
+// xDS caches hold a complete configuration.
+func (s *Status) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Info returns the current readiness state.
+func (s *Status) Info() StatusInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return StatusInfo{
+		Stage:              s.stage,
+		Ready:              s.stage == StageReady,
+		StartTime:          s.started,
+		LastTransitionTime: s.since,
+	}
+}
+
+// Check returns an error if Contour is not yet ready. Once maxWait
+// has passed since Contour started, Check no longer fails, so that
+// a slow first build does not keep Contour unready forever. Zero
+// waits indefinitely.
+func (s *Status) Check(maxWait time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stage == StageReady {
+		return nil
+	}
+	if maxWait > 0 && s.now().Sub(s.started) > maxWait {
+		return nil
+	}
+	return fmt.Errorf("not ready: %s", s.stage)
+}
+
+// ServeHTTP serves the readiness state as JSON.
+func (s *Status) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s.Info()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readiness
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusStages(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newStatus(func() time.Time { return now })
+
+	assert.EqualError(t, s.Check(0), "not ready: WaitingForCacheSync")
+
+	// A DAG built before the caches have synced does not count.
+	s.DAGBuilt()
+	s.SnapshotGenerated()
+	assert.Equal(t, StageCacheSync, s.Info().Stage)
+
+	s.CachesSynced()
+	assert.EqualError(t, s.Check(0), "not ready: WaitingForDAGBuild")
+
+	s.DAGBuilt()
+	assert.EqualError(t, s.Check(0), "not ready: WaitingForSnapshot")
+
+	select {
+	case <-s.Ready():
+		t.Fatal("ready before the snapshot was generated")
+	default:
+	}
+
+	now = now.Add(3 * time.Second)
+	s.SnapshotGenerated()
+	assert.NoError(t, s.Check(0))
+	<-s.Ready()
+
+	assert.Equal(t, StatusInfo{
+		Stage:              StageReady,
+		Ready:              true,
+		StartTime:          time.Unix(1000, 0),
+		LastTransitionTime: time.Unix(1003, 0),
+	}, s.Info())
+
+	// Later rebuilds do not move the stage back.
+	s.CachesSynced()
+	s.DAGBuilt()
+	assert.Equal(t, StageReady, s.Info().Stage)
+}
+
+func TestStatusCheckMaxWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newStatus(func() time.Time { return now })
+	s.CachesSynced()
+
+	now = now.Add(5 * time.Second)
+	assert.Error(t, s.Check(10*time.Second))
+
+	// Once the maximum wait has passed, Contour reports
+	// ready even though the snapshot is incomplete.
+	now = now.Add(10 * time.Second)
+	assert.NoError(t, s.Check(10*time.Second))
+
+	// A zero maximum waits indefinitely.
+	assert.Error(t, s.Check(0))
+}
+
+func TestStatusServeHTTP(t *testing.T) {
+	s := newStatus(func() time.Time { return time.Unix(1000, 0).UTC() })
+	s.CachesSynced()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/readiness", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var info StatusInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, StageDAGBuild, info.Stage)
+	assert.False(t, info.Ready)
+}
@@ -18,6 +18,7 @@ import (
 	"strings"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
@@ -127,15 +128,19 @@ func longestRouteByHeaderConditions(lhs, rhs *dag.Route) bool {
 }
 
 // Sorts the given Route slice in place. Routes are ordered first by
-// type (exact sorts before regex, sorts before prefix) and then
-// longest path match value, then by the length of the HeaderMatch
-// slice (if any). The HeaderMatch slice is also ordered by the matching
-// header name.
+// priority (highest first), then by type (exact sorts before regex,
+// sorts before prefix) and then longest path match value, then by the
+// length of the HeaderMatch slice (if any). The HeaderMatch slice is
+// also ordered by the matching header name.
 type routeSorter []*dag.Route
 
 func (s routeSorter) Len() int      { return len(s) }
 func (s routeSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 func (s routeSorter) Less(i, j int) bool {
+	if s[i].Priority != s[j].Priority {
+		return s[i].Priority > s[j].Priority
+	}
+
 	switch a := s[i].PathMatchCondition.(type) {
 	case *dag.PrefixMatchCondition:
 		if b, ok := s[j].PathMatchCondition.(*dag.PrefixMatchCondition); ok {
@@ -214,7 +219,7 @@ func (s httpWeightedClusterSorter) Len() int      { return len(s) }
 func (s httpWeightedClusterSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 func (s httpWeightedClusterSorter) Less(i, j int) bool {
 	if s[i].Name == s[j].Name {
-		return s[i].Weight.Value < s[j].Weight.Value
+		return s[i].GetWeight().GetValue() < s[j].GetWeight().GetValue()
 	}
 
 	return s[i].Name < s[j].Name
@@ -240,27 +245,51 @@ func (s listenerSorter) Len() int           { return len(s) }
 func (s listenerSorter) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s listenerSorter) Less(i, j int) bool { return s[i].Name < s[j].Name }
 
-// FilterChains sorts the filter chains by the first server name in the chain match.
+// FilterChains sorts the filter chains by the first server name in the
+// chain match, then by name. Filter chains without server names sort last.
 type filterChainSorter []*envoy_listener_v3.FilterChain
 
 func (s filterChainSorter) Len() int      { return len(s) }
 func (s filterChainSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 func (s filterChainSorter) Less(i, j int) bool {
+	// The ServerNames field will only ever have a single entry
+	// in our FilterChain config, so it's okay to only sort
+	// on the first slice entry.
+	lhs := s[i].GetFilterChainMatch().GetServerNames()
+	rhs := s[j].GetFilterChainMatch().GetServerNames()
 
-	// If i's ServerNames aren't defined, then it should not swap
-	if len(s[i].FilterChainMatch.ServerNames) == 0 {
+	switch {
+	case len(lhs) == 0 && len(rhs) == 0:
+		return s[i].Name < s[j].Name
+	case len(lhs) == 0:
 		return false
-	}
-
-	// If j's ServerNames aren't defined, then it should not swap
-	if len(s[j].FilterChainMatch.ServerNames) == 0 {
+	case len(rhs) == 0:
 		return true
+	case lhs[0] != rhs[0]:
+		return lhs[0] < rhs[0]
+	default:
+		return s[i].Name < s[j].Name
 	}
+}
 
-	// The ServerNames field will only ever have a single entry
-	// in our FilterChain config, so it's okay to only sort
-	// on the first slice entry.
-	return s[i].FilterChainMatch.ServerNames[0] < s[j].FilterChainMatch.ServerNames[0]
+// Sorts the header value options by key, then by value, then
+// with the options that replace a header before those that
+// append to it.
+type headerValueOptionSorter []*envoy_core_v3.HeaderValueOption
+
+func (s headerValueOptionSorter) Len() int      { return len(s) }
+func (s headerValueOptionSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s headerValueOptionSorter) Less(i, j int) bool {
+	lhs, rhs := s[i].GetHeader(), s[j].GetHeader()
+
+	switch {
+	case lhs.GetKey() != rhs.GetKey():
+		return lhs.GetKey() < rhs.GetKey()
+	case lhs.GetValue() != rhs.GetValue():
+		return lhs.GetValue() < rhs.GetValue()
+	default:
+		return !s[i].GetAppend().GetValue() && s[j].GetAppend().GetValue()
+	}
 }
 
 // Sorts the secret values by name.
@@ -297,6 +326,8 @@ func For(v interface{}) sort.Interface {
 		return listenerSorter(v)
 	case []*envoy_listener_v3.FilterChain:
 		return filterChainSorter(v)
+	case []*envoy_core_v3.HeaderValueOption:
+		return headerValueOptionSorter(v)
 	default:
 		return nil
 	}
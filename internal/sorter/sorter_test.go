@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
@@ -283,6 +284,35 @@ func TestSortRoutesLongestHeaders(t *testing.T) {
 	assert.Equal(t, want, have)
 }
 
+func TestSortRoutesPriority(t *testing.T) {
+	want := []*dag.Route{
+		// Note that priority takes precedence over specificity.
+		{
+			PathMatchCondition: matchPrefixString("/"),
+			Priority:           10,
+		},
+		{
+			PathMatchCondition: matchExact("/path"),
+			Priority:           5,
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path/prefix"),
+			Priority:           5,
+		},
+		{
+			PathMatchCondition: matchExact("/path/exact"),
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path"),
+		},
+	}
+
+	have := shuffleRoutes(want)
+
+	sort.Stable(For(have))
+	assert.Equal(t, want, have)
+}
+
 func TestSortSecrets(t *testing.T) {
 	want := []*envoy_tls_v3.Secret{
 		{Name: "first"},
@@ -432,6 +462,26 @@ func TestSortHTTPWeightedClusters(t *testing.T) {
 	assert.Equal(t, want, have)
 }
 
+func TestSortHTTPWeightedClustersWithoutWeight(t *testing.T) {
+	want := []*envoy_route_v3.WeightedCluster_ClusterWeight{
+		{
+			Name: "first",
+		},
+		{
+			Name:   "first",
+			Weight: protobuf.UInt32(10),
+		},
+	}
+
+	have := []*envoy_route_v3.WeightedCluster_ClusterWeight{
+		want[1],
+		want[0],
+	}
+
+	sort.Stable(For(have))
+	assert.Equal(t, want, have)
+}
+
 func TestSortTCPWeightedClusters(t *testing.T) {
 	want := []*tcp.TcpProxy_WeightedCluster_ClusterWeight{
 		{
@@ -510,3 +560,65 @@ func TestSortFilterChains(t *testing.T) {
 	sort.Stable(For(have))
 	assert.Equal(t, want, have)
 }
+
+func TestSortFilterChainsByName(t *testing.T) {
+	chain := func(name string, serverNames ...string) *envoy_listener_v3.FilterChain {
+		fc := &envoy_listener_v3.FilterChain{Name: name}
+		if len(serverNames) > 0 {
+			fc.FilterChainMatch = &envoy_listener_v3.FilterChainMatch{
+				ServerNames: serverNames,
+			}
+		}
+		return fc
+	}
+
+	want := []*envoy_listener_v3.FilterChain{
+		chain("a", "example.com"),
+		chain("b", "example.com"),
+		chain("c", "www.example.com"),
+		chain("fallback-a"),
+		chain("fallback-b"),
+	}
+
+	for i := 0; i < 10; i++ {
+		have := make([]*envoy_listener_v3.FilterChain, len(want))
+		copy(have, want)
+		rand.Shuffle(len(have), func(i, j int) {
+			have[i], have[j] = have[j], have[i]
+		})
+
+		sort.Stable(For(have))
+		assert.Equal(t, want, have)
+	}
+}
+
+func TestSortHeaderValueOptions(t *testing.T) {
+	option := func(key, value string, app bool) *envoy_core_v3.HeaderValueOption {
+		return &envoy_core_v3.HeaderValueOption{
+			Header: &envoy_core_v3.HeaderValue{
+				Key:   key,
+				Value: value,
+			},
+			Append: protobuf.Bool(app),
+		}
+	}
+
+	want := []*envoy_core_v3.HeaderValueOption{
+		option("x-a", "1", false),
+		option("x-a", "1", true),
+		option("x-a", "2", false),
+		option("x-b", "1", true),
+		option("x-c", "0", false),
+	}
+
+	for i := 0; i < 10; i++ {
+		have := make([]*envoy_core_v3.HeaderValueOption, len(want))
+		copy(have, want)
+		rand.Shuffle(len(have), func(i, j int) {
+			have[i], have[j] = have[j], have[i]
+		})
+
+		sort.Stable(For(have))
+		assert.Equal(t, want, have)
+	}
+}
@@ -34,6 +34,11 @@ type ConditionType string
 // ValidCondition is the ConditionType for Valid.
 const ValidCondition ConditionType = "Valid"
 
+// ConflictedCondition is the ConditionType for Conflicted. It is
+// set on HTTPProxies that define a route matching the same conditions
+// as a route of another HTTPProxy that takes precedence.
+const ConflictedCondition ConditionType = "Conflicted"
+
 // NewCache creates a new Cache for holding status updates.
 func NewCache(gateway types.NamespacedName, gatewayController gatewayapi_v1alpha2.GatewayController) Cache {
 	return Cache{
@@ -147,6 +152,14 @@ func (c *Cache) GetProxyUpdates() []*ProxyUpdate {
 	return allUpdates
 }
 
+// SetProxyTrafficShifts records the progress of the traffic shifts of
+// the routes of an HTTPProxy in its pending status update, if any.
+func (c *Cache) SetProxyTrafficShifts(name types.NamespacedName, shifts []contour_api_v1.TrafficShiftStatus) {
+	if pu, ok := c.proxyUpdates[name]; ok {
+		pu.TrafficShifts = shifts
+	}
+}
+
 // GetGatewayUpdates gets the underlying GatewayStatusUpdate objects from the cache.
 func (c *Cache) GetGatewayUpdates() []*GatewayStatusUpdate {
 	var allUpdates []*GatewayStatusUpdate
@@ -204,18 +217,30 @@ func (c *Cache) ProxyAccessor(proxy *contour_api_v1.HTTPProxy) (*ProxyUpdate, fu
 			return
 		}
 
-		_, ok := c.proxyUpdates[pu.Fullname]
+		existing, ok := c.proxyUpdates[pu.Fullname]
 		if ok {
 			// When we're committing, if we already have a Valid Condition with an error, and we're trying to
 			// set the object back to Valid, skip the commit, as we've visited too far down.
 			// If this is removed, the status reporting for when a parent delegates to a child that delegates to itself
 			// will not work. Yes, I know, problems everywhere. I'm sorry.
 			// TODO(youngnick)#2968: This issue has more details.
-			if c.proxyUpdates[pu.Fullname].Conditions[ValidCondition].Status == contour_api_v1.ConditionFalse {
-				if pu.Conditions[ValidCondition].Status == contour_api_v1.ConditionTrue {
+			prev, cur := existing.Conditions[ValidCondition], pu.Conditions[ValidCondition]
+			if prev != nil && cur != nil && prev.Status == contour_api_v1.ConditionFalse {
+				if cur.Status == contour_api_v1.ConditionTrue {
 					return
 				}
 			}
+
+			// Keep the conditions of other types that were
+			// committed earlier.
+			for condType, cond := range existing.Conditions {
+				if _, ok := pu.Conditions[condType]; !ok {
+					pu.Conditions[condType] = cond
+				}
+			}
+			if pu.Vhost == "" {
+				pu.Vhost = existing.Vhost
+			}
 		}
 		c.proxyUpdates[pu.Fullname] = pu
 	}
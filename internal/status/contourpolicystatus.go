@@ -0,0 +1,96 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"time"
+
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/k8s"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ContourPolicyCacheEntry holds status updates for a particular ContourPolicy
+type ContourPolicyCacheEntry struct {
+	ConditionCache
+
+	Name           types.NamespacedName
+	Generation     int64
+	TransitionTime v1.Time
+}
+
+var _ CacheEntry = &ContourPolicyCacheEntry{}
+
+func (e *ContourPolicyCacheEntry) AsStatusUpdate() k8s.StatusUpdate {
+	m := k8s.StatusMutatorFunc(func(obj client.Object) client.Object {
+		o, ok := obj.(*contour_api_v1alpha1.ContourPolicy)
+		if !ok {
+			panic(fmt.Sprintf("unsupported %T object %q in status mutator", obj, e.Name))
+		}
+
+		cp := o.DeepCopy()
+
+		for condType, cond := range e.Conditions {
+			cond.ObservedGeneration = e.Generation
+			cond.LastTransitionTime = e.TransitionTime
+
+			currCond := cp.Status.GetConditionFor(string(condType))
+			if currCond == nil {
+				cp.Status.Conditions = append(cp.Status.Conditions, *cond)
+				continue
+			}
+
+			// Don't update the condition if our observation is stale.
+			if currCond.ObservedGeneration > cond.ObservedGeneration {
+				continue
+			}
+
+			cond.DeepCopyInto(currCond)
+		}
+
+		return cp
+	})
+
+	return k8s.StatusUpdate{
+		NamespacedName: e.Name,
+		Resource:       &contour_api_v1alpha1.ContourPolicy{},
+		Mutator:        m,
+	}
+}
+
+// ContourPolicyAccessor returns a pointer to a shared status cache entry
+// for the given ContourPolicy object. If no such entry exists, a new
+// entry is added. When the caller finishes with the cache entry, it must
+// call the returned function to release the entry back to the cache.
+func ContourPolicyAccessor(c *Cache, cp *contour_api_v1alpha1.ContourPolicy) (*ContourPolicyCacheEntry, func()) {
+	entry := c.Get(cp)
+	if entry == nil {
+		entry = &ContourPolicyCacheEntry{
+			Name:           k8s.NamespacedNameOf(cp),
+			Generation:     cp.GetGeneration(),
+			TransitionTime: v1.NewTime(time.Now()),
+		}
+
+		// Populate the cache with the new entry
+		c.Put(cp, entry)
+	}
+
+	entry = c.Get(cp)
+	return entry.(*ContourPolicyCacheEntry), func() {
+		c.Put(cp, entry)
+	}
+}
@@ -85,6 +85,47 @@ func (gatewayUpdate *GatewayStatusUpdate) SetListenerSupportedKinds(listenerName
 	}
 }
 
+// AddListenerCondition adds a Condition of the given type to the
+// status of the named listener.
+func (gatewayUpdate *GatewayStatusUpdate) AddListenerCondition(
+	listenerName string,
+	cond gatewayapi_v1alpha2.ListenerConditionType,
+	status metav1.ConditionStatus,
+	reason gatewayapi_v1alpha2.ListenerConditionReason,
+	message string,
+) metav1.Condition {
+	if gatewayUpdate.ListenerStatus == nil {
+		gatewayUpdate.ListenerStatus = map[string]*gatewayapi_v1alpha2.ListenerStatus{}
+	}
+	if gatewayUpdate.ListenerStatus[listenerName] == nil {
+		gatewayUpdate.ListenerStatus[listenerName] = &gatewayapi_v1alpha2.ListenerStatus{
+			Name: gatewayapi_v1alpha2.SectionName(listenerName),
+		}
+	}
+
+	listenerStatus := gatewayUpdate.ListenerStatus[listenerName]
+
+	newCond := metav1.Condition{
+		Reason:             string(reason),
+		Status:             status,
+		Type:               string(cond),
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(clock.Now()),
+		ObservedGeneration: gatewayUpdate.Generation,
+	}
+
+	for i := range listenerStatus.Conditions {
+		if listenerStatus.Conditions[i].Type == string(cond) {
+			newCond.Message = fmt.Sprintf("%s, %s", listenerStatus.Conditions[i].Message, message)
+			listenerStatus.Conditions[i] = newCond
+			return newCond
+		}
+	}
+
+	listenerStatus.Conditions = append(listenerStatus.Conditions, newCond)
+	return newCond
+}
+
 func (gatewayUpdate *GatewayStatusUpdate) SetListenerAttachedRoutes(listenerName string, numRoutes int) {
 	if gatewayUpdate.ListenerStatus == nil {
 		gatewayUpdate.ListenerStatus = map[string]*gatewayapi_v1alpha2.ListenerStatus{}
@@ -156,7 +197,13 @@ func (gatewayUpdate *GatewayStatusUpdate) Mutate(obj client.Object) client.Objec
 	// for each Gateway status update.
 	var listenerStatusToWrite []gatewayapi_v1alpha2.ListenerStatus
 	for _, status := range gatewayUpdate.ListenerStatus {
-		status.Conditions = []metav1.Condition{} // Conditions is a required field so we have to specify an empty slice here
+		if status.Conditions == nil {
+			status.Conditions = []metav1.Condition{} // Conditions is a required field so we have to specify an empty slice here
+		}
+		for i := range status.Conditions {
+			status.Conditions[i].ObservedGeneration = gatewayUpdate.Generation
+			status.Conditions[i].LastTransitionTime = gatewayUpdate.TransitionTime
+		}
 		listenerStatusToWrite = append(listenerStatusToWrite, *status)
 	}
 
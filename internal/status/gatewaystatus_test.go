@@ -96,6 +96,23 @@ func TestGatewaySetListenerAttachedRoutes(t *testing.T) {
 	assert.Equal(t, int32(77), gsu.ListenerStatus["https"].AttachedRoutes)
 }
 
+func TestGatewayAddListenerCondition(t *testing.T) {
+	gsu := GatewayStatusUpdate{
+		Generation: 7,
+	}
+
+	gsu.AddListenerCondition("http", gatewayapi_v1alpha2.ListenerConditionResolvedRefs, metav1.ConditionFalse,
+		gatewayapi_v1alpha2.ListenerReasonInvalidRouteKinds, "Kind \"FooRoute\" is not supported")
+	got := gsu.AddListenerCondition("http", gatewayapi_v1alpha2.ListenerConditionResolvedRefs, metav1.ConditionFalse,
+		gatewayapi_v1alpha2.ListenerReasonInvalidRouteKinds, "Kind \"BarRoute\" is not supported")
+
+	assert.Equal(t, `Kind "FooRoute" is not supported, Kind "BarRoute" is not supported`, got.Message)
+	assert.Equal(t, int64(7), got.ObservedGeneration)
+
+	require.NotNil(t, gsu.ListenerStatus["http"])
+	assert.Equal(t, []metav1.Condition{got}, gsu.ListenerStatus["http"].Conditions)
+}
+
 func TestGatewayMutate(t *testing.T) {
 	var gsu GatewayStatusUpdate
 
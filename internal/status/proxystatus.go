@@ -41,10 +41,14 @@ type ProxyUpdate struct {
 	// keyed by the Type (since that's what the apiserver will end up
 	// doing.)
 	Conditions map[ConditionType]*projectcontour.DetailedCondition
+
+	// TrafficShifts holds the progress of the traffic
+	// shifts of the routes of the object.
+	TrafficShifts []projectcontour.TrafficShiftStatus
 }
 
 // ConditionFor returns a DetailedCondition for a given ConditionType.
-// Currently only "Valid" is used.
+// Currently "Valid" and "Conflicted" are used.
 func (pu *ProxyUpdate) ConditionFor(cond ConditionType) *projectcontour.DetailedCondition {
 	dc, ok := pu.Conditions[cond]
 	if !ok {
@@ -94,6 +98,20 @@ func (pu *ProxyUpdate) Mutate(obj client.Object) client.Object {
 
 	}
 
+	// A Conflicted condition is only present while the
+	// conflict exists, so remove any stale one.
+	if _, ok := pu.Conditions[ConflictedCondition]; !ok {
+		conditions := proxy.Status.Conditions[:0]
+		for _, cond := range proxy.Status.Conditions {
+			if cond.Type != string(ConflictedCondition) {
+				conditions = append(conditions, cond)
+			}
+		}
+		proxy.Status.Conditions = conditions
+	}
+
+	proxy.Status.TrafficShifts = pu.TrafficShifts
+
 	// Set the old status fields using the Valid DetailedCondition's details.
 	// Other conditions are not relevant for these two fields.
 	validCond := proxy.Status.GetConditionFor(projectcontour.ValidConditionType)
@@ -37,6 +37,7 @@ const ReasonDegraded RouteReasonType = "Degraded"
 const ReasonValid RouteReasonType = "Valid"
 const ReasonErrorsExist RouteReasonType = "ErrorsExist"
 const ReasonGatewayAllowMismatch RouteReasonType = "GatewayAllowMismatch"
+const ReasonPortConflict RouteReasonType = "PortConflict"
 const ReasonAllBackendRefsHaveZeroWeights = "AllBackendRefsHaveZeroWeights"
 
 // clock is used to set lastTransitionTime on status conditions.
@@ -128,6 +129,13 @@ func (routeUpdate *RouteConditionsUpdate) Mutate(obj client.Object) client.Objec
 		gatewayStatuses = append(gatewayStatuses, routeUpdate.combineConditions(route.Status.Parents)...)
 		route.Status.RouteStatus.Parents = gatewayStatuses
 		return route
+	case *gatewayapi_v1alpha2.UDPRoute:
+		route := o.DeepCopy()
+
+		// Set the UDPRoute status.
+		gatewayStatuses = append(gatewayStatuses, routeUpdate.combineConditions(route.Status.Parents)...)
+		route.Status.RouteStatus.Parents = gatewayStatuses
+		return route
 	default:
 		panic(fmt.Sprintf("Unsupported %T object %s/%s in RouteConditionsUpdate status mutator",
 			obj, routeUpdate.FullName.Namespace, routeUpdate.FullName.Name,
@@ -0,0 +1,96 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"time"
+
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/k8s"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TrafficWeightsCacheEntry holds status updates for a particular TrafficWeights
+type TrafficWeightsCacheEntry struct {
+	ConditionCache
+
+	Name           types.NamespacedName
+	Generation     int64
+	TransitionTime v1.Time
+}
+
+var _ CacheEntry = &TrafficWeightsCacheEntry{}
+
+func (e *TrafficWeightsCacheEntry) AsStatusUpdate() k8s.StatusUpdate {
+	m := k8s.StatusMutatorFunc(func(obj client.Object) client.Object {
+		o, ok := obj.(*contour_api_v1alpha1.TrafficWeights)
+		if !ok {
+			panic(fmt.Sprintf("unsupported %T object %q in status mutator", obj, e.Name))
+		}
+
+		tw := o.DeepCopy()
+
+		for condType, cond := range e.Conditions {
+			cond.ObservedGeneration = e.Generation
+			cond.LastTransitionTime = e.TransitionTime
+
+			currCond := tw.Status.GetConditionFor(string(condType))
+			if currCond == nil {
+				tw.Status.Conditions = append(tw.Status.Conditions, *cond)
+				continue
+			}
+
+			// Don't update the condition if our observation is stale.
+			if currCond.ObservedGeneration > cond.ObservedGeneration {
+				continue
+			}
+
+			cond.DeepCopyInto(currCond)
+		}
+
+		return tw
+	})
+
+	return k8s.StatusUpdate{
+		NamespacedName: e.Name,
+		Resource:       &contour_api_v1alpha1.TrafficWeights{},
+		Mutator:        m,
+	}
+}
+
+// TrafficWeightsAccessor returns a pointer to a shared status cache entry
+// for the given TrafficWeights object. If no such entry exists, a new
+// entry is added. When the caller finishes with the cache entry, it must
+// call the returned function to release the entry back to the cache.
+func TrafficWeightsAccessor(c *Cache, tw *contour_api_v1alpha1.TrafficWeights) (*TrafficWeightsCacheEntry, func()) {
+	entry := c.Get(tw)
+	if entry == nil {
+		entry = &TrafficWeightsCacheEntry{
+			Name:           k8s.NamespacedNameOf(tw),
+			Generation:     tw.GetGeneration(),
+			TransitionTime: v1.NewTime(time.Now()),
+		}
+
+		// Populate the cache with the new entry
+		c.Put(tw, entry)
+	}
+
+	entry = c.Get(tw)
+	return entry.(*TrafficWeightsCacheEntry), func() {
+		c.Put(tw, entry)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing configures OpenTelemetry tracing of the Contour
+// control plane and holds the helpers used to correlate Kubernetes
+// object changes with the xDS updates they produce.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/projectcontour/contour/internal/build"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies Contour as the source of its spans.
+const instrumentationName = "github.com/projectcontour/contour"
+
+// Config holds the parameters for exporting spans.
+type Config struct {
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Endpoint string
+
+	// Insecure disables TLS when connecting to the collector.
+	Insecure bool
+
+	// SamplingPercent is the percentage of root spans to sample.
+	SamplingPercent uint32
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+}
+
+// Setup installs a global tracer provider which exports spans to
+// the OTLP collector described by cfg. The returned function flushes
+// and stops the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "contour"
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(float64(cfg.SamplingPercent)/100),
+		)),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(build.Version),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for Contour's spans. If tracing
+// has not been set up, the returned tracer does nothing.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+var (
+	lastUpdateMu sync.RWMutex
+	lastUpdate   trace.SpanContext
+)
+
+// RecordUpdate notes the span that produced the most recent
+// change to the xDS caches.
+func RecordUpdate(sc trace.SpanContext) {
+	lastUpdateMu.Lock()
+	defer lastUpdateMu.Unlock()
+
+	lastUpdate = sc
+}
+
+// LastUpdate returns a link to the span recorded by the most
+// recent call to RecordUpdate. Spans for xDS pushes carry this
+// link so that they can be traced back to the Kubernetes object
+// changes that caused them.
+func LastUpdate() trace.Link {
+	lastUpdateMu.RLock()
+	defer lastUpdateMu.RUnlock()
+
+	return trace.Link{SpanContext: lastUpdate}
+}
@@ -0,0 +1,246 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/projectcontour/contour/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Connections tracks the xDS streams of the Envoys connected to the
+// xDS server, and the version of each resource type they have
+// acknowledged. It can disconnect the streams of an Envoy, so that
+// an Envoy whose streams are stuck reconnects and resyncs.
+type Connections struct {
+	// Metrics, if set, records the number of open
+	// streams of each connected Envoy.
+	Metrics *metrics.Metrics
+
+	mu      sync.Mutex
+	streams map[uint64]*stream
+	counter Counter
+
+	// now is used in place of time.Now in tests.
+	now func() time.Time
+}
+
+// stream is the state of an xDS stream.
+type stream struct {
+	info ConnectionInfo
+
+	// disconnect is closed to disconnect the stream.
+	disconnect chan struct{}
+}
+
+// ConnectionInfo describes an xDS stream of a connected Envoy.
+type ConnectionInfo struct {
+	ID     uint64 `json:"id"`
+	NodeID string `json:"nodeID"`
+
+	// Method is the gRPC method of the stream, which
+	// tells the type of resources it serves.
+	Method      string    `json:"method"`
+	ConnectedAt time.Time `json:"connectedAt"`
+
+	// Versions are the versions of the resources of each type,
+	// by type URL, that the Envoy has acknowledged or rejected.
+	Versions map[string]TypeVersion `json:"versions"`
+}
+
+// TypeVersion is the state of a resource type of an xDS stream.
+type TypeVersion struct {
+	// Acked is the version the Envoy last acknowledged, and
+	// AckedAt when it did.
+	Acked   string    `json:"acked"`
+	AckedAt time.Time `json:"ackedAt"`
+
+	// Error is why the Envoy rejected the last version sent
+	// to it, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// StreamInterceptor returns a gRPC stream interceptor that tracks
+// each stream of the xDS server until it ends or is disconnected.
+func (c *Connections) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		s := c.add(info.FullMethod)
+		defer c.remove(s)
+
+		// The handler runs in its own goroutine so that the stream
+		// can be ended even if the handler is blocked waiting for
+		// changes. It returns once the stream is closed.
+		errc := make(chan error, 1)
+		go func() {
+			errc <- handler(srv, &trackedServerStream{ServerStream: ss, connections: c, stream: s})
+		}()
+
+		select {
+		case err := <-errc:
+			return err
+		case <-s.disconnect:
+			return status.Error(codes.Unavailable, "xDS stream disconnected by Contour")
+		}
+	}
+}
+
+func (c *Connections) add(method string) *stream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &stream{
+		info: ConnectionInfo{
+			ID:          c.counter.Next(),
+			Method:      method,
+			ConnectedAt: c.clock(),
+			Versions:    map[string]TypeVersion{},
+		},
+		disconnect: make(chan struct{}),
+	}
+	if c.streams == nil {
+		c.streams = map[uint64]*stream{}
+	}
+	c.streams[s.info.ID] = s
+	return s
+}
+
+func (c *Connections) remove(s *stream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.streams, s.info.ID)
+	c.recordStreams(s.info.NodeID)
+}
+
+// observe records the node ID and the version acknowledged,
+// or rejected, by a request received on the stream.
+func (c *Connections) observe(s *stream, req *envoy_service_discovery_v3.DiscoveryRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id := req.GetNode().GetId(); id != "" && id != s.info.NodeID {
+		previous := s.info.NodeID
+		s.info.NodeID = id
+		c.recordStreams(previous)
+		c.recordStreams(id)
+	}
+
+	// The first request of a stream, with no nonce,
+	// does not respond to anything.
+	if req.GetResponseNonce() == "" {
+		return
+	}
+
+	v := s.info.Versions[req.GetTypeUrl()]
+	if detail := req.GetErrorDetail(); detail != nil {
+		v.Error = detail.GetMessage()
+	} else {
+		v.Acked = req.GetVersionInfo()
+		v.AckedAt = c.clock()
+		v.Error = ""
+	}
+	s.info.Versions[req.GetTypeUrl()] = v
+}
+
+// recordStreams records the number of open streams of the node.
+// It must be called with c.mu held.
+func (c *Connections) recordStreams(nodeID string) {
+	if c.Metrics == nil || nodeID == "" {
+		return
+	}
+
+	n := 0
+	for _, s := range c.streams {
+		if s.info.NodeID == nodeID {
+			n++
+		}
+	}
+	c.Metrics.SetXDSStreams(nodeID, n)
+}
+
+// Info returns the streams of the connected Envoys,
+// sorted by node ID and stream ID.
+func (c *Connections) Info() []ConnectionInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	infos := make([]ConnectionInfo, 0, len(c.streams))
+	for _, s := range c.streams {
+		info := s.info
+		info.Versions = make(map[string]TypeVersion, len(s.info.Versions))
+		for typeURL, v := range s.info.Versions {
+			info.Versions[typeURL] = v
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].NodeID != infos[j].NodeID {
+			return infos[i].NodeID < infos[j].NodeID
+		}
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+// Disconnect ends every stream of the Envoy node, and
+// returns how many there were.
+func (c *Connections) Disconnect(nodeID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for id, s := range c.streams {
+		if s.info.NodeID != nodeID {
+			continue
+		}
+		close(s.disconnect)
+		delete(c.streams, id)
+		n++
+	}
+	c.recordStreams(nodeID)
+	return n
+}
+
+func (c *Connections) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// trackedServerStream is a grpc.ServerStream that
+// observes the requests received on the stream.
+type trackedServerStream struct {
+	grpc.ServerStream
+
+	connections *Connections
+	stream      *stream
+}
+
+func (t *trackedServerStream) RecvMsg(m interface{}) error {
+	if err := t.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if req, ok := m.(*envoy_service_discovery_v3.DiscoveryRequest); ok {
+		t.connections.observe(t.stream, req)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeServerStream is a grpc.ServerStream that
+// receives the requests sent to it on reqs.
+type fakeServerStream struct {
+	grpc.ServerStream
+	reqs chan *envoy_service_discovery_v3.DiscoveryRequest
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return context.Background()
+}
+
+func (f *fakeServerStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(*envoy_service_discovery_v3.DiscoveryRequest), <-f.reqs)
+	return nil
+}
+
+func TestConnections(t *testing.T) {
+	now := time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC)
+	c := &Connections{
+		now: func() time.Time { return now },
+	}
+
+	ss := &fakeServerStream{reqs: make(chan *envoy_service_discovery_v3.DiscoveryRequest)}
+	received := make(chan struct{})
+	errc := make(chan error, 1)
+
+	// The handler receives requests until the stream is disconnected,
+	// and then blocks as if it were waiting for changes.
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		for {
+			var req envoy_service_discovery_v3.DiscoveryRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			received <- struct{}{}
+		}
+	}
+
+	go func() {
+		errc <- c.StreamInterceptor()(nil, ss, &grpc.StreamServerInfo{FullMethod: "/envoy.service.cluster.v3.ClusterDiscoveryService/StreamClusters"}, handler)
+	}()
+
+	send := func(req *envoy_service_discovery_v3.DiscoveryRequest) {
+		ss.reqs <- req
+		<-received
+	}
+
+	const typeURL = "type.googleapis.com/envoy.config.cluster.v3.Cluster"
+	send(&envoy_service_discovery_v3.DiscoveryRequest{
+		Node:    &envoy_config_core_v3.Node{Id: "envoy-a"},
+		TypeUrl: typeURL,
+	})
+	now = now.Add(time.Second)
+	send(&envoy_service_discovery_v3.DiscoveryRequest{
+		VersionInfo:   "1",
+		ResponseNonce: "1",
+		TypeUrl:       typeURL,
+	})
+	send(&envoy_service_discovery_v3.DiscoveryRequest{
+		VersionInfo:   "1",
+		ResponseNonce: "2",
+		TypeUrl:       typeURL,
+		ErrorDetail:   &status.Status{Message: "duplicate cluster"},
+	})
+
+	assert.Equal(t, []ConnectionInfo{{
+		ID:          1,
+		NodeID:      "envoy-a",
+		Method:      "/envoy.service.cluster.v3.ClusterDiscoveryService/StreamClusters",
+		ConnectedAt: time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC),
+		Versions: map[string]TypeVersion{
+			typeURL: {
+				Acked:   "1",
+				AckedAt: time.Date(2021, 11, 2, 10, 0, 1, 0, time.UTC),
+				Error:   "duplicate cluster",
+			},
+		},
+	}}, c.Info())
+
+	assert.Equal(t, 0, c.Disconnect("envoy-b"))
+	assert.Equal(t, 1, c.Disconnect("envoy-a"))
+
+	err := <-errc
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, grpc_status.Code(err))
+	assert.Empty(t, c.Info())
+}
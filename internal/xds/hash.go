@@ -32,3 +32,34 @@ func (c ConstantHashV3) ID(*envoy_config_v3.Node) string {
 func (c ConstantHashV3) String() string {
 	return CONSTANT_HASH_VALUE
 }
+
+// ProfileHashV3 is a node ID hasher that groups Envoy nodes by the
+// listener profile bound to their node ID or, failing that, their
+// node cluster. Nodes that are not bound to a profile share the
+// CONSTANT_HASH_VALUE.
+type ProfileHashV3 struct {
+	// NodeIDs maps Envoy node IDs to profile names.
+	NodeIDs map[string]string
+
+	// NodeClusters maps Envoy node clusters to profile names.
+	NodeClusters map[string]string
+}
+
+func (p ProfileHashV3) ID(node *envoy_config_v3.Node) string {
+	if node == nil {
+		return CONSTANT_HASH_VALUE
+	}
+	if profile, ok := p.NodeIDs[node.Id]; ok {
+		return ProfileHash(profile)
+	}
+	if profile, ok := p.NodeClusters[node.Cluster]; ok {
+		return ProfileHash(profile)
+	}
+	return CONSTANT_HASH_VALUE
+}
+
+// ProfileHash returns the node hash of the Envoy nodes bound to the
+// named listener profile.
+func ProfileHash(profile string) string {
+	return CONSTANT_HASH_VALUE + "/" + profile
+}
@@ -0,0 +1,67 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	envoy_config_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileHashV3(t *testing.T) {
+	hash := ProfileHashV3{
+		NodeIDs: map[string]string{
+			"envoy-internal-0": "internal",
+		},
+		NodeClusters: map[string]string{
+			"projectcontour-internal": "internal",
+			"projectcontour-partner":  "partner",
+		},
+	}
+
+	tests := map[string]struct {
+		node *envoy_config_v3.Node
+		want string
+	}{
+		"nil node": {
+			node: nil,
+			want: "contour",
+		},
+		"unbound node": {
+			node: &envoy_config_v3.Node{Id: "envoy-0", Cluster: "projectcontour"},
+			want: "contour",
+		},
+		"bound by node ID": {
+			node: &envoy_config_v3.Node{Id: "envoy-internal-0", Cluster: "projectcontour"},
+			want: "contour/internal",
+		},
+		"bound by node cluster": {
+			node: &envoy_config_v3.Node{Id: "envoy-1", Cluster: "projectcontour-partner"},
+			want: "contour/partner",
+		},
+		"node ID takes precedence": {
+			node: &envoy_config_v3.Node{Id: "envoy-internal-0", Cluster: "projectcontour-partner"},
+			want: "contour/internal",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, hash.ID(tc.node))
+		})
+	}
+
+	assert.Equal(t, "contour", ProfileHashV3{}.ID(&envoy_config_v3.Node{Id: "envoy-0"}))
+}
@@ -22,10 +22,13 @@ import (
 // Resource represents a source of proto.Messages that can be registered
 // for interest.
 type Resource interface {
-	// Contents returns the contents of this resource.
+	// Contents returns the contents of this resource. The caller
+	// owns the returned slice, and may hand it back to
+	// protobuf.PutMessages once it is done with it.
 	Contents() []proto.Message
 
 	// Query returns an entry for each resource name supplied.
+	// The caller owns the returned slice, as for Contents.
 	Query(names []string) []proto.Message
 
 	// Register registers ch to receive a value when Notify is called.
@@ -14,6 +14,8 @@
 package xds
 
 import (
+	"crypto/sha1" // nolint:gosec
+	"fmt"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/types"
@@ -38,3 +40,30 @@ func ClusterLoadAssignmentName(service types.NamespacedName, portName string) st
 
 	return strings.Join(name, "/")
 }
+
+// HealthCheckClusterLoadAssignmentName generates the name used for an
+// EDS ClusterLoadAssignment whose endpoints are health checked on
+// healthCheckPort rather than on the port that traffic is sent to.
+// Since the health check port is set on each endpoint, these endpoints
+// cannot share the ClusterLoadAssignment of the Service port. If
+// healthCheckPort is zero, the name is the same as the name returned
+// by ClusterLoadAssignmentName.
+func HealthCheckClusterLoadAssignmentName(service types.NamespacedName, portName string, healthCheckPort uint32) string {
+	name := ClusterLoadAssignmentName(service, portName)
+	if healthCheckPort == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s/healthcheck-%d", name, healthCheckPort)
+}
+
+// BackupClusterLoadAssignmentName generates the name used for an EDS
+// ClusterLoadAssignment that includes the endpoints of the backups,
+// given by their ClusterLoadAssignment names, after the endpoints of
+// the ClusterLoadAssignment called name.
+func BackupClusterLoadAssignmentName(name string, backups ...string) string {
+	// This isn't a crypto hash, we just want a unique name.
+	hash := sha1.Sum([]byte(strings.Join(backups, ","))) // nolint:gosec
+
+	return fmt.Sprintf("%s/backup-%x", name, hash[:5])
+}
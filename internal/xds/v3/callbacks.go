@@ -14,23 +14,124 @@
 package v3
 
 import (
+	"context"
 	"fmt"
 
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	envoy_server_v3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"github.com/projectcontour/contour/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // NewRequestLoggingCallbacks returns an implementation of the Envoy xDS server
 // callbacks for use when Contour is run in Envoy xDS server mode to provide
-// request detail logging. Currently only the xDS State of the World callback
-// OnStreamRequest is implemented.
+// request detail logging and response tracing. Currently only the xDS State
+// of the World callbacks OnStreamRequest and OnStreamResponse are implemented.
 func NewRequestLoggingCallbacks(log logrus.FieldLogger) envoy_server_v3.Callbacks {
 	return &envoy_server_v3.CallbackFuncs{
 		StreamRequestFunc: func(streamID int64, req *envoy_service_discovery_v3.DiscoveryRequest) error {
 			logDiscoveryRequestDetails(log, req)
 			return nil
 		},
+		StreamResponseFunc: func(ctx context.Context, streamID int64, req *envoy_service_discovery_v3.DiscoveryRequest, resp *envoy_service_discovery_v3.DiscoveryResponse) {
+			_, span := tracing.Tracer().Start(ctx, "PushDiscoveryResponse",
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithLinks(tracing.LastUpdate()),
+				trace.WithAttributes(
+					attribute.Int64("xds.stream_id", streamID),
+					attribute.String("xds.type_url", resp.GetTypeUrl()),
+					attribute.String("xds.version_info", resp.GetVersionInfo()),
+					attribute.String("xds.node_id", req.GetNode().GetId()),
+					attribute.Int("xds.resources", len(resp.GetResources())),
+				))
+			span.End()
+		},
+	}
+}
+
+// ComposeCallbacks returns Envoy xDS server callbacks that call each
+// of the given callbacks in turn. A request is rejected with the error
+// of the first callback that returns one.
+func ComposeCallbacks(callbacks ...envoy_server_v3.Callbacks) envoy_server_v3.Callbacks {
+	return composedCallbacks(callbacks)
+}
+
+type composedCallbacks []envoy_server_v3.Callbacks
+
+func (c composedCallbacks) OnStreamOpen(ctx context.Context, streamID int64, typeURL string) error {
+	for _, cb := range c {
+		if err := cb.OnStreamOpen(ctx, streamID, typeURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c composedCallbacks) OnStreamClosed(streamID int64) {
+	for _, cb := range c {
+		cb.OnStreamClosed(streamID)
+	}
+}
+
+func (c composedCallbacks) OnStreamRequest(streamID int64, req *envoy_service_discovery_v3.DiscoveryRequest) error {
+	for _, cb := range c {
+		if err := cb.OnStreamRequest(streamID, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c composedCallbacks) OnStreamResponse(ctx context.Context, streamID int64, req *envoy_service_discovery_v3.DiscoveryRequest, resp *envoy_service_discovery_v3.DiscoveryResponse) {
+	for _, cb := range c {
+		cb.OnStreamResponse(ctx, streamID, req, resp)
+	}
+}
+
+func (c composedCallbacks) OnDeltaStreamOpen(ctx context.Context, streamID int64, typeURL string) error {
+	for _, cb := range c {
+		if err := cb.OnDeltaStreamOpen(ctx, streamID, typeURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c composedCallbacks) OnDeltaStreamClosed(streamID int64) {
+	for _, cb := range c {
+		cb.OnDeltaStreamClosed(streamID)
+	}
+}
+
+func (c composedCallbacks) OnStreamDeltaRequest(streamID int64, req *envoy_service_discovery_v3.DeltaDiscoveryRequest) error {
+	for _, cb := range c {
+		if err := cb.OnStreamDeltaRequest(streamID, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c composedCallbacks) OnStreamDeltaResponse(streamID int64, req *envoy_service_discovery_v3.DeltaDiscoveryRequest, resp *envoy_service_discovery_v3.DeltaDiscoveryResponse) {
+	for _, cb := range c {
+		cb.OnStreamDeltaResponse(streamID, req, resp)
+	}
+}
+
+func (c composedCallbacks) OnFetchRequest(ctx context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) error {
+	for _, cb := range c {
+		if err := cb.OnFetchRequest(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c composedCallbacks) OnFetchResponse(req *envoy_service_discovery_v3.DiscoveryRequest, resp *envoy_service_discovery_v3.DiscoveryResponse) {
+	for _, cb := range c {
+		cb.OnFetchResponse(req, resp)
 	}
 }
 
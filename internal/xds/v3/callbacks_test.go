@@ -14,12 +14,15 @@
 package v3
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	envoy_server_v3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
@@ -149,3 +152,28 @@ func TestOnStreamRequestCallbackLogs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, logHook.AllEntries())
 }
+
+func TestComposeCallbacks(t *testing.T) {
+	var calls []string
+	callback := func(name string, err error) envoy_server_v3.Callbacks {
+		return &envoy_server_v3.CallbackFuncs{
+			StreamRequestFunc: func(int64, *envoy_service_discovery_v3.DiscoveryRequest) error {
+				calls = append(calls, name)
+				return err
+			},
+			StreamResponseFunc: func(context.Context, int64, *envoy_service_discovery_v3.DiscoveryRequest, *envoy_service_discovery_v3.DiscoveryResponse) {
+				calls = append(calls, name)
+			},
+		}
+	}
+
+	callbacks := ComposeCallbacks(callback("first", nil), callback("second", errors.New("rejected")), callback("third", nil))
+
+	callbacks.OnStreamResponse(context.Background(), 1, &envoy_service_discovery_v3.DiscoveryRequest{}, &envoy_service_discovery_v3.DiscoveryResponse{})
+	assert.Equal(t, []string{"first", "second", "third"}, calls)
+
+	calls = nil
+	err := callbacks.OnStreamRequest(1, &envoy_service_discovery_v3.DiscoveryRequest{})
+	assert.EqualError(t, err, "rejected")
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
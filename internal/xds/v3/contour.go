@@ -23,11 +23,17 @@ import (
 	envoy_service_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
 	envoy_service_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
 	envoy_service_route_v3 "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	envoy_service_runtime_v3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
 	envoy_service_secret_v3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/internal/tracing"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -63,6 +69,7 @@ type contourServer struct {
 	envoy_service_endpoint_v3.UnimplementedEndpointDiscoveryServiceServer
 	envoy_service_cluster_v3.UnimplementedClusterDiscoveryServiceServer
 	envoy_service_listener_v3.UnimplementedListenerDiscoveryServiceServer
+	envoy_service_runtime_v3.UnimplementedRuntimeDiscoveryServiceServer
 
 	logrus.FieldLogger
 	resources   map[string]xds.Resource
@@ -93,18 +100,31 @@ func (s *contourServer) stream(st grpcStream) error {
 	last := -1
 	ctx := st.Context()
 
+	// nodeID is the ID of the Envoy node on this stream. Only
+	// the first request on a stream is required to carry it.
+	var nodeID string
+
+	// typeURL is the xDS type of the last request on this stream,
+	// so that the termination of the stream can be logged with it.
+	var typeURL string
+
 	// now stick in this loop until the client disconnects.
 	for {
 		// first we wait for the request from Envoy, this is part of
 		// the xDS protocol.
 		req, err := st.Recv()
 		if err != nil {
-			return done(log, err)
+			return done(log.WithField("type_url", typeURL), err)
 		}
+		typeURL = req.GetTypeUrl()
 
 		// Note: redeclare log in this scope so the next time around the loop all is forgotten.
 		log := logDiscoveryRequestDetails(log, req)
 
+		if req.Node != nil {
+			nodeID = req.Node.Id
+		}
+
 		// From the request we derive the resource to stream which have
 		// been registered according to the typeURL.
 		r, ok := s.resources[req.GetTypeUrl()]
@@ -140,6 +160,7 @@ func (s *contourServer) stream(st grpcStream) error {
 				}
 				any = append(any, a)
 			}
+			protobuf.PutMessages(resources)
 
 			resp := &envoy_service_discovery_v3.DiscoveryResponse{
 				VersionInfo: strconv.Itoa(last),
@@ -148,9 +169,23 @@ func (s *contourServer) stream(st grpcStream) error {
 				Nonce:       strconv.Itoa(last),
 			}
 
+			_, span := tracing.Tracer().Start(ctx, "PushDiscoveryResponse",
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithLinks(tracing.LastUpdate()),
+				trace.WithAttributes(
+					attribute.String("xds.type_url", resp.TypeUrl),
+					attribute.String("xds.version_info", resp.VersionInfo),
+					attribute.String("xds.node_id", nodeID),
+					attribute.Int("xds.resources", len(resp.Resources)),
+				))
+
 			if err := st.Send(resp); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				span.End()
 				return done(log, err)
 			}
+			span.End()
 
 		case <-ctx.Done():
 			return done(log, ctx.Err())
@@ -174,6 +209,10 @@ func (s *contourServer) StreamRoutes(srv envoy_service_route_v3.RouteDiscoverySe
 	return s.stream(srv)
 }
 
+func (s *contourServer) StreamRuntime(srv envoy_service_runtime_v3.RuntimeDiscoveryService_StreamRuntimeServer) error {
+	return s.stream(srv)
+}
+
 func (s *contourServer) StreamSecrets(srv envoy_service_secret_v3.SecretDiscoveryService_StreamSecretsServer) error {
 	return s.stream(srv)
 }
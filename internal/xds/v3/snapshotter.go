@@ -16,11 +16,17 @@ package v3
 import (
 	"context"
 
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	envoy_cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	envoy_log "github.com/envoyproxy/go-control-plane/pkg/log"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/projectcontour/contour/internal/xds"
 	"github.com/projectcontour/contour/internal/xdscache"
+	"google.golang.org/protobuf/proto"
 )
 
 var Hash = xds.ConstantHashV3{}
@@ -36,23 +42,111 @@ type snapshotter struct {
 }
 
 func (s *snapshotter) Generate(version string, resources map[envoy_types.ResponseType][]envoy_types.Resource) error {
-	// Create a snapshot with all xDS resources.
-	snapshot := envoy_cache_v3.NewSnapshot(
-		version,
-		resources[envoy_types.Endpoint],
-		resources[envoy_types.Cluster],
-		resources[envoy_types.Route],
-		resources[envoy_types.Listener],
-		nil,
-		resources[envoy_types.Secret],
-		nil,
-	)
-
+	snapshot, err := newSnapshot(version, resources)
+	if err != nil {
+		return err
+	}
 	return s.SetSnapshot(context.TODO(), Hash.String(), snapshot)
 }
 
+// GenerateProfile implements xdscache.ProfileSnapshotter.
+func (s *snapshotter) GenerateProfile(profile string, version string, resources map[envoy_types.ResponseType][]envoy_types.Resource) error {
+	snapshot, err := newSnapshot(version, resources)
+	if err != nil {
+		return err
+	}
+	return s.SetSnapshot(context.TODO(), xds.ProfileHash(profile), snapshot)
+}
+
+// newSnapshot creates a snapshot with all xDS resources. Secrets
+// that none of the snapshot's listeners or clusters refer to are
+// left out, so that Envoy nodes bound to a listener profile only
+// receive the secrets of their own listeners.
+func newSnapshot(version string, resources map[envoy_types.ResponseType][]envoy_types.Resource) (envoy_cache_v3.Snapshot, error) {
+	return envoy_cache_v3.NewSnapshot(version, map[resource.Type][]envoy_types.Resource{
+		resource.EndpointType: resources[envoy_types.Endpoint],
+		resource.ClusterType:  resources[envoy_types.Cluster],
+		resource.RouteType:    resources[envoy_types.Route],
+		resource.ListenerType: resources[envoy_types.Listener],
+		resource.RuntimeType:  resources[envoy_types.Runtime],
+		resource.SecretType:   referencedSecrets(resources),
+	})
+}
+
+// referencedSecrets returns the secrets of resources whose names are
+// referred to over SDS by the TLS transport sockets of the listeners
+// and clusters of resources.
+func referencedSecrets(resources map[envoy_types.ResponseType][]envoy_types.Resource) []envoy_types.Resource {
+	names := map[string]bool{}
+
+	for _, r := range resources[envoy_types.Listener] {
+		l, ok := r.(*envoy_listener_v3.Listener)
+		if !ok {
+			continue
+		}
+		for _, fc := range l.FilterChains {
+			addSecretNames(names, fc.TransportSocket, &envoy_tls_v3.DownstreamTlsContext{})
+		}
+		if l.DefaultFilterChain != nil {
+			addSecretNames(names, l.DefaultFilterChain.TransportSocket, &envoy_tls_v3.DownstreamTlsContext{})
+		}
+	}
+
+	for _, r := range resources[envoy_types.Cluster] {
+		if c, ok := r.(*envoy_cluster_v3.Cluster); ok {
+			addSecretNames(names, c.TransportSocket, &envoy_tls_v3.UpstreamTlsContext{})
+		}
+	}
+
+	var secrets []envoy_types.Resource
+	for _, r := range resources[envoy_types.Secret] {
+		if s, ok := r.(*envoy_tls_v3.Secret); ok && names[s.Name] {
+			secrets = append(secrets, r)
+		}
+	}
+
+	return secrets
+}
+
+// tlsContext is implemented by the Downstream and UpstreamTlsContext
+// messages carried by TLS transport sockets.
+type tlsContext interface {
+	proto.Message
+	GetCommonTlsContext() *envoy_tls_v3.CommonTlsContext
+}
+
+// addSecretNames adds to names the names of the SDS secrets that the
+// TLS context of the transport socket ts refers to. The context is
+// unmarshaled into tc, which must match the direction of ts.
+func addSecretNames(names map[string]bool, ts *envoy_core_v3.TransportSocket, tc tlsContext) {
+	if ts.GetTypedConfig() == nil {
+		return
+	}
+	if err := ts.GetTypedConfig().UnmarshalTo(tc); err != nil {
+		return
+	}
+
+	common := tc.GetCommonTlsContext()
+	for _, sds := range common.GetTlsCertificateSdsSecretConfigs() {
+		names[sds.GetName()] = true
+	}
+	if sds := common.GetValidationContextSdsSecretConfig(); sds != nil {
+		names[sds.GetName()] = true
+	}
+	if sds := common.GetCombinedValidationContext().GetValidationContextSdsSecretConfig(); sds != nil {
+		names[sds.GetName()] = true
+	}
+}
+
 func NewSnapshotCache(ads bool, logger envoy_log.Logger) Snapshotter {
+	return NewProfileSnapshotCache(ads, xds.ProfileHashV3{}, logger)
+}
+
+// NewProfileSnapshotCache returns a Snapshotter that serves the
+// snapshot of each listener profile to the Envoy nodes bound to it
+// by the hash, and the default snapshot to all other nodes.
+func NewProfileSnapshotCache(ads bool, hash xds.ProfileHashV3, logger envoy_log.Logger) Snapshotter {
 	return &snapshotter{
-		SnapshotCache: envoy_cache_v3.NewSnapshotCache(ads, &Hash, logger),
+		SnapshotCache: envoy_cache_v3.NewSnapshotCache(ads, hash, logger),
 	}
 }
@@ -0,0 +1,131 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/protobuf"
+)
+
+func TestReferencedSecrets(t *testing.T) {
+	secrets := []envoy_types.Resource{
+		tlsSecret("default/listener/68621186db"),
+		tlsSecret("default/client/5397c67313"),
+		tlsSecret("default/unreferenced/8d1e2c8a4f"),
+	}
+
+	tests := map[string]struct {
+		resources map[envoy_types.ResponseType][]envoy_types.Resource
+		want      []envoy_types.Resource
+	}{
+		"no listeners or clusters": {
+			resources: map[envoy_types.ResponseType][]envoy_types.Resource{
+				envoy_types.Secret: secrets,
+			},
+			want: nil,
+		},
+		"listener filter chain secret": {
+			resources: map[envoy_types.ResponseType][]envoy_types.Resource{
+				envoy_types.Listener: {
+					&envoy_listener_v3.Listener{
+						Name: "ingress_https",
+						FilterChains: []*envoy_listener_v3.FilterChain{
+							{},
+							{
+								TransportSocket: tlsTransportSocket(&envoy_tls_v3.DownstreamTlsContext{
+									CommonTlsContext: commonTLSContext("default/listener/68621186db"),
+								}),
+							},
+						},
+					},
+				},
+				envoy_types.Secret: secrets,
+			},
+			want: []envoy_types.Resource{
+				tlsSecret("default/listener/68621186db"),
+			},
+		},
+		"cluster client secret": {
+			resources: map[envoy_types.ResponseType][]envoy_types.Resource{
+				envoy_types.Cluster: {
+					&envoy_cluster_v3.Cluster{
+						Name: "default/backend/443/da39a3ee5e",
+						TransportSocket: tlsTransportSocket(&envoy_tls_v3.UpstreamTlsContext{
+							CommonTlsContext: commonTLSContext("default/client/5397c67313"),
+						}),
+					},
+				},
+				envoy_types.Secret: secrets,
+			},
+			want: []envoy_types.Resource{
+				tlsSecret("default/client/5397c67313"),
+			},
+		},
+		"missing secret": {
+			resources: map[envoy_types.ResponseType][]envoy_types.Resource{
+				envoy_types.Listener: {
+					&envoy_listener_v3.Listener{
+						Name: "ingress_https",
+						FilterChains: []*envoy_listener_v3.FilterChain{{
+							TransportSocket: tlsTransportSocket(&envoy_tls_v3.DownstreamTlsContext{
+								CommonTlsContext: commonTLSContext("default/missing/68621186db"),
+							}),
+						}},
+					},
+				},
+				envoy_types.Secret: secrets,
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			protobuf.ExpectEqual(t, tc.want, referencedSecrets(tc.resources))
+		})
+	}
+}
+
+func tlsSecret(name string) *envoy_tls_v3.Secret {
+	return &envoy_tls_v3.Secret{
+		Name: name,
+		Type: &envoy_tls_v3.Secret_TlsCertificate{
+			TlsCertificate: &envoy_tls_v3.TlsCertificate{},
+		},
+	}
+}
+
+func commonTLSContext(secretName string) *envoy_tls_v3.CommonTlsContext {
+	return &envoy_tls_v3.CommonTlsContext{
+		TlsCertificateSdsSecretConfigs: []*envoy_tls_v3.SdsSecretConfig{{
+			Name: secretName,
+		}},
+	}
+}
+
+func tlsTransportSocket(tc proto.Message) *envoy_core_v3.TransportSocket {
+	return &envoy_core_v3.TransportSocket{
+		Name: "envoy.transport_sockets.tls",
+		ConfigType: &envoy_core_v3.TransportSocket_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(tc),
+		},
+	}
+}
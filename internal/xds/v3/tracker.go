@@ -0,0 +1,281 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/projectcontour/contour/internal/metrics"
+)
+
+// VersionTracker tracks the version of the xDS resources that each
+// Envoy connected to the xDS server has applied, as acknowledged in
+// its requests, against the version of the latest snapshot, so that
+// the Envoys whose xDS streams are stuck can be found. It implements
+// the Envoy xDS server callbacks, and xdscache.Snapshotter to learn
+// the version of each snapshot.
+type VersionTracker struct {
+	// Threshold is how long an Envoy may take to apply the latest
+	// snapshot before it is reported as lagging.
+	Threshold time.Duration
+
+	// Metrics, if set, records the number of lagging Envoys.
+	Metrics *metrics.Metrics
+
+	mu sync.Mutex
+
+	// latest is the version of the latest snapshot.
+	latest string
+
+	// streams holds the state of each open xDS stream.
+	streams map[int64]*trackedStream
+
+	// now is used in place of time.Now in tests.
+	now func() time.Time
+}
+
+// trackedStream is the state of an xDS stream.
+type trackedStream struct {
+	nodeID string
+
+	// types holds the state of each resource type
+	// requested on the stream, by type URL.
+	types map[string]*trackedType
+}
+
+// trackedType is the state of a resource type of an xDS stream.
+type trackedType struct {
+	// applied is the version the Envoy last acknowledged.
+	applied string
+
+	// behindSince is when the Envoy was first seen not to have
+	// applied the latest snapshot, or zero if it has.
+	behindSince time.Time
+}
+
+// LaggingEnvoy is an Envoy that has not applied the
+// resources of a type of the latest snapshot.
+type LaggingEnvoy struct {
+	NodeID         string `json:"nodeID"`
+	TypeURL        string `json:"typeURL"`
+	AppliedVersion string `json:"appliedVersion"`
+	LatestVersion  string `json:"latestVersion"`
+
+	// Behind is how long the Envoy has not applied the
+	// latest snapshot for, in seconds.
+	Behind float64 `json:"behindSeconds"`
+}
+
+// LaggingReport lists the Envoys lagging the latest snapshot
+// for longer than the threshold.
+type LaggingReport struct {
+	Threshold string         `json:"threshold"`
+	Envoys    []LaggingEnvoy `json:"envoys"`
+}
+
+// Generate implements xdscache.Snapshotter. Envoys that have applied
+// the previous snapshot start lagging when a new one is generated.
+func (t *VersionTracker) Generate(version string, resources map[envoy_types.ResponseType][]envoy_types.Resource) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.latest = version
+	now := t.clock()
+	for _, s := range t.streams {
+		for _, tt := range s.types {
+			t.update(tt, now)
+		}
+	}
+	return nil
+}
+
+// update sets whether the resource type is behind the latest snapshot.
+func (t *VersionTracker) update(tt *trackedType, now time.Time) {
+	switch {
+	case tt.applied == t.latest:
+		tt.behindSince = time.Time{}
+	case tt.behindSince.IsZero():
+		tt.behindSince = now
+	}
+}
+
+// OnStreamOpen implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnStreamOpen(_ context.Context, streamID int64, _ string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.streams == nil {
+		t.streams = map[int64]*trackedStream{}
+	}
+	t.streams[streamID] = &trackedStream{types: map[string]*trackedType{}}
+	return nil
+}
+
+// OnStreamClosed implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnStreamClosed(streamID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.streams, streamID)
+}
+
+// OnStreamRequest implements envoy_server_v3.Callbacks. The version
+// of a request is the one the Envoy last applied, including when it
+// rejects a newer one.
+func (t *VersionTracker) OnStreamRequest(streamID int64, req *envoy_service_discovery_v3.DiscoveryRequest) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.streams[streamID]
+	if !ok {
+		return nil
+	}
+	if id := req.GetNode().GetId(); id != "" {
+		s.nodeID = id
+	}
+
+	tt, ok := s.types[req.GetTypeUrl()]
+	if !ok {
+		tt = &trackedType{}
+		s.types[req.GetTypeUrl()] = tt
+	}
+	tt.applied = req.GetVersionInfo()
+	t.update(tt, t.clock())
+	return nil
+}
+
+// OnStreamResponse implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnStreamResponse(context.Context, int64, *envoy_service_discovery_v3.DiscoveryRequest, *envoy_service_discovery_v3.DiscoveryResponse) {
+}
+
+// OnDeltaStreamOpen implements envoy_server_v3.Callbacks. Contour
+// only serves State of the World xDS, so delta streams aren't tracked.
+func (t *VersionTracker) OnDeltaStreamOpen(context.Context, int64, string) error {
+	return nil
+}
+
+// OnDeltaStreamClosed implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnDeltaStreamClosed(int64) {
+}
+
+// OnStreamDeltaRequest implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnStreamDeltaRequest(int64, *envoy_service_discovery_v3.DeltaDiscoveryRequest) error {
+	return nil
+}
+
+// OnStreamDeltaResponse implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnStreamDeltaResponse(int64, *envoy_service_discovery_v3.DeltaDiscoveryRequest, *envoy_service_discovery_v3.DeltaDiscoveryResponse) {
+}
+
+// OnFetchRequest implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnFetchRequest(context.Context, *envoy_service_discovery_v3.DiscoveryRequest) error {
+	return nil
+}
+
+// OnFetchResponse implements envoy_server_v3.Callbacks.
+func (t *VersionTracker) OnFetchResponse(*envoy_service_discovery_v3.DiscoveryRequest, *envoy_service_discovery_v3.DiscoveryResponse) {
+}
+
+// Lagging returns the Envoys that have not applied the latest
+// snapshot for longer than the threshold, sorted by node ID
+// and type URL.
+func (t *VersionTracker) Lagging() []LaggingEnvoy {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock()
+	lagging := []LaggingEnvoy{}
+	for _, s := range t.streams {
+		for typeURL, tt := range s.types {
+			if tt.behindSince.IsZero() {
+				continue
+			}
+			behind := now.Sub(tt.behindSince)
+			if behind <= t.Threshold {
+				continue
+			}
+			lagging = append(lagging, LaggingEnvoy{
+				NodeID:         s.nodeID,
+				TypeURL:        typeURL,
+				AppliedVersion: tt.applied,
+				LatestVersion:  t.latest,
+				Behind:         behind.Seconds(),
+			})
+		}
+	}
+
+	sort.Slice(lagging, func(i, j int) bool {
+		if lagging[i].NodeID != lagging[j].NodeID {
+			return lagging[i].NodeID < lagging[j].NodeID
+		}
+		return lagging[i].TypeURL < lagging[j].TypeURL
+	})
+	return lagging
+}
+
+// Start periodically records the number of lagging Envoys
+// until stop is closed. It fulfills the g.Start contract.
+func (t *VersionTracker) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.recordLagging()
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// recordLagging records the number of distinct
+// Envoy nodes that are lagging.
+func (t *VersionTracker) recordLagging() {
+	if t.Metrics == nil {
+		return
+	}
+	nodes := map[string]bool{}
+	for _, e := range t.Lagging() {
+		nodes[e.NodeID] = true
+	}
+	t.Metrics.SetXDSLaggingEnvoys(len(nodes))
+}
+
+func (t *VersionTracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	report := LaggingReport{
+		Threshold: t.Threshold.String(),
+		Envoys:    t.Lagging(),
+	}
+	if err := enc.Encode(report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (t *VersionTracker) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,79 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionTracker(t *testing.T) {
+	now := time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC)
+	tracker := &VersionTracker{
+		Threshold: time.Minute,
+		now:       func() time.Time { return now },
+	}
+
+	request := func(streamID int64, version string) {
+		t.Helper()
+		require.NoError(t, tracker.OnStreamRequest(streamID, &envoy_service_discovery_v3.DiscoveryRequest{
+			VersionInfo: version,
+			Node:        &envoy_config_core_v3.Node{Id: "envoy-" + string(rune('a'+streamID))},
+			TypeUrl:     resource.ClusterType,
+		}))
+	}
+
+	require.NoError(t, tracker.Generate("1", nil))
+	require.NoError(t, tracker.OnStreamOpen(context.Background(), 0, resource.ClusterType))
+	require.NoError(t, tracker.OnStreamOpen(context.Background(), 1, resource.ClusterType))
+	request(0, "")
+	request(1, "")
+
+	// Both Envoys apply the first snapshot.
+	now = now.Add(time.Second)
+	request(0, "1")
+	request(1, "1")
+	assert.Empty(t, tracker.Lagging())
+
+	// Only envoy-a applies the second snapshot.
+	require.NoError(t, tracker.Generate("2", nil))
+	request(0, "2")
+	now = now.Add(30 * time.Second)
+	assert.Empty(t, tracker.Lagging())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, []LaggingEnvoy{{
+		NodeID:         "envoy-b",
+		TypeURL:        resource.ClusterType,
+		AppliedVersion: "1",
+		LatestVersion:  "2",
+		Behind:         90,
+	}}, tracker.Lagging())
+
+	// A newer snapshot does not reset how long envoy-b is behind.
+	require.NoError(t, tracker.Generate("3", nil))
+	request(0, "3")
+	assert.Len(t, tracker.Lagging(), 1)
+
+	// Closed streams are forgotten.
+	tracker.OnStreamClosed(1)
+	assert.Empty(t, tracker.Lagging())
+}
@@ -15,13 +15,15 @@ package xdscache
 
 import (
 	"math"
-	"reflect"
 	"strconv"
 	"sync"
 
 	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/metrics"
+	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/sirupsen/logrus"
 )
 
@@ -29,6 +31,12 @@ type Snapshotter interface {
 	Generate(version string, resources map[envoy_types.ResponseType][]envoy_types.Resource) error
 }
 
+// ProfileSnapshotter is implemented by Snapshotters that can serve
+// the resources of a listener profile to the Envoy nodes bound to it.
+type ProfileSnapshotter interface {
+	GenerateProfile(profile string, version string, resources map[envoy_types.ResponseType][]envoy_types.Resource) error
+}
+
 // SnapshotHandler implements the xDS snapshot cache
 // by responding to the OnChange() event causing a new
 // snapshot to be created.
@@ -39,12 +47,31 @@ type SnapshotHandler struct {
 	// snapshotVersion holds the current version of the snapshot.
 	snapshotVersion int64
 
+	// profiles holds the listener caches of the listener profiles,
+	// indexed by profile name.
+	profiles map[string]ResourceCache
+
 	snapshotters []Snapshotter
 	snapLock     sync.Mutex
 
+	// Metrics, if not nil, records the size of the
+	// resources of each snapshot.
+	Metrics *metrics.Metrics
+
 	logrus.FieldLogger
 }
 
+// resourceTypeNames are the names of the xDS resource
+// types, as recorded by the metrics.
+var resourceTypeNames = map[envoy_types.ResponseType]string{
+	envoy_types.Endpoint: "endpoint",
+	envoy_types.Cluster:  "cluster",
+	envoy_types.Route:    "route",
+	envoy_types.Listener: "listener",
+	envoy_types.Secret:   "secret",
+	envoy_types.Runtime:  "runtime",
+}
+
 // NewSnapshotHandler returns an instance of SnapshotHandler.
 func NewSnapshotHandler(resources []ResourceCache, logger logrus.FieldLogger) *SnapshotHandler {
 	return &SnapshotHandler{
@@ -60,6 +87,19 @@ func (s *SnapshotHandler) AddSnapshotter(snap Snapshotter) {
 	s.snapshotters = append(s.snapshotters, snap)
 }
 
+// AddProfile registers the cache holding the listeners served to the
+// Envoy nodes bound to the named listener profile. All other resources
+// are shared with the nodes that are not bound to a profile.
+func (s *SnapshotHandler) AddProfile(profile string, listeners ResourceCache) {
+	s.snapLock.Lock()
+	defer s.snapLock.Unlock()
+
+	if s.profiles == nil {
+		s.profiles = map[string]ResourceCache{}
+	}
+	s.profiles[profile] = listeners
+}
+
 // Refresh is called when the EndpointsTranslator updates values
 // in its cache.
 func (s *SnapshotHandler) Refresh() {
@@ -83,6 +123,11 @@ func (s *SnapshotHandler) generateNewSnapshot() {
 		envoy_types.Route:    asResources(s.resources[envoy_types.Route].Contents()),
 		envoy_types.Listener: asResources(s.resources[envoy_types.Listener].Contents()),
 		envoy_types.Secret:   asResources(s.resources[envoy_types.Secret].Contents()),
+		envoy_types.Runtime:  asResources(s.resources[envoy_types.Runtime].Contents()),
+	}
+
+	if s.Metrics != nil {
+		s.Metrics.SetXDSCacheBytes(resourceSizes(resources))
 	}
 
 	s.snapLock.Lock()
@@ -93,6 +138,24 @@ func (s *SnapshotHandler) generateNewSnapshot() {
 			s.Errorf("failed to generate snapshot version %q: %s", version, err)
 		}
 	}
+
+	for profile, listeners := range s.profiles {
+		profileResources := make(map[envoy_types.ResponseType][]envoy_types.Resource, len(resources))
+		for typ, res := range resources {
+			profileResources[typ] = res
+		}
+		profileResources[envoy_types.Listener] = asResources(listeners.Contents())
+
+		for _, snap := range s.snapshotters {
+			ps, ok := snap.(ProfileSnapshotter)
+			if !ok {
+				continue
+			}
+			if err := ps.GenerateProfile(profile, version, profileResources); err != nil {
+				s.Errorf("failed to generate snapshot version %q for listener profile %q: %s", version, profile, err)
+			}
+		}
+	}
 }
 
 // newSnapshotVersion increments the current snapshotVersion
@@ -109,24 +172,37 @@ func (s *SnapshotHandler) newSnapshotVersion() string {
 	return strconv.FormatInt(s.snapshotVersion, 10)
 }
 
-// asResources casts the given slice of values (that implement the envoy_types.Resource
+// asResources casts the given slice of messages (that implement the envoy_types.Resource
 // interface) to a slice of envoy_types.Resource. If the length of the slice is 0, it
-// returns nil.
-func asResources(messages interface{}) []envoy_types.Resource {
-	v := reflect.ValueOf(messages)
-	if v.Len() == 0 {
+// returns nil. The messages slice is handed back to protobuf.PutMessages for reuse.
+func asResources(messages []proto.Message) []envoy_types.Resource {
+	if len(messages) == 0 {
 		return nil
 	}
 
-	protos := make([]envoy_types.Resource, v.Len())
-
-	for i := range protos {
-		protos[i] = v.Index(i).Interface().(envoy_types.Resource)
+	protos := make([]envoy_types.Resource, len(messages))
+	for i, m := range messages {
+		protos[i] = m
 	}
 
+	protobuf.PutMessages(messages)
 	return protos
 }
 
+// resourceSizes returns the size in bytes of the
+// encoded resources, by resource type name.
+func resourceSizes(resources map[envoy_types.ResponseType][]envoy_types.Resource) map[string]int {
+	sizes := make(map[string]int, len(resources))
+	for typ, res := range resources {
+		size := 0
+		for _, r := range res {
+			size += proto.Size(r)
+		}
+		sizes[resourceTypeNames[typ]] = size
+	}
+	return sizes
+}
+
 // parseResources converts an []ResourceCache to a map[envoy_types.ResponseType]ResourceCache
 // for faster indexing when creating new snapshots.
 func parseResources(resources []ResourceCache) map[envoy_types.ResponseType]ResourceCache {
@@ -144,6 +220,8 @@ func parseResources(resources []ResourceCache) map[envoy_types.ResponseType]Reso
 			resourceMap[envoy_types.Secret] = r
 		case resource.EndpointType:
 			resourceMap[envoy_types.Endpoint] = r
+		case resource.RuntimeType:
+			resourceMap[envoy_types.Runtime] = r
 		}
 	}
 	return resourceMap
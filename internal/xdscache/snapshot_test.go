@@ -17,6 +17,9 @@ import (
 	"math"
 	"testing"
 
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,3 +58,17 @@ func TestGetNewSnapshotVersion(t *testing.T) {
 		want:            "1",
 	})
 }
+
+func TestResourceSizes(t *testing.T) {
+	a := &envoy_cluster_v3.Cluster{Name: "default/a/80/da39a3ee5e"}
+	b := &envoy_cluster_v3.Cluster{Name: "default/b/443/da39a3ee5e"}
+
+	got := resourceSizes(map[envoy_types.ResponseType][]envoy_types.Resource{
+		envoy_types.Cluster: {a, b},
+		envoy_types.Route:   nil,
+	})
+	assert.Equal(t, map[string]int{
+		"cluster": proto.Size(a) + proto.Size(b),
+		"route":   0,
+	}, got)
+}
@@ -16,6 +16,7 @@ package v3
 import (
 	"sort"
 	"sync"
+	"time"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
@@ -33,6 +34,30 @@ type ClusterCache struct {
 	mu     sync.Mutex
 	values map[string]*envoy_cluster_v3.Cluster
 	contour.Cond
+
+	// ConnectTimeout is the timeout for new connections to
+	// upstream clusters. If zero, the 2s connect timeout set
+	// by envoy_v3.Cluster is used.
+	ConnectTimeout time.Duration
+
+	// PreserveHeaderCase preserves the case of the HTTP/1
+	// header names of requests sent to upstream clusters.
+	PreserveHeaderCase bool
+
+	// HealthyPanicThreshold is the percentage of healthy hosts of
+	// an upstream cluster below which Envoy balances requests across
+	// all hosts. If zero, panic mode is disabled.
+	HealthyPanicThreshold uint32
+
+	// ZoneAwareRoutingMinClusterSize is the minimum number of hosts
+	// of an upstream cluster for zone aware routing to be used. If
+	// zero, Envoy's default applies.
+	ZoneAwareRoutingMinClusterSize uint64
+
+	// StaticClusters are clusters, not built from the DAG, that
+	// the cache always contains, e.g. for the sinks and collectors
+	// referenced by the Envoy configuration.
+	StaticClusters []*envoy_cluster_v3.Cluster
 }
 
 // Update replaces the contents of the cache with the supplied map.
@@ -48,7 +73,7 @@ func (c *ClusterCache) Update(v map[string]*envoy_cluster_v3.Cluster) {
 func (c *ClusterCache) Contents() []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []*envoy_cluster_v3.Cluster
+	values := make([]*envoy_cluster_v3.Cluster, 0, len(c.values))
 	for _, v := range c.values {
 		values = append(values, v)
 	}
@@ -59,7 +84,7 @@ func (c *ClusterCache) Contents() []proto.Message {
 func (c *ClusterCache) Query(names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []*envoy_cluster_v3.Cluster
+	values := make([]*envoy_cluster_v3.Cluster, 0, len(names))
 	for _, n := range names {
 		// if the cluster is not registered we cannot return
 		// a blank cluster because each cluster has a required
@@ -92,5 +117,31 @@ func (c *ClusterCache) OnChange(root *dag.DAG) {
 		}
 	}
 
+	// The static clusters are copied, since the
+	// settings below modify the clusters in place.
+	for _, sc := range c.StaticClusters {
+		if _, ok := clusters[sc.Name]; !ok {
+			clusters[sc.Name] = proto.Clone(sc).(*envoy_cluster_v3.Cluster)
+		}
+	}
+
+	if c.ConnectTimeout > 0 {
+		for _, cluster := range clusters {
+			cluster.ConnectTimeout = protobuf.Duration(c.ConnectTimeout)
+		}
+	}
+
+	if c.PreserveHeaderCase {
+		for _, cluster := range clusters {
+			envoy_v3.PreserveHeaderCase(cluster)
+		}
+	}
+
+	if c.HealthyPanicThreshold > 0 || c.ZoneAwareRoutingMinClusterSize > 0 {
+		for _, cluster := range clusters {
+			envoy_v3.CommonLBConfig(cluster, c.HealthyPanicThreshold, c.ZoneAwareRoutingMinClusterSize)
+		}
+	}
+
 	c.Update(clusters)
 }
@@ -825,6 +825,67 @@ func TestClusterVisit(t *testing.T) {
 	}
 }
 
+func TestClusterVisitConnectTimeout(t *testing.T) {
+	cc := ClusterCache{
+		ConnectTimeout: 5 * time.Second,
+	}
+	cc.OnChange(buildDAG(t,
+		&networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: "default",
+			},
+			Spec: networking_v1.IngressSpec{
+				DefaultBackend: backend("kuard", 443),
+			},
+		},
+		service("default", "kuard",
+			v1.ServicePort{
+				Protocol:   "TCP",
+				Port:       443,
+				TargetPort: intstr.FromInt(8443),
+			},
+		),
+	))
+
+	want := clustermap(
+		&envoy_cluster_v3.Cluster{
+			Name:                 "default/kuard/443/da39a3ee5e",
+			AltStatName:          "default_kuard_443",
+			ClusterDiscoveryType: envoy_v3.ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+			EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+				EdsConfig:   envoy_v3.ConfigSource("contour"),
+				ServiceName: "default/kuard",
+			},
+			ConnectTimeout: protobuf.Duration(5 * time.Second),
+		})
+
+	protobuf.ExpectEqual(t, want, cc.values)
+}
+
+func TestClusterVisitStaticClusters(t *testing.T) {
+	statsd := envoy_v3.StaticCluster("statsd", "", envoy_v3.SocketAddress("10.0.0.1", 8125))
+	cc := ClusterCache{
+		ConnectTimeout: 5 * time.Second,
+		StaticClusters: []*envoy_cluster_v3.Cluster{statsd},
+	}
+	cc.OnChange(buildDAG(t))
+
+	want := clustermap(
+		&envoy_cluster_v3.Cluster{
+			Name:                 "statsd",
+			AltStatName:          "statsd",
+			ClusterDiscoveryType: envoy_v3.ClusterDiscoveryType(envoy_cluster_v3.Cluster_STATIC),
+			LoadAssignment:       envoy_v3.ClusterLoadAssignment("statsd", envoy_v3.SocketAddress("10.0.0.1", 8125)),
+			ConnectTimeout:       protobuf.Duration(5 * time.Second),
+		})
+
+	protobuf.ExpectEqual(t, want, cc.values)
+
+	// The configured cluster is not modified by the cache.
+	protobuf.ExpectEqual(t, protobuf.Duration(2*time.Second), statsd.ConnectTimeout)
+}
+
 func service(ns, name string, ports ...v1.ServicePort) *v1.Service {
 	return serviceWithAnnotations(ns, name, nil, ports...)
 }
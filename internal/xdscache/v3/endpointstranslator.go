@@ -17,7 +17,9 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/golang/protobuf/proto"
@@ -29,6 +31,7 @@ import (
 	"github.com/projectcontour/contour/internal/sorter"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	discovery_v1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 )
@@ -79,6 +82,80 @@ func RecalculateEndpoints(port v1.ServicePort, ep *v1.Endpoints) []*LoadBalancin
 	return lb
 }
 
+// RecalculateEndpointSlices generates a slice of LoadBalancingEndpoint
+// resources by matching the given service port to the given EndpointSlices.
+// Since a dual-stack Service has an EndpointSlice for each address family,
+// the result includes both its IPv4 and IPv6 endpoints.
+func RecalculateEndpointSlices(port v1.ServicePort, slices []*discovery_v1.EndpointSlice) []*LoadBalancingEndpoint {
+	type endpoint struct {
+		ip   string
+		port int
+	}
+
+	var endpoints []endpoint
+	seen := map[endpoint]bool{}
+
+	for _, s := range slices {
+		// FQDN endpoints cannot be used as socket addresses.
+		if s.AddressType == discovery_v1.AddressTypeFQDN {
+			continue
+		}
+
+		for _, p := range s.Ports {
+			if p.Port == nil {
+				continue
+			}
+
+			if p.Protocol != nil && port.Protocol != *p.Protocol && *p.Protocol != v1.ProtocolTCP {
+				// NOTE: we only support "TCP", which is the default.
+				continue
+			}
+
+			// As with Endpoints, an unnamed port must be
+			// the only Service port.
+			if port.Name != "" && (p.Name == nil || port.Name != *p.Name) {
+				continue
+			}
+
+			for _, e := range s.Endpoints {
+				// A nil ready condition means the endpoint is ready.
+				if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+					continue
+				}
+
+				// Addresses of an endpoint are fungible,
+				// so only use the first one.
+				if len(e.Addresses) == 0 {
+					continue
+				}
+
+				// Endpoints can briefly appear in more than
+				// one slice while they are being updated.
+				ep := endpoint{ip: e.Addresses[0], port: int(*p.Port)}
+				if seen[ep] {
+					continue
+				}
+				seen[ep] = true
+				endpoints = append(endpoints, ep)
+			}
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].ip != endpoints[j].ip {
+			return endpoints[i].ip < endpoints[j].ip
+		}
+		return endpoints[i].port < endpoints[j].port
+	})
+
+	var lb []*LoadBalancingEndpoint
+	for _, ep := range endpoints {
+		lb = append(lb, envoy_v3.LBEndpoint(envoy_v3.SocketAddress(ep.ip, ep.port)))
+	}
+
+	return lb
+}
+
 // EndpointsCache is a cache of Endpoint and ServiceCluster objects.
 type EndpointsCache struct {
 	mu sync.Mutex // Protects all fields.
@@ -95,6 +172,31 @@ type EndpointsCache struct {
 
 	// Cache of endpoints, indexed by name.
 	endpoints map[types.NamespacedName]*v1.Endpoints
+
+	// Cache of endpoint slices, indexed by the name of their
+	// Service and then by their own name. When a Service has
+	// endpoint slices, they are used instead of its endpoints.
+	endpointSlices map[types.NamespacedName]map[string]*discovery_v1.EndpointSlice
+
+	// Cache of the endpoints of remote clusters, indexed by the
+	// name of the cluster and then by name. They are added to
+	// the endpoints of the Service with the same name.
+	remoteEndpoints map[string]map[types.NamespacedName]*v1.Endpoints
+
+	// Terminating endpoint addresses, indexed by address. The
+	// endpoints at these addresses are draining until they are
+	// removed.
+	terminating map[string]terminatingAddress
+}
+
+// terminatingAddress is the address of a terminating Pod.
+type terminatingAddress struct {
+	// pod is the name of the Pod with the address.
+	pod types.NamespacedName
+
+	// removeAt is the time that endpoints at the address
+	// stop draining and are removed.
+	removeAt time.Time
 }
 
 // Recalculate regenerates all the ClusterLoadAssignments from the
@@ -124,7 +226,17 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoad
 		// attach them as a new LocalityEndpoints resource2.
 		for _, w := range cluster.Services {
 			n := types.NamespacedName{Namespace: w.ServiceNamespace, Name: w.ServiceName}
-			if lb := RecalculateEndpoints(w.ServicePort, c.endpoints[n]); lb != nil {
+			if lb := c.recalculateService(n, w.ServicePort); lb != nil {
+				// Health checks of the endpoints are sent to
+				// a different port, if one is set.
+				if w.HealthCheckPort > 0 {
+					for _, ep := range lb {
+						ep.GetEndpoint().HealthCheckConfig = &envoy_endpoint_v3.Endpoint_HealthCheckConfig{
+							PortValue: w.HealthCheckPort,
+						}
+					}
+				}
+
 				// Append the new set of endpoints. Users are allowed to set the load
 				// balancing weight to 0, which we reflect to Envoy as nil in order to
 				// assign no load to that locality.
@@ -133,9 +245,16 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoad
 					&LocalityEndpoints{
 						LbEndpoints:         lb,
 						LoadBalancingWeight: protobuf.UInt32OrNil(w.Weight),
+						Priority:            w.Priority,
 					},
 				)
 			}
+
+			// Endpoints at lower priorities are backups that
+			// only receive traffic once the others fail.
+			if w.Priority > 0 {
+				cla.Policy = envoy_v3.FailoverPolicy()
+			}
 		}
 
 		assignments[cla.ClusterName] = &cla
@@ -145,6 +264,65 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoad
 	return assignments
 }
 
+// recalculateService returns the LoadBalancingEndpoints of the named
+// Service's port, taken from its endpoint slices if it has any.
+func (c *EndpointsCache) recalculateService(name types.NamespacedName, port v1.ServicePort) []*LoadBalancingEndpoint {
+	slices, ok := c.endpointSlices[name]
+	if !ok {
+		return append(c.drain(RecalculateEndpoints(port, c.endpoints[name])), c.recalculateRemoteService(name, port)...)
+	}
+
+	sorted := make([]*discovery_v1.EndpointSlice, 0, len(slices))
+	for _, s := range slices {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	return append(c.drain(RecalculateEndpointSlices(port, sorted)), c.recalculateRemoteService(name, port)...)
+}
+
+// recalculateRemoteService returns the LoadBalancingEndpoints of the
+// named Service's port in each of the remote clusters, ordered by the
+// name of the cluster.
+func (c *EndpointsCache) recalculateRemoteService(name types.NamespacedName, port v1.ServicePort) []*LoadBalancingEndpoint {
+	clusters := make([]string, 0, len(c.remoteEndpoints))
+	for cluster := range c.remoteEndpoints {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	var lb []*LoadBalancingEndpoint
+	for _, cluster := range clusters {
+		lb = append(lb, RecalculateEndpoints(port, c.remoteEndpoints[cluster][name])...)
+	}
+
+	return lb
+}
+
+// drain marks the endpoints in lb at terminating addresses as draining,
+// or removes them if they have drained for long enough.
+func (c *EndpointsCache) drain(lb []*LoadBalancingEndpoint) []*LoadBalancingEndpoint {
+	if len(c.terminating) == 0 {
+		return lb
+	}
+
+	now := time.Now()
+
+	var drained []*LoadBalancingEndpoint
+	for _, ep := range lb {
+		t, ok := c.terminating[ep.GetEndpoint().GetAddress().GetSocketAddress().GetAddress()]
+		switch {
+		case !ok:
+			drained = append(drained, ep)
+		case now.Before(t.removeAt):
+			ep.HealthStatus = envoy_core_v3.HealthStatus_DRAINING
+			drained = append(drained, ep)
+		}
+	}
+
+	return drained
+}
+
 // SetClusters replaces the cache of ServiceCluster resources. All
 // the added clusters will be marked stale.
 func (c *EndpointsCache) SetClusters(clusters []*dag.ServiceCluster) error {
@@ -227,6 +405,224 @@ func (c *EndpointsCache) DeleteEndpoint(ep *v1.Endpoints) bool {
 	return false
 }
 
+// UpdateRemoteEndpoint adds ep of the named remote cluster to the
+// cache, or replaces it if it is already cached. Any ServiceClusters
+// that are backed by the Service of the same name become stale.
+// Returns a boolean indicating whether any ServiceClusters use ep or not.
+func (c *EndpointsCache) UpdateRemoteEndpoint(cluster string, ep *v1.Endpoints) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := k8s.NamespacedNameOf(ep)
+	if c.remoteEndpoints[cluster] == nil {
+		c.remoteEndpoints[cluster] = map[types.NamespacedName]*v1.Endpoints{}
+	}
+	c.remoteEndpoints[cluster][name] = ep.DeepCopy()
+
+	if affected := c.services[name]; len(affected) > 0 {
+		c.stale = append(c.stale, affected...)
+		return true
+	}
+
+	return false
+}
+
+// DeleteRemoteEndpoint deletes ep of the named remote cluster from
+// the cache. Any ServiceClusters that are backed by the Service of
+// the same name become stale. Returns a boolean indicating whether
+// any ServiceClusters use ep or not.
+func (c *EndpointsCache) DeleteRemoteEndpoint(cluster string, ep *v1.Endpoints) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := k8s.NamespacedNameOf(ep)
+	delete(c.remoteEndpoints[cluster], name)
+	if len(c.remoteEndpoints[cluster]) == 0 {
+		delete(c.remoteEndpoints, cluster)
+	}
+
+	if affected := c.services[name]; len(affected) > 0 {
+		c.stale = append(c.stale, affected...)
+		return true
+	}
+
+	return false
+}
+
+// UpdateEndpointSlice adds es to the cache, or replaces it if it is
+// already cached. Any ServiceClusters that are backed by the Service
+// that es belongs to become stale. Returns a boolean indicating whether
+// any ServiceClusters use es or not.
+func (c *EndpointsCache) UpdateEndpointSlice(es *discovery_v1.EndpointSlice) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name, ok := endpointSliceServiceName(es)
+	if !ok {
+		return false
+	}
+
+	if c.endpointSlices[name] == nil {
+		c.endpointSlices[name] = map[string]*discovery_v1.EndpointSlice{}
+	}
+	c.endpointSlices[name][es.Name] = es.DeepCopy()
+
+	// If any service clusters include this endpoint slice, mark them
+	// all as stale.
+	if affected := c.services[name]; len(affected) > 0 {
+		c.stale = append(c.stale, affected...)
+		return true
+	}
+
+	return false
+}
+
+// DeleteEndpointSlice deletes es from the cache. Any ServiceClusters
+// that are backed by the Service that es belongs to become stale. Returns
+// a boolean indicating whether any ServiceClusters use es or not.
+func (c *EndpointsCache) DeleteEndpointSlice(es *discovery_v1.EndpointSlice) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name, ok := endpointSliceServiceName(es)
+	if !ok {
+		return false
+	}
+
+	delete(c.endpointSlices[name], es.Name)
+	if len(c.endpointSlices[name]) == 0 {
+		delete(c.endpointSlices, name)
+	}
+
+	// If any service clusters include this endpoint slice, mark them
+	// all as stale.
+	if affected := c.services[name]; len(affected) > 0 {
+		c.stale = append(c.stale, affected...)
+		return true
+	}
+
+	return false
+}
+
+// SetTerminating records that the addresses of pod are terminating,
+// and that endpoints at those addresses are removed at removeAt. Any
+// ServiceClusters that are backed by a Service with endpoints at those
+// addresses become stale. Returns a boolean indicating whether any
+// ServiceClusters have endpoints at the addresses or not.
+func (c *EndpointsCache) SetTerminating(pod *v1.Pod, removeAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := k8s.NamespacedNameOf(pod)
+	addresses := podAddresses(pod)
+	for _, addr := range addresses {
+		c.terminating[addr] = terminatingAddress{pod: name, removeAt: removeAt}
+	}
+
+	return c.markAddressesStale(addresses)
+}
+
+// DeleteTerminating forgets the terminating addresses of pod, since
+// they may now be assigned to other Pods. Any ServiceClusters that are
+// backed by a Service with endpoints at those addresses become stale.
+// Returns a boolean indicating whether any ServiceClusters have
+// endpoints at the addresses or not.
+func (c *EndpointsCache) DeleteTerminating(pod *v1.Pod) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := k8s.NamespacedNameOf(pod)
+
+	var addresses []string
+	for _, addr := range podAddresses(pod) {
+		if t, ok := c.terminating[addr]; ok && t.pod == name {
+			delete(c.terminating, addr)
+			addresses = append(addresses, addr)
+		}
+	}
+
+	return c.markAddressesStale(addresses)
+}
+
+// Drained marks the ServiceClusters that have endpoints at the
+// terminating addresses of pod as stale, so that endpoints which
+// have finished draining are removed. Returns a boolean indicating
+// whether any ServiceClusters have endpoints at the addresses or not.
+func (c *EndpointsCache) Drained(pod *v1.Pod) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.markAddressesStale(podAddresses(pod))
+}
+
+// markAddressesStale marks the ServiceClusters backed by Services
+// with endpoints at any of addresses as stale. Returns a boolean
+// indicating whether any ServiceClusters were marked or not.
+func (c *EndpointsCache) markAddressesStale(addresses []string) bool {
+	if len(addresses) == 0 {
+		return false
+	}
+
+	wanted := map[string]bool{}
+	for _, addr := range addresses {
+		wanted[addr] = true
+	}
+
+	names := map[types.NamespacedName]bool{}
+	for name, ep := range c.endpoints {
+		for _, s := range ep.Subsets {
+			for _, a := range s.Addresses {
+				if wanted[a.IP] {
+					names[name] = true
+				}
+			}
+		}
+	}
+	for name, slices := range c.endpointSlices {
+		for _, s := range slices {
+			for _, e := range s.Endpoints {
+				if len(e.Addresses) > 0 && wanted[e.Addresses[0]] {
+					names[name] = true
+				}
+			}
+		}
+	}
+
+	stale := false
+	for name := range names {
+		if affected := c.services[name]; len(affected) > 0 {
+			c.stale = append(c.stale, affected...)
+			stale = true
+		}
+	}
+
+	return stale
+}
+
+// podAddresses returns the IP addresses of pod.
+func podAddresses(pod *v1.Pod) []string {
+	var addresses []string
+	for _, ip := range pod.Status.PodIPs {
+		addresses = append(addresses, ip.IP)
+	}
+	if len(addresses) == 0 && pod.Status.PodIP != "" {
+		addresses = append(addresses, pod.Status.PodIP)
+	}
+
+	return addresses
+}
+
+// endpointSliceServiceName returns the name of the Service that
+// owns es, or false if es is not owned by a Service.
+func endpointSliceServiceName(es *discovery_v1.EndpointSlice) (types.NamespacedName, bool) {
+	service := es.Labels[discovery_v1.LabelServiceName]
+	if service == "" {
+		return types.NamespacedName{}, false
+	}
+
+	return types.NamespacedName{Namespace: es.Namespace, Name: service}, true
+}
+
 // NewEndpointsTranslator allocates a new endpoints translator.
 func NewEndpointsTranslator(log logrus.FieldLogger) *EndpointsTranslator {
 	return &EndpointsTranslator{
@@ -234,9 +630,12 @@ func NewEndpointsTranslator(log logrus.FieldLogger) *EndpointsTranslator {
 		FieldLogger: log,
 		entries:     map[string]*envoy_endpoint_v3.ClusterLoadAssignment{},
 		cache: EndpointsCache{
-			stale:     nil,
-			services:  map[types.NamespacedName][]*dag.ServiceCluster{},
-			endpoints: map[types.NamespacedName]*v1.Endpoints{},
+			stale:           nil,
+			services:        map[types.NamespacedName][]*dag.ServiceCluster{},
+			endpoints:       map[types.NamespacedName]*v1.Endpoints{},
+			endpointSlices:  map[types.NamespacedName]map[string]*discovery_v1.EndpointSlice{},
+			remoteEndpoints: map[string]map[types.NamespacedName]*v1.Endpoints{},
+			terminating:     map[string]terminatingAddress{},
 		},
 	}
 }
@@ -247,6 +646,10 @@ type EndpointsTranslator struct {
 	// Observer notifies when the endpoints cache has been updated.
 	Observer contour.Observer
 
+	// DrainDelay is how long the endpoints of a terminating Pod
+	// are kept draining before they are removed.
+	DrainDelay time.Duration
+
 	contour.Cond
 	logrus.FieldLogger
 
@@ -348,6 +751,23 @@ func (e *EndpointsTranslator) OnAdd(obj interface{}) {
 		if e.Observer != nil {
 			e.Observer.Refresh()
 		}
+	case *discovery_v1.EndpointSlice:
+		if !e.cache.UpdateEndpointSlice(obj) {
+			return
+		}
+
+		e.WithField("endpointslice", k8s.NamespacedNameOf(obj)).Debug("EndpointSlice is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate())
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	case *v1.Pod:
+		if obj.DeletionTimestamp == nil {
+			return
+		}
+
+		e.terminating(obj)
 	default:
 		e.Errorf("OnAdd unexpected type %T: %#v", obj, obj)
 	}
@@ -385,6 +805,48 @@ func (e *EndpointsTranslator) OnUpdate(oldObj, newObj interface{}) {
 		if e.Observer != nil {
 			e.Observer.Refresh()
 		}
+	case *discovery_v1.EndpointSlice:
+		oldObj, ok := oldObj.(*discovery_v1.EndpointSlice)
+		if !ok {
+			e.Errorf("OnUpdate endpointslice %#v received invalid oldObj %T; %#v", newObj, oldObj, oldObj)
+			return
+		}
+
+		if oldObj == newObj {
+			return
+		}
+
+		// If there are no endpoints in this object, and the old
+		// object also had zero endpoints, ignore this update
+		// to avoid sending a noop notification to watchers.
+		if len(oldObj.Endpoints) == 0 && len(newObj.Endpoints) == 0 {
+			return
+		}
+
+		if !e.cache.UpdateEndpointSlice(newObj) {
+			return
+		}
+
+		e.WithField("endpointslice", k8s.NamespacedNameOf(newObj)).Debug("EndpointSlice is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate())
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	case *v1.Pod:
+		oldObj, ok := oldObj.(*v1.Pod)
+		if !ok {
+			e.Errorf("OnUpdate pod %#v received invalid oldObj %T; %#v", newObj, oldObj, oldObj)
+			return
+		}
+
+		// Pods are updated often, but only the start of their
+		// termination matters here.
+		if oldObj.DeletionTimestamp != nil || newObj.DeletionTimestamp == nil {
+			return
+		}
+
+		e.terminating(newObj)
 	default:
 		e.Errorf("OnUpdate unexpected type %T: %#v", newObj, newObj)
 	}
@@ -403,6 +865,28 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 		if e.Observer != nil {
 			e.Observer.Refresh()
 		}
+	case *discovery_v1.EndpointSlice:
+		if !e.cache.DeleteEndpointSlice(obj) {
+			return
+		}
+
+		e.WithField("endpointslice", k8s.NamespacedNameOf(obj)).Debug("EndpointSlice was in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate())
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	case *v1.Pod:
+		if !e.cache.DeleteTerminating(obj) {
+			return
+		}
+
+		e.WithField("pod", k8s.NamespacedNameOf(obj)).Debug("Pod was terminating, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate())
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
 	case cache.DeletedFinalStateUnknown:
 		e.OnDelete(obj.Obj) // recurse into ourselves with the tombstoned value
 	default:
@@ -410,6 +894,112 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 	}
 }
 
+// terminating drains the endpoints of pod, which has started terminating,
+// and removes them once DrainDelay has passed.
+func (e *EndpointsTranslator) terminating(pod *v1.Pod) {
+	if e.cache.SetTerminating(pod, time.Now().Add(e.DrainDelay)) {
+		e.WithField("pod", k8s.NamespacedNameOf(pod)).Debug("Pod is terminating, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate())
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	}
+
+	if e.DrainDelay <= 0 {
+		return
+	}
+
+	time.AfterFunc(e.DrainDelay, func() {
+		if !e.cache.Drained(pod) {
+			return
+		}
+
+		e.WithField("pod", k8s.NamespacedNameOf(pod)).Debug("Pod has drained, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate())
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	})
+}
+
+// RemoteClusterHandler returns a handler of the Endpoints of the named
+// remote cluster. The endpoints are added to those of the Services with
+// the same namespace and name in this cluster.
+func (e *EndpointsTranslator) RemoteClusterHandler(cluster string) cache.ResourceEventHandler {
+	return &remoteEndpointsHandler{
+		cluster:    cluster,
+		translator: e,
+	}
+}
+
+// remoteEndpointsHandler adds the Endpoints of a remote cluster
+// to the cache of an EndpointsTranslator.
+type remoteEndpointsHandler struct {
+	cluster    string
+	translator *EndpointsTranslator
+}
+
+func (h *remoteEndpointsHandler) OnAdd(obj interface{}) {
+	switch obj := obj.(type) {
+	case *v1.Endpoints:
+		h.recalculate(obj, h.translator.cache.UpdateRemoteEndpoint(h.cluster, obj))
+	default:
+		h.translator.Errorf("OnAdd unexpected type %T: %#v", obj, obj)
+	}
+}
+
+func (h *remoteEndpointsHandler) OnUpdate(oldObj, newObj interface{}) {
+	switch newObj := newObj.(type) {
+	case *v1.Endpoints:
+		oldObj, ok := oldObj.(*v1.Endpoints)
+		if !ok {
+			h.translator.Errorf("OnUpdate endpoints %#v received invalid oldObj %T; %#v", newObj, oldObj, oldObj)
+			return
+		}
+
+		if oldObj == newObj {
+			return
+		}
+
+		if len(oldObj.Subsets) == 0 && len(newObj.Subsets) == 0 {
+			return
+		}
+
+		h.recalculate(newObj, h.translator.cache.UpdateRemoteEndpoint(h.cluster, newObj))
+	default:
+		h.translator.Errorf("OnUpdate unexpected type %T: %#v", newObj, newObj)
+	}
+}
+
+func (h *remoteEndpointsHandler) OnDelete(obj interface{}) {
+	switch obj := obj.(type) {
+	case *v1.Endpoints:
+		h.recalculate(obj, h.translator.cache.DeleteRemoteEndpoint(h.cluster, obj))
+	case cache.DeletedFinalStateUnknown:
+		h.OnDelete(obj.Obj) // recurse into ourselves with the tombstoned value
+	default:
+		h.translator.Errorf("OnDelete unexpected type %T: %#v", obj, obj)
+	}
+}
+
+// recalculate recalculates the ClusterLoadAssignments if the
+// Endpoints are in use by a ServiceCluster.
+func (h *remoteEndpointsHandler) recalculate(ep *v1.Endpoints, inUse bool) {
+	if !inUse {
+		return
+	}
+
+	e := h.translator
+	e.WithField("cluster", h.cluster).WithField("endpoint", k8s.NamespacedNameOf(ep)).Debug("Remote Endpoint is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+	e.Merge(e.cache.Recalculate())
+	e.Notify()
+	if e.Observer != nil {
+		e.Observer.Refresh()
+	}
+}
+
 // Contents returns a copy of the contents of the cache.
 func (e *EndpointsTranslator) Contents() []proto.Message {
 	e.mu.Lock()
@@ -432,10 +1022,15 @@ func (e *EndpointsTranslator) Query(names []string) []proto.Message {
 	for _, n := range names {
 		v, ok := e.entries[n]
 		if !ok {
+			// Leave unknown clusters out of the response rather than
+			// sending an empty load assignment. Envoy keeps such a
+			// cluster warming, and so holds back the listeners that
+			// depend on it, until the real load assignment arrives
+			// or its initial fetch timeout expires. An empty load
+			// assignment would finish warming with no endpoints,
+			// and the cluster would serve 503s until the next update.
 			e.Debugf("no cache entry for %q", n)
-			v = &envoy_endpoint_v3.ClusterLoadAssignment{
-				ClusterName: n,
-			}
+			continue
 		}
 		values = append(values, v)
 	}
@@ -15,7 +15,9 @@ package v3
 
 import (
 	"testing"
+	"time"
 
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/dag"
@@ -25,6 +27,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	discovery_v1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 )
 
 func TestEndpointsTranslatorContents(t *testing.T) {
@@ -90,7 +95,6 @@ func TestEndpointCacheQuery(t *testing.T) {
 				envoy_v3.ClusterLoadAssignment("default/httpbin-org",
 					envoy_v3.SocketAddress("10.10.10.10", 80),
 				),
-				envoy_v3.ClusterLoadAssignment("default/kuard/8080"),
 			},
 		},
 		"no match": {
@@ -100,9 +104,7 @@ func TestEndpointCacheQuery(t *testing.T) {
 				),
 			),
 			query: []string{"default/kuard/8080"},
-			want: []proto.Message{
-				envoy_v3.ClusterLoadAssignment("default/kuard/8080"),
-			},
+			want:  nil,
 		},
 	}
 
@@ -699,6 +701,139 @@ func TestEndpointsTranslatorRecomputeClusterLoadAssignment(t *testing.T) {
 	}
 }
 
+func TestEndpointsTranslatorEndpointSlices(t *testing.T) {
+	cluster := dag.ServiceCluster{
+		ClusterName: "default/dualstack/https",
+		Services: []dag.WeightedService{{
+			Weight:           1,
+			ServiceName:      "dualstack",
+			ServiceNamespace: "default",
+			ServicePort:      v1.ServicePort{Name: "https"},
+		}},
+	}
+
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+	require.NoError(t, et.cache.SetClusters([]*dag.ServiceCluster{&cluster}))
+
+	ipv4 := endpointSlice("default", "dualstack-abcde", "dualstack", discovery_v1.AddressTypeIPv4,
+		[]discovery_v1.EndpointPort{
+			{Name: pointer.StringPtr("http"), Port: pointer.Int32Ptr(8080)},
+			{Name: pointer.StringPtr("https"), Port: pointer.Int32Ptr(8443)},
+		},
+		discovery_v1.Endpoint{Addresses: []string{"192.168.183.25"}},
+		discovery_v1.Endpoint{Addresses: []string{"192.168.183.24"}, Conditions: discovery_v1.EndpointConditions{Ready: pointer.BoolPtr(true)}},
+		discovery_v1.Endpoint{Addresses: []string{"192.168.183.26"}, Conditions: discovery_v1.EndpointConditions{Ready: pointer.BoolPtr(false)}},
+	)
+	ipv6 := endpointSlice("default", "dualstack-fghij", "dualstack", discovery_v1.AddressTypeIPv6,
+		[]discovery_v1.EndpointPort{
+			{Name: pointer.StringPtr("https"), Port: pointer.Int32Ptr(8443)},
+		},
+		discovery_v1.Endpoint{Addresses: []string{"fd00::24"}},
+	)
+
+	// Endpoints of both address families are used,
+	// skipping endpoints that are not ready.
+	et.OnAdd(ipv4)
+	et.OnAdd(ipv6)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/dualstack/https",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8443),
+				envoy_v3.SocketAddress("192.168.183.25", 8443),
+				envoy_v3.SocketAddress("fd00::24", 8443),
+			),
+		},
+	}, et.Contents())
+
+	// An endpoint duplicated across slices is only used once.
+	ipv4Dup := endpointSlice("default", "dualstack-klmno", "dualstack", discovery_v1.AddressTypeIPv4,
+		[]discovery_v1.EndpointPort{
+			{Name: pointer.StringPtr("https"), Port: pointer.Int32Ptr(8443)},
+		},
+		discovery_v1.Endpoint{Addresses: []string{"192.168.183.24"}},
+	)
+	et.OnAdd(ipv4Dup)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/dualstack/https",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8443),
+				envoy_v3.SocketAddress("192.168.183.25", 8443),
+				envoy_v3.SocketAddress("fd00::24", 8443),
+			),
+		},
+	}, et.Contents())
+
+	// Removing slices removes their endpoints.
+	et.OnDelete(ipv4)
+	et.OnDelete(ipv4Dup)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/dualstack/https",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("fd00::24", 8443),
+			),
+		},
+	}, et.Contents())
+
+	et.OnDelete(ipv6)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{ClusterName: "default/dualstack/https"},
+	}, et.Contents())
+}
+
+func TestRecalculateEndpointSlices(t *testing.T) {
+	tests := map[string]struct {
+		port   v1.ServicePort
+		slices []*discovery_v1.EndpointSlice
+		want   []*LoadBalancingEndpoint
+	}{
+		"no slices": {
+			port: v1.ServicePort{},
+			want: nil,
+		},
+		"unnamed port": {
+			port: v1.ServicePort{},
+			slices: []*discovery_v1.EndpointSlice{
+				endpointSlice("default", "simple-abcde", "simple", discovery_v1.AddressTypeIPv4,
+					[]discovery_v1.EndpointPort{{Port: pointer.Int32Ptr(8080)}},
+					discovery_v1.Endpoint{Addresses: []string{"192.168.183.24"}},
+				),
+			},
+			want: []*LoadBalancingEndpoint{
+				envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+		"fqdn slice": {
+			port: v1.ServicePort{},
+			slices: []*discovery_v1.EndpointSlice{
+				endpointSlice("default", "simple-abcde", "simple", discovery_v1.AddressTypeFQDN,
+					[]discovery_v1.EndpointPort{{Port: pointer.Int32Ptr(8080)}},
+					discovery_v1.Endpoint{Addresses: []string{"example.com"}},
+				),
+			},
+			want: nil,
+		},
+		"udp port": {
+			port: v1.ServicePort{Protocol: v1.ProtocolTCP},
+			slices: []*discovery_v1.EndpointSlice{
+				endpointSlice("default", "simple-abcde", "simple", discovery_v1.AddressTypeIPv4,
+					[]discovery_v1.EndpointPort{{Port: pointer.Int32Ptr(53), Protocol: protocol(v1.ProtocolUDP)}},
+					discovery_v1.Endpoint{Addresses: []string{"192.168.183.24"}},
+				),
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			protobuf.ExpectEqual(t, tc.want, RecalculateEndpointSlices(tc.port, tc.slices))
+		})
+	}
+}
+
 // See #602
 func TestEndpointsTranslatorScaleToZeroEndpoints(t *testing.T) {
 	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
@@ -745,6 +880,252 @@ func TestEndpointsTranslatorScaleToZeroEndpoints(t *testing.T) {
 	protobuf.RequireEqual(t, want, et.Contents())
 }
 
+func TestEndpointsTranslatorTerminatingPods(t *testing.T) {
+	clusters := []*dag.ServiceCluster{{
+		ClusterName: "default/simple",
+		Services: []dag.WeightedService{{
+			Weight:           1,
+			ServiceName:      "simple",
+			ServiceNamespace: "default",
+			ServicePort:      v1.ServicePort{},
+		}},
+	}}
+
+	e1 := endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24", "192.168.183.25"),
+		Ports: ports(
+			port("", 8080),
+		),
+	})
+
+	running := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple-abcde",
+			Namespace: "default",
+		},
+		Status: v1.PodStatus{
+			PodIP:  "192.168.183.25",
+			PodIPs: []v1.PodIP{{IP: "192.168.183.25"}},
+		},
+	}
+	terminating := running.DeepCopy()
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	// With a drain delay, the endpoints of a terminating
+	// pod are draining until the delay has passed.
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+	et.DrainDelay = time.Hour
+	require.NoError(t, et.cache.SetClusters(clusters))
+	et.OnAdd(e1)
+
+	// Updates of a pod that is not terminating are ignored.
+	et.OnUpdate(running, running)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8080),
+				envoy_v3.SocketAddress("192.168.183.25", 8080),
+			),
+		},
+	}, et.Contents())
+
+	et.OnUpdate(running, terminating)
+	draining := envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.168.183.25", 8080))
+	draining.HealthStatus = envoy_core_v3.HealthStatus_DRAINING
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+					envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.168.183.24", 8080)),
+					draining,
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}},
+		},
+	}, et.Contents())
+
+	// Once the pod is deleted its address may be reused,
+	// so it is no longer drained.
+	et.OnDelete(terminating)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8080),
+				envoy_v3.SocketAddress("192.168.183.25", 8080),
+			),
+		},
+	}, et.Contents())
+
+	// Without a drain delay, the endpoints of a
+	// terminating pod are removed immediately.
+	et = NewEndpointsTranslator(fixture.NewTestLogger(t))
+	require.NoError(t, et.cache.SetClusters(clusters))
+	et.OnAdd(e1)
+	et.OnAdd(terminating)
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8080),
+			),
+		},
+	}, et.Contents())
+}
+
+func TestEndpointsTranslatorHealthCheckPort(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+
+	require.NoError(t, et.cache.SetClusters([]*dag.ServiceCluster{
+		{
+			ClusterName: "default/simple/healthcheck-8081",
+			Services: []dag.WeightedService{{
+				Weight:           1,
+				ServiceName:      "simple",
+				ServiceNamespace: "default",
+				ServicePort:      v1.ServicePort{},
+				HealthCheckPort:  8081,
+			}},
+		},
+	}))
+
+	et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24"),
+		Ports: ports(
+			port("", 8080),
+		),
+	}))
+
+	// Health checks of the endpoint are sent to the health check port.
+	lb := envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.168.183.24", 8080))
+	lb.GetEndpoint().HealthCheckConfig = &envoy_endpoint_v3.Endpoint_HealthCheckConfig{PortValue: 8081}
+
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple/healthcheck-8081",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints:         []*envoy_endpoint_v3.LbEndpoint{lb},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}},
+		},
+	}, et.Contents())
+}
+
+// Test that the endpoints of backup services are at lower priorities.
+func TestEndpointsTranslatorBackupService(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+
+	require.NoError(t, et.cache.SetClusters([]*dag.ServiceCluster{
+		{
+			ClusterName: "default/simple/backup-0123456789",
+			Services: []dag.WeightedService{{
+				Weight:           1,
+				ServiceName:      "simple",
+				ServiceNamespace: "default",
+				ServicePort:      v1.ServicePort{},
+			}, {
+				Weight:           1,
+				ServiceName:      "backup",
+				ServiceNamespace: "default",
+				ServicePort:      v1.ServicePort{},
+				Priority:         1,
+			}},
+		},
+	}))
+
+	et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24"),
+		Ports:     ports(port("", 8080)),
+	}))
+	et.OnAdd(endpoints("default", "backup", v1.EndpointSubset{
+		Addresses: addresses("10.10.1.1"),
+		Ports:     ports(port("", 8080)),
+	}))
+
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple/backup-0123456789",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+					envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.168.183.24", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}, {
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+					envoy_v3.LBEndpoint(envoy_v3.SocketAddress("10.10.1.1", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+				Priority:            1,
+			}},
+			Policy: envoy_v3.FailoverPolicy(),
+		},
+	}, et.Contents())
+}
+
+// Test that the endpoints of remote clusters are added to those
+// of the Service with the same name.
+func TestEndpointsTranslatorRemoteCluster(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+
+	require.NoError(t, et.cache.SetClusters([]*dag.ServiceCluster{
+		{
+			ClusterName: "default/simple",
+			Services: []dag.WeightedService{{
+				Weight:           1,
+				ServiceName:      "simple",
+				ServiceNamespace: "default",
+				ServicePort:      v1.ServicePort{},
+			}},
+		},
+	}))
+
+	et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24"),
+		Ports:     ports(port("", 8080)),
+	}))
+
+	west := et.RemoteClusterHandler("west")
+	west.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("10.20.1.1"),
+		Ports:     ports(port("", 8080)),
+	}))
+	east := et.RemoteClusterHandler("east")
+	east.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("10.30.1.1"),
+		Ports:     ports(port("", 8080)),
+	}))
+
+	// The remote endpoints follow the local endpoints, in
+	// the order of the names of the remote clusters.
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8080),
+				envoy_v3.SocketAddress("10.30.1.1", 8080),
+				envoy_v3.SocketAddress("10.20.1.1", 8080),
+			),
+		},
+	}, et.Contents())
+
+	east.OnDelete(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("10.30.1.1"),
+		Ports:     ports(port("", 8080)),
+	}))
+
+	protobuf.RequireEqual(t, []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8080),
+				envoy_v3.SocketAddress("10.20.1.1", 8080),
+			),
+		},
+	}, et.Contents())
+}
+
 // Test that a cluster with weighted services propagates the weights.
 func TestEndpointsTranslatorWeightedService(t *testing.T) {
 	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
@@ -966,6 +1347,25 @@ func port(name string, port int32) v1.EndpointPort {
 	}
 }
 
+func endpointSlice(namespace, name, service string, addressType discovery_v1.AddressType, ports []discovery_v1.EndpointPort, eps ...discovery_v1.Endpoint) *discovery_v1.EndpointSlice {
+	return &discovery_v1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels: map[string]string{
+				discovery_v1.LabelServiceName: service,
+			},
+		},
+		AddressType: addressType,
+		Ports:       ports,
+		Endpoints:   eps,
+	}
+}
+
+func protocol(p v1.Protocol) *v1.Protocol {
+	return &p
+}
+
 func clusterloadassignments(clas ...*envoy_endpoint_v3.ClusterLoadAssignment) map[string]*envoy_endpoint_v3.ClusterLoadAssignment {
 	m := make(map[string]*envoy_endpoint_v3.ClusterLoadAssignment)
 	for _, cla := range clas {
@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
@@ -28,6 +29,7 @@ import (
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/contourconfig"
 	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/envoy"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
@@ -81,6 +83,36 @@ type ListenerConfig struct {
 	// If not set, defaults to false.
 	UseProxyProto bool
 
+	// ProxyProtocolTLVs are the PROXY protocol v2 TLVs to extract
+	// into dynamic metadata on listeners expecting a PROXY preamble.
+	ProxyProtocolTLVs []envoy_v3.ProxyProtocolTLV
+
+	// HTTPListenerFilters overrides the listener filters enabled
+	// on the HTTP (non TLS) listeners.
+	HTTPListenerFilters ListenerFilters
+
+	// HTTPSListenerFilters overrides the listener filters enabled
+	// on the HTTPS (TLS) listeners.
+	HTTPSListenerFilters ListenerFilters
+
+	// ListenerFiltersTimeout is the timeout for the listener filters
+	// to finish inspecting a new connection.
+	// If not set, defaults to Envoy's default of 15s.
+	ListenerFiltersTimeout timeout.Setting
+
+	// ContinueOnListenerFiltersTimeout passes a connection to the
+	// filter chains when its listener filters time out, rather than
+	// closing it.
+	ContinueOnListenerFiltersTimeout bool
+
+	// DrainType sets when Envoy drains the connections of the listeners.
+	// The validated values are 'default' and 'modify-only'.
+	// If not set, defaults to Envoy's default drain type.
+	DrainType string
+
+	// SocketOptions holds the socket options of the listeners.
+	SocketOptions ListenerSocketOptions
+
 	// MinimumTLSVersion defines the minimum TLS protocol version the proxy should accept.
 	MinimumTLSVersion string
 
@@ -92,9 +124,34 @@ type ListenerConfig struct {
 	// versions the proxy should accept. If not specified, all
 	// supported versions are accepted. This is applied to both
 	// HTTP and HTTPS listeners but has practical effect only for
-	// HTTPS, because we don't support h2c.
+	// HTTPS, unless EnableH2C is set.
 	DefaultHTTPVersions []envoy_v3.HTTPVersionType
 
+	// EnableH2C accepts HTTP/2 over cleartext (h2c) with prior
+	// knowledge, as well as HTTP/1, on the HTTP listeners,
+	// whatever the DefaultHTTPVersions.
+	EnableH2C bool
+
+	// DefaultHostForHTTP10 is the host that HTTP/1.0 requests
+	// without a Host header are handled as. If not set, such
+	// requests are rejected. HTTP/1.0 requests with a Host header
+	// are always accepted.
+	DefaultHostForHTTP10 string
+
+	// PreserveHeaderCase preserves the case of HTTP/1 header
+	// names received by the listeners, rather than lowercasing
+	// them.
+	PreserveHeaderCase bool
+
+	// HeaderLimits limits the headers of requests received by
+	// the listeners. Secure virtual hosts may override them.
+	HeaderLimits dag.HeaderLimits
+
+	// RejectInvalidRequests rejects requests that exceed the
+	// header limits, or are otherwise invalid, without closing
+	// their connection.
+	RejectInvalidRequests bool
+
 	// AccessLogType defines if Envoy logs should be output as Envoy's default or JSON.
 	// Valid values: 'envoy', 'json'
 	// If not set, defaults to 'envoy'
@@ -123,6 +180,11 @@ type ListenerConfig struct {
 	// right side of the x-forwarded-for HTTP header to trust.
 	XffNumTrustedHops uint32
 
+	// DualStack additionally binds listeners that are bound to
+	// the IPv4 or IPv6 wildcard address to the wildcard address
+	// of the other IP family.
+	DualStack bool
+
 	// ConnectionBalancer
 	// The validated value is 'exact'.
 	// If no configuration is specified, Envoy will not attempt to balance active connections between worker threads
@@ -133,12 +195,61 @@ type ListenerConfig struct {
 	RateLimitConfig *RateLimitConfig
 }
 
+// ListenerFilters holds the listener filters to enable on a listener.
+type ListenerFilters struct {
+	// ProxyProtocol enables the PROXY protocol listener filter.
+	// If not set, defaults to UseProxyProto.
+	ProxyProtocol *bool
+
+	// TLSInspector enables the TLS inspector listener filter.
+	// If not set, defaults to false for HTTP listeners and
+	// true for HTTPS listeners.
+	TLSInspector *bool
+}
+
+// ListenerSocketOptions holds the socket options of the listeners.
+type ListenerSocketOptions struct {
+	// DisableTCPKeepalive disables TCP keep-alive on
+	// downstream connections.
+	DisableTCPKeepalive bool
+
+	// TCPKeepaliveIdle, TCPKeepaliveInterval and TCPKeepaliveProbes
+	// set the TCP keep-alive idle time and probe interval, in seconds,
+	// and probe count. Zero values default to 45, 5 and 9.
+	TCPKeepaliveIdle     uint32
+	TCPKeepaliveInterval uint32
+	TCPKeepaliveProbes   uint32
+
+	// TCPFastOpenQueueLength enables TCP Fast Open with the given
+	// queue length if greater than zero.
+	TCPFastOpenQueueLength uint32
+
+	// ReusePort sets SO_REUSEPORT on the listener sockets.
+	ReusePort bool
+
+	// Freebind sets IP_FREEBIND on the listener sockets, so that
+	// listeners can bind to addresses not yet assigned to the host.
+	Freebind bool
+}
+
+// socketOptions returns the socket options of a listener.
+func (o *ListenerSocketOptions) socketOptions() []*envoy_core_v3.SocketOption {
+	if o.DisableTCPKeepalive {
+		return nil
+	}
+	return envoy_v3.TCPKeepaliveSocketOptionsWith(o.TCPKeepaliveIdle, o.TCPKeepaliveInterval, o.TCPKeepaliveProbes)
+}
+
 type RateLimitConfig struct {
 	ExtensionService        types.NamespacedName
 	Domain                  string
 	Timeout                 timeout.Setting
 	FailOpen                bool
 	EnableXRateLimitHeaders bool
+
+	// Stages are the rate limit stages Envoy evaluates, each
+	// with its own rate limit filter. Defaults to stage 0.
+	Stages []uint32
 }
 
 // DefaultListeners returns the configured Listeners or a single
@@ -182,7 +293,7 @@ func (lvc *ListenerConfig) secureListeners() map[string]*envoy_listener_v3.Liste
 			ENVOY_HTTPS_LISTENER,
 			DEFAULT_HTTPS_LISTENER_ADDRESS,
 			DEFAULT_HTTPS_LISTENER_PORT,
-			secureProxyProtocol(lvc.UseProxyProto),
+			lvc.listenerFilters(lvc.HTTPSListenerFilters, true),
 		)
 	}
 
@@ -191,13 +302,22 @@ func (lvc *ListenerConfig) secureListeners() map[string]*envoy_listener_v3.Liste
 			l.Name,
 			l.Address,
 			l.Port,
-			secureProxyProtocol(lvc.UseProxyProto),
+			lvc.listenerFilters(lvc.HTTPSListenerFilters, true),
 		)
 	}
 
 	return listeners
 }
 
+// udpListenerAddress returns the address of the UDP listeners,
+// which is the address of the default HTTP listener.
+func (lvc *ListenerConfig) udpListenerAddress() string {
+	if l, ok := lvc.HTTPListeners[ENVOY_HTTP_LISTENER]; ok && l.Address != "" {
+		return l.Address
+	}
+	return DEFAULT_HTTP_LISTENER_ADDRESS
+}
+
 // httpAccessLog returns the access log for the HTTP (non TLS)
 // listener or DEFAULT_HTTP_ACCESS_LOG if not configured.
 func (lvc *ListenerConfig) httpAccessLog() string {
@@ -252,6 +372,14 @@ func (lvc *ListenerConfig) newSecureAccessLog() []*envoy_accesslog_v3.AccessLog
 	}
 }
 
+// insecureCodec returns the HTTP codec of the HTTP listeners.
+func (lvc *ListenerConfig) insecureCodec() envoy_v3.HTTPVersionType {
+	if lvc.EnableH2C {
+		return envoy_v3.HTTPVersionAuto
+	}
+	return envoy_v3.CodecForVersions(lvc.DefaultHTTPVersions...)
+}
+
 // minTLSVersion returns the requested minimum TLS protocol
 // version or envoy_tls_v3.TlsParameters_TLSv1_2 if not configured.
 func (lvc *ListenerConfig) minTLSVersion() envoy_tls_v3.TlsParameters_TlsProtocol {
@@ -262,6 +390,47 @@ func (lvc *ListenerConfig) minTLSVersion() envoy_tls_v3.TlsParameters_TlsProtoco
 	return envoy_tls_v3.TlsParameters_TLSv1_2
 }
 
+// connectionTimeouts returns the connection timeouts for the secure
+// virtual host, applying its overrides to the configured timeouts.
+func (lvc *ListenerConfig) connectionTimeouts(vh *dag.SecureVirtualHost) dag.ConnectionTimeouts {
+	timeouts := dag.ConnectionTimeouts{
+		MaxConnectionDuration:         lvc.Timeouts.MaxConnectionDuration,
+		ConnectionShutdownGracePeriod: lvc.Timeouts.ConnectionShutdownGracePeriod,
+		DelayedClose:                  lvc.Timeouts.DelayedClose,
+	}
+
+	if ct := vh.ConnectionTimeouts; ct != nil {
+		if !ct.MaxConnectionDuration.UseDefault() {
+			timeouts.MaxConnectionDuration = ct.MaxConnectionDuration
+		}
+		if !ct.ConnectionShutdownGracePeriod.UseDefault() {
+			timeouts.ConnectionShutdownGracePeriod = ct.ConnectionShutdownGracePeriod
+		}
+		if !ct.DelayedClose.UseDefault() {
+			timeouts.DelayedClose = ct.DelayedClose
+		}
+	}
+
+	return timeouts
+}
+
+// headerLimits returns the header limits for the secure
+// virtual host, applying its overrides to the configured limits.
+func (lvc *ListenerConfig) headerLimits(vh *dag.SecureVirtualHost) dag.HeaderLimits {
+	limits := lvc.HeaderLimits
+
+	if hl := vh.HeaderLimits; hl != nil {
+		if hl.MaxRequestHeadersKB > 0 {
+			limits.MaxRequestHeadersKB = hl.MaxRequestHeadersKB
+		}
+		if hl.MaxRequestHeadersCount > 0 {
+			limits.MaxRequestHeadersCount = hl.MaxRequestHeadersCount
+		}
+	}
+
+	return limits
+}
+
 // ListenerCache manages the contents of the gRPC LDS cache.
 type ListenerCache struct {
 	mu           sync.Mutex
@@ -281,6 +450,12 @@ func NewListenerCache(envoyConfig contour_api_v1alpha1.EnvoyConfig, listenerConf
 
 	for _, l := range envoy_v3.StatsListeners(envoyConfig.Metrics, envoyConfig.Health) {
 		listenerCache.staticValues[l.Name] = l
+
+		if envoyConfig.Network.DualStack {
+			if dual := envoy_v3.DualStackListener(l); dual != nil {
+				listenerCache.staticValues[dual.Name] = dual
+			}
+		}
 	}
 
 	// If the port is not zero, allow the read-only options from the
@@ -306,7 +481,7 @@ func (c *ListenerCache) Update(v map[string]*envoy_listener_v3.Listener) {
 func (c *ListenerCache) Contents() []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []*envoy_listener_v3.Listener
+	values := make([]*envoy_listener_v3.Listener, 0, len(c.values)+len(c.staticValues))
 	for _, v := range c.values {
 		values = append(values, v)
 	}
@@ -322,7 +497,7 @@ func (c *ListenerCache) Contents() []proto.Message {
 func (c *ListenerCache) Query(names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []*envoy_listener_v3.Listener
+	values := make([]*envoy_listener_v3.Listener, 0, len(names))
 	for _, n := range names {
 		v, ok := c.values[n]
 		if !ok {
@@ -347,6 +522,7 @@ func (*ListenerCache) TypeURL() string { return resource.ListenerType }
 func (c *ListenerCache) OnChange(root *dag.DAG) {
 	cfg := c.Config.defaultListeners()
 	listeners := c.Config.secureListeners()
+	udpListeners := map[string]*envoy_listener_v3.Listener{}
 
 	max := func(a, b envoy_tls_v3.TlsParameters_TlsProtocol) envoy_tls_v3.TlsParameters_TlsProtocol {
 		if a > b {
@@ -359,11 +535,21 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 	// want the vhosts that have been attached to a listener
 	// by the listener processor.
 	for _, listener := range root.Listeners {
+		if listener.UDPProxy != nil {
+			udpListeners[listener.Name] = envoy_v3.UDPListener(
+				listener.Name,
+				cfg.udpListenerAddress(),
+				listener.Port,
+				listener.UDPProxy,
+			)
+			continue
+		}
+
 		if len(listener.VirtualHosts) > 0 {
 			if httpListener, ok := cfg.HTTPListeners[listener.Name]; ok {
 				// Add a listener if there are vhosts bound to http.
 				cm := envoy_v3.HTTPConnectionManagerBuilder().
-					Codec(envoy_v3.CodecForVersions(cfg.DefaultHTTPVersions...)).
+					Codec(cfg.insecureCodec()).
 					DefaultFilters().
 					RouteConfigName(httpListener.Name).
 					MetricsPrefix(httpListener.Name).
@@ -375,15 +561,20 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					MaxConnectionDuration(cfg.Timeouts.MaxConnectionDuration).
 					ConnectionShutdownGracePeriod(cfg.Timeouts.ConnectionShutdownGracePeriod).
 					AllowChunkedLength(cfg.AllowChunkedLength).
+					DefaultHostForHTTP10(cfg.DefaultHostForHTTP10).
+					PreserveHeaderCase(cfg.PreserveHeaderCase).
+					MaxRequestHeadersKB(cfg.HeaderLimits.MaxRequestHeadersKB).
+					MaxRequestHeadersCount(cfg.HeaderLimits.MaxRequestHeadersCount).
+					RejectInvalidRequests(cfg.RejectInvalidRequests).
 					AddFilter(envoy_v3.OriginalIPDetectionFilter(cfg.XffNumTrustedHops)).
-					AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))).
+					AddFilters(envoy_v3.GlobalRateLimitFilters(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))...).
 					Get()
 
 				listeners[httpListener.Name] = envoy_v3.Listener(
 					httpListener.Name,
 					httpListener.Address,
 					httpListener.Port,
-					proxyProtocol(cfg.UseProxyProto),
+					cfg.listenerFilters(cfg.HTTPListenerFilters, false),
 					cm,
 				)
 			}
@@ -404,6 +595,9 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					)
 				}
 
+				connectionTimeouts := cfg.connectionTimeouts(vh)
+				headerLimits := cfg.headerLimits(vh)
+
 				// Create a uniquely named HTTP connection manager for
 				// this vhost, so that the SNI name the client requests
 				// only grants access to that host. See RFC 6066 for
@@ -413,7 +607,7 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 				// coded into monitoring dashboards.
 				cm := envoy_v3.HTTPConnectionManagerBuilder().
 					Codec(envoy_v3.CodecForVersions(cfg.DefaultHTTPVersions...)).
-					AddFilter(envoy_v3.FilterMisdirectedRequests(vh.VirtualHost.Name)).
+					AddFilter(envoy_v3.FilterMisdirectedRequests(vh.VirtualHost.Name, vh.Aliases...)).
 					DefaultFilters().
 					AddFilter(authFilter).
 					RouteConfigName(path.Join("https", vh.VirtualHost.Name)).
@@ -422,12 +616,17 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					RequestTimeout(cfg.Timeouts.Request).
 					ConnectionIdleTimeout(cfg.Timeouts.ConnectionIdle).
 					StreamIdleTimeout(cfg.Timeouts.StreamIdle).
-					DelayedCloseTimeout(cfg.Timeouts.DelayedClose).
-					MaxConnectionDuration(cfg.Timeouts.MaxConnectionDuration).
-					ConnectionShutdownGracePeriod(cfg.Timeouts.ConnectionShutdownGracePeriod).
+					DelayedCloseTimeout(connectionTimeouts.DelayedClose).
+					MaxConnectionDuration(connectionTimeouts.MaxConnectionDuration).
+					ConnectionShutdownGracePeriod(connectionTimeouts.ConnectionShutdownGracePeriod).
 					AllowChunkedLength(cfg.AllowChunkedLength).
+					DefaultHostForHTTP10(cfg.DefaultHostForHTTP10).
+					PreserveHeaderCase(cfg.PreserveHeaderCase).
+					MaxRequestHeadersKB(headerLimits.MaxRequestHeadersKB).
+					MaxRequestHeadersCount(headerLimits.MaxRequestHeadersCount).
+					RejectInvalidRequests(cfg.RejectInvalidRequests).
 					AddFilter(envoy_v3.OriginalIPDetectionFilter(cfg.XffNumTrustedHops)).
-					AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))).
+					AddFilters(envoy_v3.GlobalRateLimitFilters(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))...).
 					Get()
 
 				filters = envoy_v3.Filters(cm)
@@ -460,7 +659,13 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					alpnProtos...)
 			}
 
-			listeners[listener.Name].FilterChains = append(listeners[listener.Name].FilterChains, envoy_v3.FilterChainTLS(vh.VirtualHost.Name, downstreamTLS, filters))
+			fc := envoy_v3.FilterChainTLS(vh.VirtualHost.Name, downstreamTLS, filters)
+			if len(vh.Aliases) > 0 {
+				// Aliases share the filter chain, and so
+				// the TLS configuration, of the vhost.
+				fc.FilterChainMatch.ServerNames = append(fc.FilterChainMatch.ServerNames, vh.Aliases...)
+			}
+			listeners[listener.Name].FilterChains = append(listeners[listener.Name].FilterChains, fc)
 
 			// If this VirtualHost has enabled the fallback certificate then set a default
 			// FilterChain which will allow routes with this vhost to accept non-SNI TLS requests.
@@ -490,8 +695,13 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					MaxConnectionDuration(cfg.Timeouts.MaxConnectionDuration).
 					ConnectionShutdownGracePeriod(cfg.Timeouts.ConnectionShutdownGracePeriod).
 					AllowChunkedLength(cfg.AllowChunkedLength).
+					DefaultHostForHTTP10(cfg.DefaultHostForHTTP10).
+					PreserveHeaderCase(cfg.PreserveHeaderCase).
+					MaxRequestHeadersKB(cfg.HeaderLimits.MaxRequestHeadersKB).
+					MaxRequestHeadersCount(cfg.HeaderLimits.MaxRequestHeadersCount).
+					RejectInvalidRequests(cfg.RejectInvalidRequests).
 					AddFilter(envoy_v3.OriginalIPDetectionFilter(cfg.XffNumTrustedHops)).
-					AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))).
+					AddFilters(envoy_v3.GlobalRateLimitFilters(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))...).
 					Get()
 
 				// Default filter chain
@@ -505,10 +715,12 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 	// Remove the https listener if there are no vhosts bound to it.
 	if len(listeners[ENVOY_HTTPS_LISTENER].FilterChains) == 0 {
 		delete(listeners, ENVOY_HTTPS_LISTENER)
-	} else {
-		// there's some https listeners, we need to sort the filter chains
-		// to ensure that the LDS entries are identical.
-		sort.Stable(sorter.For(listeners[ENVOY_HTTPS_LISTENER].FilterChains))
+	}
+
+	// Sort the filter chains of every listener to ensure
+	// that the LDS entries are identical across rebuilds.
+	for _, l := range listeners {
+		sort.Stable(sorter.For(l.FilterChains))
 	}
 
 	// support more params of envoy listener
@@ -524,6 +736,50 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 		}
 	}
 
+	// 2. listener filters timeout
+	if !cfg.ListenerFiltersTimeout.UseDefault() || cfg.ContinueOnListenerFiltersTimeout {
+		for _, listener := range listeners {
+			listener.ListenerFiltersTimeout = envoy.Timeout(cfg.ListenerFiltersTimeout)
+			listener.ContinueOnListenerFiltersTimeout = cfg.ContinueOnListenerFiltersTimeout
+		}
+	}
+
+	// 3. drain type
+	if cfg.DrainType == "modify-only" {
+		for _, listener := range listeners {
+			listener.DrainType = envoy_listener_v3.Listener_MODIFY_ONLY
+		}
+	}
+
+	// 4. socket options
+	for _, listener := range listeners {
+		listener.SocketOptions = cfg.SocketOptions.socketOptions()
+		listener.TcpFastOpenQueueLength = protobuf.UInt32OrNil(cfg.SocketOptions.TCPFastOpenQueueLength)
+		listener.ReusePort = cfg.SocketOptions.ReusePort
+		if cfg.SocketOptions.Freebind {
+			listener.Freebind = protobuf.Bool(true)
+		}
+	}
+
+	// The options above are for TCP sockets, so
+	// the UDP listeners are only added now.
+	for name, listener := range udpListeners {
+		listeners[name] = listener
+	}
+
+	// 5. dual-stack listeners
+	if cfg.DualStack {
+		var duals []*envoy_listener_v3.Listener
+		for _, listener := range listeners {
+			if dual := envoy_v3.DualStackListener(listener); dual != nil {
+				duals = append(duals, dual)
+			}
+		}
+		for _, dual := range duals {
+			listeners[dual.Name] = dual
+		}
+	}
+
 	c.Update(listeners)
 }
 
@@ -538,18 +794,27 @@ func envoyGlobalRateLimitConfig(config *RateLimitConfig) *envoy_v3.GlobalRateLim
 		Timeout:                 config.Timeout,
 		Domain:                  config.Domain,
 		EnableXRateLimitHeaders: config.EnableXRateLimitHeaders,
+		Stages:                  config.Stages,
 	}
 }
 
-func proxyProtocol(useProxy bool) []*envoy_listener_v3.ListenerFilter {
-	if useProxy {
-		return envoy_v3.ListenerFilters(
-			envoy_v3.ProxyProtocol(),
-		)
+// listenerFilters returns the listener filters for a listener,
+// applying the overrides in filters to the defaults.
+func (lvc *ListenerConfig) listenerFilters(filters ListenerFilters, tlsInspector bool) []*envoy_listener_v3.ListenerFilter {
+	useProxy := lvc.UseProxyProto
+	if filters.ProxyProtocol != nil {
+		useProxy = *filters.ProxyProtocol
+	}
+	if filters.TLSInspector != nil {
+		tlsInspector = *filters.TLSInspector
 	}
-	return nil
-}
 
-func secureProxyProtocol(useProxy bool) []*envoy_listener_v3.ListenerFilter {
-	return append(proxyProtocol(useProxy), envoy_v3.TLSInspector())
+	var lf []*envoy_listener_v3.ListenerFilter
+	if useProxy {
+		lf = append(lf, envoy_v3.ProxyProtocol(lvc.ProxyProtocolTLVs...))
+	}
+	if tlsInspector {
+		lf = append(lf, envoy_v3.TLSInspector())
+	}
+	return lf
 }
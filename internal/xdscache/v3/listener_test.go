@@ -14,6 +14,7 @@
 package v3
 
 import (
+	"math/rand"
 	"path"
 	"testing"
 	"time"
@@ -23,6 +24,7 @@ import (
 	ratelimit_config_v3 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
 	ratelimit_filter_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	udp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/proto"
@@ -33,10 +35,12 @@ import (
 	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 )
 
 func TestListenerCacheContents(t *testing.T) {
@@ -686,6 +690,50 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
 			}),
 		},
+		"dual stack http only ingress": {
+			ListenerConfig: ListenerConfig{
+				DualStack: true,
+			},
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						DefaultBackend: backend("kuard", 8080),
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:          ENVOY_HTTP_LISTENER,
+				Address:       envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name: ENVOY_HTTP_LISTENER + "_ipv6",
+				Address: func() *envoy_core_v3.Address {
+					addr := envoy_v3.SocketAddress("::", 8080)
+					addr.GetSocketAddress().Ipv4Compat = false
+					return addr
+				}(),
+				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
 		"use proxy proto": {
 			ListenerConfig: ListenerConfig{
 				UseProxyProto: true,
@@ -718,8 +766,337 @@ func TestListenerVisit(t *testing.T) {
 						Name:      "secret",
 						Namespace: "default",
 					},
-					Type: "kubernetes.io/tls",
-					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.ProxyProtocol(),
+				),
+				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.ProxyProtocol(),
+					envoy_v3.TLSInspector(),
+				),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"whatever.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters:         envoy_v3.Filters(httpsFilterFor("whatever.example.com")),
+				}},
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
+		"use proxy proto with tlvs": {
+			ListenerConfig: ListenerConfig{
+				UseProxyProto: true,
+				ProxyProtocolTLVs: []envoy_v3.ProxyProtocolTLV{{
+					Type: 0xEA,
+					Key:  "vpce_id",
+				}},
+			},
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						TLS: []networking_v1.IngressTLS{{
+							Hosts:      []string{"whatever.example.com"},
+							SecretName: "secret",
+						}},
+						Rules: []networking_v1.IngressRule{{
+							Host: "whatever.example.com",
+							IngressRuleValue: networking_v1.IngressRuleValue{
+								HTTP: &networking_v1.HTTPIngressRuleValue{
+									Paths: []networking_v1.HTTPIngressPath{{
+										Backend: *backend("kuard", 8080),
+									}},
+								},
+							},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.ProxyProtocol(envoy_v3.ProxyProtocolTLV{Type: 0xEA, Key: "vpce_id"}),
+				),
+				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.ProxyProtocol(envoy_v3.ProxyProtocolTLV{Type: 0xEA, Key: "vpce_id"}),
+					envoy_v3.TLSInspector(),
+				),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"whatever.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters:         envoy_v3.Filters(httpsFilterFor("whatever.example.com")),
+				}},
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
+		"listener filter overrides and timeout": {
+			ListenerConfig: ListenerConfig{
+				UseProxyProto: true,
+				HTTPListenerFilters: ListenerFilters{
+					ProxyProtocol: pointer.Bool(false),
+				},
+				ListenerFiltersTimeout:           timeout.DurationSetting(30 * time.Second),
+				ContinueOnListenerFiltersTimeout: true,
+			},
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						TLS: []networking_v1.IngressTLS{{
+							Hosts:      []string{"whatever.example.com"},
+							SecretName: "secret",
+						}},
+						Rules: []networking_v1.IngressRule{{
+							Host: "whatever.example.com",
+							IngressRuleValue: networking_v1.IngressRuleValue{
+								HTTP: &networking_v1.HTTPIngressRuleValue{
+									Paths: []networking_v1.HTTPIngressPath{{
+										Backend: *backend("kuard", 8080),
+									}},
+								},
+							},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:                             ENVOY_HTTP_LISTENER,
+				Address:                          envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains:                     envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions:                    envoy_v3.TCPKeepaliveSocketOptions(),
+				ListenerFiltersTimeout:           protobuf.Duration(30 * time.Second),
+				ContinueOnListenerFiltersTimeout: true,
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.ProxyProtocol(),
+					envoy_v3.TLSInspector(),
+				),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"whatever.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters:         envoy_v3.Filters(httpsFilterFor("whatever.example.com")),
+				}},
+				SocketOptions:                    envoy_v3.TCPKeepaliveSocketOptions(),
+				ListenerFiltersTimeout:           protobuf.Duration(30 * time.Second),
+				ContinueOnListenerFiltersTimeout: true,
+			}),
+		},
+		"drain type modify-only": {
+			ListenerConfig: ListenerConfig{
+				DrainType: "modify-only",
+			},
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						TLS: []networking_v1.IngressTLS{{
+							Hosts:      []string{"whatever.example.com"},
+							SecretName: "secret",
+						}},
+						Rules: []networking_v1.IngressRule{{
+							Host: "whatever.example.com",
+							IngressRuleValue: networking_v1.IngressRuleValue{
+								HTTP: &networking_v1.HTTPIngressRuleValue{
+									Paths: []networking_v1.HTTPIngressPath{{
+										Backend: *backend("kuard", 8080),
+									}},
+								},
+							},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:          ENVOY_HTTP_LISTENER,
+				Address:       envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+				DrainType:     envoy_listener_v3.Listener_MODIFY_ONLY,
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"whatever.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters:         envoy_v3.Filters(httpsFilterFor("whatever.example.com")),
+				}},
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+				DrainType:     envoy_listener_v3.Listener_MODIFY_ONLY,
+			}),
+		},
+		"socket options": {
+			ListenerConfig: ListenerConfig{
+				SocketOptions: ListenerSocketOptions{
+					TCPKeepaliveIdle:       60,
+					TCPKeepaliveInterval:   10,
+					TCPFastOpenQueueLength: 256,
+					ReusePort:              true,
+					Freebind:               true,
+				},
+			},
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						DefaultBackend: backend("kuard", 8080),
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     8080,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:                   ENVOY_HTTP_LISTENER,
+				Address:                envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains:           envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions:          envoy_v3.TCPKeepaliveSocketOptionsWith(60, 10, 9),
+				TcpFastOpenQueueLength: protobuf.UInt32(256),
+				ReusePort:              true,
+				Freebind:               protobuf.Bool(true),
+			}),
+		},
+		"tcp keepalive disabled": {
+			ListenerConfig: ListenerConfig{
+				SocketOptions: ListenerSocketOptions{
+					DisableTCPKeepalive: true,
+				},
+			},
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						DefaultBackend: backend("kuard", 8080),
+					},
 				},
 				&v1.Service{
 					ObjectMeta: metav1.ObjectMeta{
@@ -736,28 +1113,9 @@ func TestListenerVisit(t *testing.T) {
 				},
 			},
 			want: listenermap(&envoy_listener_v3.Listener{
-				Name:    ENVOY_HTTP_LISTENER,
-				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
-				ListenerFilters: envoy_v3.ListenerFilters(
-					envoy_v3.ProxyProtocol(),
-				),
-				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
-				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
-			}, &envoy_listener_v3.Listener{
-				Name:    ENVOY_HTTPS_LISTENER,
-				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
-				ListenerFilters: envoy_v3.ListenerFilters(
-					envoy_v3.ProxyProtocol(),
-					envoy_v3.TLSInspector(),
-				),
-				FilterChains: []*envoy_listener_v3.FilterChain{{
-					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
-						ServerNames: []string{"whatever.example.com"},
-					},
-					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
-					Filters:         envoy_v3.Filters(httpsFilterFor("whatever.example.com")),
-				}},
-				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+				Name:         ENVOY_HTTP_LISTENER,
+				Address:      envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
 			}),
 		},
 		"--envoy-http-access-log": {
@@ -1352,14 +1710,246 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
 			}),
 		},
-		"httpproxy with fallback certificate and with stream idle timeout set": {
+		"httpproxy with fallback certificate and with stream idle timeout set": {
+			fallbackCertificate: &types.NamespacedName{
+				Name:      "fallbacksecret",
+				Namespace: "default",
+			},
+			ListenerConfig: ListenerConfig{
+				Timeouts: contourconfig.Timeouts{
+					StreamIdle: timeout.DurationSetting(90 * time.Second),
+				},
+			},
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &contour_api_v1.TLS{
+								SecretName:                "secret",
+								EnableFallbackCertificate: true,
+							},
+						},
+						Routes: []contour_api_v1.Route{
+							{
+								Services: []contour_api_v1.Service{
+									{
+										Name: "backend",
+										Port: 80,
+									},
+								},
+							},
+						},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "fallbacksecret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(
+					envoy_v3.HTTPConnectionManagerBuilder().
+						RouteConfigName(ENVOY_HTTP_LISTENER).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						DefaultFilters().
+						StreamIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+						Get(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+						AddFilter(envoy_v3.FilterMisdirectedRequests("www.example.com")).
+						DefaultFilters().
+						MetricsPrefix(ENVOY_HTTPS_LISTENER).
+						RouteConfigName(path.Join("https", "www.example.com")).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						StreamIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+						Get(),
+					),
+				}, {
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						TransportProtocol: "tls",
+					},
+					TransportSocket: transportSocket("fallbacksecret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+						DefaultFilters().
+						MetricsPrefix(ENVOY_HTTPS_LISTENER).
+						RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						StreamIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+						Get(),
+					),
+					Name: "fallback-certificate",
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
+		"httpproxy with fallback certificate and with delayed close timeout set": {
+			fallbackCertificate: &types.NamespacedName{
+				Name:      "fallbacksecret",
+				Namespace: "default",
+			},
+			ListenerConfig: ListenerConfig{
+				Timeouts: contourconfig.Timeouts{
+					DelayedClose: timeout.DurationSetting(90 * time.Second),
+				},
+			},
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &contour_api_v1.TLS{
+								SecretName:                "secret",
+								EnableFallbackCertificate: true,
+							},
+						},
+						Routes: []contour_api_v1.Route{
+							{
+								Services: []contour_api_v1.Service{
+									{
+										Name: "backend",
+										Port: 80,
+									},
+								},
+							},
+						},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "fallbacksecret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(
+					envoy_v3.HTTPConnectionManagerBuilder().
+						RouteConfigName(ENVOY_HTTP_LISTENER).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						DefaultFilters().
+						DelayedCloseTimeout(timeout.DurationSetting(90 * time.Second)).
+						Get(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+						AddFilter(envoy_v3.FilterMisdirectedRequests("www.example.com")).
+						DefaultFilters().
+						MetricsPrefix(ENVOY_HTTPS_LISTENER).
+						RouteConfigName(path.Join("https", "www.example.com")).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						DelayedCloseTimeout(timeout.DurationSetting(90 * time.Second)).
+						Get(),
+					),
+				}, {
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						TransportProtocol: "tls",
+					},
+					TransportSocket: transportSocket("fallbacksecret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+						DefaultFilters().
+						MetricsPrefix(ENVOY_HTTPS_LISTENER).
+						RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						DelayedCloseTimeout(timeout.DurationSetting(90 * time.Second)).
+						Get(),
+					),
+					Name: "fallback-certificate",
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
+		"httpproxy with fallback certificate and with max connection duration set": {
 			fallbackCertificate: &types.NamespacedName{
 				Name:      "fallbacksecret",
 				Namespace: "default",
 			},
 			ListenerConfig: ListenerConfig{
 				Timeouts: contourconfig.Timeouts{
-					StreamIdle: timeout.DurationSetting(90 * time.Second),
+					MaxConnectionDuration: timeout.DurationSetting(90 * time.Second),
 				},
 			},
 			objs: []interface{}{
@@ -1426,7 +2016,7 @@ func TestListenerVisit(t *testing.T) {
 						RouteConfigName(ENVOY_HTTP_LISTENER).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
 						DefaultFilters().
-						StreamIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
 						Get(),
 				),
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
@@ -1444,7 +2034,7 @@ func TestListenerVisit(t *testing.T) {
 						MetricsPrefix(ENVOY_HTTPS_LISTENER).
 						RouteConfigName(path.Join("https", "www.example.com")).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
-						StreamIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
 						Get(),
 					),
 				}, {
@@ -1457,7 +2047,7 @@ func TestListenerVisit(t *testing.T) {
 						MetricsPrefix(ENVOY_HTTPS_LISTENER).
 						RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
-						StreamIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
 						Get(),
 					),
 					Name: "fallback-certificate",
@@ -1468,14 +2058,11 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
 			}),
 		},
-		"httpproxy with fallback certificate and with delayed close timeout set": {
-			fallbackCertificate: &types.NamespacedName{
-				Name:      "fallbacksecret",
-				Namespace: "default",
-			},
+		"httpproxy with connection timeout policy": {
 			ListenerConfig: ListenerConfig{
 				Timeouts: contourconfig.Timeouts{
-					DelayedClose: timeout.DurationSetting(90 * time.Second),
+					MaxConnectionDuration:         timeout.DurationSetting(90 * time.Second),
+					ConnectionShutdownGracePeriod: timeout.DurationSetting(10 * time.Second),
 				},
 			},
 			objs: []interface{}{
@@ -1488,8 +2075,11 @@ func TestListenerVisit(t *testing.T) {
 						VirtualHost: &contour_api_v1.VirtualHost{
 							Fqdn: "www.example.com",
 							TLS: &contour_api_v1.TLS{
-								SecretName:                "secret",
-								EnableFallbackCertificate: true,
+								SecretName: "secret",
+							},
+							ConnectionTimeoutPolicy: &contour_api_v1.ConnectionTimeoutPolicy{
+								MaxConnectionDuration: "30m",
+								DelayedCloseTimeout:   "infinity",
 							},
 						},
 						Routes: []contour_api_v1.Route{
@@ -1512,14 +2102,6 @@ func TestListenerVisit(t *testing.T) {
 					Type: "kubernetes.io/tls",
 					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
 				},
-				&v1.Secret{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "fallbacksecret",
-						Namespace: "default",
-					},
-					Type: "kubernetes.io/tls",
-					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
-				},
 				&v1.Service{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "backend",
@@ -1542,7 +2124,8 @@ func TestListenerVisit(t *testing.T) {
 						RouteConfigName(ENVOY_HTTP_LISTENER).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
 						DefaultFilters().
-						DelayedCloseTimeout(timeout.DurationSetting(90 * time.Second)).
+						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
+						ConnectionShutdownGracePeriod(timeout.DurationSetting(10 * time.Second)).
 						Get(),
 				),
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
@@ -1560,23 +2143,11 @@ func TestListenerVisit(t *testing.T) {
 						MetricsPrefix(ENVOY_HTTPS_LISTENER).
 						RouteConfigName(path.Join("https", "www.example.com")).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
-						DelayedCloseTimeout(timeout.DurationSetting(90 * time.Second)).
-						Get(),
-					),
-				}, {
-					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
-						TransportProtocol: "tls",
-					},
-					TransportSocket: transportSocket("fallbacksecret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
-					Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
-						DefaultFilters().
-						MetricsPrefix(ENVOY_HTTPS_LISTENER).
-						RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
-						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
-						DelayedCloseTimeout(timeout.DurationSetting(90 * time.Second)).
+						MaxConnectionDuration(timeout.DurationSetting(30 * time.Minute)).
+						ConnectionShutdownGracePeriod(timeout.DurationSetting(10 * time.Second)).
+						DelayedCloseTimeout(timeout.DisabledSetting()).
 						Get(),
 					),
-					Name: "fallback-certificate",
 				}},
 				ListenerFilters: envoy_v3.ListenerFilters(
 					envoy_v3.TLSInspector(),
@@ -1584,15 +2155,13 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
 			}),
 		},
-		"httpproxy with fallback certificate and with max connection duration set": {
-			fallbackCertificate: &types.NamespacedName{
-				Name:      "fallbacksecret",
-				Namespace: "default",
-			},
+		"httpproxy with header limits policy": {
 			ListenerConfig: ListenerConfig{
-				Timeouts: contourconfig.Timeouts{
-					MaxConnectionDuration: timeout.DurationSetting(90 * time.Second),
+				HeaderLimits: dag.HeaderLimits{
+					MaxRequestHeadersKB:    96,
+					MaxRequestHeadersCount: 200,
 				},
+				RejectInvalidRequests: true,
 			},
 			objs: []interface{}{
 				&contour_api_v1.HTTPProxy{
@@ -1604,8 +2173,10 @@ func TestListenerVisit(t *testing.T) {
 						VirtualHost: &contour_api_v1.VirtualHost{
 							Fqdn: "www.example.com",
 							TLS: &contour_api_v1.TLS{
-								SecretName:                "secret",
-								EnableFallbackCertificate: true,
+								SecretName: "secret",
+							},
+							HeaderLimitsPolicy: &contour_api_v1.HeaderLimitsPolicy{
+								MaxRequestHeadersCount: 50,
 							},
 						},
 						Routes: []contour_api_v1.Route{
@@ -1628,14 +2199,6 @@ func TestListenerVisit(t *testing.T) {
 					Type: "kubernetes.io/tls",
 					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
 				},
-				&v1.Secret{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "fallbacksecret",
-						Namespace: "default",
-					},
-					Type: "kubernetes.io/tls",
-					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
-				},
 				&v1.Service{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "backend",
@@ -1658,7 +2221,9 @@ func TestListenerVisit(t *testing.T) {
 						RouteConfigName(ENVOY_HTTP_LISTENER).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
 						DefaultFilters().
-						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
+						MaxRequestHeadersKB(96).
+						MaxRequestHeadersCount(200).
+						RejectInvalidRequests(true).
 						Get(),
 				),
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
@@ -1676,23 +2241,11 @@ func TestListenerVisit(t *testing.T) {
 						MetricsPrefix(ENVOY_HTTPS_LISTENER).
 						RouteConfigName(path.Join("https", "www.example.com")).
 						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
-						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
-						Get(),
-					),
-				}, {
-					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
-						TransportProtocol: "tls",
-					},
-					TransportSocket: transportSocket("fallbacksecret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
-					Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
-						DefaultFilters().
-						MetricsPrefix(ENVOY_HTTPS_LISTENER).
-						RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
-						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
-						MaxConnectionDuration(timeout.DurationSetting(90 * time.Second)).
+						MaxRequestHeadersKB(96).
+						MaxRequestHeadersCount(50).
+						RejectInvalidRequests(true).
 						Get(),
 					),
-					Name: "fallback-certificate",
 				}},
 				ListenerFilters: envoy_v3.ListenerFilters(
 					envoy_v3.TLSInspector(),
@@ -2575,6 +3128,115 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
 			}),
 		},
+		"httpproxy with h2c enabled in listener config": {
+			ListenerConfig: ListenerConfig{
+				DefaultHTTPVersions: []envoy_v3.HTTPVersionType{envoy_v3.HTTPVersion1},
+				EnableH2C:           true,
+			},
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []contour_api_v1.Route{{
+							Conditions: []contour_api_v1.MatchCondition{{
+								Prefix: "/",
+							}},
+							Services: []contour_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(
+					envoy_v3.HTTPConnectionManagerBuilder().
+						RouteConfigName(ENVOY_HTTP_LISTENER).
+						MetricsPrefix(ENVOY_HTTP_LISTENER).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						DefaultFilters().
+						Codec(envoy_v3.HTTPVersionAuto).
+						Get(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
+		"httpproxy with default host for HTTP/1.0 set in listener config": {
+			ListenerConfig: ListenerConfig{
+				DefaultHostForHTTP10: "www.example.com",
+			},
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []contour_api_v1.Route{{
+							Conditions: []contour_api_v1.MatchCondition{{
+								Prefix: "/",
+							}},
+							Services: []contour_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(
+					envoy_v3.HTTPConnectionManagerBuilder().
+						RouteConfigName(ENVOY_HTTP_LISTENER).
+						MetricsPrefix(ENVOY_HTTP_LISTENER).
+						AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+						DefaultFilters().
+						DefaultHostForHTTP10("www.example.com").
+						Get(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
 		"httpproxy with XffNumTrustedHops set in listener config": {
 			ListenerConfig: ListenerConfig{
 				XffNumTrustedHops: 1,
@@ -3363,6 +4025,159 @@ func TestListenerVisit(t *testing.T) {
 	}
 }
 
+// TestListenerVisitUnrelatedVirtualHost checks that adding a secure
+// virtual host only adds a filter chain to the HTTPS listener, so that
+// Envoy can update the listener in place without draining the
+// connections of the other virtual hosts.
+func TestListenerVisitUnrelatedVirtualHost(t *testing.T) {
+	proxy := func(name string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: name + ".example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: "secret",
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "backend",
+						Port: 80,
+					}},
+				}},
+			},
+		}
+	}
+
+	objs := []interface{}{
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: "kubernetes.io/tls",
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backend",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     80,
+				}},
+			},
+		},
+		proxy("b"),
+	}
+
+	var before, after ListenerCache
+	before.OnChange(buildDAG(t, objs...))
+	after.OnChange(buildDAG(t, append(objs, proxy("a"), proxy("c"))...))
+
+	// The HTTP listener doesn't depend on the virtual hosts.
+	protobuf.ExpectEqual(t, before.values[ENVOY_HTTP_LISTENER], after.values[ENVOY_HTTP_LISTENER])
+
+	beforeHTTPS := before.values[ENVOY_HTTPS_LISTENER]
+	afterHTTPS := after.values[ENVOY_HTTPS_LISTENER]
+	require.Len(t, beforeHTTPS.FilterChains, 1)
+	require.Len(t, afterHTTPS.FilterChains, 3)
+
+	// The filter chain of the existing virtual host is unchanged.
+	protobuf.ExpectEqual(t, beforeHTTPS.FilterChains[0], afterHTTPS.FilterChains[1])
+
+	// Only the filter chains of the HTTPS listener changed.
+	beforeHTTPS = proto.Clone(beforeHTTPS).(*envoy_listener_v3.Listener)
+	afterHTTPS = proto.Clone(afterHTTPS).(*envoy_listener_v3.Listener)
+	beforeHTTPS.FilterChains = nil
+	afterHTTPS.FilterChains = nil
+	protobuf.ExpectEqual(t, beforeHTTPS, afterHTTPS)
+}
+
+func TestListenerVisitDeterministic(t *testing.T) {
+	proxy := func(name string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: name + ".example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName:                "secret",
+						EnableFallbackCertificate: true,
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "backend",
+						Port: 80,
+					}},
+				}},
+			},
+		}
+	}
+
+	objs := []interface{}{
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: "kubernetes.io/tls",
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backend",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     80,
+				}},
+			},
+		},
+		proxy("a"),
+		proxy("b"),
+		proxy("c"),
+		proxy("d"),
+	}
+
+	fallback := &types.NamespacedName{Name: "secret", Namespace: "default"}
+
+	marshal := func(objs []interface{}) []byte {
+		var lc ListenerCache
+		lc.OnChange(buildDAGFallback(t, fallback, objs...))
+
+		buf := proto.NewBuffer(nil)
+		buf.SetDeterministic(true)
+		require.NoError(t, buf.Marshal(lc.values[ENVOY_HTTPS_LISTENER]))
+		return buf.Bytes()
+	}
+
+	want := marshal(objs)
+	for i := 0; i < 10; i++ {
+		shuffled := make([]interface{}, len(objs))
+		copy(shuffled, objs)
+		rand.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		require.Equal(t, want, marshal(shuffled))
+	}
+}
+
 func transportSocket(secretname string, tlsMinProtoVersion envoy_tls_v3.TlsParameters_TlsProtocol, cipherSuites []string, alpnprotos ...string) *envoy_core_v3.TransportSocket {
 	secret := &dag.Secret{
 		Object: &v1.Secret{
@@ -3379,6 +4194,57 @@ func transportSocket(secretname string, tlsMinProtoVersion envoy_tls_v3.TlsParam
 	)
 }
 
+// TestListenerVisitUDPProxy checks that a UDP proxy gets a UDP
+// listener on its port, without the options of the TCP listeners.
+func TestListenerVisitUDPProxy(t *testing.T) {
+	root := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: "ingress_udp_53",
+			Port: 53,
+			UDPProxy: &dag.UDPProxy{
+				Cluster: &dag.Cluster{
+					Upstream: &dag.Service{
+						Weighted: dag.WeightedService{
+							ServiceName:      "dns",
+							ServiceNamespace: "default",
+							ServicePort: v1.ServicePort{
+								Name:     "dns",
+								Protocol: "UDP",
+								Port:     53,
+							},
+						},
+					},
+				},
+			},
+		}},
+	}
+
+	lc := ListenerCache{
+		Config: ListenerConfig{
+			ConnectionBalancer: "exact",
+		},
+	}
+	lc.OnChange(root)
+
+	protobuf.ExpectEqual(t, listenermap(&envoy_listener_v3.Listener{
+		Name:    "ingress_udp_53",
+		Address: envoy_v3.UDPSocketAddress("0.0.0.0", 53),
+		ListenerFilters: envoy_v3.ListenerFilters(
+			&envoy_listener_v3.ListenerFilter{
+				Name: "envoy.filters.udp_listener.udp_proxy",
+				ConfigType: &envoy_listener_v3.ListenerFilter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&udp.UdpProxyConfig{
+						StatPrefix: "ingress_udp_53",
+						RouteSpecifier: &udp.UdpProxyConfig_Cluster{
+							Cluster: "default/dns/53/e9a6f622e3",
+						},
+					}),
+				},
+			},
+		),
+	}), lc.values)
+}
+
 func listenermap(listeners ...*envoy_listener_v3.Listener) map[string]*envoy_listener_v3.Listener {
 	m := make(map[string]*envoy_listener_v3.Listener)
 	for _, l := range listeners {
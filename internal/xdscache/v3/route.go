@@ -14,6 +14,7 @@
 package v3
 
 import (
+	"crypto/sha256"
 	"path"
 	"sort"
 	"sync"
@@ -33,16 +34,66 @@ import (
 type RouteCache struct {
 	mu     sync.Mutex
 	values map[string]*envoy_route_v3.RouteConfiguration
+	hashes map[string][sha256.Size]byte
 	contour.Cond
 }
 
 // Update replaces the contents of the cache with the supplied map.
+// Only watchers of RouteConfigurations whose contents were added,
+// changed or removed are notified, so a change to one virtual host's
+// "https/<fqdn>" configuration does not churn the shared "ingress_http"
+// configuration, or any other.
 func (c *RouteCache) Update(v map[string]*envoy_route_v3.RouteConfiguration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	hashes := make(map[string][sha256.Size]byte, len(v))
+	var changed []string
+	for name, rc := range v {
+		hash, ok := routeConfigurationHash(rc)
+		if !ok {
+			changed = append(changed, name)
+			continue
+		}
+		hashes[name] = hash
+
+		if prev, ok := c.hashes[name]; ok && prev == hash {
+			// Unchanged, keep serving the existing value.
+			v[name] = c.values[name]
+			continue
+		}
+		changed = append(changed, name)
+	}
+	for name := range c.hashes {
+		if _, ok := v[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+
+	first := c.hashes == nil
 	c.values = v
-	c.Cond.Notify()
+	c.hashes = hashes
+
+	switch {
+	case first:
+		// Always notify on the first update so that watchers
+		// receive an initial response, even an empty one.
+		c.Cond.Notify()
+	case len(changed) > 0:
+		sort.Strings(changed)
+		c.Cond.Notify(changed...)
+	}
+}
+
+// routeConfigurationHash returns a digest of the deterministic
+// wire encoding of rc, or false if rc could not be encoded.
+func routeConfigurationHash(rc *envoy_route_v3.RouteConfiguration) ([sha256.Size]byte, bool) {
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(rc); err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256(buf.Bytes()), true
 }
 
 // Contents returns a copy of the cache's contents.
@@ -50,7 +101,7 @@ func (c *RouteCache) Contents() []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var values []*envoy_route_v3.RouteConfiguration
+	values := make([]*envoy_route_v3.RouteConfiguration, 0, len(c.values))
 	for _, v := range c.values {
 		values = append(values, v)
 	}
@@ -64,7 +115,7 @@ func (c *RouteCache) Query(names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var values []*envoy_route_v3.RouteConfiguration
+	values := make([]*envoy_route_v3.RouteConfiguration, 0, len(names))
 	for _, n := range names {
 		v, ok := c.values[n]
 		if !ok {
@@ -105,7 +156,7 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 				// envoy.RouteRoute.
 				return &envoy_route_v3.Route{
 					Match:  envoy_v3.RouteMatch(route),
-					Action: envoy_v3.UpgradeHTTPS(),
+					Action: envoy_v3.UpgradeHTTPSRedirect(route.HTTPSRedirectPolicy),
 				}
 			case route.DirectResponse != nil:
 				return &envoy_route_v3.Route{
@@ -137,6 +188,18 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 					}
 					rt.TypedPerFilterConfig["envoy.filters.http.local_ratelimit"] = envoy_v3.LocalRateLimitConfig(route.RateLimitPolicy.Local, "vhost."+vhost.Name)
 				}
+				if route.RateLimitPolicy != nil && route.RateLimitPolicy.Global != nil && route.RateLimitPolicy.Global.Disabled {
+					if rt.TypedPerFilterConfig == nil {
+						rt.TypedPerFilterConfig = map[string]*any.Any{}
+					}
+					rt.TypedPerFilterConfig["envoy.filters.http.ratelimit"] = envoy_v3.GlobalRateLimitsDisabled()
+				}
+				for name, config := range envoy_v3.RouteFiltersDisabled(route, "vhost."+vhost.Name) {
+					if rt.TypedPerFilterConfig == nil {
+						rt.TypedPerFilterConfig = map[string]*any.Any{}
+					}
+					rt.TypedPerFilterConfig[name] = config
+				}
 
 				return rt
 			}
@@ -180,6 +243,12 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 					}
 					rt.TypedPerFilterConfig["envoy.filters.http.local_ratelimit"] = envoy_v3.LocalRateLimitConfig(route.RateLimitPolicy.Local, "vhost."+vhost.Name)
 				}
+				if route.RateLimitPolicy != nil && route.RateLimitPolicy.Global != nil && route.RateLimitPolicy.Global.Disabled {
+					if rt.TypedPerFilterConfig == nil {
+						rt.TypedPerFilterConfig = map[string]*any.Any{}
+					}
+					rt.TypedPerFilterConfig["envoy.filters.http.ratelimit"] = envoy_v3.GlobalRateLimitsDisabled()
+				}
 
 				// If authorization is enabled on this host, we may need to set per-route filter overrides.
 				if vhost.AuthorizationService != nil {
@@ -197,6 +266,15 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 					}
 				}
 
+				// Disabled filters override the per-route
+				// configs set above.
+				for name, config := range envoy_v3.RouteFiltersDisabled(route, "vhost."+vhost.Name) {
+					if rt.TypedPerFilterConfig == nil {
+						rt.TypedPerFilterConfig = map[string]*any.Any{}
+					}
+					rt.TypedPerFilterConfig[name] = config
+				}
+
 				return rt
 			}
 		}
@@ -207,8 +285,21 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 			routeConfigs[name] = envoy_v3.RouteConfiguration(name)
 		}
 
+		toEnvoySecureVirtualHost := func() *envoy_route_v3.VirtualHost {
+			evh := toEnvoyVirtualHost(&vhost.VirtualHost, routes, toEnvoyRoute)
+			if vhost.DownstreamValidation != nil && vhost.DownstreamValidation.ForwardClientCertificateStatus {
+				evh.Routes = envoy_v3.ClientCertificateStatusRoutes(evh.Routes)
+			}
+			if vhost.HSTSPolicy != nil {
+				evh.ResponseHeadersToAdd = envoy_v3.HeaderValueList(map[string]string{
+					"Strict-Transport-Security": envoy_v3.StrictTransportSecurity(vhost.HSTSPolicy),
+				}, false)
+			}
+			return evh
+		}
+
 		sortRoutes(routes)
-		routeConfigs[name].VirtualHosts = append(routeConfigs[name].VirtualHosts, toEnvoyVirtualHost(&vhost.VirtualHost, routes, toEnvoyRoute))
+		routeConfigs[name].VirtualHosts = append(routeConfigs[name].VirtualHosts, toEnvoySecureVirtualHost())
 
 		// A fallback route configuration contains routes for all the vhosts that have the fallback certificate enabled.
 		// When a request is received, the default TLS filterchain will accept the connection,
@@ -219,7 +310,7 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 				routeConfigs[ENVOY_FALLBACK_ROUTECONFIG] = envoy_v3.RouteConfiguration(ENVOY_FALLBACK_ROUTECONFIG)
 			}
 
-			routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts = append(routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts, toEnvoyVirtualHost(&vhost.VirtualHost, routes, toEnvoyRoute))
+			routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts = append(routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts, toEnvoySecureVirtualHost())
 		}
 	}
 
@@ -231,9 +322,10 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 }
 
 // sortRoutes sorts the given Route slice in place. Routes are ordered
-// first by path match type, path match value via string comparison and
-// then by the length of the HeaderMatch slice (if any). The HeaderMatch
-// slice is also ordered by the matching header name.
+// first by priority, then by path match type, path match value via
+// string comparison and then by the length of the HeaderMatch slice
+// (if any). The HeaderMatch slice is also ordered by the matching
+// header name.
 // We sort dag.Route objects before converting to Envoy types to ensure
 // more accurate ordering of route matches. Contour route match types may
 // be implemented by Envoy route match types that change over time, or by
@@ -258,6 +350,8 @@ func toEnvoyVirtualHost(vh *dag.VirtualHost, routes []*dag.Route, toEnvoyRoute f
 	}
 
 	evh := envoy_v3.VirtualHost(vh.Name, envoyRoutes...)
+	evh.Domains = append(evh.Domains, vh.Aliases...)
+	evh.VirtualClusters = envoy_v3.VirtualClusters(routes)
 	if vh.CORSPolicy != nil {
 		evh.Cors = envoy_v3.CORSPolicy(vh.CORSPolicy)
 	}
@@ -23,6 +23,7 @@ import (
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/dag"
@@ -136,6 +137,64 @@ func TestRouteCacheQuery(t *testing.T) {
 	}
 }
 
+func TestRouteCacheUpdateNotifiesChangedOnly(t *testing.T) {
+	routes := func(https string) map[string]*envoy_route_v3.RouteConfiguration {
+		return map[string]*envoy_route_v3.RouteConfiguration{
+			"ingress_http": envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("www.example.com",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/"),
+						Action: routecluster("default/backend/80/da39a3ee5e"),
+					},
+				),
+			),
+			"https/www.example.com": envoy_v3.RouteConfiguration("https/www.example.com",
+				envoy_v3.VirtualHost("www.example.com",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/"),
+						Action: routecluster(https),
+					},
+				),
+			),
+		}
+	}
+
+	notified := func(ch chan int) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var rc RouteCache
+	rc.Update(routes("default/backend/80/da39a3ee5e"))
+	shared := rc.Query([]string{"ingress_http"})
+
+	httpCh := make(chan int, 1)
+	httpsCh := make(chan int, 1)
+	rc.Register(httpCh, 1, "ingress_http")
+	rc.Register(httpsCh, 1, "https/www.example.com")
+
+	// Identical contents should not notify anyone.
+	rc.Update(routes("default/backend/80/da39a3ee5e"))
+	assert.False(t, notified(httpCh))
+	assert.False(t, notified(httpsCh))
+
+	// Changing the secure virtual host only notifies its watchers.
+	rc.Update(routes("default/other/80/da39a3ee5e"))
+	assert.False(t, notified(httpCh))
+	assert.True(t, notified(httpsCh))
+
+	// The unchanged shared configuration is served as before.
+	assert.Same(t, shared[0], rc.Query([]string{"ingress_http"})[0])
+
+	// Removing a configuration notifies its watchers.
+	rc.Update(map[string]*envoy_route_v3.RouteConfiguration{})
+	assert.True(t, notified(httpCh))
+}
+
 func TestRouteVisit(t *testing.T) {
 	tests := map[string]struct {
 		objs                []interface{}
@@ -1656,6 +1715,92 @@ func TestRouteVisit(t *testing.T) {
 					)),
 			),
 		},
+		"httpproxy with hstsPolicy with tls": {
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &contour_api_v1.TLS{
+								SecretName: "secret",
+							},
+							HSTSPolicy: &contour_api_v1.HSTSPolicy{
+								MaxAge:            "8760h",
+								IncludeSubDomains: true,
+								Preload:           true,
+							},
+						},
+						Routes: []contour_api_v1.Route{{
+							Services: []contour_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: routeConfigurations(
+				envoy_v3.RouteConfiguration("ingress_http",
+					envoy_v3.VirtualHost("www.example.com",
+						&envoy_route_v3.Route{
+							Match: routePrefix("/"),
+							Action: &envoy_route_v3.Route_Redirect{
+								Redirect: &envoy_route_v3.RedirectAction{
+									SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_HttpsRedirect{
+										HttpsRedirect: true,
+									},
+								},
+							},
+						},
+					),
+				),
+				envoy_v3.RouteConfiguration("https/www.example.com",
+					func() *envoy_route_v3.VirtualHost {
+						vh := envoy_v3.VirtualHost("www.example.com",
+							&envoy_route_v3.Route{
+								Match:  routePrefix("/"),
+								Action: routecluster("default/backend/80/da39a3ee5e"),
+							},
+						)
+						vh.ResponseHeadersToAdd = []*envoy_core_v3.HeaderValueOption{{
+							Header: &envoy_core_v3.HeaderValue{
+								Key:   "Strict-Transport-Security",
+								Value: "max-age=31536000; includeSubDomains; preload",
+							},
+							Append: &wrappers.BoolValue{
+								Value: false,
+							},
+						}}
+						return vh
+					}(),
+				),
+			),
+		},
 		"httpproxy with pathPrefix includes": {
 			objs: []interface{}{
 				&contour_api_v1.HTTPProxy{
@@ -2302,6 +2447,69 @@ func TestRouteVisit(t *testing.T) {
 				),
 			),
 		},
+		"httpproxy with route-level disabled filters": {
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []contour_api_v1.Route{{
+							Conditions: []contour_api_v1.MatchCondition{{
+								Prefix: "/",
+							}},
+							Services: []contour_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+							DisabledFilters: []contour_api_v1.HTTPFilterName{
+								contour_api_v1.HTTPFilterCORS,
+								contour_api_v1.HTTPFilterLocalRateLimit,
+								contour_api_v1.HTTPFilterRateLimit,
+							},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: routeConfigurations(
+				envoy_v3.RouteConfiguration("ingress_http",
+					envoy_v3.VirtualHost("www.example.com",
+						&envoy_route_v3.Route{
+							Match: routePrefix("/"),
+							Action: &envoy_route_v3.Route_Route{
+								Route: &envoy_route_v3.RouteAction{
+									ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+										Cluster: "default/backend/80/da39a3ee5e",
+									},
+									Cors: envoy_v3.CORSPolicyDisabled(),
+								},
+							},
+							TypedPerFilterConfig: map[string]*any.Any{
+								"envoy.filters.http.local_ratelimit": envoy_v3.LocalRateLimitConfig(&dag.LocalRateLimitPolicy{Disabled: true}, "vhost.www.example.com"),
+								"envoy.filters.http.ratelimit":       envoy_v3.GlobalRateLimitsDisabled(),
+							},
+						},
+					),
+				),
+			),
+		},
 		"httpproxy with fallback certificate": {
 			fallbackCertificate: &types.NamespacedName{
 				Name:      "fallbacksecret",
@@ -0,0 +1,88 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	envoy_service_runtime_v3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/protobuf"
+)
+
+// RuntimeSettings holds the runtime values served to Envoy.
+type RuntimeSettings struct {
+	// MaxConnectionsPerListener limits the number of downstream
+	// connections of each HTTP and HTTPS listener. If zero, the
+	// number of connections is not limited.
+	MaxConnectionsPerListener uint32
+
+	// GlobalMaxConnections limits the number of downstream
+	// connections of all listeners. If zero, the limit set in
+	// the bootstrap applies.
+	GlobalMaxConnections uint32
+}
+
+// RuntimeCache manages the contents of the gRPC RTDS cache.
+type RuntimeCache struct {
+	runtime *envoy_service_runtime_v3.Runtime
+	contour.Cond
+}
+
+// NewRuntimeCache returns a RuntimeCache serving the dynamic runtime
+// layer built from the supplied settings.
+func NewRuntimeCache(settings RuntimeSettings) *RuntimeCache {
+	values := map[string]uint32{}
+
+	if settings.MaxConnectionsPerListener > 0 {
+		// Listeners that don't exist are not affected by
+		// their keys, so set the keys of the dual-stack
+		// listeners of both IP families unconditionally.
+		for _, name := range []string{ENVOY_HTTP_LISTENER, ENVOY_HTTPS_LISTENER} {
+			for _, suffix := range []string{"", "_ipv4", "_ipv6"} {
+				values[envoy_v3.ListenerConnectionLimitKey(name+suffix)] = settings.MaxConnectionsPerListener
+			}
+		}
+	}
+
+	if settings.GlobalMaxConnections > 0 {
+		values[envoy_v3.GlobalDownstreamMaxConnectionsKey] = settings.GlobalMaxConnections
+	}
+
+	return &RuntimeCache{
+		runtime: envoy_v3.RuntimeLayer(values),
+	}
+}
+
+// Contents returns the dynamic runtime layer.
+func (c *RuntimeCache) Contents() []proto.Message {
+	return protobuf.AsMessages([]*envoy_service_runtime_v3.Runtime{c.runtime})
+}
+
+// Query returns the dynamic runtime layer if it is named in names.
+func (c *RuntimeCache) Query(names []string) []proto.Message {
+	for _, name := range names {
+		if name == c.runtime.Name {
+			return c.Contents()
+		}
+	}
+	return nil
+}
+
+func (*RuntimeCache) TypeURL() string { return resource.RuntimeType }
+
+// OnChange is a no-op since the runtime values don't depend on the DAG.
+func (*RuntimeCache) OnChange(*dag.DAG) {}
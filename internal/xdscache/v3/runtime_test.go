@@ -0,0 +1,70 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeCacheContents(t *testing.T) {
+	tests := map[string]struct {
+		settings RuntimeSettings
+		want     map[string]uint32
+	}{
+		"no limits": {
+			want: map[string]uint32{},
+		},
+		"max connections per listener": {
+			settings: RuntimeSettings{
+				MaxConnectionsPerListener: 1000,
+			},
+			want: map[string]uint32{
+				"envoy.resource_limits.listener.ingress_http.connection_limit":       1000,
+				"envoy.resource_limits.listener.ingress_http_ipv4.connection_limit":  1000,
+				"envoy.resource_limits.listener.ingress_http_ipv6.connection_limit":  1000,
+				"envoy.resource_limits.listener.ingress_https.connection_limit":      1000,
+				"envoy.resource_limits.listener.ingress_https_ipv4.connection_limit": 1000,
+				"envoy.resource_limits.listener.ingress_https_ipv6.connection_limit": 1000,
+			},
+		},
+		"global max connections": {
+			settings: RuntimeSettings{
+				GlobalMaxConnections: 50000,
+			},
+			want: map[string]uint32{
+				"overload.global_downstream_max_connections": 50000,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rc := NewRuntimeCache(tc.settings)
+			protobuf.ExpectEqual(t, []proto.Message{envoy_v3.RuntimeLayer(tc.want)}, rc.Contents())
+		})
+	}
+}
+
+func TestRuntimeCacheQuery(t *testing.T) {
+	rc := NewRuntimeCache(RuntimeSettings{MaxConnectionsPerListener: 1000})
+
+	assert.Len(t, rc.Query([]string{"dynamic"}), 1)
+	assert.Empty(t, rc.Query([]string{"static"}))
+	assert.Empty(t, rc.Query(nil))
+}
@@ -60,7 +60,7 @@ func (c *SecretCache) Update(v map[string]*envoy_tls_v3.Secret) {
 func (c *SecretCache) Contents() []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []*envoy_tls_v3.Secret
+	values := make([]*envoy_tls_v3.Secret, 0, len(c.values)+len(c.staticValues))
 	for _, v := range c.values {
 		values = append(values, v)
 	}
@@ -74,7 +74,7 @@ func (c *SecretCache) Contents() []proto.Message {
 func (c *SecretCache) Query(names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []*envoy_tls_v3.Secret
+	values := make([]*envoy_tls_v3.Secret, 0, len(names))
 	for _, n := range names {
 		// we can only return secrets where their value is
 		// known. if the secret is not registered in the cache
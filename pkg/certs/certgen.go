@@ -14,6 +14,9 @@
 package certs
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha1" // nolint:gosec
@@ -21,8 +24,10 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"time"
 )
 
@@ -50,6 +55,12 @@ const (
 	// configuring Subject Alt Names on the certificates.
 	DefaultDNSName = "cluster.local"
 
+	// KeyTypeRSA generates 2048 bit RSA keys.
+	KeyTypeRSA = "rsa"
+
+	// KeyTypeECDSA generates ECDSA keys on the P-256 curve.
+	KeyTypeECDSA = "ecdsa"
+
 	// keySize sets the RSA key size to 2048 bits. This is minimum recommended size
 	// for RSA keys.
 	keySize = 2048
@@ -61,6 +72,14 @@ type Configuration struct {
 	// Lifetime is the number of days for which certificates will be valid.
 	Lifetime uint
 
+	// CALifetime is the number of days for which the CA certificate
+	// will be valid. Defaults to Lifetime.
+	CALifetime uint
+
+	// KeyType is the type of the generated keys, either KeyTypeRSA
+	// or KeyTypeECDSA. Defaults to KeyTypeRSA.
+	KeyType string
+
 	// Namespace is the Kubernetes namespace name to add to the generated
 	// certificates Subject Alternate Name values.
 	Namespace string
@@ -75,12 +94,21 @@ type Configuration struct {
 
 	// EnvoyServiceName holds the name of the Envoy service name.
 	EnvoyServiceName string
+
+	// ContourSANs holds additional DNS names or IP addresses to add
+	// to the Contour certificate's Subject Alt Names.
+	ContourSANs []string
+
+	// EnvoySANs holds additional DNS names or IP addresses to add
+	// to the Envoy certificate's Subject Alt Names.
+	EnvoySANs []string
 }
 
 // Certificates contains a set of Certificates as []byte each holding
 // the CA Cert along with with Contour & Envoy Certs.
 type Certificates struct {
 	CACertificate      []byte
+	CAPrivateKey       []byte
 	ContourCertificate []byte
 	ContourPrivateKey  []byte
 	EnvoyCertificate   []byte
@@ -96,49 +124,139 @@ func GenerateCerts(config *Configuration) (*Certificates, error) {
 		config = &Configuration{}
 	}
 
+	if err := validKeyType(config.KeyType); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
-	expiry := now.Add(24 * time.Duration(uint32OrDefault(config.Lifetime, DefaultCertificateLifetime)) * time.Hour)
-	caCertPEM, caKeyPEM, err := newCA("Project Contour", expiry)
+	caExpiry := now.Add(days(uint32OrDefault(config.CALifetime, uint32OrDefault(config.Lifetime, DefaultCertificateLifetime))))
+	caCertPEM, caKeyPEM, err := newCA("Project Contour", caExpiry, config.KeyType)
 	if err != nil {
 		return nil, err
 	}
 
-	contourCert, contourKey, err := newCert(caCertPEM,
-		caKeyPEM,
-		expiry,
+	certs := &Certificates{
+		CACertificate: caCertPEM,
+		CAPrivateKey:  caKeyPEM,
+	}
+
+	if err := issueContourCert(config, certs); err != nil {
+		return nil, err
+	}
+
+	if err := issueEnvoyCert(config, certs); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+// RotateCerts renews the Contour and Envoy certificates in existing that
+// are missing, not signed by the existing CA, or expire within renewBefore,
+// signing them with the existing CA so that it remains trusted. If the CA
+// is missing or itself expires within renewBefore, all the certificates
+// are regenerated. The returned bool reports whether any certificate was
+// renewed.
+func RotateCerts(config *Configuration, existing *Certificates, renewBefore time.Duration) (*Certificates, bool, error) {
+	if config == nil {
+		config = &Configuration{}
+	}
+
+	if err := validKeyType(config.KeyType); err != nil {
+		return nil, false, err
+	}
+
+	deadline := time.Now().Add(renewBefore)
+
+	caCert, err := parseCert(existing.CACertificate)
+	if err != nil || len(existing.CAPrivateKey) == 0 || caCert.NotAfter.Before(deadline) {
+		certs, err := GenerateCerts(config)
+		return certs, err == nil, err
+	}
+
+	certs := *existing
+	renewed := false
+
+	if needsRenewal(certs.ContourCertificate, caCert, deadline) {
+		if err := issueContourCert(config, &certs); err != nil {
+			return nil, false, err
+		}
+		renewed = true
+	}
+
+	if needsRenewal(certs.EnvoyCertificate, caCert, deadline) {
+		if err := issueEnvoyCert(config, &certs); err != nil {
+			return nil, false, err
+		}
+		renewed = true
+	}
+
+	return &certs, renewed, nil
+}
+
+// needsRenewal returns true if the certificate cannot be parsed, was
+// not signed by the CA, or expires before the deadline.
+func needsRenewal(certPEM []byte, caCert *x509.Certificate, deadline time.Time) bool {
+	cert, err := parseCert(certPEM)
+	if err != nil {
+		return true
+	}
+	if err := cert.CheckSignatureFrom(caCert); err != nil {
+		return true
+	}
+	return cert.NotAfter.Before(deadline)
+}
+
+func parseCert(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("failed to decode certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func issueContourCert(config *Configuration, certs *Certificates) error {
+	cert, key, err := newCert(certs.CACertificate,
+		certs.CAPrivateKey,
+		time.Now().Add(days(uint32OrDefault(config.Lifetime, DefaultCertificateLifetime))),
+		config.KeyType,
 		stringOrDefault(config.ContourServiceName, DefaultContourServiceName),
 		stringOrDefault(config.Namespace, DefaultNamespace),
 		stringOrDefault(config.DNSName, DefaultDNSName),
+		config.ContourSANs...,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	envoyCert, envoyKey, err := newCert(caCertPEM,
-		caKeyPEM,
-		expiry,
+	certs.ContourCertificate, certs.ContourPrivateKey = cert, key
+	return nil
+}
+
+func issueEnvoyCert(config *Configuration, certs *Certificates) error {
+	cert, key, err := newCert(certs.CACertificate,
+		certs.CAPrivateKey,
+		time.Now().Add(days(uint32OrDefault(config.Lifetime, DefaultCertificateLifetime))),
+		config.KeyType,
 		stringOrDefault(config.EnvoyServiceName, DefaultEnvoyServiceName),
 		stringOrDefault(config.Namespace, DefaultNamespace),
 		stringOrDefault(config.DNSName, DefaultDNSName),
+		config.EnvoySANs...,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &Certificates{
-		CACertificate:      caCertPEM,
-		ContourCertificate: contourCert,
-		ContourPrivateKey:  contourKey,
-		EnvoyCertificate:   envoyCert,
-		EnvoyPrivateKey:    envoyKey,
-	}, nil
+	certs.EnvoyCertificate, certs.EnvoyPrivateKey = cert, key
+	return nil
 }
 
-// newCert generates a new keypair given the CA keypair, the expiry time, the service name
-// ("contour" or "envoy"), and the Kubernetes namespace the service will run in (because
-// of the Kubernetes DNS schema.)
+// newCert generates a new keypair given the CA keypair, the expiry time, the key type,
+// the service name ("contour" or "envoy"), the Kubernetes namespace the service will
+// run in (because of the Kubernetes DNS schema), and any additional DNS names or IP
+// addresses to add to the Subject Alt Names.
 // The return values are cert, key, err.
-func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, service, namespace, dnsname string) ([]byte, []byte, error) {
+func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, keyType, service, namespace, dnsname string, sans ...string) ([]byte, []byte, error) {
 
 	caKeyPair, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
 	if err != nil {
@@ -148,12 +266,12 @@ func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, service, namespace, d
 	if err != nil {
 		return nil, nil, err
 	}
-	caKey, ok := caKeyPair.PrivateKey.(*rsa.PrivateKey)
+	caKey, ok := caKeyPair.PrivateKey.(crypto.Signer)
 	if !ok {
 		return nil, nil, fmt.Errorf("CA private key has unexpected type %T", caKeyPair.PrivateKey)
 	}
 
-	newKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	newKey, err := generateKey(keyType)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot generate key: %v", err)
 	}
@@ -166,22 +284,28 @@ func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, service, namespace, d
 		},
 		NotBefore:    now.UTC().AddDate(0, 0, -1),
 		NotAfter:     expiry.UTC(),
-		SubjectKeyId: bigIntHash(newKey.N),
-		KeyUsage: x509.KeyUsageDigitalSignature |
-			x509.KeyUsageDataEncipherment |
-			x509.KeyUsageKeyEncipherment |
-			x509.KeyUsageContentCommitment,
-		DNSNames: serviceNames(service, namespace, dnsname),
-	}
-	newCert, err := x509.CreateCertificate(rand.Reader, template, caCert, &newKey.PublicKey, caKey)
+		SubjectKeyId: subjectKeyID(newKey.Public()),
+		KeyUsage:     leafKeyUsage(keyType),
+		DNSNames:     serviceNames(service, namespace, dnsname),
+	}
+
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+
+	newCert, err := x509.CreateCertificate(rand.Reader, template, caCert, newKey.Public(), caKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	newKeyPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(newKey),
-	})
+	newKeyPEM, err := encodeKey(newKey)
+	if err != nil {
+		return nil, nil, err
+	}
 	newCertPEM := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
 		Bytes: newCert,
@@ -190,11 +314,11 @@ func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, service, namespace, d
 
 }
 
-// newCA generates a new CA, given the CA's CN and an expiry time.
+// newCA generates a new CA, given the CA's CN, an expiry time and the key type.
 // The return order is cacert, cakey, error.
-func newCA(cn string, expiry time.Time) ([]byte, []byte, error) {
+func newCA(cn string, expiry time.Time, keyType string) ([]byte, []byte, error) {
 
-	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	key, err := generateKey(keyType)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -209,12 +333,15 @@ func newCA(cn string, expiry time.Time) ([]byte, []byte, error) {
 		},
 		NotBefore:             now.UTC().AddDate(0, 0, -1),
 		NotAfter:              expiry.UTC(),
-		SubjectKeyId:          bigIntHash(key.N),
+		SubjectKeyId:          subjectKeyID(key.Public()),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
 		IsCA:                  true,
 		BasicConstraintsValid: true,
 	}
-	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if keyType == KeyTypeECDSA {
+		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -222,17 +349,79 @@ func newCA(cn string, expiry time.Time) ([]byte, []byte, error) {
 		Type:  "CERTIFICATE",
 		Bytes: certDER,
 	})
-	keyPEMData := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(key),
-	})
+	keyPEMData, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
 	return certPEMData, keyPEMData, nil
 }
 
+func validKeyType(keyType string) error {
+	switch keyType {
+	case "", KeyTypeRSA, KeyTypeECDSA:
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+func generateKey(keyType string) (crypto.Signer, error) {
+	if keyType == KeyTypeECDSA {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	return rsa.GenerateKey(rand.Reader, keySize)
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(k),
+		}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: der,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// leafKeyUsage returns the key usages for Contour and Envoy certificates.
+// ECDSA keys cannot be used for encipherment.
+func leafKeyUsage(keyType string) x509.KeyUsage {
+	if keyType == KeyTypeECDSA {
+		return x509.KeyUsageDigitalSignature
+	}
+	return x509.KeyUsageDigitalSignature |
+		x509.KeyUsageDataEncipherment |
+		x509.KeyUsageKeyEncipherment |
+		x509.KeyUsageContentCommitment
+}
+
 func newSerial(now time.Time) *big.Int {
 	return big.NewInt(int64(now.Nanosecond()))
 }
 
+// subjectKeyID generates a SubjectKeyId from the public key: the
+// modulus for RSA keys, and the marshaled point for ECDSA keys.
+func subjectKeyID(pub crypto.PublicKey) []byte {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return bigIntHash(k.N)
+	case *ecdsa.PublicKey:
+		return bytesHash(elliptic.Marshal(k.Curve, k.X, k.Y))
+	default:
+		return nil
+	}
+}
+
 // bigIntHash generates a SubjectKeyId by hashing the modulus of the private
 // key. This isn't one of the methods listed in RFC 5280 4.2.1.2, but that also
 // notes that other methods are acceptable.
@@ -242,8 +431,12 @@ func newSerial(now time.Time) *big.Int {
 // https://github.com/golang/go/issues/26676) also uses SHA-1, as recommended
 // by RFC 5280.
 func bigIntHash(n *big.Int) []byte {
-	h := sha1.New()    // nolint:gosec
-	h.Write(n.Bytes()) // nolint:errcheck
+	return bytesHash(n.Bytes())
+}
+
+func bytesHash(b []byte) []byte {
+	h := sha1.New() // nolint:gosec
+	h.Write(b)      // nolint:errcheck
 	return h.Sum(nil)
 }
 
@@ -256,6 +449,10 @@ func serviceNames(service, namespace, dnsname string) []string {
 	}
 }
 
+func days(n uint) time.Duration {
+	return 24 * time.Duration(n) * time.Hour
+}
+
 func stringOrDefault(val string, defaultval string) string {
 	if len(val) > 0 {
 		return val
@@ -112,6 +112,113 @@ func TestGenerateCerts(t *testing.T) {
 		wantEnvoyDNSName:   "envoy",
 		wantError:          nil,
 	})
+
+	run(t, "ecdsa keys", testcase{
+		config: &Configuration{
+			KeyType: KeyTypeECDSA,
+		},
+		wantContourDNSName: "contour",
+		wantEnvoyDNSName:   "envoy",
+		wantError:          nil,
+	})
+
+	run(t, "additional SANs", testcase{
+		config: &Configuration{
+			ContourSANs: []string{"contour.example.com"},
+			EnvoySANs:   []string{"envoy.example.com"},
+		},
+		wantContourDNSName: "contour.example.com",
+		wantEnvoyDNSName:   "envoy.example.com",
+		wantError:          nil,
+	})
+}
+
+func TestGenerateCertsKeyType(t *testing.T) {
+	got, err := GenerateCerts(&Configuration{KeyType: KeyTypeECDSA})
+	require.NoError(t, err)
+
+	for _, key := range [][]byte{got.CAPrivateKey, got.ContourPrivateKey, got.EnvoyPrivateKey} {
+		block, _ := pem.Decode(key)
+		require.NotNil(t, block)
+		assert.Equal(t, "EC PRIVATE KEY", block.Type)
+	}
+
+	_, err = GenerateCerts(&Configuration{KeyType: "dsa"})
+	assert.Error(t, err)
+}
+
+func TestGenerateCertsIPSAN(t *testing.T) {
+	got, err := GenerateCerts(&Configuration{ContourSANs: []string{"10.0.0.1"}})
+	require.NoError(t, err)
+
+	cert, err := parseCert(got.ContourCertificate)
+	require.NoError(t, err)
+	require.Len(t, cert.IPAddresses, 1)
+	assert.Equal(t, "10.0.0.1", cert.IPAddresses[0].String())
+}
+
+func TestGenerateCertsCALifetime(t *testing.T) {
+	got, err := GenerateCerts(&Configuration{Lifetime: 30, CALifetime: 3650})
+	require.NoError(t, err)
+
+	ca, err := parseCert(got.CACertificate)
+	require.NoError(t, err)
+	contour, err := parseCert(got.ContourCertificate)
+	require.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now().Add(3650*24*time.Hour), ca.NotAfter, time.Minute)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), contour.NotAfter, time.Minute)
+}
+
+func TestRotateCerts(t *testing.T) {
+	config := &Configuration{Lifetime: 30, CALifetime: 365}
+
+	existing, err := GenerateCerts(config)
+	require.NoError(t, err)
+
+	// Nothing expires within a week, so nothing is renewed.
+	got, renewed, err := RotateCerts(config, existing, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+	assert.Equal(t, existing, got)
+
+	// The leaf certificates expire within 60 days, but the CA does
+	// not, so they are renewed and the CA is preserved.
+	got, renewed, err = RotateCerts(config, existing, 60*24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, existing.CACertificate, got.CACertificate)
+	assert.Equal(t, existing.CAPrivateKey, got.CAPrivateKey)
+	assert.NotEqual(t, existing.ContourCertificate, got.ContourCertificate)
+	assert.NotEqual(t, existing.EnvoyCertificate, got.EnvoyCertificate)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(got.CACertificate))
+	assert.NoError(t, verifyCert(got.ContourCertificate, roots, "contour", time.Now()))
+	assert.NoError(t, verifyCert(got.EnvoyCertificate, roots, "envoy", time.Now()))
+
+	// A missing leaf certificate is issued by the existing CA.
+	partial := *existing
+	partial.EnvoyCertificate, partial.EnvoyPrivateKey = nil, nil
+	got, renewed, err = RotateCerts(config, &partial, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, existing.CACertificate, got.CACertificate)
+	assert.Equal(t, existing.ContourCertificate, got.ContourCertificate)
+	assert.NotEmpty(t, got.EnvoyCertificate)
+
+	// The CA expires within 400 days, so everything is regenerated.
+	got, renewed, err = RotateCerts(config, existing, 400*24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.NotEqual(t, existing.CACertificate, got.CACertificate)
+
+	// Without the CA key, everything is regenerated.
+	got, renewed, err = RotateCerts(config, &Certificates{CACertificate: existing.CACertificate}, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.NotEqual(t, existing.CACertificate, got.CACertificate)
+	assert.NotEmpty(t, got.CAPrivateKey)
 }
 
 func TestGeneratedCertsValid(t *testing.T) {
@@ -119,17 +226,17 @@ func TestGeneratedCertsValid(t *testing.T) {
 	now := time.Now()
 	expiry := now.Add(24 * 365 * time.Hour)
 
-	cacert, cakey, err := newCA("contour", expiry)
+	cacert, cakey, err := newCA("contour", expiry, KeyTypeRSA)
 	require.NoErrorf(t, err, "Failed to generate CA cert")
 
-	contourcert, _, err := newCert(cacert, cakey, expiry, "contour", "projectcontour", "cluster.local")
+	contourcert, _, err := newCert(cacert, cakey, expiry, KeyTypeRSA, "contour", "projectcontour", "cluster.local")
 	require.NoErrorf(t, err, "Failed to generate Contour cert")
 
 	roots := x509.NewCertPool()
 	ok := roots.AppendCertsFromPEM(cacert)
 	require.Truef(t, ok, "Failed to set up CA cert for testing, maybe it's an invalid PEM")
 
-	envoycert, _, err := newCert(cacert, cakey, expiry, "envoy", "projectcontour", "cluster.local")
+	envoycert, _, err := newCert(cacert, cakey, expiry, KeyTypeRSA, "envoy", "projectcontour", "cluster.local")
 	require.NoErrorf(t, err, "Failed to generate Envoy cert")
 
 	tests := map[string]struct {
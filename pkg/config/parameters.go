@@ -114,19 +114,35 @@ const JSONAccessLog AccessLogType = "json"
 type AccessLogFields []string
 
 func (a AccessLogFields) Validate() error {
+	fields := a.AsFieldMap()
 
-	for key, val := range a.AsFieldMap() {
+	for key, val := range fields {
 		if val == "" {
 			return fmt.Errorf("invalid JSON log field name %s", key)
 		}
 
+		for _, name := range strings.Split(key, ".") {
+			if name == "" {
+				return fmt.Errorf("invalid JSON log field name %q: nested field names must not be empty", key)
+			}
+		}
+
 		if jsonFields[key] == val {
 			continue
 		}
 
 		err := parseAccessLogFormat(val)
 		if err != nil {
-			return fmt.Errorf("invalid JSON field: %s", err)
+			return fmt.Errorf("invalid JSON log field %q: %s", key, err)
+		}
+	}
+
+	// A field can't both have a value and contain nested fields.
+	for key := range fields {
+		for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+			if _, ok := fields[key[:i]]; ok {
+				return fmt.Errorf("invalid JSON log field %q: conflicts with nested field %q", key[:i], key)
+			}
 		}
 	}
 
@@ -202,22 +218,22 @@ func parseAccessLogFormat(format string) error {
 	for _, f := range tokens {
 		op := f[2]
 		if op == "" {
-			return fmt.Errorf("invalid Envoy format: %s", f)
+			return fmt.Errorf("invalid Envoy format: %s", f[0])
 		}
 
 		_, okSimple := envoySimpleOperators[op]
 		_, okComplex := envoyComplexOperators[op]
 		if !okSimple && !okComplex {
-			return fmt.Errorf("invalid Envoy format: %s, invalid Envoy operator: %s", f, op)
+			return fmt.Errorf("invalid Envoy format: %s, invalid Envoy operator: %s", f[0], op)
 		}
 
 		if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "REQ_WITHOUT_QUERY") && f[3] == "" {
-			return fmt.Errorf("invalid Envoy format: %s, arguments required for operator: %s", f, op)
+			return fmt.Errorf("invalid Envoy format: %s, arguments required for operator: %s", f[0], op)
 		}
 
 		// START_TIME cannot not have truncation length.
 		if op == "START_TIME" && f[4] != "" {
-			return fmt.Errorf("invalid Envoy format: %s, operator %s cannot have truncation length", f, op)
+			return fmt.Errorf("invalid Envoy format: %s, operator %s cannot have truncation length", f[0], op)
 		}
 	}
 
@@ -365,6 +381,93 @@ type LeaderElectionParameters struct {
 	RetryPeriod   time.Duration `yaml:"retry-period,omitempty"`
 	Namespace     string        `yaml:"configmap-namespace,omitempty"`
 	Name          string        `yaml:"configmap-name,omitempty"`
+
+	// ReadinessTimeout is how long leader election may be lost
+	// before Contour reports itself as not ready. Leader election
+	// is lost when no leader has been observed, or when this
+	// Contour fails to renew the lease it holds. Zero disables
+	// the check.
+	ReadinessTimeout time.Duration `yaml:"readiness-timeout,omitempty"`
+}
+
+// ReadinessMode determines when Contour reports itself as ready.
+type ReadinessMode string
+
+// DefaultReadinessMode reports ready once the xDS server is listening.
+const DefaultReadinessMode ReadinessMode = "default"
+
+// SnapshotReadinessMode reports ready once the first complete
+// xDS snapshot has been generated.
+const SnapshotReadinessMode ReadinessMode = "snapshot"
+
+// Validate the readiness mode.
+func (m ReadinessMode) Validate() error {
+	switch m {
+	case "", DefaultReadinessMode, SnapshotReadinessMode:
+		return nil
+	default:
+		return fmt.Errorf("invalid readiness mode %q", m)
+	}
+}
+
+// ReadinessParameters holds the configuration of when Contour
+// reports itself as ready.
+type ReadinessParameters struct {
+	// Mode is either "default" or "snapshot". In "snapshot" mode,
+	// Contour only starts serving xDS, and only passes its /ready
+	// check, once the informer caches have synced and the first
+	// DAG built from them has populated the xDS caches.
+	Mode ReadinessMode `yaml:"mode,omitempty"`
+
+	// MaxWait is how long "snapshot" mode waits for the first
+	// snapshot before Contour reports ready regardless. Zero
+	// waits indefinitely.
+	MaxWait time.Duration `yaml:"max-wait,omitempty"`
+}
+
+// Validate the readiness parameters.
+func (p ReadinessParameters) Validate() error {
+	if err := p.Mode.Validate(); err != nil {
+		return err
+	}
+	if p.MaxWait < 0 {
+		return fmt.Errorf("invalid readiness max-wait %q: must not be negative", p.MaxWait)
+	}
+	return nil
+}
+
+// KubernetesClientParameters holds the configuration of the clients
+// Contour uses to talk to the Kubernetes API server.
+type KubernetesClientParameters struct {
+	// QPS is the sustained number of requests per second
+	// Contour may send to the API server. Zero uses the
+	// client-go default. A negative value disables client
+	// side rate limiting, leaving the API server's priority
+	// and fairness to queue Contour's requests.
+	QPS float32 `yaml:"qps,omitempty"`
+
+	// Burst is the number of requests Contour may send to the
+	// API server above QPS for short periods. Zero uses the
+	// client-go default.
+	Burst int `yaml:"burst,omitempty"`
+
+	// ResyncPeriod is how often informers replay every cached
+	// object to Contour, regardless of whether it changed. Zero
+	// uses the controller-runtime default.
+	ResyncPeriod time.Duration `yaml:"resync-period,omitempty"`
+}
+
+// Validate ensures that the Kubernetes client parameters are valid.
+func (k KubernetesClientParameters) Validate() error {
+	if k.Burst < 0 {
+		return fmt.Errorf("invalid Kubernetes client burst %d: must not be negative", k.Burst)
+	}
+
+	if k.ResyncPeriod < 0 {
+		return fmt.Errorf("invalid informer resync period %s: must not be negative", k.ResyncPeriod)
+	}
+
+	return nil
 }
 
 // TimeoutParameters holds various configurable proxy timeout values.
@@ -466,6 +569,55 @@ func (t TimeoutParameters) Validate() error {
 	return nil
 }
 
+// TimeoutPolicyParameters holds the default connect timeout for
+// upstream clusters, and the largest route timeouts that users may set.
+type TimeoutPolicyParameters struct {
+	// ConnectTimeout is the timeout for new connections to
+	// upstream Services. Defaults to 2s.
+	ConnectTimeout string `yaml:"connect-timeout,omitempty"`
+
+	// MaxResponseTimeout is the largest response timeout that an
+	// HTTPProxy route or Ingress may set. HTTPProxy routes that set a
+	// larger or infinite response timeout are rejected. Unlimited if unset.
+	MaxResponseTimeout string `yaml:"max-response-timeout,omitempty"`
+
+	// MaxIdleTimeout is the largest idle timeout that an HTTPProxy
+	// route may set. HTTPProxy routes that set a larger or infinite
+	// idle timeout are rejected. Unlimited if unset.
+	MaxIdleTimeout string `yaml:"max-idle-timeout,omitempty"`
+}
+
+// Validate the timeout policy parameters.
+func (t TimeoutPolicyParameters) Validate() error {
+	v := func(str string) error {
+		if str == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("must be greater than zero")
+		}
+		return nil
+	}
+
+	if err := v(t.ConnectTimeout); err != nil {
+		return fmt.Errorf("invalid connect timeout %q: %w", t.ConnectTimeout, err)
+	}
+
+	if err := v(t.MaxResponseTimeout); err != nil {
+		return fmt.Errorf("invalid max response timeout %q: %w", t.MaxResponseTimeout, err)
+	}
+
+	if err := v(t.MaxIdleTimeout); err != nil {
+		return fmt.Errorf("invalid max idle timeout %q: %w", t.MaxIdleTimeout, err)
+	}
+
+	return nil
+}
+
 type HeadersPolicy struct {
 	Set    map[string]string `yaml:"set,omitempty"`
 	Remove []string          `yaml:"remove,omitempty"`
@@ -485,6 +637,58 @@ func (h HeadersPolicy) Validate() error {
 	return nil
 }
 
+// LocalRateLimitParameters defines a default local rate limit policy.
+type LocalRateLimitParameters struct {
+	// Requests defines how many requests per unit of time should
+	// be allowed before rate limiting occurs.
+	Requests uint32 `yaml:"requests,omitempty"`
+
+	// Unit defines the period of time within which requests
+	// over the limit will be rate limited. Valid values are
+	// "second", "minute" and "hour".
+	Unit string `yaml:"unit,omitempty"`
+
+	// Burst defines the number of requests above the requests per
+	// unit that should be allowed within a short period of time.
+	Burst uint32 `yaml:"burst,omitempty"`
+
+	// ResponseStatusCode is the HTTP status code to use for responses
+	// to rate-limited requests. Defaults to 429 (Too Many Requests).
+	ResponseStatusCode uint32 `yaml:"response-status-code,omitempty"`
+
+	// ResponseHeadersToAdd defines the response headers to set
+	// when a request is rate-limited.
+	ResponseHeadersToAdd map[string]string `yaml:"response-headers-to-add,omitempty"`
+}
+
+// Validate the local rate limit parameters.
+func (l LocalRateLimitParameters) Validate() error {
+	if l.Requests == 0 {
+		if l.Unit != "" || l.Burst > 0 || l.ResponseStatusCode > 0 || len(l.ResponseHeadersToAdd) > 0 {
+			return errors.New("local rate limit requests must be set")
+		}
+		return nil
+	}
+
+	switch l.Unit {
+	case "second", "minute", "hour":
+	default:
+		return fmt.Errorf("invalid local rate limit unit %q", l.Unit)
+	}
+
+	if l.ResponseStatusCode > 0 && (l.ResponseStatusCode < 400 || l.ResponseStatusCode > 599) {
+		return fmt.Errorf("invalid local rate limit response status code %d: must be in the 400-599 range", l.ResponseStatusCode)
+	}
+
+	for key := range l.ResponseHeadersToAdd {
+		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
+			return fmt.Errorf("invalid header name %q: %v", key, msgs)
+		}
+	}
+
+	return nil
+}
+
 // PolicyParameters holds default policy used if not explicitly set by the user
 type PolicyParameters struct {
 	// RequestHeadersPolicy defines the request headers set/removed on all routes
@@ -495,6 +699,15 @@ type PolicyParameters struct {
 
 	// ApplyToIngress determines if the Policies will apply to ingress objects
 	ApplyToIngress bool `yaml:"applyToIngress,omitempty"`
+
+	// FilterExemptPrefixes is a list of path prefixes, e.g. /healthz,
+	// for which external authorization and global rate limiting are
+	// disabled on all virtual hosts.
+	FilterExemptPrefixes []string `yaml:"filter-exempt-prefixes,omitempty"`
+
+	// LocalRateLimit defines the default local rate limit
+	// policy of all virtual hosts.
+	LocalRateLimit LocalRateLimitParameters `yaml:"local-rate-limit,omitempty"`
 }
 
 // Validate the header parameters.
@@ -502,7 +715,17 @@ func (h PolicyParameters) Validate() error {
 	if err := h.RequestHeadersPolicy.Validate(); err != nil {
 		return err
 	}
-	return h.ResponseHeadersPolicy.Validate()
+	if err := h.ResponseHeadersPolicy.Validate(); err != nil {
+		return err
+	}
+
+	for _, prefix := range h.FilterExemptPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("invalid filter exempt prefix %q: must begin with a /", prefix)
+		}
+	}
+
+	return h.LocalRateLimit.Validate()
 }
 
 // ClusterParameters holds various configurable cluster values.
@@ -519,6 +742,123 @@ type ClusterParameters struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/cluster/v3/cluster.proto.html#envoy-v3-api-enum-config-cluster-v3-cluster-dnslookupfamily
 	// for more information.
 	DNSLookupFamily ClusterDNSFamilyType `yaml:"dns-lookup-family"`
+
+	// UseEndpointSlices configures Contour to watch EndpointSlices
+	// rather than Endpoints for the addresses of upstream Services.
+	// This is required for dual-stack Services, since Endpoints only
+	// carry addresses of the Service's primary IP family.
+	// Requires Kubernetes 1.21 or later.
+	UseEndpointSlices bool `yaml:"use-endpoint-slices,omitempty"`
+
+	// EndpointDrainDelay configures Contour to watch Pods, and to
+	// stop sending requests to the endpoints of a Pod as soon as the
+	// Pod starts terminating, rather than when its Endpoints are
+	// updated. The endpoints are kept draining for this long, so that
+	// requests in progress can complete, and then removed. If unset,
+	// Pods are not watched.
+	EndpointDrainDelay string `yaml:"endpoint-drain-delay,omitempty"`
+
+	// PreserveHeaderCase preserves the case of the HTTP/1 header
+	// names of requests sent to upstream clusters, as they were
+	// received by the listeners.
+	PreserveHeaderCase bool `yaml:"preserve-header-case,omitempty"`
+
+	// HealthyPanicThreshold is the percentage of healthy hosts of an
+	// upstream cluster below which Envoy ignores host health and
+	// balances requests across all hosts. If zero, panic mode is
+	// disabled.
+	HealthyPanicThreshold uint32 `yaml:"healthy-panic-threshold,omitempty"`
+
+	// ZoneAwareRoutingMinClusterSize is the minimum number of hosts
+	// of an upstream cluster for zone aware routing to be used. If
+	// zero, Envoy's default applies.
+	ZoneAwareRoutingMinClusterSize uint64 `yaml:"zone-aware-routing-min-cluster-size,omitempty"`
+
+	// InsecureSkipVerifyNamespaces are the namespaces in which the
+	// validation of the certificates of upstream Services may be
+	// skipped with `insecureSkipVerify`. The value "*" allows it in
+	// every namespace. If empty, it is not allowed in any namespace.
+	InsecureSkipVerifyNamespaces []string `yaml:"insecure-skip-verify-namespaces,omitempty"`
+
+	// StaticClusters are clusters of fixed addresses, not backed by
+	// Kubernetes Services, that are always sent to Envoy, e.g. for
+	// the stats sinks or trace collectors referenced by the Envoy
+	// configuration.
+	StaticClusters []StaticClusterParameters `yaml:"static-clusters,omitempty"`
+}
+
+// StaticClusterParameters define a cluster of fixed addresses.
+type StaticClusterParameters struct {
+	// Name is the name of the Envoy cluster. It cannot contain a
+	// "/", so that it cannot clash with the clusters of Services.
+	Name string `yaml:"name"`
+
+	// Endpoints are the addresses of the cluster.
+	Endpoints []StaticClusterEndpoint `yaml:"endpoints"`
+
+	// Protocol is the protocol of the endpoints. If the value is
+	// "h2c", Envoy uses HTTP/2 without TLS. If not set, HTTP
+	// requests are sent with HTTP/1.1.
+	Protocol string `yaml:"protocol,omitempty"`
+}
+
+// StaticClusterEndpoint is the address of an endpoint of a
+// static cluster.
+type StaticClusterEndpoint struct {
+	// Address is the IP address or DNS name of the endpoint.
+	// If it is a DNS name, it is resolved by Envoy.
+	Address string `yaml:"address"`
+
+	// Port is the port of the endpoint.
+	Port int `yaml:"port"`
+}
+
+// Validate ensures that the cluster parameters are valid.
+func (p ClusterParameters) Validate() error {
+	if err := p.DNSLookupFamily.Validate(); err != nil {
+		return err
+	}
+
+	if p.EndpointDrainDelay != "" {
+		if _, err := time.ParseDuration(p.EndpointDrainDelay); err != nil {
+			return fmt.Errorf("invalid endpoint drain delay %q: %w", p.EndpointDrainDelay, err)
+		}
+	}
+
+	if p.HealthyPanicThreshold > 100 {
+		return fmt.Errorf("invalid healthy panic threshold %d, must be a percentage between 0 and 100", p.HealthyPanicThreshold)
+	}
+
+	names := map[string]struct{}{}
+	for _, sc := range p.StaticClusters {
+		if sc.Name == "" || strings.Contains(sc.Name, "/") {
+			return fmt.Errorf("invalid static cluster name %q, must be non-empty and cannot contain '/'", sc.Name)
+		}
+		if _, ok := names[sc.Name]; ok {
+			return fmt.Errorf("duplicate static cluster %q", sc.Name)
+		}
+		names[sc.Name] = struct{}{}
+
+		if len(sc.Endpoints) == 0 {
+			return fmt.Errorf("static cluster %q must have at least one endpoint", sc.Name)
+		}
+		for _, ep := range sc.Endpoints {
+			if ep.Address == "" {
+				return fmt.Errorf("static cluster %q endpoint must have an address", sc.Name)
+			}
+			if ep.Port < 1 || ep.Port > 65535 {
+				return fmt.Errorf("invalid static cluster %q endpoint port %d, must be between 1 and 65535", sc.Name, ep.Port)
+			}
+		}
+
+		switch sc.Protocol {
+		case "", "h2c":
+		default:
+			return fmt.Errorf("invalid static cluster %q protocol %q, only 'h2c' is supported", sc.Name, sc.Protocol)
+		}
+	}
+
+	return nil
 }
 
 // NetworkParameters hold various configurable network values.
@@ -534,6 +874,12 @@ type NetworkParameters struct {
 	// Configure the port used to access the Envoy Admin interface.
 	// If configured to port "0" then the admin interface is disabled.
 	EnvoyAdminPort int `yaml:"admin-port,omitempty"`
+
+	// DualStack binds Envoy listeners that are bound to the IPv4
+	// or IPv6 wildcard address to the wildcard address of the other
+	// IP family too, using a separate listener, so that both IPv4
+	// and IPv6 clients can connect.
+	DualStack bool `yaml:"dual-stack,omitempty"`
 }
 
 // ListenerParameters hold various configurable listener values.
@@ -542,6 +888,157 @@ type ListenerParameters struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/api/v2/listener.proto#envoy-api-msg-listener-connectionbalanceconfig
 	// for more information.
 	ConnectionBalancer string `yaml:"connection-balancer"`
+
+	// FilterTimeout is the timeout for the listener filters, such as the
+	// PROXY protocol and TLS inspector filters, to finish inspecting a new
+	// connection. Set to "infinity" to disable the timeout entirely.
+	// If not set, Envoy's default of 15s applies.
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/listener/v3/listener.proto#envoy-v3-api-field-config-listener-v3-listener-listener-filters-timeout
+	// for more information.
+	FilterTimeout string `yaml:"filter-timeout,omitempty"`
+
+	// DrainType sets when Envoy drains the connections of the listeners.
+	// Valid values are "default" and "modify-only". If not set, defaults
+	// to "default".
+	//
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/listener/v3/listener.proto#envoy-v3-api-enum-config-listener-v3-listener-draintype
+	// for more information.
+	DrainType string `yaml:"drain-type,omitempty"`
+
+	// ContinueOnFilterTimeout passes a connection to the filter chains when
+	// its listener filters time out, rather than closing it.
+	ContinueOnFilterTimeout bool `yaml:"continue-on-filter-timeout,omitempty"`
+
+	// HTTPFilters overrides the listener filters enabled on the HTTP listener.
+	HTTPFilters ListenerFilterParameters `yaml:"http-filters,omitempty"`
+
+	// HTTPSFilters overrides the listener filters enabled on the HTTPS listener.
+	HTTPSFilters ListenerFilterParameters `yaml:"https-filters,omitempty"`
+
+	// ProxyProtocol configures the PROXY protocol listener filter.
+	ProxyProtocol ProxyProtocolParameters `yaml:"proxy-protocol,omitempty"`
+
+	// SocketOptions configures the socket options of the listeners.
+	SocketOptions SocketOptionsParameters `yaml:"socket-options,omitempty"`
+
+	// MaxConnectionsPerListener limits the number of downstream
+	// connections of each HTTP and HTTPS listener. If zero, the
+	// number of connections is not limited.
+	MaxConnectionsPerListener uint32 `yaml:"max-connections-per-listener,omitempty"`
+
+	// GlobalMaxConnections limits the number of downstream connections
+	// of all listeners, overriding the limit set in the Envoy bootstrap.
+	// If zero, the limit set in the bootstrap applies.
+	GlobalMaxConnections uint32 `yaml:"global-max-connections,omitempty"`
+
+	// EnableH2C accepts HTTP/2 over cleartext (h2c) with prior
+	// knowledge on the HTTP listener, whatever the default HTTP
+	// versions.
+	EnableH2C bool `yaml:"enable-h2c,omitempty"`
+
+	// DefaultHostForHTTP10 is the host that HTTP/1.0 requests
+	// without a Host header are handled as. If not set, such
+	// requests are rejected.
+	DefaultHostForHTTP10 string `yaml:"default-host-for-http10,omitempty"`
+
+	// PreserveHeaderCase preserves the case of the HTTP/1 header
+	// names of requests and responses received by the listeners.
+	PreserveHeaderCase bool `yaml:"preserve-header-case,omitempty"`
+
+	// HeaderLimits limits the headers of requests received
+	// by the listeners.
+	HeaderLimits HeaderLimitsParameters `yaml:"header-limits,omitempty"`
+}
+
+// SocketOptionsParameters hold the socket options of the listeners.
+type SocketOptionsParameters struct {
+	// TCPKeepalive configures TCP keep-alive on downstream connections.
+	TCPKeepalive TCPKeepaliveParameters `yaml:"tcp-keepalive,omitempty"`
+
+	// TCPFastOpenQueueLength enables TCP Fast Open with the given
+	// queue length. If not set or zero, TCP Fast Open is not enabled.
+	TCPFastOpenQueueLength uint32 `yaml:"tcp-fast-open-queue-length,omitempty"`
+
+	// ReusePort sets SO_REUSEPORT on the listener sockets.
+	ReusePort bool `yaml:"reuse-port,omitempty"`
+
+	// Freebind sets IP_FREEBIND on the listener sockets, so that
+	// listeners can bind to addresses not yet assigned to the host.
+	Freebind bool `yaml:"freebind,omitempty"`
+}
+
+// TCPKeepaliveParameters hold the TCP keep-alive settings of the listeners.
+type TCPKeepaliveParameters struct {
+	// Disabled disables TCP keep-alive on downstream connections.
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	// IdleTime is the time, in seconds, a connection needs to be
+	// idle before keep-alive probes are sent. Defaults to 45.
+	IdleTime uint32 `yaml:"idle-time,omitempty"`
+
+	// Interval is the time, in seconds, between keep-alive probes.
+	// Defaults to 5.
+	Interval uint32 `yaml:"interval,omitempty"`
+
+	// Probes is the number of unanswered keep-alive probes to send
+	// before the connection is closed. Defaults to 9.
+	Probes uint32 `yaml:"probes,omitempty"`
+}
+
+// HeaderLimitsParameters hold the limits of the headers of requests
+// received by the listeners.
+type HeaderLimitsParameters struct {
+	// MaxRequestHeadersKB is the maximum size, in KiB, of the headers
+	// of a request. If zero, Envoy's default of 60 KiB applies.
+	MaxRequestHeadersKB uint32 `yaml:"max-request-headers-kb,omitempty"`
+
+	// MaxRequestHeadersCount is the maximum number of headers of a
+	// request. If zero, Envoy's default of 100 applies.
+	MaxRequestHeadersCount uint32 `yaml:"max-request-headers-count,omitempty"`
+
+	// RejectAction is how requests that exceed the limits, or are
+	// otherwise invalid, are rejected. Valid values are
+	// "close-connection" and "reject-request". If not set, defaults
+	// to "close-connection".
+	RejectAction string `yaml:"reject-action,omitempty"`
+}
+
+// ProxyProtocolParameters hold the PROXY protocol listener filter settings.
+type ProxyProtocolParameters struct {
+	// Enabled configures all listeners to expect a PROXY v1 or v2
+	// preamble. This is equivalent to the --use-proxy-protocol flag.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// TLVs are the PROXY protocol v2 TLVs to extract into the
+	// dynamic metadata of a connection.
+	TLVs []ProxyProtocolTLV `yaml:"tlvs,omitempty"`
+}
+
+// ProxyProtocolTLV is a PROXY protocol v2 TLV to extract into
+// dynamic metadata.
+type ProxyProtocolTLV struct {
+	// Type is the type of the TLV, between 0 and 255.
+	Type int `yaml:"type"`
+
+	// MetadataNamespace is the dynamic metadata namespace to store
+	// the value in. Defaults to "envoy.filters.listener.proxy_protocol".
+	MetadataNamespace string `yaml:"metadata-namespace,omitempty"`
+
+	// Key is the dynamic metadata key to store the value in.
+	Key string `yaml:"key"`
+}
+
+// ListenerFilterParameters hold the listener filters to enable on a listener.
+type ListenerFilterParameters struct {
+	// ProxyProtocol enables the PROXY protocol listener filter.
+	// If not set, the --use-proxy-protocol flag applies.
+	ProxyProtocol *bool `yaml:"proxy-protocol,omitempty"`
+
+	// TLSInspector enables the TLS inspector listener filter.
+	// If not set, it is disabled on the HTTP listener and enabled
+	// on the HTTPS listener.
+	TLSInspector *bool `yaml:"tls-inspector,omitempty"`
 }
 
 func (p *ListenerParameters) Validate() error {
@@ -552,6 +1049,49 @@ func (p *ListenerParameters) Validate() error {
 	if p.ConnectionBalancer != "" && p.ConnectionBalancer != "exact" {
 		return fmt.Errorf("invalid listener connection balancer value %q, only 'exact' connection balancing is supported for now", p.ConnectionBalancer)
 	}
+
+	switch p.DrainType {
+	case "", "default", "modify-only":
+	default:
+		return fmt.Errorf("invalid listener drain type %q, must be 'default' or 'modify-only'", p.DrainType)
+	}
+
+	switch p.FilterTimeout {
+	case "", "infinity", "infinite":
+	default:
+		if _, err := time.ParseDuration(p.FilterTimeout); err != nil {
+			return fmt.Errorf("invalid listener filter timeout %q: %w", p.FilterTimeout, err)
+		}
+	}
+
+	if p.HeaderLimits.MaxRequestHeadersKB > 8192 {
+		return fmt.Errorf("invalid listener max request headers size %d KiB, must be at most 8192", p.HeaderLimits.MaxRequestHeadersKB)
+	}
+
+	switch p.HeaderLimits.RejectAction {
+	case "", "close-connection", "reject-request":
+	default:
+		return fmt.Errorf("invalid listener header limits reject action %q, must be 'close-connection' or 'reject-request'", p.HeaderLimits.RejectAction)
+	}
+
+	if ka := p.SocketOptions.TCPKeepalive; ka.Disabled && (ka.IdleTime != 0 || ka.Interval != 0 || ka.Probes != 0) {
+		return fmt.Errorf("invalid listener TCP keep-alive settings, cannot be set when TCP keep-alive is disabled")
+	}
+
+	tlvTypes := map[int]struct{}{}
+	for _, tlv := range p.ProxyProtocol.TLVs {
+		if tlv.Type < 0 || tlv.Type > 255 {
+			return fmt.Errorf("invalid PROXY protocol TLV type %d, must be between 0 and 255", tlv.Type)
+		}
+		if tlv.Key == "" {
+			return fmt.Errorf("PROXY protocol TLV type %d must have a key", tlv.Type)
+		}
+		if _, ok := tlvTypes[tlv.Type]; ok {
+			return fmt.Errorf("duplicate PROXY protocol TLV type %d", tlv.Type)
+		}
+		tlvTypes[tlv.Type] = struct{}{}
+	}
+
 	return nil
 }
 
@@ -565,6 +1105,10 @@ type Parameters struct {
 	InCluster  bool   `yaml:"incluster,omitempty"`
 	Kubeconfig string `yaml:"kubeconfig,omitempty"`
 
+	// KubernetesClient holds the rate limits and informer
+	// settings of Contour's Kubernetes clients.
+	KubernetesClient KubernetesClientParameters `yaml:"kubernetes-client,omitempty"`
+
 	// Server contains parameters for the xDS server.
 	Server ServerParameters `yaml:"server,omitempty"`
 
@@ -596,6 +1140,19 @@ type Parameters struct {
 	// permitInsecure field in HTTPProxy.
 	DisablePermitInsecure bool `yaml:"disablePermitInsecure,omitempty"`
 
+	// PermitInsecurePrefixes is a list of path prefixes that are
+	// always served over HTTP, without a redirect to HTTPS, on
+	// virtual hosts that have TLS enabled, e.g.
+	// /.well-known/acme-challenge/ for ACME HTTP-01 challenges.
+	PermitInsecurePrefixes []string `yaml:"permitInsecurePrefixes,omitempty"`
+
+	// DenyByDefault only programs the root HTTPProxies and Ingresses
+	// in the root namespaces, and the HTTPProxies they include. If no
+	// root namespaces are set, none are programmed. Unlike without it,
+	// Contour still watches every namespace, so that roots can include
+	// HTTPProxies outside the root namespaces.
+	DenyByDefault bool `yaml:"denyByDefault,omitempty"`
+
 	// DisableAllowChunkedLength disables the RFC-compliant Envoy behavior to
 	// strip the "Content-Length" header if "Transfer-Encoding: chunked" is
 	// also set. This is an emergency off-switch to revert back to Envoy's
@@ -609,15 +1166,28 @@ type Parameters struct {
 	// TODO(youngnick): put a link to the issue and CVE here.
 	EnableExternalNameService bool `yaml:"enableExternalNameService,omitempty"`
 
+	// EnableStaticEndpoints allows HTTPProxy services to route to
+	// static endpoints rather than to Kubernetes Services.
+	// Defaults to disabled, since static endpoints can route traffic
+	// to any address that Envoy can reach.
+	EnableStaticEndpoints bool `yaml:"enableStaticEndpoints,omitempty"`
+
 	// LeaderElection contains leader election parameters.
 	// Note: This method of configuring leader election is deprecated,
 	// please use command line flags instead.
 	LeaderElection LeaderElectionParameters `yaml:"leaderelection,omitempty"`
 
+	// Readiness configures when Contour reports itself as ready.
+	Readiness ReadinessParameters `yaml:"readiness,omitempty"`
+
 	// Timeouts holds various configurable timeouts that can
 	// be set in the config file.
 	Timeouts TimeoutParameters `yaml:"timeouts,omitempty"`
 
+	// TimeoutPolicy holds the default upstream connect timeout
+	// and the largest route timeouts that users may set.
+	TimeoutPolicy TimeoutPolicyParameters `yaml:"timeout-policy,omitempty"`
+
 	// Policy specifies default policy applied if not overridden by the user
 	Policy PolicyParameters `yaml:"policy,omitempty"`
 
@@ -651,6 +1221,175 @@ type Parameters struct {
 
 	// MetricsParameters holds configurable parameters for Contour and Envoy metrics.
 	Metrics MetricsParameters `yaml:"metrics,omitempty"`
+
+	// Tracing optionally holds the parameters for exporting
+	// OpenTelemetry traces of the Contour control plane.
+	Tracing *TracingParameters `yaml:"tracing,omitempty"`
+
+	// ACMEChallengeSolver optionally routes ACME HTTP-01 challenge
+	// requests on every insecure virtual host to a solver Service.
+	ACMEChallengeSolver *ACMEChallengeSolverParameters `yaml:"acmeChallengeSolver,omitempty"`
+
+	// ListenerProfiles define listener settings that are served to
+	// specific Envoy nodes in place of the top level settings.
+	// Listener profiles require the "envoy" xDS server type.
+	ListenerProfiles []ListenerProfile `yaml:"listener-profiles,omitempty"`
+
+	// RemoteClusters are other Kubernetes clusters whose endpoints
+	// are added to those of the Services with the same namespace
+	// and name in this cluster.
+	RemoteClusters []RemoteClusterParameters `yaml:"remote-clusters,omitempty"`
+}
+
+// RemoteClusterParameters defines another Kubernetes cluster whose
+// endpoints are aggregated with those of this cluster.
+type RemoteClusterParameters struct {
+	// Name is the unique name of the remote cluster.
+	Name string `yaml:"name"`
+
+	// Kubeconfig is the path to the kubeconfig file used to
+	// connect to the remote cluster.
+	Kubeconfig string `yaml:"kubeconfig"`
+}
+
+// Validate the remote cluster parameters.
+func (r RemoteClusterParameters) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("remote cluster name must not be empty")
+	}
+
+	if r.Kubeconfig == "" {
+		return fmt.Errorf("remote cluster %q: kubeconfig must not be empty", r.Name)
+	}
+
+	return nil
+}
+
+// ListenerProfile defines the listener settings served to the Envoy
+// nodes bound to it. Settings that are not set in the profile are
+// inherited from the top level settings.
+type ListenerProfile struct {
+	// Name is the unique name of the profile.
+	Name string `yaml:"name"`
+
+	// NodeIDs binds the Envoy nodes with these IDs, as set by
+	// Envoy's --service-node flag, to the profile.
+	NodeIDs []string `yaml:"node-ids,omitempty"`
+
+	// NodeClusters binds the Envoy nodes with these clusters, as set
+	// by Envoy's --service-cluster flag, to the profile.
+	NodeClusters []string `yaml:"node-clusters,omitempty"`
+
+	// Timeouts overrides the listener timeouts.
+	Timeouts *TimeoutParameters `yaml:"timeouts,omitempty"`
+
+	// AccessLogFormat overrides the access log format.
+	AccessLogFormat AccessLogType `yaml:"accesslog-format,omitempty"`
+
+	// AccessLogFormatString overrides the access log format string
+	// used when the access log format is envoy.
+	AccessLogFormatString string `yaml:"accesslog-format-string,omitempty"`
+
+	// AccessLogFields overrides the fields logged when the access log
+	// format is json.
+	AccessLogFields AccessLogFields `yaml:"json-fields,omitempty"`
+
+	// HTTPFilters overrides the listener filters enabled on the HTTP listener.
+	HTTPFilters *ListenerFilterParameters `yaml:"http-filters,omitempty"`
+
+	// HTTPSFilters overrides the listener filters enabled on the HTTPS listener.
+	HTTPSFilters *ListenerFilterParameters `yaml:"https-filters,omitempty"`
+}
+
+// Validate verifies that the profile values do not have any syntax errors.
+func (p ListenerProfile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("listener profile name must not be empty")
+	}
+
+	if p.AccessLogFormat != "" {
+		if err := p.AccessLogFormat.Validate(); err != nil {
+			return fmt.Errorf("listener profile %q: %v", p.Name, err)
+		}
+	}
+
+	if err := p.AccessLogFields.Validate(); err != nil {
+		return fmt.Errorf("listener profile %q: %v", p.Name, err)
+	}
+
+	if err := validateAccessLogFormatString(p.AccessLogFormatString); err != nil {
+		return fmt.Errorf("listener profile %q: %v", p.Name, err)
+	}
+
+	if p.Timeouts != nil {
+		if err := p.Timeouts.Validate(); err != nil {
+			return fmt.Errorf("listener profile %q: %v", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ACMEChallengeSolverParameters defines the Service that ACME HTTP-01
+// challenge requests are routed to.
+type ACMEChallengeSolverParameters struct {
+	// Service is the solver Service, formatted as <namespace>/<name>.
+	Service string `yaml:"service,omitempty"`
+
+	// Port is the port of the solver Service.
+	Port int `yaml:"port,omitempty"`
+}
+
+// Validate the ACME challenge solver parameters.
+func (a *ACMEChallengeSolverParameters) Validate() error {
+	if a == nil {
+		return nil
+	}
+
+	parts := strings.Split(a.Service, "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return fmt.Errorf("invalid ACME challenge solver service %q, must be formatted as <namespace>/<name>", a.Service)
+	}
+
+	if a.Port < 1 || a.Port > 65535 {
+		return fmt.Errorf("invalid ACME challenge solver port %d, must be between 1 and 65535", a.Port)
+	}
+
+	return nil
+}
+
+// TracingParameters defines how Contour exports control plane traces.
+type TracingParameters struct {
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	// Insecure disables TLS when connecting to the collector.
+	Insecure bool `yaml:"insecure,omitempty"`
+
+	// SamplingPercent is the percentage of traces to sample,
+	// from 0 to 100. Defaults to 100.
+	SamplingPercent *uint32 `yaml:"sampling-percent,omitempty"`
+
+	// ServiceName is the service name reported with each span.
+	// Defaults to "contour".
+	ServiceName string `yaml:"service-name,omitempty"`
+}
+
+// Validate the tracing parameters.
+func (t *TracingParameters) Validate() error {
+	if t == nil {
+		return nil
+	}
+
+	if len(t.Endpoint) == 0 {
+		return fmt.Errorf("invalid tracing parameters specified: endpoint required")
+	}
+
+	if t.SamplingPercent != nil && *t.SamplingPercent > 100 {
+		return fmt.Errorf("invalid tracing sampling percent %d, must be between 0 and 100", *t.SamplingPercent)
+	}
+
+	return nil
 }
 
 // RateLimitService defines properties of a global Rate Limit Service.
@@ -674,6 +1413,30 @@ type RateLimitService struct {
 	//
 	// ref. https://tools.ietf.org/id/draft-polli-ratelimit-headers-03.html
 	EnableXRateLimitHeaders bool `yaml:"enableXRateLimitHeaders,omitempty"`
+
+	// ConfigMap, if set, identifies the ConfigMap to which Contour writes
+	// the configuration of the Envoy rate limit service generated from
+	// HTTPProxy global rate limit policies, formatted as <namespace>/<name>.
+	ConfigMap string `yaml:"configMap,omitempty"`
+
+	// Stages lists the rate limit stages for which Envoy's rate
+	// limit filter is configured. Descriptors are only evaluated
+	// in a listed stage. Defaults to stage 0 only.
+	Stages []uint32 `yaml:"stages,omitempty"`
+}
+
+// MaxRateLimitStage is the highest rate limit stage supported by Envoy.
+const MaxRateLimitStage = 10
+
+// Validate the rate limit service parameters.
+func (r RateLimitService) Validate() error {
+	for _, stage := range r.Stages {
+		if stage > MaxRateLimitStage {
+			return fmt.Errorf("invalid rate limit stage %d, must be between 0 and %d", stage, MaxRateLimitStage)
+		}
+	}
+
+	return nil
 }
 
 // MetricsParameters defines configuration for metrics server endpoints in both
@@ -702,12 +1465,29 @@ type MetricsServerParameters struct {
 	// CABundle is the file path for CA certificate(s) used for validating the client certificate.
 	// Optional: required only if client certificates shall be validated to protect the metrics endpoint.
 	CABundle string `yaml:"ca-certificate-path,omitempty"`
+
+	// UseXDSCertificate serves the Envoy metrics endpoint over HTTPS using
+	// the client certificate and key Envoy uses to connect to Contour,
+	// instead of server-certificate-path and server-key-path.
+	// Only supported for Envoy metrics.
+	UseXDSCertificate bool `yaml:"use-xds-certificate,omitempty"`
+
+	// Paths is the list of Envoy admin interface path prefixes exposed
+	// by the Envoy metrics listener. Defaults to "/stats".
+	// Only supported for Envoy metrics.
+	Paths []string `yaml:"paths,omitempty"`
 }
 
 func (p *MetricsParameters) Validate() error {
 	if err := p.Contour.Validate(); err != nil {
 		return fmt.Errorf("metrics.contour: %v", err)
 	}
+	if p.Contour.UseXDSCertificate {
+		return fmt.Errorf("metrics.contour: use-xds-certificate is only supported for Envoy metrics")
+	}
+	if len(p.Contour.Paths) > 0 {
+		return fmt.Errorf("metrics.contour: paths is only supported for Envoy metrics")
+	}
 	if err := p.Envoy.Validate(); err != nil {
 		return fmt.Errorf("metrics.envoy: %v", err)
 	}
@@ -721,22 +1501,40 @@ func (p *MetricsServerParameters) Validate() error {
 		return fmt.Errorf("you must supply at least server-certificate-path and server-key-path or none of them")
 	}
 
+	if p.UseXDSCertificate && p.ServerCert != "" {
+		return fmt.Errorf("you cannot supply server-certificate-path and server-key-path if setting use-xds-certificate")
+	}
+
 	// Optional client certificate validation can be enabled if server certificate (and consequently also key) is also provided.
-	if (p.CABundle != "") && (p.ServerCert == "") {
+	if (p.CABundle != "") && (p.ServerCert == "") && !p.UseXDSCertificate {
 		return fmt.Errorf("you must supply also server-certificate-path and server-key-path if setting ca-certificate-path")
 	}
 
+	for _, path := range p.Paths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid path %q: must start with \"/\"", path)
+		}
+	}
+
 	return nil
 }
 
 // HasTLS returns true if parameters have been provided to enable TLS for metrics.
 func (p *MetricsServerParameters) HasTLS() bool {
-	return p.ServerCert != "" && p.ServerKey != ""
+	return (p.ServerCert != "" && p.ServerKey != "") || p.UseXDSCertificate
 }
 
 // Validate verifies that the parameter values do not have any syntax errors.
 func (p *Parameters) Validate() error {
-	if err := p.Cluster.DNSLookupFamily.Validate(); err != nil {
+	if err := p.KubernetesClient.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Readiness.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Cluster.Validate(); err != nil {
 		return err
 	}
 
@@ -768,6 +1566,10 @@ func (p *Parameters) Validate() error {
 		return err
 	}
 
+	if err := p.TimeoutPolicy.Validate(); err != nil {
+		return err
+	}
+
 	if err := p.Policy.Validate(); err != nil {
 		return err
 	}
@@ -778,10 +1580,45 @@ func (p *Parameters) Validate() error {
 		}
 	}
 
+	for _, prefix := range p.PermitInsecurePrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("invalid permit insecure prefix %q: must begin with a /", prefix)
+		}
+	}
+
 	if err := p.Metrics.Validate(); err != nil {
 		return err
 	}
 
+	if err := p.Tracing.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.RateLimitService.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.ACMEChallengeSolver.Validate(); err != nil {
+		return err
+	}
+
+	for _, profile := range p.ListenerProfiles {
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+	}
+
+	remoteClusters := map[string]bool{}
+	for _, rc := range p.RemoteClusters {
+		if err := rc.Validate(); err != nil {
+			return err
+		}
+		if remoteClusters[rc.Name] {
+			return fmt.Errorf("duplicate remote cluster %q", rc.Name)
+		}
+		remoteClusters[rc.Name] = true
+	}
+
 	return p.Listener.Validate()
 }
 
@@ -809,6 +1646,9 @@ func Defaults() Parameters {
 			Name:          "leader-elect",
 			Namespace:     contourNamespace,
 		},
+		Readiness: ReadinessParameters{
+			Mode: DefaultReadinessMode,
+		},
 		Timeouts: TimeoutParameters{
 			// This is chosen as a rough default to stop idle connections wasting resources,
 			// without stopping slow connections from being terminated too quickly.
@@ -78,6 +78,8 @@ leaderelection:
   retry-period: 2s
   configmap-namespace: projectcontour
   configmap-name: leader-elect
+readiness:
+  mode: default
 timeouts:
   connection-idle-timeout: 60s
 envoy-service-namespace: projectcontour
@@ -131,6 +133,41 @@ func TestValidateClusterDNSFamilyType(t *testing.T) {
 	assert.NoError(t, IPv6ClusterDNSFamily.Validate())
 }
 
+func TestValidateClusterParameters(t *testing.T) {
+	assert.NoError(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily}.Validate())
+	assert.NoError(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, EndpointDrainDelay: "5s"}.Validate())
+	assert.NoError(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, EndpointDrainDelay: "0s"}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, EndpointDrainDelay: "five seconds"}.Validate())
+
+	assert.NoError(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, HealthyPanicThreshold: 50}.Validate())
+	assert.NoError(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, HealthyPanicThreshold: 100}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, HealthyPanicThreshold: 101}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: "v5"}.Validate())
+
+	statsd := StaticClusterParameters{
+		Name:      "statsd",
+		Endpoints: []StaticClusterEndpoint{{Address: "10.0.0.1", Port: 8125}},
+	}
+	assert.NoError(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, StaticClusters: []StaticClusterParameters{statsd}}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, StaticClusters: []StaticClusterParameters{statsd, statsd}}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, StaticClusters: []StaticClusterParameters{{
+		Name:      "default/statsd",
+		Endpoints: statsd.Endpoints,
+	}}}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, StaticClusters: []StaticClusterParameters{{
+		Name: "statsd",
+	}}}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, StaticClusters: []StaticClusterParameters{{
+		Name:      "statsd",
+		Endpoints: []StaticClusterEndpoint{{Address: "10.0.0.1"}},
+	}}}.Validate())
+	assert.Error(t, ClusterParameters{DNSLookupFamily: AutoClusterDNSFamily, StaticClusters: []StaticClusterParameters{{
+		Name:      "statsd",
+		Endpoints: statsd.Endpoints,
+		Protocol:  "h2",
+	}}}.Validate())
+}
+
 func TestValidateHeadersPolicy(t *testing.T) {
 	assert.Error(t, HeadersPolicy{
 		Set: map[string]string{
@@ -162,6 +199,41 @@ func TestValidateHeadersPolicy(t *testing.T) {
 	}.Validate())
 }
 
+func TestValidatePolicyParameters(t *testing.T) {
+	assert.NoError(t, PolicyParameters{}.Validate())
+	assert.NoError(t, PolicyParameters{
+		FilterExemptPrefixes: []string{"/healthz", "/metrics"},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		FilterExemptPrefixes: []string{"healthz"},
+	}.Validate())
+	assert.NoError(t, PolicyParameters{
+		LocalRateLimit: LocalRateLimitParameters{
+			Requests:             100,
+			Unit:                 "second",
+			Burst:                20,
+			ResponseStatusCode:   503,
+			ResponseHeadersToAdd: map[string]string{"X-Rate-Limited": "true"},
+		},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		LocalRateLimit: LocalRateLimitParameters{Unit: "second"},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		LocalRateLimit: LocalRateLimitParameters{Requests: 100, Unit: "day"},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		LocalRateLimit: LocalRateLimitParameters{Requests: 100, Unit: "second", ResponseStatusCode: 200},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		LocalRateLimit: LocalRateLimitParameters{
+			Requests:             100,
+			Unit:                 "second",
+			ResponseHeadersToAdd: map[string]string{"inv@lid-header": "true"},
+		},
+	}.Validate())
+}
+
 func TestValidateNamespacedName(t *testing.T) {
 	assert.NoErrorf(t, NamespacedName{}.Validate(), "empty name should be OK")
 	assert.NoError(t, NamespacedName{Name: "name", Namespace: "ns"}.Validate())
@@ -170,6 +242,24 @@ func TestValidateNamespacedName(t *testing.T) {
 	assert.Error(t, NamespacedName{Namespace: "ns"}.Validate())
 }
 
+func TestValidateKubernetesClientParameters(t *testing.T) {
+	assert.NoError(t, KubernetesClientParameters{}.Validate())
+	assert.NoError(t, KubernetesClientParameters{QPS: 100, Burst: 200, ResyncPeriod: time.Hour}.Validate())
+	assert.NoError(t, KubernetesClientParameters{QPS: -1}.Validate())
+
+	assert.Error(t, KubernetesClientParameters{Burst: -1}.Validate())
+	assert.Error(t, KubernetesClientParameters{ResyncPeriod: -time.Second}.Validate())
+}
+
+func TestValidateReadinessParameters(t *testing.T) {
+	assert.NoError(t, ReadinessParameters{}.Validate())
+	assert.NoError(t, ReadinessParameters{Mode: DefaultReadinessMode}.Validate())
+	assert.NoError(t, ReadinessParameters{Mode: SnapshotReadinessMode, MaxWait: time.Minute}.Validate())
+
+	assert.Error(t, ReadinessParameters{Mode: "foo"}.Validate())
+	assert.Error(t, ReadinessParameters{MaxWait: -time.Second}.Validate())
+}
+
 func TestValidateServerType(t *testing.T) {
 	assert.Error(t, ServerType("").Validate())
 	assert.Error(t, ServerType("foo").Validate())
@@ -213,6 +303,10 @@ func TestValidateAccessLogFields(t *testing.T) {
 		{"invalid=%RESP%"},
 		{"invalid=%REQ_WITHOUT_QUERY%"},
 		{"@timestamp", "invalid=%START_TIME(%s.%6f):10%"},
+		{"http=%REQ(:METHOD)%", "http.method=%REQ(:METHOD)%"},
+		{"http..method=%REQ(:METHOD)%"},
+		{".method=%REQ(:METHOD)%"},
+		{"http.=%REQ(:METHOD)%"},
 	}
 
 	for _, c := range errorCases {
@@ -233,6 +327,7 @@ func TestValidateAccessLogFields(t *testing.T) {
 		{"@timestamp", "duration=my durations are %DURATION%.0 and method is %REQ(:METHOD)%"},
 		{"path=%REQ_WITHOUT_QUERY(X-ENVOY-ORIGINAL-PATH?:PATH)%"},
 		{"dog=pug", "cat=black"},
+		{"@timestamp", "http.method=%REQ(:METHOD)%", "http.path=%REQ(:PATH)%"},
 	}
 
 	for _, c := range successCases {
@@ -250,6 +345,22 @@ func TestValidateHTTPVersionType(t *testing.T) {
 	assert.NoError(t, HTTPVersion2.Validate())
 }
 
+func TestValidateListenerProfile(t *testing.T) {
+	assert.NoError(t, ListenerProfile{Name: "internal"}.Validate())
+	assert.NoError(t, ListenerProfile{
+		Name:            "internal",
+		AccessLogFormat: JSONAccessLog,
+		AccessLogFields: AccessLogFields{"@timestamp", "http.method=%REQ(:METHOD)%"},
+		Timeouts:        &TimeoutParameters{ConnectionIdleTimeout: "1h"},
+	}.Validate())
+
+	assert.Error(t, ListenerProfile{}.Validate())
+	assert.Error(t, ListenerProfile{Name: "internal", AccessLogFormat: "xml"}.Validate())
+	assert.Error(t, ListenerProfile{Name: "internal", AccessLogFields: AccessLogFields{"dog"}}.Validate())
+	assert.Error(t, ListenerProfile{Name: "internal", AccessLogFormatString: "%DOG%\n"}.Validate())
+	assert.Error(t, ListenerProfile{Name: "internal", Timeouts: &TimeoutParameters{ConnectionIdleTimeout: "a"}}.Validate())
+}
+
 func TestValidateTimeoutParams(t *testing.T) {
 	assert.NoError(t, TimeoutParameters{}.Validate())
 	assert.NoError(t, TimeoutParameters{
@@ -278,6 +389,20 @@ func TestValidateTimeoutParams(t *testing.T) {
 
 }
 
+func TestValidateTimeoutPolicyParams(t *testing.T) {
+	assert.NoError(t, TimeoutPolicyParameters{}.Validate())
+	assert.NoError(t, TimeoutPolicyParameters{
+		ConnectTimeout:     "5s",
+		MaxResponseTimeout: "1m",
+		MaxIdleTimeout:     "10m",
+	}.Validate())
+
+	assert.Error(t, TimeoutPolicyParameters{ConnectTimeout: "foo"}.Validate())
+	assert.Error(t, TimeoutPolicyParameters{ConnectTimeout: "0s"}.Validate())
+	assert.Error(t, TimeoutPolicyParameters{MaxResponseTimeout: "infinity"}.Validate())
+	assert.Error(t, TimeoutPolicyParameters{MaxIdleTimeout: "-1m"}.Validate())
+}
+
 func TestTLSParametersValidation(t *testing.T) {
 	// Fallback certificate validation
 	assert.NoError(t, TLSParameters{
@@ -438,6 +563,33 @@ listener:
   connection-balancer: notexact
 `)
 
+	check(`
+listener:
+  filter-timeout: soon
+`)
+
+	check(`
+permitInsecurePrefixes:
+- .well-known/acme-challenge/
+`)
+
+	check(`
+policy:
+  filter-exempt-prefixes:
+  - healthz
+`)
+
+	check(`
+acmeChallengeSolver:
+  service: acme-solver
+  port: 8089
+`)
+
+	check(`
+acmeChallengeSolver:
+  service: cert-manager/acme-solver
+`)
+
 }
 
 func TestConfigFileDefaultOverrideImport(t *testing.T) {
@@ -646,6 +798,40 @@ func TestMetricsParametersValidation(t *testing.T) {
 	}
 	assert.Error(t, tlsCAWithoutServerCert.Validate())
 
+	xdsCertValid := MetricsParameters{
+		Envoy: MetricsServerParameters{
+			Address:           "0.0.0.0",
+			Port:              1234,
+			CABundle:          "ca.pem",
+			UseXDSCertificate: true,
+			Paths:             []string{"/stats/prometheus"},
+		},
+	}
+	assert.NoError(t, xdsCertValid.Validate())
+	assert.True(t, xdsCertValid.Envoy.HasTLS())
+
+	xdsCertWithServerCert := MetricsParameters{
+		Envoy: MetricsServerParameters{
+			ServerCert:        "cert.pem",
+			ServerKey:         "key.pem",
+			UseXDSCertificate: true,
+		},
+	}
+	assert.Error(t, xdsCertWithServerCert.Validate())
+
+	xdsCertForContour := MetricsParameters{
+		Contour: MetricsServerParameters{
+			UseXDSCertificate: true,
+		},
+	}
+	assert.Error(t, xdsCertForContour.Validate())
+
+	invalidPath := MetricsParameters{
+		Envoy: MetricsServerParameters{
+			Paths: []string{"stats"},
+		},
+	}
+	assert.Error(t, invalidPath.Validate())
 }
 
 func TestListenerValidation(t *testing.T) {
@@ -663,4 +849,157 @@ func TestListenerValidation(t *testing.T) {
 		ConnectionBalancer: "invalid",
 	}
 	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		FilterTimeout: "30s",
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		FilterTimeout: "infinity",
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		FilterTimeout: "thirty seconds",
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		DrainType: "modify-only",
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		DrainType: "default",
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		DrainType: "never",
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		HeaderLimits: HeaderLimitsParameters{MaxRequestHeadersKB: 96, MaxRequestHeadersCount: 200, RejectAction: "reject-request"},
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		HeaderLimits: HeaderLimitsParameters{MaxRequestHeadersKB: 10000},
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		HeaderLimits: HeaderLimitsParameters{RejectAction: "drop"},
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		SocketOptions: SocketOptionsParameters{
+			TCPKeepalive: TCPKeepaliveParameters{IdleTime: 60, Interval: 10, Probes: 3},
+		},
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		SocketOptions: SocketOptionsParameters{
+			TCPKeepalive: TCPKeepaliveParameters{Disabled: true},
+		},
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		SocketOptions: SocketOptionsParameters{
+			TCPKeepalive: TCPKeepaliveParameters{Disabled: true, IdleTime: 60},
+		},
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		ProxyProtocol: ProxyProtocolParameters{
+			TLVs: []ProxyProtocolTLV{{Type: 0xEA, Key: "vpce_id"}},
+		},
+	}
+	require.NoError(t, l.Validate())
+	l = &ListenerParameters{
+		ProxyProtocol: ProxyProtocolParameters{
+			TLVs: []ProxyProtocolTLV{{Type: 256, Key: "vpce_id"}},
+		},
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		ProxyProtocol: ProxyProtocolParameters{
+			TLVs: []ProxyProtocolTLV{{Type: 0xEA}},
+		},
+	}
+	require.Error(t, l.Validate())
+	l = &ListenerParameters{
+		ProxyProtocol: ProxyProtocolParameters{
+			TLVs: []ProxyProtocolTLV{{Type: 0xEA, Key: "a"}, {Type: 0xEA, Key: "b"}},
+		},
+	}
+	require.Error(t, l.Validate())
+}
+
+func TestTracingValidation(t *testing.T) {
+	var tp *TracingParameters
+	require.NoError(t, tp.Validate())
+
+	tp = &TracingParameters{
+		Endpoint: "otel-collector:4317",
+	}
+	require.NoError(t, tp.Validate())
+
+	half, tooMany := uint32(50), uint32(101)
+
+	tp = &TracingParameters{
+		Endpoint:        "otel-collector:4317",
+		SamplingPercent: &half,
+	}
+	require.NoError(t, tp.Validate())
+
+	tp = &TracingParameters{
+		SamplingPercent: &half,
+	}
+	require.Error(t, tp.Validate())
+
+	tp = &TracingParameters{
+		Endpoint:        "otel-collector:4317",
+		SamplingPercent: &tooMany,
+	}
+	require.Error(t, tp.Validate())
+}
+
+func TestRateLimitServiceValidation(t *testing.T) {
+	require.NoError(t, RateLimitService{}.Validate())
+	require.NoError(t, RateLimitService{Stages: []uint32{0, 1, 10}}.Validate())
+	require.Error(t, RateLimitService{Stages: []uint32{0, 11}}.Validate())
+}
+
+func TestACMEChallengeSolverValidation(t *testing.T) {
+	var a *ACMEChallengeSolverParameters
+	require.NoError(t, a.Validate())
+
+	a = &ACMEChallengeSolverParameters{
+		Service: "cert-manager/acme-solver",
+		Port:    8089,
+	}
+	require.NoError(t, a.Validate())
+
+	a = &ACMEChallengeSolverParameters{
+		Service: "/acme-solver",
+		Port:    8089,
+	}
+	require.Error(t, a.Validate())
+
+	a = &ACMEChallengeSolverParameters{
+		Service: "cert-manager/acme-solver",
+		Port:    65536,
+	}
+	require.Error(t, a.Validate())
+}
+
+func TestRemoteClusterValidation(t *testing.T) {
+	assert.NoError(t, RemoteClusterParameters{Name: "west", Kubeconfig: "/remote/west/kubeconfig"}.Validate())
+
+	assert.Error(t, RemoteClusterParameters{Kubeconfig: "/remote/west/kubeconfig"}.Validate())
+	assert.Error(t, RemoteClusterParameters{Name: "west"}.Validate())
+
+	p := Defaults()
+	p.RemoteClusters = []RemoteClusterParameters{
+		{Name: "west", Kubeconfig: "/remote/west/kubeconfig"},
+		{Name: "east", Kubeconfig: "/remote/east/kubeconfig"},
+	}
+	assert.NoError(t, p.Validate())
+
+	p.RemoteClusters = append(p.RemoteClusters, RemoteClusterParameters{Name: "west", Kubeconfig: "/remote/other/kubeconfig"})
+	assert.Error(t, p.Validate())
 }
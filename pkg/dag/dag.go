@@ -0,0 +1,254 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dag exposes a stable subset of Contour's DAG builder for
+// programmatic consumers such as linters, visualizers and policy
+// checkers. It builds a DAG from typed Kubernetes objects using the
+// same processors as a running Contour, so the computed virtual hosts,
+// routes, clusters and policies match what Contour would program into
+// Envoy.
+//
+// Only the identifiers declared in this package are covered by
+// Contour's compatibility guarantees. Fields on the aliased types
+// may be added over time, but will not be removed or change meaning
+// within a major version.
+package dag
+
+import (
+	"io/ioutil"
+	"sort"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DAG is the computed graph of Contour configuration.
+type DAG = dag.DAG
+
+// Listener represents a TCP socket that accepts incoming connections.
+type Listener = dag.Listener
+
+// VirtualHost represents a named L4/L7 service.
+type VirtualHost = dag.VirtualHost
+
+// SecureVirtualHost represents a HTTP host protected by TLS.
+type SecureVirtualHost = dag.SecureVirtualHost
+
+// Route defines the properties of a route to a Cluster.
+type Route = dag.Route
+
+// Cluster holds the connection specific parameters that apply to
+// traffic routed to an upstream service.
+type Cluster = dag.Cluster
+
+// Service represents a single Kubernetes Service's port.
+type Service = dag.Service
+
+// Secret represents a Kubernetes Secret used for TLS.
+type Secret = dag.Secret
+
+// TCPProxy represents a cluster of TCP endpoints.
+type TCPProxy = dag.TCPProxy
+
+// ExtensionCluster generates an Envoy cluster for an ExtensionService.
+type ExtensionCluster = dag.ExtensionCluster
+
+// KubernetesCache holds the Kubernetes objects a DAG is built from.
+type KubernetesCache = dag.KubernetesCache
+
+// Processor constructs part of a DAG.
+type Processor = dag.Processor
+
+// ProcessorFunc adapts a function to the Processor interface.
+type ProcessorFunc = dag.ProcessorFunc
+
+// Computed policies attached to routes, virtual hosts and clusters.
+type (
+	TimeoutPolicy         = dag.TimeoutPolicy
+	RetryPolicy           = dag.RetryPolicy
+	HeadersPolicy         = dag.HeadersPolicy
+	MirrorPolicy          = dag.MirrorPolicy
+	CORSPolicy            = dag.CORSPolicy
+	RateLimitPolicy       = dag.RateLimitPolicy
+	LocalRateLimitPolicy  = dag.LocalRateLimitPolicy
+	GlobalRateLimitPolicy = dag.GlobalRateLimitPolicy
+	RequestHashPolicy     = dag.RequestHashPolicy
+	PeerValidationContext = dag.PeerValidationContext
+)
+
+// Options configures how a DAG is built. The zero value builds a
+// DAG the way a Contour with a default configuration file would.
+type Options struct {
+	// RootNamespaces restricts the namespaces root HTTPProxies
+	// may be defined in. If empty, roots may be defined in any
+	// namespace.
+	RootNamespaces []string
+
+	// DenyByDefault only builds the root HTTPProxies and Ingresses
+	// in the root namespaces, and the HTTPProxies they include.
+	DenyByDefault bool
+
+	// IngressClassName is the ingress class Contour serves.
+	// If empty, the default class is used.
+	IngressClassName string
+
+	// EnableExternalNameService allows routing to Services
+	// of type ExternalName.
+	EnableExternalNameService bool
+
+	// EnableStaticEndpoints allows routing to static endpoints
+	// rather than to Kubernetes Services.
+	EnableStaticEndpoints bool
+
+	// DisablePermitInsecure ignores the permitInsecure field
+	// of HTTPProxy routes.
+	DisablePermitInsecure bool
+
+	// FallbackCertificate is the Secret served to clients that
+	// do not send SNI, for hosts that opt into it.
+	FallbackCertificate *types.NamespacedName
+
+	// ClientCertificate is the Secret presented to upstreams
+	// that require client authentication.
+	ClientCertificate *types.NamespacedName
+
+	// GatewayAPI enables processing of Gateway API resources.
+	GatewayAPI bool
+
+	// FieldLogger receives the builder's diagnostics.
+	// If nil, diagnostics are discarded.
+	FieldLogger logrus.FieldLogger
+}
+
+// Build returns the DAG computed from objs. Objects of types Contour
+// does not understand, or that do not match opts, are ignored.
+func Build(opts Options, objs ...interface{}) *DAG {
+	log := opts.FieldLogger
+	if log == nil {
+		l := logrus.New()
+		l.SetOutput(ioutil.Discard)
+		log = l
+	}
+
+	var secretRefs []*types.NamespacedName
+	if opts.FallbackCertificate != nil {
+		secretRefs = append(secretRefs, opts.FallbackCertificate)
+	}
+	if opts.ClientCertificate != nil {
+		secretRefs = append(secretRefs, opts.ClientCertificate)
+	}
+
+	processors := []dag.Processor{
+		&dag.IngressProcessor{
+			EnableExternalNameService: opts.EnableExternalNameService,
+			FieldLogger:               log.WithField("context", "IngressProcessor"),
+			ClientCertificate:         opts.ClientCertificate,
+		},
+		&dag.ExtensionServiceProcessor{
+			FieldLogger:       log.WithField("context", "ExtensionServiceProcessor"),
+			ClientCertificate: opts.ClientCertificate,
+		},
+		&dag.HTTPProxyProcessor{
+			EnableExternalNameService: opts.EnableExternalNameService,
+			EnableStaticEndpoints:     opts.EnableStaticEndpoints,
+			DisablePermitInsecure:     opts.DisablePermitInsecure,
+			FallbackCertificate:       opts.FallbackCertificate,
+			ClientCertificate:         opts.ClientCertificate,
+		},
+	}
+	if opts.GatewayAPI {
+		processors = append(processors, &dag.GatewayAPIProcessor{
+			EnableExternalNameService: opts.EnableExternalNameService,
+			FieldLogger:               log.WithField("context", "GatewayAPIProcessor"),
+		})
+	}
+	processors = append(processors, dag.RegisteredProcessors()...)
+	processors = append(processors, &dag.ListenerProcessor{})
+
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			RootNamespaces:       opts.RootNamespaces,
+			DenyByDefault:        opts.DenyByDefault,
+			IngressClassName:     opts.IngressClassName,
+			ConfiguredSecretRefs: secretRefs,
+			FieldLogger:          log.WithField("context", "KubernetesCache"),
+		},
+		Processors: processors,
+	}
+	for _, obj := range objs {
+		builder.Source.Insert(obj)
+	}
+	return builder.Build()
+}
+
+// RegisterProcessor registers a custom Processor to run as part of
+// every DAG build, both in Contour and in Build. Processors run after
+// the Ingress, HTTPProxy and Gateway API processors, in registration
+// order; each name in after must already be registered. Registering
+// an empty, duplicate or nil processor returns an error.
+//
+// Processors should be registered before Contour starts serving,
+// typically from an init function.
+func RegisterProcessor(name string, p Processor, after ...string) error {
+	return dag.RegisterProcessor(name, p, after...)
+}
+
+// Walk calls fn for each vertex reachable from the listeners of d,
+// depth first. Vertices are one of *Listener, *VirtualHost,
+// *SecureVirtualHost, *Route, *Cluster or *Service. Children of a
+// vertex are visited in a stable order, and only if fn returns true.
+// A Cluster shared by several routes is visited once per route.
+func Walk(d *DAG, fn func(vertex interface{}) bool) {
+	for _, l := range d.Listeners {
+		if !fn(l) {
+			continue
+		}
+		for _, vh := range l.VirtualHosts {
+			if fn(vh) {
+				walkRoutes(vh.Routes, fn)
+			}
+		}
+		for _, svh := range l.SecureVirtualHosts {
+			if !fn(svh) {
+				continue
+			}
+			walkRoutes(svh.Routes, fn)
+			if svh.TCPProxy != nil {
+				walkClusters(svh.TCPProxy.Clusters, fn)
+			}
+		}
+	}
+}
+
+func walkRoutes(routes map[string]*Route, fn func(interface{}) bool) {
+	keys := make([]string, 0, len(routes))
+	for k := range routes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if r := routes[k]; fn(r) {
+			walkClusters(r.Clusters, fn)
+		}
+	}
+}
+
+func walkClusters(clusters []*Cluster, fn func(interface{}) bool) {
+	for _, c := range clusters {
+		if fn(c) && c.Upstream != nil {
+			fn(c.Upstream)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestBuildAndWalk(t *testing.T) {
+	svc := fixture.NewService("default/kuard").
+		WithPorts(v1.ServicePort{Name: "http", Port: 8080})
+
+	proxy := fixture.NewProxy("default/example").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{Prefix: "/"}},
+				Services:   []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+				TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+					Response: "5s",
+				},
+			}},
+		})
+
+	d := Build(Options{FieldLogger: fixture.NewTestLogger(t)}, svc, proxy)
+
+	var kinds []string
+	var routes []*Route
+	Walk(d, func(vertex interface{}) bool {
+		switch v := vertex.(type) {
+		case *Listener:
+			kinds = append(kinds, "listener/"+v.Name)
+		case *VirtualHost:
+			kinds = append(kinds, "vhost/"+v.Name)
+		case *SecureVirtualHost:
+			kinds = append(kinds, "svhost/"+v.Name)
+		case *Route:
+			kinds = append(kinds, "route")
+			routes = append(routes, v)
+		case *Cluster:
+			kinds = append(kinds, "cluster")
+		case *Service:
+			kinds = append(kinds, "service/"+v.Weighted.ServiceName)
+		}
+		return true
+	})
+
+	assert.Equal(t, []string{
+		"listener/ingress_http",
+		"vhost/example.com",
+		"route",
+		"cluster",
+		"service/kuard",
+	}, kinds)
+
+	if assert.Len(t, routes, 1) && assert.NotNil(t, routes[0].TimeoutPolicy) {
+		assert.Equal(t, "5s", routes[0].TimeoutPolicy.ResponseTimeout.Duration().String())
+	}
+}
+
+func TestWalkStopsDescending(t *testing.T) {
+	svc := fixture.NewService("default/kuard").
+		WithPorts(v1.ServicePort{Name: "http", Port: 8080})
+
+	proxy := fixture.NewProxy("default/example").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+			}},
+		})
+
+	d := Build(Options{}, svc, proxy)
+
+	var visited int
+	Walk(d, func(vertex interface{}) bool {
+		visited++
+		_, ok := vertex.(*Listener)
+		return !ok
+	})
+	assert.Equal(t, len(d.Listeners), visited)
+}
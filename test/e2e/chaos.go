@@ -0,0 +1,248 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KillEnvoyPods deletes every Envoy pod without a grace period,
+// simulating Envoy crashing or its node going away. The Envoy
+// DaemonSet replaces the pods; use WaitForEnvoyDaemonSetUpdated
+// to wait for them to become available.
+func (d *Deployment) KillEnvoyPods() error {
+	pods, err := d.envoyPods()
+	if err != nil {
+		return err
+	}
+
+	for i := range pods {
+		if err := d.client.Delete(context.TODO(), &pods[i], client.GracePeriodSeconds(0)); err != nil {
+			return fmt.Errorf("failed to delete Envoy pod %s: %w", pods[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// CordonEnvoyNodes marks the nodes running Envoy pods unschedulable,
+// so that Envoy pods that are killed cannot be rescheduled. The
+// returned function marks the nodes schedulable again.
+func (d *Deployment) CordonEnvoyNodes() (func() error, error) {
+	pods, err := d.envoyPods()
+	if err != nil {
+		return nil, err
+	}
+
+	var cordoned []string
+	uncordon := func() error {
+		for _, name := range cordoned {
+			if err := d.setNodeUnschedulable(name, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || contains(cordoned, pod.Spec.NodeName) {
+			continue
+		}
+		if err := d.setNodeUnschedulable(pod.Spec.NodeName, true); err != nil {
+			if uerr := uncordon(); uerr != nil {
+				return nil, fmt.Errorf("%v (and failed to uncordon nodes: %v)", err, uerr)
+			}
+			return nil, err
+		}
+		cordoned = append(cordoned, pod.Spec.NodeName)
+	}
+
+	return uncordon, nil
+}
+
+// RestartContour deletes the Contour pods and waits for their
+// replacements to become ready.
+func (d *Deployment) RestartContour() error {
+	pods, err := d.contourPods()
+	if err != nil {
+		return err
+	}
+
+	var deleted []types.UID
+	for i := range pods {
+		if err := d.client.Delete(context.TODO(), &pods[i]); err != nil {
+			return fmt.Errorf("failed to delete Contour pod %s: %w", pods[i].Name, err)
+		}
+		deleted = append(deleted, pods[i].UID)
+	}
+
+	return d.waitForContourPodsReady(deleted...)
+}
+
+// PartitionXDS cuts the xDS connection between Envoy and Contour
+// by scaling the Contour Deployment down to zero replicas. Envoy
+// keeps serving the configuration it last received. The returned
+// function heals the partition by scaling the Contour Deployment
+// back up, and waits for Contour to become ready.
+func (d *Deployment) PartitionXDS() (func() error, error) {
+	replicas, err := d.scaleContour(0)
+	if err != nil {
+		return nil, err
+	}
+
+	heal := func() error {
+		if _, err := d.scaleContour(replicas); err != nil {
+			return err
+		}
+		return d.waitForContourPodsReady()
+	}
+
+	return heal, nil
+}
+
+// WaitForRecovery polls recovered until it returns true, and returns
+// how long recovery took. It returns an error if recovered does not
+// return true within timeout.
+func WaitForRecovery(timeout time.Duration, recovered func() bool) (time.Duration, error) {
+	start := time.Now()
+	err := wait.PollImmediate(100*time.Millisecond, timeout, func() (bool, error) {
+		return recovered(), nil
+	})
+	if err != nil {
+		return time.Since(start), fmt.Errorf("did not recover within %s: %w", timeout, err)
+	}
+	return time.Since(start), nil
+}
+
+func (d *Deployment) envoyPods() ([]v1.Pod, error) {
+	pods := new(v1.PodList)
+	if err := d.client.List(context.TODO(), pods, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(d.EnvoyDaemonSet.Spec.Selector.MatchLabels),
+		Namespace:     d.EnvoyDaemonSet.Namespace,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list Envoy pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+func (d *Deployment) contourPods() ([]v1.Pod, error) {
+	pods := new(v1.PodList)
+	if err := d.client.List(context.TODO(), pods, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(d.ContourDeployment.Spec.Selector.MatchLabels),
+		Namespace:     d.ContourDeployment.Namespace,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list Contour pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// waitForContourPodsReady waits until as many Contour pods as the
+// Deployment wants are ready, not counting pods with the given UIDs
+// or pods that are being deleted.
+func (d *Deployment) waitForContourPodsReady(ignore ...types.UID) error {
+	ready := func() (bool, error) {
+		deployment := new(apps_v1.Deployment)
+		if err := d.client.Get(context.TODO(), client.ObjectKeyFromObject(d.ContourDeployment), deployment); err != nil {
+			return false, err
+		}
+
+		pods, err := d.contourPods()
+		if err != nil {
+			return false, err
+		}
+
+		readyPods := 0
+		for _, pod := range pods {
+			if pod.DeletionTimestamp != nil || containsUID(ignore, pod.UID) {
+				continue
+			}
+			for _, cond := range pod.Status.Conditions {
+				if cond.Type == v1.PodReady && cond.Status == v1.ConditionTrue {
+					readyPods++
+				}
+			}
+		}
+
+		wanted := int32(1)
+		if deployment.Spec.Replicas != nil {
+			wanted = *deployment.Spec.Replicas
+		}
+		return readyPods == int(wanted), nil
+	}
+	return wait.PollImmediate(time.Millisecond*50, time.Minute*2, ready)
+}
+
+// scaleContour sets the number of Contour replicas, and
+// returns the number of replicas before scaling.
+func (d *Deployment) scaleContour(replicas int32) (int32, error) {
+	deployment := new(apps_v1.Deployment)
+	if err := d.client.Get(context.TODO(), client.ObjectKeyFromObject(d.ContourDeployment), deployment); err != nil {
+		return 0, err
+	}
+
+	previous := int32(1)
+	if deployment.Spec.Replicas != nil {
+		previous = *deployment.Spec.Replicas
+	}
+
+	patch := client.MergeFrom(deployment.DeepCopy())
+	deployment.Spec.Replicas = &replicas
+	if err := d.client.Patch(context.TODO(), deployment, patch); err != nil {
+		return 0, fmt.Errorf("failed to scale Contour to %d replicas: %w", replicas, err)
+	}
+	return previous, nil
+}
+
+func (d *Deployment) setNodeUnschedulable(name string, unschedulable bool) error {
+	node := new(v1.Node)
+	if err := d.client.Get(context.TODO(), client.ObjectKey{Name: name}, node); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = unschedulable
+	if err := d.client.Patch(context.TODO(), node, patch); err != nil {
+		return fmt.Errorf("failed to set node %s unschedulable=%t: %w", name, unschedulable, err)
+	}
+	return nil
+}
+
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUID(uids []types.UID, uid types.UID) bool {
+	for _, u := range uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
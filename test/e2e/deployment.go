@@ -63,6 +63,9 @@ type Deployment struct {
 	localContourPort string
 	// Path to Contour binary for use when running locally.
 	contourBin string
+	// IP family of the cluster, used to choose addresses
+	// for a local Contour and Envoy to bind to.
+	ipFamily IPFamily
 
 	Namespace                 *v1.Namespace
 	ContourServiceAccount     *v1.ServiceAccount
@@ -363,13 +366,49 @@ func (d *Deployment) WaitForEnvoyDaemonSetUpdated() error {
 		if err := d.client.Get(context.TODO(), client.ObjectKeyFromObject(d.EnvoyDaemonSet), tempDS); err != nil {
 			return false, err
 		}
-		return tempDS.Status.NumberAvailable > 0 &&
+		return tempDS.Status.ObservedGeneration >= tempDS.Generation &&
+			tempDS.Status.NumberAvailable > 0 &&
 			tempDS.Status.NumberAvailable == tempDS.Status.DesiredNumberScheduled &&
 			tempDS.Status.UpdatedNumberScheduled == tempDS.Status.DesiredNumberScheduled, nil
 	}
 	return wait.PollImmediate(time.Millisecond*50, time.Minute*3, daemonSetUpdated)
 }
 
+// EnsureReleaseManifests applies the quickstart manifests of the given
+// Contour release, e.g. to upgrade through intermediate releases before
+// upgrading to the version under test.
+func (d *Deployment) EnsureReleaseManifests(version string) error {
+	url := fmt.Sprintf("https://projectcontour.io/quickstart/%s/contour.yaml", version)
+	cmd := exec.Command("kubectl", "apply", "-f", url) // nolint:gosec
+	cmd.Stdout = d.cmdOutputWriter
+	cmd.Stderr = d.cmdOutputWriter
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to apply Contour %s manifests: %w", version, err)
+	}
+	return nil
+}
+
+// WaitForContourDeploymentRolledOut waits for the latest generation of
+// the Contour Deployment to be rolled out to all replicas, whatever
+// image it uses.
+func (d *Deployment) WaitForContourDeploymentRolledOut() error {
+	rolledOut := func() (bool, error) {
+		tempD := new(apps_v1.Deployment)
+		if err := d.client.Get(context.TODO(), client.ObjectKeyFromObject(d.ContourDeployment), tempD); err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if tempD.Spec.Replicas != nil {
+			replicas = *tempD.Spec.Replicas
+		}
+		return tempD.Status.ObservedGeneration >= tempD.Generation &&
+			tempD.Status.Replicas == replicas &&
+			tempD.Status.UpdatedReplicas == replicas &&
+			tempD.Status.AvailableReplicas == replicas, nil
+	}
+	return wait.PollImmediate(time.Millisecond*50, time.Minute*3, rolledOut)
+}
+
 func (d *Deployment) EnsureRateLimitResources(namespace string, configContents string) error {
 	setNamespace := d.Namespace.Name
 	if len(namespace) > 0 {
@@ -437,6 +476,7 @@ func (d *Deployment) EnsureResourcesForLocalContour() error {
 	if err := d.EnsureContourDeploymentCRD(); err != nil {
 		return err
 	}
+	d.ipFamily.ConfigureService(d.EnvoyService)
 	if err := d.EnsureEnvoyService(); err != nil {
 		return err
 	}
@@ -597,13 +637,25 @@ func (d *Deployment) StartLocalContour(config *config.Parameters, contourConfigu
 		// Set the xds server to the defined testing port as well as enable insecure communication.
 		contourConfiguration.Spec.XDSServer = contour_api_v1alpha1.XDSServerConfig{
 			Type:    contour_api_v1alpha1.ContourServerType,
-			Address: "0.0.0.0",
+			Address: d.ipFamily.AnyAddress(),
 			Port:    port,
 			TLS: &contour_api_v1alpha1.TLS{
 				Insecure: true,
 			},
 		}
 
+		// Bind Envoy's listeners to any address of the cluster's IP family.
+		for _, addr := range []*string{
+			&contourConfiguration.Spec.Envoy.HTTPListener.Address,
+			&contourConfiguration.Spec.Envoy.HTTPSListener.Address,
+			&contourConfiguration.Spec.Envoy.Health.Address,
+			&contourConfiguration.Spec.Envoy.Metrics.Address,
+		} {
+			if *addr == "0.0.0.0" {
+				*addr = d.ipFamily.AnyAddress()
+			}
+		}
+
 		if err := d.client.Create(context.TODO(), contourConfiguration); err != nil {
 			return nil, "", fmt.Errorf("could not create ContourConfiguration: %v", err)
 		}
@@ -632,15 +684,25 @@ func (d *Deployment) StartLocalContour(config *config.Parameters, contourConfigu
 			return nil, "", err
 		}
 
-		contourServeArgs = append([]string{
+		contourServeArgs = []string{
 			"serve",
-			"--xds-address=0.0.0.0",
+			"--xds-address=" + d.ipFamily.AnyAddress(),
 			"--xds-port=" + d.localContourPort,
 			"--insecure",
 			"--kubeconfig=" + d.kubeConfig,
 			"--config-path=" + configFile.Name(),
 			"--disable-leader-election",
-		}, additionalArgs...)
+		}
+		if d.ipFamily != IPv4 {
+			// Bind Envoy's listeners to any address of the cluster's
+			// IP family, rather than the IPv4 defaults.
+			contourServeArgs = append(contourServeArgs,
+				"--envoy-service-http-address="+d.ipFamily.AnyAddress(),
+				"--envoy-service-https-address="+d.ipFamily.AnyAddress(),
+				"--stats-address="+d.ipFamily.AnyAddress(),
+			)
+		}
+		contourServeArgs = append(contourServeArgs, additionalArgs...)
 
 		configReferenceName = configFile.Name()
 	}
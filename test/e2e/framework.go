@@ -19,6 +19,7 @@ package e2e
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -77,6 +78,9 @@ type Framework struct {
 	// Kubectl provides helpers for managing kubectl port-forward helpers.
 	Kubectl *Kubectl
 
+	// IPFamily is the IP family of the cluster under test.
+	IPFamily IPFamily
+
 	t ginkgo.GinkgoTInterface
 }
 
@@ -120,24 +124,31 @@ func NewFramework(inClusterTestSuite bool) *Framework {
 	crClient, err := client.New(config, client.Options{Scheme: scheme})
 	require.NoError(t, err)
 
+	ipFamily, err := IPFamilyFromEnv()
+	require.NoError(t, err)
+
+	// The kind cluster forwards Envoy's ports to the loopback
+	// address of the cluster's IP family.
+	loopback := ipFamily.LoopbackAddress()
+
 	httpURLBase := os.Getenv("CONTOUR_E2E_HTTP_URL_BASE")
 	if httpURLBase == "" {
-		httpURLBase = "http://127.0.0.1:9080"
+		httpURLBase = "http://" + net.JoinHostPort(loopback, "9080")
 	}
 
 	httpsURLBase := os.Getenv("CONTOUR_E2E_HTTPS_URL_BASE")
 	if httpsURLBase == "" {
-		httpsURLBase = "https://127.0.0.1:9443"
+		httpsURLBase = "https://" + net.JoinHostPort(loopback, "9443")
 	}
 
 	httpURLMetricsBase := os.Getenv("CONTOUR_E2E_HTTP_URL_METRICS_BASE")
 	if httpURLMetricsBase == "" {
-		httpURLMetricsBase = "http://127.0.0.1:8002"
+		httpURLMetricsBase = "http://" + net.JoinHostPort(loopback, "8002")
 	}
 
 	httpURLAdminBase := os.Getenv("CONTOUR_E2E_HTTP_URL_ADMIN_BASE")
 	if httpURLAdminBase == "" {
-		httpURLAdminBase = "http://127.0.0.1:19001"
+		httpURLAdminBase = "http://" + net.JoinHostPort(loopback, "19001")
 	}
 
 	var (
@@ -173,6 +184,7 @@ func NewFramework(inClusterTestSuite bool) *Framework {
 		localContourHost: contourHost,
 		localContourPort: contourPort,
 		contourBin:       contourBin,
+		ipFamily:         ipFamily,
 	}
 
 	kubectl := &Kubectl{
@@ -212,6 +224,7 @@ func NewFramework(inClusterTestSuite bool) *Framework {
 		},
 		Deployment: deployment,
 		Kubectl:    kubectl,
+		IPFamily:   ipFamily,
 		t:          t,
 	}
 }
@@ -0,0 +1,100 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+type GRPCDialOpts struct {
+	// Host is the :authority of requests made on the connection.
+	Host string
+
+	// Secure dials the HTTPS listener with TLS, rather than
+	// the (insecure) HTTP listener.
+	Secure bool
+
+	TLSConfigOpts []func(*tls.Config)
+	DialOpts      []grpc.DialOption
+}
+
+// GRPCDial connects to Envoy with the provided parameters. The caller
+// is responsible for closing the returned connection.
+func (h *HTTP) GRPCDial(opts *GRPCDialOpts) (*grpc.ClientConn, error) {
+	urlBase := h.HTTPURLBase
+	if opts.Secure {
+		urlBase = h.HTTPSURLBase
+	}
+
+	addr, err := hostPort(urlBase)
+	if err != nil {
+		return nil, err
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithAuthority(opts.Host),
+	}
+
+	if opts.Secure {
+		tlsConfig := &tls.Config{
+			ServerName: opts.Host,
+			//nolint:gosec
+			InsecureSkipVerify: true,
+		}
+		for _, opt := range opts.TLSConfigOpts {
+			opt(tlsConfig)
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+
+	return grpc.Dial(addr, append(dialOpts, opts.DialOpts...)...)
+}
+
+// GRPCRequestUntil connects to Envoy with the provided parameters and
+// repeatedly calls makeRequest with the connection until it returns
+// nil or the timeout is reached. It returns whether a request
+// succeeded and, if not, the last error.
+func (h *HTTP) GRPCRequestUntil(opts *GRPCDialOpts, makeRequest func(context.Context, *grpc.ClientConn) error) (bool, error) {
+	conn, err := h.GRPCDial(opts)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var lastErr error
+	if err := wait.PollImmediate(h.RetryInterval, h.RetryTimeout, func() (bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), h.RetryInterval)
+		defer cancel()
+
+		// If there was an error, we want to keep
+		// retrying, so just return false, not an
+		// error.
+		lastErr = makeRequest(ctx, conn)
+		return lastErr == nil, nil
+	}); err != nil {
+		return false, lastErr
+	}
+
+	return true, nil
+}
@@ -18,12 +18,16 @@ package e2e
 
 import (
 	"crypto/tls"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/onsi/ginkgo"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -97,6 +101,38 @@ func (h *HTTP) RequestUntil(opts *HTTPRequestOpts) (*HTTPResponse, bool) {
 	return h.requestUntil(makeRequest, opts.Condition)
 }
 
+// HTTP2RequestUntil is like RequestUntil, but makes cleartext HTTP/2
+// requests with prior knowledge (h2c) rather than HTTP/1.1 requests.
+func (h *HTTP) HTTP2RequestUntil(opts *HTTPRequestOpts) (*HTTPResponse, bool) {
+	req, err := http.NewRequest("GET", h.HTTPURLBase+opts.Path, nil)
+	require.NoError(h.t, err, "error creating HTTP request")
+
+	req.Host = opts.Host
+	for _, opt := range opts.RequestOpts {
+		opt(req)
+	}
+
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			// Dial without TLS, the transport only uses
+			// DialTLS to create connections.
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	for _, opt := range opts.ClientOpts {
+		opt(client)
+	}
+
+	makeRequest := func() (*http.Response, error) {
+		return client.Do(req)
+	}
+
+	return h.requestUntil(makeRequest, opts.Condition)
+}
+
 func OptDontFollowRedirects(c *http.Client) {
 	// Per CheckRedirect godoc: "As a special case, if
 	// CheckRedirect returns ErrUseLastResponse, then
@@ -190,6 +226,40 @@ func (h *HTTP) SecureRequestUntil(opts *HTTPSRequestOpts) (*HTTPResponse, bool)
 	return h.requestUntil(makeRequest, opts.Condition)
 }
 
+// SecureHTTP2RequestUntil is like SecureRequestUntil, but only
+// negotiates HTTP/2 with ALPN, rather than falling back to HTTP/1.1.
+func (h *HTTP) SecureHTTP2RequestUntil(opts *HTTPSRequestOpts) (*HTTPResponse, bool) {
+	req, err := http.NewRequest("GET", h.HTTPSURLBase+opts.Path, nil)
+	require.NoError(h.t, err, "error creating HTTP request")
+
+	req.Host = opts.Host
+	for _, opt := range opts.RequestOpts {
+		opt(req)
+	}
+
+	transport := &http2.Transport{
+		TLSClientConfig: &tls.Config{
+			ServerName: opts.Host,
+			NextProtos: []string{http2.NextProtoTLS},
+			//nolint:gosec
+			InsecureSkipVerify: true,
+		},
+	}
+	for _, opt := range opts.TLSConfigOpts {
+		opt(transport.TLSClientConfig)
+	}
+
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	makeRequest := func() (*http.Response, error) {
+		return client.Do(req)
+	}
+
+	return h.requestUntil(makeRequest, opts.Condition)
+}
+
 // SecureRequest makes a single HTTPS request with the provided parameters
 // and returns the HTTP response or an error. Note that opts.Condition is
 // ignored by this method.
@@ -233,6 +303,7 @@ func (h *HTTP) SecureRequest(opts *HTTPSRequestOpts) (*HTTPResponse, error) {
 		StatusCode: r.StatusCode,
 		Headers:    r.Header,
 		Body:       bodyBytes,
+		Proto:      r.Proto,
 	}, nil
 }
 
@@ -256,6 +327,7 @@ func (h *HTTP) requestUntil(makeRequest func() (*http.Response, error), conditio
 			StatusCode: r.StatusCode,
 			Headers:    r.Header,
 			Body:       bodyBytes,
+			Proto:      r.Proto,
 		}
 
 		if condition != nil {
@@ -273,6 +345,10 @@ type HTTPResponse struct {
 	StatusCode int
 	Headers    http.Header
 	Body       []byte
+
+	// Proto is the protocol of the response,
+	// e.g. "HTTP/1.1" or "HTTP/2.0".
+	Proto string
 }
 
 // HasStatusCode returns a function that returns true
@@ -283,3 +359,25 @@ func HasStatusCode(code int) func(*http.Response) bool {
 		return res != nil && res.StatusCode == code
 	}
 }
+
+// hostPort returns the "host:port" address of the given URL base,
+// defaulting the port based on the scheme.
+func hostPort(urlBase string) (string, error) {
+	u, err := url.Parse(urlBase)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+
+	switch u.Scheme {
+	case "http":
+		return net.JoinHostPort(u.Hostname(), "80"), nil
+	case "https":
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	default:
+		return "", fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// IPFamily is the IP family of the cluster the tests run against.
+type IPFamily string
+
+const (
+	IPv4      IPFamily = "ipv4"
+	IPv6      IPFamily = "ipv6"
+	DualStack IPFamily = "dual"
+)
+
+// IPFamilyFromEnv returns the IP family set in the CONTOUR_E2E_IP_FAMILY
+// environment variable, defaulting to IPv4.
+func IPFamilyFromEnv() (IPFamily, error) {
+	switch family := IPFamily(os.Getenv("CONTOUR_E2E_IP_FAMILY")); family {
+	case "":
+		return IPv4, nil
+	case IPv4, IPv6, DualStack:
+		return family, nil
+	default:
+		return "", fmt.Errorf("invalid CONTOUR_E2E_IP_FAMILY %q, must be one of %q, %q or %q", family, IPv4, IPv6, DualStack)
+	}
+}
+
+// AnyAddress returns the address to bind to to accept connections
+// from any address of the family. Binding to "::" also accepts IPv4
+// connections, so is used for dual-stack clusters.
+func (f IPFamily) AnyAddress() string {
+	if f == IPv4 {
+		return "0.0.0.0"
+	}
+	return "::"
+}
+
+// LoopbackAddress returns the loopback address of the family,
+// preferring IPv4 for dual-stack clusters.
+func (f IPFamily) LoopbackAddress() string {
+	if f == IPv6 {
+		return "::1"
+	}
+	return "127.0.0.1"
+}
+
+// ConfigureService sets the IP families of the Service for the family.
+// IPv4 Services are left as they are, to use the cluster default.
+func (f IPFamily) ConfigureService(svc *v1.Service) {
+	singleStack := v1.IPFamilyPolicySingleStack
+	preferDualStack := v1.IPFamilyPolicyPreferDualStack
+
+	switch f {
+	case IPv6:
+		svc.Spec.IPFamilyPolicy = &singleStack
+		svc.Spec.IPFamilies = []v1.IPFamily{v1.IPv6Protocol}
+	case DualStack:
+		svc.Spec.IPFamilyPolicy = &preferDualStack
+		svc.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+	}
+}
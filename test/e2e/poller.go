@@ -18,18 +18,84 @@ package e2e
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// Categories of failed requests recorded by an AppPoller, in
+// addition to "status <code>" for responses with an unexpected
+// status code.
+const (
+	PollerErrorConnectionRefused = "connection refused"
+	PollerErrorTimeout           = "timeout"
+	PollerErrorOther             = "other"
+)
+
 type AppPoller struct {
 	cancel             context.CancelFunc
 	wg                 *sync.WaitGroup
 	totalRequests      uint
 	successfulRequests uint
+
+	// latencies holds the latency of every request
+	// that received a response, regardless of status.
+	latencies []time.Duration
+
+	// errors counts failed requests by category.
+	errors map[string]uint
+
+	// outageStart is when the current run of failed requests
+	// started, or zero if the last request succeeded.
+	outageStart time.Time
+	// longestOutage is the longest run of failed requests,
+	// from the first failure to the next success.
+	longestOutage time.Duration
+}
+
+// PollerReport summarizes the requests made by an AppPoller.
+type PollerReport struct {
+	TotalRequests      uint
+	SuccessfulRequests uint
+
+	// P50, P95 and P99 are the latency percentiles of the
+	// requests that received a response.
+	P50, P95, P99 time.Duration
+
+	// Errors counts failed requests by category, one of the
+	// PollerError constants or "status <code>".
+	Errors map[string]uint
+
+	// LongestOutage is the longest time between a failed request
+	// and the next successful one. An outage still ongoing when the
+	// poller was stopped is counted up to when it was stopped.
+	LongestOutage time.Duration
+}
+
+// String formats the report for logging.
+func (r PollerReport) String() string {
+	categories := make([]string, 0, len(r.Errors))
+	for category := range r.Errors {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	errs := make([]string, 0, len(categories))
+	for _, category := range categories {
+		errs = append(errs, fmt.Sprintf("%s: %d", category, r.Errors[category]))
+	}
+
+	return fmt.Sprintf("Total requests: %d, successful requests: %d, latency p50: %s, p95: %s, p99: %s, longest outage: %s, errors: {%s}",
+		r.TotalRequests, r.SuccessfulRequests, r.P50, r.P95, r.P99, r.LongestOutage, strings.Join(errs, ", "))
 }
 
 func StartAppPoller(address string, hostName string, expectedStatus int) (*AppPoller, error) {
@@ -38,6 +104,7 @@ func StartAppPoller(address string, hostName string, expectedStatus int) (*AppPo
 	poller := &AppPoller{
 		wg:     new(sync.WaitGroup),
 		cancel: cancel,
+		errors: map[string]uint{},
 	}
 
 	client := &http.Client{
@@ -64,12 +131,22 @@ func StartAppPoller(address string, hostName string, expectedStatus int) (*AppPo
 			}
 
 			poller.totalRequests++
+			start := time.Now()
 			res, err := client.Do(req)
 			if err != nil {
+				poller.errors[categorizeError(err)]++
+				poller.recordFailure(start)
 				return false, nil
 			}
+			poller.latencies = append(poller.latencies, time.Since(start))
+			res.Body.Close()
+
 			if res.StatusCode == expectedStatus {
 				poller.successfulRequests++
+				poller.recordSuccess(start)
+			} else {
+				poller.errors[fmt.Sprintf("status %d", res.StatusCode)]++
+				poller.recordFailure(start)
 			}
 			return false, nil
 		})
@@ -78,11 +155,89 @@ func StartAppPoller(address string, hostName string, expectedStatus int) (*AppPo
 	return poller, nil
 }
 
+// categorizeError returns the PollerError category of err.
+func categorizeError(err error) string {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return PollerErrorConnectionRefused
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return PollerErrorTimeout
+	}
+
+	return PollerErrorOther
+}
+
+// recordFailure starts an outage at t, unless one is already ongoing.
+func (p *AppPoller) recordFailure(t time.Time) {
+	if p.outageStart.IsZero() {
+		p.outageStart = t
+	}
+}
+
+// recordSuccess ends the ongoing outage, if any, at t.
+func (p *AppPoller) recordSuccess(t time.Time) {
+	if p.outageStart.IsZero() {
+		return
+	}
+	if outage := t.Sub(p.outageStart); outage > p.longestOutage {
+		p.longestOutage = outage
+	}
+	p.outageStart = time.Time{}
+}
+
 func (p *AppPoller) Stop() {
 	p.cancel()
 	p.wg.Wait()
+	p.recordSuccess(time.Now())
 }
 
+// Results returns the number of requests made, and the number of
+// those that received the expected status. It must be called after
+// Stop.
 func (p *AppPoller) Results() (uint, uint) {
 	return p.totalRequests, p.successfulRequests
 }
+
+// Latency returns the latency percentile, between 0 and 100, of the
+// requests that received a response, or zero if there were none.
+// It must be called after Stop.
+func (p *AppPoller) Latency(percentile float64) time.Duration {
+	if len(p.latencies) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(p.latencies))
+	copy(sorted, p.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	// Use the nearest-rank method.
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Report summarizes the requests made by the poller.
+// It must be called after Stop.
+func (p *AppPoller) Report() PollerReport {
+	errs := make(map[string]uint, len(p.errors))
+	for category, n := range p.errors {
+		errs[category] = n
+	}
+
+	return PollerReport{
+		TotalRequests:      p.totalRequests,
+		SuccessfulRequests: p.successfulRequests,
+		P50:                p.Latency(50),
+		P95:                p.Latency(95),
+		P99:                p.Latency(99),
+		Errors:             errs,
+		LongestOutage:      p.longestOutage,
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/projectcontour/contour/test/e2e"
+	"github.com/stretchr/testify/require"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("disrupting Contour and Envoy", func() {
+	const appHost = "chaos-echo.test.com"
+
+	f.NamespacedTest("contour-chaos-test", func(namespace string) {
+		BeforeEach(func() {
+			By("deploying an app")
+			f.Fixtures.Echo.DeployN(namespace, "echo", 2)
+			require.NoError(f.T(), f.Client.Create(context.TODO(), echoIngress(namespace, appHost)))
+
+			By("ensuring it is routable")
+			checkRoutability(appHost)
+		})
+
+		Specify("applications remain routable while Envoy is partitioned from Contour", func() {
+			poller, err := e2e.StartAppPoller(f.HTTP.HTTPURLBase, appHost, http.StatusOK)
+			require.NoError(f.T(), err)
+
+			By("partitioning Envoy from Contour")
+			heal, err := f.Deployment.PartitionXDS()
+			require.NoError(f.T(), err)
+
+			// Give Envoy time to notice the xDS connection is gone.
+			time.Sleep(10 * time.Second)
+			checkRoutability(appHost)
+
+			By("healing the partition")
+			require.NoError(f.T(), heal())
+			checkRoutability(appHost)
+
+			poller.Stop()
+			report := poller.Report()
+			fmt.Fprintln(GinkgoWriter, report)
+			require.Lessf(f.T(), int64(report.LongestOutage), int64(time.Second), "longest outage of %s while partitioned is more than 1s", report.LongestOutage)
+		})
+
+		Specify("applications recover after Contour is restarted", func() {
+			poller, err := e2e.StartAppPoller(f.HTTP.HTTPURLBase, appHost, http.StatusOK)
+			require.NoError(f.T(), err)
+
+			By("restarting Contour")
+			require.NoError(f.T(), f.Deployment.RestartContour())
+			checkRoutability(appHost)
+
+			poller.Stop()
+			report := poller.Report()
+			fmt.Fprintln(GinkgoWriter, report)
+			require.Lessf(f.T(), int64(report.LongestOutage), int64(time.Second), "longest outage of %s during Contour restart is more than 1s", report.LongestOutage)
+		})
+
+		Specify("applications recover after Envoy pods are killed", func() {
+			poller, err := e2e.StartAppPoller(f.HTTP.HTTPURLBase, appHost, http.StatusOK)
+			require.NoError(f.T(), err)
+
+			By("killing Envoy pods")
+			require.NoError(f.T(), f.Deployment.KillEnvoyPods())
+
+			By("waiting for the app to be routable again")
+			recovery, err := e2e.WaitForRecovery(time.Minute, func() bool {
+				res, ok := f.HTTP.RequestUntil(&e2e.HTTPRequestOpts{
+					Host:      appHost,
+					Path:      "/echo",
+					Condition: e2e.HasStatusCode(200),
+				})
+				return res != nil && ok
+			})
+			require.NoError(f.T(), err)
+			fmt.Fprintf(GinkgoWriter, "recovered after %s\n", recovery)
+			require.NoError(f.T(), f.Deployment.WaitForEnvoyDaemonSetUpdated())
+
+			poller.Stop()
+			report := poller.Report()
+			fmt.Fprintln(GinkgoWriter, report)
+			require.Lessf(f.T(), int64(report.LongestOutage), int64(30*time.Second), "longest outage of %s after killing Envoy is more than 30s", report.LongestOutage)
+		})
+	})
+})
+
+func echoIngress(namespace, host string) *networking_v1.Ingress {
+	return &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "echo",
+		},
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networking_v1.IngressRuleValue{
+						HTTP: &networking_v1.HTTPIngressRuleValue{
+							Paths: []networking_v1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: ingressPathTypePtr(networking_v1.PathTypePrefix),
+									Backend: networking_v1.IngressBackend{
+										Service: &networking_v1.IngressServiceBackend{
+											Name: "echo",
+											Port: networking_v1.ServiceBackendPort{Number: 80},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
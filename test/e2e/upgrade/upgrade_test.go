@@ -21,9 +21,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	contourv1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/test/e2e"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
@@ -40,8 +44,11 @@ var (
 	// the cluster prior to running this test suite.
 	contourUpgradeToImage string
 
-	// Contour version we are upgrading from.
-	contourUpgradeFromVersion string
+	// Contour versions we are upgrading from, oldest first. The
+	// first is the version initially installed, any others are
+	// upgraded through in order before upgrading to
+	// contourUpgradeToImage.
+	contourUpgradeFromVersions []string
 )
 
 func TestUpgrade(t *testing.T) {
@@ -49,9 +56,15 @@ func TestUpgrade(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	contourUpgradeFromVersion = os.Getenv("CONTOUR_UPGRADE_FROM_VERSION")
+	contourUpgradeFromVersion := os.Getenv("CONTOUR_UPGRADE_FROM_VERSION")
 	require.NotEmpty(f.T(), contourUpgradeFromVersion, "CONTOUR_UPGRADE_FROM_VERSION environment variable not supplied")
-	By("Testing Contour upgrade from " + contourUpgradeFromVersion)
+	for _, version := range strings.Split(contourUpgradeFromVersion, ",") {
+		if version = strings.TrimSpace(version); version != "" {
+			contourUpgradeFromVersions = append(contourUpgradeFromVersions, version)
+		}
+	}
+	require.NotEmpty(f.T(), contourUpgradeFromVersions, "CONTOUR_UPGRADE_FROM_VERSION environment variable has no versions")
+	By("Testing Contour upgrade from " + strings.Join(contourUpgradeFromVersions, " to "))
 
 	contourUpgradeToImage = os.Getenv("CONTOUR_UPGRADE_TO_IMAGE")
 	require.NotEmpty(f.T(), contourUpgradeToImage, "CONTOUR_UPGRADE_TO_IMAGE environment variable not supplied")
@@ -66,72 +79,116 @@ var _ = BeforeSuite(func() {
 	f.HTTP.HTTPSURLBase = "https://" + f.Deployment.EnvoyService.Status.LoadBalancer.Ingress[0].IP
 })
 
-var _ = Describe("upgrading Contour", func() {
-	const appHost = "upgrade-echo.test.com"
+const (
+	appHost   = "upgrade-echo.test.com"
+	proxyHost = "upgrade-echo-proxy.test.com"
+)
 
+var _ = Describe("upgrading Contour", func() {
 	f.NamespacedTest("contour-upgrade-test", func(namespace string) {
-		Specify("applications remain routable after the upgrade", func() {
+		Specify("applications remain routable after each upgrade", func() {
 			By("deploying an app")
 			f.Fixtures.Echo.DeployN(namespace, "echo", 2)
-			i := &networking_v1.Ingress{
-				ObjectMeta: metav1.ObjectMeta{
-					Namespace: namespace,
-					Name:      "echo",
-				},
-				Spec: networking_v1.IngressSpec{
-					Rules: []networking_v1.IngressRule{
-						{
-							Host: appHost,
-							IngressRuleValue: networking_v1.IngressRuleValue{
-								HTTP: &networking_v1.HTTPIngressRuleValue{
-									Paths: []networking_v1.HTTPIngressPath{
-										{
-											Path:     "/",
-											PathType: ingressPathTypePtr(networking_v1.PathTypePrefix),
-											Backend: networking_v1.IngressBackend{
-												Service: &networking_v1.IngressServiceBackend{
-													Name: "echo",
-													Port: networking_v1.ServiceBackendPort{Number: 80},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			}
+			i := echoIngress(namespace, appHost)
 			require.NoError(f.T(), f.Client.Create(context.TODO(), i))
 
+			p := echoHTTPProxy(namespace, proxyHost)
+			require.NoError(f.T(), f.Client.Create(context.TODO(), p))
+
 			By("ensuring it is routable")
 			checkRoutability(appHost)
+			checkHTTPProxyValid(p)
+			checkRoutability(proxyHost)
 
-			poller, err := e2e.StartAppPoller(f.HTTP.HTTPURLBase, appHost, http.StatusOK)
-			require.NoError(f.T(), err)
+			// Upgrade through each intermediate release in turn.
+			for _, version := range contourUpgradeFromVersions[1:] {
+				upgradeHop(version, func() {
+					By("applying Contour " + version + " manifests")
+					require.NoError(f.T(), f.Deployment.EnsureReleaseManifests(version))
 
-			updateContourDeploymentResources()
+					By("waiting for contour deployment to be updated")
+					require.NoError(f.T(), f.Deployment.WaitForContourDeploymentRolledOut())
 
-			By("waiting for contour deployment to be updated")
-			require.NoError(f.T(), f.Deployment.WaitForContourDeploymentUpdated())
+					By("waiting for envoy daemonset to be updated")
+					require.NoError(f.T(), f.Deployment.WaitForEnvoyDaemonSetUpdated())
+				}, p)
+			}
 
-			By("waiting for envoy daemonset to be updated")
-			require.NoError(f.T(), f.Deployment.WaitForEnvoyDaemonSetOutOfDate())
-			require.NoError(f.T(), f.Deployment.WaitForEnvoyDaemonSetUpdated())
+			// Finally, upgrade to the version under test.
+			upgradeHop(contourUpgradeToImage, func() {
+				updateContourDeploymentResources()
 
-			By("ensuring app is still routable")
-			checkRoutability(appHost)
+				By("waiting for contour deployment to be updated")
+				require.NoError(f.T(), f.Deployment.WaitForContourDeploymentUpdated())
 
-			poller.Stop()
-			totalRequests, successfulRequests := poller.Results()
-			fmt.Fprintf(GinkgoWriter, "Total requests: %d, successful requests: %d\n", totalRequests, successfulRequests)
-			require.Greater(f.T(), totalRequests, uint(0))
-			successPercentage := 100 * float64(successfulRequests) / float64(totalRequests)
-			require.Greaterf(f.T(), successPercentage, float64(90.0), "success rate of %.2f%% less than 90%", successPercentage)
+				By("waiting for envoy daemonset to be updated")
+				require.NoError(f.T(), f.Deployment.WaitForEnvoyDaemonSetOutOfDate())
+				require.NoError(f.T(), f.Deployment.WaitForEnvoyDaemonSetUpdated())
+			}, p)
 		})
 	})
 })
 
+// upgradeHop runs upgrade while polling the app, then checks that the
+// app is still routable, that the HTTPProxy is still valid after any
+// CRD or configuration migrations, and that the upgrade did not
+// disrupt traffic.
+func upgradeHop(to string, upgrade func(), p *contourv1.HTTPProxy) {
+	By("upgrading to " + to)
+
+	poller, err := e2e.StartAppPoller(f.HTTP.HTTPURLBase, appHost, http.StatusOK)
+	require.NoError(f.T(), err)
+
+	upgrade()
+
+	By("ensuring app is still routable")
+	checkRoutability(appHost)
+	checkHTTPProxyValid(p)
+	checkRoutability(proxyHost)
+
+	poller.Stop()
+	report := poller.Report()
+	fmt.Fprintf(GinkgoWriter, "upgrade to %s: %s\n", to, report)
+	require.Greater(f.T(), report.TotalRequests, uint(0))
+	successPercentage := 100 * float64(report.SuccessfulRequests) / float64(report.TotalRequests)
+	require.Greaterf(f.T(), successPercentage, float64(90.0), "success rate of %.2f%% during upgrade to %s less than 90%", successPercentage, to)
+	require.Lessf(f.T(), int64(report.P95), int64(50*time.Millisecond), "p95 latency of %s during upgrade to %s is more than 50ms", report.P95, to)
+}
+
+func echoHTTPProxy(namespace, host string) *contourv1.HTTPProxy {
+	return &contourv1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "echo",
+		},
+		Spec: contourv1.HTTPProxySpec{
+			VirtualHost: &contourv1.VirtualHost{
+				Fqdn: host,
+			},
+			Routes: []contourv1.Route{
+				{
+					Services: []contourv1.Service{
+						{
+							Name: "echo",
+							Port: 80,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func checkHTTPProxyValid(p *contourv1.HTTPProxy) {
+	require.Eventually(f.T(), func() bool {
+		proxy := new(contourv1.HTTPProxy)
+		if err := f.Client.Get(context.TODO(), client.ObjectKeyFromObject(p), proxy); err != nil {
+			return false
+		}
+		return proxy.Status.CurrentStatus == string(status.ProxyStatusValid)
+	}, time.Minute, time.Second, "HTTPProxy %s/%s never became valid", p.Namespace, p.Name)
+}
+
 func ingressPathTypePtr(t networking_v1.PathType) *networking_v1.PathType {
 	return &t
 }
@@ -0,0 +1,115 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/websocket"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+type WebSocketOpts struct {
+	Path string
+	Host string
+
+	// Secure connects to the HTTPS listener with TLS ("wss"),
+	// rather than the (insecure) HTTP listener ("ws").
+	Secure bool
+
+	// Header holds additional headers to send with
+	// the opening handshake.
+	Header        http.Header
+	TLSConfigOpts []func(*tls.Config)
+}
+
+// WebSocketDial makes a WebSocket opening handshake with the provided
+// parameters and returns the connection. The caller is responsible for
+// closing the returned connection.
+func (h *HTTP) WebSocketDial(opts *WebSocketOpts) (*websocket.Conn, error) {
+	urlBase, scheme := h.HTTPURLBase, "ws"
+	if opts.Secure {
+		urlBase, scheme = h.HTTPSURLBase, "wss"
+	}
+
+	addr, err := hostPort(urlBase)
+	if err != nil {
+		return nil, err
+	}
+
+	// The location's host is sent as the Host header,
+	// the connection is made to Envoy's address.
+	location := &url.URL{Scheme: scheme, Host: opts.Host, Path: opts.Path}
+	origin := &url.URL{Scheme: "http", Host: opts.Host}
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	config.Header = opts.Header
+
+	var conn net.Conn
+	if opts.Secure {
+		tlsConfig := &tls.Config{
+			ServerName: opts.Host,
+			//nolint:gosec
+			InsecureSkipVerify: true,
+		}
+		for _, opt := range opts.TLSConfigOpts {
+			opt(tlsConfig)
+		}
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// WebSocketDialUntil repeatedly makes WebSocket opening handshakes
+// with the provided parameters until one succeeds or the timeout is
+// reached. It returns the connection, if any, and whether the
+// handshake succeeded.
+func (h *HTTP) WebSocketDialUntil(opts *WebSocketOpts) (*websocket.Conn, bool) {
+	var ws *websocket.Conn
+
+	if err := wait.PollImmediate(h.RetryInterval, h.RetryTimeout, func() (bool, error) {
+		conn, err := h.WebSocketDial(opts)
+		if err != nil {
+			// if there was an error, we want to keep
+			// retrying, so just return false, not an
+			// error.
+			return false, nil
+		}
+		ws = conn
+		return true, nil
+	}); err != nil {
+		return nil, false
+	}
+
+	return ws, true
+}
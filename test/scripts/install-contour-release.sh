@@ -15,6 +15,7 @@
 # under the License.
 
 # install-contour-release.sh: Install a specific release of Contour.
+# If given a comma separated list of releases, the first is installed.
 
 set -o pipefail
 set -o errexit
@@ -24,7 +25,8 @@ readonly KUBECTL=${KUBECTL:-kubectl}
 readonly WAITTIME=${WAITTIME:-5m}
 
 readonly PROGNAME=$(basename "$0")
-readonly VERS=${1:-}
+readonly VERSIONS=${1:-}
+readonly VERS=${VERSIONS%%,*}
 
 if [ -z "$VERS" ] ; then
         printf "Usage: %s VERSION\n" $PROGNAME
@@ -25,6 +25,7 @@ readonly KIND=${KIND:-kind}
 readonly KUBECTL=${KUBECTL:-kubectl}
 
 readonly MULTINODE_CLUSTER=${MULTINODE_CLUSTER:-"false"}
+readonly IP_FAMILY=${CONTOUR_E2E_IP_FAMILY:-"ipv4"}
 readonly NODEIMAGE=${NODEIMAGE:-"docker.io/kindest/node:v1.22.0"}
 readonly CLUSTERNAME=${CLUSTERNAME:-contour-e2e}
 readonly WAITTIME=${WAITTIME:-5m}
@@ -38,6 +39,9 @@ kind::cluster::exists() {
 
 kind::cluster::create() {
     local config_file="${REPO}/test/scripts/kind-expose-port.yaml"
+    if [[ "${IP_FAMILY}" != "ipv4" ]]; then
+        config_file="${REPO}/test/scripts/kind-expose-port-${IP_FAMILY}.yaml"
+    fi
     if [[ "${MULTINODE_CLUSTER}" == "true" ]]; then
         config_file="${REPO}/test/scripts/kind-multinode.yaml"
     fi